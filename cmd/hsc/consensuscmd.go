@@ -25,6 +25,10 @@ func consensuscmd() {
 	if err != nil {
 		die("Could not get current consensus state:", err)
 	}
+	if jsonOutput {
+		printJSON(cg)
+		return
+	}
 	if cg.Synced {
 		fmt.Printf(`Synced: %v
 Block:      %v
@@ -9,6 +9,15 @@ import (
 )
 
 var (
+	reloadCmd = &cobra.Command{
+		Use:   "reload",
+		Short: "Reload the config file of the Hyperspace daemon",
+		Long: `Reload the config file of the Hyperspace daemon. The settings that can be
+changed in place are applied right away, the others are listed and take effect
+after a restart.`,
+		Run: wrap(reloadcmd),
+	}
+
 	stopCmd = &cobra.Command{
 		Use:   "stop",
 		Short: "Stop the Hyperspace daemon",
@@ -40,6 +49,14 @@ var (
 
 // version prints the version of hsc and hsd.
 func versioncmd() {
+	if jsonOutput {
+		dvg, err := httpClient.DaemonVersionGet()
+		if err != nil {
+			die("Could not get daemon version:", err)
+		}
+		printJSON(dvg)
+		return
+	}
 	fmt.Println("Hyperspace Client")
 	fmt.Println("\tVersion " + build.Version)
 	if build.GitRevision != "" {
@@ -59,6 +76,33 @@ func versioncmd() {
 	}
 }
 
+// reloadcmd is the handler for the command `hsc reload`.
+// Reloads the config file of the daemon.
+func reloadcmd() {
+	drp, err := httpClient.DaemonReloadPost()
+	if err != nil {
+		die("Could not reload config file:", err)
+	}
+	if jsonOutput {
+		printJSON(drp)
+		return
+	}
+	if len(drp.Applied) == 0 {
+		fmt.Println("No settings were applied.")
+	} else {
+		fmt.Println("Applied settings:")
+		for _, key := range drp.Applied {
+			fmt.Println("\t" + key)
+		}
+	}
+	if len(drp.RestartRequired) > 0 {
+		fmt.Println("Settings that require a restart:")
+		for _, key := range drp.RestartRequired {
+			fmt.Println("\t" + key)
+		}
+	}
+}
+
 // stopcmd is the handler for the command `hsc stop`.
 // Stops the daemon.
 func stopcmd() {
@@ -66,23 +110,31 @@ func stopcmd() {
 	if err != nil {
 		die("Could not stop daemon:", err)
 	}
-	fmt.Println("Hyperspace daemon stopped.")
+	if !jsonOutput {
+		fmt.Println("Hyperspace daemon stopped.")
+	}
 }
 
 func updatecmd() {
 	update, err := httpClient.DaemonUpdateGet()
 	if err != nil {
-		fmt.Println("Could not check for update:", err)
-		return
+		die("Could not check for update:", err)
 	}
 	if !update.Available {
-		fmt.Println("Already up to date.")
+		if jsonOutput {
+			printJSON(update)
+		} else {
+			fmt.Println("Already up to date.")
+		}
 		return
 	}
 
 	err = httpClient.DaemonUpdatePost()
 	if err != nil {
-		fmt.Println("Could not apply update:", err)
+		die("Could not apply update:", err)
+	}
+	if jsonOutput {
+		printJSON(update)
 		return
 	}
 	fmt.Printf("Updated to version %s! Restart hsd now.\n", update.Version)
@@ -91,7 +143,10 @@ func updatecmd() {
 func updatecheckcmd() {
 	update, err := httpClient.DaemonUpdateGet()
 	if err != nil {
-		fmt.Println("Could not check for update:", err)
+		die("Could not check for update:", err)
+	}
+	if jsonOutput {
+		printJSON(update)
 		return
 	}
 	if update.Available {
@@ -47,5 +47,7 @@ func renterexportcontracttxnscmd(destination string) {
 	if err != nil {
 		die("Could not export to file:", err)
 	}
-	fmt.Println("Exported contract data to", destination)
+	if !jsonOutput {
+		fmt.Println("Exported contract data to", destination)
+	}
 }
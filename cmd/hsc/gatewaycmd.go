@@ -54,7 +54,9 @@ func gatewayconnectcmd(addr string) {
 	if err != nil {
 		die("Could not add peer:", err)
 	}
-	fmt.Println("Added", addr, "to peer list.")
+	if !jsonOutput {
+		fmt.Println("Added", addr, "to peer list.")
+	}
 }
 
 // gatewaydisconnectcmd is the handler for the command `hsc gateway remove [address]`.
@@ -64,7 +66,9 @@ func gatewaydisconnectcmd(addr string) {
 	if err != nil {
 		die("Could not remove peer:", err)
 	}
-	fmt.Println("Removed", addr, "from peer list.")
+	if !jsonOutput {
+		fmt.Println("Removed", addr, "from peer list.")
+	}
 }
 
 // gatewayaddresscmd is the handler for the command `hsc gateway address`.
@@ -74,6 +78,10 @@ func gatewayaddresscmd() {
 	if err != nil {
 		die("Could not get gateway address:", err)
 	}
+	if jsonOutput {
+		printJSON(info)
+		return
+	}
 	fmt.Println("Address:", info.NetAddress)
 }
 
@@ -84,6 +92,10 @@ func gatewaycmd() {
 	if err != nil {
 		die("Could not get gateway address:", err)
 	}
+	if jsonOutput {
+		printJSON(info)
+		return
+	}
 	fmt.Println("Address:", info.NetAddress)
 	fmt.Println("Active peers:", len(info.Peers))
 }
@@ -95,6 +107,10 @@ func gatewaylistcmd() {
 	if err != nil {
 		die("Could not get peer list:", err)
 	}
+	if jsonOutput {
+		printJSON(info)
+		return
+	}
 	if len(info.Peers) == 0 {
 		fmt.Println("No peers to show.")
 		return
@@ -143,6 +143,13 @@ func hostcmd() {
 	if err != nil {
 		die("Could not fetch storage info:", err)
 	}
+	if jsonOutput {
+		printJSON(map[string]interface{}{
+			"host":    hg,
+			"storage": sg,
+		})
+		return
+	}
 
 	es := hg.ExternalSettings
 	fm := hg.FinancialMetrics
@@ -382,7 +389,9 @@ func hostconfigcmd(param, value string) {
 	if err != nil {
 		die("Failed to update host settings:", err)
 	}
-	fmt.Println("Host settings updated.")
+	if !jsonOutput {
+		fmt.Println("Host settings updated.")
+	}
 
 	// get the estimated conversion rate.
 	eg, err := httpClient.HostEstimateScoreGet(param, value)
@@ -393,6 +402,10 @@ func hostconfigcmd(param, value string) {
 		}
 		die("could not get host score estimate:", err)
 	}
+	if jsonOutput {
+		printJSON(eg)
+		return
+	}
 	fmt.Printf("Estimated conversion rate: %v%%\n", eg.ConversionRate)
 }
 
@@ -402,6 +415,10 @@ func hostcontractcmd() {
 	if err != nil {
 		die("Could not fetch host contract info:", err)
 	}
+	if jsonOutput {
+		printJSON(cg)
+		return
+	}
 	sort.Slice(cg.Contracts, func(i, j int) bool { return cg.Contracts[i].ExpirationHeight < cg.Contracts[j].ExpirationHeight })
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
 	switch hostContractOutputType {
@@ -440,16 +457,20 @@ func hostannouncecmd(cmd *cobra.Command, args []string) {
 	if err != nil {
 		die("Could not announce host:", err)
 	}
-	fmt.Println("Host announcement submitted to network.")
+	if !jsonOutput {
+		fmt.Println("Host announcement submitted to network.")
+	}
 
 	// start accepting contracts
 	err = httpClient.HostModifySettingPost(client.HostParamAcceptingContracts, true)
 	if err != nil {
 		die("Could not configure host to accept contracts:", err)
 	}
-	fmt.Println(`The host has also been configured to accept contracts.
+	if !jsonOutput {
+		fmt.Println(`The host has also been configured to accept contracts.
 To revert this, run:
 	hsc host config acceptingcontracts false`)
+	}
 }
 
 // hostfolderaddcmd adds a folder to the host.
@@ -468,7 +489,9 @@ func hostfolderaddcmd(path, size string) {
 	if err != nil {
 		die("Could not add folder:", err)
 	}
-	fmt.Println("Added folder", path)
+	if !jsonOutput {
+		fmt.Println("Added folder", path)
+	}
 }
 
 // hostfolderremovecmd removes a folder from the host.
@@ -477,7 +500,9 @@ func hostfolderremovecmd(path string) {
 	if err != nil {
 		die("Could not remove folder:", err)
 	}
-	fmt.Println("Removed folder", path)
+	if !jsonOutput {
+		fmt.Println("Removed folder", path)
+	}
 }
 
 // hostfolderresizecmd resizes a folder in the host.
@@ -496,7 +521,9 @@ func hostfolderresizecmd(path, newsize string) {
 	if err != nil {
 		die("Could not resize folder:", err)
 	}
-	fmt.Printf("Resized folder %v to %v\n", path, newsize)
+	if !jsonOutput {
+		fmt.Printf("Resized folder %v to %v\n", path, newsize)
+	}
 }
 
 // hostsectordeletecmd deletes a sector from the host.
@@ -510,5 +537,7 @@ func hostsectordeletecmd(root string) {
 	if err != nil {
 		die("Could not delete sector:", err)
 	}
-	fmt.Println("Deleted sector", root)
+	if !jsonOutput {
+		fmt.Println("Deleted sector", root)
+	}
 }
@@ -41,10 +41,13 @@ func printScoreBreakdown(info *api.HostdbHostsGET) {
 	fmt.Println("\n  Score Breakdown:")
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	fmt.Fprintf(w, "\t\tAge:\t %.3f\n", info.ScoreBreakdown.AgeAdjustment)
+	fmt.Fprintf(w, "\t\tBandwidth:\t %.3f\n", info.ScoreBreakdown.BandwidthAdjustment)
 	fmt.Fprintf(w, "\t\tBurn:\t %.3f\n", info.ScoreBreakdown.BurnAdjustment)
 	fmt.Fprintf(w, "\t\tCollateral:\t %.3f\n", info.ScoreBreakdown.CollateralAdjustment)
 	fmt.Fprintf(w, "\t\tInteraction:\t %.3f\n", info.ScoreBreakdown.InteractionAdjustment)
+	fmt.Fprintf(w, "\t\tMissed Proofs:\t %.3f (%v)\n", info.ScoreBreakdown.MissedProofAdjustment, info.ScoreBreakdown.MissedProofs)
 	fmt.Fprintf(w, "\t\tPrice:\t %.3f\n", info.ScoreBreakdown.PriceAdjustment*1e6)
+	fmt.Fprintf(w, "\t\tReputation:\t %.3f\n", info.ScoreBreakdown.ReputationAdjustment)
 	fmt.Fprintf(w, "\t\tStorage:\t %.3f\n", info.ScoreBreakdown.StorageRemainingAdjustment)
 	fmt.Fprintf(w, "\t\tUptime:\t %.3f\n", info.ScoreBreakdown.UptimeAdjustment)
 	fmt.Fprintf(w, "\t\tVersion:\t %.3f\n", info.ScoreBreakdown.VersionAdjustment)
@@ -57,7 +60,7 @@ func hostdbcmd() {
 		if err != nil {
 			die("Could not fetch host list:", err)
 		}
-		if len(info.Hosts) == 0 {
+		if len(info.Hosts) == 0 && !jsonOutput {
 			fmt.Println("No known active hosts")
 			return
 		}
@@ -67,6 +70,11 @@ func hostdbcmd() {
 			info.Hosts = info.Hosts[len(info.Hosts)-hostdbNumHosts:]
 		}
 
+		if jsonOutput {
+			printJSON(info)
+			return
+		}
+
 		fmt.Println(len(info.Hosts), "Active Hosts:")
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 		fmt.Fprintln(w, "\t\tAddress\tPrice (per TB per Mo)")
@@ -80,6 +88,10 @@ func hostdbcmd() {
 		if err != nil {
 			die("Could not fetch host list:", err)
 		}
+		if jsonOutput {
+			printJSON(info)
+			return
+		}
 		if len(info.Hosts) == 0 {
 			fmt.Println("No known hosts")
 			return
@@ -280,6 +292,10 @@ func hostdbviewcmd(pubkey string) {
 	if err != nil {
 		die("Could not fetch provided host:", err)
 	}
+	if jsonOutput {
+		printJSON(info)
+		return
+	}
 
 	fmt.Println("Host information:")
 
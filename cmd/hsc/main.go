@@ -1,13 +1,16 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/node/api"
 	"github.com/HyperspaceApp/Hyperspace/node/api/client"
 )
 
@@ -17,6 +20,7 @@ var (
 	hostVerbose            bool   // display additional host info
 	initForce              bool   // destroy and re-encrypt the wallet on init if it already exists
 	initPassword           bool   // supply a custom password when creating a wallet
+	jsonOutput             bool   // print the raw API responses instead of formatted output
 	renterAllContracts     bool   // Show all active and expired contracts
 	renterDownloadAsync    bool   // Downloads files asynchronously
 	renterListVerbose      bool   // Show additional info about uploaded files.
@@ -67,12 +71,30 @@ func wrap(fn interface{}) func(*cobra.Command, []string) {
 }
 
 // die prints its arguments to stderr, then exits the program with the default
-// error code.
+// error code. In JSON mode the error is printed in the same format as the
+// errors returned by the API.
 func die(args ...interface{}) {
-	fmt.Fprintln(os.Stderr, args...)
+	if jsonOutput {
+		msg := strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+		json.NewEncoder(os.Stderr).Encode(api.Error{Message: msg})
+	} else {
+		fmt.Fprintln(os.Stderr, args...)
+	}
 	os.Exit(exitCodeGeneral)
 }
 
+// printJSON prints v as indented JSON. Commands call it instead of printing
+// their formatted output if the --json flag is set. Commands that only
+// perform an action don't print anything in JSON mode, since the API doesn't
+// respond with a body to such calls.
+func printJSON(v interface{}) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		die("Could not encode response as JSON:", err)
+	}
+	fmt.Println(string(b))
+}
+
 func main() {
 	root := &cobra.Command{
 		Use:   os.Args[0],
@@ -86,6 +108,7 @@ func main() {
 	// create command tree
 	root.AddCommand(versionCmd)
 	root.AddCommand(stopCmd)
+	root.AddCommand(reloadCmd)
 
 	root.AddCommand(updateCmd)
 	updateCmd.AddCommand(updateCheckCmd)
@@ -153,12 +176,18 @@ func main() {
 	root.PersistentFlags().StringVarP(&httpClient.Address, "addr", "a", "localhost:5580", "which host/port to communicate with (i.e. the host/port hsd is listening on)")
 	root.PersistentFlags().StringVarP(&httpClient.Password, "apipassword", "", "", "the password for the API's http authentication")
 	root.PersistentFlags().StringVarP(&httpClient.UserAgent, "useragent", "", "Hyperspace-Agent", "the useragent used by hsc to connect to the daemon's API")
+	root.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "print the raw JSON responses of the API instead of formatted output")
 
-	// Check if the api password environment variable is set.
+	// Check if the api password environment variable is set. The notice is
+	// printed once the flags are parsed, so it can be omitted in JSON mode.
 	apiPassword := os.Getenv("HYPERSPACE_API_PASSWORD")
 	if apiPassword != "" {
 		httpClient.Password = apiPassword
-		fmt.Println("Using HYPERSPACE_API_PASSWORD environment variable")
+		root.PersistentPreRun = func(*cobra.Command, []string) {
+			if !jsonOutput {
+				fmt.Println("Using HYPERSPACE_API_PASSWORD environment variable")
+			}
+		}
 	}
 
 	// run
@@ -36,7 +36,9 @@ func minerstartcmd() {
 	if err != nil {
 		die("Could not start miner:", err)
 	}
-	fmt.Println("CPU Miner is now running.")
+	if !jsonOutput {
+		fmt.Println("CPU Miner is now running.")
+	}
 }
 
 // minercmd is the handler for the command `hsc miner`.
@@ -46,6 +48,10 @@ func minercmd() {
 	if err != nil {
 		die("Could not get miner status:", err)
 	}
+	if jsonOutput {
+		printJSON(status)
+		return
+	}
 
 	miningStr := "off"
 	if status.CPUMining {
@@ -65,5 +71,7 @@ func minerstopcmd() {
 	if err != nil {
 		die("Could not stop miner:", err)
 	}
-	fmt.Println("Stopped mining.")
+	if !jsonOutput {
+		fmt.Println("Stopped mining.")
+	}
 }
@@ -72,6 +72,10 @@ func poolcmd() {
 	if err != nil {
 		die("Could not get pool config:", err)
 	}
+	if jsonOutput {
+		printJSON(config)
+		return
+	}
 	fmt.Printf(`Pool status:
 
 Pool config:
@@ -112,6 +116,10 @@ func poolclientscmd() {
 	if err != nil {
 		die("Could not get pool clients:", err)
 	}
+	if jsonOutput {
+		printJSON(clients)
+		return
+	}
 	fmt.Printf("Clients List:\n\n")
 	fmt.Printf("Number of Clients: %d\nNumber of Workers: %d\n\n", clients.NumberOfClients, clients.NumberOfWorkers)
 	fmt.Printf("Client Name                                                                  \n")
@@ -128,6 +136,17 @@ func poolclientcmd(name string) {
 	if err != nil {
 		die("Could not get pool client: ", err)
 	}
+	if jsonOutput {
+		txs, err := httpClient.MiningPoolTransactionsGet(name)
+		if err != nil {
+			die("Could not get pool client transactions:", err)
+		}
+		printJSON(struct {
+			Client       api.MiningPoolClientInfo           `json:"client"`
+			Transactions []api.MiningPoolClientTransactions `json:"transactions"`
+		}{client, txs})
+		return
+	}
 	reward := big.NewInt(0)
 	reward.SetString(client.Balance, 10)
 	currency := types.NewCurrency(reward)
@@ -189,6 +208,10 @@ func poolblockscmd() {
 	if err != nil {
 		die("Could not get pool blocks: ", err)
 	}
+	if jsonOutput {
+		printJSON(blocks)
+		return
+	}
 	fmt.Printf("Blocks List:\n")
 	fmt.Printf("%-10s %-10s   %-19s   %-10s   %s\n", "Blocks", "Height", "Timestamp", "Reward", "Status")
 	fmt.Printf("---------- ----------   -------------------   ------   -------------------\n")
@@ -220,6 +243,18 @@ func poolblockcmd(name string) {
 	if err != nil {
 		die("Could not get pool block:", err)
 	}
+	if jsonOutput {
+		// The current block isn't listed yet.
+		var info *api.MiningPoolBlocksInfo
+		if match {
+			info = &blocksInfo
+		}
+		printJSON(struct {
+			Block   *api.MiningPoolBlocksInfo       `json:"block,omitempty"`
+			Clients []api.MiningPoolBlockClientInfo `json:"clients"`
+		}{info, block})
+		return
+	}
 	if match == false {
 		fmt.Printf("Current Block\n\n")
 	} else {
@@ -160,6 +160,17 @@ func rentercmd() {
 	if err != nil {
 		die("Could not get renter info:", err)
 	}
+	if jsonOutput {
+		rf, err := httpClient.RenterFilesGet()
+		if err != nil {
+			die("Could not get file list:", err)
+		}
+		printJSON(map[string]interface{}{
+			"renter": rg,
+			"files":  rf.Files,
+		})
+		return
+	}
 	fm := rg.FinancialMetrics
 	totalSpent := fm.ContractFees.Add(fm.UploadSpending).
 		Add(fm.DownloadSpending).Add(fm.StorageSpending)
@@ -188,6 +199,10 @@ func renteruploadscmd() {
 	if err != nil {
 		die("Could not get upload queue:", err)
 	}
+	if jsonOutput {
+		printJSON(rf)
+		return
+	}
 
 	// TODO: add a --history flag to the uploads command to mirror the --history
 	//       flag in the downloads command. This hasn't been done yet because the
@@ -219,6 +234,10 @@ func renterdownloadscmd() {
 	if err != nil {
 		die("Could not get download queue:", err)
 	}
+	if jsonOutput {
+		printJSON(queue)
+		return
+	}
 	// Filter out files that have been downloaded.
 	var downloading []api.DownloadInfo
 	for _, file := range queue.Downloads {
@@ -261,6 +280,10 @@ func renterallowancecmd() {
 	if err != nil {
 		die("Could not get allowance:", err)
 	}
+	if jsonOutput {
+		printJSON(rg)
+		return
+	}
 	allowance := rg.Settings.Allowance
 
 	// Show allowance info
@@ -341,7 +364,9 @@ again:
 	if err != nil {
 		die("error canceling allowance:", err)
 	}
-	fmt.Println("Allowance canceled.")
+	if !jsonOutput {
+		fmt.Println("Allowance canceled.")
+	}
 }
 
 // rentersetallowancecmd allows the user to set the allowance.
@@ -393,7 +418,9 @@ func rentersetallowancecmd(cmd *cobra.Command, args []string) {
 	if err != nil {
 		die("Could not set allowance:", err)
 	}
-	fmt.Println("Allowance updated.")
+	if !jsonOutput {
+		fmt.Println("Allowance updated.")
+	}
 }
 
 // byValue sorts contracts by their value in siacoins, high to low. If two
@@ -417,6 +444,17 @@ func rentercontractscmd() {
 	if err != nil {
 		die("Could not get contracts:", err)
 	}
+	if jsonOutput {
+		if renterAllContracts {
+			rce, err := httpClient.RenterExpiredContractsGet()
+			if err != nil {
+				die("Could not get expired contracts:", err)
+			}
+			rc.ExpiredContracts = rce.ExpiredContracts
+		}
+		printJSON(rc)
+		return
+	}
 
 	fmt.Println("Active Contracts:")
 	if len(rc.ActiveContracts) == 0 {
@@ -575,6 +613,13 @@ func rentercontractsviewcmd(cid string) {
 			if err != nil {
 				die("Could not fetch details of host: ", err)
 			}
+			if jsonOutput {
+				printJSON(map[string]interface{}{
+					"contract": rc,
+					"host":     hostInfo,
+				})
+				return
+			}
 			fmt.Printf(`
 Contract %v
   Host: %v (Public Key: %v)
@@ -605,7 +650,7 @@ Contract %v
 		}
 	}
 
-	fmt.Println("Contract not found")
+	die("Contract not found")
 }
 
 // renterfilesdeletecmd is the handler for the command `hsc renter delete [path]`.
@@ -615,7 +660,9 @@ func renterfilesdeletecmd(path string) {
 	if err != nil {
 		die("Could not delete file:", err)
 	}
-	fmt.Println("Deleted", path)
+	if !jsonOutput {
+		fmt.Println("Deleted", path)
+	}
 }
 
 // renterfilesdownloadcmd is the handler for the comand `hsc renter download [path] [destination]`.
@@ -633,7 +680,9 @@ func renterfilesdownloadcmd(path, destination string) {
 
 	// If the download is async, report success.
 	if renterDownloadAsync {
-		fmt.Printf("Queued Download '%s' to %s.\n", path, abs(destination))
+		if !jsonOutput {
+			fmt.Printf("Queued Download '%s' to %s.\n", path, abs(destination))
+		}
 		return
 	}
 
@@ -642,6 +691,19 @@ func renterfilesdownloadcmd(path, destination string) {
 	if err != nil {
 		die("\nDownload could not be completed:", err)
 	}
+	if jsonOutput {
+		queue, err := httpClient.RenterDownloadsGet()
+		if err != nil {
+			die("Could not get download queue:", err)
+		}
+		for _, d := range queue.Downloads {
+			if d.SiaPath == path && d.Destination == destination {
+				printJSON(d)
+				return
+			}
+		}
+		return
+	}
 	fmt.Printf("\nDownloaded '%s' to '%s'.\n", path, abs(destination))
 }
 
@@ -738,6 +800,9 @@ func downloadprogress(siapath, hyperspacepath string) error {
 		elapsed -= elapsed % time.Second // round to nearest second
 
 		// Update the progress for the user.
+		if jsonOutput {
+			continue
+		}
 		fmt.Printf("\rDownloading... %5.1f%% of %v, %v elapsed, %s    ", pct, filesizeUnits(int64(d.Filesize)), elapsed, speed)
 	}
 
@@ -761,6 +826,10 @@ func renterfileslistcmd() {
 	if err != nil {
 		die("Could not get file list:", err)
 	}
+	if jsonOutput {
+		printJSON(rf)
+		return
+	}
 	if len(rf.Files) == 0 {
 		fmt.Println("No files have been uploaded.")
 		return
@@ -809,7 +878,9 @@ func renterfilesrenamecmd(path, newpath string) {
 	if err != nil {
 		die("Could not rename file:", err)
 	}
-	fmt.Printf("Renamed %s to %s\n", path, newpath)
+	if !jsonOutput {
+		fmt.Printf("Renamed %s to %s\n", path, newpath)
+	}
 }
 
 // renterfilesuploadcmd is the handler for the command `hsc renter upload
@@ -852,14 +923,18 @@ func renterfilesuploadcmd(source, path string) {
 				fmt.Printf("Could not upload file %s :%v\n", file, err)
 			}
 		}
-		fmt.Printf("\nUploaded %d of %d files into '%s'.\n", len(files)-failed, len(files), path)
+		if !jsonOutput {
+			fmt.Printf("\nUploaded %d of %d files into '%s'.\n", len(files)-failed, len(files), path)
+		}
 	} else {
 		// single file
 		err = httpClient.RenterUploadDefaultPost(abs(source), path)
 		if err != nil {
 			die("Could not upload file:", err)
 		}
-		fmt.Printf("Uploaded '%s' as '%s'.\n", abs(source), path)
+		if !jsonOutput {
+			fmt.Printf("Uploaded '%s' as '%s'.\n", abs(source), path)
+		}
 	}
 }
 
@@ -870,6 +945,10 @@ func renterpricescmd() {
 	if err != nil {
 		die("Could not read the renter prices:", err)
 	}
+	if jsonOutput {
+		printJSON(rpg)
+		return
+	}
 
 	fmt.Println("Renter Prices (estimated):")
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
@@ -43,7 +43,9 @@ func stratumminerstartcmd(cmd *cobra.Command, args []string) {
 	if err != nil {
 		die("Could not start stratum miner:", err)
 	}
-	fmt.Println("Stratum miner is now running.")
+	if !jsonOutput {
+		fmt.Println("Stratum miner is now running.")
+	}
 }
 
 // stratumminerstopcmd is the handler for the command `hsc stratum-miner stop`.
@@ -53,7 +55,9 @@ func stratumminerstopcmd() {
 	if err != nil {
 		die("Could not stop stratum miner:", err)
 	}
-	fmt.Println("Stopped mining.")
+	if !jsonOutput {
+		fmt.Println("Stopped mining.")
+	}
 }
 
 // stratumminercmd is the handler for the command `hsc stratum-miner`.
@@ -63,6 +67,10 @@ func stratumminercmd() {
 	if err != nil {
 		die("Could not get stratum miner status:", err)
 	}
+	if jsonOutput {
+		printJSON(status)
+		return
+	}
 
 	miningStr := "off"
 	if status.Mining {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"math/big"
 	"os"
@@ -206,11 +207,20 @@ const confirmPasswordText = "Confirm: "
 // maximum value of a uint64.
 const unconfirmedTransactionTimestamp = ^uint64(0)
 
+// promptWriter returns where prompts and progress messages are written. In
+// JSON mode they go to stderr, so stdout only contains the JSON output.
+func promptWriter() io.Writer {
+	if jsonOutput {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
 // passwordPrompt securely reads a password from stdin.
 func passwordPrompt(prompt string) (string, error) {
-	fmt.Print(prompt)
+	fmt.Fprint(promptWriter(), prompt)
 	pw, err := terminal.ReadPassword(int(syscall.Stdin))
-	fmt.Println()
+	fmt.Fprintln(promptWriter())
 	return string(pw), err
 }
 
@@ -231,6 +241,10 @@ func walletaddressescmd() {
 	if err != nil {
 		die("Failed to fetch addresses:", err)
 	}
+	if jsonOutput {
+		printJSON(addrs)
+		return
+	}
 	for _, addr := range addrs.Addresses {
 		fmt.Println(addr)
 	}
@@ -252,7 +266,9 @@ func walletchangepasswordcmd() {
 	if err != nil {
 		die("Changing the password failed:", err)
 	}
-	fmt.Println("Password changed successfully.")
+	if !jsonOutput {
+		fmt.Println("Password changed successfully.")
+	}
 }
 
 // walletgetaddresscmd fetches a new address from the wallet that will be able to
@@ -262,6 +278,10 @@ func walletgetaddresscmd() {
 	if err != nil {
 		die("Could not get unused address:", err)
 	}
+	if jsonOutput {
+		printJSON(addr)
+		return
+	}
 	fmt.Printf("Got unused address: %s\n", addr.Address)
 }
 
@@ -281,6 +301,10 @@ func walletinitcmd() {
 	if err != nil {
 		die("Error when encrypting wallet:", err)
 	}
+	if jsonOutput {
+		printJSON(er)
+		return
+	}
 	fmt.Printf("Recovery seed:\n%s\n\n", er.PrimarySeed)
 	if initPassword {
 		fmt.Printf("Wallet encrypted with given password\n")
@@ -308,10 +332,12 @@ func walletinitseedcmd() {
 	if err != nil {
 		die("Could not initialize wallet from seed:", err)
 	}
-	if initPassword {
-		fmt.Println("Wallet initialized and encrypted with given password.")
-	} else {
-		fmt.Println("Wallet initialized and encrypted with seed.")
+	if !jsonOutput {
+		if initPassword {
+			fmt.Println("Wallet initialized and encrypted with given password.")
+		} else {
+			fmt.Println("Wallet initialized and encrypted with seed.")
+		}
 	}
 }
 
@@ -329,7 +355,9 @@ func walletloadseedcmd() {
 	if err != nil {
 		die("Could not add seed:", err)
 	}
-	fmt.Println("Added Key")
+	if !jsonOutput {
+		fmt.Println("Added Key")
+	}
 }
 
 // walletloadsiagcmd loads a siag key set into the wallet.
@@ -342,7 +370,9 @@ func walletloadsiagcmd(keyfiles string) {
 	if err != nil {
 		die("Loading siag key failed:", err)
 	}
-	fmt.Println("Wallet loading successful.")
+	if !jsonOutput {
+		fmt.Println("Wallet loading successful.")
+	}
 }
 
 // walletlockcmd locks the wallet
@@ -360,6 +390,10 @@ func walletnewaddresscmd() {
 	if err != nil {
 		die("Could not create a new address:", err)
 	}
+	if jsonOutput {
+		printJSON(addr)
+		return
+	}
 	fmt.Printf("Created new address: %s\n", addr.Address)
 }
 
@@ -369,6 +403,10 @@ func walletseedscmd() {
 	if err != nil {
 		die("Error retrieving the current seed:", err)
 	}
+	if jsonOutput {
+		printJSON(seedInfo)
+		return
+	}
 	fmt.Println("Primary Seed:")
 	fmt.Println(seedInfo.PrimarySeed)
 	if len(seedInfo.AllSeeds) == 1 {
@@ -400,10 +438,14 @@ func walletsendsiacoinscmd(amount, dest string) {
 	if _, err := fmt.Sscan(dest, &hash); err != nil {
 		die("Failed to parse destination address", err)
 	}
-	_, err = httpClient.WalletSiacoinsPost(value, hash)
+	wsp, err := httpClient.WalletSiacoinsPost(value, hash)
 	if err != nil {
 		die("Could not send siacoins:", err)
 	}
+	if jsonOutput {
+		printJSON(wsp)
+		return
+	}
 	fmt.Printf("Sent %s hastings to %s\n", hastings, dest)
 }
 
@@ -417,6 +459,13 @@ func walletbalancecmd() {
 	if err != nil {
 		die("Could not get fee estimation:", err)
 	}
+	if jsonOutput {
+		printJSON(map[string]interface{}{
+			"wallet": status,
+			"fees":   fees,
+		})
+		return
+	}
 	encStatus := "Unencrypted"
 	if status.Encrypted {
 		encStatus = "Encrypted"
@@ -460,7 +509,9 @@ func walletbroadcastcmd(txnStr string) {
 	if err != nil {
 		die("Could not broadcast transaction:", err)
 	}
-	fmt.Println("Transaction broadcast successfully")
+	if !jsonOutput {
+		fmt.Println("Transaction broadcast successfully")
+	}
 }
 
 // walletsweepcmd sweeps coins and funds from a seed.
@@ -474,7 +525,14 @@ func walletsweepcmd() {
 	if err != nil {
 		die("Could not sweep seed:", err)
 	}
+	if jsonOutput {
+		printJSON(swept)
+		return
+	}
 	fmt.Printf("Swept %v and %v SF from seed.\n", currencyUnits(swept.Coins), swept.Funds)
+	for _, txid := range swept.TransactionIDs {
+		fmt.Println("Transaction:", txid)
+	}
 }
 
 // walletsigncmd signs a transaction.
@@ -509,7 +567,7 @@ func walletsigncmd(cmd *cobra.Command, args []string) {
 		}
 
 		// siad is not running; fallback to offline keygen
-		fmt.Println("Enter your wallet seed to generate the signing key(s) now and sign without siad.")
+		fmt.Fprintln(promptWriter(), "Enter your wallet seed to generate the signing key(s) now and sign without siad.")
 		seedString, err := passwordPrompt("Seed: ")
 		if err != nil {
 			die("Reading seed failed:", err)
@@ -525,7 +583,7 @@ func walletsigncmd(cmd *cobra.Command, args []string) {
 		go func() {
 			select {
 			case <-time.After(time.Second):
-				fmt.Println("Generating keys; this may take a few seconds...")
+				fmt.Fprintln(promptWriter(), "Generating keys; this may take a few seconds...")
 			case <-done:
 			}
 		}()
@@ -551,6 +609,10 @@ func wallettransactionscmd() {
 	if err != nil {
 		die("Could not fetch transaction history:", err)
 	}
+	if jsonOutput {
+		printJSON(wtg)
+		return
+	}
 	fmt.Println("             [timestamp]    [height]                                                   [transaction id]    [net space cash]")
 	txns := append(wtg.ConfirmedTransactions, wtg.UnconfirmedTransactions...)
 	for _, txn := range txns {
@@ -598,13 +660,17 @@ func walletunlockcmd() {
 	// interactive method. Also allow overriding auto-unlock via -p
 	password := os.Getenv("HYPERSPACE_WALLET_PASSWORD")
 	if password != "" && !initPassword {
-		fmt.Println("Using HYPERSPACE_WALLET_PASSWORD environment variable")
+		if !jsonOutput {
+			fmt.Println("Using HYPERSPACE_WALLET_PASSWORD environment variable")
+		}
 		err := httpClient.WalletUnlockPost(password)
-		if err != nil {
-			fmt.Println("Automatic unlock failed!")
-		} else {
-			fmt.Println("Wallet unlocked")
+		if err == nil {
+			if !jsonOutput {
+				fmt.Println("Wallet unlocked")
+			}
 			return
+		} else if !jsonOutput {
+			fmt.Println("Automatic unlock failed!")
 		}
 	}
 	password, err := passwordPrompt("Wallet password: ")
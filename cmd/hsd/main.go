@@ -41,6 +41,7 @@ type Config struct {
 
 		Modules           string
 		NoBootstrap       bool
+		DNSSeeds          []string
 		RequiredUserAgent string
 		AuthenticateAPI   bool
 		AddressGapLimit   int
@@ -183,6 +184,7 @@ func main() {
 	root.Flags().StringVarP(&globalConfig.Siad.APIaddr, "api-addr", "", "localhost:5580", "which host:port the API server listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.SiaDir, "hyperspace-directory", "d", "", "location of the hyperspace directory")
 	root.Flags().BoolVarP(&globalConfig.Siad.NoBootstrap, "no-bootstrap", "", false, "disable bootstrapping on this run")
+	root.Flags().StringSliceVarP(&globalConfig.Siad.DNSSeeds, "dns-seeds", "", modules.DNSSeeds, "comma separated list of DNS seeds used to discover bootstrap peers")
 	root.Flags().StringVarP(&globalConfig.Siad.Profile, "profile", "", "", "enable profiling with flags 'cmt' for CPU, memory, trace")
 	root.Flags().StringVarP(&globalConfig.Siad.RPCaddr, "rpc-addr", "", ":5581", "which port the gateway listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.Modules, "modules", "M", "cghrtw", "enabled modules, see 'hsd modules' for more info")
@@ -190,7 +192,7 @@ func main() {
 	root.Flags().BoolVarP(&globalConfig.Siad.ScanAirdrop, "scan-airdrop", "", false, "scan the airdrop blocks")
 	root.Flags().BoolVarP(&globalConfig.Siad.AuthenticateAPI, "authenticate-api", "", false, "enable API password protection")
 	root.Flags().BoolVarP(&globalConfig.Siad.AllowAPIBind, "disable-api-security", "", false, "allow hsd to listen on a non-localhost address (DANGEROUS)")
-	root.Flags().BoolVarP(&globalConfig.Siad.Spv, "spv", "", false, "enable SPV mode")
+	root.Flags().BoolVarP(&globalConfig.Siad.Spv, "spv", "", false, "enable SPV mode, which only validates the block headers (see doc/Consensus.md)")
 
 	// Parse cmdline flags, overwriting both the default values and the config
 	// file values.
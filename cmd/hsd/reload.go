@@ -0,0 +1,266 @@
+package main
+
+// reload.go lets operators change the settings of a running daemon by editing
+// the config file and calling /daemon/reload. Only the settings that the
+// modules can change in place are applied, using the same setters as the
+// module's own API routes. Every other setting in the file is reported as
+// requiring a restart.
+//
+// The reloadable settings live in the log, gateway, renter and host sections
+// of sia.yaml, next to the miningpool and index sections that are read on
+// startup:
+//
+//   log:
+//     level: info             # debug or info
+//   gateway:
+//     minpeerversion: "0.2.0"
+//     relayfanout: 8
+//     maxpeers: 0             # 0 is the default
+//     maxoutboundpeers: 0     # 0 is the default
+//   renter:
+//     maxdownloadspeed: 0     # bytes per second
+//     maxuploadspeed: 0       # bytes per second
+//     streamcachesize: 4
+//     allowance:
+//       funds: "1000000000"   # hastings
+//       hosts: 50
+//       period: 4320          # blocks
+//       renewwindow: 1440     # blocks
+//   host:
+//     acceptingcontracts: true
+//     maxconcurrentrpcs: 0
+//     maxconcurrentrpcsperrenter: 0
+//     rpcqueuepolicy: fair
+//     maxstoragepercontract: 0
+//
+// Settings are applied in the order above and a reload stops at the first
+// setting that can't be applied. The settings applied before it stay in
+// effect, so they are logged and reported along with the error.
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/node/api"
+	"github.com/HyperspaceApp/Hyperspace/persist"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/spf13/viper"
+)
+
+// reloadableModules are the modules whose settings can be reloaded, keyed by
+// their section in the config file.
+type reloadableModules struct {
+	gateway modules.Gateway
+	host    modules.Host
+	renter  modules.Renter
+}
+
+// readReloadConfig reads the config file from the working directory of the
+// daemon, the same file that readFileConfig reads on startup.
+func readReloadConfig() (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	v.SetConfigName("sia")
+	v.AddConfigPath(".")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// applyLogLevel enables the debug messages of the loggers for the debug level
+// and disables them for the info level.
+func applyLogLevel(level string) error {
+	switch level {
+	case "debug", "info":
+		persist.SetDebugLogging(level == "debug")
+		return nil
+	default:
+		return fmt.Errorf("unknown level %q, expected debug or info", level)
+	}
+}
+
+// applyReloadConfig applies the reloadable settings of v to the modules. The
+// keys of the applied settings are returned in applied, the keys of all other
+// settings in restartRequired. Settings of modules that aren't loaded require
+// a restart as well. If a setting can't be applied, the settings that were
+// applied before it are returned along with the error.
+func applyReloadConfig(v *viper.Viper, m reloadableModules) (applied, restartRequired []string, err error) {
+	handled := make(map[string]bool)
+	// set marks a key as handled and returns true if it is set in the file
+	// and its module is loaded.
+	set := func(key string, loaded bool) bool {
+		if !v.IsSet(key) || !loaded {
+			return false
+		}
+		handled[key] = true
+		return true
+	}
+	// fail returns the settings applied so far along with the error.
+	fail := func(format string, args ...interface{}) ([]string, []string, error) {
+		sort.Strings(applied)
+		return applied, nil, fmt.Errorf(format, args...)
+	}
+
+	// Log.
+	if set("log.level", true) {
+		if err := applyLogLevel(v.GetString("log.level")); err != nil {
+			return fail("unable to apply log.level: %v", err)
+		}
+		applied = append(applied, "log.level")
+	}
+
+	// Gateway.
+	if set("gateway.minpeerversion", m.gateway != nil) {
+		if err := m.gateway.SetMinPeerVersion(v.GetString("gateway.minpeerversion")); err != nil {
+			return fail("unable to apply gateway.minpeerversion: %v", err)
+		}
+		applied = append(applied, "gateway.minpeerversion")
+	}
+	if set("gateway.relayfanout", m.gateway != nil) {
+		if err := m.gateway.SetRelayFanout(v.GetInt("gateway.relayfanout")); err != nil {
+			return fail("unable to apply gateway.relayfanout: %v", err)
+		}
+		applied = append(applied, "gateway.relayfanout")
+	}
+	// The peer limits depend on each other, so they are applied together.
+	if m.gateway != nil {
+		limits := m.gateway.PeerLimits()
+		var keys []string
+		if set("gateway.maxpeers", true) {
+			limits.MaxPeers = v.GetInt("gateway.maxpeers")
+			keys = append(keys, "gateway.maxpeers")
+		}
+		if set("gateway.maxoutboundpeers", true) {
+			limits.MaxOutboundPeers = v.GetInt("gateway.maxoutboundpeers")
+			keys = append(keys, "gateway.maxoutboundpeers")
+		}
+		if len(keys) > 0 {
+			if err := m.gateway.SetPeerLimits(limits); err != nil {
+				return fail("unable to apply gateway peer limits: %v", err)
+			}
+			applied = append(applied, keys...)
+		}
+	}
+
+	// Renter. The settings are applied together so that the allowance is
+	// only changed once.
+	if m.renter != nil {
+		settings := m.renter.Settings()
+		var keys []string
+		if set("renter.maxdownloadspeed", true) {
+			settings.MaxDownloadSpeed = v.GetInt64("renter.maxdownloadspeed")
+			keys = append(keys, "renter.maxdownloadspeed")
+		}
+		if set("renter.maxuploadspeed", true) {
+			settings.MaxUploadSpeed = v.GetInt64("renter.maxuploadspeed")
+			keys = append(keys, "renter.maxuploadspeed")
+		}
+		if set("renter.streamcachesize", true) {
+			settings.StreamCacheSize = uint64(v.GetInt64("renter.streamcachesize"))
+			keys = append(keys, "renter.streamcachesize")
+		}
+		if set("renter.allowance.funds", true) {
+			var funds types.Currency
+			if _, err := fmt.Sscan(v.GetString("renter.allowance.funds"), &funds); err != nil {
+				return fail("unable to parse renter.allowance.funds: %v", err)
+			}
+			settings.Allowance.Funds = funds
+			keys = append(keys, "renter.allowance.funds")
+		}
+		if set("renter.allowance.hosts", true) {
+			settings.Allowance.Hosts = uint64(v.GetInt64("renter.allowance.hosts"))
+			keys = append(keys, "renter.allowance.hosts")
+		}
+		if set("renter.allowance.period", true) {
+			settings.Allowance.Period = types.BlockHeight(v.GetInt64("renter.allowance.period"))
+			keys = append(keys, "renter.allowance.period")
+		}
+		if set("renter.allowance.renewwindow", true) {
+			settings.Allowance.RenewWindow = types.BlockHeight(v.GetInt64("renter.allowance.renewwindow"))
+			keys = append(keys, "renter.allowance.renewwindow")
+		}
+		if len(keys) > 0 {
+			if err := m.renter.SetSettings(settings); err != nil {
+				return fail("unable to apply renter settings: %v", err)
+			}
+			applied = append(applied, keys...)
+		}
+	}
+
+	// Host.
+	if m.host != nil {
+		settings := m.host.InternalSettings()
+		var keys []string
+		if set("host.acceptingcontracts", true) {
+			settings.AcceptingContracts = v.GetBool("host.acceptingcontracts")
+			keys = append(keys, "host.acceptingcontracts")
+		}
+		if set("host.maxconcurrentrpcs", true) {
+			settings.MaxConcurrentRPCs = uint64(v.GetInt64("host.maxconcurrentrpcs"))
+			keys = append(keys, "host.maxconcurrentrpcs")
+		}
+		if set("host.maxconcurrentrpcsperrenter", true) {
+			settings.MaxConcurrentRPCsPerRenter = uint64(v.GetInt64("host.maxconcurrentrpcsperrenter"))
+			keys = append(keys, "host.maxconcurrentrpcsperrenter")
+		}
+		if set("host.rpcqueuepolicy", true) {
+			settings.RPCQueuePolicy = v.GetString("host.rpcqueuepolicy")
+			keys = append(keys, "host.rpcqueuepolicy")
+		}
+		if set("host.maxstoragepercontract", true) {
+			settings.MaxStoragePerContract = uint64(v.GetInt64("host.maxstoragepercontract"))
+			keys = append(keys, "host.maxstoragepercontract")
+		}
+		if len(keys) > 0 {
+			if err := m.host.SetInternalSettings(settings); err != nil {
+				return fail("unable to apply host settings: %v", err)
+			}
+			applied = append(applied, keys...)
+		}
+	}
+
+	for _, key := range v.AllKeys() {
+		if !handled[key] {
+			restartRequired = append(restartRequired, key)
+		}
+	}
+	sort.Strings(applied)
+	sort.Strings(restartRequired)
+	return applied, restartRequired, nil
+}
+
+// daemonReloadHandler handles the API call to reload the config file of the
+// daemon.
+func (srv *Server) daemonReloadHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	srv.mu.Lock()
+	m := srv.reloadable
+	srv.mu.Unlock()
+	v, err := readReloadConfig()
+	if err != nil {
+		api.WriteError(w, api.Error{Message: "unable to read config file: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	applied, restartRequired, err := applyReloadConfig(v, m)
+	if err != nil {
+		msg := err.Error()
+		if len(applied) > 0 {
+			msg += "; settings applied before the error: " + strings.Join(applied, ", ")
+		}
+		srv.log.Println("WARN: reload failed:", msg)
+		api.WriteError(w, api.Error{Message: msg}, http.StatusBadRequest)
+		return
+	}
+	if len(applied) > 0 {
+		srv.log.Println("Reloaded settings:", strings.Join(applied, ", "))
+	}
+	api.WriteJSON(w, api.DaemonReloadPOST{
+		Applied:         applied,
+		RestartRequired: restartRequired,
+	})
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/persist"
+
+	"github.com/spf13/viper"
+)
+
+// reloadGateway is a gateway that only records the reloadable settings.
+type reloadGateway struct {
+	modules.Gateway
+	minPeerVersion string
+	relayFanout    int
+	peerLimits     modules.GatewayPeerLimits
+	peerLimitsErr  error
+}
+
+func (g *reloadGateway) PeerLimits() modules.GatewayPeerLimits {
+	return g.peerLimits
+}
+
+func (g *reloadGateway) SetPeerLimits(limits modules.GatewayPeerLimits) error {
+	if g.peerLimitsErr != nil {
+		return g.peerLimitsErr
+	}
+	g.peerLimits = limits
+	return nil
+}
+
+func (g *reloadGateway) SetMinPeerVersion(version string) error {
+	g.minPeerVersion = version
+	return nil
+}
+
+func (g *reloadGateway) SetRelayFanout(fanout int) error {
+	g.relayFanout = fanout
+	return nil
+}
+
+// TestApplyReloadConfig checks that the reloadable settings are applied to the
+// loaded modules and that all other settings are reported as requiring a
+// restart.
+func TestApplyReloadConfig(t *testing.T) {
+	defer persist.SetDebugLogging(build.DEBUG)
+	config := []byte(`
+log:
+  level: debug
+gateway:
+  minpeerversion: "0.2.1"
+  relayfanout: 5
+  maxoutboundpeers: 3
+renter:
+  maxdownloadspeed: 100
+miningpool:
+  poolwallet: abc
+`)
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(config)); err != nil {
+		t.Fatal(err)
+	}
+
+	persist.SetDebugLogging(false)
+	g := &reloadGateway{peerLimits: modules.GatewayPeerLimits{MaxPeers: 10, MaxOutboundPeers: 8}}
+	applied, restartRequired, err := applyReloadConfig(v, reloadableModules{gateway: g})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"gateway.maxoutboundpeers", "gateway.minpeerversion", "gateway.relayfanout", "log.level"}; !reflect.DeepEqual(applied, expected) {
+		t.Fatal("unexpected applied settings", applied)
+	}
+	// The renter isn't loaded, so its settings require a restart.
+	if expected := []string{"miningpool.poolwallet", "renter.maxdownloadspeed"}; !reflect.DeepEqual(restartRequired, expected) {
+		t.Fatal("unexpected settings requiring a restart", restartRequired)
+	}
+	if g.minPeerVersion != "0.2.1" || g.relayFanout != 5 {
+		t.Fatal("gateway settings weren't applied", g.minPeerVersion, g.relayFanout)
+	}
+	// The peer limit that isn't in the file is kept.
+	if expected := (modules.GatewayPeerLimits{MaxPeers: 10, MaxOutboundPeers: 3}); g.peerLimits != expected {
+		t.Fatal("peer limits weren't applied", g.peerLimits)
+	}
+	if !persist.DebugLogging() {
+		t.Fatal("log level wasn't applied")
+	}
+
+	// If a setting fails, the settings applied before it are returned with
+	// the error.
+	g = &reloadGateway{peerLimitsErr: errors.New("invalid limits")}
+	applied, _, err = applyReloadConfig(v, reloadableModules{gateway: g})
+	if err == nil {
+		t.Fatal("expected the peer limits to fail")
+	}
+	if expected := []string{"gateway.minpeerversion", "gateway.relayfanout", "log.level"}; !reflect.DeepEqual(applied, expected) {
+		t.Fatal("unexpected applied settings", applied)
+	}
+
+	// An unknown log level is rejected.
+	v.Set("log.level", "verbose")
+	if _, _, err := applyReloadConfig(v, reloadableModules{}); err == nil {
+		t.Fatal("expected an unknown log level to fail")
+	}
+}
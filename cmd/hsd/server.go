@@ -35,6 +35,7 @@ import (
 	"github.com/HyperspaceApp/Hyperspace/modules/transactionpool"
 	"github.com/HyperspaceApp/Hyperspace/modules/wallet"
 	"github.com/HyperspaceApp/Hyperspace/node/api"
+	"github.com/HyperspaceApp/Hyperspace/persist"
 	"github.com/HyperspaceApp/Hyperspace/types"
 
 	"github.com/inconshreveable/go-update"
@@ -53,6 +54,8 @@ type (
 		config        Config
 		moduleClosers []moduleCloser
 		api           http.Handler
+		reloadable    reloadableModules
+		log           *persist.Logger
 		mu            sync.Mutex
 	}
 
@@ -393,6 +396,7 @@ func (srv *Server) daemonHandler(password string) http.Handler {
 	router := httprouter.New()
 
 	router.GET("/daemon/constants", srv.daemonConstantsHandler)
+	router.POST("/daemon/reload", api.RequirePassword(srv.daemonReloadHandler, password))
 	router.GET("/daemon/version", srv.daemonVersionHandler)
 	router.GET("/daemon/update", srv.daemonUpdateHandlerGET)
 	router.POST("/daemon/update", srv.daemonUpdateHandlerPOST)
@@ -433,6 +437,31 @@ func NewServer(config Config) (*Server, error) {
 		return nil, err
 	}
 
+	// Create the daemon logger, which logs the events of the daemon that don't
+	// belong to a module, such as reloads of the config file. An empty
+	// directory is the working directory.
+	if config.Siad.SiaDir != "" {
+		if err := os.MkdirAll(config.Siad.SiaDir, 0700); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+	logger, err := persist.NewFileLogger(filepath.Join(config.Siad.SiaDir, "hsd.log"))
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	// The log level is the only reloadable setting that isn't persisted by a
+	// module, so it is read from the config file on startup as well.
+	if v, err := readReloadConfig(); err == nil && v.IsSet("log.level") {
+		if err := applyLogLevel(v.GetString("log.level")); err != nil {
+			l.Close()
+			logger.Close()
+			return nil, fmt.Errorf("unable to apply log.level: %v", err)
+		}
+	}
+
 	// Create the Server
 	mux := http.NewServeMux()
 	srv := &Server{
@@ -458,6 +487,7 @@ func NewServer(config Config) (*Server, error) {
 			IdleTimeout: time.Minute * 5,
 		},
 		config: config,
+		log:    logger,
 	}
 
 	// Register hsd routes
@@ -490,7 +520,7 @@ func (srv *Server) loadModules() error {
 	if strings.Contains(srv.config.Siad.Modules, "g") {
 		i++
 		fmt.Printf("(%d/%d) Loading gateway...\n", i, len(srv.config.Siad.Modules))
-		g, err = gateway.New(srv.config.Siad.RPCaddr, !srv.config.Siad.NoBootstrap, filepath.Join(srv.config.Siad.SiaDir, modules.GatewayDir), srv.config.Siad.Spv)
+		g, err = gateway.NewCustomGateway(srv.config.Siad.RPCaddr, !srv.config.Siad.NoBootstrap, filepath.Join(srv.config.Siad.SiaDir, modules.GatewayDir), srv.config.Siad.Spv, srv.config.Siad.DNSSeeds, modules.ProdDependencies)
 		if err != nil {
 			return err
 		}
@@ -637,6 +667,11 @@ func (srv *Server) loadModules() error {
 	// connect the API to the server
 	srv.mu.Lock()
 	srv.api = a
+	srv.reloadable = reloadableModules{
+		gateway: g,
+		host:    h,
+		renter:  r,
+	}
 	srv.mu.Unlock()
 
 	// Attempt to auto-unlock the wallet using the HYPERSPACE_WALLET_PASSWORD env variable
@@ -679,6 +714,9 @@ func (srv *Server) Close() error {
 			errs = append(errs, err)
 		}
 	}
+	if err := srv.log.Close(); err != nil {
+		errs = append(errs, err)
+	}
 
 	return build.JoinErrors(errs, "\n")
 }
@@ -146,6 +146,13 @@ type (
 		// applied.
 		AppliedBlocks []types.Block
 
+		// RevertedBlockIDs and AppliedBlockIDs contain the ids of the
+		// reverted and applied blocks. They are only set for subscribers with
+		// a ConsensusChangeFilter, whose blocks only contain the selected
+		// transactions and therefore no longer have their original ids.
+		RevertedBlockIDs []types.BlockID
+		AppliedBlockIDs  []types.BlockID
+
 		// SiacoinOutputDiffs contains the set of siacoin diffs that were applied
 		// to the consensus set in the recent change. The direction for the set of
 		// diffs is 'DiffApply'.
@@ -188,6 +195,14 @@ type (
 		SiacoinOutput types.SiacoinOutput
 	}
 
+	// An UnspentSiacoinOutput is a siacoin output of the consensus set that
+	// hasn't been spent yet, together with its id.
+	UnspentSiacoinOutput struct {
+		ID         types.SiacoinOutputID `json:"id"`
+		Value      types.Currency        `json:"value"`
+		UnlockHash types.UnlockHash      `json:"unlockhash"`
+	}
+
 	// A FileContractDiff indicates the addition or removal of a FileContract in
 	// the consensus set.
 	FileContractDiff struct {
@@ -260,6 +275,12 @@ type (
 		// A channel can be provided to abort the subscription process.
 		ConsensusSetSubscribe(ConsensusSetSubscriber, ConsensusChangeID, <-chan struct{}) error
 
+		// FilteredConsensusSetSubscribe works like ConsensusSetSubscribe, but
+		// the subscriber only receives the transactions and diffs of every
+		// change that are selected by the filter, together with the original
+		// ids of the blocks. The subscriber is removed with Unsubscribe.
+		FilteredConsensusSetSubscribe(ConsensusSetSubscriber, ConsensusChangeID, ConsensusChangeFilter, <-chan struct{}) error
+
 		// HeaderConsensusSetSubscribe adds a subscriber to the list of subscribers
 		// and gives them every consensus change
 		HeaderConsensusSetSubscribe(HeaderConsensusSetSubscriber, ConsensusChangeID, <-chan struct{}) error
@@ -291,6 +312,11 @@ type (
 		// risk of mining invalid blocks.
 		MinimumValidChildTimestamp(types.BlockID) (types.Timestamp, bool)
 
+		// SiacoinOutputs returns up to limit unspent siacoin outputs whose
+		// address starts with the hex prefix, ordered by id and starting
+		// after the provided id.
+		SiacoinOutputs(addressPrefix string, after types.SiacoinOutputID, limit int) ([]UnspentSiacoinOutput, error)
+
 		// StorageProofSegment returns the segment to be used in the storage proof for
 		// a given file contract.
 		StorageProofSegment(types.FileContractID) (uint64, error)
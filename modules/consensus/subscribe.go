@@ -11,6 +11,28 @@ import (
 	"github.com/coreos/bbolt"
 )
 
+// filteredSubscriber passes every consensus change to a subscriber after
+// removing the transactions and diffs that the filter doesn't select.
+type filteredSubscriber struct {
+	filter     modules.ConsensusChangeFilter
+	subscriber modules.ConsensusSetSubscriber
+}
+
+// ProcessConsensusChange filters the consensus change and passes it on to the
+// subscriber.
+func (fs *filteredSubscriber) ProcessConsensusChange(cc modules.ConsensusChange) {
+	fs.subscriber.ProcessConsensusChange(fs.filter.Apply(cc))
+}
+
+// unwrapSubscriber returns the subscriber that was passed to the consensus set
+// by the caller, which is wrapped if it subscribed with a filter.
+func unwrapSubscriber(subscriber modules.ConsensusSetSubscriber) modules.ConsensusSetSubscriber {
+	if fs, ok := subscriber.(*filteredSubscriber); ok {
+		return fs.subscriber
+	}
+	return subscriber
+}
+
 // computeConsensusChange computes the consensus change from the change entry
 // at index 'i' in the change log. If i is out of bounds, an error is returned.
 func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (modules.ConsensusChange, error) {
@@ -260,7 +282,7 @@ func (cs *ConsensusSet) ConsensusSetSubscribe(subscriber modules.ConsensusSetSub
 	// Add the module to the list of subscribers.
 	// Sanity check - subscriber should not be already subscribed.
 	for _, s := range cs.subscribers {
-		if s == subscriber {
+		if unwrapSubscriber(s) == unwrapSubscriber(subscriber) {
 			build.Critical("refusing to double-subscribe subscriber")
 		}
 	}
@@ -268,6 +290,18 @@ func (cs *ConsensusSet) ConsensusSetSubscribe(subscriber modules.ConsensusSetSub
 	return nil
 }
 
+// FilteredConsensusSetSubscribe adds a subscriber to the list of subscribers,
+// and gives them every consensus change that has occurred since the change
+// with the provided id, reduced to the transactions and diffs selected by the
+// filter. Every change is delivered with all of its blocks, even if the filter
+// removes all of their transactions, so that the subscriber can track the
+// change ids, the block ids and the height of the consensus set.
+func (cs *ConsensusSet) FilteredConsensusSetSubscribe(subscriber modules.ConsensusSetSubscriber, start modules.ConsensusChangeID,
+	filter modules.ConsensusChangeFilter, cancel <-chan struct{}) error {
+
+	return cs.ConsensusSetSubscribe(&filteredSubscriber{filter: filter, subscriber: subscriber}, start, cancel)
+}
+
 // Unsubscribe removes a subscriber from the list of subscribers, allowing for
 // garbage collection and rescanning. If the subscriber is not found in the
 // subscriber database, no action is taken.
@@ -282,7 +316,7 @@ func (cs *ConsensusSet) Unsubscribe(subscriber modules.ConsensusSetSubscriber) {
 	// Search for the subscriber in the list of subscribers and remove it if
 	// found.
 	for i := range cs.subscribers {
-		if cs.subscribers[i] == subscriber {
+		if unwrapSubscriber(cs.subscribers[i]) == subscriber {
 			// nil the subscriber entry (otherwise it will not be GC'd if it's
 			// at the end of the subscribers slice).
 			cs.subscribers[i] = nil
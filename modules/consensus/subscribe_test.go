@@ -152,6 +152,86 @@ func TestUnsubscribe(t *testing.T) {
 	}
 }
 
+// TestFilteredSubscribe checks that a filtered subscriber receives every
+// consensus change and the original block ids, but only the transactions and
+// diffs selected by the filter, and that it can be unsubscribed.
+func TestFilteredSubscribe(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	ms := newMockSubscriber()
+	err = cst.cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeBeginning, cst.cs.tg.StopChan())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fms := newMockSubscriber()
+	filter := modules.ConsensusChangeFilter{HostAnnouncements: true}
+	err = cst.cs.FilteredConsensusSetSubscribe(&fms, modules.ConsensusChangeBeginning, filter, cst.cs.tg.StopChan())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cst.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The filtered subscriber receives the same changes and block ids,
+	// without any diffs or transactions that don't announce a host.
+	if len(fms.updates) != len(ms.updates) {
+		t.Fatalf("filtered subscriber received %v updates, expected %v", len(fms.updates), len(ms.updates))
+	}
+	var filteredTxns bool
+	for i, cc := range fms.updates {
+		if cc.ID != ms.updates[i].ID || len(cc.AppliedBlocks) != len(ms.updates[i].AppliedBlocks) || len(cc.RevertedBlocks) != len(ms.updates[i].RevertedBlocks) {
+			t.Fatal("filtered subscriber received a different change", i)
+		}
+		if len(cc.SiacoinOutputDiffs) != 0 || len(cc.DelayedSiacoinOutputDiffs) != 0 || len(cc.FileContractDiffs) != 0 {
+			t.Fatal("filtered subscriber received diffs that weren't selected")
+		}
+		if len(cc.AppliedBlockIDs) != len(cc.AppliedBlocks) || len(cc.RevertedBlockIDs) != len(cc.RevertedBlocks) {
+			t.Fatal("filtered subscriber didn't receive the block ids")
+		}
+		for j, b := range cc.AppliedBlocks {
+			original := ms.updates[i].AppliedBlocks[j]
+			if cc.AppliedBlockIDs[j] != original.ID() || b.ParentID != original.ParentID {
+				t.Fatal("filtered subscriber received a different block")
+			}
+			for _, txn := range b.Transactions {
+				if !filter.MatchesTransaction(txn) {
+					t.Fatal("filtered subscriber received a transaction that doesn't match the filter")
+				}
+			}
+			if len(b.Transactions) != len(original.Transactions) {
+				filteredTxns = true
+			}
+		}
+	}
+	if !filteredTxns {
+		t.Fatal("no transactions were filtered")
+	}
+
+	// Unsubscribing the filtered subscriber stops its updates.
+	cst.cs.Unsubscribe(&fms)
+	fmsLen := len(fms.updates)
+	_, err = cst.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fms.updates) != fmsLen {
+		t.Error("filtered subscriber was not correctly unsubscribed")
+	}
+	if len(ms.updates) != fmsLen+1 {
+		t.Error("unfiltered subscriber stopped receiving updates")
+	}
+}
+
 // TestModuletDesync is a reproduction test for the bug that caused a module to
 // desync while subscribing to the consensus set.
 func TestModuleDesync(t *testing.T) {
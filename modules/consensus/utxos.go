@@ -0,0 +1,89 @@
+package consensus
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/HyperspaceApp/Hyperspace/encoding"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// errUTXOsSpvMode is returned when the unspent outputs are requested
+	// from a consensus set in spv mode, which only tracks the outputs
+	// relevant to its wallet.
+	errUTXOsSpvMode = errors.New("the unspent outputs aren't available in spv mode")
+
+	// errInvalidAddressPrefix is returned if the address prefix isn't a
+	// lowercase hex string.
+	errInvalidAddressPrefix = errors.New("address prefix must be a lowercase hex string")
+
+	// errNonPositiveLimit is returned if fewer than one output is requested.
+	errNonPositiveLimit = errors.New("limit must be positive")
+)
+
+// validAddressPrefix returns true if prefix only consists of lowercase hex
+// characters. The prefix may have an odd length.
+func validAddressPrefix(prefix string) bool {
+	for _, c := range prefix {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// SiacoinOutputs returns up to limit unspent siacoin outputs whose address
+// starts with addressPrefix, ordered by their ids. Only outputs with an id
+// greater than 'after' are returned, so the whole set can be paged through by
+// passing the id of the last output of the previous page. The zero id starts
+// at the first output.
+func (cs *ConsensusSet) SiacoinOutputs(addressPrefix string, after types.SiacoinOutputID, limit int) (outputs []modules.UnspentSiacoinOutput, err error) {
+	if err := cs.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer cs.tg.Done()
+	if cs.spv {
+		return nil, errUTXOsSpvMode
+	}
+	if !validAddressPrefix(addressPrefix) {
+		return nil, errInvalidAddressPrefix
+	}
+	if limit <= 0 {
+		return nil, errNonPositiveLimit
+	}
+
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(SiacoinOutputs).Cursor()
+		k, v := c.First()
+		if after != (types.SiacoinOutputID{}) {
+			k, v = c.Seek(after[:])
+			if bytes.Equal(k, after[:]) {
+				k, v = c.Next()
+			}
+		}
+		for ; k != nil && len(outputs) < limit; k, v = c.Next() {
+			var sco types.SiacoinOutput
+			if err := encoding.Unmarshal(v, &sco); err != nil {
+				return err
+			}
+			if !strings.HasPrefix(hex.EncodeToString(sco.UnlockHash[:]), addressPrefix) {
+				continue
+			}
+			var id types.SiacoinOutputID
+			copy(id[:], k)
+			outputs = append(outputs, modules.UnspentSiacoinOutput{
+				ID:         id,
+				Value:      sco.Value,
+				UnlockHash: sco.UnlockHash,
+			})
+		}
+		return nil
+	})
+	return outputs, err
+}
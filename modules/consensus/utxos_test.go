@@ -0,0 +1,73 @@
+package consensus
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestSiacoinOutputs checks that the unspent outputs can be paged through and
+// filtered by address prefix.
+func TestSiacoinOutputs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	all, err := cst.cs.SiacoinOutputs("", types.SiacoinOutputID{}, 1e6)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(all) < 2 {
+		t.Fatal("expected the tester to have several outputs but got", len(all))
+	}
+
+	// Page through the outputs two at a time.
+	var paged int
+	var after types.SiacoinOutputID
+	for {
+		page, err := cst.cs.SiacoinOutputs("", after, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sco := range page {
+			if sco.ID != all[paged].ID {
+				t.Fatal("pages don't match the full set at output", paged)
+			}
+			paged++
+		}
+		if len(page) < 2 {
+			break
+		}
+		after = page[len(page)-1].ID
+	}
+	if paged != len(all) {
+		t.Fatalf("paged through %v of %v outputs", paged, len(all))
+	}
+
+	// Filter by the address of the first output.
+	prefix := hex.EncodeToString(all[0].UnlockHash[:])[:3]
+	filtered, err := cst.cs.SiacoinOutputs(prefix, types.SiacoinOutputID{}, 1e6)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(filtered) == 0 {
+		t.Fatal("expected at least one output to match the prefix")
+	}
+	for _, sco := range filtered {
+		if !strings.HasPrefix(hex.EncodeToString(sco.UnlockHash[:]), prefix) {
+			t.Fatal("output doesn't match the prefix", sco.UnlockHash)
+		}
+	}
+
+	if _, err := cst.cs.SiacoinOutputs("ABC", types.SiacoinOutputID{}, 1); err != errInvalidAddressPrefix {
+		t.Fatal("expected errInvalidAddressPrefix but got", err)
+	}
+	if _, err := cst.cs.SiacoinOutputs("", types.SiacoinOutputID{}, 0); err != errNonPositiveLimit {
+		t.Fatal("expected errNonPositiveLimit but got", err)
+	}
+}
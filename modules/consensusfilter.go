@@ -0,0 +1,100 @@
+package modules
+
+import (
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// A ConsensusChangeFilter selects the transactions and diffs of a consensus
+// change that a filtered subscriber receives. A transaction is kept if it
+// contains at least one of the selected elements. The filter only looks at
+// the transaction itself, so a reverted block always contains the same
+// transactions as when it was applied, and reverted diffs are kept exactly if
+// the applied diffs were kept.
+type ConsensusChangeFilter struct {
+	// SiacoinOutputs selects transactions with siacoin inputs or outputs,
+	// together with all siacoin output diffs and delayed siacoin output
+	// diffs.
+	SiacoinOutputs bool `json:"siacoinoutputs"`
+
+	// FileContracts, FileContractRevisions and StorageProofs select
+	// transactions that contain the respective element. Any of them also
+	// selects the file contract diffs.
+	FileContracts         bool `json:"filecontracts"`
+	FileContractRevisions bool `json:"filecontractrevisions"`
+	StorageProofs         bool `json:"storageproofs"`
+
+	// HostAnnouncements selects transactions with arbitrary data that is
+	// prefixed as a host announcement. The announcements aren't verified.
+	HostAnnouncements bool `json:"hostannouncements"`
+}
+
+// MatchesTransaction returns true if the transaction contains any of the
+// elements selected by the filter.
+func (f ConsensusChangeFilter) MatchesTransaction(txn types.Transaction) bool {
+	if f.SiacoinOutputs && (len(txn.SiacoinInputs) > 0 || len(txn.SiacoinOutputs) > 0) {
+		return true
+	}
+	if f.FileContracts && len(txn.FileContracts) > 0 {
+		return true
+	}
+	if f.FileContractRevisions && len(txn.FileContractRevisions) > 0 {
+		return true
+	}
+	if f.StorageProofs && len(txn.StorageProofs) > 0 {
+		return true
+	}
+	if f.HostAnnouncements {
+		var prefix types.Specifier
+		for _, arb := range txn.ArbitraryData {
+			copy(prefix[:], arb)
+			if prefix == PrefixHostAnnouncement {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterBlock returns a copy of the block that only contains the transactions
+// matched by the filter. The header and miner payouts are kept, but the id of
+// the copy differs from the id of the block if any transaction was removed.
+func (f ConsensusChangeFilter) filterBlock(b types.Block) types.Block {
+	txns := b.Transactions
+	b.Transactions = nil
+	for _, txn := range txns {
+		if f.MatchesTransaction(txn) {
+			b.Transactions = append(b.Transactions, txn)
+		}
+	}
+	return b
+}
+
+// Apply returns a copy of the consensus change that only contains the
+// transactions and diffs selected by the filter. The id of the change and the
+// headers of the reverted and applied blocks are always kept. Since removing
+// transactions changes the ids of the blocks, the original ids are passed in
+// RevertedBlockIDs and AppliedBlockIDs, so a subscriber can still track the
+// chain and resume its subscription.
+func (f ConsensusChangeFilter) Apply(cc ConsensusChange) ConsensusChange {
+	filtered := cc
+	filtered.RevertedBlocks = make([]types.Block, 0, len(cc.RevertedBlocks))
+	filtered.RevertedBlockIDs = make([]types.BlockID, 0, len(cc.RevertedBlocks))
+	for _, b := range cc.RevertedBlocks {
+		filtered.RevertedBlocks = append(filtered.RevertedBlocks, f.filterBlock(b))
+		filtered.RevertedBlockIDs = append(filtered.RevertedBlockIDs, b.ID())
+	}
+	filtered.AppliedBlocks = make([]types.Block, 0, len(cc.AppliedBlocks))
+	filtered.AppliedBlockIDs = make([]types.BlockID, 0, len(cc.AppliedBlocks))
+	for _, b := range cc.AppliedBlocks {
+		filtered.AppliedBlocks = append(filtered.AppliedBlocks, f.filterBlock(b))
+		filtered.AppliedBlockIDs = append(filtered.AppliedBlockIDs, b.ID())
+	}
+	if !f.SiacoinOutputs {
+		filtered.SiacoinOutputDiffs = nil
+		filtered.DelayedSiacoinOutputDiffs = nil
+	}
+	if !f.FileContracts && !f.FileContractRevisions && !f.StorageProofs {
+		filtered.FileContractDiffs = nil
+	}
+	return filtered
+}
@@ -0,0 +1,95 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestConsensusChangeFilterMatches checks that every element of the filter
+// selects the transactions containing it.
+func TestConsensusChangeFilterMatches(t *testing.T) {
+	announcement := append(PrefixHostAnnouncement[:], []byte("announcement")...)
+	txns := map[string]types.Transaction{
+		"siacoin":      {SiacoinOutputs: []types.SiacoinOutput{{}}},
+		"contract":     {FileContracts: []types.FileContract{{}}},
+		"revision":     {FileContractRevisions: []types.FileContractRevision{{}}},
+		"proof":        {StorageProofs: []types.StorageProof{{}}},
+		"announcement": {ArbitraryData: [][]byte{announcement}},
+		"arbitrary":    {ArbitraryData: [][]byte{[]byte("arbitrary")}},
+	}
+	tests := []struct {
+		filter  ConsensusChangeFilter
+		matches string
+	}{
+		{ConsensusChangeFilter{SiacoinOutputs: true}, "siacoin"},
+		{ConsensusChangeFilter{FileContracts: true}, "contract"},
+		{ConsensusChangeFilter{FileContractRevisions: true}, "revision"},
+		{ConsensusChangeFilter{StorageProofs: true}, "proof"},
+		{ConsensusChangeFilter{HostAnnouncements: true}, "announcement"},
+	}
+	for _, test := range tests {
+		for name, txn := range txns {
+			if test.filter.MatchesTransaction(txn) != (name == test.matches) {
+				t.Errorf("filter %+v matched %v: %v", test.filter, name, !(name == test.matches))
+			}
+		}
+	}
+}
+
+// TestConsensusChangeFilterApply checks that Apply keeps only the selected
+// transactions and diffs, treats reverted blocks like applied ones and passes
+// on the original block ids.
+func TestConsensusChangeFilterApply(t *testing.T) {
+	announcement := append(PrefixHostAnnouncement[:], []byte("announcement")...)
+	revision := types.Transaction{FileContractRevisions: []types.FileContractRevision{{}}}
+	b := types.Block{
+		ParentID: types.BlockID{1},
+		Transactions: []types.Transaction{
+			{SiacoinOutputs: []types.SiacoinOutput{{}}},
+			revision,
+			{ArbitraryData: [][]byte{announcement}},
+		},
+	}
+	cc := ConsensusChange{
+		ID:                 ConsensusChangeID{2},
+		RevertedBlocks:     []types.Block{b},
+		AppliedBlocks:      []types.Block{b},
+		SiacoinOutputDiffs: []SiacoinOutputDiff{{}},
+		FileContractDiffs:  []FileContractDiff{{}},
+	}
+
+	filtered := ConsensusChangeFilter{FileContractRevisions: true}.Apply(cc)
+	if filtered.ID != cc.ID {
+		t.Fatal("filtered change has a different id")
+	}
+	if len(filtered.SiacoinOutputDiffs) != 0 || len(filtered.FileContractDiffs) != 1 {
+		t.Fatal("wrong diffs were kept", filtered.SiacoinOutputDiffs, filtered.FileContractDiffs)
+	}
+	for _, blocks := range [][]types.Block{filtered.RevertedBlocks, filtered.AppliedBlocks} {
+		if len(blocks) != 1 || len(blocks[0].Transactions) != 1 || blocks[0].Transactions[0].ID() != revision.ID() {
+			t.Fatal("wrong transactions were kept", blocks)
+		}
+		if blocks[0].ParentID != b.ParentID {
+			t.Fatal("header of the block wasn't kept")
+		}
+	}
+	if len(filtered.RevertedBlockIDs) != 1 || filtered.RevertedBlockIDs[0] != b.ID() {
+		t.Fatal("wrong reverted block ids", filtered.RevertedBlockIDs)
+	}
+	if len(filtered.AppliedBlockIDs) != 1 || filtered.AppliedBlockIDs[0] != b.ID() {
+		t.Fatal("wrong applied block ids", filtered.AppliedBlockIDs)
+	}
+	if len(cc.AppliedBlocks[0].Transactions) != 3 {
+		t.Fatal("Apply modified the original change")
+	}
+
+	// A filter that selects nothing still delivers the blocks and their ids.
+	filtered = ConsensusChangeFilter{}.Apply(cc)
+	if len(filtered.AppliedBlocks) != 1 || len(filtered.AppliedBlocks[0].Transactions) != 0 || filtered.AppliedBlockIDs[0] != b.ID() {
+		t.Fatal("empty filter didn't deliver the bare block", filtered.AppliedBlocks)
+	}
+	if len(filtered.SiacoinOutputDiffs) != 0 || len(filtered.FileContractDiffs) != 0 {
+		t.Fatal("empty filter kept diffs")
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/HyperspaceApp/Hyperspace/build"
 	"github.com/HyperspaceApp/Hyperspace/persist"
+	"github.com/HyperspaceApp/Hyperspace/types"
 	"github.com/HyperspaceApp/fastrand"
 )
 
@@ -50,6 +51,11 @@ type (
 		// forcibly triggered. In production, disrupt will always return false.
 		Disrupt(string) bool
 
+		// HostWeightFunc returns a custom function that weighs the hosts of
+		// the hostdb when selecting hosts at random. If it returns nil, the
+		// hostdb's default weighting is used.
+		HostWeightFunc() HostWeightFunc
+
 		// Listen gives the host the ability to receive incoming connections.
 		Listen(string, string) (net.Listener, error)
 
@@ -361,6 +367,13 @@ type Resolver interface {
 	LookupIP(string) ([]net.IP, error)
 }
 
+// TXTResolver is a Resolver that can also look up the TXT records of a
+// hostname.
+type TXTResolver interface {
+	Resolver
+	LookupTXT(string) ([]string, error)
+}
+
 // ProductionResolver is the hostname resolver used in production builds.
 type ProductionResolver struct{}
 
@@ -375,7 +388,25 @@ func (ProductionResolver) LookupIP(host string) ([]net.IP, error) {
 	return net.LookupIP(host)
 }
 
+// LookupTXT is a passthrough function to net.LookupTXT. In testing builds it
+// returns no records.
+func (ProductionResolver) LookupTXT(host string) ([]string, error) {
+	if build.Release == "testing" {
+		return nil, nil
+	}
+	return net.LookupTXT(host)
+}
+
 // Resolver returns the ProductionResolver.
 func (*ProductionDependencies) Resolver() Resolver {
 	return ProductionResolver{}
 }
+
+// HostWeightFunc is a function that weighs a host of the hostdb. Hosts are
+// selected at random with a probability proportional to their weight.
+type HostWeightFunc func(HostDBEntry) types.Currency
+
+// HostWeightFunc returns nil, which selects the hostdb's default weighting.
+func (*ProductionDependencies) HostWeightFunc() HostWeightFunc {
+	return nil
+}
@@ -28,6 +28,11 @@ const (
 	RelayHeaderCmd = "RelayHeader"
 	// RelayTransactionSetCmd sends a transaction set to a peer.
 	RelayTransactionSetCmd = "RelayTransactionSet"
+	// RelayConnectCmd asks a node to forward our connection to one of its
+	// peers
+	RelayConnectCmd = "RelayConnect"
+	// RelayAcceptCmd asks a node to accept a connection forwarded by a relay
+	RelayAcceptCmd = "RelayAccept"
 )
 
 var (
@@ -70,6 +75,18 @@ var (
 		Testing: []NetAddress(nil),
 	}).([]NetAddress)
 
+	// DNSSeeds is a list of hostnames which resolve to the addresses of
+	// active peers, either through their A and AAAA records or through TXT
+	// records listing "host:port" addresses. They are looked up in addition
+	// to the BootstrapPeers so that new nodes can still find the network if
+	// the hardcoded peers go offline. A seed can optionally specify the port
+	// of its peers, e.g. "seed.example.com:5581".
+	DNSSeeds = build.Select(build.Var{
+		Standard: []string(nil),
+		Dev:      []string(nil),
+		Testing:  []string(nil),
+	}).([]string)
+
 	// SPVBootstrapPeers is the bootstrap nodes for spv
 	SPVBootstrapPeers = build.Select(build.Var{
 		Standard: []NetAddress{
@@ -94,14 +111,47 @@ var (
 )
 
 type (
-	// Peer contains all the info necessary to Broadcast to a peer.
+	// Peer contains all the info necessary to Broadcast to a peer. Relayed
+	// peers are connected through a relay and can't be dialed directly at
+	// their NetAddress.
 	Peer struct {
 		Inbound    bool       `json:"inbound"`
 		Local      bool       `json:"local"`
 		NetAddress NetAddress `json:"netaddress"`
+		Relayed    bool       `json:"relayed"`
 		Version    string     `json:"version"`
 	}
 
+	// GatewayRejectionStats counts the peers that were rejected because their
+	// version was below the minimum peer version of the gateway.
+	GatewayRejectionStats struct {
+		// CoolingDown is the number of peers the gateway won't connect to
+		// until their cooldown expires.
+		CoolingDown      int    `json:"coolingdown"`
+		RejectedInbound  uint64 `json:"rejectedinbound"`
+		RejectedOutbound uint64 `json:"rejectedoutbound"`
+	}
+
+	// GatewayPeerLimits are the number of peers the gateway connects to.
+	// MaxPeers is the number of peers at which inbound peers are kicked to
+	// make room for new ones, MaxOutboundPeers is the number of outbound
+	// peers at which the gateway stops forming new connections.
+	GatewayPeerLimits struct {
+		MaxPeers         int `json:"maxpeers"`
+		MaxOutboundPeers int `json:"maxoutboundpeers"`
+	}
+
+	// GatewayRelayStats reports how the gateway relayed objects to its peers.
+	// Fanout is the maximum number of peers an object is relayed to, 0 means
+	// that objects are relayed to every peer. DedupHits counts the relays that
+	// were skipped because the peer was sent the same object recently, which
+	// only happens if the fanout is set.
+	GatewayRelayStats struct {
+		Fanout         int    `json:"fanout"`
+		ObjectsRelayed uint64 `json:"objectsrelayed"`
+		DedupHits      uint64 `json:"deduphits"`
+	}
+
 	// A PeerConn is the connection type used when communicating with peers during
 	// an RPC. It is identical to a net.Conn with the additional RPCAddr method.
 	// This method acts as an identifier for peers and is the address that the
@@ -128,6 +178,10 @@ type (
 		// Connect establishes a persistent connection to a peer.
 		Connect(NetAddress) error
 
+		// ConnectRelayed establishes a persistent connection to a peer
+		// through a relay the gateway is connected to.
+		ConnectRelayed(addr, relay NetAddress) error
+
 		// Disconnect terminates a connection to a peer.
 		Disconnect(NetAddress) error
 
@@ -144,12 +198,34 @@ type (
 		// Address returns the Gateway's address.
 		Address() NetAddress
 
+		// MinPeerVersion returns the oldest version that peers may run.
+		MinPeerVersion() string
+
+		// PeerLimits returns the number of peers the gateway connects to.
+		PeerLimits() GatewayPeerLimits
+
 		// Peers returns the addresses that the Gateway is currently connected to.
 		Peers() []Peer
 
 		// RandomPeer returns a random peer currently connected to the Gateway.
 		RandomPeer() (Peer, error)
 
+		// RejectionStats returns the number of peers that were rejected
+		// because of their version.
+		RejectionStats() GatewayRejectionStats
+
+		// RelayConnections returns true if the gateway forwards connections
+		// between its peers.
+		RelayConnections() bool
+
+		// AcceptRelayed returns true if the gateway accepts connections that
+		// relays forward to it.
+		AcceptRelayed() bool
+
+		// RelayStats returns the relay fanout and the number of relayed and
+		// deduplicated objects.
+		RelayStats() GatewayRelayStats
+
 		// RegisterRPC registers a function to handle incoming connections that
 		// supply the given RPC ID.
 		RegisterRPC(string, RPCFunc)
@@ -174,13 +250,39 @@ type (
 		// address that the Gateway is not connected to.
 		RPC(NetAddress, string, RPCFunc) error
 
-		// Broadcast transmits obj, prefaced by the RPC name, to all of the
-		// given peers in parallel.
+		// Broadcast transmits obj, prefaced by the RPC name, to the given
+		// peers in parallel. If the relay fanout is set, peers that were sent
+		// the same object recently are skipped, and at most the relay fanout
+		// of peers is picked.
 		Broadcast(name string, obj interface{}, peers []Peer)
 
+		// Rebroadcast works like Broadcast, but doesn't skip the peers that
+		// were sent the same object recently.
+		Rebroadcast(name string, obj interface{}, peers []Peer)
+
 		// Online returns true if the gateway is connected to remote hosts
 		Online() bool
 
+		// SetMinPeerVersion sets the oldest version that peers may run.
+		// Connected peers with an older version are disconnected.
+		SetMinPeerVersion(version string) error
+
+		// SetPeerLimits sets the number of peers the gateway connects to. A
+		// limit of 0 resets it to the default.
+		SetPeerLimits(limits GatewayPeerLimits) error
+
+		// SetRelayConnections enables or disables forwarding connections
+		// between the peers of the gateway.
+		SetRelayConnections(relay bool) error
+
+		// SetAcceptRelayed enables or disables accepting connections that
+		// relays forward to the gateway.
+		SetAcceptRelayed(accept bool) error
+
+		// SetRelayFanout sets the maximum number of peers an object is
+		// relayed to. 0 relays objects to every peer.
+		SetRelayFanout(fanout int) error
+
 		// Close safely stops the Gateway's listener process.
 		Close() error
 	}
@@ -31,6 +31,10 @@ const (
 	minimumAcceptablePeerVersion = "0.1.1"
 
 	minimumSPVAcceptablePeerVersion = "0.2.1"
+
+	// dnsSeedDefaultPort is the port used for the peers discovered through a
+	// DNS seed which doesn't specify a port of its own.
+	dnsSeedDefaultPort = "5581"
 )
 
 var (
@@ -84,6 +88,42 @@ var (
 		Testing:  20 * time.Millisecond,
 	}).(time.Duration)
 
+	// peerRejectionCooldown defines how long the gateway waits before it
+	// tries to connect to a peer again after the peer was rejected because its
+	// version was below the minimum peer version.
+	peerRejectionCooldown = build.Select(build.Var{
+		Standard: 1 * time.Hour,
+		Dev:      5 * time.Minute,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// maxRelayedConns defines the maximum number of connections that the
+	// gateway forwards between its peers at the same time.
+	maxRelayedConns = build.Select(build.Var{
+		Standard: 8,
+		Dev:      4,
+		Testing:  2,
+	}).(int)
+
+	// maxRelayedPeers defines the maximum number of inbound peers that the
+	// gateway accepts through relays. Relayed peers are announced by the
+	// relay and their address can't be verified, so they only use slots that
+	// are kept apart from the direct peers.
+	maxRelayedPeers = build.Select(build.Var{
+		Standard: 4,
+		Dev:      2,
+		Testing:  1,
+	}).(int)
+
+	// relayDedupWindow defines how long the gateway remembers which peers
+	// were sent an object. If the same object is broadcast again within the
+	// window, the peers that already have it are skipped.
+	relayDedupWindow = build.Select(build.Var{
+		Standard: 10 * time.Minute,
+		Dev:      1 * time.Minute,
+		Testing:  1 * time.Second,
+	}).(time.Duration)
+
 	// pruneNodeListLen defines the number of nodes that the gateway must have
 	// to be pruning nodes from the node list.
 	pruneNodeListLen = build.Select(build.Var{
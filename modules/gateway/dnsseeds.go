@@ -0,0 +1,102 @@
+package gateway
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+// parseDNSSeedRecords returns the peer addresses listed in the TXT records of
+// a DNS seed. A record lists one or more addresses of the form "host:port",
+// separated by spaces or commas. Invalid addresses are skipped.
+func parseDNSSeedRecords(records []string) []modules.NetAddress {
+	var addrs []modules.NetAddress
+	for _, record := range records {
+		for _, field := range strings.Fields(strings.Replace(record, ",", " ", -1)) {
+			addr := modules.NetAddress(field)
+			if addr.IsStdValid() == nil {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs
+}
+
+// lookupDNSSeed returns the peer addresses of a DNS seed. The A and AAAA
+// records of the seed are combined with port, the TXT records are looked up
+// as well if the resolver supports it. An error is only returned if neither
+// lookup succeeded.
+func lookupDNSSeed(resolver modules.Resolver, host, port string) ([]modules.NetAddress, error) {
+	var addrs []modules.NetAddress
+	ips, ipErr := resolver.LookupIP(host)
+	for _, ip := range ips {
+		addrs = append(addrs, modules.NetAddress(net.JoinHostPort(ip.String(), port)))
+	}
+	txtResolver, ok := resolver.(modules.TXTResolver)
+	if !ok {
+		return addrs, ipErr
+	}
+	records, txtErr := txtResolver.LookupTXT(host)
+	addrs = append(addrs, parseDNSSeedRecords(records)...)
+	if ipErr != nil && txtErr != nil {
+		return nil, errors.Compose(ipErr, txtErr)
+	}
+	return addrs, nil
+}
+
+// resolveDNSSeeds looks up the peers behind every DNS seed and returns the
+// resulting set of peer addresses without duplicates. Seeds may specify a port
+// for the peers of their A and AAAA records, otherwise dnsSeedDefaultPort is
+// used. Seeds that can't be resolved are skipped and reported in the returned
+// error.
+func resolveDNSSeeds(resolver modules.Resolver, seeds []string) ([]modules.NetAddress, error) {
+	var addrs []modules.NetAddress
+	var errs error
+	seen := make(map[modules.NetAddress]struct{})
+	for _, seed := range seeds {
+		host, port, err := net.SplitHostPort(seed)
+		if err != nil {
+			host, port = seed, dnsSeedDefaultPort
+		}
+		seedAddrs, err := lookupDNSSeed(resolver, host, port)
+		if err != nil {
+			errs = errors.Compose(errs, errors.AddContext(err, fmt.Sprintf("unable to resolve DNS seed '%v'", seed)))
+			continue
+		}
+		for _, addr := range seedAddrs {
+			if _, exists := seen[addr]; exists {
+				continue
+			}
+			seen[addr] = struct{}{}
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs, errs
+}
+
+// threadedAddDNSSeedNodes resolves the DNS seeds of the gateway and adds the
+// discovered peers to the node list, next to the hardcoded bootstrap peers.
+func (g *Gateway) threadedAddDNSSeedNodes(seeds []string) {
+	if err := g.threads.Add(); err != nil {
+		return
+	}
+	defer g.threads.Done()
+
+	addrs, err := resolveDNSSeeds(g.staticDeps.Resolver(), seeds)
+	if err != nil {
+		g.log.Println("WARN: failed to resolve some DNS seeds:", err)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, addr := range addrs {
+		err := g.addNode(addr)
+		if err != nil && err != errNodeExists {
+			g.log.Printf("WARN: failed to add the DNS seed node '%v': %v", addr, err)
+		}
+	}
+}
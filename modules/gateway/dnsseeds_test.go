@@ -0,0 +1,127 @@
+package gateway
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+type (
+	// dnsSeedResolver is a resolver that serves a fixed set of DNS records.
+	dnsSeedResolver struct {
+		records    map[string][]net.IP
+		txtRecords map[string][]string
+	}
+
+	// dependencyDNSSeedResolver is a dependency which replaces the resolver
+	// of the gateway with a dnsSeedResolver.
+	dependencyDNSSeedResolver struct {
+		modules.ProductionDependencies
+		resolver dnsSeedResolver
+	}
+)
+
+// LookupIP implements the modules.Resolver interface.
+func (r dnsSeedResolver) LookupIP(host string) ([]net.IP, error) {
+	ips, exists := r.records[host]
+	if !exists {
+		return nil, errors.New("no such host")
+	}
+	return ips, nil
+}
+
+// LookupTXT implements the modules.TXTResolver interface.
+func (r dnsSeedResolver) LookupTXT(host string) ([]string, error) {
+	records, exists := r.txtRecords[host]
+	if !exists {
+		return nil, errors.New("no such host")
+	}
+	return records, nil
+}
+
+// Resolver returns the dnsSeedResolver of the dependency.
+func (d *dependencyDNSSeedResolver) Resolver() modules.Resolver {
+	return d.resolver
+}
+
+// TestResolveDNSSeeds checks that the addresses returned by the DNS seeds are
+// combined correctly.
+func TestResolveDNSSeeds(t *testing.T) {
+	resolver := dnsSeedResolver{
+		records: map[string][]net.IP{
+			"seed1.test": {net.ParseIP("1.2.3.4"), net.ParseIP("5.6.7.8")},
+			"seed2.test": {net.ParseIP("5.6.7.8"), net.ParseIP("2001:db8::1")},
+		},
+		txtRecords: map[string][]string{
+			"seed2.test": {"9.9.9.9:1234, 5.6.7.8:" + dnsSeedDefaultPort, "invalid"},
+			"seed3.test": {"[2001:db8::2]:1234"},
+		},
+	}
+
+	// A failing seed shouldn't prevent the other seeds from being used.
+	addrs, err := resolveDNSSeeds(resolver, []string{"seed1.test", "seed2.test", "seed1.test:9981", "seed3.test", "missing.test"})
+	if err == nil {
+		t.Fatal("expected an error for the missing seed")
+	}
+	expected := []modules.NetAddress{
+		"1.2.3.4:" + dnsSeedDefaultPort,
+		"5.6.7.8:" + dnsSeedDefaultPort,
+		"[2001:db8::1]:" + dnsSeedDefaultPort,
+		"9.9.9.9:1234",
+		"1.2.3.4:9981",
+		"5.6.7.8:9981",
+		"[2001:db8::2]:1234",
+	}
+	if len(addrs) != len(expected) {
+		t.Fatalf("expected %v addresses but got %v: %v", len(expected), len(addrs), addrs)
+	}
+	for i := range addrs {
+		if addrs[i] != expected[i] {
+			t.Errorf("expected address %v to be %v but was %v", i, expected[i], addrs[i])
+		}
+	}
+
+	// Without any seeds there are no addresses and no error.
+	addrs, err = resolveDNSSeeds(resolver, nil)
+	if err != nil || len(addrs) != 0 {
+		t.Fatal("expected no addresses and no error", addrs, err)
+	}
+}
+
+// TestDNSSeedBootstrap checks that a bootstrapping gateway adds the peers of
+// its DNS seeds to the node list.
+func TestDNSSeedBootstrap(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	deps := &dependencyDNSSeedResolver{
+		resolver: dnsSeedResolver{records: map[string][]net.IP{
+			"seed.test": {net.ParseIP("1.2.3.4"), net.ParseIP("5.6.7.8")},
+		}},
+	}
+	g, err := NewCustomGateway("localhost:0", true, build.TempDir("gateway", t.Name()), false, []string{"seed.test"}, deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		g.mu.RLock()
+		defer g.mu.RUnlock()
+		for _, addr := range []modules.NetAddress{"1.2.3.4:" + dnsSeedDefaultPort, "5.6.7.8:" + dnsSeedDefaultPort} {
+			if _, exists := g.nodes[addr]; !exists {
+				return errors.New("DNS seed node missing from node list: " + string(addr))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
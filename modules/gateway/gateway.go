@@ -14,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/HyperspaceApp/Hyperspace/crypto"
 	"github.com/HyperspaceApp/Hyperspace/modules"
 	"github.com/HyperspaceApp/Hyperspace/persist"
 
@@ -143,6 +144,39 @@ type Gateway struct {
 	peers  map[modules.NetAddress]*peer
 	peerTG siasync.ThreadGroup
 
+	// minPeerVersion is the oldest version that peers may run. It can be
+	// raised above minimumAcceptablePeerVersion after a network upgrade.
+	//
+	// rejectedPeers maps the addresses of outbound peers that were rejected
+	// because of their version to the time at which the gateway may connect
+	// to them again. rejectionStats counts the rejections for diagnostics.
+	minPeerVersion string
+	rejectedPeers  map[modules.NetAddress]time.Time
+	rejectionStats modules.GatewayRejectionStats
+
+	// maxPeers and maxOutboundPeers override fullyConnectedThreshold and
+	// wellConnectedThreshold if they are set.
+	maxPeers         int
+	maxOutboundPeers int
+
+	// relayFanout is the maximum number of peers that Broadcast relays an
+	// object to, 0 relays to every peer.
+	//
+	// relayedObjects remembers which peers an object was relayed to recently,
+	// so that the same object isn't sent to a peer twice. relayStats counts
+	// the relays for diagnostics.
+	relayFanout    int
+	relayedObjects map[crypto.Hash]*relayedObject
+	relayStats     modules.GatewayRelayStats
+
+	// relayConns enables forwarding connections between peers that can't
+	// dial each other directly. relayedConns is the number of connections
+	// that are currently forwarded. acceptRelayed enables accepting
+	// connections that other relays forward to the gateway.
+	acceptRelayed bool
+	relayConns    bool
+	relayedConns  int
+
 	// Utilities.
 	log        *persist.Logger
 	mu         sync.RWMutex
@@ -151,6 +185,8 @@ type Gateway struct {
 
 	spv bool
 
+	staticDeps modules.Dependencies
+
 	// Unique ID
 	staticId gatewayID
 }
@@ -206,6 +242,12 @@ func (g *Gateway) ForwardPort(port string) error {
 
 // New returns an initialized Gateway.
 func New(addr string, bootstrap bool, persistDir string, spv bool) (*Gateway, error) {
+	return NewCustomGateway(addr, bootstrap, persistDir, spv, modules.DNSSeeds, modules.ProdDependencies)
+}
+
+// NewCustomGateway returns an initialized Gateway which uses the provided DNS
+// seeds and dependencies.
+func NewCustomGateway(addr string, bootstrap bool, persistDir string, spv bool, dnsSeeds []string, deps modules.Dependencies) (*Gateway, error) {
 	// Create the directory if it doesn't exist.
 	err := os.MkdirAll(persistDir, 0700)
 	if err != nil {
@@ -219,9 +261,14 @@ func New(addr string, bootstrap bool, persistDir string, spv bool) (*Gateway, er
 		nodes: make(map[modules.NetAddress]*node),
 		peers: make(map[modules.NetAddress]*peer),
 
+		minPeerVersion: minimumAcceptablePeerVersion,
+		rejectedPeers:  make(map[modules.NetAddress]time.Time),
+		relayedObjects: make(map[crypto.Hash]*relayedObject),
+
 		spv: spv,
 
 		persistDir: persistDir,
+		staticDeps: deps,
 	}
 
 	// Set Unique GatewayID
@@ -254,11 +301,15 @@ func New(addr string, bootstrap bool, persistDir string, spv bool) (*Gateway, er
 	// Register RPCs.
 	g.RegisterRPC(modules.ShareNodesCmd, g.shareNodes)
 	g.RegisterRPC(modules.DiscoverIPCmd, g.discoverPeerIP)
+	g.RegisterRPC(modules.RelayConnectCmd, g.relayConnect)
+	g.RegisterRPC(modules.RelayAcceptCmd, g.relayAccept)
 	g.RegisterConnectCall(modules.ShareNodesCmd, g.requestNodes)
 	// Establish the de-registration of the RPCs.
 	g.threads.OnStop(func() {
 		g.UnregisterRPC(modules.ShareNodesCmd)
 		g.UnregisterRPC(modules.DiscoverIPCmd)
+		g.UnregisterRPC(modules.RelayConnectCmd)
+		g.UnregisterRPC(modules.RelayAcceptCmd)
 		g.UnregisterConnectCall(modules.ShareNodesCmd)
 	})
 
@@ -296,6 +347,11 @@ func New(addr string, bootstrap bool, persistDir string, spv bool) (*Gateway, er
 				}
 			}
 		}
+		// Look up additional bootstrap peers in the background, resolving the
+		// seeds can take a while.
+		if len(dnsSeeds) > 0 {
+			go g.threadedAddDNSSeedNodes(dnsSeeds)
+		}
 	}
 
 	// Create the listener which will listen for new connections from peers.
@@ -46,8 +46,14 @@ func (g *Gateway) managedIPFromPeers(cancel <-chan struct{}) (string, error) {
 			return "", errors.New("failed to discover ip in time")
 		default:
 		}
-		// Get peers
-		peers := g.Peers()
+		// Get peers. Relayed peers see the address of the relay instead of
+		// ours, so they aren't asked.
+		var peers []modules.Peer
+		for _, peer := range g.Peers() {
+			if !peer.Relayed {
+				peers = append(peers, peer)
+			}
+		}
 		// Check if there are enough peers. Otherwise wait.
 		if len(peers) < minPeersForIPDiscovery {
 			g.managedSleep(peerDiscoveryRetryInterval)
@@ -0,0 +1,62 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+var (
+	// errNegativePeerLimit is returned when a peer limit is set to a negative
+	// number of peers.
+	errNegativePeerLimit = errors.New("peer limits can't be negative")
+)
+
+// peerLimits returns the peer limits of the gateway, using the defaults for the
+// limits that aren't set.
+func (g *Gateway) peerLimits() modules.GatewayPeerLimits {
+	limits := modules.GatewayPeerLimits{
+		MaxPeers:         fullyConnectedThreshold,
+		MaxOutboundPeers: wellConnectedThreshold,
+	}
+	if g.maxPeers > 0 {
+		limits.MaxPeers = g.maxPeers
+	}
+	if g.maxOutboundPeers > 0 {
+		limits.MaxOutboundPeers = g.maxOutboundPeers
+	}
+	return limits
+}
+
+// PeerLimits returns the number of peers the gateway connects to.
+func (g *Gateway) PeerLimits() modules.GatewayPeerLimits {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.peerLimits()
+}
+
+// SetPeerLimits sets the number of peers the gateway connects to. A limit of 0
+// resets it to the default. Peers aren't disconnected if the limits are
+// lowered. New inbound peers replace existing inbound peers while the gateway
+// has too many peers, and no outbound connections are formed until the
+// gateway is below the outbound limit.
+func (g *Gateway) SetPeerLimits(limits modules.GatewayPeerLimits) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+
+	if limits.MaxPeers < 0 || limits.MaxOutboundPeers < 0 {
+		return errNegativePeerLimit
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	oldMax, oldOutbound := g.maxPeers, g.maxOutboundPeers
+	g.maxPeers, g.maxOutboundPeers = limits.MaxPeers, limits.MaxOutboundPeers
+	if effective := g.peerLimits(); effective.MaxOutboundPeers > effective.MaxPeers {
+		g.maxPeers, g.maxOutboundPeers = oldMax, oldOutbound
+		return fmt.Errorf("maximum of %v outbound peers exceeds the maximum of %v peers", effective.MaxOutboundPeers, effective.MaxPeers)
+	}
+	return g.saveSync()
+}
@@ -0,0 +1,69 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// TestSetPeerLimits checks that invalid peer limits are rejected, that a limit
+// of 0 resets it to the default and that the limits are persisted.
+func TestSetPeerLimits(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g := newTestingGateway(t)
+	defer g.Close()
+
+	defaults := modules.GatewayPeerLimits{
+		MaxPeers:         fullyConnectedThreshold,
+		MaxOutboundPeers: wellConnectedThreshold,
+	}
+	if limits := g.PeerLimits(); limits != defaults {
+		t.Fatal("expected the default limits, got", limits)
+	}
+	if err := g.SetPeerLimits(modules.GatewayPeerLimits{MaxPeers: -1}); err != errNegativePeerLimit {
+		t.Fatal("expected errNegativePeerLimit, got", err)
+	}
+	// The outbound peers count towards the maximum, including the default
+	// maximum if only the outbound limit is set.
+	if err := g.SetPeerLimits(modules.GatewayPeerLimits{MaxPeers: 4, MaxOutboundPeers: 5}); err == nil {
+		t.Fatal("outbound limit above the maximum should be rejected")
+	}
+	if err := g.SetPeerLimits(modules.GatewayPeerLimits{MaxOutboundPeers: fullyConnectedThreshold + 1}); err == nil {
+		t.Fatal("outbound limit above the default maximum should be rejected")
+	}
+	if limits := g.PeerLimits(); limits != defaults {
+		t.Fatal("rejected limits were applied", limits)
+	}
+
+	limits := modules.GatewayPeerLimits{MaxPeers: 3, MaxOutboundPeers: 2}
+	if err := g.SetPeerLimits(limits); err != nil {
+		t.Fatal(err)
+	}
+	if g.PeerLimits() != limits {
+		t.Fatal("limits weren't applied", g.PeerLimits())
+	}
+
+	// The limits are persisted.
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	g, err := New("localhost:0", false, g.persistDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if g.PeerLimits() != limits {
+		t.Fatal("limits weren't persisted", g.PeerLimits())
+	}
+
+	// Only the outbound limit is reset.
+	if err := g.SetPeerLimits(modules.GatewayPeerLimits{MaxPeers: limits.MaxPeers + wellConnectedThreshold}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := (modules.GatewayPeerLimits{MaxPeers: limits.MaxPeers + wellConnectedThreshold, MaxOutboundPeers: wellConnectedThreshold}); g.PeerLimits() != expected {
+		t.Fatal("outbound limit wasn't reset", g.PeerLimits())
+	}
+}
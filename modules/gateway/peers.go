@@ -153,10 +153,12 @@ func (g *Gateway) threadedAcceptConn(conn net.Conn) {
 		return
 	}
 
-	if build.VersionCmp(remoteVersion, minimumAcceptablePeerVersion) >= 0 {
-		err = g.managedAcceptConnPeer(conn, remoteVersion)
-	} else {
+	if build.VersionCmp(remoteVersion, minimumAcceptablePeerVersion) < 0 {
 		err = errors.New("version number is below threshold")
+	} else if err = g.managedCheckPeerVersion(addr, remoteVersion, true); err != nil {
+		rejectRemoteHeader(conn, err)
+	} else {
+		err = g.managedAcceptConnPeer(conn, remoteVersion)
 	}
 	if err != nil {
 		g.log.Debugf("INFO: %v wanted to connect, but failed: %v", addr, err)
@@ -250,7 +252,7 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 // peers, then adds the peer to the peer list.
 func (g *Gateway) acceptPeer(p *peer) {
 	// If we are not fully connected, add the peer without kicking any out.
-	if len(g.peers) < fullyConnectedThreshold {
+	if len(g.peers) < g.peerLimits().MaxPeers {
 		g.addPeer(p)
 		return
 	}
@@ -423,6 +425,9 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 	if exists {
 		return errPeerExists
 	}
+	if g.managedPeerCoolingDown(addr) {
+		return errPeerCoolingDown
+	}
 
 	// Dial the peer and perform peer initialization.
 	conn, err := g.staticDial(addr)
@@ -441,10 +446,10 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 		return fmt.Errorf("spv require higher version: %s < %s", remoteVersion, minimumSPVAcceptablePeerVersion)
 	}
 
-	if build.VersionCmp(remoteVersion, minimumAcceptablePeerVersion) >= 0 {
-		err = g.managedConnectPeer(conn, remoteVersion, addr)
-	} else {
+	if build.VersionCmp(remoteVersion, minimumAcceptablePeerVersion) < 0 {
 		err = errors.New("version number is below threshold")
+	} else if err = g.managedCheckPeerVersion(addr, remoteVersion, false); err == nil {
+		err = g.managedConnectPeer(conn, remoteVersion, addr)
 	}
 	if err != nil {
 		conn.Close()
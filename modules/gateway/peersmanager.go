@@ -54,10 +54,11 @@ func (g *Gateway) managedPeerManagerConnect(addr modules.NetAddress) {
 }
 
 // numOutboundPeers returns the number of outbound peers in the gateway.
+// Relayed peers are not counted, the gateway can't dial them directly.
 func (g *Gateway) numOutboundPeers() int {
 	n := 0
 	for _, p := range g.peers {
-		if !p.Inbound {
+		if !p.Inbound && !p.Relayed {
 			n++
 		}
 	}
@@ -95,9 +96,10 @@ func (g *Gateway) permanentPeerManager(closedChan chan struct{}) {
 			// Break as soon as we have enough outbound peers.
 			g.mu.RLock()
 			numOutboundPeers := g.numOutboundPeers()
+			maxOutboundPeers := g.peerLimits().MaxOutboundPeers
 			isOutboundPeer := g.peers[addr] != nil && !g.peers[addr].Inbound
 			g.mu.RUnlock()
-			if numOutboundPeers >= wellConnectedThreshold {
+			if numOutboundPeers >= maxOutboundPeers {
 				g.log.Debugln("INFO: [PPM] Gateway has enough peers, sleeping.")
 				if !g.managedSleep(wellConnectedDelay) {
 					return
@@ -0,0 +1,143 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/encoding"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+var (
+	// errPeerCoolingDown is returned when connecting to a peer that was
+	// rejected recently because its version was too old.
+	errPeerCoolingDown = errors.New("peer was recently rejected because of its version")
+)
+
+// belowMinimumVersionError indicates that a peer's version is acceptable for
+// the protocol but below the minimum peer version of the gateway.
+type belowMinimumVersionError struct {
+	version string
+	minimum string
+}
+
+// Error implements the error interface for belowMinimumVersionError.
+func (e belowMinimumVersionError) Error() string {
+	return fmt.Sprintf("peer version %v is below the minimum peer version %v", e.version, e.minimum)
+}
+
+// managedCheckPeerVersion returns an error if the version of a peer is below
+// the minimum peer version. Rejected outbound peers are put into a cooldown,
+// during which the gateway doesn't try to connect to them again. Inbound
+// peers connect from an ephemeral port, so they are only counted.
+func (g *Gateway) managedCheckPeerVersion(addr modules.NetAddress, version string, inbound bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if build.VersionCmp(version, g.minPeerVersion) >= 0 {
+		return nil
+	}
+
+	if inbound {
+		g.rejectionStats.RejectedInbound++
+		return belowMinimumVersionError{version: version, minimum: g.minPeerVersion}
+	}
+
+	// Expired cooldowns are removed whenever a peer is rejected, which keeps
+	// the map from growing without bounds.
+	now := time.Now()
+	for rejected, expiry := range g.rejectedPeers {
+		if now.After(expiry) {
+			delete(g.rejectedPeers, rejected)
+		}
+	}
+	g.rejectedPeers[addr] = now.Add(peerRejectionCooldown)
+	g.rejectionStats.RejectedOutbound++
+	return belowMinimumVersionError{version: version, minimum: g.minPeerVersion}
+}
+
+// managedPeerCoolingDown returns true if the peer was rejected because of its
+// version and its cooldown hasn't expired yet.
+func (g *Gateway) managedPeerCoolingDown(addr modules.NetAddress) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	expiry, exists := g.rejectedPeers[addr]
+	return exists && time.Now().Before(expiry)
+}
+
+// rejectRemoteHeader reads the session header of a connecting peer and
+// answers it with the reason for rejecting the connection. The connecting
+// peer reports the reason as the error of its header exchange.
+func rejectRemoteHeader(conn net.Conn, reason error) {
+	var remoteHeader sessionHeader
+	if err := encoding.ReadObject(conn, &remoteHeader, maxEncodedSessionHeaderSize); err != nil {
+		return
+	}
+	encoding.WriteObject(conn, reason.Error()) // error can be ignored
+}
+
+// MinPeerVersion returns the oldest version that peers of the gateway may run.
+func (g *Gateway) MinPeerVersion() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.minPeerVersion
+}
+
+// RejectionStats returns the number of peers that were rejected because their
+// version was below the minimum peer version.
+func (g *Gateway) RejectionStats() modules.GatewayRejectionStats {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	stats := g.rejectionStats
+	now := time.Now()
+	for _, expiry := range g.rejectedPeers {
+		if now.Before(expiry) {
+			stats.CoolingDown++
+		}
+	}
+	return stats
+}
+
+// SetMinPeerVersion sets the oldest version that peers of the gateway may run.
+// The version can't be lower than the oldest version the protocol supports,
+// an empty version resets it to that version. Peers that are already
+// connected and run an older version are disconnected. Their addresses remain
+// in the node list, but they will be rejected until they upgrade.
+func (g *Gateway) SetMinPeerVersion(version string) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+
+	if version == "" {
+		version = minimumAcceptablePeerVersion
+	}
+	if !build.IsVersion(version) {
+		return invalidVersionError(version)
+	}
+	if build.VersionCmp(version, minimumAcceptablePeerVersion) < 0 {
+		return fmt.Errorf("minimum peer version can't be lower than %v", minimumAcceptablePeerVersion)
+	}
+
+	g.mu.Lock()
+	g.minPeerVersion = version
+	// Cooldowns were based on the old minimum.
+	g.rejectedPeers = make(map[modules.NetAddress]time.Time)
+	var outdated []*peer
+	for addr, p := range g.peers {
+		if build.VersionCmp(p.Version, version) < 0 {
+			outdated = append(outdated, p)
+			delete(g.peers, addr)
+		}
+	}
+	err := g.saveSync()
+	g.mu.Unlock()
+
+	for _, p := range outdated {
+		p.sess.Close()
+		g.log.Printf("INFO: disconnected from %v, its version %v is below the minimum peer version %v", p.NetAddress, p.Version, version)
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package gateway
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestMinPeerVersion checks that peers below the minimum peer version are
+// rejected in both directions, that rejected peers aren't dialed again during
+// their cooldown and that the minimum is persisted.
+func TestMinPeerVersion(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer g1.Close()
+	g2 := newNamedTestingGateway(t, "2")
+	defer g2.Close()
+
+	if err := g1.SetMinPeerVersion("0.0.1"); err == nil {
+		t.Fatal("minimum below the protocol minimum should be rejected")
+	}
+	if err := g1.SetMinPeerVersion("foo"); err == nil {
+		t.Fatal("invalid version should be rejected")
+	}
+	if err := g1.SetMinPeerVersion("99.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	// An inbound peer learns why it was rejected.
+	err := g2.Connect(g1.Address())
+	if err == nil || !strings.Contains(err.Error(), "below the minimum peer version") {
+		t.Fatal("expected the connection to be rejected because of the version, got", err)
+	}
+	// An outbound peer is put into a cooldown.
+	if err := g1.Connect(g2.Address()); err == nil {
+		t.Fatal("g1 shouldn't connect to a peer below its minimum version")
+	}
+	if err := g1.Connect(g2.Address()); err != errPeerCoolingDown {
+		t.Fatal("expected errPeerCoolingDown, got", err)
+	}
+	stats := g1.RejectionStats()
+	if stats.RejectedInbound != 1 || stats.RejectedOutbound != 1 || stats.CoolingDown != 1 {
+		t.Fatal("unexpected rejection stats", stats)
+	}
+	if len(g1.Peers()) != 0 || len(g2.Peers()) != 0 {
+		t.Fatal("rejected peers shouldn't be connected")
+	}
+
+	// The minimum is persisted.
+	if err := g1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	g1, err = New("localhost:0", false, g1.persistDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g1.Close()
+	if g1.MinPeerVersion() != "99.0.0" {
+		t.Fatal("minimum peer version wasn't persisted", g1.MinPeerVersion())
+	}
+
+	// Resetting the minimum allows the peers to connect again.
+	if err := g1.SetMinPeerVersion(""); err != nil {
+		t.Fatal(err)
+	}
+	if g1.MinPeerVersion() != minimumAcceptablePeerVersion {
+		t.Fatal("minimum peer version wasn't reset", g1.MinPeerVersion())
+	}
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Raising the minimum disconnects the peer.
+	if err := g1.SetMinPeerVersion("99.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if len(g1.Peers()) != 0 {
+		t.Fatal("outdated peer should have been disconnected")
+	}
+}
@@ -1,6 +1,7 @@
 package gateway
 
 import (
+	"os"
 	"path/filepath"
 	"time"
 
@@ -14,6 +15,10 @@ const (
 
 	// nodesFile is the name of the file that contains all seen nodes.
 	nodesFile = "nodes.json"
+
+	// settingsFile is the name of the file that contains the settings of the
+	// gateway.
+	settingsFile = "settings.json"
 )
 
 // persistMetadata contains the header and version strings that identify the
@@ -23,6 +28,25 @@ var persistMetadata = persist.Metadata{
 	Version: "0.2.0",
 }
 
+// settingsMetadata contains the header and version strings that identify the
+// gateway settings file.
+var settingsMetadata = persist.Metadata{
+	Header:  "Gateway Settings",
+	Version: "0.2.2",
+}
+
+// gatewaySettings contains the settings of the Gateway which are saved to
+// disk. They are kept separate from the node list to keep the format of the
+// node list unchanged.
+type gatewaySettings struct {
+	AcceptRelayed    bool   `json:"acceptrelayed"`
+	MaxOutboundPeers int    `json:"maxoutboundpeers"`
+	MaxPeers         int    `json:"maxpeers"`
+	MinPeerVersion   string `json:"minpeerversion"`
+	RelayConnections bool   `json:"relayconnections"`
+	RelayFanout      int    `json:"relayfanout"`
+}
+
 // persistData returns the data in the Gateway that will be saved to disk.
 func (g *Gateway) persistData() (nodes []*node) {
 	for _, node := range g.nodes {
@@ -38,12 +62,38 @@ func (g *Gateway) load() error {
 	for i := range nodes {
 		g.nodes[nodes[i].NetAddress] = nodes[i]
 	}
+	var settings gatewaySettings
+	err := persist.LoadJSON(settingsMetadata, &settings, filepath.Join(g.persistDir, settingsFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if settings.MinPeerVersion != "" {
+		g.minPeerVersion = settings.MinPeerVersion
+	}
+	if settings.RelayFanout > 0 {
+		g.relayFanout = settings.RelayFanout
+	}
+	g.relayConns = settings.RelayConnections
+	g.acceptRelayed = settings.AcceptRelayed
+	g.maxPeers = settings.MaxPeers
+	g.maxOutboundPeers = settings.MaxOutboundPeers
 	return nil
 }
 
 // saveSync stores the Gateway's persistent data on disk, and then syncs to
 // disk to minimize the possibility of data loss.
 func (g *Gateway) saveSync() error {
+	settings := gatewaySettings{
+		AcceptRelayed:    g.acceptRelayed,
+		MaxOutboundPeers: g.maxOutboundPeers,
+		MaxPeers:         g.maxPeers,
+		MinPeerVersion:   g.minPeerVersion,
+		RelayConnections: g.relayConns,
+		RelayFanout:      g.relayFanout,
+	}
+	if err := persist.SaveJSON(settingsMetadata, settings, filepath.Join(g.persistDir, settingsFile)); err != nil {
+		return err
+	}
 	return persist.SaveJSON(persistMetadata, g.persistData(), filepath.Join(g.persistDir, nodesFile))
 }
 
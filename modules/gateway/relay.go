@@ -0,0 +1,130 @@
+package gateway
+
+import (
+	"errors"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+var (
+	// errNegativeRelayFanout is returned when the relay fanout is set to a
+	// negative number of peers.
+	errNegativeRelayFanout = errors.New("relay fanout can't be negative")
+)
+
+// relayedObject records the peers that an object was relayed to.
+type relayedObject struct {
+	peers  map[modules.NetAddress]struct{}
+	expiry time.Time
+}
+
+// relayID identifies an object that is relayed by Broadcast. The RPC name is
+// included because the same encoding may be relayed through different RPCs.
+func relayID(name string, enc []byte) crypto.Hash {
+	return crypto.HashAll(name, enc)
+}
+
+// managedRelayPeers returns the peers that an object should be relayed to.
+// If the relay fanout is set, peers that were sent the object within
+// relayDedupWindow are skipped unless dedup is false, and if there are more
+// remaining peers than the relay fanout, a random subset is picked. The
+// returned peers are marked as having received the object; peers that can't
+// be reached should be unmarked using managedUnmarkRelayed. Without a fanout
+// every peer is returned, since objects are always sent to all peers anyway.
+func (g *Gateway) managedRelayPeers(id crypto.Hash, peers []modules.Peer, dedup bool) []modules.NetAddress {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.relayFanout == 0 {
+		addrs := make([]modules.NetAddress, 0, len(peers))
+		for _, p := range peers {
+			addrs = append(addrs, p.NetAddress)
+		}
+		return addrs
+	}
+
+	// Expired objects are removed whenever an object is relayed, which keeps
+	// the map from growing without bounds.
+	now := time.Now()
+	for oid, obj := range g.relayedObjects {
+		if now.After(obj.expiry) {
+			delete(g.relayedObjects, oid)
+		}
+	}
+	obj, exists := g.relayedObjects[id]
+	if !exists {
+		obj = &relayedObject{peers: make(map[modules.NetAddress]struct{})}
+		g.relayedObjects[id] = obj
+	}
+	obj.expiry = now.Add(relayDedupWindow)
+
+	var addrs []modules.NetAddress
+	for _, p := range peers {
+		if _, sent := obj.peers[p.NetAddress]; sent && dedup {
+			g.relayStats.DedupHits++
+			continue
+		}
+		addrs = append(addrs, p.NetAddress)
+	}
+	if len(addrs) > g.relayFanout {
+		subset := make([]modules.NetAddress, g.relayFanout)
+		for i, j := range fastrand.Perm(len(addrs))[:g.relayFanout] {
+			subset[i] = addrs[j]
+		}
+		addrs = subset
+	}
+	for _, addr := range addrs {
+		obj.peers[addr] = struct{}{}
+	}
+	return addrs
+}
+
+// managedUnmarkRelayed removes a peer from the peers that an object was
+// relayed to, so that the object is relayed to it again next time.
+func (g *Gateway) managedUnmarkRelayed(id crypto.Hash, addr modules.NetAddress) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if obj, exists := g.relayedObjects[id]; exists {
+		delete(obj.peers, addr)
+	}
+}
+
+// managedCountRelayed counts an object that was relayed to a peer.
+func (g *Gateway) managedCountRelayed() {
+	g.mu.Lock()
+	g.relayStats.ObjectsRelayed++
+	g.mu.Unlock()
+}
+
+// RelayStats returns the relay fanout of the gateway together with the number
+// of objects that were relayed and the number of relays that were skipped
+// because the peer already had the object.
+func (g *Gateway) RelayStats() modules.GatewayRelayStats {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	stats := g.relayStats
+	stats.Fanout = g.relayFanout
+	return stats
+}
+
+// SetRelayFanout sets the maximum number of peers that an object is relayed
+// to. A fanout of 0 relays objects to every peer, which is the default. A
+// lower fanout reduces the bandwidth used for relaying, at the cost of
+// objects taking more hops to propagate through the network.
+func (g *Gateway) SetRelayFanout(fanout int) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+
+	if fanout < 0 {
+		return errNegativeRelayFanout
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.relayFanout = fanout
+	return g.saveSync()
+}
@@ -0,0 +1,82 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// TestRelayPeers checks that objects are only relayed once to each peer if a
+// relay fanout is set, that the fanout limits the number of peers an object is
+// relayed to, and that rebroadcasts aren't deduplicated.
+func TestRelayPeers(t *testing.T) {
+	g := &Gateway{relayedObjects: make(map[crypto.Hash]*relayedObject)}
+	peers := []modules.Peer{
+		{NetAddress: "111.111.111.111:1"},
+		{NetAddress: "111.111.111.111:2"},
+		{NetAddress: "111.111.111.111:3"},
+		{NetAddress: "111.111.111.111:4"},
+	}
+
+	// Without a fanout, an object is relayed to every peer every time.
+	id := relayID("Recv", []byte("foo"))
+	for i := 0; i < 2; i++ {
+		if addrs := g.managedRelayPeers(id, peers, true); len(addrs) != len(peers) {
+			t.Fatal("object should be relayed to every peer, got", addrs)
+		}
+	}
+	if g.relayStats.DedupHits != 0 || len(g.relayedObjects) != 0 {
+		t.Fatal("objects shouldn't be deduplicated without a fanout")
+	}
+
+	// With a fanout, an object is only relayed once to each peer.
+	g.relayFanout = len(peers)
+	if addrs := g.managedRelayPeers(id, peers, true); len(addrs) != len(peers) {
+		t.Fatal("object should be relayed to every peer, got", addrs)
+	}
+	if addrs := g.managedRelayPeers(id, peers, true); len(addrs) != 0 {
+		t.Fatal("object shouldn't be relayed twice, got", addrs)
+	}
+	if g.relayStats.DedupHits != uint64(len(peers)) {
+		t.Fatal("expected a dedup hit for every peer, got", g.relayStats.DedupHits)
+	}
+
+	// A deliberate rebroadcast isn't deduplicated.
+	if addrs := g.managedRelayPeers(id, peers, false); len(addrs) != len(peers) {
+		t.Fatal("rebroadcast object should be relayed to every peer, got", addrs)
+	}
+
+	// A peer that couldn't be reached receives the object next time.
+	g.managedUnmarkRelayed(id, peers[0].NetAddress)
+	if addrs := g.managedRelayPeers(id, peers, true); len(addrs) != 1 || addrs[0] != peers[0].NetAddress {
+		t.Fatal("object should be relayed to the unmarked peer, got", addrs)
+	}
+
+	// The same encoding relayed through another RPC is a different object.
+	if addrs := g.managedRelayPeers(relayID("Other", []byte("foo")), peers, true); len(addrs) != len(peers) {
+		t.Fatal("object of another RPC should be relayed to every peer, got", addrs)
+	}
+
+	// With a smaller fanout, a random subset of the peers is picked, and the
+	// remaining peers receive the object when it is relayed again.
+	g.relayFanout = 3
+	id = relayID("Recv", []byte("bar"))
+	first := g.managedRelayPeers(id, peers, true)
+	if len(first) != 3 {
+		t.Fatal("object should be relayed to the fanout of peers, got", first)
+	}
+	second := g.managedRelayPeers(id, peers, true)
+	if len(second) != 1 {
+		t.Fatal("object should be relayed to the remaining peer, got", second)
+	}
+	for _, addr := range first {
+		if addr == second[0] {
+			t.Fatal("object was relayed to the same peer twice")
+		}
+	}
+
+	if err := g.SetRelayFanout(-1); err != errNegativeRelayFanout {
+		t.Fatal("expected errNegativeRelayFanout, got", err)
+	}
+}
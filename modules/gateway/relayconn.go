@@ -0,0 +1,373 @@
+package gateway
+
+// Peers that can't dial each other directly, e.g. because one of them is
+// behind a restrictive firewall, can be connected through a relay that both
+// of them are connected to. The connecting gateway calls the RelayConnect RPC
+// on the relay, which calls the RelayAccept RPC on the target and forwards
+// the two streams to each other. The peers then perform the usual handshake
+// over the forwarded streams.
+//
+// Relaying connections and accepting relayed connections are both opt-in,
+// and a relay forwards at most maxRelayedConns connections at a time. The
+// address of a relayed peer is announced by the relay and can't be verified,
+// so the gateway accepts at most maxRelayedPeers relayed peers and never
+// disconnects a direct peer to make room for one. Relayed peers are marked as
+// such and are never added to the node list, since they can't be reached at
+// the address they are known by.
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/encoding"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+var (
+	errRelayBusy        = errors.New("relay is forwarding too many connections")
+	errRelayChained     = errors.New("connections can't be relayed through or to a relayed peer")
+	errRelayDisabled    = errors.New("peer doesn't relay connections")
+	errRelayFull        = errors.New("peer can't accept more relayed connections")
+	errRelayNotAccepted = errors.New("peer doesn't accept relayed connections")
+	errRelayNotPeer     = errors.New("not connected to the relay")
+	errRelayUnknownPeer = errors.New("relay isn't connected to the peer")
+)
+
+// relayedConn wraps the stream that a relayed peer is connected through and
+// signals when the stream is closed.
+type relayedConn struct {
+	net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Close closes the stream and signals that it was closed.
+func (rc *relayedConn) Close() error {
+	rc.closeOnce.Do(func() { close(rc.closed) })
+	return rc.Conn.Close()
+}
+
+// pipeConns copies data between two connections in both directions until
+// either of them is closed, then closes both.
+func pipeConns(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+	copyConn := func(dst, src net.Conn) {
+		io.Copy(dst, src)
+		done <- struct{}{}
+	}
+	go copyConn(a, b)
+	go copyConn(b, a)
+	<-done
+	a.Close()
+	b.Close()
+	<-done
+}
+
+// relayConnect is the handler for the RelayConnect RPC. It forwards the
+// connection of the caller to the requested peer, if the gateway relays
+// connections and isn't forwarding too many connections already.
+func (g *Gateway) relayConnect(conn modules.PeerConn) error {
+	var target modules.NetAddress
+	if err := encoding.ReadObject(conn, &target, modules.MaxEncodedNetAddressLength); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	p, exists := g.peers[target]
+	var err error
+	if !g.relayConns {
+		err = errRelayDisabled
+	} else if g.relayedConns >= maxRelayedConns {
+		err = errRelayBusy
+	} else if !exists {
+		err = errRelayUnknownPeer
+	} else if p.Relayed {
+		err = errRelayChained
+	} else {
+		g.relayedConns++
+	}
+	g.mu.Unlock()
+	if err != nil {
+		return modules.WriteNegotiationRejection(conn, err)
+	}
+	defer func() {
+		g.mu.Lock()
+		g.relayedConns--
+		g.mu.Unlock()
+	}()
+
+	// Ask the target to accept the connection and pass its response on to
+	// the caller.
+	targetConn, err := p.open()
+	if err != nil {
+		return modules.WriteNegotiationRejection(conn, err)
+	}
+	defer targetConn.Close()
+	targetConn.SetDeadline(time.Now().Add(rpcStdDeadline))
+	var response string
+	err = encoding.WriteObject(targetConn, handlerName(modules.RelayAcceptCmd))
+	if err == nil {
+		err = encoding.WriteObject(targetConn, conn.RPCAddr())
+	}
+	if err == nil {
+		err = encoding.ReadObject(targetConn, &response, modules.NegotiateMaxErrorSize)
+	}
+	if err != nil {
+		return modules.WriteNegotiationRejection(conn, fmt.Errorf("unable to reach the peer: %v", err))
+	}
+	if err := encoding.WriteObject(conn, response); err != nil {
+		return err
+	} else if response != modules.AcceptResponse {
+		return fmt.Errorf("peer rejected relayed connection: %v", response)
+	}
+
+	// Forward the connection until either side closes it.
+	conn.SetDeadline(time.Time{})
+	targetConn.SetDeadline(time.Time{})
+	g.log.Debugf("INFO: relaying connection from %v to %v", conn.RPCAddr(), target)
+	pipeConns(conn, targetConn)
+	g.log.Debugf("INFO: stopped relaying connection from %v to %v", conn.RPCAddr(), target)
+	return nil
+}
+
+// numRelayedPeers returns the number of inbound peers that are connected
+// through a relay.
+func (g *Gateway) numRelayedPeers() int {
+	n := 0
+	for _, p := range g.peers {
+		if p.Inbound && p.Relayed {
+			n++
+		}
+	}
+	return n
+}
+
+// canAcceptRelayed returns an error if the gateway can't accept a relayed
+// peer at origin. Relayed peers don't kick other peers, so they are only
+// accepted while the gateway has room for more peers.
+func (g *Gateway) canAcceptRelayed(origin modules.NetAddress) error {
+	if !g.acceptRelayed {
+		return errRelayNotAccepted
+	} else if _, exists := g.peers[origin]; exists {
+		return errPeerExists
+	} else if g.numRelayedPeers() >= maxRelayedPeers || len(g.peers) >= g.peerLimits().MaxPeers {
+		return errRelayFull
+	}
+	return nil
+}
+
+// relayAccept is the handler for the RelayAccept RPC. A relay calls it to
+// forward the connection of a peer which can't dial the gateway directly. The
+// handler returns once the relayed peer disconnects.
+func (g *Gateway) relayAccept(conn modules.PeerConn) error {
+	var origin modules.NetAddress
+	if err := encoding.ReadObject(conn, &origin, modules.MaxEncodedNetAddressLength); err != nil {
+		return err
+	}
+	g.mu.RLock()
+	err := g.canAcceptRelayed(origin)
+	g.mu.RUnlock()
+	if err != nil {
+		return modules.WriteNegotiationRejection(conn, err)
+	}
+	if err := modules.WriteNegotiationAcceptance(conn); err != nil {
+		return err
+	}
+
+	// Perform the handshake of an inbound connection over the relayed
+	// stream.
+	remoteVersion, err := acceptVersionHandshake(conn, build.Version)
+	if err != nil {
+		return err
+	}
+	if build.VersionCmp(remoteVersion, minimumAcceptablePeerVersion) < 0 {
+		return errors.New("version number is below threshold")
+	} else if err := g.managedCheckPeerVersion(origin, remoteVersion, true); err != nil {
+		rejectRemoteHeader(conn, err)
+		return err
+	}
+	g.mu.RLock()
+	ourHeader := sessionHeader{
+		GenesisID:  types.GenesisID,
+		UniqueID:   g.staticId,
+		NetAddress: g.myAddr,
+	}
+	g.mu.RUnlock()
+	if _, err := exchangeRemoteHeader(conn, ourHeader); err != nil {
+		return err
+	} else if err := exchangeOurHeader(conn, ourHeader); err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Time{})
+
+	// The relayed peer isn't added to the node list, it can't be dialed at
+	// its address. Other peers might have connected during the handshake.
+	rc := &relayedConn{Conn: conn, closed: make(chan struct{})}
+	g.mu.Lock()
+	if err := g.canAcceptRelayed(origin); err != nil {
+		g.mu.Unlock()
+		return err
+	}
+	g.addPeer(&peer{
+		Peer: modules.Peer{
+			Inbound:    true,
+			Local:      origin.IsLocal(),
+			NetAddress: origin,
+			Relayed:    true,
+			Version:    remoteVersion,
+		},
+		sess: newServerStream(rc, remoteVersion),
+	})
+	g.mu.Unlock()
+	g.log.Debugf("INFO: accepted relayed connection from new peer %v (v%v)", origin, remoteVersion)
+
+	select {
+	case <-rc.closed:
+	case <-g.threads.StopChan():
+	}
+	return nil
+}
+
+// managedConnectRelayed connects to a peer through the RelayConnect RPC of
+// the relay, and adds it to the Gateway's peer list.
+func (g *Gateway) managedConnectRelayed(addr, relay modules.NetAddress) error {
+	g.mu.RLock()
+	gaddr := g.myAddr
+	_, exists := g.peers[addr]
+	relayPeer, relayExists := g.peers[relay]
+	g.mu.RUnlock()
+	if addr == gaddr {
+		return errors.New("can't connect to our own address")
+	} else if err := addr.IsStdValid(); err != nil {
+		return errors.New("can't connect to invalid address")
+	} else if exists {
+		return errPeerExists
+	} else if !relayExists {
+		return errRelayNotPeer
+	} else if relayPeer.Relayed {
+		return errRelayChained
+	} else if g.managedPeerCoolingDown(addr) {
+		return errPeerCoolingDown
+	}
+
+	// Ask the relay to forward the connection.
+	conn, err := relayPeer.open()
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(connStdDeadline))
+	err = encoding.WriteObject(conn, handlerName(modules.RelayConnectCmd))
+	if err == nil {
+		err = encoding.WriteObject(conn, addr)
+	}
+	if err == nil {
+		err = modules.ReadNegotiationAcceptance(conn)
+	}
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("relay didn't forward the connection: %v", err)
+	}
+
+	// Perform the handshake of an outbound connection over the relayed
+	// stream.
+	remoteVersion, err := connectVersionHandshake(conn, build.Version)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if g.spv && (build.VersionCmp(remoteVersion, minimumSPVAcceptablePeerVersion) < 0) {
+		conn.Close()
+		return fmt.Errorf("spv require higher version: %s < %s", remoteVersion, minimumSPVAcceptablePeerVersion)
+	}
+	if build.VersionCmp(remoteVersion, minimumAcceptablePeerVersion) < 0 {
+		err = errors.New("version number is below threshold")
+	} else if err = g.managedCheckPeerVersion(addr, remoteVersion, false); err == nil {
+		err = g.managedConnectPeer(conn, remoteVersion, addr)
+	}
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	conn.SetDeadline(time.Time{})
+
+	// The peer isn't added to the node list, it can't be dialed directly and
+	// shouldn't be shared with other peers.
+	g.mu.Lock()
+	g.addPeer(&peer{
+		Peer: modules.Peer{
+			Inbound:    false,
+			Local:      addr.IsLocal(),
+			NetAddress: addr,
+			Relayed:    true,
+			Version:    remoteVersion,
+		},
+		sess: newClientStream(conn, remoteVersion),
+	})
+	g.mu.Unlock()
+	g.log.Debugf("INFO: connected to new peer %v through relay %v", addr, relay)
+
+	g.callInitRPCs(addr)
+	return nil
+}
+
+// ConnectRelayed establishes a persistent connection to a peer through a
+// relay that the gateway is connected to. The relay needs to have relaying
+// of connections enabled.
+func (g *Gateway) ConnectRelayed(addr, relay modules.NetAddress) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+	return g.managedConnectRelayed(addr, relay)
+}
+
+// RelayConnections returns true if the gateway forwards connections between
+// its peers.
+func (g *Gateway) RelayConnections() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.relayConns
+}
+
+// SetRelayConnections enables or disables forwarding connections between the
+// peers of the gateway. Connections that are already forwarded are not
+// affected when relaying is disabled.
+func (g *Gateway) SetRelayConnections(relay bool) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.relayConns = relay
+	return g.saveSync()
+}
+
+// AcceptRelayed returns true if the gateway accepts connections that relays
+// forward to it.
+func (g *Gateway) AcceptRelayed() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.acceptRelayed
+}
+
+// SetAcceptRelayed enables or disables accepting connections that relays
+// forward to the gateway. Relayed peers that are already connected are not
+// disconnected when it is disabled.
+func (g *Gateway) SetAcceptRelayed(accept bool) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.acceptRelayed = accept
+	return g.saveSync()
+}
@@ -0,0 +1,188 @@
+package gateway
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/encoding"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// relayedPeer returns the peer of g with the given address if it is connected
+// through a relay.
+func relayedPeer(g *Gateway, addr modules.NetAddress) (modules.Peer, error) {
+	for _, p := range g.Peers() {
+		if p.NetAddress == addr {
+			if !p.Relayed {
+				return p, errors.New("peer isn't marked as relayed")
+			}
+			return p, nil
+		}
+	}
+	return modules.Peer{}, errors.New("not connected to peer")
+}
+
+// TestConnectRelayed checks that two gateways can connect through a relay
+// which has relaying enabled if the target accepts relayed connections, that
+// they mark each other as relayed and that relayed connections are limited.
+func TestConnectRelayed(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer g1.Close()
+	relay := newNamedTestingGateway(t, "2")
+	defer relay.Close()
+	g3 := newNamedTestingGateway(t, "3")
+	defer g3.Close()
+
+	// Prevent the relay from sharing its nodes, otherwise g1 might connect to
+	// g3 directly.
+	relay.mu.Lock()
+	relay.handlers[handlerName(modules.ShareNodesCmd)] = func(modules.PeerConn) error {
+		return nil
+	}
+	relay.mu.Unlock()
+	if err := g1.Connect(relay.myAddr); err != nil {
+		t.Fatal(err)
+	}
+	if err := g3.Connect(relay.myAddr); err != nil {
+		t.Fatal(err)
+	}
+	err := build.Retry(50, 100*time.Millisecond, func() error {
+		if len(relay.Peers()) != 2 {
+			return errors.New("relay isn't connected to both gateways")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Relaying is disabled by default.
+	err = g1.ConnectRelayed(g3.myAddr, relay.myAddr)
+	if err == nil || !strings.Contains(err.Error(), errRelayDisabled.Error()) {
+		t.Fatal("expected errRelayDisabled, got", err)
+	}
+	if err := relay.SetRelayConnections(true); err != nil {
+		t.Fatal(err)
+	}
+	// Accepting relayed connections is disabled by default as well.
+	err = g1.ConnectRelayed(g3.myAddr, relay.myAddr)
+	if err == nil || !strings.Contains(err.Error(), errRelayNotAccepted.Error()) {
+		t.Fatal("expected errRelayNotAccepted, got", err)
+	}
+	if err := g3.SetAcceptRelayed(true); err != nil {
+		t.Fatal(err)
+	}
+	// A relay that forwards too many connections rejects new ones.
+	relay.mu.Lock()
+	relay.relayedConns = maxRelayedConns
+	relay.mu.Unlock()
+	err = g1.ConnectRelayed(g3.myAddr, relay.myAddr)
+	if err == nil || !strings.Contains(err.Error(), errRelayBusy.Error()) {
+		t.Fatal("expected errRelayBusy, got", err)
+	}
+	relay.mu.Lock()
+	relay.relayedConns = 0
+	relay.mu.Unlock()
+	// A relayed peer doesn't replace a direct peer of a full gateway.
+	full := modules.GatewayPeerLimits{MaxPeers: len(g3.Peers()), MaxOutboundPeers: len(g3.Peers())}
+	if err := g3.SetPeerLimits(full); err != nil {
+		t.Fatal(err)
+	}
+	err = g1.ConnectRelayed(g3.myAddr, relay.myAddr)
+	if err == nil || !strings.Contains(err.Error(), errRelayFull.Error()) {
+		t.Fatal("expected errRelayFull, got", err)
+	}
+	if _, err := relayedPeer(g3, relay.myAddr); err == nil || err.Error() != "peer isn't marked as relayed" {
+		t.Fatal("direct peer was replaced by a relayed peer", err)
+	}
+	if err := g3.SetPeerLimits(modules.GatewayPeerLimits{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.ConnectRelayed(g3.myAddr, relay.myAddr); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both sides should mark the connection as relayed and neither should
+	// add the other to its node list.
+	if p, err := relayedPeer(g1, g3.myAddr); err != nil || p.Inbound {
+		t.Fatal("g1 should have g3 as a relayed outbound peer", p, err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		p, err := relayedPeer(g3, g1.myAddr)
+		if err == nil && !p.Inbound {
+			return errors.New("peer should be inbound")
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g1.mu.RLock()
+	_, exists := g1.nodes[g3.myAddr]
+	g1.mu.RUnlock()
+	if exists {
+		t.Fatal("relayed peer shouldn't be added to the node list")
+	}
+	g1.mu.RLock()
+	outbound := g1.numOutboundPeers()
+	g1.mu.RUnlock()
+	if outbound != 1 {
+		t.Fatal("relayed peer shouldn't count as an outbound peer", outbound)
+	}
+
+	// The relayed peers of g3 are limited.
+	g4 := newNamedTestingGateway(t, "4")
+	defer g4.Close()
+	if err := g4.Connect(relay.myAddr); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if len(relay.Peers()) != 3 {
+			return errors.New("relay isn't connected to g4")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g4.ConnectRelayed(g3.myAddr, relay.myAddr)
+	if err == nil || !strings.Contains(err.Error(), errRelayFull.Error()) {
+		t.Fatal("expected errRelayFull, got", err)
+	}
+
+	// RPCs can be called over the relayed connection.
+	g3.RegisterRPC("Foo", func(conn modules.PeerConn) error {
+		return encoding.WriteObject(conn, "foo")
+	})
+	var resp string
+	err = g1.RPC(g3.myAddr, "Foo", func(conn modules.PeerConn) error {
+		return encoding.ReadObject(conn, &resp, 11)
+	})
+	if err != nil || resp != "foo" {
+		t.Fatal("RPC over relayed connection failed", resp, err)
+	}
+
+	// Disconnecting stops the relay from forwarding the connection.
+	if err := g1.Disconnect(g3.myAddr); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		relay.mu.RLock()
+		defer relay.mu.RUnlock()
+		if relay.relayedConns != 0 {
+			return errors.New("relay is still forwarding the connection")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
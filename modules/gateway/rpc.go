@@ -227,26 +227,45 @@ func (g *Gateway) threadedHandleConn(conn modules.PeerConn) {
 	}
 }
 
-// Broadcast calls an RPC on all of the specified peers. The calls are run in
+// Broadcast calls an RPC on the specified peers. The calls are run in
 // parallel. Broadcasts are restricted to "one-way" RPCs, which simply write an
 // object and disconnect. This is why Broadcast takes an interface{} instead of
-// an RPCFunc.
+// an RPCFunc. If the relay fanout is set, peers that were sent the same object
+// recently are skipped, and at most relayFanout peers are called.
 func (g *Gateway) Broadcast(name string, obj interface{}, peers []modules.Peer) {
 	if g.threads.Add() != nil {
 		return
 	}
 	defer g.threads.Done()
+	g.managedBroadcast(name, obj, peers, true)
+}
 
-	g.log.Debugf("INFO: broadcasting RPC %q to %v peers", name, len(peers))
+// Rebroadcast works like Broadcast, but sends the object to peers that were
+// sent it recently as well. It is used to deliberately send an object again,
+// e.g. a transaction that hasn't been confirmed yet.
+func (g *Gateway) Rebroadcast(name string, obj interface{}, peers []modules.Peer) {
+	if g.threads.Add() != nil {
+		return
+	}
+	defer g.threads.Done()
+	g.managedBroadcast(name, obj, peers, false)
+}
 
+// managedBroadcast calls a one-way RPC on the relay peers of the object,
+// skipping the peers that were sent it recently if dedup is true.
+func (g *Gateway) managedBroadcast(name string, obj interface{}, peers []modules.Peer, dedup bool) {
 	// only encode obj once, instead of using WriteObject
 	enc := encoding.Marshal(obj)
 	fn := func(conn modules.PeerConn) error {
 		return encoding.WritePrefixedBytes(conn, enc)
 	}
+	id := relayID(name, enc)
+	addrs := g.managedRelayPeers(id, peers, dedup)
+
+	g.log.Debugf("INFO: broadcasting RPC %q to %v of %v peers", name, len(addrs), len(peers))
 
 	var wg sync.WaitGroup
-	for _, p := range peers {
+	for _, addr := range addrs {
 		wg.Add(1)
 		go func(addr modules.NetAddress) {
 			defer wg.Done()
@@ -257,14 +276,18 @@ func (g *Gateway) Broadcast(name string, obj interface{}, peers []modules.Peer)
 				select {
 				case <-time.After(10 * time.Second):
 				case <-g.threads.StopChan():
+					g.managedUnmarkRelayed(id, addr)
 					return
 				}
 				err := g.managedRPC(addr, name, fn)
 				if err != nil {
 					g.log.Debugf("WARN: broadcasting RPC %q to peer %q failed twice: %v", name, addr, err)
+					g.managedUnmarkRelayed(id, addr)
+					return
 				}
 			}
-		}(p.NetAddress)
+			g.managedCountRelayed()
+		}(addr)
 	}
 	wg.Wait()
 }
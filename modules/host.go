@@ -1,12 +1,22 @@
 package modules
 
 import (
+	"time"
+
 	"github.com/HyperspaceApp/Hyperspace/types"
 )
 
 const (
 	// HostDir names the directory that contains the host persistence.
 	HostDir = "host"
+
+	// HostRPCQueuePolicyFair hands free RPC slots to the waiting renter
+	// that has the fewest RPCs in progress. It is the default policy.
+	HostRPCQueuePolicyFair = "fair"
+
+	// HostRPCQueuePolicyFIFO hands free RPC slots to the RPCs in the order
+	// they arrived, only subject to the per-renter limit.
+	HostRPCQueuePolicyFIFO = "fifo"
 )
 
 var (
@@ -86,6 +96,10 @@ type (
 		NetAddress           NetAddress        `json:"netaddress"`
 		WindowSize           types.BlockHeight `json:"windowsize"`
 
+		// PruneGracePeriod is the number of blocks the sectors of a resolved
+		// storage obligation are kept before they are removed from disk.
+		PruneGracePeriod types.BlockHeight `json:"prunegraceperiod"`
+
 		Collateral       types.Currency `json:"collateral"`
 		CollateralBudget types.Currency `json:"collateralbudget"`
 		MaxCollateral    types.Currency `json:"maxcollateral"`
@@ -94,6 +108,146 @@ type (
 		MinDownloadBandwidthPrice types.Currency `json:"mindownloadbandwidthprice"`
 		MinStoragePrice           types.Currency `json:"minstorageprice"`
 		MinUploadBandwidthPrice   types.Currency `json:"minuploadbandwidthprice"`
+
+		// RenewalDiscount is the fraction by which the contract price and the
+		// storage price are lowered when a renter renews an existing contract
+		// with the host. A value of 0.1 grants a 10% discount.
+		RenewalDiscount float64 `json:"renewaldiscount"`
+
+		// ReservedStorage maps the string form of a renter's public key to
+		// the number of bytes that are set aside for that renter. Other
+		// renters are not allowed to allocate storage from the reserved pool.
+		ReservedStorage map[string]uint64 `json:"reservedstorage"`
+
+		// MaxStoragePerContract is the number of bytes a single contract
+		// may store on the host, 0 means no limit. Contracts that already
+		// exceed the limit keep their data but can't add more.
+		MaxStoragePerContract uint64 `json:"maxstoragepercontract"`
+
+		// MaxConcurrentRPCs limits the number of RPCs on existing contracts
+		// that the host processes at once, further RPCs are queued. 0 means
+		// no limit. MaxConcurrentRPCsPerRenter limits the RPCs of a single
+		// renter, 0 means no limit. RPCQueuePolicy decides which queued RPC
+		// is processed next, an empty policy is HostRPCQueuePolicyFair.
+		MaxConcurrentRPCs          uint64 `json:"maxconcurrentrpcs"`
+		MaxConcurrentRPCsPerRenter uint64 `json:"maxconcurrentrpcsperrenter"`
+		RPCQueuePolicy             string `json:"rpcqueuepolicy"`
+
+		// TrustedArbiters are the arbiters the host accepts in the unlock
+		// conditions of arbitrated contracts. An arbiter can sign revisions
+		// together with the renter without the host, so the host has to
+		// trust it not to collude with the renter. Contracts with other
+		// arbiters are rejected.
+		TrustedArbiters []types.SiaPublicKey `json:"trustedarbiters"`
+	}
+
+	// HostCapacityMetrics reports how the remaining storage of the host is
+	// split between the reserved pool and the storage that is free for any
+	// renter to use.
+	HostCapacityMetrics struct {
+		TotalStorage     uint64 `json:"totalstorage"`
+		RemainingStorage uint64 `json:"remainingstorage"`
+
+		// ReservedStorage is the part of the remaining storage that is set
+		// aside for renters with a reservation but has not been used by them
+		// yet. FreeStorage is what is left for everyone else.
+		ReservedStorage uint64 `json:"reservedstorage"`
+		FreeStorage     uint64 `json:"freestorage"`
+
+		Reservations []HostStorageReservation `json:"reservations"`
+
+		// MaxStoragePerContract is the limit of the storage used by a single
+		// contract. Contracts reports the storage used by every unresolved
+		// contract.
+		MaxStoragePerContract uint64                `json:"maxstoragepercontract"`
+		Contracts             []HostContractStorage `json:"contracts"`
+	}
+
+	// HostContractStorage describes how much storage a single contract is
+	// using on the host.
+	HostContractStorage struct {
+		ContractID  types.FileContractID `json:"contractid"`
+		RenterKey   types.SiaPublicKey   `json:"renterkey"`
+		UsedStorage uint64               `json:"usedstorage"`
+	}
+
+	// HostStorageReservation describes the storage reserved for a single
+	// renter and how much of it is currently in use.
+	HostStorageReservation struct {
+		RenterKey   types.SiaPublicKey `json:"renterkey"`
+		Reserved    uint64             `json:"reserved"`
+		UsedStorage uint64             `json:"usedstorage"`
+	}
+
+	// HostRPCQueueStatus reports the RPCs of a single renter that the host
+	// is processing and the ones that are waiting for a free slot.
+	HostRPCQueueStatus struct {
+		RenterKey types.SiaPublicKey `json:"renterkey"`
+		Active    uint64             `json:"active"`
+		Queued    uint64             `json:"queued"`
+	}
+
+	// HostPruneResult reports the storage that was reclaimed by pruning the
+	// sectors of resolved storage obligations. ReclaimedStorage only counts
+	// sectors that were deleted from disk, sectors that are still used by
+	// other obligations are merely dereferenced.
+	HostPruneResult struct {
+		ObligationsPruned uint64 `json:"obligationspruned"`
+		SectorsRemoved    uint64 `json:"sectorsremoved"`
+		ReclaimedStorage  uint64 `json:"reclaimedstorage"`
+	}
+
+	// HostContractEarnings reports the revenue of a single storage
+	// obligation. The revenue of unresolved obligations is potential revenue
+	// that is only earned if the storage proof succeeds. CollateralReturned
+	// and CollateralLost are only set once the obligation is resolved.
+	HostContractEarnings struct {
+		ContractID        types.FileContractID `json:"contractid"`
+		ObligationStatus  string               `json:"obligationstatus"`
+		NegotiationHeight types.BlockHeight    `json:"negotiationheight"`
+		ExpirationHeight  types.BlockHeight    `json:"expirationheight"`
+		ResolutionHeight  types.BlockHeight    `json:"resolutionheight"`
+		ResolutionTime    time.Time            `json:"resolutiontime"`
+
+		ContractRevenue    types.Currency `json:"contractrevenue"`
+		StorageRevenue     types.Currency `json:"storagerevenue"`
+		DownloadRevenue    types.Currency `json:"downloadrevenue"`
+		UploadRevenue      types.Currency `json:"uploadrevenue"`
+		CollateralReturned types.Currency `json:"collateralreturned"`
+		CollateralLost     types.Currency `json:"collaterallost"`
+		TransactionFees    types.Currency `json:"transactionfees"`
+	}
+
+	// HostProofAttempt records an attempt of the host to submit a storage
+	// proof. Submitted is true if the proof was accepted by the transaction
+	// pool, otherwise Error explains why the attempt failed. A proof that
+	// doesn't get confirmed in time results in a failed obligation.
+	HostProofAttempt struct {
+		ContractID types.FileContractID `json:"contractid"`
+		Height     types.BlockHeight    `json:"height"`
+		Timestamp  time.Time            `json:"timestamp"`
+		Submitted  bool                 `json:"submitted"`
+		Error      string               `json:"error,omitempty"`
+	}
+
+	// HostEarnings is an accounting export of the storage obligations
+	// resolved and the storage proofs attempted during a period.
+	HostEarnings struct {
+		Contracts []HostContractEarnings `json:"contracts"`
+		Proofs    []HostProofAttempt     `json:"proofs"`
+	}
+
+	// HostBenchmark reports the throughput of the host's disk and loopback
+	// network in bytes per second, measured by writing and reading
+	// SectorsWritten scratch sectors and by sending data to itself. Warnings
+	// lists the throughputs that are far below what renters expect.
+	HostBenchmark struct {
+		DiskWriteThroughput uint64        `json:"diskwritethroughput"`
+		DiskReadThroughput  uint64        `json:"diskreadthroughput"`
+		NetworkThroughput   uint64        `json:"networkthroughput"`
+		SectorsWritten      uint64        `json:"sectorswritten"`
+		Duration            time.Duration `json:"duration"`
+		Warnings            []string      `json:"warnings"`
 	}
 
 	// HostNetworkMetrics reports the quantity of each type of RPC call that
@@ -158,6 +312,30 @@ type (
 		// AnnounceAddress submits an announcement using the given address.
 		AnnounceAddress(NetAddress) error
 
+		// Benchmark measures the throughput of the host's disk and loopback
+		// network.
+		Benchmark() (HostBenchmark, error)
+
+		// CapacityMetrics reports the reserved and free storage of the host.
+		CapacityMetrics() HostCapacityMetrics
+
+		// Drain stops the host from forming and renewing contracts and from
+		// accepting uploads, while it keeps serving downloads and storage
+		// proofs.
+		Drain() error
+
+		// Draining returns true if the host is in drain mode.
+		Draining() bool
+
+		// Earnings returns the revenue of the storage obligations resolved
+		// and the storage proofs attempted between start and end. A zero end
+		// time leaves the range open and includes unresolved obligations.
+		Earnings(start, end time.Time) (HostEarnings, error)
+
+		// ExportEarnings writes the earnings between start and end as CSV to
+		// the file at dst.
+		ExportEarnings(dst string, start, end time.Time) error
+
 		// ExternalSettings returns the settings of the host as seen by an
 		// untrusted node querying the host for settings.
 		ExternalSettings() HostExternalSettings
@@ -173,9 +351,18 @@ type (
 		// have been made to the host.
 		NetworkMetrics() HostNetworkMetrics
 
+		// PruneStorageObligations removes the sectors of all storage
+		// obligations that were resolved more than PruneGracePeriod blocks
+		// ago.
+		PruneStorageObligations() (HostPruneResult, error)
+
 		// PublicKey returns the public key of the host.
 		PublicKey() types.SiaPublicKey
 
+		// RPCQueue returns the number of active and queued RPCs of every
+		// renter that has RPCs in progress.
+		RPCQueue() []HostRPCQueueStatus
+
 		// SetInternalSettings sets the hosting parameters of the host.
 		SetInternalSettings(HostInternalSettings) error
 
@@ -183,6 +370,9 @@ type (
 		// the host.
 		StorageObligations() []StorageObligation
 
+		// Undrain ends the drain mode of the host.
+		Undrain() error
+
 		// ConnectabilityStatus returns the connectability status of the host, that
 		// is, if it can connect to itself on the configured NetAddress.
 		ConnectabilityStatus() HostConnectabilityStatus
@@ -0,0 +1,37 @@
+package host
+
+import (
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+var (
+	// errUntrustedArbiter is returned if a renter tries to form or renew a
+	// contract with an arbiter that the host doesn't trust.
+	errUntrustedArbiter = ErrorCommunication("host does not accept contracts with that arbiter")
+)
+
+// trustedArbiter returns true if the host accepts contracts with the arbiter.
+// Contracts without an arbiter are always accepted. An arbiter can revise a
+// contract together with the renter, so the host only accepts the arbiters it
+// was configured to trust.
+func trustedArbiter(settings modules.HostInternalSettings, arbiterPK types.SiaPublicKey) bool {
+	if len(arbiterPK.Key) == 0 {
+		return true
+	}
+	for _, pk := range settings.TrustedArbiters {
+		if pk.String() == arbiterPK.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// arbiterKey returns the public key of the arbiter of the storage obligation.
+// The key is empty if the obligation has no arbiter.
+func (so storageObligation) arbiterKey() types.SiaPublicKey {
+	if len(so.RevisionTransactionSet) == 0 {
+		return types.SiaPublicKey{}
+	}
+	return modules.ContractArbiter(so.RevisionTransactionSet[len(so.RevisionTransactionSet)-1].FileContractRevisions[0].UnlockConditions)
+}
@@ -0,0 +1,50 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestTrustedArbiter checks that the host only accepts the arbiters it trusts
+// and that contracts without arbiter are always accepted.
+func TestTrustedArbiter(t *testing.T) {
+	trusted := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	untrusted := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{2}}
+	settings := modules.HostInternalSettings{
+		TrustedArbiters: []types.SiaPublicKey{trusted},
+	}
+	if !trustedArbiter(settings, types.SiaPublicKey{}) {
+		t.Fatal("contracts without arbiter should be accepted")
+	}
+	if !trustedArbiter(settings, trusted) {
+		t.Fatal("trusted arbiter should be accepted")
+	}
+	if trustedArbiter(settings, untrusted) {
+		t.Fatal("untrusted arbiter should be rejected")
+	}
+}
+
+// TestStorageObligationArbiterKey checks that the arbiter is read from the
+// unlock conditions of the latest revision.
+func TestStorageObligationArbiterKey(t *testing.T) {
+	renterPK := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	hostPK := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{2}}
+	arbiterPK := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{3}}
+	so := storageObligation{}
+	if len(so.arbiterKey().Key) != 0 {
+		t.Fatal("obligation without revision shouldn't have an arbiter")
+	}
+	so.RevisionTransactionSet = []types.Transaction{{
+		FileContractRevisions: []types.FileContractRevision{{
+			UnlockConditions: modules.ContractUnlockConditions(renterPK, hostPK, arbiterPK),
+		}},
+	}}
+	if key := so.arbiterKey(); key.String() != arbiterPK.String() {
+		t.Fatal("wrong arbiter", key)
+	}
+	if key := so.renterKey(); key.String() != renterPK.String() {
+		t.Fatal("arbiter shouldn't change the renter key", key)
+	}
+}
@@ -0,0 +1,193 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+var (
+	// errBenchmarkRunning is returned if a benchmark is started while another
+	// benchmark is running.
+	errBenchmarkRunning = errors.New("a benchmark is already running")
+)
+
+// throughput returns the number of bytes per second of moving n bytes in d.
+func throughput(n uint64, d time.Duration) uint64 {
+	if d <= 0 {
+		return 0
+	}
+	return uint64(float64(n) / d.Seconds())
+}
+
+// benchmarkWarnings returns a warning for every throughput of the benchmark
+// that is below benchmarkMinThroughput.
+func benchmarkWarnings(b modules.HostBenchmark) []string {
+	warnings := []string{}
+	for _, m := range []struct {
+		name       string
+		throughput uint64
+	}{
+		{"disk write", b.DiskWriteThroughput},
+		{"disk read", b.DiskReadThroughput},
+		{"network", b.NetworkThroughput},
+	} {
+		if m.throughput < benchmarkMinThroughput {
+			warnings = append(warnings, fmt.Sprintf("%v throughput of %.2f MB/s is far below the %.2f MB/s renters expect", m.name, float64(m.throughput)/1e6, float64(benchmarkMinThroughput)/1e6))
+		}
+	}
+	return warnings
+}
+
+// managedBenchmarkDisk measures the disk throughput of the host by writing
+// scratch sectors of random data to the benchmark file in the persist
+// directory and reading them back. The storage folders aren't touched, and
+// the benchmark file is removed again when the benchmark is done. A file left
+// behind by a crash is removed when the host starts.
+func (h *Host) managedBenchmarkDisk(b *modules.HostBenchmark) (err error) {
+	path := filepath.Join(h.persistDir, benchmarkFile)
+	f, err := h.dependencies.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.New("failed to create benchmark file: " + err.Error())
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		if removeErr := h.dependencies.RemoveFile(path); removeErr != nil {
+			h.log.Println("WARN: failed to remove benchmark file:", removeErr)
+		}
+	}()
+
+	// Only the writes and reads of the file are timed, generating the random
+	// data and verifying it isn't part of the throughput. Every sector is
+	// synced, so the write throughput isn't measured against the cache of
+	// the operating system.
+	var roots []crypto.Hash
+	var writeTime time.Duration
+	start := time.Now()
+	for len(roots) < benchmarkSectors && time.Since(start) < benchmarkDuration/2 {
+		sector := fastrand.Bytes(int(modules.SectorSize))
+		offset := int64(len(roots)) * int64(modules.SectorSize)
+		writeStart := time.Now()
+		if _, err := f.WriteAt(sector, offset); err != nil {
+			return errors.New("failed to write benchmark sector: " + err.Error())
+		}
+		if err := f.Sync(); err != nil {
+			return errors.New("failed to sync benchmark sector: " + err.Error())
+		}
+		writeTime += time.Since(writeStart)
+		roots = append(roots, crypto.MerkleRoot(sector))
+	}
+	var readTime time.Duration
+	data := make([]byte, modules.SectorSize)
+	for i, root := range roots {
+		readStart := time.Now()
+		if _, err := f.ReadAt(data, int64(i)*int64(modules.SectorSize)); err != nil {
+			return errors.New("failed to read benchmark sector: " + err.Error())
+		}
+		readTime += time.Since(readStart)
+		if crypto.MerkleRoot(data) != root {
+			return errors.New("benchmark sector was corrupted on disk")
+		}
+	}
+
+	b.SectorsWritten = uint64(len(roots))
+	b.DiskWriteThroughput = throughput(b.SectorsWritten*modules.SectorSize, writeTime)
+	b.DiskReadThroughput = throughput(b.SectorsWritten*modules.SectorSize, readTime)
+	return nil
+}
+
+// benchmarkNetwork measures the loopback network throughput by sending as
+// much data as the disk benchmark moves at most over a TCP connection to
+// itself.
+func benchmarkNetwork() (uint64, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+
+	received := make(chan int64, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			received <- 0
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(2 * benchmarkDuration))
+		n, _ := io.Copy(ioutil.Discard, conn)
+		received <- n
+	}()
+
+	conn, err := net.DialTimeout("tcp", l.Addr().String(), benchmarkDuration)
+	if err != nil {
+		return 0, err
+	}
+	conn.SetDeadline(time.Now().Add(benchmarkDuration))
+	buf := fastrand.Bytes(1 << 20)
+	total := uint64(benchmarkSectors) * modules.SectorSize
+	start := time.Now()
+	for sent := uint64(0); sent < total && time.Since(start) < benchmarkDuration; {
+		n, err := conn.Write(buf)
+		sent += uint64(n)
+		if err != nil {
+			break
+		}
+	}
+	conn.Close()
+	n := <-received
+	return throughput(uint64(n), time.Since(start)), nil
+}
+
+// Benchmark measures the disk throughput of the host's storage and the
+// loopback network throughput. The disk benchmark uses a scratch file in the
+// persist directory of the host, the storage folders aren't touched. Both
+// benchmarks are bounded by benchmarkDuration, and throughputs far below what
+// renters expect are reported as warnings. Reads of freshly written sectors
+// may be served from the cache of the operating system, so the read
+// throughput is an upper bound.
+func (h *Host) Benchmark() (modules.HostBenchmark, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostBenchmark{}, err
+	}
+	defer h.tg.Done()
+
+	h.mu.Lock()
+	if h.benchmarking {
+		h.mu.Unlock()
+		return modules.HostBenchmark{}, errBenchmarkRunning
+	}
+	h.benchmarking = true
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		h.benchmarking = false
+		h.mu.Unlock()
+	}()
+
+	start := time.Now()
+	var b modules.HostBenchmark
+	if err := h.managedBenchmarkDisk(&b); err != nil {
+		return modules.HostBenchmark{}, err
+	}
+	networkThroughput, err := benchmarkNetwork()
+	if err != nil {
+		return modules.HostBenchmark{}, errors.New("network benchmark failed: " + err.Error())
+	}
+	b.NetworkThroughput = networkThroughput
+	b.Duration = time.Since(start)
+	b.Warnings = benchmarkWarnings(b)
+	h.log.Printf("INFO: benchmark measured %v B/s disk writes, %v B/s disk reads and %v B/s network throughput", b.DiskWriteThroughput, b.DiskReadThroughput, b.NetworkThroughput)
+	return b, nil
+}
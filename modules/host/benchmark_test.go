@@ -0,0 +1,48 @@
+package host
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestHostBenchmark checks that the benchmark measures the disk and the
+// network of the host without touching its storage folders, and removes its
+// scratch file again.
+func TestHostBenchmark(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	_, remaining := ht.host.capacity()
+	b, err := ht.host.Benchmark()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.SectorsWritten != uint64(benchmarkSectors) {
+		t.Fatalf("benchmark should write %v sectors, wrote %v", benchmarkSectors, b.SectorsWritten)
+	}
+	if b.DiskWriteThroughput == 0 || b.DiskReadThroughput == 0 || b.NetworkThroughput == 0 {
+		t.Fatal("benchmark didn't measure all throughputs:", b)
+	}
+	if b.Warnings == nil {
+		t.Fatal("warnings should be an empty list if there are none")
+	}
+	if _, r := ht.host.capacity(); r != remaining {
+		t.Fatalf("benchmark shouldn't use the storage folders, remaining storage changed from %v to %v", remaining, r)
+	}
+	if _, err := os.Stat(filepath.Join(ht.host.persistDir, benchmarkFile)); !os.IsNotExist(err) {
+		t.Fatal("benchmark file wasn't removed:", err)
+	}
+
+	// The warnings should report the throughputs that are too low.
+	b.DiskReadThroughput = 0
+	if warnings := benchmarkWarnings(b); len(warnings) != 1 {
+		t.Fatal("expected a warning for the disk read throughput, got", warnings)
+	}
+}
@@ -26,6 +26,12 @@ const (
 	// connection.
 	iteratedConnectionTime = 1200 * time.Second
 
+	// maxRenewalDiscount is the largest renewal discount a host can grant.
+	// The discount is meant as a small reward for loyal renters, a host that
+	// wants to lower its prices by more than half should lower its regular
+	// prices instead.
+	maxRenewalDiscount = 0.5
+
 	// resubmissionTimeout defines the number of blocks that a host will wait
 	// before attempting to resubmit a transaction to the blockchain.
 	// Typically, this transaction will contain either a file contract, a file
@@ -34,6 +40,43 @@ const (
 )
 
 var (
+	// benchmarkDuration bounds the time the disk and the network benchmark of
+	// the host each take. The benchmarks stop early once they have moved
+	// benchmarkSectors sectors.
+	benchmarkDuration = build.Select(build.Var{
+		Standard: 10 * time.Second,
+		Dev:      5 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// benchmarkSectors is the number of scratch sectors the disk benchmark
+	// writes and reads at most. The network benchmark sends the same amount
+	// of data.
+	benchmarkSectors = build.Select(build.Var{
+		Standard: 16,
+		Dev:      8,
+		Testing:  2,
+	}).(int)
+
+	// benchmarkMinThroughput is the throughput in bytes per second below
+	// which the benchmark warns that the host is unlikely to serve renters at
+	// the speeds they expect.
+	benchmarkMinThroughput = build.Select(build.Var{
+		Standard: uint64(10e6),
+		Dev:      uint64(10e6),
+		Testing:  uint64(1e3),
+	}).(uint64)
+
+	// capacityProofCooldown is the minimum amount of time between two capacity
+	// challenges of the same renter that the host answers. Every challenge
+	// costs the host a sector write and a few sector reads, so answering them
+	// without a limit would allow renters to keep the disks of the host busy.
+	capacityProofCooldown = build.Select(build.Var{
+		Standard: time.Minute,
+		Dev:      time.Second * 10,
+		Testing:  time.Duration(0),
+	}).(time.Duration)
+
 	// connectablityCheckFirstWait defines how often the host's connectability
 	// check is run.
 	connectabilityCheckFirstWait = build.Select(build.Var{
@@ -141,6 +184,16 @@ var (
 		Testing:  types.BlockHeight(5),   // 5 seconds.
 	}).(types.BlockHeight)
 
+	// defaultPruneGracePeriod is the number of blocks the host keeps the
+	// sectors of a storage obligation after it has been resolved. Keeping the
+	// data around for a while protects the host against reorgs that revert
+	// the resolution of the obligation.
+	defaultPruneGracePeriod = build.Select(build.Var{
+		Dev:      types.BlockHeight(36),  // 3.6 minutes.
+		Standard: types.BlockHeight(144), // 1 day.
+		Testing:  types.BlockHeight(3),   // 3 seconds.
+	}).(types.BlockHeight)
+
 	// logAllLimit is the number of errors of each type that the host will log
 	// before switching to probabilistic logging. If there are not many errors,
 	// it is reasonable that all errors get logged. If there are lots of
@@ -188,6 +241,14 @@ var (
 		Testing:  types.BlockHeight(4),
 	}).(types.BlockHeight)
 
+	// rpcQueueTimeout is the amount of time an RPC waits for a free slot
+	// before the host gives up on it.
+	rpcQueueTimeout = build.Select(build.Var{
+		Standard: time.Minute * 2,
+		Dev:      time.Second * 30,
+		Testing:  time.Second * 5,
+	}).(time.Duration)
+
 	// rpcRatelimit prevents someone from spamming the host with connections,
 	// causing it to spin up enough goroutines to crash.
 	rpcRatelimit = build.Select(build.Var{
@@ -196,6 +257,23 @@ var (
 		Testing:  time.Millisecond,
 	}).(time.Duration)
 
+	// sectorRootsLimit is the number of sector roots requests per contract
+	// that the host answers within sectorRootsPeriod. Every request makes the
+	// host send all roots of the contract without being paid for it.
+	sectorRootsLimit = build.Select(build.Var{
+		Standard: 10,
+		Dev:      10,
+		Testing:  100,
+	}).(int)
+
+	// sectorRootsPeriod is the period over which the sector roots requests of
+	// a contract are limited to sectorRootsLimit.
+	sectorRootsPeriod = build.Select(build.Var{
+		Standard: time.Hour,
+		Dev:      time.Minute * 10,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
 	// workingStatusFirstCheck defines how frequently the Host's working status
 	// check runs
 	workingStatusFirstCheck = build.Select(build.Var{
@@ -234,6 +312,18 @@ var (
 	// using the id.
 	bucketActionItems = []byte("BucketActionItems")
 
+	// bucketCapacityProbes contains the Merkle roots of the probe sectors of
+	// capacity challenges that were written to the storage manager and not
+	// removed yet. The host removes the sectors that are left over from a
+	// crash when it starts.
+	bucketCapacityProbes = []byte("BucketCapacityProbes")
+
+	// bucketProofAttempts contains the storage proofs the host attempted to
+	// submit as JSON encoded 'modules.HostProofAttempt's. The keys are the
+	// big endian height of the attempt followed by the file contract id, so
+	// the attempts are sorted by height.
+	bucketProofAttempts = []byte("BucketProofAttempts")
+
 	// bucketStorageObligations contains a set of serialized
 	// 'storageObligations' sorted by their file contract id.
 	bucketStorageObligations = []byte("BucketStorageObligations")
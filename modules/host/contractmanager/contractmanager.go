@@ -28,6 +28,7 @@ package contractmanager
 import (
 	"errors"
 	"path/filepath"
+	"sync"
 	"sync/atomic"
 
 	"github.com/HyperspaceApp/Hyperspace/build"
@@ -93,6 +94,11 @@ type ContractManager struct {
 	// or modified.
 	lockedSectors map[sectorID]*sectorLock
 
+	// migration is the progress of the most recent storage folder migration.
+	// It has a separate mutex because it is updated for every moved sector.
+	migration   modules.StorageFolderMigration
+	migrationMu sync.Mutex
+
 	// Utilities.
 	dependencies modules.Dependencies
 	log          *persist.Logger
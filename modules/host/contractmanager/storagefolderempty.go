@@ -16,8 +16,9 @@ var (
 )
 
 // managedMoveSector will move a sector from its current storage folder to
-// another.
-func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
+// another. If targets is not empty, the sector is only moved to one of the
+// storage folders with the provided indices.
+func (wal *writeAheadLog) managedMoveSector(id sectorID, targets []uint16) error {
 	wal.managedLockSector(id)
 	defer wal.managedUnlockSector(id)
 
@@ -51,6 +52,9 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 	wal.mu.Lock()
 	storageFolders := wal.cm.availableStorageFolders()
 	wal.mu.Unlock()
+	if len(targets) > 0 {
+		storageFolders = filterStorageFolders(storageFolders, targets)
+	}
 	for len(storageFolders) >= 1 {
 		var storageFolderIndex int
 		err := func() error {
@@ -192,7 +196,7 @@ func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoin
 			for {
 				select {
 				case id := <-workChan:
-					err := wal.managedMoveSector(id)
+					err := wal.managedMoveSector(id, nil)
 					if err != nil {
 						atomic.AddUint64(&errCount, 1)
 						wal.cm.log.Println("Unable to write sector:", err)
@@ -0,0 +1,200 @@
+package contractmanager
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+var (
+	// errMigrationInProgress is returned if a storage folder migration is
+	// started while another one is still running.
+	errMigrationInProgress = errors.New("a storage folder migration is already in progress")
+
+	// errMigrationSourceIsTarget is returned if the source of a storage folder
+	// migration is also one of its targets.
+	errMigrationSourceIsTarget = errors.New("the source of a migration can't be one of its targets")
+
+	// errMigrationStopped is the error of a migration that was paused because
+	// the contract manager shut down.
+	errMigrationStopped = errors.New("the contract manager shut down during the migration")
+)
+
+// filterStorageFolders returns the storage folders with one of the provided
+// indices.
+func filterStorageFolders(sfs []*storageFolder, indices []uint16) []*storageFolder {
+	filtered := make([]*storageFolder, 0, len(indices))
+	for _, sf := range sfs {
+		for _, index := range indices {
+			if sf.index == index {
+				filtered = append(filtered, sf)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// managedPauseMigration pauses the current migration because of err.
+func (cm *ContractManager) managedPauseMigration(err error) {
+	cm.migrationMu.Lock()
+	defer cm.migrationMu.Unlock()
+	cm.migration.Paused = true
+	cm.migration.Error = err.Error()
+	cm.log.Printf("Storage folder migration of folder %v paused: %v\n", cm.migration.Source, err)
+}
+
+// threadedMigrateStorageFolder moves all sectors of the storage folder to the
+// target storage folders. The storage folder is locked for the duration of the
+// migration, which keeps AddSector from placing new sectors into it. Reads and
+// storage proofs keep working, since the sector lock guarantees that a sector
+// is read either from its old or its new location.
+func (cm *ContractManager) threadedMigrateStorageFolder(sf *storageFolder, targets []uint16) {
+	if err := cm.tg.Add(); err != nil {
+		cm.managedPauseMigration(errMigrationStopped)
+		return
+	}
+	defer cm.tg.Done()
+
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	// Copy the usage of the storage folder, the moves clear the bits of the
+	// moved sectors.
+	cm.wal.mu.Lock()
+	usage := append([]uint64(nil), sf.usage...)
+	total := sf.sectors
+	cm.wal.mu.Unlock()
+
+	cm.migrationMu.Lock()
+	cm.migration.SectorsTotal = total
+	cm.migrationMu.Unlock()
+	atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
+	atomic.StoreUint64(&sf.atomicProgressDenominator, total*modules.SectorSize)
+	defer func() {
+		atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
+		atomic.StoreUint64(&sf.atomicProgressDenominator, 0)
+	}()
+
+	// Read the sector lookup bytes into memory to find the ids of the
+	// sectors in the storage folder.
+	sectorLookupBytes, err := readFullMetadata(sf.metadataFile, len(usage)*storageFolderGranularity)
+	if err != nil {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+		cm.managedPauseMigration(build.ExtendErr("unable to read sector metadata", err))
+		return
+	}
+	atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+
+	for i, u := range usage {
+		// The usage is a bitfield indicating where sectors exist.
+		for j := 0; j < storageFolderGranularity; j++ {
+			if u&(uint64(1)<<uint(j)) == 0 {
+				continue
+			}
+			select {
+			case <-cm.tg.StopChan():
+				cm.managedPauseMigration(errMigrationStopped)
+				return
+			default:
+			}
+
+			// Skip sectors that were deleted since the usage was copied.
+			var id sectorID
+			readHead := (i*storageFolderGranularity + j) * sectorMetadataDiskSize
+			copy(id[:], sectorLookupBytes[readHead:readHead+12])
+			cm.wal.mu.Lock()
+			sl, exists := cm.sectorLocations[id]
+			cm.wal.mu.Unlock()
+			if !exists || sl.storageFolder != sf.index {
+				continue
+			}
+
+			// Pause the migration if the targets are full. The sectors that
+			// were moved so far stay in their new location.
+			err := cm.wal.managedMoveSector(id, targets)
+			if err == errInsufficientStorageForSector {
+				cm.managedPauseMigration(err)
+				return
+			}
+			cm.migrationMu.Lock()
+			if err != nil {
+				cm.migration.SectorsFailed++
+				cm.log.Println("Unable to migrate sector:", err)
+			} else {
+				cm.migration.SectorsMoved++
+			}
+			cm.migrationMu.Unlock()
+			atomic.AddUint64(&sf.atomicProgressNumerator, modules.SectorSize)
+		}
+	}
+
+	// Wait for a synchronize to confirm that all of the moves have succeeded
+	// in full.
+	cm.wal.mu.Lock()
+	syncChan := cm.wal.syncChan
+	cm.wal.mu.Unlock()
+	<-syncChan
+
+	cm.migrationMu.Lock()
+	defer cm.migrationMu.Unlock()
+	cm.migration.Active = false
+	if cm.migration.SectorsFailed > 0 {
+		cm.migration.Error = fmt.Sprintf("%v: %v sectors failed", ErrPartialRelocation, cm.migration.SectorsFailed)
+	}
+}
+
+// MigrateStorageFolder starts moving the sectors of the source storage folder
+// to the target storage folders in the background. If no targets are provided,
+// the sectors are moved to any of the other storage folders. A paused
+// migration is resumed by migrating its source again.
+func (cm *ContractManager) MigrateStorageFolder(source uint16, targets []uint16) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+
+	cm.wal.mu.Lock()
+	sf, exists := cm.storageFolders[source]
+	for _, target := range targets {
+		if _, ok := cm.storageFolders[target]; !ok {
+			exists = false
+		}
+	}
+	cm.wal.mu.Unlock()
+	if !exists {
+		return errStorageFolderNotFound
+	}
+	for _, target := range targets {
+		if target == source {
+			return errMigrationSourceIsTarget
+		}
+	}
+
+	cm.migrationMu.Lock()
+	defer cm.migrationMu.Unlock()
+	if cm.migration.Active && !cm.migration.Paused {
+		return errMigrationInProgress
+	}
+	cm.migration = modules.StorageFolderMigration{
+		Active:  true,
+		Source:  source,
+		Targets: append([]uint16(nil), targets...),
+	}
+	go cm.threadedMigrateStorageFolder(sf, cm.migration.Targets)
+	return nil
+}
+
+// StorageFolderMigration returns the progress of the most recent storage
+// folder migration.
+func (cm *ContractManager) StorageFolderMigration() modules.StorageFolderMigration {
+	cm.migrationMu.Lock()
+	defer cm.migrationMu.Unlock()
+	migration := cm.migration
+	migration.Targets = append([]uint16(nil), migration.Targets...)
+	return migration
+}
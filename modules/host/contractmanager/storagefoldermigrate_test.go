@@ -0,0 +1,94 @@
+package contractmanager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// TestMigrateStorageFolder checks that the sectors of a storage folder are
+// moved to the target storage folder and can still be read afterwards.
+func TestMigrateStorageFolder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	addFolder := func(name string) uint16 {
+		dir := filepath.Join(cmt.persistDir, name)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := cmt.cm.AddStorageFolder(dir, modules.SectorSize*storageFolderGranularity); err != nil {
+			t.Fatal(err)
+		}
+		for _, sf := range cmt.cm.StorageFolders() {
+			if sf.Path == dir {
+				return sf.Index
+			}
+		}
+		t.Fatal("storage folder wasn't added")
+		return 0
+	}
+	source := addFolder("source")
+
+	// Fill the source with a few sectors.
+	sectors := make(map[crypto.Hash][]byte)
+	for i := 0; i < 5; i++ {
+		root, data := randSector()
+		if err := cmt.cm.AddSector(root, data); err != nil {
+			t.Fatal(err)
+		}
+		sectors[root] = data
+	}
+	target := addFolder("target")
+	if err := cmt.cm.MigrateStorageFolder(source, []uint16{source}); err != errMigrationSourceIsTarget {
+		t.Fatal("expected errMigrationSourceIsTarget, got", err)
+	}
+	if err := cmt.cm.MigrateStorageFolder(source, []uint16{target}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the migration to finish.
+	var migration modules.StorageFolderMigration
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		migration = cmt.cm.StorageFolderMigration()
+		if migration.Active && !migration.Paused {
+			return errMigrationInProgress
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migration.Paused || migration.SectorsMoved != 5 || migration.SectorsFailed != 0 || migration.Error != "" {
+		t.Fatal("migration didn't move all sectors", migration)
+	}
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Index == source && sf.CapacityRemaining != sf.Capacity {
+			t.Fatal("source still contains sectors")
+		} else if sf.Index == target && sf.CapacityRemaining != sf.Capacity-5*modules.SectorSize {
+			t.Fatal("target doesn't contain the sectors")
+		}
+	}
+	for root, data := range sectors {
+		read, err := cmt.cm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(read, data) {
+			t.Fatal("migrated sector has the wrong data")
+		}
+	}
+}
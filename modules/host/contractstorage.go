@@ -0,0 +1,86 @@
+package host
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// errMaxStoragePerContract is returned if a renter tries to store more
+	// data in a contract than the host allows for a single contract.
+	errMaxStoragePerContract = ErrorInternal("contract has reached the maximum storage the host allows per contract")
+)
+
+// contractStorage returns the number of bytes that the storage obligation
+// stores on the host.
+func (so storageObligation) contractStorage() uint64 {
+	return uint64(len(so.SectorRoots)) * modules.SectorSize
+}
+
+// checkContractStorage returns an error if the storage obligation, after
+// gaining newSectors sectors, stores more data than a single contract may
+// store on the host. Revisions that don't grow the contract are always
+// accepted, so contracts which exceeded the limit before it was lowered keep
+// their data.
+func checkContractStorage(so storageObligation, newSectors int, maxStorage uint64) error {
+	if maxStorage == 0 || newSectors <= 0 {
+		return nil
+	}
+	if so.contractStorage() > maxStorage {
+		return errMaxStoragePerContract
+	}
+	return nil
+}
+
+// indexContractStorage updates the index of the storage used by every
+// unresolved storage obligation after the obligation was added or changed.
+// Resolved obligations are removed from the index.
+func (h *Host) indexContractStorage(so storageObligation) {
+	if so.ObligationStatus != obligationUnresolved {
+		delete(h.contractStorage, so.id())
+		return
+	}
+	h.contractStorage[so.id()] = modules.HostContractStorage{
+		ContractID:  so.id(),
+		RenterKey:   so.renterKey(),
+		UsedStorage: so.contractStorage(),
+	}
+}
+
+// rebuildContractStorage rebuilds the index of the storage used by every
+// unresolved storage obligation from the database. This is only necessary
+// when the host is loaded, the index is kept up to date whenever a storage
+// obligation is modified otherwise.
+func (h *Host) rebuildContractStorage() error {
+	h.contractStorage = make(map[types.FileContractID]modules.HostContractStorage)
+	return h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			if err := json.Unmarshal(soBytes, &so); err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			h.indexContractStorage(so)
+			return nil
+		})
+	})
+}
+
+// contractStorageUsage returns the storage used by every unresolved storage
+// obligation, sorted by the contract id.
+func (h *Host) contractStorageUsage() []modules.HostContractStorage {
+	contracts := make([]modules.HostContractStorage, 0, len(h.contractStorage))
+	for _, cs := range h.contractStorage {
+		contracts = append(contracts, cs)
+	}
+	sort.Slice(contracts, func(i, j int) bool {
+		return bytes.Compare(contracts[i].ContractID[:], contracts[j].ContractID[:]) < 0
+	})
+	return contracts
+}
@@ -0,0 +1,70 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestCheckContractStorage checks that a contract can't grow beyond the
+// maximum storage per contract, while contracts that already exceed it can
+// still be revised without adding data.
+func TestCheckContractStorage(t *testing.T) {
+	// The sectors are inserted before the check, so the obligation already
+	// contains the new sectors.
+	so := storageObligation{SectorRoots: make([]crypto.Hash, 3)}
+	if err := checkContractStorage(so, 1, 0); err != nil {
+		t.Fatal("no limit should be enforced if the maximum is 0", err)
+	}
+	if err := checkContractStorage(so, 1, 3*modules.SectorSize); err != nil {
+		t.Fatal("contract reaching the limit should be accepted", err)
+	}
+	if err := checkContractStorage(so, 1, 2*modules.SectorSize); err != errMaxStoragePerContract {
+		t.Fatal("expected errMaxStoragePerContract but got", err)
+	}
+	// Contracts above the limit are not evicted, revisions that modify or
+	// remove sectors remain possible.
+	if err := checkContractStorage(so, 0, 2*modules.SectorSize); err != nil {
+		t.Fatal("modifying a contract above the limit should be accepted", err)
+	}
+	if err := checkContractStorage(so, -1, 2*modules.SectorSize); err != nil {
+		t.Fatal("shrinking a contract above the limit should be accepted", err)
+	}
+}
+
+// TestIndexContractStorage checks that the index of the storage used by the
+// contracts follows the changes of the storage obligations and drops resolved
+// obligations.
+func TestIndexContractStorage(t *testing.T) {
+	obligation := func(seed uint64, sectors int) storageObligation {
+		return storageObligation{
+			OriginTransactionSet: []types.Transaction{{
+				FileContracts: []types.FileContract{{FileMerkleRoot: crypto.Hash{byte(seed)}}},
+			}},
+			SectorRoots: make([]crypto.Hash, sectors),
+		}
+	}
+	h := &Host{contractStorage: make(map[types.FileContractID]modules.HostContractStorage)}
+	a, b := obligation(1, 2), obligation(2, 1)
+	h.indexContractStorage(a)
+	h.indexContractStorage(b)
+	if usage := h.contractStorageUsage(); len(usage) != 2 {
+		t.Fatal("expected 2 contracts, got", usage)
+	}
+
+	// A revision updates the storage of the contract.
+	a.SectorRoots = append(a.SectorRoots, crypto.Hash{})
+	h.indexContractStorage(a)
+	if cs := h.contractStorage[a.id()]; cs.UsedStorage != 3*modules.SectorSize {
+		t.Fatal("expected the storage of 3 sectors, got", cs.UsedStorage)
+	}
+
+	// Resolved obligations are removed.
+	b.ObligationStatus = obligationSucceeded
+	h.indexContractStorage(b)
+	if usage := h.contractStorageUsage(); len(usage) != 1 || usage[0].ContractID != a.id() {
+		t.Fatal("resolved contract should be removed, got", usage)
+	}
+}
@@ -0,0 +1,52 @@
+package host
+
+var (
+	// errHostDraining is returned to renters that try to renew a contract or
+	// upload data while the host is draining.
+	errHostDraining = ErrorCommunication("host is draining and not accepting new data")
+)
+
+// Drain puts the host into drain mode. A draining host doesn't form or renew
+// contracts and rejects revisions that add data to existing contracts, but it
+// keeps serving downloads and submitting storage proofs. Unlike turning off
+// AcceptingContracts, draining also stops uploads to existing contracts, which
+// lets the host wind down before planned maintenance. The drain mode is
+// persisted, so the host stays drained if it is restarted.
+func (h *Host) Drain() error {
+	return h.managedSetDraining(true)
+}
+
+// Draining returns true if the host is in drain mode.
+func (h *Host) Draining() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.draining
+}
+
+// Undrain ends the drain mode of the host.
+func (h *Host) Undrain() error {
+	return h.managedSetDraining(false)
+}
+
+// managedSetDraining sets the drain mode of the host. The revision number is
+// increased because the drain mode changes the external settings.
+func (h *Host) managedSetDraining(draining bool) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.draining == draining {
+		return nil
+	}
+	h.draining = draining
+	h.revisionNumber++
+	if draining {
+		h.log.Println("INFO: host is draining, no longer accepting contracts and uploads")
+	} else {
+		h.log.Println("INFO: host is no longer draining")
+	}
+	return h.saveSync()
+}
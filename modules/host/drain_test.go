@@ -0,0 +1,63 @@
+package host
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// TestHostDrain checks that a draining host stops advertising that it accepts
+// contracts and that the drain mode is persisted.
+func TestHostDrain(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+	settings := ht.host.InternalSettings()
+	settings.AcceptingContracts = true
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	revision := ht.host.ExternalSettings().RevisionNumber
+	if err := ht.host.Drain(); err != nil {
+		t.Fatal(err)
+	}
+	if !ht.host.Draining() {
+		t.Fatal("host should be draining")
+	}
+	es := ht.host.ExternalSettings()
+	if es.AcceptingContracts {
+		t.Fatal("draining host shouldn't advertise that it accepts contracts")
+	}
+	if es.RevisionNumber <= revision {
+		t.Fatal("draining should bump the revision number of the settings")
+	}
+	if !ht.host.InternalSettings().AcceptingContracts {
+		t.Fatal("draining shouldn't change the internal settings")
+	}
+
+	// The drain mode survives a restart.
+	if err := ht.host.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ht.host, err = New(ht.cs, ht.gateway, ht.tpool, ht.wallet, "localhost:0", filepath.Join(ht.persistDir, modules.HostDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ht.host.Draining() {
+		t.Fatal("drain mode wasn't persisted")
+	}
+
+	if err := ht.host.Undrain(); err != nil {
+		t.Fatal(err)
+	}
+	if ht.host.Draining() || !ht.host.ExternalSettings().AcceptingContracts {
+		t.Fatal("host should be accepting contracts again")
+	}
+}
@@ -0,0 +1,222 @@
+package host
+
+// earnings.go builds an accounting export of the host's revenue. The revenue
+// of every storage obligation is taken from the obligation records, which are
+// kept after the obligation is resolved. The storage proofs are recorded
+// separately whenever the host attempts to submit one, including the attempts
+// that failed before the proof made it into the transaction pool.
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/coreos/bbolt"
+)
+
+// obligationEarnings returns the revenue of a storage obligation. Failed and
+// rejected obligations didn't earn anything, the collateral of failed
+// obligations is lost.
+func obligationEarnings(so storageObligation) modules.HostContractEarnings {
+	e := modules.HostContractEarnings{
+		ContractID:        so.id(),
+		ObligationStatus:  so.ObligationStatus.String(),
+		NegotiationHeight: so.NegotiationHeight,
+		ExpirationHeight:  so.expiration(),
+		ResolutionHeight:  so.ResolutionHeight,
+		TransactionFees:   so.TransactionFeesAdded,
+	}
+	switch so.ObligationStatus {
+	case obligationUnresolved, obligationSucceeded:
+		e.ContractRevenue = so.ContractCost
+		e.StorageRevenue = so.PotentialStorageRevenue
+		e.DownloadRevenue = so.PotentialDownloadRevenue
+		e.UploadRevenue = so.PotentialUploadRevenue
+		if so.ObligationStatus == obligationSucceeded {
+			e.CollateralReturned = so.LockedCollateral
+		}
+	case obligationFailed:
+		e.CollateralLost = so.RiskedCollateral
+		if so.LockedCollateral.Cmp(so.RiskedCollateral) > 0 {
+			e.CollateralReturned = so.LockedCollateral.Sub(so.RiskedCollateral)
+		}
+	}
+	return e
+}
+
+// managedRecordProofAttempt records the outcome of an attempt to submit a
+// storage proof. err is nil if the proof was accepted by the transaction pool.
+func (h *Host) managedRecordProofAttempt(id types.FileContractID, height types.BlockHeight, err error) {
+	attempt := modules.HostProofAttempt{
+		ContractID: id,
+		Height:     height,
+		Timestamp:  time.Now(),
+		Submitted:  err == nil,
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	key := make([]byte, 8, 8+len(id))
+	binary.BigEndian.PutUint64(key, uint64(height))
+	key = append(key, id[:]...)
+	dbErr := h.db.Update(func(tx *bolt.Tx) error {
+		attemptBytes, err := json.Marshal(attempt)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(bucketProofAttempts).Put(key, attemptBytes)
+	})
+	if dbErr != nil {
+		h.log.Println("Unable to record storage proof attempt:", dbErr)
+	}
+}
+
+// Earnings returns the revenue of the storage obligations resolved and the
+// storage proofs attempted between start and end. A zero end time leaves the
+// range open and includes the potential revenue of unresolved obligations.
+func (h *Host) Earnings(start, end time.Time) (modules.HostEarnings, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostEarnings{}, err
+	}
+	defer h.tg.Done()
+	inRange := func(t time.Time) bool {
+		return !t.Before(start) && (end.IsZero() || !t.After(end))
+	}
+
+	var earnings modules.HostEarnings
+	err := h.db.View(func(tx *bolt.Tx) error {
+		err := tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			if err := json.Unmarshal(soBytes, &so); err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			if so.ObligationStatus == obligationUnresolved && !end.IsZero() {
+				return nil
+			}
+			earnings.Contracts = append(earnings.Contracts, obligationEarnings(so))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(bucketProofAttempts).ForEach(func(_, attemptBytes []byte) error {
+			var attempt modules.HostProofAttempt
+			if err := json.Unmarshal(attemptBytes, &attempt); err != nil {
+				return build.ExtendErr("unable to unmarshal storage proof attempt:", err)
+			}
+			if inRange(attempt.Timestamp) {
+				earnings.Proofs = append(earnings.Proofs, attempt)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return modules.HostEarnings{}, err
+	}
+
+	// Obligations are dated by the timestamp of the block they were resolved
+	// in. The consensus set is queried without holding the host lock, since
+	// the consensus set holds its own lock while it updates the host.
+	contracts := earnings.Contracts[:0]
+	for _, e := range earnings.Contracts {
+		if e.ObligationStatus != obligationUnresolved.String() {
+			b, exists := h.cs.BlockAtHeight(e.ResolutionHeight)
+			if !exists {
+				continue
+			}
+			e.ResolutionTime = time.Unix(int64(b.Timestamp), 0)
+			if !inRange(e.ResolutionTime) {
+				continue
+			}
+		}
+		contracts = append(contracts, e)
+	}
+	earnings.Contracts = contracts
+	sort.SliceStable(earnings.Contracts, func(i, j int) bool {
+		ci, cj := earnings.Contracts[i], earnings.Contracts[j]
+		unresolvedI := ci.ObligationStatus == obligationUnresolved.String()
+		unresolvedJ := cj.ObligationStatus == obligationUnresolved.String()
+		if unresolvedI != unresolvedJ {
+			return unresolvedJ
+		}
+		if ci.ResolutionHeight != cj.ResolutionHeight {
+			return ci.ResolutionHeight < cj.ResolutionHeight
+		}
+		return ci.NegotiationHeight < cj.NegotiationHeight
+	})
+	return earnings, nil
+}
+
+// ExportEarnings writes the earnings between start and end as CSV to the file
+// at dst. Every row is either a contract or a storage proof attempt, as
+// indicated by the first column. Amounts are in hastings.
+func (h *Host) ExportEarnings(dst string, start, end time.Time) (err error) {
+	earnings, err := h.Earnings(start, end)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = composeErrors(err, f.Close())
+	}()
+
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	height := func(bh types.BlockHeight) string {
+		return strconv.FormatUint(uint64(bh), 10)
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"record", "contractid", "status", "height", "timestamp", "negotiationheight", "expirationheight",
+		"contractrevenue", "storagerevenue", "downloadrevenue", "uploadrevenue", "collateralreturned", "collaterallost", "transactionfees", "error"})
+	for _, e := range earnings.Contracts {
+		w.Write([]string{
+			"contract",
+			e.ContractID.String(),
+			e.ObligationStatus,
+			height(e.ResolutionHeight),
+			formatTime(e.ResolutionTime),
+			height(e.NegotiationHeight),
+			height(e.ExpirationHeight),
+			e.ContractRevenue.String(),
+			e.StorageRevenue.String(),
+			e.DownloadRevenue.String(),
+			e.UploadRevenue.String(),
+			e.CollateralReturned.String(),
+			e.CollateralLost.String(),
+			e.TransactionFees.String(),
+			"",
+		})
+	}
+	for _, p := range earnings.Proofs {
+		status := "failed"
+		if p.Submitted {
+			status = "submitted"
+		}
+		w.Write([]string{
+			"proof",
+			p.ContractID.String(),
+			status,
+			height(p.Height),
+			formatTime(p.Timestamp),
+			"", "", "", "", "", "", "", "", "",
+			p.Error,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
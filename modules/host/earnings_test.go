@@ -0,0 +1,100 @@
+package host
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestObligationEarnings checks that only succeeded and unresolved
+// obligations report revenue, and that failed obligations lose their risked
+// collateral.
+func TestObligationEarnings(t *testing.T) {
+	so := storageObligation{
+		ContractCost:             types.NewCurrency64(1),
+		PotentialStorageRevenue:  types.NewCurrency64(2),
+		PotentialDownloadRevenue: types.NewCurrency64(3),
+		PotentialUploadRevenue:   types.NewCurrency64(4),
+		LockedCollateral:         types.NewCurrency64(10),
+		RiskedCollateral:         types.NewCurrency64(6),
+		OriginTransactionSet: []types.Transaction{{
+			FileContracts: []types.FileContract{{WindowStart: 50}},
+		}},
+	}
+
+	so.ObligationStatus = obligationSucceeded
+	e := obligationEarnings(so)
+	if !e.StorageRevenue.Equals(so.PotentialStorageRevenue) || !e.CollateralReturned.Equals(so.LockedCollateral) {
+		t.Fatal("succeeded obligation should earn its revenue and get its collateral back", e)
+	}
+	if e.ExpirationHeight != 50 {
+		t.Fatal("unexpected expiration height", e.ExpirationHeight)
+	}
+
+	so.ObligationStatus = obligationFailed
+	e = obligationEarnings(so)
+	if !e.StorageRevenue.Equals(types.ZeroCurrency) || !e.ContractRevenue.Equals(types.ZeroCurrency) {
+		t.Fatal("failed obligation shouldn't earn revenue", e)
+	}
+	if !e.CollateralLost.Equals64(6) || !e.CollateralReturned.Equals64(4) {
+		t.Fatal("failed obligation should lose its risked collateral", e)
+	}
+
+	so.ObligationStatus = obligationRejected
+	e = obligationEarnings(so)
+	if !e.ContractRevenue.Equals(types.ZeroCurrency) || !e.CollateralLost.Equals(types.ZeroCurrency) {
+		t.Fatal("rejected obligation shouldn't earn or lose anything", e)
+	}
+}
+
+// TestEarningsProofAttempts checks that storage proof attempts are recorded
+// and filtered by the requested period.
+func TestEarningsProofAttempts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	before := time.Now()
+	ht.host.managedRecordProofAttempt(types.FileContractID{1}, 10, nil)
+	ht.host.managedRecordProofAttempt(types.FileContractID{2}, 11, errProofFeeTooHigh)
+
+	earnings, err := ht.host.Earnings(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(earnings.Proofs) != 2 {
+		t.Fatal("expected 2 proof attempts, got", len(earnings.Proofs))
+	}
+	if p := earnings.Proofs[0]; !p.Submitted || p.Height != 10 {
+		t.Fatal("unexpected proof attempt", p)
+	}
+	if p := earnings.Proofs[1]; p.Submitted || p.Error != errProofFeeTooHigh.Error() {
+		t.Fatal("failed proof attempt should record its error", p)
+	}
+
+	// Attempts outside of the period are left out.
+	earnings, err = ht.host.Earnings(time.Time{}, before.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(earnings.Proofs) != 0 {
+		t.Fatal("expected no proof attempts, got", len(earnings.Proofs))
+	}
+
+	// A later attempt is part of an open-ended period.
+	ht.host.managedRecordProofAttempt(types.FileContractID{3}, 12, errors.New("foo"))
+	earnings, err = ht.host.Earnings(before, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(earnings.Proofs) != 3 || earnings.Proofs[2].Error != "foo" {
+		t.Fatal("unexpected proof attempts", earnings.Proofs)
+	}
+}
@@ -67,8 +67,10 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/HyperspaceApp/Hyperspace/build"
 	"github.com/HyperspaceApp/Hyperspace/crypto"
@@ -81,9 +83,10 @@ import (
 
 const (
 	// Names of the various persistent files in the host.
-	dbFilename   = modules.HostDir + ".db"
-	logFile      = modules.HostDir + ".log"
-	settingsFile = modules.HostDir + ".json"
+	benchmarkFile = modules.HostDir + ".benchmark"
+	dbFilename    = modules.HostDir + ".db"
+	logFile       = modules.HostDir + ".log"
+	settingsFile  = modules.HostDir + ".json"
 )
 
 var (
@@ -104,6 +107,10 @@ var (
 	errNilWallet  = errors.New("host cannot use a nil wallet")
 	errNilGateway = errors.New("host cannot use nil gateway")
 
+	// errRenewalDiscountOutOfRange is returned if the renewal discount of the
+	// internal settings is negative or larger than maxRenewalDiscount.
+	errRenewalDiscountOutOfRange = fmt.Errorf("renewal discount must be between 0 and %v", maxRenewalDiscount)
+
 	// persistMetadata is the header that gets written to the persist file, and is
 	// used to recognize other persist files.
 	persistMetadata = persist.Metadata{
@@ -153,6 +160,10 @@ type Host struct {
 	recentChange      modules.ConsensusChangeID
 	unlockHash        types.UnlockHash // A wallet address that can receive coins.
 
+	// draining is set while the host winds down before maintenance. It is
+	// persisted.
+	draining bool
+
 	// Host transient fields - these fields are either determined at startup or
 	// otherwise are not critical to always be correct.
 	autoAddress          modules.NetAddress // Determined using automatic tooling in network.go
@@ -162,11 +173,35 @@ type Host struct {
 	workingStatus        modules.HostWorkingStatus
 	connectabilityStatus modules.HostConnectabilityStatus
 
+	// reservedSectors indexes the sectors stored by the renters with a
+	// reservation, keyed by the renter key. Every sector maps to the number
+	// of unresolved obligations of the renter that list it.
+	reservedSectors map[string]map[crypto.Hash]uint64
+
+	// contractStorage indexes the storage used by every unresolved storage
+	// obligation, so it can be reported without reading all obligations
+	// from the database.
+	contractStorage map[types.FileContractID]modules.HostContractStorage
+
+	// lastCapacityProofs maps the keys of renters to the time at which the
+	// host last answered one of their capacity challenges.
+	lastCapacityProofs map[string]time.Time
+
+	// sectorRootsRequests maps the ids of contracts to the times of their
+	// sector roots requests within the last sectorRootsPeriod.
+	sectorRootsRequests map[types.FileContractID][]time.Time
+
+	// benchmarking is set while a benchmark of the host is running.
+	benchmarking bool
+
 	// A map of storage obligations that are currently being modified. Locks on
 	// storage obligations can be long-running, and each storage obligation can
 	// be locked separately.
 	lockedStorageObligations map[types.FileContractID]*siasync.TryMutex
 
+	// staticRPCQueue limits the number of RPCs that are processed at once.
+	staticRPCQueue *rpcQueue
+
 	// Utilities.
 	db         *persist.BoltDatabase
 	listener   net.Listener
@@ -240,6 +275,11 @@ func newHost(dependencies modules.Dependencies, cs modules.ConsensusSet, g modul
 		dependencies: dependencies,
 
 		lockedStorageObligations: make(map[types.FileContractID]*siasync.TryMutex),
+		lastCapacityProofs:       make(map[string]time.Time),
+		reservedSectors:          make(map[string]map[crypto.Hash]uint64),
+		contractStorage:          make(map[types.FileContractID]modules.HostContractStorage),
+		sectorRootsRequests:      make(map[types.FileContractID][]time.Time),
+		staticRPCQueue:           newRPCQueue(),
 
 		persistDir: persistDir,
 	}
@@ -273,6 +313,12 @@ func newHost(dependencies modules.Dependencies, cs modules.ConsensusSet, g modul
 		}
 	})
 
+	// Remove the scratch file of a benchmark that was interrupted by a crash.
+	err = dependencies.RemoveFile(filepath.Join(h.persistDir, benchmarkFile))
+	if err != nil && !os.IsNotExist(err) {
+		h.log.Println("WARN: could not remove the benchmark file:", err)
+	}
+
 	// Add the storage manager to the host, and set up the stop call that will
 	// close the storage manager.
 	h.StorageManager, err = contractmanager.New(filepath.Join(persistDir, "contractmanager"))
@@ -400,6 +446,18 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		}
 	}
 
+	if err := verifyReservedStorage(settings.ReservedStorage); err != nil {
+		return errors.New("internal settings not updated: " + err.Error())
+	}
+	if settings.RenewalDiscount < 0 || settings.RenewalDiscount > maxRenewalDiscount {
+		return errors.New("internal settings not updated: " + errRenewalDiscountOutOfRange.Error())
+	}
+	if err := verifyRPCQueuePolicy(settings.RPCQueuePolicy); err != nil {
+		return errors.New("internal settings not updated: " + err.Error())
+	}
+	settings.ReservedStorage = copyReservedStorage(settings.ReservedStorage)
+	settings.TrustedArbiters = append([]types.SiaPublicKey(nil), settings.TrustedArbiters...)
+
 	// Check if the net address for the host has changed. If it has, and it's
 	// not equal to the auto address, then the host is going to need to make
 	// another blockchain announcement.
@@ -407,8 +465,14 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		h.announced = false
 	}
 
+	rebuildIndex := !sameReservationKeys(h.settings.ReservedStorage, settings.ReservedStorage)
 	h.settings = settings
 	h.revisionNumber++
+	if rebuildIndex {
+		if err := h.rebuildReservedSectors(); err != nil {
+			h.log.Println("ERROR: unable to index the sectors of the renters with a reservation:", err)
+		}
+	}
 
 	err = h.saveSync()
 	if err != nil {
@@ -426,5 +490,7 @@ func (h *Host) InternalSettings() modules.HostInternalSettings {
 		return modules.HostInternalSettings{}
 	}
 	defer h.tg.Done()
-	return h.settings
+	settings := h.settings
+	settings.ReservedStorage = copyReservedStorage(h.settings.ReservedStorage)
+	return settings
 }
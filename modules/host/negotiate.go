@@ -164,7 +164,7 @@ func createRevisionSignature(fcr types.FileContractRevision, renterSig types.Tra
 // collateral, and then try submitting the file contract to the transaction
 // pool. If there is no error, the completed transaction set will be returned
 // to the caller.
-func (h *Host) managedFinalizeContract(builder modules.TransactionBuilder, renterPK crypto.PublicKey, renterSignatures []types.TransactionSignature, renterRevisionSignature types.TransactionSignature, initialSectorRoots []crypto.Hash, hostCollateral, hostInitialRevenue, hostInitialRisk types.Currency, settings modules.HostExternalSettings) ([]types.TransactionSignature, types.TransactionSignature, types.FileContractID, error) {
+func (h *Host) managedFinalizeContract(builder modules.TransactionBuilder, renterPK crypto.PublicKey, arbiterPK types.SiaPublicKey, renterSignatures []types.TransactionSignature, renterRevisionSignature types.TransactionSignature, initialSectorRoots []crypto.Hash, hostCollateral, hostInitialRevenue, hostInitialRisk types.Currency, settings modules.HostExternalSettings) ([]types.TransactionSignature, types.TransactionSignature, types.FileContractID, error) {
 	for _, sig := range renterSignatures {
 		builder.AddTransactionSignature(sig)
 	}
@@ -183,14 +183,8 @@ func (h *Host) managedFinalizeContract(builder modules.TransactionBuilder, rente
 	contractTxn := fullTxnSet[len(fullTxnSet)-1]
 	fc := contractTxn.FileContracts[0]
 	noOpRevision := types.FileContractRevision{
-		ParentID: contractTxn.FileContractID(0),
-		UnlockConditions: types.UnlockConditions{
-			PublicKeys: []types.SiaPublicKey{
-				types.Ed25519PublicKey(renterPK),
-				hostSPK,
-			},
-			SignaturesRequired: 2,
-		},
+		ParentID:          contractTxn.FileContractID(0),
+		UnlockConditions:  modules.ContractUnlockConditions(types.Ed25519PublicKey(renterPK), hostSPK, arbiterPK),
 		NewRevisionNumber: fc.RevisionNumber + 1,
 
 		NewFileSize:           fc.FileSize,
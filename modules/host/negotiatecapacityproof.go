@@ -0,0 +1,211 @@
+package host
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/encoding"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// errCapacityProofInvalid is returned if a capacity challenge asks for too
+	// many sectors or for segments that don't exist, or if the probe sector
+	// has the wrong size.
+	errCapacityProofInvalid = errors.New("invalid capacity challenge")
+
+	// errCapacityProofNoSpace is returned if a capacity probe is sent to a
+	// host that doesn't have a sector of unused capacity.
+	errCapacityProofNoSpace = errors.New("host has no unused capacity for the capacity probe")
+
+	// errCapacityProofUnknownSector is returned if a capacity challenge asks
+	// for a sector that isn't stored under the contract.
+	errCapacityProofUnknownSector = errors.New("capacity challenge asks for a sector that isn't part of the contract")
+)
+
+// managedCapacityProofAllowed returns true if the cooldown since the previous
+// capacity challenge of the renter has passed, and records the new challenge
+// if it did.
+func (h *Host) managedCapacityProofAllowed(renterKey string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if time.Since(h.lastCapacityProofs[renterKey]) < capacityProofCooldown {
+		return false
+	}
+	// Drop the renters whose cooldown passed to bound the map.
+	for key, last := range h.lastCapacityProofs {
+		if time.Since(last) >= capacityProofCooldown {
+			delete(h.lastCapacityProofs, key)
+		}
+	}
+	h.lastCapacityProofs[renterKey] = time.Now()
+	return true
+}
+
+// removeCapacityProbes removes the probe sectors that were left in the storage
+// manager by capacity probes that were interrupted by a crash.
+func (h *Host) removeCapacityProbes() error {
+	return h.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketCapacityProbes)
+		var roots [][]byte
+		err := b.ForEach(func(k, _ []byte) error {
+			roots = append(roots, append([]byte(nil), k...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range roots {
+			var root crypto.Hash
+			copy(root[:], k)
+			if err := h.RemoveSector(root); err != nil {
+				h.log.Println("WARN: unable to remove the sector of an interrupted capacity probe:", err)
+			}
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// managedCapacityProbe writes the probe sector to the unused storage of the
+// host, reads it back and proves the requested segment of the sector as it was
+// read from disk. The sector is removed again before the proof is returned.
+// Its root is recorded in the database until then, so that it is removed on
+// startup if the host crashes in the meantime.
+func (h *Host) managedCapacityProbe(probe modules.CapacityProbe) (_ modules.CapacityProbeProof, err error) {
+	if _, remaining := h.capacity(); remaining < modules.SectorSize {
+		return modules.CapacityProbeProof{}, errCapacityProofNoSpace
+	}
+	root := crypto.MerkleRoot(probe.Sector)
+	err = h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketCapacityProbes).Put(root[:], []byte{})
+	})
+	if err != nil {
+		return modules.CapacityProbeProof{}, ErrorInternal("failed to record capacity probe: " + err.Error())
+	}
+	if err := h.AddSector(root, probe.Sector); err != nil {
+		h.managedRemoveCapacityProbe(root, false)
+		return modules.CapacityProbeProof{}, ErrorInternal("failed to write capacity probe: " + err.Error())
+	}
+	defer h.managedRemoveCapacityProbe(root, true)
+
+	sector, err := h.ReadSector(root)
+	if err != nil {
+		return modules.CapacityProbeProof{}, ErrorInternal("failed to read capacity probe: " + err.Error())
+	}
+	segment, hashSet := crypto.MerkleProof(sector, probe.SegmentIndex)
+	return modules.CapacityProbeProof{Segment: segment, HashSet: hashSet}, nil
+}
+
+// managedRemoveCapacityProbe removes the probe sector from the storage
+// manager if it was added, and the record of the probe from the database.
+func (h *Host) managedRemoveCapacityProbe(root crypto.Hash, added bool) {
+	if added {
+		if err := h.RemoveSector(root); err != nil {
+			h.log.Println("WARN: unable to remove the sector of a capacity probe:", err)
+			return
+		}
+	}
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketCapacityProbes).Delete(root[:])
+	})
+	if err != nil {
+		h.log.Println("WARN: unable to remove the record of a capacity probe:", err)
+	}
+}
+
+// managedRPCCapacityProof answers a capacity challenge of a renter. The renter
+// proves that it owns the contract, then asks for random segments of sectors
+// stored under the contract and sends a probe sector of random data. The host
+// reads the sectors from disk and sends back the segments together with their
+// Merkle proofs, so the renter can check them against the roots it already
+// knows. The probe sector is written to the unused storage of the host and
+// proven the same way, which shows that the advertised free space can be
+// written to. Nothing is revised, so the RPC isn't paid for; every renter can
+// only send a challenge once per cooldown.
+func (h *Host) managedRPCCapacityProof(conn net.Conn) error {
+	_, so, err := h.managedRPCRecentRevision(conn)
+	if err != nil {
+		return extendErr("failed RPCRecentRevision during RPCCapacityProof: ", err)
+	}
+	defer h.managedUnlockStorageObligation(so.id())
+
+	// Set the negotiation deadline.
+	conn.SetDeadline(time.Now().Add(modules.NegotiateCapacityProofTime))
+
+	var challenge modules.CapacityChallenge
+	maxLen := uint64(16 + modules.NegotiateMaxCapacityChallengeSectors*(crypto.HashSize+8))
+	err = encoding.ReadObject(conn, &challenge, maxLen)
+	if err != nil {
+		return ErrorConnection("failed to read capacity challenge: " + err.Error())
+	}
+	var probe modules.CapacityProbe
+	err = encoding.ReadObject(conn, &probe, 16+modules.SectorSize)
+	if err != nil {
+		return ErrorConnection("failed to read capacity probe: " + err.Error())
+	}
+	if len(challenge.SectorRoots) > modules.NegotiateMaxCapacityChallengeSectors || len(challenge.SectorRoots) != len(challenge.SegmentIndices) {
+		return modules.WriteNegotiationRejection(conn, errCapacityProofInvalid)
+	}
+	if len(probe.Sector) != 0 && (uint64(len(probe.Sector)) != modules.SectorSize || probe.SegmentIndex >= modules.SectorSize/crypto.SegmentSize) {
+		return modules.WriteNegotiationRejection(conn, errCapacityProofInvalid)
+	}
+	stored := make(map[crypto.Hash]struct{}, len(so.SectorRoots))
+	for _, root := range so.SectorRoots {
+		stored[root] = struct{}{}
+	}
+	for i, root := range challenge.SectorRoots {
+		if challenge.SegmentIndices[i] >= modules.SectorSize/crypto.SegmentSize {
+			return modules.WriteNegotiationRejection(conn, errCapacityProofInvalid)
+		}
+		if _, exists := stored[root]; !exists {
+			return modules.WriteNegotiationRejection(conn, errCapacityProofUnknownSector)
+		}
+	}
+
+	// Every challenge costs a sector write and a few sector reads, only
+	// answer one per renter and cooldown.
+	renterKey := so.renterKey()
+	if !h.managedCapacityProofAllowed(renterKey.String()) {
+		return modules.WriteNegotiationRejection(conn, modules.ErrCapacityProofCooldown)
+	}
+
+	var probeProof modules.CapacityProbeProof
+	if len(probe.Sector) != 0 {
+		probeProof, err = h.managedCapacityProbe(probe)
+		if err != nil {
+			return modules.WriteNegotiationRejection(conn, err)
+		}
+	}
+
+	var proof modules.CapacityProof
+	for i, root := range challenge.SectorRoots {
+		sector, err := h.ReadSector(root)
+		if err != nil {
+			return modules.WriteNegotiationRejection(conn, ErrorInternal("failed to read challenged sector: "+err.Error()))
+		}
+		base, hashSet := crypto.MerkleProof(sector, challenge.SegmentIndices[i])
+		proof.Segments = append(proof.Segments, base)
+		proof.HashSets = append(proof.HashSets, hashSet)
+	}
+
+	err = modules.WriteNegotiationAcceptance(conn)
+	if err != nil {
+		return ErrorConnection("failed to write acceptance during RPCCapacityProof: " + err.Error())
+	}
+	err = encoding.WriteObject(conn, proof)
+	if err != nil {
+		return ErrorConnection("failed to write capacity proof: " + err.Error())
+	}
+	err = encoding.WriteObject(conn, probeProof)
+	if err != nil {
+		return ErrorConnection("failed to write capacity probe proof: " + err.Error())
+	}
+	return nil
+}
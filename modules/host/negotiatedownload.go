@@ -262,6 +262,13 @@ func (h *Host) managedRPCDownload(conn net.Conn) error {
 		h.managedUnlockStorageObligation(so.id())
 	}()
 
+	// Wait for a free slot now that the renter is known.
+	release, err := h.managedAcquireRPCSlot(so.renterKey())
+	if err != nil {
+		return extendErr("no rpc slot for RPCDownload: ", err)
+	}
+	defer release()
+
 	// Perform a loop that will allow downloads to happen until the maximum
 	// time for a single connection has been reached.
 	for time.Now().Before(startTime.Add(iteratedConnectionTime)) {
@@ -0,0 +1,155 @@
+package host
+
+import (
+	"net"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/encoding"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+var (
+	// errDownloadSegmentsInvalid is returned if a segment request asks for
+	// too many segments or for segments that don't exist.
+	errDownloadSegmentsInvalid = ErrorCommunication("invalid segment request")
+
+	// errDownloadSegmentsUnknownSector is returned if a segment request asks
+	// for a sector that isn't stored under the contract.
+	errDownloadSegmentsUnknownSector = ErrorCommunication("segment request asks for a sector that isn't part of the contract")
+)
+
+// managedAcceptDownloadPayment reads a revision of the storage obligation that
+// pays 'price' for downloaded data, and exchanges the signatures of the
+// revision with the renter. The exchange is the same as the one of a download
+// iteration, the caller sends the data once the revision is signed.
+func (h *Host) managedAcceptDownloadPayment(conn net.Conn, so *storageObligation, price types.Currency) error {
+	h.mu.Lock()
+	blockHeight := h.blockHeight
+	secretKey := h.secretKey
+	h.mu.Unlock()
+
+	var paymentRevision types.FileContractRevision
+	err := encoding.ReadObject(conn, &paymentRevision, modules.NegotiateMaxFileContractRevisionSize)
+	if err != nil {
+		return extendErr("failed to read payment revision:", ErrorConnection(err.Error()))
+	}
+	existingRevision := so.RevisionTransactionSet[len(so.RevisionTransactionSet)-1].FileContractRevisions[0]
+	err = verifyPaymentRevision(existingRevision, paymentRevision, blockHeight, price)
+	if err != nil {
+		modules.WriteNegotiationRejection(conn, err) // Error not reported to preserve type in extendErr
+		return extendErr("payment verification failed: ", err)
+	}
+	err = modules.WriteNegotiationAcceptance(conn)
+	if err != nil {
+		return extendErr("failed to write acceptance for renter revision: ", ErrorConnection(err.Error()))
+	}
+
+	// Renter will send a transaction signature for the file contract revision.
+	var renterSignature types.TransactionSignature
+	err = encoding.ReadObject(conn, &renterSignature, modules.NegotiateMaxTransactionSignatureSize)
+	if err != nil {
+		return extendErr("failed to read renter signature: ", ErrorConnection(err.Error()))
+	}
+	txn, err := createRevisionSignature(paymentRevision, renterSignature, secretKey, blockHeight)
+	if err != nil {
+		return extendErr("failed to create revision signature: ", ErrorCommunication(modules.WriteNegotiationRejection(conn, err).Error()))
+	}
+
+	// Update the storage obligation.
+	paymentTransfer := existingRevision.NewValidProofOutputs[0].Value.Sub(paymentRevision.NewValidProofOutputs[0].Value)
+	so.PotentialDownloadRevenue = so.PotentialDownloadRevenue.Add(paymentTransfer)
+	so.RevisionTransactionSet = []types.Transaction{{
+		FileContractRevisions: []types.FileContractRevision{paymentRevision},
+		TransactionSignatures: []types.TransactionSignature{renterSignature, txn.TransactionSignatures[1]},
+	}}
+	h.mu.Lock()
+	err = h.modifyStorageObligation(*so, nil, nil, nil)
+	h.mu.Unlock()
+	if err != nil {
+		return extendErr("failed to modify storage obligation: ", ErrorInternal(modules.WriteNegotiationRejection(conn, err).Error()))
+	}
+
+	err = modules.WriteNegotiationAcceptance(conn)
+	if err != nil {
+		return extendErr("failed to write acceptance following obligation modification: ", ErrorConnection(err.Error()))
+	}
+	err = encoding.WriteObject(conn, txn.TransactionSignatures[1])
+	if err != nil {
+		return extendErr("failed to write signature: ", ErrorConnection(err.Error()))
+	}
+	return nil
+}
+
+// managedRPCDownloadSegments sends single segments of sectors stored under a
+// contract together with their Merkle proofs. The renter proves that it owns
+// the contract, asks for the segments and pays for them with a revision of the
+// contract, like it would for a download of the same size.
+func (h *Host) managedRPCDownloadSegments(conn net.Conn) error {
+	_, so, err := h.managedRPCRecentRevision(conn)
+	if err != nil {
+		return extendErr("failed RPCRecentRevision during RPCDownloadSegments: ", err)
+	}
+	defer h.managedUnlockStorageObligation(so.id())
+
+	// Wait for a free slot now that the renter is known.
+	release, err := h.managedAcquireRPCSlot(so.renterKey())
+	if err != nil {
+		return extendErr("no rpc slot for RPCDownloadSegments: ", err)
+	}
+	defer release()
+
+	conn.SetDeadline(time.Now().Add(modules.NegotiateDownloadTime))
+	var request modules.CapacityChallenge
+	maxLen := uint64(16 + modules.NegotiateMaxDownloadSegments*(crypto.HashSize+8))
+	err = encoding.ReadObject(conn, &request, maxLen)
+	if err != nil {
+		return ErrorConnection("failed to read segment request: " + err.Error())
+	}
+
+	// Check the request and read the segments before asking for the payment.
+	segments, err := func() (modules.CapacityProof, error) {
+		if len(request.SectorRoots) > modules.NegotiateMaxDownloadSegments || len(request.SectorRoots) != len(request.SegmentIndices) {
+			return modules.CapacityProof{}, errDownloadSegmentsInvalid
+		}
+		stored := make(map[crypto.Hash]struct{}, len(so.SectorRoots))
+		for _, root := range so.SectorRoots {
+			stored[root] = struct{}{}
+		}
+		var segments modules.CapacityProof
+		for i, root := range request.SectorRoots {
+			if request.SegmentIndices[i] >= modules.SectorSize/crypto.SegmentSize {
+				return modules.CapacityProof{}, errDownloadSegmentsInvalid
+			}
+			if _, exists := stored[root]; !exists {
+				return modules.CapacityProof{}, errDownloadSegmentsUnknownSector
+			}
+			sector, err := h.ReadSector(root)
+			if err != nil {
+				return modules.CapacityProof{}, ErrorInternal("failed to read requested sector: " + err.Error())
+			}
+			base, hashSet := crypto.MerkleProof(sector, request.SegmentIndices[i])
+			segments.Segments = append(segments.Segments, base)
+			segments.HashSets = append(segments.HashSets, hashSet)
+		}
+		return segments, nil
+	}()
+	if err != nil {
+		modules.WriteNegotiationRejection(conn, err) // Error not reported to preserve type in extendErr
+		return extendErr("segment request rejected: ", err)
+	}
+
+	h.mu.Lock()
+	settings := h.externalSettings()
+	h.mu.Unlock()
+	price := settings.DownloadBandwidthPrice.Mul64(modules.SegmentDownloadSize * uint64(len(request.SectorRoots)))
+	if err := h.managedAcceptDownloadPayment(conn, &so, price); err != nil {
+		return err
+	}
+	err = encoding.WriteObject(conn, segments)
+	if err != nil {
+		return extendErr("failed to write segments: ", ErrorConnection(err.Error()))
+	}
+	return nil
+}
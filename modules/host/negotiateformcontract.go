@@ -63,8 +63,9 @@ func (h *Host) managedAddCollateral(settings modules.HostExternalSettings, txnSe
 // managedRPCFormContract accepts a file contract from a renter, checks the
 // file contract for compliance with the host settings, and then commits to the
 // file contract, creating a storage obligation and submitting the contract to
-// the blockchain.
-func (h *Host) managedRPCFormContract(conn net.Conn) error {
+// the blockchain. If the contract is arbitrated, the renter also sends the key
+// of the arbiter that is part of the contract's unlock conditions.
+func (h *Host) managedRPCFormContract(conn net.Conn, arbitrated bool) error {
 	// Send the host settings to the renter.
 	err := h.managedRPCSettings(conn)
 	if err != nil {
@@ -105,10 +106,21 @@ func (h *Host) managedRPCFormContract(conn net.Conn) error {
 	if err != nil {
 		return extendErr("could not read renter public key: ", ErrorConnection(err.Error()))
 	}
+	var arbiterPK types.SiaPublicKey
+	if arbitrated {
+		err = encoding.ReadObject(conn, &arbiterPK, modules.NegotiateMaxSiaPubkeySize)
+		if err != nil {
+			return extendErr("could not read arbiter public key: ", ErrorConnection(err.Error()))
+		}
+		if len(arbiterPK.Key) == 0 {
+			modules.WriteNegotiationRejection(conn, errUntrustedArbiter) // Error ignored to preserve type in extendErr
+			return extendErr("arbitrated contract without arbiter: ", errUntrustedArbiter)
+		}
+	}
 
 	// The host verifies that the file contract coming over the wire is
 	// acceptable.
-	err = h.managedVerifyNewContract(txnSet, renterPK, settings)
+	err = h.managedVerifyNewContract(txnSet, renterPK, arbiterPK, settings)
 	if err != nil {
 		// The incoming file contract is not acceptable to the host, indicate
 		// why to the renter.
@@ -167,7 +179,7 @@ func (h *Host) managedRPCFormContract(conn net.Conn) error {
 	h.mu.RLock()
 	hostCollateral := contractCollateral(settings, txnSet[len(txnSet)-1].FileContracts[0])
 	h.mu.RUnlock()
-	hostTxnSignatures, hostRevisionSignature, newSOID, err := h.managedFinalizeContract(txnBuilder, renterPK, renterTxnSignatures, renterRevisionSignature, nil, hostCollateral, types.ZeroCurrency, types.ZeroCurrency, settings)
+	hostTxnSignatures, hostRevisionSignature, newSOID, err := h.managedFinalizeContract(txnBuilder, renterPK, arbiterPK, renterTxnSignatures, renterRevisionSignature, nil, hostCollateral, types.ZeroCurrency, types.ZeroCurrency, settings)
 	if err != nil {
 		// The incoming file contract is not acceptable to the host, indicate
 		// why to the renter.
@@ -194,7 +206,7 @@ func (h *Host) managedRPCFormContract(conn net.Conn) error {
 
 // managedVerifyNewContract checks that an incoming file contract matches the host's
 // expectations for a valid contract.
-func (h *Host) managedVerifyNewContract(txnSet []types.Transaction, renterPK crypto.PublicKey, eSettings modules.HostExternalSettings) error {
+func (h *Host) managedVerifyNewContract(txnSet []types.Transaction, renterPK crypto.PublicKey, arbiterPK types.SiaPublicKey, eSettings modules.HostExternalSettings) error {
 	// Check that the transaction set is not empty.
 	if len(txnSet) < 1 {
 		return extendErr("zero-length transaction set: ", errEmptyObject)
@@ -271,16 +283,21 @@ func (h *Host) managedVerifyNewContract(txnSet []types.Transaction, renterPK cry
 	if lockedStorageCollateral.Add(expectedCollateral).Cmp(iSettings.CollateralBudget) > 0 {
 		return errCollateralBudgetExceeded
 	}
+	// Check that the renter will be able to store at least one sector without
+	// touching storage which is reserved for other renters.
+	if err := h.managedCheckReservedStorage(types.Ed25519PublicKey(renterPK), modules.SectorSize); err != nil {
+		return err
+	}
+
+	// An arbiter can revise the contract together with the renter, the host
+	// only accepts arbiters it trusts.
+	if !trustedArbiter(iSettings, arbiterPK) {
+		return errUntrustedArbiter
+	}
 
 	// The unlock hash for the file contract must match the unlock hash that
 	// the host knows how to spend.
-	expectedUH := types.UnlockConditions{
-		PublicKeys: []types.SiaPublicKey{
-			types.Ed25519PublicKey(renterPK),
-			publicKey,
-		},
-		SignaturesRequired: 2,
-	}.UnlockHash()
+	expectedUH := modules.ContractUnlockConditions(types.Ed25519PublicKey(renterPK), publicKey, arbiterPK).UnlockHash()
 	if fc.UnlockHash != expectedUH {
 		return errBadUnlockHash
 	}
@@ -74,8 +74,9 @@ func (h *Host) managedVerifyChallengeResponse(fcid types.FileContractID, challen
 
 	// Verify that the challegne response matches the public key.
 	var renterPK crypto.PublicKey
-	// Sanity check - there should be two public keys.
-	if len(recentRevision.UnlockConditions.PublicKeys) != 2 {
+	// Sanity check - there should be two public keys, or three if the
+	// contract has an arbiter.
+	if n := len(recentRevision.UnlockConditions.PublicKeys); n != 2 && n != 3 {
 		// The error has to be set here so that the defered error check will
 		// unlock the storage obligation.
 		h.log.Critical("wrong public key count in file contract revision")
@@ -87,8 +87,17 @@ func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 		h.managedUnlockStorageObligation(so.id())
 	}()
 
-	// Perform the host settings exchange with the renter.
-	err = h.managedRPCSettings(conn)
+	// Wait for a free slot now that the renter is known.
+	release, err := h.managedAcquireRPCSlot(so.renterKey())
+	if err != nil {
+		return extendErr("no rpc slot for RPCRenewContract: ", err)
+	}
+	defer release()
+
+	// Perform the host settings exchange with the renter. The recent revision
+	// identified the contract that is renewed, so the renter is sent the
+	// renewal prices.
+	err = h.managedRPCRenewSettings(conn)
 	if err != nil {
 		return extendErr("RPCSettings failed: ", err)
 	}
@@ -119,9 +128,18 @@ func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 	}
 
 	h.mu.Lock()
-	settings := h.externalSettings()
+	settings := h.renewalSettings()
+	draining := h.draining
 	h.mu.Unlock()
 
+	// A draining host doesn't renew contracts. The renter only learns about
+	// it once it has sent the renewal, since existing contracts are revised
+	// regardless of AcceptingContracts.
+	if draining {
+		modules.WriteNegotiationRejection(conn, errHostDraining) // Error is ignored to preserve type for extendErr
+		return extendErr("renewal rejected: ", errHostDraining)
+	}
+
 	// Verify that the transaction coming over the wire is a proper renewal.
 	err = h.managedVerifyRenewedContract(so, txnSet, renterPK)
 	if err != nil {
@@ -182,7 +200,7 @@ func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 	renewRevenue := renewBasePrice(so, settings, fc)
 	renewRisk := renewBaseCollateral(so, settings, fc)
 	h.mu.RUnlock()
-	hostTxnSignatures, hostRevisionSignature, newSOID, err := h.managedFinalizeContract(txnBuilder, renterPK, renterTxnSignatures, renterRevisionSignature, so.SectorRoots, renewCollateral, renewRevenue, renewRisk, settings)
+	hostTxnSignatures, hostRevisionSignature, newSOID, err := h.managedFinalizeContract(txnBuilder, renterPK, so.arbiterKey(), renterTxnSignatures, renterRevisionSignature, so.SectorRoots, renewCollateral, renewRevenue, renewRisk, settings)
 	if err != nil {
 		modules.WriteNegotiationRejection(conn, err) // Error is ignored to preserve type for extendErr
 		return extendErr("failed to finalize contract: ", err)
@@ -219,7 +237,7 @@ func (h *Host) managedVerifyRenewedContract(so storageObligation, txnSet []types
 
 	h.mu.Lock()
 	blockHeight := h.blockHeight
-	externalSettings := h.externalSettings()
+	externalSettings := h.renewalSettings()
 	internalSettings := h.settings
 	lockedStorageCollateral := h.financialMetrics.LockedStorageCollateral
 	publicKey := h.publicKey
@@ -290,15 +308,16 @@ func (h *Host) managedVerifyRenewedContract(so storageObligation, txnSet []types
 		return errLowVoidOutput
 	}
 
+	// The renewed contract keeps the arbiter of the previous contract, as
+	// long as the host still trusts it.
+	arbiterPK := so.arbiterKey()
+	if !trustedArbiter(internalSettings, arbiterPK) {
+		return errUntrustedArbiter
+	}
+
 	// The unlock hash for the file contract must match the unlock hash that
 	// the host knows how to spend.
-	expectedUH := types.UnlockConditions{
-		PublicKeys: []types.SiaPublicKey{
-			types.Ed25519PublicKey(renterPK),
-			publicKey,
-		},
-		SignaturesRequired: 2,
-	}.UnlockHash()
+	expectedUH := modules.ContractUnlockConditions(types.Ed25519PublicKey(renterPK), publicKey, arbiterPK).UnlockHash()
 	if fc.UnlockHash != expectedUH {
 		return errBadUnlockHash
 	}
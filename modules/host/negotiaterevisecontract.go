@@ -42,6 +42,8 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 	settings := h.externalSettings()
 	secretKey := h.secretKey
 	blockHeight := h.blockHeight
+	draining := h.draining
+	maxContractStorage := h.settings.MaxStoragePerContract
 	h.mu.Unlock()
 
 	// The renter is going to send its intended modifications, followed by the
@@ -136,6 +138,22 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 				return errUnknownModification
 			}
 		}
+		// A draining host only accepts revisions that remove data.
+		if draining && len(sectorsGained) > 0 {
+			return errHostDraining
+		}
+		// Inserting sectors grows the amount of data stored for the renter,
+		// which must not come out of storage reserved for someone else.
+		if newSectors := len(sectorsGained) - len(sectorsRemoved); newSectors > 0 {
+			if err := h.managedCheckReservedStorage(so.renterKey(), uint64(newSectors)*modules.SectorSize); err != nil {
+				return err
+			}
+		}
+		// A single contract may not grow beyond the limit of the host.
+		if err := checkContractStorage(*so, len(sectorsGained)-len(sectorsRemoved), maxContractStorage); err != nil {
+			return err
+		}
+
 		newRevenue := storageRevenue.Add(bandwidthRevenue)
 		return extendErr("unable to verify updated contract: ", verifyRevision(*so, revision, blockHeight, newRevenue, newCollateral))
 	}()
@@ -211,6 +229,13 @@ func (h *Host) managedRPCReviseContract(conn net.Conn) error {
 		h.managedUnlockStorageObligation(so.id())
 	}()
 
+	// Wait for a free slot now that the renter is known.
+	release, err := h.managedAcquireRPCSlot(so.renterKey())
+	if err != nil {
+		return extendErr("no rpc slot for RPCReviseContract: ", err)
+	}
+	defer release()
+
 	// Begin the revision loop. The host will process revisions until a
 	// timeout is reached, or until the renter sends a StopResponse.
 	for timeoutReached := false; !timeoutReached; {
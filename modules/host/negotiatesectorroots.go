@@ -0,0 +1,76 @@
+package host
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/encoding"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// errSectorRootsLimit is returned if the sector roots of a contract were
+// requested too often within sectorRootsPeriod.
+var errSectorRootsLimit = errors.New("host sent the sector roots of the contract too often recently, try again later")
+
+// managedSectorRootsAllowed returns true if fewer than sectorRootsLimit
+// requests for the sector roots of the contract were answered within the last
+// sectorRootsPeriod, and records the new request if so.
+func (h *Host) managedSectorRootsAllowed(id types.FileContractID) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// Drop the requests that are older than the period to bound the map.
+	for fcid, requests := range h.sectorRootsRequests {
+		i := 0
+		for i < len(requests) && time.Since(requests[i]) >= sectorRootsPeriod {
+			i++
+		}
+		if i == len(requests) {
+			delete(h.sectorRootsRequests, fcid)
+		} else {
+			h.sectorRootsRequests[fcid] = requests[i:]
+		}
+	}
+	if len(h.sectorRootsRequests[id]) >= sectorRootsLimit {
+		return false
+	}
+	h.sectorRootsRequests[id] = append(h.sectorRootsRequests[id], time.Now())
+	return true
+}
+
+// managedRPCSectorRoots sends the Merkle roots of all sectors stored under a
+// contract to the renter. The renter proves that it owns the contract during
+// the revision exchange, and can check the roots against the Merkle root of
+// the revision it receives. Nothing is revised, so the RPC isn't paid for;
+// the roots of every contract are only sent sectorRootsLimit times per
+// sectorRootsPeriod.
+func (h *Host) managedRPCSectorRoots(conn net.Conn) error {
+	_, so, err := h.managedRPCRecentRevision(conn)
+	if err != nil {
+		return extendErr("failed RPCRecentRevision during RPCSectorRoots: ", err)
+	}
+	defer h.managedUnlockStorageObligation(so.id())
+
+	conn.SetDeadline(time.Now().Add(modules.NegotiateSectorRootsTime))
+	if !h.managedSectorRootsAllowed(so.id()) {
+		return modules.WriteNegotiationRejection(conn, errSectorRootsLimit)
+	}
+
+	// Wait for a free slot now that the renter is known.
+	release, err := h.managedAcquireRPCSlot(so.renterKey())
+	if err != nil {
+		return extendErr("no rpc slot for RPCSectorRoots: ", modules.WriteNegotiationRejection(conn, err))
+	}
+	defer release()
+
+	err = modules.WriteNegotiationAcceptance(conn)
+	if err != nil {
+		return ErrorConnection("failed to write acceptance during RPCSectorRoots: " + err.Error())
+	}
+	err = encoding.WriteObject(conn, so.SectorRoots)
+	if err != nil {
+		return ErrorConnection("failed to write sector roots during RPCSectorRoots: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestSectorRootsAllowed checks that the sector roots requests of a contract
+// are limited per period and that expired requests are dropped.
+func TestSectorRootsAllowed(t *testing.T) {
+	h := &Host{sectorRootsRequests: make(map[types.FileContractID][]time.Time)}
+	limited, other := types.FileContractID{1}, types.FileContractID{2}
+	for i := 0; i < sectorRootsLimit; i++ {
+		if !h.managedSectorRootsAllowed(limited) {
+			t.Fatal("request within the limit was rejected", i)
+		}
+	}
+	if h.managedSectorRootsAllowed(limited) {
+		t.Fatal("request above the limit was allowed")
+	}
+	// The limit applies per contract.
+	if !h.managedSectorRootsAllowed(other) {
+		t.Fatal("request of another contract was rejected")
+	}
+
+	// Once the period passed the contract can be requested again and the
+	// expired requests are dropped.
+	expired := time.Now().Add(-sectorRootsPeriod)
+	for i := range h.sectorRootsRequests[limited] {
+		h.sectorRootsRequests[limited][i] = expired
+	}
+	h.sectorRootsRequests[other][0] = expired
+	if !h.managedSectorRootsAllowed(limited) {
+		t.Fatal("request after the period was rejected")
+	}
+	if len(h.sectorRootsRequests[limited]) != 1 {
+		t.Fatal("expired requests weren't dropped", len(h.sectorRootsRequests[limited]))
+	}
+	if _, exists := h.sectorRootsRequests[other]; exists {
+		t.Fatal("contract without recent requests wasn't dropped")
+	}
+}
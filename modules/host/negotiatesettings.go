@@ -44,7 +44,7 @@ func (h *Host) externalSettings() modules.HostExternalSettings {
 	}
 
 	return modules.HostExternalSettings{
-		AcceptingContracts:   h.settings.AcceptingContracts,
+		AcceptingContracts:   h.settings.AcceptingContracts && !h.draining,
 		MaxDownloadBatchSize: h.settings.MaxDownloadBatchSize,
 		MaxDuration:          h.settings.MaxDuration,
 		MaxReviseBatchSize:   h.settings.MaxReviseBatchSize,
@@ -68,6 +68,43 @@ func (h *Host) externalSettings() modules.HostExternalSettings {
 	}
 }
 
+// discountSettings returns the settings with the contract price and the
+// storage price lowered by the discount. Bandwidth is paid for in later
+// revisions of the contract, which use the regular settings, so the bandwidth
+// prices are left alone.
+func discountSettings(settings modules.HostExternalSettings, discount float64) modules.HostExternalSettings {
+	if discount <= 0 {
+		return settings
+	}
+	settings.ContractPrice = settings.ContractPrice.MulFloat(1 - discount)
+	settings.StoragePrice = settings.StoragePrice.MulFloat(1 - discount)
+	return settings
+}
+
+// renewalSettings returns the external settings that apply to the renewal of
+// an existing contract.
+func (h *Host) renewalSettings() modules.HostExternalSettings {
+	return discountSettings(h.externalSettings(), h.settings.RenewalDiscount)
+}
+
+// managedRPCRenewSettings is the settings exchange of a contract renewal. It
+// is the same as managedRPCSettings, except that the renter is sent the
+// discounted prices of a renewal.
+func (h *Host) managedRPCRenewSettings(conn net.Conn) error {
+	conn.SetDeadline(time.Now().Add(modules.NegotiateSettingsTime))
+
+	h.mu.Lock()
+	secretKey := h.secretKey
+	hes := h.renewalSettings()
+	h.mu.Unlock()
+
+	err := crypto.WriteSignedObject(conn, hes, secretKey)
+	if err != nil {
+		return ErrorConnection("failed WriteSignedObject during RPCRenewSettings: " + err.Error())
+	}
+	return nil
+}
+
 // managedRPCSettings is an rpc that returns the host's settings.
 func (h *Host) managedRPCSettings(conn net.Conn) error {
 	// Set the negotiation deadline.
@@ -270,18 +270,28 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 	}
 
 	switch id {
+	case modules.RPCCapacityProof:
+		err = extendErr("incoming RPCCapacityProof failed: ", h.managedRPCCapacityProof(conn))
 	case modules.RPCDownload:
 		atomic.AddUint64(&h.atomicDownloadCalls, 1)
 		err = extendErr("incoming RPCDownload failed: ", h.managedRPCDownload(conn))
+	case modules.RPCDownloadSegments:
+		atomic.AddUint64(&h.atomicDownloadCalls, 1)
+		err = extendErr("incoming RPCDownloadSegments failed: ", h.managedRPCDownloadSegments(conn))
 	case modules.RPCRenewContract:
 		atomic.AddUint64(&h.atomicRenewCalls, 1)
 		err = extendErr("incoming RPCRenewContract failed: ", h.managedRPCRenewContract(conn))
 	case modules.RPCFormContract:
 		atomic.AddUint64(&h.atomicFormContractCalls, 1)
-		err = extendErr("incoming RPCFormContract failed: ", h.managedRPCFormContract(conn))
+		err = extendErr("incoming RPCFormContract failed: ", h.managedRPCFormContract(conn, false))
+	case modules.RPCFormArbitratedContract:
+		atomic.AddUint64(&h.atomicFormContractCalls, 1)
+		err = extendErr("incoming RPCFormArbitratedContract failed: ", h.managedRPCFormContract(conn, true))
 	case modules.RPCReviseContract:
 		atomic.AddUint64(&h.atomicReviseCalls, 1)
 		err = extendErr("incoming RPCReviseContract failed: ", h.managedRPCReviseContract(conn))
+	case modules.RPCSectorRoots:
+		err = extendErr("incoming RPCSectorRoots failed: ", h.managedRPCSectorRoots(conn))
 	case modules.RPCSettings:
 		atomic.AddUint64(&h.atomicSettingsCalls, 1)
 		err = extendErr("incoming RPCSettings failed: ", h.managedRPCSettings(conn))
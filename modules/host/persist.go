@@ -23,6 +23,7 @@ type persistence struct {
 	// Host Identity.
 	Announced        bool                         `json:"announced"`
 	AutoAddress      modules.NetAddress           `json:"autoaddress"`
+	Draining         bool                         `json:"draining"`
 	FinancialMetrics modules.HostFinancialMetrics `json:"financialmetrics"`
 	PublicKey        types.SiaPublicKey           `json:"publickey"`
 	RevisionNumber   uint64                       `json:"revisionnumber"`
@@ -41,6 +42,7 @@ func (h *Host) persistData() persistence {
 		// Host Identity.
 		Announced:        h.announced,
 		AutoAddress:      h.autoAddress,
+		Draining:         h.draining,
 		FinancialMetrics: h.financialMetrics,
 		PublicKey:        h.publicKey,
 		RevisionNumber:   h.revisionNumber,
@@ -58,6 +60,7 @@ func (h *Host) establishDefaults() error {
 		MaxDownloadBatchSize: uint64(defaultMaxDownloadBatchSize),
 		MaxDuration:          defaultMaxDuration,
 		MaxReviseBatchSize:   uint64(defaultMaxReviseBatchSize),
+		PruneGracePeriod:     defaultPruneGracePeriod,
 		WindowSize:           defaultWindowSize,
 
 		Collateral:       defaultCollateral,
@@ -97,6 +100,7 @@ func (h *Host) loadPersistObject(p *persistence) {
 		h.log.Printf("WARN: AutoAddress '%v' loaded from persist is invalid: %v", p.AutoAddress, err)
 		h.autoAddress = ""
 	}
+	h.draining = p.Draining
 	h.financialMetrics = p.FinancialMetrics
 	h.publicKey = p.PublicKey
 	h.revisionNumber = p.RevisionNumber
@@ -129,6 +133,8 @@ func (h *Host) initDB() (err error) {
 		// database needs to be initialized. Create the database buckets.
 		buckets := [][]byte{
 			bucketActionItems,
+			bucketCapacityProbes,
+			bucketProofAttempts,
 			bucketStorageObligations,
 		}
 		for _, bucket := range buckets {
@@ -196,6 +202,15 @@ func (h *Host) load() error {
 	if err != nil {
 		return err
 	}
+	if err := h.rebuildReservedSectors(); err != nil {
+		return build.ExtendErr("unable to index the sectors of the renters with a reservation:", err)
+	}
+	if err := h.rebuildContractStorage(); err != nil {
+		return build.ExtendErr("unable to index the storage used by the contracts:", err)
+	}
+	if err := h.removeCapacityProbes(); err != nil {
+		return build.ExtendErr("unable to remove the sectors of interrupted capacity probes:", err)
+	}
 
 	return h.initConsensusSubscription()
 }
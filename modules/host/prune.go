@@ -0,0 +1,132 @@
+package host
+
+import (
+	"encoding/json"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/coreos/bbolt"
+)
+
+// pruneHeight returns the height at which the sectors of a resolved storage
+// obligation can be removed. Pruning happens at least one block after the
+// resolution, so that it is always handled by an action item.
+func (so storageObligation) pruneHeight(gracePeriod types.BlockHeight) types.BlockHeight {
+	if gracePeriod == 0 {
+		gracePeriod = 1
+	}
+	return so.ResolutionHeight + gracePeriod
+}
+
+// remainingStorage returns the sum of the remaining capacity of all storage
+// folders.
+func (h *Host) remainingStorage() (remaining uint64) {
+	for _, sf := range h.StorageFolders() {
+		remaining += sf.CapacityRemaining
+	}
+	return remaining
+}
+
+// managedPruneStorageObligation removes the sectors of a resolved storage
+// obligation and returns the number of sectors that were dereferenced. The
+// contract manager keeps a reference count for every sector, so a sector that
+// is shared with another obligation stays on disk until the last obligation
+// using it is pruned. The caller needs to hold the lock of the storage
+// obligation.
+func (h *Host) managedPruneStorageObligation(so storageObligation) (uint64, error) {
+	if so.ObligationStatus == obligationUnresolved {
+		build.Critical("pruning unresolved storage obligation", so.id())
+		return 0, nil
+	}
+	roots := so.SectorRoots
+	if len(roots) == 0 {
+		return 0, nil
+	}
+
+	// The sector roots are removed from the database before the sectors are
+	// removed. If the host crashes in between, the sectors are leaked instead
+	// of being dereferenced twice, which could delete sectors that are still
+	// used by other obligations.
+	so.SectorRoots = nil
+	h.mu.Lock()
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		return putStorageObligation(tx, so)
+	})
+	h.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	// Error is not checked, we want to call remove on every sector even if
+	// there are problems - disk health information will be updated.
+	_ = h.RemoveSectorBatch(roots)
+	h.log.Debugln("Pruned", len(roots), "sectors of storage obligation", so.id())
+	return uint64(len(roots)), nil
+}
+
+// PruneStorageObligations removes the sectors of all storage obligations that
+// were resolved more than PruneGracePeriod blocks ago. Obligations are also
+// pruned automatically once their grace period has passed, calling this is
+// only necessary after the grace period was reduced.
+func (h *Host) PruneStorageObligations() (modules.HostPruneResult, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostPruneResult{}, err
+	}
+	defer h.tg.Done()
+
+	// Find the obligations which are due.
+	var soids []types.FileContractID
+	h.mu.RLock()
+	blockHeight := h.blockHeight
+	gracePeriod := h.settings.PruneGracePeriod
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			if err := json.Unmarshal(soBytes, &so); err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			if so.ObligationStatus != obligationUnresolved && len(so.SectorRoots) > 0 && blockHeight >= so.pruneHeight(gracePeriod) {
+				soids = append(soids, so.id())
+			}
+			return nil
+		})
+	})
+	h.mu.RUnlock()
+	if err != nil {
+		return modules.HostPruneResult{}, err
+	}
+
+	var result modules.HostPruneResult
+	remainingBefore := h.remainingStorage()
+	for _, soid := range soids {
+		// The obligation might have been pruned by an action item in the
+		// meantime, so it is fetched again while it is locked.
+		h.managedLockStorageObligation(soid)
+		var so storageObligation
+		h.mu.RLock()
+		err := h.db.View(func(tx *bolt.Tx) (err error) {
+			so, err = getStorageObligation(tx, soid)
+			return err
+		})
+		h.mu.RUnlock()
+		var removed uint64
+		if err == nil {
+			removed, err = h.managedPruneStorageObligation(so)
+		}
+		h.managedUnlockStorageObligation(soid)
+		if err != nil {
+			return result, err
+		}
+		if removed > 0 {
+			result.ObligationsPruned++
+			result.SectorsRemoved += removed
+		}
+	}
+	// Sectors might be added concurrently, so the reclaimed storage is only
+	// an estimate.
+	if remainingAfter := h.remainingStorage(); remainingAfter > remainingBefore {
+		result.ReclaimedStorage = remainingAfter - remainingBefore
+	}
+	return result, nil
+}
@@ -0,0 +1,144 @@
+package host
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+
+	"github.com/coreos/bbolt"
+)
+
+// TestPruneHeight checks that resolved storage obligations are pruned after
+// the grace period, but never at the height they were resolved at.
+func TestPruneHeight(t *testing.T) {
+	so := storageObligation{ResolutionHeight: 100}
+	if height := so.pruneHeight(10); height != 110 {
+		t.Fatal("expected a prune height of 110 but got", height)
+	}
+	if height := so.pruneHeight(0); height != 101 {
+		t.Fatal("expected a prune height of 101 but got", height)
+	}
+}
+
+// TestPruneStorageObligations checks that the sectors of a resolved storage
+// obligation are removed once the grace period has passed, while sectors that
+// are shared with another obligation are kept until that obligation is pruned
+// as well.
+func TestPruneStorageObligations(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Add two obligations that share a sector.
+	sharedRoot, sharedData := randSector()
+	ownRoot, ownData := randSector()
+	addObligation := func(roots []crypto.Hash, data [][]byte) storageObligation {
+		so, err := ht.newTesterStorageObligation()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ht.host.managedLockStorageObligation(so.id())
+		defer ht.host.managedUnlockStorageObligation(so.id())
+		if err := ht.host.managedAddStorageObligation(so); err != nil {
+			t.Fatal(err)
+		}
+		so.SectorRoots = roots
+		ht.host.mu.Lock()
+		err = ht.host.modifyStorageObligation(so, nil, roots, data)
+		ht.host.mu.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return so
+	}
+	pruned := addObligation([]crypto.Hash{ownRoot, sharedRoot}, [][]byte{ownData, sharedData})
+	kept := addObligation([]crypto.Hash{sharedRoot}, [][]byte{sharedData})
+	resolve := func(so storageObligation) {
+		ht.host.managedLockStorageObligation(so.id())
+		defer ht.host.managedUnlockStorageObligation(so.id())
+		ht.host.mu.Lock()
+		defer ht.host.mu.Unlock()
+		if err := ht.host.removeStorageObligation(so, obligationSucceeded); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sectorRoots := func(so storageObligation) []crypto.Hash {
+		ht.host.mu.RLock()
+		defer ht.host.mu.RUnlock()
+		var roots []crypto.Hash
+		err := ht.host.db.View(func(tx *bolt.Tx) error {
+			so, err := getStorageObligation(tx, so.id())
+			roots = so.SectorRoots
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return roots
+	}
+
+	// Resolve the first obligation. Nothing is pruned during the grace period.
+	ht.host.mu.Lock()
+	ht.host.settings.PruneGracePeriod = 5
+	ht.host.mu.Unlock()
+	resolve(pruned)
+	if _, err := ht.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := ht.host.PruneStorageObligations(); err != nil {
+		t.Fatal(err)
+	} else if result.ObligationsPruned != 0 || result.SectorsRemoved != 0 {
+		t.Fatal("obligation was pruned during the grace period", result)
+	}
+
+	// Reduce the grace period and prune the obligation manually.
+	ht.host.mu.Lock()
+	ht.host.settings.PruneGracePeriod = 1
+	ht.host.mu.Unlock()
+	result, err := ht.host.PruneStorageObligations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ObligationsPruned != 1 || result.SectorsRemoved != 2 {
+		t.Fatal("expected 1 obligation with 2 sectors to be pruned, got", result)
+	}
+	if len(sectorRoots(pruned)) != 0 {
+		t.Fatal("sector roots of the pruned obligation weren't cleared")
+	}
+	if _, err := ht.host.ReadSector(ownRoot); err == nil {
+		t.Fatal("sector of the pruned obligation wasn't removed")
+	}
+	if data, err := ht.host.ReadSector(sharedRoot); err != nil || len(data) != len(sharedData) {
+		t.Fatal("shared sector was removed:", err)
+	}
+
+	// The second obligation is pruned by its action item once its grace
+	// period has passed, which removes the shared sector.
+	resolve(kept)
+	for i := 0; i < 2; i++ {
+		if _, err := ht.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if len(sectorRoots(kept)) != 0 {
+			return errors.New("sector roots of the second obligation weren't cleared")
+		}
+		if _, err := ht.host.ReadSector(sharedRoot); err == nil {
+			return errors.New("shared sector wasn't removed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
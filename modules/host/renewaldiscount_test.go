@@ -0,0 +1,65 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestDiscountSettings checks that the renewal discount only lowers the
+// contract price and the storage price.
+func TestDiscountSettings(t *testing.T) {
+	settings := modules.HostExternalSettings{
+		ContractPrice:          types.NewCurrency64(1000),
+		StoragePrice:           types.NewCurrency64(100),
+		UploadBandwidthPrice:   types.NewCurrency64(10),
+		DownloadBandwidthPrice: types.NewCurrency64(10),
+	}
+	if discounted := discountSettings(settings, 0); !discounted.ContractPrice.Equals(settings.ContractPrice) || !discounted.StoragePrice.Equals(settings.StoragePrice) {
+		t.Fatal("settings shouldn't change without a discount")
+	}
+	discounted := discountSettings(settings, 0.2)
+	if !discounted.ContractPrice.Equals64(800) || !discounted.StoragePrice.Equals64(80) {
+		t.Fatal("prices weren't discounted", discounted.ContractPrice, discounted.StoragePrice)
+	}
+	if !discounted.UploadBandwidthPrice.Equals64(10) || !discounted.DownloadBandwidthPrice.Equals64(10) {
+		t.Fatal("bandwidth prices shouldn't be discounted")
+	}
+}
+
+// TestSetRenewalDiscount checks that the renewal discount of the internal
+// settings is bounded.
+func TestSetRenewalDiscount(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	for _, discount := range []float64{-0.1, maxRenewalDiscount + 0.01} {
+		settings := ht.host.InternalSettings()
+		settings.RenewalDiscount = discount
+		if err := ht.host.SetInternalSettings(settings); err == nil {
+			t.Fatal("renewal discount out of range was accepted:", discount)
+		}
+	}
+	settings := ht.host.InternalSettings()
+	settings.RenewalDiscount = maxRenewalDiscount
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// Renewals are offered the discounted prices, everyone else pays the
+	// regular prices.
+	ht.host.mu.Lock()
+	regular := ht.host.externalSettings()
+	renewal := ht.host.renewalSettings()
+	ht.host.mu.Unlock()
+	if renewal.StoragePrice.Cmp(regular.StoragePrice) >= 0 || renewal.ContractPrice.Cmp(regular.ContractPrice) >= 0 {
+		t.Fatal("renewal prices weren't discounted")
+	}
+}
@@ -0,0 +1,225 @@
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// errInsufficientUnreservedStorage is returned if a renter tries to
+	// allocate storage that the host has set aside for other renters.
+	errInsufficientUnreservedStorage = ErrorInternal("host does not have enough unreserved storage available for this renter")
+
+	// errInvalidReservationKey is returned if the reserved storage settings
+	// contain a key which is not a valid renter public key.
+	errInvalidReservationKey = errors.New("reserved storage contains an invalid renter public key")
+)
+
+// availableStorage returns the number of bytes that the renter identified by
+// renterKey may still allocate on the host. Storage that is reserved for other
+// renters and not yet used by them is off limits. The unused part of the
+// renter's own reservation is already included in remaining.
+func availableStorage(remaining uint64, reservations, usage map[string]uint64, renterKey string) uint64 {
+	var unusedByOthers uint64
+	for key, reserved := range reservations {
+		if key == renterKey || usage[key] >= reserved {
+			continue
+		}
+		unusedByOthers += reserved - usage[key]
+	}
+	if unusedByOthers >= remaining {
+		return 0
+	}
+	return remaining - unusedByOthers
+}
+
+// copyReservedStorage returns a copy of the provided reservations so that the
+// host's settings can't be modified through a map shared with the caller.
+func copyReservedStorage(reservations map[string]uint64) map[string]uint64 {
+	if reservations == nil {
+		return nil
+	}
+	c := make(map[string]uint64, len(reservations))
+	for key, reserved := range reservations {
+		c[key] = reserved
+	}
+	return c
+}
+
+// verifyReservedStorage checks that every key of the reserved storage
+// settings can be parsed as a renter public key.
+func verifyReservedStorage(reservations map[string]uint64) error {
+	for key := range reservations {
+		var spk types.SiaPublicKey
+		spk.LoadString(key)
+		if len(spk.Key) == 0 || spk.String() != key {
+			return errInvalidReservationKey
+		}
+	}
+	return nil
+}
+
+// renterKey returns the public key of the renter that formed the storage
+// obligation.
+func (so storageObligation) renterKey() types.SiaPublicKey {
+	if len(so.RevisionTransactionSet) > 0 {
+		uc := so.RevisionTransactionSet[len(so.RevisionTransactionSet)-1].FileContractRevisions[0].UnlockConditions
+		if len(uc.PublicKeys) > 0 {
+			return uc.PublicKeys[0]
+		}
+	}
+	return types.SiaPublicKey{}
+}
+
+// indexReservedSectors adds the sectors of an unresolved storage obligation to
+// the index of the sectors stored by renters with a reservation, or removes
+// them from the index if remove is set. The index counts how many obligations
+// of the renter list a sector, so that sectors shared by a contract and its
+// renewal are only counted once.
+func (h *Host) indexReservedSectors(so storageObligation, remove bool) {
+	if so.ObligationStatus != obligationUnresolved || len(so.SectorRoots) == 0 {
+		return
+	}
+	rk := so.renterKey()
+	key := rk.String()
+	if _, reserved := h.settings.ReservedStorage[key]; !reserved {
+		return
+	}
+	roots, exists := h.reservedSectors[key]
+	if !exists {
+		if remove {
+			return
+		}
+		roots = make(map[crypto.Hash]uint64)
+		h.reservedSectors[key] = roots
+	}
+	for _, root := range so.SectorRoots {
+		if !remove {
+			roots[root]++
+		} else if roots[root] > 1 {
+			roots[root]--
+		} else {
+			delete(roots, root)
+		}
+	}
+	if len(roots) == 0 {
+		delete(h.reservedSectors, key)
+	}
+}
+
+// updateReservedSectors updates the index of the sectors stored by renters
+// with a reservation after a storage obligation was changed from oldSO to so.
+func (h *Host) updateReservedSectors(oldSO, so storageObligation) {
+	h.indexReservedSectors(oldSO, true)
+	h.indexReservedSectors(so, false)
+}
+
+// rebuildReservedSectors rebuilds the index of the sectors stored by renters
+// with a reservation from the storage obligations in the database. This is
+// only necessary when the host is loaded or the set of renters with a
+// reservation changed, the index is kept up to date whenever a storage
+// obligation is modified otherwise.
+func (h *Host) rebuildReservedSectors() error {
+	h.reservedSectors = make(map[string]map[crypto.Hash]uint64)
+	if len(h.settings.ReservedStorage) == 0 {
+		return nil
+	}
+	return h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			if err := json.Unmarshal(soBytes, &so); err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			h.indexReservedSectors(so, false)
+			return nil
+		})
+	})
+}
+
+// sameReservationKeys returns true if both reservations are made for the same
+// renters.
+func sameReservationKeys(a, b map[string]uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key := range a {
+		if _, exists := b[key]; !exists {
+			return false
+		}
+	}
+	return true
+}
+
+// reservedStorageUsage returns the number of bytes that every renter with a
+// reservation is storing on the host.
+func (h *Host) reservedStorageUsage() map[string]uint64 {
+	usage := make(map[string]uint64, len(h.reservedSectors))
+	for key, roots := range h.reservedSectors {
+		usage[key] = uint64(len(roots)) * modules.SectorSize
+	}
+	return usage
+}
+
+// managedCheckReservedStorage returns an error if allocating size more bytes
+// for the provided renter would eat into storage reserved for other renters.
+func (h *Host) managedCheckReservedStorage(renterKey types.SiaPublicKey, size uint64) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if len(h.settings.ReservedStorage) == 0 {
+		return nil
+	}
+	usage := h.reservedStorageUsage()
+	_, remaining := h.capacity()
+	if size > availableStorage(remaining, h.settings.ReservedStorage, usage, renterKey.String()) {
+		return errInsufficientUnreservedStorage
+	}
+	return nil
+}
+
+// CapacityMetrics returns the reserved and free storage of the host.
+func (h *Host) CapacityMetrics() modules.HostCapacityMetrics {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	err := h.tg.Add()
+	if err != nil {
+		return modules.HostCapacityMetrics{}
+	}
+	defer h.tg.Done()
+
+	total, remaining := h.capacity()
+	cm := modules.HostCapacityMetrics{
+		TotalStorage:     total,
+		RemainingStorage: remaining,
+		FreeStorage:      remaining,
+
+		MaxStoragePerContract: h.settings.MaxStoragePerContract,
+		Contracts:             h.contractStorageUsage(),
+	}
+	if len(h.settings.ReservedStorage) == 0 {
+		return cm
+	}
+	usage := h.reservedStorageUsage()
+	cm.FreeStorage = availableStorage(remaining, h.settings.ReservedStorage, usage, "")
+	cm.ReservedStorage = remaining - cm.FreeStorage
+	for key, reserved := range h.settings.ReservedStorage {
+		var spk types.SiaPublicKey
+		spk.LoadString(key)
+		cm.Reservations = append(cm.Reservations, modules.HostStorageReservation{
+			RenterKey:   spk,
+			Reserved:    reserved,
+			UsedStorage: usage[key],
+		})
+	}
+	sort.Slice(cm.Reservations, func(i, j int) bool {
+		return cm.Reservations[i].RenterKey.String() < cm.Reservations[j].RenterKey.String()
+	})
+	return cm
+}
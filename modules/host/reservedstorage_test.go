@@ -0,0 +1,124 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestAvailableStorage checks that storage reserved for a renter can only be
+// allocated by that renter.
+func TestAvailableStorage(t *testing.T) {
+	reservations := map[string]uint64{
+		"a": 100,
+		"b": 50,
+	}
+	usage := map[string]uint64{
+		"a": 20,
+		"b": 60,
+	}
+
+	// Renter b used up its reservation, so only the 80 unused bytes of renter
+	// a are off limits for everyone else.
+	if avail := availableStorage(200, reservations, usage, "c"); avail != 120 {
+		t.Fatal("expected 120 available bytes but got", avail)
+	}
+	if avail := availableStorage(200, reservations, usage, "b"); avail != 120 {
+		t.Fatal("expected 120 available bytes but got", avail)
+	}
+	// Renter a may use its own reservation on top of the free storage.
+	if avail := availableStorage(200, reservations, usage, "a"); avail != 200 {
+		t.Fatal("expected 200 available bytes but got", avail)
+	}
+	// If the reserved storage exceeds the remaining storage, nothing is left
+	// for other renters.
+	if avail := availableStorage(50, reservations, usage, "c"); avail != 0 {
+		t.Fatal("expected 0 available bytes but got", avail)
+	}
+	if avail := availableStorage(50, reservations, usage, "a"); avail != 50 {
+		t.Fatal("expected 50 available bytes but got", avail)
+	}
+}
+
+// TestVerifyReservedStorage checks that reservations are only accepted for
+// valid renter public keys.
+func TestVerifyReservedStorage(t *testing.T) {
+	spk := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       make([]byte, 32),
+	}
+	if err := verifyReservedStorage(map[string]uint64{spk.String(): 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyReservedStorage(nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", "ed25519", "ed25519:zz", "nothex"} {
+		if err := verifyReservedStorage(map[string]uint64{key: 1}); err != errInvalidReservationKey {
+			t.Fatalf("expected errInvalidReservationKey for %q but got %v", key, err)
+		}
+	}
+}
+
+// TestIndexReservedSectors checks that the index of the sectors stored by
+// renters with a reservation follows the changes of their storage
+// obligations.
+func TestIndexReservedSectors(t *testing.T) {
+	renterKey := func(b byte) types.SiaPublicKey {
+		return types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{b}}
+	}
+	obligation := func(rk types.SiaPublicKey, roots ...crypto.Hash) storageObligation {
+		return storageObligation{
+			SectorRoots: roots,
+			RevisionTransactionSet: []types.Transaction{{
+				FileContractRevisions: []types.FileContractRevision{{
+					UnlockConditions: types.UnlockConditions{PublicKeys: []types.SiaPublicKey{rk}},
+				}},
+			}},
+		}
+	}
+	reserved, other := renterKey(1), renterKey(2)
+	h := &Host{reservedSectors: make(map[string]map[crypto.Hash]uint64)}
+	h.settings.ReservedStorage = map[string]uint64{reserved.String(): 10 * modules.SectorSize}
+	usage := func() uint64 {
+		return h.reservedStorageUsage()[reserved.String()] / modules.SectorSize
+	}
+
+	// Only the sectors of renters with a reservation are indexed.
+	so := obligation(reserved, crypto.Hash{1}, crypto.Hash{2})
+	h.indexReservedSectors(so, false)
+	h.indexReservedSectors(obligation(other, crypto.Hash{3}), false)
+	if usage() != 2 || len(h.reservedSectors) != 1 {
+		t.Fatal("unexpected usage", h.reservedStorageUsage())
+	}
+
+	// A renewal shares the sectors of the old obligation.
+	renewal := obligation(reserved, crypto.Hash{1}, crypto.Hash{2})
+	h.indexReservedSectors(renewal, false)
+	if usage() != 2 {
+		t.Fatal("shared sectors should only be counted once, got", usage())
+	}
+
+	// Revising the renewal adds and removes sectors.
+	revised := obligation(reserved, crypto.Hash{2}, crypto.Hash{4}, crypto.Hash{5})
+	h.updateReservedSectors(renewal, revised)
+	if usage() != 4 {
+		t.Fatal("expected 4 sectors but got", usage())
+	}
+
+	// Resolving the old obligation drops the sectors only it stored.
+	resolved := so
+	resolved.ObligationStatus = obligationSucceeded
+	h.updateReservedSectors(so, resolved)
+	if usage() != 3 {
+		t.Fatal("expected 3 sectors but got", usage())
+	}
+	resolvedRevision := revised
+	resolvedRevision.ObligationStatus = obligationSucceeded
+	h.updateReservedSectors(revised, resolvedRevision)
+	if usage() != 0 || len(h.reservedSectors) != 0 {
+		t.Fatal("expected an empty index, got", h.reservedSectors)
+	}
+}
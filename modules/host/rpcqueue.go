@@ -0,0 +1,231 @@
+package host
+
+// rpcqueue.go limits the number of RPCs that the host processes at once and
+// decides which renter gets the next free slot, so that a single renter
+// opening many connections can't starve the others.
+//
+// Only the RPCs on existing contracts are queued. Those are the RPCs that
+// occupy the host the longest, and the renter proves that it owns the
+// contract before the RPC is queued, so a renter can't dodge its per-renter
+// limit by claiming to be someone else. As long as there are free slots, an
+// RPC is started right away.
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+var (
+	// errInvalidRPCQueuePolicy is returned if the internal settings contain
+	// an unknown RPC queue policy.
+	errInvalidRPCQueuePolicy = errors.New("unknown rpc queue policy")
+
+	// errRPCQueueTimeout is returned if an RPC waited for a free slot for
+	// longer than rpcQueueTimeout.
+	errRPCQueueTimeout = errors.New("host is busy, timed out waiting for a free rpc slot")
+)
+
+type (
+	// rpcQueue hands out the slots for processing RPCs.
+	rpcQueue struct {
+		// active counts the RPCs in progress per renter, total counts all
+		// of them.
+		active map[string]uint64
+		total  uint64
+
+		// waiting contains the queued RPCs in the order they arrived.
+		waiting []*rpcWaiter
+
+		// The limits of the queue, updated with every acquire.
+		maxTotal     uint64
+		maxPerRenter uint64
+		policy       string
+
+		keys map[string]types.SiaPublicKey
+		mu   sync.Mutex
+	}
+
+	// rpcWaiter is an RPC waiting for a free slot. ready is closed once the
+	// RPC was granted a slot.
+	rpcWaiter struct {
+		renter  string
+		granted bool
+		ready   chan struct{}
+	}
+)
+
+// newRPCQueue returns an empty rpcQueue.
+func newRPCQueue() *rpcQueue {
+	return &rpcQueue{
+		active: make(map[string]uint64),
+		keys:   make(map[string]types.SiaPublicKey),
+	}
+}
+
+// verifyRPCQueuePolicy checks that policy is a known RPC queue policy.
+func verifyRPCQueuePolicy(policy string) error {
+	switch policy {
+	case "", modules.HostRPCQueuePolicyFair, modules.HostRPCQueuePolicyFIFO:
+		return nil
+	default:
+		return errInvalidRPCQueuePolicy
+	}
+}
+
+// canRun returns true if the renter may start another RPC without exceeding
+// the limits of the queue.
+func (q *rpcQueue) canRun(renter string) bool {
+	if q.maxTotal != 0 && q.total >= q.maxTotal {
+		return false
+	}
+	return q.maxPerRenter == 0 || q.active[renter] < q.maxPerRenter
+}
+
+// dispatch grants free slots to the waiting RPCs according to the policy of
+// the queue.
+func (q *rpcQueue) dispatch() {
+	for {
+		next := -1
+		for i, w := range q.waiting {
+			if !q.canRun(w.renter) {
+				continue
+			}
+			if q.policy == modules.HostRPCQueuePolicyFIFO {
+				next = i
+				break
+			}
+			if next == -1 || q.active[w.renter] < q.active[q.waiting[next].renter] {
+				next = i
+			}
+		}
+		if next == -1 {
+			return
+		}
+		w := q.waiting[next]
+		q.waiting = append(q.waiting[:next], q.waiting[next+1:]...)
+		q.active[w.renter]++
+		q.total++
+		w.granted = true
+		close(w.ready)
+	}
+}
+
+// prune forgets a renter that has neither active nor queued RPCs.
+func (q *rpcQueue) prune(renter string) {
+	if q.active[renter] != 0 {
+		return
+	}
+	for _, w := range q.waiting {
+		if w.renter == renter {
+			return
+		}
+	}
+	delete(q.active, renter)
+	delete(q.keys, renter)
+}
+
+// acquire blocks until the renter is granted a slot, the cancel channel is
+// closed, or the timeout is reached. Every successful call has to be followed
+// by a call to release.
+func (q *rpcQueue) acquire(renterKey types.SiaPublicKey, settings modules.HostInternalSettings, cancel <-chan struct{}, timeout time.Duration) error {
+	renter := renterKey.String()
+	w := &rpcWaiter{
+		renter: renter,
+		ready:  make(chan struct{}),
+	}
+	q.mu.Lock()
+	q.maxTotal = settings.MaxConcurrentRPCs
+	q.maxPerRenter = settings.MaxConcurrentRPCsPerRenter
+	q.policy = settings.RPCQueuePolicy
+	q.keys[renter] = renterKey
+	q.waiting = append(q.waiting, w)
+	q.dispatch()
+	granted := w.granted
+	q.mu.Unlock()
+	if granted {
+		return nil
+	}
+
+	var err error
+	select {
+	case <-w.ready:
+		return nil
+	case <-cancel:
+		err = errHostClosed
+	case <-time.After(timeout):
+		err = errRPCQueueTimeout
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if w.granted {
+		// The slot was granted while giving up, use it anyway.
+		return nil
+	}
+	for i := range q.waiting {
+		if q.waiting[i] == w {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			break
+		}
+	}
+	q.prune(renter)
+	return err
+}
+
+// release frees a slot of the renter and hands it to the next waiting RPC.
+func (q *rpcQueue) release(renterKey types.SiaPublicKey) {
+	renter := renterKey.String()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.active[renter]--
+	q.total--
+	q.prune(renter)
+	q.dispatch()
+}
+
+// status returns the active and queued RPCs of every renter, sorted by the
+// key of the renter.
+func (q *rpcQueue) status() []modules.HostRPCQueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	queued := make(map[string]uint64)
+	for _, w := range q.waiting {
+		queued[w.renter]++
+	}
+	statuses := make([]modules.HostRPCQueueStatus, 0, len(q.keys))
+	for renter, key := range q.keys {
+		statuses = append(statuses, modules.HostRPCQueueStatus{
+			RenterKey: key,
+			Active:    q.active[renter],
+			Queued:    queued[renter],
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].RenterKey.String() < statuses[j].RenterKey.String()
+	})
+	return statuses
+}
+
+// managedAcquireRPCSlot blocks until the renter may process another RPC. The
+// returned function releases the slot and has to be called once the RPC is
+// done.
+func (h *Host) managedAcquireRPCSlot(renterKey types.SiaPublicKey) (func(), error) {
+	h.mu.RLock()
+	settings := h.settings
+	h.mu.RUnlock()
+	err := h.staticRPCQueue.acquire(renterKey, settings, h.tg.StopChan(), rpcQueueTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return func() { h.staticRPCQueue.release(renterKey) }, nil
+}
+
+// RPCQueue returns the number of active and queued RPCs of every renter that
+// has RPCs in progress.
+func (h *Host) RPCQueue() []modules.HostRPCQueueStatus {
+	return h.staticRPCQueue.status()
+}
@@ -0,0 +1,74 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestRPCQueueLimits checks that RPCs are started right away while there are
+// free slots, and that a renter can't exceed its own limit.
+func TestRPCQueueLimits(t *testing.T) {
+	q := newRPCQueue()
+	renter := types.SiaPublicKey{Key: []byte{1}}
+	settings := modules.HostInternalSettings{MaxConcurrentRPCsPerRenter: 1}
+
+	if err := q.acquire(renter, settings, nil, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.acquire(renter, settings, nil, time.Millisecond); err != errRPCQueueTimeout {
+		t.Fatal("expected errRPCQueueTimeout, got", err)
+	}
+	status := q.status()
+	if len(status) != 1 || status[0].Active != 1 || status[0].Queued != 0 {
+		t.Fatal("unexpected queue status", status)
+	}
+	q.release(renter)
+	if status := q.status(); len(status) != 0 {
+		t.Fatal("idle renter should be forgotten", status)
+	}
+}
+
+// TestRPCQueuePolicies checks that the fair policy hands a free slot to the
+// renter with the fewest RPCs in progress, while the fifo policy hands it to
+// the RPC that waited the longest.
+func TestRPCQueuePolicies(t *testing.T) {
+	renterA := types.SiaPublicKey{Key: []byte{1}}
+	renterB := types.SiaPublicKey{Key: []byte{2}}
+	for _, policy := range []string{modules.HostRPCQueuePolicyFair, modules.HostRPCQueuePolicyFIFO} {
+		q := newRPCQueue()
+		settings := modules.HostInternalSettings{MaxConcurrentRPCs: 2, RPCQueuePolicy: policy}
+		for i := 0; i < 2; i++ {
+			if err := q.acquire(renterA, settings, nil, time.Second); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Queue another RPC of renter A and then one of renter B.
+		started := make(chan types.SiaPublicKey, 2)
+		wait := func(renter types.SiaPublicKey) {
+			if err := q.acquire(renter, settings, nil, time.Second); err == nil {
+				started <- renter
+			}
+		}
+		go wait(renterA)
+		for len(q.status()) == 0 || q.status()[0].Queued != 1 {
+			time.Sleep(time.Millisecond)
+		}
+		go wait(renterB)
+		for len(q.status()) != 2 {
+			time.Sleep(time.Millisecond)
+		}
+
+		q.release(renterA)
+		expected := renterB
+		if policy == modules.HostRPCQueuePolicyFIFO {
+			expected = renterA
+		}
+		if renter := <-started; renter.String() != expected.String() {
+			t.Fatalf("%v: expected %v to get the free slot, got %v", policy, expected, renter)
+		}
+	}
+}
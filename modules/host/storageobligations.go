@@ -102,6 +102,10 @@ var (
 	// is not found in the database.
 	errNoStorageObligation = errors.New("storage obligation not found in database")
 
+	// errProofFeeTooHigh is returned if the fee of a storage proof exceeds
+	// the revenue of the storage obligation.
+	errProofFeeTooHigh = errors.New("value of the storage obligation does not sufficiently exceed the fee of the storage proof")
+
 	// errObligationUnlocked is returned when a storage obligation is being
 	// removed from lock, but is already unlocked.
 	errObligationUnlocked = errors.New("storage obligation is unlocked, and should not be getting unlocked")
@@ -149,6 +153,11 @@ type storageObligation struct {
 	ProofConstructed    bool
 	RevisionConfirmed   bool
 	RevisionConstructed bool
+
+	// ResolutionHeight is the height at which the obligation was resolved.
+	// The sectors of the obligation are pruned once the grace period after
+	// the resolution has passed.
+	ResolutionHeight types.BlockHeight
 }
 
 func (i storageObligationStatus) String() string {
@@ -388,6 +397,8 @@ func (h *Host) managedAddStorageObligation(so storageObligation) error {
 		if err != nil {
 			return err
 		}
+		h.indexReservedSectors(so, false)
+		h.indexContractStorage(so)
 
 		// Update the host financial metrics with regards to this storage
 		// obligation.
@@ -518,6 +529,8 @@ func (h *Host) modifyStorageObligation(so storageObligation, sectorsRemoved []cr
 		}
 		return err
 	}
+	h.updateReservedSectors(oldSO, so)
+	h.indexContractStorage(so)
 	// Call removeSector for all of the sectors that have been removed.
 	for k := range sectorsRemoved {
 		// Error is not checkeed because there's nothing useful that can be
@@ -549,12 +562,9 @@ func (h *Host) modifyStorageObligation(so storageObligation, sectorsRemoved []cr
 }
 
 // removeStorageObligation will remove a storage obligation from the host,
-// either due to failure or success.
+// either due to failure or success. The sectors of the obligation are kept
+// until the prune grace period has passed.
 func (h *Host) removeStorageObligation(so storageObligation, sos storageObligationStatus) error {
-	// Error is not checked, we want to call remove on every sector even if
-	// there are problems - disk health information will be updated.
-	_ = h.RemoveSectorBatch(so.SectorRoots)
-
 	// Update the host revenue metrics based on the status of the obligation.
 	if sos == obligationUnresolved {
 		h.log.Critical("storage obligation 'unresolved' during call to removeStorageObligation, id", so.id())
@@ -614,14 +624,95 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 
 	// Update the storage obligation to be finalized but still in-database. The
 	// obligation status is updated so that the user can see how the obligation
-	// ended up, and the sector roots are removed because they are large
-	// objects with little purpose once storage proofs are no longer needed.
+	// ended up. The sector roots are kept until the sectors are pruned, since
+	// they are needed to remove the sectors from disk.
 	h.financialMetrics.ContractCount--
+	oldSO := so
 	so.ObligationStatus = sos
-	so.SectorRoots = nil
-	return h.db.Update(func(tx *bolt.Tx) error {
+	so.ResolutionHeight = h.blockHeight
+	err := h.db.Update(func(tx *bolt.Tx) error {
 		return putStorageObligation(tx, so)
 	})
+	if err == nil {
+		h.updateReservedSectors(oldSO, so)
+		h.indexContractStorage(so)
+	}
+	if err != nil || len(so.SectorRoots) == 0 {
+		return err
+	}
+	return h.queueActionItem(so.pruneHeight(h.settings.PruneGracePeriod), so.id())
+}
+
+// managedSubmitStorageProof builds a storage proof for the obligation and
+// submits it to the transaction pool, returning the miner fee that was added.
+func (h *Host) managedSubmitStorageProof(so storageObligation) (types.Currency, error) {
+	// Get the index of the segment, and the index of the sector containing
+	// the segment.
+	segmentIndex, err := h.cs.StorageProofSegment(so.id())
+	if err != nil {
+		return types.ZeroCurrency, build.ExtendErr("unable to fetch the storage proof segment", err)
+	}
+	sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize)
+	// Pull the corresponding sector into memory.
+	sectorRoot := so.SectorRoots[sectorIndex]
+	sectorBytes, err := h.ReadSector(sectorRoot)
+	if err != nil {
+		return types.ZeroCurrency, build.ExtendErr("unable to read the sector of the storage proof", err)
+	}
+
+	// Build the storage proof for just the sector.
+	sectorSegment := segmentIndex % (modules.SectorSize / crypto.SegmentSize)
+	base, cachedHashSet := crypto.MerkleProof(sectorBytes, sectorSegment)
+
+	// Using the sector, build a cached root.
+	log2SectorSize := uint64(0)
+	for 1<<log2SectorSize < (modules.SectorSize / crypto.SegmentSize) {
+		log2SectorSize++
+	}
+	ct := crypto.NewCachedTree(log2SectorSize)
+	ct.SetIndex(segmentIndex)
+	for _, root := range so.SectorRoots {
+		ct.Push(root)
+	}
+	hashSet := ct.Prove(base, cachedHashSet)
+	sp := types.StorageProof{
+		ParentID: so.id(),
+		HashSet:  hashSet,
+	}
+	copy(sp.Segment[:], base)
+
+	// Create and build the transaction with the storage proof.
+	builder, err := h.wallet.StartTransaction()
+	if err != nil {
+		return types.ZeroCurrency, build.ExtendErr("failed to start transaction", err)
+	}
+	_, feeRecommendation := h.tpool.FeeEstimation()
+	if so.value().Cmp(feeRecommendation) < 0 {
+		// There's no sense submitting the storage proof if the fee is more
+		// than the anticipated revenue.
+		builder.Drop()
+		return types.ZeroCurrency, errProofFeeTooHigh
+	}
+	txnSize := uint64(len(encoding.Marshal(sp)) + 300)
+	requiredFee := feeRecommendation.Mul64(txnSize)
+	err = builder.FundSiacoins(requiredFee)
+	if err != nil {
+		builder.Drop()
+		return types.ZeroCurrency, build.ExtendErr("error when funding the storage proof transaction fee", err)
+	}
+	builder.AddMinerFee(requiredFee)
+	builder.AddStorageProof(sp)
+	storageProofSet, err := builder.Sign(true)
+	if err != nil {
+		builder.Drop()
+		return types.ZeroCurrency, build.ExtendErr("error when signing the storage proof transaction", err)
+	}
+	err = h.tpool.AcceptTransactionSet(storageProofSet)
+	if err != nil {
+		builder.Drop()
+		return types.ZeroCurrency, build.ExtendErr("transaction pool rejected the storage proof transaction", err)
+	}
+	return requiredFee, nil
 }
 
 // threadedHandleActionItem will look at a storage obligation and determine
@@ -653,9 +744,29 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		return
 	}
 
-	// Check whether the storage obligation has already been completed.
+	// Check whether the storage obligation has already been completed. The
+	// only thing left to do for a completed obligation is removing its
+	// sectors once the grace period has passed.
 	if so.ObligationStatus != obligationUnresolved {
-		// Storage obligation has already been completed, skip action item.
+		if len(so.SectorRoots) == 0 {
+			return
+		}
+		h.mu.Lock()
+		pruneHeight := so.pruneHeight(h.settings.PruneGracePeriod)
+		if h.blockHeight < pruneHeight {
+			// The grace period was extended after the action item was
+			// queued.
+			err = h.queueActionItem(pruneHeight, so.id())
+			h.mu.Unlock()
+			if err != nil {
+				h.log.Println("Error queuing action item:", err)
+			}
+			return
+		}
+		h.mu.Unlock()
+		if _, err := h.managedPruneStorageObligation(so); err != nil {
+			h.log.Println("Error pruning storage obligation:", err)
+		}
 		return
 	}
 
@@ -805,77 +916,10 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 			}
 			return
 		}
-		// Get the index of the segment, and the index of the sector containing
-		// the segment.
-		segmentIndex, err := h.cs.StorageProofSegment(so.id())
-		if err != nil {
-			h.log.Debugln("Host got an error when fetching a storage proof segment:", err)
-			return
-		}
-		sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize)
-		// Pull the corresponding sector into memory.
-		sectorRoot := so.SectorRoots[sectorIndex]
-		sectorBytes, err := h.ReadSector(sectorRoot)
-		if err != nil {
-			h.log.Debugln(err)
-			return
-		}
-
-		// Build the storage proof for just the sector.
-		sectorSegment := segmentIndex % (modules.SectorSize / crypto.SegmentSize)
-		base, cachedHashSet := crypto.MerkleProof(sectorBytes, sectorSegment)
-
-		// Using the sector, build a cached root.
-		log2SectorSize := uint64(0)
-		for 1<<log2SectorSize < (modules.SectorSize / crypto.SegmentSize) {
-			log2SectorSize++
-		}
-		ct := crypto.NewCachedTree(log2SectorSize)
-		ct.SetIndex(segmentIndex)
-		for _, root := range so.SectorRoots {
-			ct.Push(root)
-		}
-		hashSet := ct.Prove(base, cachedHashSet)
-		sp := types.StorageProof{
-			ParentID: so.id(),
-			HashSet:  hashSet,
-		}
-		copy(sp.Segment[:], base)
-
-		// Create and build the transaction with the storage proof.
-		builder, err := h.wallet.StartTransaction()
-		if err != nil {
-			h.log.Println("Failed to start transaction:", err)
-			return
-		}
-		_, feeRecommendation := h.tpool.FeeEstimation()
-		if so.value().Cmp(feeRecommendation) < 0 {
-			// There's no sense submitting the storage proof if the fee is more
-			// than the anticipated revenue.
-			h.log.Debugln("Host not submitting storage proof due to a value that does not sufficiently exceed the fee cost")
-			builder.Drop()
-			return
-		}
-		txnSize := uint64(len(encoding.Marshal(sp)) + 300)
-		requiredFee := feeRecommendation.Mul64(txnSize)
-		err = builder.FundSiacoins(requiredFee)
+		requiredFee, err := h.managedSubmitStorageProof(so)
+		h.managedRecordProofAttempt(so.id(), blockHeight, err)
 		if err != nil {
-			h.log.Println("Host error when funding a storage proof transaction fee:", err)
-			builder.Drop()
-			return
-		}
-		builder.AddMinerFee(requiredFee)
-		builder.AddStorageProof(sp)
-		storageProofSet, err := builder.Sign(true)
-		if err != nil {
-			h.log.Println("Host error when signing the storage proof transaction:", err)
-			builder.Drop()
-			return
-		}
-		err = h.tpool.AcceptTransactionSet(storageProofSet)
-		if err != nil {
-			h.log.Println("Host unable to submit storage proof transaction to transaction pool:", err)
-			builder.Drop()
+			h.log.Println("Host unable to submit storage proof:", err)
 			return
 		}
 		so.TransactionFeesAdded = so.TransactionFeesAdded.Add(requiredFee)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"math/bits"
 	"time"
 
 	"github.com/HyperspaceApp/Hyperspace/build"
@@ -42,6 +43,14 @@ const (
 	// required round trips to complete the negotiation.
 	NegotiateFileContractTime = 360 * time.Second
 
+	// NegotiateMaxCapacityChallengeSectors is the maximum number of sectors
+	// that a single capacity challenge can ask the host to prove.
+	NegotiateMaxCapacityChallengeSectors = 8
+
+	// NegotiateMaxDownloadSegments is the maximum number of segments that a
+	// single call of RPCDownloadSegments can request.
+	NegotiateMaxDownloadSegments = 64
+
 	// NegotiateMaxDownloadActionRequestSize defines the maximum size that a
 	// download request can be. Note, this is not a max size for the data that
 	// can be requested, but instead is a max size for the definition of the
@@ -99,6 +108,27 @@ const (
 )
 
 var (
+	// NegotiateCapacityProofTime establishes the minimum amount of time that
+	// the connection deadline is expected to be set to when a host is
+	// challenged to prove that it stores the sectors of a contract and has
+	// unused capacity. The host needs to receive the probe sector, write it to
+	// disk and read every challenged sector in that time.
+	NegotiateCapacityProofTime = build.Select(build.Var{
+		Dev:      120 * time.Second,
+		Standard: 120 * time.Second,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+
+	// NegotiateSectorRootsTime establishes the minimum amount of time that
+	// the connection deadline is expected to be set to when the renter asks
+	// for the Merkle roots of the sectors stored under a contract. A contract
+	// holding terabytes of data has a few megabytes of roots.
+	NegotiateSectorRootsTime = build.Select(build.Var{
+		Dev:      120 * time.Second,
+		Standard: 300 * time.Second,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+
 	// NegotiateSettingsTime establishes the minimum amount of time that the
 	// connection deadline is expected to be set to when settings are being
 	// requested from the host. The deadline is long enough that the connection
@@ -123,6 +153,12 @@ var (
 	// data.
 	ActionModify = types.Specifier{'M', 'o', 'd', 'i', 'f', 'y'}
 
+	// ErrCapacityProofCooldown is the rejection of a capacity challenge that
+	// the renter sent before the cooldown of its previous challenge passed.
+	// The renter compares the rejection with it to tell a busy host from a
+	// host that can't answer.
+	ErrCapacityProofCooldown = errors.New("host answered a capacity challenge of the renter recently, try again later")
+
 	// ErrAnnNotAnnouncement indicates that the provided host announcement does
 	// not use a recognized specifier, indicating that it's either not a host
 	// announcement or it's not a recognized version of a host announcement.
@@ -151,9 +187,26 @@ var (
 	// announcement will follow this prefix.
 	PrefixHostAnnouncement = types.Specifier{'H', 'o', 's', 't', 'A', 'n', 'n', 'o', 'u', 'n', 'c', 'e', 'm', 'e', 'n', 't'}
 
+	// RPCCapacityProof is the specifier for challenging a host to prove that
+	// it still stores the sectors of a contract. Hosts are not required to
+	// support it.
+	RPCCapacityProof = types.Specifier{'C', 'a', 'p', 'a', 'c', 'i', 't', 'y', 'P', 'r', 'o', 'o', 'f'}
+
 	// RPCDownload is the specifier for downloading a file from a host.
 	RPCDownload = types.Specifier{'D', 'o', 'w', 'n', 'l', 'o', 'a', 'd', 2}
 
+	// RPCDownloadSegments is the specifier for downloading single segments of
+	// sectors together with their Merkle proofs. The request has the format
+	// of a capacity challenge, but the segments are paid for like a download.
+	// Hosts are not required to support it.
+	RPCDownloadSegments = types.Specifier{'D', 'o', 'w', 'n', 'l', 'o', 'a', 'd', 'S', 'e', 'g', 'm', 'e', 'n', 't', 's'}
+
+	// RPCFormArbitratedContract is the specifier for forming a contract with
+	// a host whose unlock conditions include the key of an arbiter. The
+	// renter sends the arbiter's key after its own key, otherwise the RPC is
+	// the same as RPCFormContract. Hosts are not required to support it.
+	RPCFormArbitratedContract = types.Specifier{'F', 'o', 'r', 'm', 'A', 'r', 'b', 'i', 't', 'r', 'a', 't', 'e', 'd'}
+
 	// RPCFormContract is the specifier for forming a contract with a host.
 	RPCFormContract = types.Specifier{'F', 'o', 'r', 'm', 'C', 'o', 'n', 't', 'r', 'a', 'c', 't', 2}
 
@@ -164,6 +217,11 @@ var (
 	// contract.
 	RPCReviseContract = types.Specifier{'R', 'e', 'v', 'i', 's', 'e', 'C', 'o', 'n', 't', 'r', 'a', 'c', 't', 2}
 
+	// RPCSectorRoots is the specifier for requesting the Merkle roots of all
+	// sectors that the host stores under a contract. Hosts are not required
+	// to support it.
+	RPCSectorRoots = types.Specifier{'S', 'e', 'c', 't', 'o', 'r', 'R', 'o', 'o', 't', 's'}
+
 	// RPCSettings is the specifier for requesting settings from the host.
 	RPCSettings = types.Specifier{'S', 'e', 't', 't', 'i', 'n', 'g', 's', 2}
 
@@ -177,9 +235,51 @@ var (
 		Standard: uint64(1 << 22), // 4 MiB
 		Testing:  uint64(1 << 12), // 4 KiB
 	}).(uint64)
+
+	// SegmentDownloadSize is the number of bytes that are paid for per
+	// segment downloaded with RPCDownloadSegments: the segment and the hashes
+	// of its Merkle proof.
+	SegmentDownloadSize = crypto.SegmentSize + crypto.HashSize*uint64(bits.Len64(SectorSize/crypto.SegmentSize)-1)
 )
 
 type (
+	// A CapacityChallenge asks the host to prove that it still stores
+	// sectors of a contract. For every sector, identified by its Merkle root,
+	// the host has to send the segment at the corresponding index of
+	// SegmentIndices. The renter picks random sectors and segments, so the
+	// host can't answer without keeping the data.
+	CapacityChallenge struct {
+		SectorRoots    []crypto.Hash
+		SegmentIndices []uint64
+	}
+
+	// A CapacityProof is the response of a host to a capacity challenge. It
+	// contains the requested segments together with the Merkle proofs that
+	// they are part of their sectors.
+	CapacityProof struct {
+		Segments [][]byte
+		HashSets [][]crypto.Hash
+	}
+
+	// A CapacityProbe is sent together with a capacity challenge and asks the
+	// host to prove that it has unused capacity. The host has to write the
+	// random Sector chosen by the renter to its unused storage, read it back
+	// from disk and send the segment at SegmentIndex with its Merkle proof.
+	// The sector is removed again afterwards. A host without a sector of
+	// unused capacity rejects the probe. An empty Sector skips the probe.
+	CapacityProbe struct {
+		Sector       []byte
+		SegmentIndex uint64
+	}
+
+	// A CapacityProbeProof is the response of a host to a capacity probe: the
+	// requested segment of the probe sector as it was read back from disk,
+	// together with its Merkle proof.
+	CapacityProbeProof struct {
+		Segment []byte
+		HashSet []crypto.Hash
+	}
+
 	// A DownloadAction is a description of a download that the renter would
 	// like to make. The MerkleRoot indicates the root of the sector, the
 	// offset indicates what portion of the sector is being downloaded, and the
@@ -329,6 +429,34 @@ func WriteNegotiationStop(w io.Writer) error {
 	return encoding.WriteObject(w, StopResponse)
 }
 
+// ContractUnlockConditions returns the unlock conditions that protect a file
+// contract between the renter and the host from revision. If the arbiter key
+// is not empty, it is added as a third key. Any two of the three keys can
+// sign a revision then: the renter and the host revise the contract as usual,
+// while the arbiter can resolve a dispute by signing a revision together with
+// either party. Both parties have to trust the arbiter not to collude with the
+// other party.
+func ContractUnlockConditions(renterPK, hostPK, arbiterPK types.SiaPublicKey) types.UnlockConditions {
+	uc := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{renterPK, hostPK},
+		SignaturesRequired: 2,
+	}
+	if len(arbiterPK.Key) > 0 {
+		uc.PublicKeys = append(uc.PublicKeys, arbiterPK)
+	}
+	return uc
+}
+
+// ContractArbiter returns the arbiter key of the unlock conditions of a file
+// contract, see ContractUnlockConditions. The key is empty if the contract has
+// no arbiter.
+func ContractArbiter(uc types.UnlockConditions) types.SiaPublicKey {
+	if len(uc.PublicKeys) < 3 {
+		return types.SiaPublicKey{}
+	}
+	return uc.PublicKeys[2]
+}
+
 // CreateAnnouncement will take a host announcement and encode it, returning
 // the exact []byte that should be added to the arbitrary data of a
 // transaction.
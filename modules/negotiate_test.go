@@ -125,3 +125,30 @@ func TestNegotiationResponses(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestContractUnlockConditions checks that the arbiter is only added to the
+// unlock conditions of a contract if its key is set.
+func TestContractUnlockConditions(t *testing.T) {
+	renterPK := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	hostPK := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{2}}
+	arbiterPK := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{3}}
+
+	uc := ContractUnlockConditions(renterPK, hostPK, types.SiaPublicKey{})
+	if len(uc.PublicKeys) != 2 || uc.SignaturesRequired != 2 {
+		t.Fatal("contract without arbiter should need the signatures of both parties", uc)
+	}
+	if arbiter := ContractArbiter(uc); len(arbiter.Key) != 0 {
+		t.Fatal("contract without arbiter has an arbiter", arbiter)
+	}
+
+	uc = ContractUnlockConditions(renterPK, hostPK, arbiterPK)
+	if len(uc.PublicKeys) != 3 || uc.SignaturesRequired != 2 {
+		t.Fatal("contract with arbiter should need two of three signatures", uc)
+	}
+	if uc.PublicKeys[0].String() != renterPK.String() || uc.PublicKeys[1].String() != hostPK.String() {
+		t.Fatal("renter and host keys should keep their indices", uc)
+	}
+	if arbiter := ContractArbiter(uc); arbiter.String() != arbiterPK.String() {
+		t.Fatal("wrong arbiter", arbiter)
+	}
+}
@@ -32,6 +32,24 @@ const (
 	// the host and the renter, and will also contain a file contract and file
 	// contract revision that have each been signed by all parties.
 	EstimatedFileContractTransactionSetSize = 2048
+
+	// ManifestCorruptionThreshold is the fraction of missing or corrupt
+	// pieces that the confidence of a manifest verification refers to.
+	ManifestCorruptionThreshold = 0.01
+)
+
+const (
+	// PiecePlacementCheapest prefers the hosts that charge the least for
+	// uploading and storing a sector for the allowance period.
+	PiecePlacementCheapest = "cheapest"
+
+	// PiecePlacementFastest prefers the hosts with the lowest recent upload
+	// latency.
+	PiecePlacementFastest = "fastest"
+
+	// PiecePlacementMostDiverse prefers the hosts in address ranges that
+	// don't store pieces of the chunk yet.
+	PiecePlacementMostDiverse = "most-diverse"
 )
 
 // An ErasureCoder is an error-correcting encoder and decoder.
@@ -66,6 +84,238 @@ type Allowance struct {
 	Hosts       uint64            `json:"hosts"`
 	Period      types.BlockHeight `json:"period"`
 	RenewWindow types.BlockHeight `json:"renewwindow"`
+
+	// IntegrityScanInterval is the number of blocks between two integrity
+	// scans. A scan downloads a few random sectors from every host and
+	// replaces hosts that keep returning bad data. Zero disables the scans.
+	IntegrityScanInterval types.BlockHeight `json:"integrityscaninterval"`
+
+	// MinHostsPerChunk is the minimum number of distinct hosts that need to
+	// store pieces of every chunk. Chunks whose pieces are concentrated on
+	// fewer hosts are repaired even if enough pieces are available, files
+	// aren't reported as available and uploaded packs don't count as
+	// recoverable until all of their chunks are spread across enough hosts.
+	// Zero disables the check.
+	MinHostsPerChunk uint64 `json:"minhostsperchunk"`
+
+	// PreferRenewal biases contract maintenance towards keeping existing
+	// contracts. Hosts the renter already has contracts with may score lower
+	// than the hosts that could replace them, as long as the difference is
+	// outweighed by the fees of forming new contracts and the stability of
+	// keeping the data where it is. Contracts are also refreshed before they
+	// are close to running out of funds. RenewalBias sets the strength of the
+	// bias, zero uses a default.
+	PreferRenewal bool    `json:"preferrenewal"`
+	RenewalBias   float64 `json:"renewalbias"`
+
+	// RefreshThreshold is the fraction of a contract's initial funds below
+	// which the contract is refreshed, i.e. renewed with the same host before
+	// the end of the period to add funds. Zero uses a default, which is
+	// raised by the renewal bias.
+	RefreshThreshold float64 `json:"refreshthreshold"`
+
+	// DiversifyVersions spreads new contracts across hosts running different
+	// versions of the host software, so that a bug in a single version
+	// doesn't affect too many hosts at once. This is a soft constraint, hosts
+	// running a version that is already common are still used if there are
+	// not enough other hosts.
+	DiversifyVersions bool `json:"diversifyversions"`
+
+	// MaxFundsPerContract caps the funds of every contract, to limit how much
+	// of the allowance is spent with a single host. If a contract's share of
+	// the allowance exceeds the cap, more contracts are formed with more hosts
+	// instead. Zero disables the cap.
+	MaxFundsPerContract types.Currency `json:"maxfundspercontract"`
+
+	// AllowDegradedUpload lets uploads start with fewer contracts than the
+	// erasure code of the file needs, as long as DegradedUploadMinHosts
+	// contracts are available. The file is flagged as degraded and the
+	// repair loop uploads the missing pieces first once more contracts are
+	// formed. A DegradedUploadMinHosts of zero uses the number of data
+	// pieces of the file, the minimum needed to recover it.
+	AllowDegradedUpload    bool   `json:"allowdegradedupload"`
+	DegradedUploadMinHosts uint64 `json:"degradeduploadminhosts"`
+
+	// PiecePlacementStrategy decides which hosts are offered the pieces of
+	// a chunk first. The other hosts only receive pieces if the preferred
+	// hosts fail. An empty strategy offers every piece to all hosts at once
+	// and lets the fastest workers take them.
+	PiecePlacementStrategy string `json:"pieceplacementstrategy"`
+
+	// ContractsPerHost is the number of contracts that are formed with every
+	// host. Every contract has its own revision lock, so uploads to the same
+	// host can run in parallel over the contracts. Each contract receives a
+	// share of the host's funds. Zero and one form a single contract.
+	ContractsPerHost uint64 `json:"contractsperhost"`
+
+	// AutoTopUp raises the funds of the allowance by TopUpAmount whenever
+	// less than TopUpThreshold of the funds remain unallocated in the
+	// current period. The funds are never raised above TopUpMaxFunds. A
+	// top-up is skipped if the wallet balance doesn't cover the unallocated
+	// funds it would leave, and the renter raises an alert until a top-up
+	// succeeds or is no longer needed.
+	AutoTopUp      bool           `json:"autotopup"`
+	TopUpAmount    types.Currency `json:"topupamount"`
+	TopUpMaxFunds  types.Currency `json:"topupmaxfunds"`
+	TopUpThreshold types.Currency `json:"topupthreshold"`
+
+	// PartialFunding lets the contractor keep forming and renewing contracts
+	// while the wallet balance doesn't cover the unallocated funds of the
+	// allowance. The funding of every contract is reduced by the ratio of the
+	// balance to the unallocated funds, and the contracts that were funded
+	// partially are refreshed towards their full funding once the wallet
+	// covers the allowance again.
+	PartialFunding bool `json:"partialfunding"`
+
+	// EndHeightAlignment aligns the end heights of new and renewed contracts
+	// to multiples of the given number of blocks, so that renewals cluster
+	// around predictable heights. Contracts last up to EndHeightAlignment
+	// blocks longer than the period, their renewal funding is adjusted to
+	// the actual duration. RenewStagger spreads the end heights of the
+	// contracts with different hosts over the given number of blocks after
+	// the aligned height, so that they aren't all renewed at once. Zero
+	// disables the alignment and the stagger.
+	EndHeightAlignment types.BlockHeight `json:"endheightalignment"`
+	RenewStagger       types.BlockHeight `json:"renewstagger"`
+
+	// Arbiter is added as a third key to the unlock conditions of new
+	// contracts, see ContractUnlockConditions. Together with the renter or
+	// the host, the arbiter can sign a revision that resolves a dispute.
+	// Only hosts that trust the arbiter accept such contracts. Renewed
+	// contracts keep the arbiter they were formed with. Nil forms contracts
+	// without arbiter.
+	Arbiter *types.SiaPublicKey `json:"arbiter,omitempty"`
+}
+
+// AllowanceTransition describes how far the contractor has converged towards
+// the most recently set allowance. Allowance changes are applied gradually, a
+// limited number of contracts is formed and refreshed every maintenance round
+// until the new target is reached.
+type AllowanceTransition struct {
+	// Active indicates whether the contractor is still converging towards
+	// the target allowance.
+	Active bool `json:"active"`
+
+	OldAllowance    Allowance         `json:"oldallowance"`
+	TargetAllowance Allowance         `json:"targetallowance"`
+	StartHeight     types.BlockHeight `json:"startheight"`
+
+	// ContractsFormed is the number of contracts formed since the transition
+	// started. ContractsNeeded is the number of contracts that are still
+	// missing to reach the target allowance.
+	ContractsFormed uint64 `json:"contractsformed"`
+	ContractsNeeded uint64 `json:"contractsneeded"`
+
+	// ContractsRefreshed is the number of contracts refreshed since the
+	// transition started. RefreshesNeeded is the number of contracts that
+	// still have to be refreshed, e.g. to adjust their funding to the target
+	// allowance.
+	ContractsRefreshed uint64 `json:"contractsrefreshed"`
+	RefreshesNeeded    uint64 `json:"refreshesneeded"`
+
+	// Progress is a value between 0 and 1 which indicates how close the
+	// contractor is to the target allowance. It is the fraction of the
+	// formations and refreshes of the transition that were completed.
+	Progress float64 `json:"progress"`
+}
+
+// ContractorFunding describes whether the contractor is funding its contracts
+// partially, because the wallet balance doesn't cover the unallocated funds of
+// an allowance that allows partial funding.
+type ContractorFunding struct {
+	// Underfunded indicates whether the most recent round of maintenance
+	// funded contracts partially.
+	Underfunded bool `json:"underfunded"`
+
+	// WalletBalance is the confirmed balance of the wallet and FundsNeeded
+	// are the unallocated funds of the allowance during the most recent
+	// round of maintenance. FundingRatio is the fraction of FundsNeeded that
+	// the wallet covered, every contract was funded with this fraction of
+	// its regular funding.
+	WalletBalance types.Currency `json:"walletbalance"`
+	FundsNeeded   types.Currency `json:"fundsneeded"`
+	FundingRatio  float64        `json:"fundingratio"`
+
+	// UnderfundedContracts is the number of contracts that were funded
+	// partially and haven't been topped up yet.
+	UnderfundedContracts uint64 `json:"underfundedcontracts"`
+}
+
+// ContractRenewal describes when a contract is due for renewal and whether the
+// contractor expects to renew it.
+type ContractRenewal struct {
+	ID            types.FileContractID `json:"id"`
+	HostPublicKey types.SiaPublicKey   `json:"hostpublickey"`
+	EndHeight     types.BlockHeight    `json:"endheight"`
+
+	// RenewHeight is the height at which the contractor starts to renew the
+	// contract, which is the end height minus the renew window.
+	RenewHeight types.BlockHeight `json:"renewheight"`
+
+	// WillRenew indicates whether the contract would be renewed given the
+	// current state and prices of its host. If not, DropReason explains why
+	// the contract will be dropped.
+	WillRenew  bool   `json:"willrenew"`
+	DropReason string `json:"dropreason"`
+
+	// EstimatedCost is the estimated funding of the renewed contract. It is
+	// only set if the contract will be renewed.
+	EstimatedCost types.Currency `json:"estimatedcost"`
+
+	// FailedRenewals is the number of consecutive renewals of the contract
+	// that failed because of the host.
+	FailedRenewals uint64 `json:"failedrenewals"`
+}
+
+// ContractRenewalSchedule lists when the active contracts of the contractor
+// are due for renewal.
+type ContractRenewalSchedule struct {
+	BlockHeight types.BlockHeight `json:"blockheight"`
+
+	// Contracts are the active contracts, sorted by their renew height.
+	Contracts []ContractRenewal `json:"contracts"`
+
+	// Due is the number of contracts with a renew height within the next
+	// Within blocks, including the contracts that are due already.
+	Due    uint64            `json:"due"`
+	Within types.BlockHeight `json:"within"`
+
+	// MaintenancePaused indicates that no contracts are renewed until
+	// maintenance is resumed.
+	MaintenancePaused bool `json:"maintenancepaused"`
+
+	// TargetEndHeight is the aligned end height that contracts formed or
+	// renewed now end at. Their end heights are spread over the RenewStagger
+	// blocks after it.
+	TargetEndHeight types.BlockHeight `json:"targetendheight"`
+	RenewStagger    types.BlockHeight `json:"renewstagger"`
+}
+
+// RenterTrial describes a trial allowance, which forms contracts with a few
+// hosts for a short period before the user commits to a full allowance. The
+// trial is Expired once its contracts are due for renewal. An expired trial
+// that wasn't promoted is ended, its allowance is cancelled and its contracts
+// are left to expire. Contracts and Spent are the number of active contracts
+// and their total cost.
+type RenterTrial struct {
+	Active      bool              `json:"active"`
+	Allowance   Allowance         `json:"allowance"`
+	StartHeight types.BlockHeight `json:"startheight"`
+	EndHeight   types.BlockHeight `json:"endheight"`
+	Expired     bool              `json:"expired"`
+	Contracts   uint64            `json:"contracts"`
+	Spent       types.Currency    `json:"spent"`
+}
+
+// RenterTrialReport combines the state of a trial with the spending of the
+// current period. If the trial hosts were tested, Upload and Download contain
+// the measurements of uploading a test file to every host and downloading it
+// again.
+type RenterTrialReport struct {
+	Trial    RenterTrial        `json:"trial"`
+	Spending ContractorSpending `json:"spending"`
+	Upload   *RenterSpeedTest   `json:"upload,omitempty"`
+	Download *RenterSpeedTest   `json:"download,omitempty"`
 }
 
 // ContractUtility contains metrics internal to the contractor that reflect the
@@ -76,6 +326,61 @@ type ContractUtility struct {
 	Locked        bool // Locked utilities can only be set to false.
 }
 
+// The contract lifecycle events that webhooks can subscribe to.
+const (
+	ContractEventFormed    = "formed"
+	ContractEventRenewed   = "renewed"
+	ContractEventCancelled = "cancelled"
+	ContractEventBad       = "bad"
+)
+
+// ContractEvents lists all the contract lifecycle events.
+var ContractEvents = []string{ContractEventFormed, ContractEventRenewed, ContractEventCancelled, ContractEventBad}
+
+// ContractEvent is the payload the renter POSTs to a webhook when the state of
+// a contract changes. RenewedFrom is only set for renewals.
+type ContractEvent struct {
+	Event         string               `json:"event"`
+	ContractID    types.FileContractID `json:"contractid"`
+	HostPublicKey types.SiaPublicKey   `json:"hostpublickey"`
+	RenewedFrom   types.FileContractID `json:"renewedfrom"`
+	BlockHeight   types.BlockHeight    `json:"blockheight"`
+	Timestamp     time.Time            `json:"timestamp"`
+}
+
+// RenterWebhook is a URL the renter notifies of contract lifecycle events. If
+// Events is empty, the webhook receives every event. The payload is signed
+// with the Secret using HMAC-SHA256.
+type RenterWebhook struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+// The categories of paid operations recorded in the audit log of the renter.
+const (
+	AuditCategoryFormation = "formation"
+	AuditCategoryRenewal   = "renewal"
+	AuditCategoryRefresh   = "refresh"
+	AuditCategoryUpload    = "upload"
+	AuditCategoryDownload  = "download"
+)
+
+// AuditCategories lists all the categories of the audit log.
+var AuditCategories = []string{AuditCategoryFormation, AuditCategoryRenewal, AuditCategoryRefresh, AuditCategoryUpload, AuditCategoryDownload}
+
+// RenterAuditEntry is a single operation in the audit log of the renter that
+// spent money. The costs of the entries of a contract add up to its fees and
+// spending fields. Bytes is only set for uploads and downloads.
+type RenterAuditEntry struct {
+	Timestamp     time.Time            `json:"timestamp"`
+	Category      string               `json:"category"`
+	ContractID    types.FileContractID `json:"contractid"`
+	HostPublicKey types.SiaPublicKey   `json:"hostpublickey"`
+	Bytes         uint64               `json:"bytes"`
+	Cost          types.Currency       `json:"cost"`
+}
+
 // DownloadInfo provides information about a file that has been requested for
 // download.
 type DownloadInfo struct {
@@ -84,6 +389,7 @@ type DownloadInfo struct {
 	Length          uint64 `json:"length"`          // The length requested for the download.
 	Offset          uint64 `json:"offset"`          // The offset within the siafile requested for the download.
 	SiaPath         string `json:"hyperspacepath"`  // The hyperspacepath of the file used for the download.
+	ID              string `json:"id"`              // Identifies the download when throttling, pausing or resuming it.
 
 	Completed            bool      `json:"completed"`            // Whether or not the download has completed.
 	EndTime              time.Time `json:"endtime"`              // The time when the download fully completed.
@@ -92,6 +398,9 @@ type DownloadInfo struct {
 	StartTime            time.Time `json:"starttime"`            // The time when the download was started.
 	StartTimeUnix        int64     `json:"starttimeunix"`        // The time when the download was started in unix format.
 	TotalDataTransferred uint64    `json:"totaldatatransferred"` // Total amount of data transferred, including negotiation, etc.
+
+	Paused   bool   `json:"paused"`   // Whether no new chunks of the download are fetched.
+	Throttle uint64 `json:"throttle"` // Bytes per second the download is limited to. 0 if it isn't throttled.
 }
 
 // FileUploadParams contains the information used by the Renter to upload a
@@ -101,6 +410,35 @@ type FileUploadParams struct {
 	SiaPath     string
 	ErasureCode ErasureCoder
 	Overwrite   bool
+
+	// Dedup lets the chunks of the file reference the pieces of identical
+	// chunks of other files instead of uploading them again.
+	Dedup bool
+
+	// RedundancyGroup is the redundancy group the file is added to, see
+	// FileInfo.
+	RedundancyGroup string
+}
+
+// SiaFileVerification is the result of checking the file of a siafile on disk.
+// Problems lists the corruption that was found. Repaired is true if the file
+// was written again from the loaded siafile to fix the problems, and
+// BytesReclaimed is the space that was freed by compacting the file.
+type SiaFileVerification struct {
+	SiaPath        string   `json:"siapath"`
+	Problems       []string `json:"problems"`
+	Repaired       bool     `json:"repaired"`
+	BytesReclaimed uint64   `json:"bytesreclaimed"`
+}
+
+// RenterFilesVerification summarizes a verification of all siafiles. Files
+// only lists the files that had problems or were compacted.
+type RenterFilesVerification struct {
+	FilesChecked   uint64                `json:"fileschecked"`
+	FilesCorrupted uint64                `json:"filescorrupted"`
+	FilesRepaired  uint64                `json:"filesrepaired"`
+	BytesReclaimed uint64                `json:"bytesreclaimed"`
+	Files          []SiaFileVerification `json:"files"`
 }
 
 // FileInfo provides information about a file.
@@ -109,18 +447,304 @@ type FileInfo struct {
 	Available      bool              `json:"available"`
 	ChangeTime     time.Time         `json:"changetime"`
 	CipherType     string            `json:"ciphertype"`
+	ContentHash    crypto.Hash       `json:"contenthash"`
 	CreateTime     time.Time         `json:"createtime"`
+	Degraded       bool              `json:"degraded"`
 	Expiration     types.BlockHeight `json:"expiration"`
 	Filesize       uint64            `json:"filesize"`
+	HostSpread     uint64            `json:"hostspread"`
 	LocalPath      string            `json:"localpath"`
 	ModTime        time.Time         `json:"modtime"`
 	OnDisk         bool              `json:"ondisk"`
 	Recoverable    bool              `json:"recoverable"`
 	Redundancy     float64           `json:"redundancy"`
+	Rekeying       bool              `json:"rekeying"`
 	Renewing       bool              `json:"renewing"`
 	SiaPath        string            `json:"siapath"`
 	UploadedBytes  uint64            `json:"uploadedbytes"`
 	UploadProgress float64           `json:"uploadprogress"`
+
+	// SpareHosts is the number of hosts beyond the number of pieces that the
+	// chunks of the file should be spread across. TolerableHostLoss is the
+	// number of hosts that can be lost in the worst case while the file
+	// remains recoverable.
+	SpareHosts        uint64 `json:"sparehosts"`
+	TolerableHostLoss uint64 `json:"tolerablehostloss"`
+
+	// RedundancyGroup is the name of the redundancy group of the file. The
+	// renter avoids placing the pieces of files in different redundancy
+	// groups on the same hosts. Empty if the file isn't part of a group.
+	RedundancyGroup string `json:"redundancygroup"`
+
+	// RepairBudget is the monthly budget that applies to the file and
+	// RepairBudgetRemaining is the amount that can still be spent on it
+	// during the current period. If several budgets apply, the one with the
+	// least remaining funds is reported. Both are zero if the file has no
+	// budget.
+	RepairBudget          types.Currency `json:"repairbudget"`
+	RepairBudgetRemaining types.Currency `json:"repairbudgetremaining"`
+
+	// Dedup is set if the file was uploaded with deduplication. SharedChunks
+	// is the number of its chunks that reference the pieces of an identical
+	// chunk of another file.
+	Dedup        bool   `json:"dedup"`
+	SharedChunks uint64 `json:"sharedchunks"`
+
+	// Packed is set if the file was uploaded as part of a batch and shares
+	// its chunk with other small files.
+	Packed bool `json:"packed"`
+}
+
+// RenterHostContractsCancel reports the contracts that were cancelled with a
+// host and the number of files that stored pieces on it.
+type RenterHostContractsCancel struct {
+	Contracts     []types.FileContractID `json:"contracts"`
+	AffectedFiles uint64                 `json:"affectedfiles"`
+}
+
+const (
+	// ContractMigrationInProgress is the status of a migration whose chunks
+	// are still being uploaded to other hosts.
+	ContractMigrationInProgress = "migrating"
+
+	// ContractMigrationComplete is the status of a migration whose contract
+	// was cancelled after its data was moved to other hosts.
+	ContractMigrationComplete = "complete"
+
+	// ContractMigrationFailed is the status of a migration that didn't move
+	// the data of its contract in time. The contract stays active.
+	ContractMigrationFailed = "failed"
+)
+
+// ContractMigration reports the progress of moving the data stored with the
+// host of a contract to other hosts before the contract is cancelled.
+// ChunksRemaining is the number of chunks that still depend on the host to
+// reach full redundancy.
+type ContractMigration struct {
+	ContractID      types.FileContractID `json:"contractid"`
+	HostPublicKey   types.SiaPublicKey   `json:"hostpublickey"`
+	Status          string               `json:"status"`
+	ChunksTotal     uint64               `json:"chunkstotal"`
+	ChunksRemaining uint64               `json:"chunksremaining"`
+	Started         time.Time            `json:"started"`
+	Error           string               `json:"error,omitempty"`
+}
+
+// RenterContractReassociation reports the outcome of re-associating a
+// contract with the files that reference the sectors stored under it.
+// OrphanedSectors are the sectors of the contract that no file references.
+type RenterContractReassociation struct {
+	ChunksReassociated uint64        `json:"chunksreassociated"`
+	PiecesReassociated uint64        `json:"piecesreassociated"`
+	Files              []string      `json:"files"`
+	OrphanedSectors    []crypto.Hash `json:"orphanedsectors"`
+}
+
+// RenterContractOrphanedSectors reports the sectors that the host of a
+// contract stores under it but that no file references. Reassociable sectors
+// are referenced by a file, but only with other hosts, re-associating the
+// contract adds the host to their pieces. WastedSpend estimates the share of
+// the contract's storage and upload spending that went to the orphaned
+// sectors. Error is set if the sector roots couldn't be fetched from the host.
+type RenterContractOrphanedSectors struct {
+	ContractID          types.FileContractID `json:"contractid"`
+	HostPublicKey       types.SiaPublicKey   `json:"hostpublickey"`
+	Sectors             uint64               `json:"sectors"`
+	OrphanedSectors     []crypto.Hash        `json:"orphanedsectors"`
+	ReassociableSectors uint64               `json:"reassociablesectors"`
+	WastedSpend         types.Currency       `json:"wastedspend"`
+	Error               string               `json:"error,omitempty"`
+}
+
+// RenterOrphanedSectors reports the orphaned sectors of all active contracts.
+// ReclaimableBytes is the storage taken up by the orphaned sectors.
+type RenterOrphanedSectors struct {
+	Contracts           []RenterContractOrphanedSectors `json:"contracts"`
+	OrphanedSectors     uint64                          `json:"orphanedsectors"`
+	ReassociableSectors uint64                          `json:"reassociablesectors"`
+	ReclaimableBytes    uint64                          `json:"reclaimablebytes"`
+	WastedSpend         types.Currency                  `json:"wastedspend"`
+}
+
+// RenterOrphanedSectorsReclaim reports the outcome of re-associating all
+// active contracts with the files that reference their sectors. Droppable
+// reports the sectors that are still orphaned afterwards.
+type RenterOrphanedSectorsReclaim struct {
+	ChunksReassociated uint64                `json:"chunksreassociated"`
+	PiecesReassociated uint64                `json:"piecesreassociated"`
+	Files              []string              `json:"files"`
+	Droppable          RenterOrphanedSectors `json:"droppable"`
+}
+
+// LostFileInfo describes a file that can't be recovered anymore, because
+// some of its chunks have fewer pieces on the renter's hosts than are needed
+// to decode them and the file isn't available on disk either.
+type LostFileInfo struct {
+	SiaPath             string   `json:"siapath"`
+	Filesize            uint64   `json:"filesize"`
+	LocalPath           string   `json:"localpath"`
+	NumChunks           uint64   `json:"numchunks"`
+	UnrecoverableChunks []uint64 `json:"unrecoverablechunks"`
+}
+
+// RepairBudget limits the amount the renter spends per month on uploading
+// and repairing a file or all the files in a directory. Once the budget is
+// used up, the files are no longer repaired until the next period starts or
+// the budget is raised.
+type RepairBudget struct {
+	SiaPath     string         `json:"siapath"`
+	Monthly     types.Currency `json:"monthly"`
+	Spent       types.Currency `json:"spent"`
+	PeriodStart time.Time      `json:"periodstart"`
+}
+
+const (
+	// ScheduledUploadPending is the status of a scheduled upload that hasn't
+	// reached its start time yet.
+	ScheduledUploadPending = "pending"
+
+	// ScheduledUploadStarted is the status of a scheduled upload that was
+	// handed to the renter's upload path at its start time.
+	ScheduledUploadStarted = "started"
+
+	// ScheduledUploadFailed is the status of a scheduled upload that couldn't
+	// be started, e.g. because the source file was moved or deleted.
+	ScheduledUploadFailed = "failed"
+)
+
+// ScheduledUpload is an upload that the renter starts at StartTime. Zero
+// erasure coding parameters use the default erasure code. Once the start time
+// is reached, Status records whether the upload was started, and Error holds
+// the reason if it wasn't.
+type ScheduledUpload struct {
+	ID           string    `json:"id"`
+	SiaPath      string    `json:"siapath"`
+	Source       string    `json:"source"`
+	StartTime    time.Time `json:"starttime"`
+	DataPieces   int       `json:"datapieces"`
+	ParityPieces int       `json:"paritypieces"`
+	Overwrite    bool      `json:"overwrite"`
+
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RenterBatchFile is a file of a batch upload.
+type RenterBatchFile struct {
+	SiaPath string `json:"siapath"`
+	Source  string `json:"source"`
+}
+
+// RenterUploadBatch reports the progress of a batch upload. The files of a
+// batch are packed into shared chunks which are uploaded concurrently. If the
+// upload of a pack fails, its files are listed in Failed, the files of the
+// other packs are kept.
+type RenterUploadBatch struct {
+	ID            string   `json:"id"`
+	Files         uint64   `json:"files"`
+	Packs         uint64   `json:"packs"`
+	PacksUploaded uint64   `json:"packsuploaded"`
+	FilesUploaded uint64   `json:"filesuploaded"`
+	Failed        []string `json:"failed"`
+	Finished      bool     `json:"finished"`
+	Error         string   `json:"error,omitempty"`
+}
+
+// RenterLocalBackupConfig configures the snapshots of the renter's file and
+// directory metadata that are stored in a local directory. The Count most
+// recent snapshots are kept. A zero Interval disables the snapshots.
+type RenterLocalBackupConfig struct {
+	Path     string        `json:"path"`
+	Count    uint64        `json:"count"`
+	Interval time.Duration `json:"interval"`
+}
+
+// RenterLocalSnapshot is a snapshot of the renter's metadata in the local
+// backup directory.
+type RenterLocalSnapshot struct {
+	Name  string    `json:"name"`
+	Time  time.Time `json:"time"`
+	Files uint64    `json:"files"`
+}
+
+// RenterLocalBackups lists the snapshots in the local backup directory.
+type RenterLocalBackups struct {
+	Config    RenterLocalBackupConfig `json:"config"`
+	Snapshots []RenterLocalSnapshot   `json:"snapshots"`
+}
+
+// RedundancyGroup lists the files of a redundancy group and the hosts that
+// store their pieces. OverlappingHosts are the hosts that also store pieces of
+// other groups, which happens if there weren't enough hosts to keep the groups
+// apart or if the group of a file was changed after it was uploaded.
+type RedundancyGroup struct {
+	Name             string               `json:"name"`
+	Files            []string             `json:"files"`
+	Hosts            []types.SiaPublicKey `json:"hosts"`
+	OverlappingHosts []types.SiaPublicKey `json:"overlappinghosts"`
+}
+
+// RenterAlert is a problem of the renter that needs the attention of the
+// user. Alerts are removed once their cause is resolved.
+type RenterAlert struct {
+	Cause   string    `json:"cause"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// DownloadRepairResult reports the outcome of verifying a local copy of a file
+// and repairing the chunks that didn't match.
+type DownloadRepairResult struct {
+	ChunksRepaired    uint64 `json:"chunksrepaired"`
+	ChunksUnavailable uint64 `json:"chunksunavailable"`
+	ChunksVerified    uint64 `json:"chunksverified"`
+	SizeMismatch      bool   `json:"sizemismatch"`
+}
+
+// FileEffectiveRedundancy compares the redundancy of a file with its
+// redundancy when all the hosts in the same address range are treated as a
+// single host. The address ranges are the subnets of the hostdb's address
+// filter, hosts aren't grouped by the network (ASN) they belong to. Only
+// online and goodForRenew hosts are counted.
+type FileEffectiveRedundancy struct {
+	SiaPath             string  `json:"siapath"`
+	Redundancy          float64 `json:"redundancy"`
+	EffectiveRedundancy float64 `json:"effectiveredundancy"`
+	Hosts               uint64  `json:"hosts"`
+	AddressRanges       uint64  `json:"addressranges"`
+}
+
+// FileHistoryEvent describes how the distribution of a file's pieces across
+// hosts changed between two runs of the repair loop. Only pieces on hosts that
+// are online and goodForRenew are counted.
+type FileHistoryEvent struct {
+	Time        time.Time            `json:"time"`
+	Redundancy  float64              `json:"redundancy"`
+	PiecesAdded uint64               `json:"piecesadded"`
+	PiecesLost  uint64               `json:"pieceslost"`
+	HostsAdded  []types.SiaPublicKey `json:"hostsadded"`
+	HostsLost   []types.SiaPublicKey `json:"hostslost"`
+}
+
+// FileHistory contains the history events of a file within a time range and
+// the net change between the start and the end of the range. Hosts that were
+// lost and gained again within the range don't appear in the net change.
+type FileHistory struct {
+	Events      []FileHistoryEvent   `json:"events"`
+	PiecesAdded uint64               `json:"piecesadded"`
+	PiecesLost  uint64               `json:"pieceslost"`
+	HostsAdded  []types.SiaPublicKey `json:"hostsadded"`
+	HostsLost   []types.SiaPublicKey `json:"hostslost"`
+}
+
+// FileRebuildStatus reports the progress of a user-requested rebuild of a file
+// to full redundancy.
+type FileRebuildStatus struct {
+	Active         bool   `json:"active"`
+	Cancelled      bool   `json:"cancelled"`
+	ChunksFailed   uint64 `json:"chunksfailed"`
+	ChunksFinished uint64 `json:"chunksfinished"`
+	ChunksTotal    uint64 `json:"chunkstotal"`
 }
 
 // A HostDBEntry represents one host entry in the Renter's host DB. It
@@ -138,6 +762,42 @@ type HostDBEntry struct {
 	HistoricUptime   time.Duration `json:"historicuptime"`
 	ScanHistory      HostDBScans   `json:"scanhistory"`
 
+	// FirstScan is the time of the first scan of the host. It is kept when
+	// the oldest scans are pruned from the scan history.
+	FirstScan time.Time `json:"firstscan"`
+
+	// NextScan is the time at which the hostdb will scan the host again. The
+	// interval between scans depends on how reliable the host has been.
+	NextScan time.Time `json:"nextscan"`
+
+	// ScanFailures is the number of scans in a row that the host failed. The
+	// interval between the scans of an offline host grows with every failure
+	// and the count is reset once the host passes a scan.
+	ScanFailures uint64 `json:"scanfailures"`
+
+	// Filtered is true if the filter of the hostdb excludes the host from new
+	// contracts.
+	Filtered bool `json:"filtered"`
+
+	// MeasuredUploadBandwidth and MeasuredDownloadBandwidth are moving
+	// averages of the throughput of the renter's transfers with the host, in
+	// bytes per second. They are zero until a transfer has been measured.
+	MeasuredUploadBandwidth   float64 `json:"measureduploadbandwidth"`
+	MeasuredDownloadBandwidth float64 `json:"measureddownloadbandwidth"`
+
+	// CapacityProofFailures is the number of capacity challenges in a row
+	// that the host answered with an invalid proof. LastCapacityProof is the
+	// time at which the host was last challenged. Hosts that don't support
+	// capacity challenges are never counted as failing.
+	CapacityProofFailures uint64    `json:"capacityprooffailures"`
+	LastCapacityProof     time.Time `json:"lastcapacityproof"`
+
+	// RecentMissedProofs is the number of storage proofs the host missed
+	// on-chain recently, LastMissedProof the height of the most recent one.
+	// The count is reset once the host went a while without missing a proof.
+	RecentMissedProofs uint64            `json:"recentmissedproofs"`
+	LastMissedProof    types.BlockHeight `json:"lastmissedproof"`
+
 	HistoricFailedInteractions     float64 `json:"historicfailedinteractions"`
 	HistoricSuccessfulInteractions float64 `json:"historicsuccessfulinteractions"`
 	RecentFailedInteractions       float64 `json:"recentfailedinteractions"`
@@ -156,6 +816,60 @@ type HostDBScan struct {
 	Success   bool      `json:"success"`
 }
 
+// HostDBRescanStatus reports the progress of a rescan of all hosts of the
+// hostdb.
+type HostDBRescanStatus struct {
+	// Active is true while hosts of the rescan are still waiting to be
+	// scanned.
+	Active bool `json:"active"`
+
+	// Total is the number of hosts that are rescanned, Done the number of
+	// those hosts that were scanned already and Pending the number of hosts
+	// that still need to be scanned.
+	Total   uint64 `json:"total"`
+	Done    uint64 `json:"done"`
+	Pending uint64 `json:"pending"`
+
+	// StartTime and EndTime are the times the most recent rescan started and
+	// finished. EndTime is zero while the rescan is active.
+	StartTime time.Time `json:"starttime"`
+	EndTime   time.Time `json:"endtime"`
+}
+
+// HostDBReputationFeed is an external source of host reputation scores, such
+// as a community-maintained list of reliable hosts. The scores of the feed
+// adjust the weight of the listed hosts by at most Weight in either direction.
+type HostDBReputationFeed struct {
+	// URL is the HTTP endpoint of the feed. An empty URL disables the feed.
+	URL    string  `json:"url"`
+	Weight float64 `json:"weight"`
+
+	// Hosts is the number of hosts listed by the cached scores of the feed,
+	// and LastUpdate the time they were downloaded. LastError is the error of
+	// the most recent failed download, if any.
+	Hosts      uint64    `json:"hosts"`
+	LastUpdate time.Time `json:"lastupdate"`
+	LastError  string    `json:"lasterror"`
+}
+
+// The modes of the hostdb's filter of hosts. A blacklist excludes the listed
+// hosts from new contracts, a whitelist excludes all other hosts.
+const (
+	HostDBFilterDisabled  = "disable"
+	HostDBFilterBlacklist = "blacklist"
+	HostDBFilterWhitelist = "whitelist"
+)
+
+// HostDBFilterModes lists all the modes of the hostdb's filter.
+var HostDBFilterModes = []string{HostDBFilterDisabled, HostDBFilterBlacklist, HostDBFilterWhitelist}
+
+// HostDBFilter is the filter of hosts the hostdb applies when selecting hosts
+// for new contracts.
+type HostDBFilter struct {
+	Mode  string               `json:"mode"`
+	Hosts []types.SiaPublicKey `json:"hosts"`
+}
+
 // HostScoreBreakdown provides a piece-by-piece explanation of why a host has
 // the score that they do.
 //
@@ -167,16 +881,168 @@ type HostScoreBreakdown struct {
 	Score          types.Currency `json:"score"`
 	ConversionRate float64        `json:"conversionrate"`
 
+	// DecayedUptime is the fraction of time the host was online, with recent
+	// scans weighted more heavily than old ones.
+	DecayedUptime float64 `json:"decayeduptime"`
+
+	// MissedProofs is the number of storage proofs the host recently missed
+	// on-chain.
+	MissedProofs uint64 `json:"missedproofs"`
+
 	AgeAdjustment              float64 `json:"ageadjustment"`
+	BandwidthAdjustment        float64 `json:"bandwidthadjustment"`
 	BurnAdjustment             float64 `json:"burnadjustment"`
+	CapacityAdjustment         float64 `json:"capacityadjustment"`
 	CollateralAdjustment       float64 `json:"collateraladjustment"`
 	InteractionAdjustment      float64 `json:"interactionadjustment"`
+	MissedProofAdjustment      float64 `json:"missedproofadjustment"`
 	PriceAdjustment            float64 `json:"pricesmultiplier"`
+	ReputationAdjustment       float64 `json:"reputationadjustment"`
 	StorageRemainingAdjustment float64 `json:"storageremainingadjustment"`
 	UptimeAdjustment           float64 `json:"uptimeadjustment"`
 	VersionAdjustment          float64 `json:"versionadjustment"`
 }
 
+// AllowanceRecommendation is the estimated cheapest allowance that stores a
+// given amount of data at a given redundancy, along with the breakdown of the
+// costs. Funds is the sum of the costs plus a margin for price changes and
+// usage that deviates from the estimate.
+type AllowanceRecommendation struct {
+	Funds  types.Currency    `json:"funds"`
+	Hosts  uint64            `json:"hosts"`
+	Period types.BlockHeight `json:"period"`
+
+	StorageCost     types.Currency `json:"storagecost"`
+	UploadCost      types.Currency `json:"uploadcost"`
+	ContractFees    types.Currency `json:"contractfees"`
+	TransactionFees types.Currency `json:"transactionfees"`
+	Margin          types.Currency `json:"margin"`
+}
+
+// FileRepairEstimate is the approximate time until the repair loop restored a
+// file. QueuePosition is the number of queued chunks of other files that are
+// repaired before the first chunk of the file.
+type FileRepairEstimate struct {
+	SiaPath           string        `json:"siapath"`
+	Degraded          bool          `json:"degraded"`
+	BytesToRepair     uint64        `json:"bytestorepair"`
+	QueuePosition     uint64        `json:"queueposition"`
+	EstimatedDuration time.Duration `json:"estimatedduration"`
+}
+
+// RenterRepairEstimate is the approximate time until the repair loop worked
+// through its queue, based on the bytes that still need to be uploaded and
+// the recently measured repair throughput. The durations are 0 if no
+// throughput was measured yet.
+type RenterRepairEstimate struct {
+	Files             []FileRepairEstimate `json:"files"`
+	BytesToRepair     uint64               `json:"bytestorepair"`
+	Throughput        float64              `json:"throughput"` // bytes per second
+	EstimatedDuration time.Duration        `json:"estimatedduration"`
+}
+
+// HostSpeedTest is the result of a speed test against a single host. Latency
+// is the time it took to connect to the host and open a session, Duration is
+// the time spent transferring sectors afterwards.
+type HostSpeedTest struct {
+	HostPublicKey types.SiaPublicKey `json:"hostpublickey"`
+	NetAddress    NetAddress         `json:"netaddress"`
+	Bytes         uint64             `json:"bytes"`
+	Latency       time.Duration      `json:"latency"`
+	Duration      time.Duration      `json:"duration"`
+	Throughput    float64            `json:"throughput"` // bytes per second
+	Cost          types.Currency     `json:"cost"`
+	Error         string             `json:"error"`
+}
+
+// RenterSpeedTest is the result of downloading a few sectors from every host
+// the renter has an active contract with at the same time. Throughput is the
+// combined throughput of all hosts, AverageLatency only includes the hosts
+// that could be reached.
+type RenterSpeedTest struct {
+	Hosts          []HostSpeedTest `json:"hosts"`
+	Bytes          uint64          `json:"bytes"`
+	Duration       time.Duration   `json:"duration"`
+	Throughput     float64         `json:"throughput"` // bytes per second
+	AverageLatency time.Duration   `json:"averagelatency"`
+	Cost           types.Currency  `json:"cost"`
+}
+
+// ErasureSchemeInfo describes the storage overhead and fault tolerance of an
+// erasure code. StorageMultiplier is the ratio of the storage used on the
+// hosts to the size of the file, including the encryption overhead of the
+// pieces. A file is recoverable down to MinRedundancy and has FullRedundancy
+// once all pieces are uploaded.
+type ErasureSchemeInfo struct {
+	DataPieces        int     `json:"datapieces"`
+	ParityPieces      int     `json:"paritypieces"`
+	ChunkSize         uint64  `json:"chunksize"`
+	StorageMultiplier float64 `json:"storagemultiplier"`
+	HostsTolerated    uint64  `json:"hoststolerated"`
+	MinRedundancy     float64 `json:"minredundancy"`
+	FullRedundancy    float64 `json:"fullredundancy"`
+}
+
+// RenterThroughputSample is the aggregate upload and download throughput of
+// the renter's workers over one sampling interval, which ended at Time.
+type RenterThroughputSample struct {
+	Time               time.Time `json:"time"`
+	BytesUploaded      uint64    `json:"bytesuploaded"`
+	BytesDownloaded    uint64    `json:"bytesdownloaded"`
+	UploadThroughput   float64   `json:"uploadthroughput"`   // bytes per second
+	DownloadThroughput float64   `json:"downloadthroughput"` // bytes per second
+}
+
+// WorkerStatus contains the latency estimates of a worker. The RTTs are the
+// median durations of the recent RPCs with the host, the timeouts are the
+// durations after which the next RPC is abandoned.
+type WorkerStatus struct {
+	ContractID      types.FileContractID `json:"contractid"`
+	HostPublicKey   types.SiaPublicKey   `json:"hostpublickey"`
+	DownloadRTT     time.Duration        `json:"downloadrtt"`
+	DownloadTimeout time.Duration        `json:"downloadtimeout"`
+	UploadRTT       time.Duration        `json:"uploadrtt"`
+	UploadTimeout   time.Duration        `json:"uploadtimeout"`
+}
+
+// WorkerPoolStatus contains the status of the workers in the renter's worker
+// pool.
+type WorkerPoolStatus struct {
+	NumWorkers int            `json:"numworkers"`
+	Workers    []WorkerStatus `json:"workers"`
+}
+
+// WorkerQueueStatus describes the jobs waiting in one of the queues of a
+// worker. The ages are the time since the jobs were queued.
+type WorkerQueueStatus struct {
+	Jobs          int           `json:"jobs"`
+	OldestJobAge  time.Duration `json:"oldestjobage"`
+	AverageJobAge time.Duration `json:"averagejobage"`
+	Terminated    bool          `json:"terminated"`
+}
+
+// WorkerDetailedStatus contains the job queues of a worker and whether it
+// currently refuses uploads because recent uploads to the host failed.
+type WorkerDetailedStatus struct {
+	ContractID    types.FileContractID `json:"contractid"`
+	HostPublicKey types.SiaPublicKey   `json:"hostpublickey"`
+
+	DownloadQueue WorkerQueueStatus `json:"downloadqueue"`
+	UploadQueue   WorkerQueueStatus `json:"uploadqueue"`
+
+	UploadOnCooldown          bool      `json:"uploadoncooldown"`
+	UploadCooldownUntil       time.Time `json:"uploadcooldownuntil"`
+	UploadConsecutiveFailures int       `json:"uploadconsecutivefailures"`
+	UploadRecentError         string    `json:"uploadrecenterror"`
+}
+
+// WorkerPoolDetailedStatus contains the detailed status of the workers in
+// the renter's worker pool.
+type WorkerPoolDetailedStatus struct {
+	NumWorkers int                    `json:"numworkers"`
+	Workers    []WorkerDetailedStatus `json:"workers"`
+}
+
 // RenterPriceEstimation contains a bunch of files estimating the costs of
 // various operations on the network.
 type RenterPriceEstimation struct {
@@ -200,6 +1066,14 @@ type RenterSettings struct {
 	MaxUploadSpeed   int64     `json:"maxuploadspeed"`
 	MaxDownloadSpeed int64     `json:"maxdownloadspeed"`
 	StreamCacheSize  uint64    `json:"streamcachesize"`
+
+	// CostOptimizedDownloads makes the renter fetch every chunk from the
+	// cheapest hosts that can recover it instead of the fastest ones.
+	CostOptimizedDownloads bool `json:"costoptimizeddownloads"`
+
+	// MaxConcurrentRepairs is the maximum number of chunks the renter repairs
+	// at the same time. It doesn't affect downloads.
+	MaxConcurrentRepairs uint64 `json:"maxconcurrentrepairs"`
 }
 
 // HostDBScans represents a sortable slice of scans.
@@ -270,6 +1144,10 @@ type RenterContract struct {
 	// Utility contains utility information about the renter.
 	Utility ContractUtility
 
+	// Metadata contains the key/value pairs attached to the contract by the
+	// renter. It is carried over when the contract is renewed.
+	Metadata map[string]string
+
 	// TotalCost indicates the amount of money that the renter spent and/or
 	// locked up while forming a contract. This includes fees, and includes
 	// funds which were allocated (but not necessarily committed) to spend on
@@ -287,6 +1165,105 @@ type RenterContract struct {
 	TxnFee types.Currency
 }
 
+// RecoveryHintContract is a contract listed in a recovery hint. Together with
+// the secret key, the contract can be revised without any local state.
+type RecoveryHintContract struct {
+	ID            types.FileContractID `json:"id"`
+	HostPublicKey types.SiaPublicKey   `json:"hostpublickey"`
+	EndHeight     types.BlockHeight    `json:"endheight"`
+	SecretKey     crypto.SecretKey     `json:"-"`
+}
+
+// DirectoryInfo describes a directory of the renter. DataPieces and
+// ParityPieces are the erasure code of new uploads into the directory, which
+// is inherited from a parent directory or the defaults unless the directory
+// sets its own.
+type DirectoryInfo struct {
+	SiaPath      string  `json:"siapath"`
+	DataPieces   int     `json:"datapieces"`
+	ParityPieces int     `json:"paritypieces"`
+	Redundancy   float64 `json:"redundancy"`
+	Inherited    bool    `json:"inherited"`
+}
+
+// RecoverableContract is a contract listed in a recovery hint that is not part
+// of the renter's contract set. Its size is only known once it was recovered.
+type RecoverableContract struct {
+	HostPublicKey types.SiaPublicKey   `json:"hostpublickey"`
+	ID            types.FileContractID `json:"id"`
+	EndHeight     types.BlockHeight    `json:"endheight"`
+	Size          uint64               `json:"size"`
+}
+
+// RenterRecoveryHint lists the contracts of a renter. It is encrypted with a
+// key derived from the wallet seed and stored on several hosts, so that a
+// renter can locate its contracts from the seed alone.
+type RenterRecoveryHint struct {
+	Height    types.BlockHeight      `json:"height"`
+	Contracts []RecoveryHintContract `json:"contracts"`
+}
+
+// RenterRecoveryHintSync describes a synced recovery hint. The hint is stored
+// in the sector with the given root on each of the hosts, and the ID of the
+// transaction that points to it is returned.
+type RenterRecoveryHintSync struct {
+	SectorRoot    crypto.Hash          `json:"sectorroot"`
+	Hosts         []types.SiaPublicKey `json:"hosts"`
+	TransactionID types.TransactionID  `json:"transactionid"`
+}
+
+// RenterFileManifest lists the Merkle roots of the pieces of a file and the
+// hosts storing them at the time it was exported. It is signed with a key
+// derived from the wallet seed, so the renter can later check that the file
+// still matches the manifest.
+type RenterFileManifest struct {
+	SiaPath      string                `json:"siapath"`
+	FileSize     uint64                `json:"filesize"`
+	ContentHash  crypto.Hash           `json:"contenthash"`
+	DataPieces   uint64                `json:"datapieces"`
+	ParityPieces uint64                `json:"paritypieces"`
+	Height       types.BlockHeight     `json:"height"`
+	Chunks       []RenterManifestChunk `json:"chunks"`
+
+	PublicKey types.SiaPublicKey `json:"publickey"`
+	Signature crypto.Signature   `json:"signature"`
+}
+
+// RenterManifestChunk lists the pieces of a chunk in a manifest.
+type RenterManifestChunk struct {
+	Pieces []RenterManifestPiece `json:"pieces"`
+}
+
+// RenterManifestPiece is a piece of a chunk in a manifest. The Merkle root is
+// empty if the piece wasn't uploaded when the manifest was exported.
+type RenterManifestPiece struct {
+	MerkleRoot crypto.Hash          `json:"merkleroot"`
+	Hosts      []types.SiaPublicKey `json:"hosts"`
+}
+
+// RenterManifestVerification reports the outcome of a spot check of a file
+// against its manifest. Confidence is the probability that the samples would
+// have detected a file of which ManifestCorruptionThreshold of the pieces are
+// missing or don't match the manifest.
+type RenterManifestVerification struct {
+	SiaPath    string                 `json:"siapath"`
+	Samples    []RenterManifestSample `json:"samples"`
+	Passed     uint64                 `json:"passed"`
+	Failed     uint64                 `json:"failed"`
+	Confidence float64                `json:"confidence"`
+}
+
+// RenterManifestSample is a piece that was downloaded to verify a file against
+// its manifest. Host is the host that provided the piece, Error explains why
+// the sample failed.
+type RenterManifestSample struct {
+	ChunkIndex uint64             `json:"chunkindex"`
+	PieceIndex uint64             `json:"pieceindex"`
+	Host       types.SiaPublicKey `json:"host"`
+	Passed     bool               `json:"passed"`
+	Error      string             `json:"error,omitempty"`
+}
+
 // ContractorSpending contains the metrics about how much the Contractor has
 // spent during the current billing period.
 type ContractorSpending struct {
@@ -330,15 +1307,104 @@ type Renter interface {
 	// AllHosts returns the full list of hosts known to the renter.
 	AllHosts() []HostDBEntry
 
+	// Alerts returns the problems of the renter that need the attention of
+	// the user.
+	Alerts() []RenterAlert
+
+	// AllowanceTransition returns the progress of the contractor towards the
+	// most recently set allowance.
+	AllowanceTransition() AllowanceTransition
+
+	// ContractorFunding returns whether the contractor is funding contracts
+	// partially because the wallet balance is too low.
+	ContractorFunding() ContractorFunding
+
+	// RenewalSchedule returns when the active contracts are due for renewal
+	// and whether they are expected to be renewed, counting the contracts
+	// that are due within the next within blocks.
+	RenewalSchedule(within types.BlockHeight) (ContractRenewalSchedule, error)
+
+	// StartTrial sets a trial allowance with the provided funds, which forms
+	// contracts with a few hosts for a short period. Setting a different
+	// allowance with SetSettings promotes the trial, its contracts are topped
+	// up instead of replaced.
+	StartTrial(funds types.Currency) error
+
+	// Trial reports the state and cost of the trial.
+	Trial() RenterTrialReport
+
+	// TestTrial uploads a test file to every host of the running trial and
+	// downloads it again, reporting the performance and cost of both.
+	TestTrial() (RenterTrialReport, error)
+
+	// AuditLog returns the paid operations recorded between start and end
+	// that belong to one of the categories. A zero end time and an empty list
+	// of categories don't filter anything.
+	AuditLog(start, end time.Time, categories []string) ([]RenterAuditEntry, error)
+
+	// ExportAuditLog writes the whole audit log as CSV to the file at dst.
+	ExportAuditLog(dst string) error
+
+	// ExportHosts returns all hosts known to the hostdb together with their
+	// scan histories.
+	ExportHosts() []HostDBEntry
+
 	// Close closes the Renter.
 	Close() error
 
 	// CancelContract cancels a specific contract of the renter.
 	CancelContract(id types.FileContractID) error
 
+	// CancelHostContracts cancels every active contract with a host and
+	// queues the files storing pieces on it for repair.
+	CancelHostContracts(pk types.SiaPublicKey) (RenterHostContractsCancel, error)
+
+	// MigrateContract moves the data stored with the host of a contract to
+	// other hosts in the background and cancels the contract once no chunk
+	// depends on the host anymore.
+	MigrateContract(id types.FileContractID) error
+
+	// ContractMigration returns the most recent migration of a contract.
+	ContractMigration(id types.FileContractID) (ContractMigration, bool)
+
+	// ReassociateContract adds the host of a contract to the pieces of the
+	// files whose sectors the host stores under the contract.
+	ReassociateContract(id types.FileContractID) (RenterContractReassociation, error)
+
+	// OrphanedSectors reports the sectors stored under the active contracts
+	// that no file references.
+	OrphanedSectors() (RenterOrphanedSectors, error)
+
+	// ReclaimOrphanedSectors re-associates all active contracts with the
+	// files that reference their sectors and reports the sectors that are
+	// still orphaned.
+	ReclaimOrphanedSectors() (RenterOrphanedSectorsReclaim, error)
+
+	// RegisterWebhook registers a webhook for contract lifecycle events. A
+	// webhook with the same URL is replaced.
+	RegisterWebhook(hook RenterWebhook) error
+
+	// UnregisterWebhook removes the webhook with the given URL.
+	UnregisterWebhook(url string) error
+
+	// Webhooks returns the registered webhooks. Their secrets are masked.
+	Webhooks() []RenterWebhook
+
+	// CancelRebuild stops a rebuild that was started with RebuildFile.
+	// Chunks which were already handed to the workers are still finished.
+	CancelRebuild(siaPath string) error
+
 	// Contracts returns the staticContracts of the renter's hostContractor.
 	Contracts() []RenterContract
 
+	// SetContractMetadata replaces the key/value pairs attached to a
+	// contract. The metadata persists across renewals.
+	SetContractMetadata(id types.FileContractID, metadata map[string]string) error
+
+	// ContractRevision returns the signed transaction containing the latest
+	// revision of the contract with the given id.
+	ContractRevision(id types.FileContractID) (types.Transaction, bool)
+
 	// OldContracts returns the oldContracts of the renter's hostContractor.
 	OldContracts() []RenterContract
 
@@ -360,10 +1426,19 @@ type Renter interface {
 	// downloads of `offset` and `length` type.
 	Download(params RenterDownloadParameters) error
 
+	// DownloadCost estimates the cost of downloading a file using the
+	// current prices of the hosts that store it.
+	DownloadCost(siaPath string) (types.Currency, error)
+
 	// Download performs a download according to the parameters passed without
 	// blocking, including downloads of `offset` and `length` type.
 	DownloadAsync(params RenterDownloadParameters) error
 
+	// DownloadByContentHash downloads the file with the given content hash
+	// and returns the paths of all the files with that content hash, starting
+	// with the downloaded one.
+	DownloadByContentHash(contentHash crypto.Hash, params RenterDownloadParameters) ([]string, error)
+
 	// ClearDownloadHistory clears the download history of the renter
 	// inclusive for before and after times.
 	ClearDownloadHistory(after, before time.Time) error
@@ -371,19 +1446,115 @@ type Renter interface {
 	// DownloadHistory lists all the files that have been scheduled for download.
 	DownloadHistory() []DownloadInfo
 
+	// ThrottleDownload limits the download with the provided id to
+	// bytesPerSecond. A limit of zero pauses the download.
+	ThrottleDownload(id string, bytesPerSecond uint64) error
+
+	// PauseDownload stops fetching new chunks of the download with the
+	// provided id until it is resumed.
+	PauseDownload(id string) error
+
+	// ResumeDownload removes the pause and the throttle of the download with
+	// the provided id.
+	ResumeDownload(id string) error
+
+	// EffectiveRedundancy returns the redundancy of a file after collapsing
+	// the hosts that share an address range.
+	EffectiveRedundancy(siaPath string) (FileEffectiveRedundancy, error)
+
+	// ErasureSchemeInfo returns the storage overhead and fault tolerance of
+	// an erasure code with the provided number of pieces.
+	ErasureSchemeInfo(dataPieces, parityPieces int) (ErasureSchemeInfo, error)
+
 	// File returns information on specific file queried by user
 	File(siaPath string) (FileInfo, error)
 
+	// FileHistory returns the changes of the distribution of a file's pieces
+	// across hosts between start and end. A zero end includes all events
+	// after start.
+	FileHistory(siaPath string, start, end time.Time) (FileHistory, error)
+
+	// FilesByContentHash returns the paths of all the files with the given
+	// content hash.
+	FilesByContentHash(contentHash crypto.Hash) []string
+
 	// FileList returns information on all of the files stored by the renter.
 	FileList(filter ...*regexp.Regexp) []FileInfo
 
+	// VerifyFiles checks the siafiles on disk for corruption. If compact is
+	// true, corrupted files are repaired and unused space is reclaimed.
+	VerifyFiles(compact bool) (RenterFilesVerification, error)
+
 	// Host provides the DB entry and score breakdown for the requested host.
 	Host(pk types.SiaPublicKey) (HostDBEntry, bool)
 
+	// ImportHosts seeds the hostdb with hosts exported by another node and
+	// returns the number of hosts that were imported.
+	ImportHosts(hosts []HostDBEntry) (int, error)
+
 	// InitialScanComplete returns a boolean indicating if the initial scan of the
 	// hostdb is completed.
 	InitialScanComplete() (bool, error)
 
+	// RescanHosts queues a scan of every host known to the hostdb. If a
+	// rescan is already in progress, it is left untouched.
+	RescanHosts() error
+
+	// RescanStatus returns the progress of the most recent rescan of the
+	// hostdb.
+	RescanStatus() HostDBRescanStatus
+
+	// ReputationFeed returns the external reputation feed of the hostdb and
+	// the state of its cached scores.
+	ReputationFeed() HostDBReputationFeed
+
+	// SetReputationFeed sets the external reputation feed whose scores are
+	// folded into the weights of the hosts. An empty url disables the feed.
+	SetReputationFeed(url string, weight float64) error
+
+	// SetMaxScanHistoryLen sets the number of most recent scans the hostdb
+	// keeps in the scan history of a host. Older scans are compressed into
+	// the historic uptime and downtime of the host.
+	SetMaxScanHistoryLen(n int) error
+
+	// SetMaxScanningThreads sets the number of threads the hostdb uses to
+	// scan hosts concurrently.
+	SetMaxScanningThreads(n int) error
+
+	// SetUptimeHalfLife sets the half-life of the decay that weights the
+	// uptime of a host by the age of its scans.
+	SetUptimeHalfLife(halfLife time.Duration) error
+
+	// AddressFilterRanges returns the prefix lengths of the IPv4 and IPv6
+	// subnets that hosts can't share.
+	AddressFilterRanges() (ipv4Range, ipv6Range int)
+
+	// SetAddressFilterRanges sets the prefix lengths of the IPv4 and IPv6
+	// subnets that hosts can't share. Contracts with hosts that share a
+	// subnet are cancelled.
+	SetAddressFilterRanges(ipv4Range, ipv6Range int) error
+
+	// Filter returns the filter of hosts the hostdb applies when selecting
+	// hosts for new contracts.
+	Filter() HostDBFilter
+
+	// SetFilterMode sets the mode of the hostdb's filter and the hosts it
+	// lists. Contracts with hosts that the filter excludes aren't renewed.
+	SetFilterMode(mode string, hosts []types.SiaPublicKey) error
+
+	// LocalBackups returns the configuration of the local metadata backups
+	// and the snapshots that were taken.
+	LocalBackups() (RenterLocalBackups, error)
+
+	// SetLocalBackupConfig sets the directory, the number of snapshots kept
+	// and the interval of the local metadata backups.
+	SetLocalBackupConfig(config RenterLocalBackupConfig) error
+
+	// RestoreLocalBackup replaces the metadata of the files in a local
+	// snapshot with their versions in the snapshot. It returns the number of
+	// files that were restored.
+	RestoreLocalBackup(name string) (uint64, error)
+
 	// LoadSharedFiles loads a '.sia' file into the renter. A .sia file may
 	// contain multiple files. The paths of the added files are returned.
 	LoadSharedFiles(source string) ([]string, error)
@@ -392,13 +1563,65 @@ type Renter interface {
 	// renter.
 	LoadSharedFilesASCII(asciiSia string) ([]string, error)
 
+	// LostFiles returns the files that have chunks which can't be recovered
+	// from the hosts the renter currently has contracts with.
+	LostFiles() []LostFileInfo
+
+	// MaintenancePaused returns whether renter maintenance is paused.
+	MaintenancePaused() bool
+
+	// ActiveRepairs returns the number of chunks that are currently being
+	// repaired.
+	ActiveRepairs() uint64
+
+	// PauseMaintenance stops the renter from forming, renewing and replacing
+	// contracts and from repairing files until ResumeMaintenance is called.
+	// Downloads are not affected.
+	PauseMaintenance() error
+
 	// PriceEstimation estimates the cost in siacoins of performing various
 	// storage and data operations.
 	PriceEstimation() RenterPriceEstimation
 
+	// RecommendAllowance estimates the cheapest allowance that stores
+	// dataSize bytes at the given redundancy for period blocks, given the
+	// current prices of the hosts.
+	RecommendAllowance(dataSize uint64, redundancy float64, period types.BlockHeight) (AllowanceRecommendation, error)
+
+	// RepairEstimate returns the approximate time until the files in the
+	// repair queue are repaired.
+	RepairEstimate() RenterRepairEstimate
+
+	// RepairDownload verifies a local copy of a file against the pieces on
+	// the hosts and downloads only the chunks that don't match.
+	RepairDownload(siaPath, localPath string) (DownloadRepairResult, error)
+
+	// RebuildFile repairs every chunk of a file to full redundancy,
+	// regardless of the repair threshold.
+	RebuildFile(siaPath string) error
+
+	// RebuildStatus returns the progress of the most recent rebuild of a
+	// file.
+	RebuildStatus(siaPath string) (FileRebuildStatus, error)
+
+	// RekeyFile re-encrypts a file under a new masterkey by downloading and
+	// re-uploading all of its chunks.
+	RekeyFile(siaPath string) error
+
+	// CancelRekey stops re-encrypting a file. The chunks that were
+	// re-encrypted already keep their new key.
+	CancelRekey(siaPath string) error
+
 	// RenameFile changes the path of a file.
 	RenameFile(path, newPath string) error
 
+	// RestoreSimulatedHost stops treating a host as failed after a call to
+	// SimulateHostFailure.
+	RestoreSimulatedHost(hostKey types.SiaPublicKey) error
+
+	// ResumeMaintenance resumes the maintenance paused by PauseMaintenance.
+	ResumeMaintenance() error
+
 	// EstimateHostScore will return the score for a host with the provided
 	// settings, assuming perfect age and uptime adjustments
 	EstimateHostScore(entry HostDBEntry) HostScoreBreakdown
@@ -413,6 +1636,61 @@ type Renter interface {
 	// SetSettings sets the Renter's settings.
 	SetSettings(RenterSettings) error
 
+	// SimulateHostFailure makes the renter treat a host as failed without
+	// cancelling its contract. Only available in debug and dev builds.
+	SimulateHostFailure(hostKey types.SiaPublicKey) error
+
+	// RestoreRecoveryHint finds the most recent recovery hint of the seed on
+	// the blockchain, downloads it from any of the hosts that store it and
+	// returns the contracts it lists.
+	RestoreRecoveryHint(seed Seed) (RenterRecoveryHint, error)
+
+	// SyncRecoveryHint stores a recovery hint listing the current contracts
+	// on several hosts.
+	SyncRecoveryHint() (RenterRecoveryHintSync, error)
+
+	// RecoverableContracts lists the contracts of the saved recovery hint
+	// that could be recovered, without contacting their hosts.
+	RecoverableContracts() ([]RecoverableContract, error)
+
+	// RecoverContracts restores the recovery hint of the wallet seed, adds
+	// the recoverable contracts to the contract set and returns the
+	// contracts that were recovered.
+	RecoverContracts() ([]RecoverableContract, error)
+
+	// FileManifest exports a signed manifest of the pieces of a file.
+	FileManifest(siaPath string) (RenterFileManifest, error)
+
+	// VerifyManifest checks the signature of a manifest and downloads the
+	// given number of randomly sampled pieces to verify that the hosts still
+	// store the file as listed in the manifest.
+	VerifyManifest(manifest RenterFileManifest, samples uint64) (RenterManifestVerification, error)
+
+	// RepairBudgets returns the monthly repair budgets of files and
+	// directories.
+	RepairBudgets() []RepairBudget
+
+	// SetRepairBudget sets the monthly budget for uploading and repairing the
+	// file or directory at siaPath. A zero budget removes the budget.
+	SetRepairBudget(siaPath string, monthly types.Currency) error
+
+	// SetFileRedundancy changes the number of parity pieces of an existing
+	// file. The repair loop uploads or drops pieces to match.
+	SetFileRedundancy(siaPath string, dataPieces, parityPieces int) error
+
+	// SetFileSpareHosts sets the number of hosts beyond the number of pieces
+	// that store copies of the pieces of every chunk of a file.
+	SetFileSpareHosts(siaPath string, spareHosts uint64) error
+
+	// SetFileRedundancyGroup adds a file to a redundancy group. The pieces
+	// of files in different groups are kept on different hosts. An empty
+	// group removes the file from its group.
+	SetFileRedundancyGroup(siaPath, group string) error
+
+	// RedundancyGroups returns the files and hosts of every redundancy
+	// group.
+	RedundancyGroups() []RedundancyGroup
+
 	// SetFileTrackingPath sets the on-disk location of an uploaded file to a
 	// new value. Useful if files need to be moved on disk.
 	SetFileTrackingPath(siaPath, newPath string) error
@@ -423,6 +1701,15 @@ type Renter interface {
 	// ShareFilesAscii creates an ASCII-encoded '.sia' file.
 	ShareFilesASCII(paths []string) (asciiSia string, err error)
 
+	// SpeedTest downloads up to the given number of random sectors from every
+	// host the renter has an active contract with and reports the
+	// throughput, latency and cost.
+	SpeedTest(sectorsPerHost uint64) (RenterSpeedTest, error)
+
+	// ThroughputHistory returns the recent throughput samples of the renter,
+	// oldest first.
+	ThroughputHistory() []RenterThroughputSample
+
 	// Streamer creates a io.ReadSeeker that can be used to stream downloads
 	// from the Sia network and also returns the fileName of the streamed
 	// resource.
@@ -431,8 +1718,56 @@ type Renter interface {
 	// Upload uploads a file using the input parameters.
 	Upload(FileUploadParams) error
 
+	// UploadBatch uploads a batch of small files by packing them into shared
+	// chunks. The upload happens in the background, the returned ID
+	// identifies the batch in the list of UploadBatches.
+	UploadBatch(files []RenterBatchFile) (string, error)
+
+	// UploadBatches returns the progress of the batch uploads since the
+	// renter was started.
+	UploadBatches() []RenterUploadBatch
+
+	// UploadStream appends data to the file that is streamed to up.SiaPath.
+	// offset must equal the number of bytes received so far. Once complete
+	// is true, the received data is uploaded with the parameters of up. The
+	// number of bytes received is returned, also if the data was only
+	// received partially.
+	UploadStream(up FileUploadParams, offset uint64, data io.Reader, complete bool) (uint64, error)
+
+	// UploadStreamOffset returns the number of bytes that were received for
+	// the incomplete stream to siaPath.
+	UploadStreamOffset(siaPath string) (uint64, error)
+
+	// ScheduleUpload queues an upload that starts at the start time of su.
+	// The scheduled upload is returned with its ID and status set.
+	ScheduleUpload(su ScheduledUpload) (ScheduledUpload, error)
+
+	// ScheduledUploads returns the uploads that were scheduled, including
+	// the ones that were started already or failed to start.
+	ScheduledUploads() []ScheduledUpload
+
+	// CancelScheduledUpload removes a scheduled upload that hasn't been
+	// started yet.
+	CancelScheduledUpload(id string) error
+
+	// WorkerPoolStatus returns the latency estimates and timeouts of the
+	// workers.
+	WorkerPoolStatus() WorkerPoolStatus
+
+	// WorkerPoolDetailedStatus returns the job queues and upload cooldowns
+	// of the workers.
+	WorkerPoolDetailedStatus() WorkerPoolDetailedStatus
+
 	// CreateDir creates a directory for the renter
 	CreateDir(siaPath string) error
+
+	// DirInfo returns the directory located at siaPath together with its
+	// subdirectories and the siapaths of the files it contains.
+	DirInfo(siaPath string) (DirectoryInfo, []DirectoryInfo, []string, error)
+
+	// SetDirRedundancy sets the erasure code of new uploads into the
+	// directory located at siaPath and its subdirectories.
+	SetDirRedundancy(siaPath string, dataPieces, parityPieces int) error
 }
 
 // RenterDownloadParameters defines the parameters passed to the Renter's
@@ -444,4 +1779,9 @@ type RenterDownloadParameters struct {
 	Offset      uint64
 	SiaPath     string
 	Destination string
+
+	// Tail downloads the last Tail bytes of the file instead of the range
+	// given by Offset and Length. The whole file is downloaded if it is
+	// smaller than Tail.
+	Tail uint64
 }
@@ -0,0 +1,80 @@
+package renter
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// alertCauseAllowanceTopUp is the cause of the alert registered while the
+// contractor is unable to top up the allowance.
+const alertCauseAllowanceTopUp = "allowance top-up failed"
+
+// allowanceTopUpAlertID is the id of the alert registered while the
+// contractor is unable to top up the allowance.
+const allowanceTopUpAlertID = "allowancetopup"
+
+// alertCauseUnderfunded is the cause of the alert registered while the
+// contractor funds contracts partially.
+const alertCauseUnderfunded = "wallet balance below allowance"
+
+// underfundedAlertID is the id of the alert registered while the contractor
+// funds contracts partially.
+const underfundedAlertID = "underfunded"
+
+// managedRegisterAlert registers an alert with the provided id. If an alert
+// with the same id is already registered, its message is updated but the time
+// it was first raised is kept.
+func (r *Renter) managedRegisterAlert(id, cause, msg string) {
+	r.alertsMu.Lock()
+	defer r.alertsMu.Unlock()
+	alert, exists := r.alerts[id]
+	if !exists {
+		alert.Time = time.Now()
+		r.log.Println("ALERT:", msg)
+	}
+	alert.Cause = cause
+	alert.Message = msg
+	r.alerts[id] = alert
+}
+
+// managedUnregisterAlert removes the alert with the provided id once its cause
+// has been resolved.
+func (r *Renter) managedUnregisterAlert(id string) {
+	r.alertsMu.Lock()
+	defer r.alertsMu.Unlock()
+	delete(r.alerts, id)
+}
+
+// managedUpdateFundingAlerts registers or unregisters the alerts about the
+// funding of the allowance. It is called by the contractor whenever it checked
+// the funding.
+func (r *Renter) managedUpdateFundingAlerts() {
+	if err := r.hostContractor.AllowanceTopUpError(); err != nil {
+		r.managedRegisterAlert(allowanceTopUpAlertID, alertCauseAllowanceTopUp, "unable to top up the allowance: "+err.Error())
+	} else {
+		r.managedUnregisterAlert(allowanceTopUpAlertID)
+	}
+	if funding := r.hostContractor.ContractorFunding(); funding.Underfunded {
+		msg := fmt.Sprintf("the wallet balance of %v only covers %.2f%% of the %v of unallocated allowance funds, contracts are funded partially", funding.WalletBalance, funding.FundingRatio*100, funding.FundsNeeded)
+		r.managedRegisterAlert(underfundedAlertID, alertCauseUnderfunded, msg)
+	} else {
+		r.managedUnregisterAlert(underfundedAlertID)
+	}
+}
+
+// Alerts returns the currently registered alerts, oldest first.
+func (r *Renter) Alerts() []modules.RenterAlert {
+	r.alertsMu.Lock()
+	defer r.alertsMu.Unlock()
+	alerts := make([]modules.RenterAlert, 0, len(r.alerts))
+	for _, alert := range r.alerts {
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Time.Before(alerts[j].Time)
+	})
+	return alerts
+}
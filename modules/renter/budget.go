@@ -0,0 +1,251 @@
+package renter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+// alertCauseRepairBudget is the cause of the alerts registered for files
+// that used up their repair budget.
+const alertCauseRepairBudget = "repair budget exhausted"
+
+var (
+	// errRepairBudgetUnknownPath is returned if a budget is set for a path
+	// that is neither a file nor a directory of the renter.
+	errRepairBudgetUnknownPath = errors.New("no file or directory exists at the provided path")
+)
+
+// budgetApplies returns true if the budget set for budgetPath covers the file
+// at siaPath, which is the case if budgetPath is the file itself or one of its
+// parent directories.
+func budgetApplies(budgetPath, siaPath string) bool {
+	return budgetPath == siaPath || strings.HasPrefix(siaPath, budgetPath+"/")
+}
+
+// resetExpiredBudget starts a new period for the budget if the current one
+// ended, clearing the amount spent.
+func resetExpiredBudget(b modules.RepairBudget, now time.Time) modules.RepairBudget {
+	if now.Sub(b.PeriodStart) < repairBudgetPeriod {
+		return b
+	}
+	b.PeriodStart = now
+	b.Spent = types.ZeroCurrency
+	return b
+}
+
+// remainingBudget returns the amount of the budget that can still be spent
+// during the current period.
+func remainingBudget(b modules.RepairBudget) types.Currency {
+	if b.Spent.Cmp(b.Monthly) >= 0 {
+		return types.ZeroCurrency
+	}
+	return b.Monthly.Sub(b.Spent)
+}
+
+// repairBudgetAlertID returns the id of the alert that is registered when the
+// budget for siaPath is used up.
+func repairBudgetAlertID(siaPath string) string {
+	return "repairbudget:" + siaPath
+}
+
+// tightestBudget returns the budget covering the file at siaPath that has the
+// least remaining funds. The caller must hold the renter's lock.
+func (r *Renter) tightestBudget(siaPath string, now time.Time) (modules.RepairBudget, bool) {
+	var tightest modules.RepairBudget
+	var found bool
+	for path, b := range r.persist.RepairBudgets {
+		if !budgetApplies(path, siaPath) {
+			continue
+		}
+		b = resetExpiredBudget(b, now)
+		if !found || remainingBudget(b).Cmp(remainingBudget(tightest)) < 0 {
+			tightest = b
+			found = true
+		}
+	}
+	return tightest, found
+}
+
+// managedRepairBudget returns the budget that limits the repairs of the file at
+// siaPath and its remaining funds. Both are zero if no budget applies.
+func (r *Renter) managedRepairBudget(siaPath string) (monthly, remaining types.Currency) {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	b, ok := r.tightestBudget(siaPath, time.Now())
+	if !ok {
+		return types.ZeroCurrency, types.ZeroCurrency
+	}
+	return b.Monthly, remainingBudget(b)
+}
+
+// managedRepairBudgetExhausted returns true if a budget covering the file at
+// siaPath has been used up during the current period. An alert is kept
+// registered for as long as that is the case.
+func (r *Renter) managedRepairBudgetExhausted(siaPath string) bool {
+	id := r.mu.RLock()
+	b, ok := r.tightestBudget(siaPath, time.Now())
+	r.mu.RUnlock(id)
+	if !ok || !remainingBudget(b).IsZero() {
+		r.managedUnregisterAlert(repairBudgetAlertID(siaPath))
+		return false
+	}
+	msg := fmt.Sprintf("%v isn't repaired anymore because the repair budget of %v was used up, it will be repaired again after %v",
+		siaPath, b.SiaPath, b.PeriodStart.Add(repairBudgetPeriod).Format(time.RFC1123))
+	r.managedRegisterAlert(repairBudgetAlertID(siaPath), alertCauseRepairBudget, msg)
+	return true
+}
+
+// managedChargeRepairBudget attributes the cost of uploading or repairing a
+// part of the file at siaPath to every budget covering the file. The budgets
+// are only changed in memory, threadedSaveRepairBudgets saves them.
+func (r *Renter) managedChargeRepairBudget(siaPath string, cost types.Currency) {
+	if cost.IsZero() {
+		return
+	}
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	now := time.Now()
+	var charged bool
+	for path, b := range r.persist.RepairBudgets {
+		if !budgetApplies(path, siaPath) {
+			continue
+		}
+		b = resetExpiredBudget(b, now)
+		b.Spent = b.Spent.Add(cost)
+		r.persist.RepairBudgets[path] = b
+		charged = true
+	}
+	if charged {
+		r.repairBudgetsChanged = true
+	}
+}
+
+// managedSaveRepairBudgets saves the renter's persistence if a repair budget
+// was charged since the last save.
+func (r *Renter) managedSaveRepairBudgets() error {
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	if !r.repairBudgetsChanged {
+		return nil
+	}
+	if err := r.saveSync(); err != nil {
+		return errors.AddContext(err, "unable to save repair budgets")
+	}
+	r.repairBudgetsChanged = false
+	return nil
+}
+
+// threadedSaveRepairBudgets periodically saves the charged repair budgets.
+// Saving them for every charge would sync the renter's persistence for every
+// uploaded piece.
+func (r *Renter) threadedSaveRepairBudgets() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	ticker := time.NewTicker(repairBudgetSaveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-ticker.C:
+		}
+		if err := r.managedSaveRepairBudgets(); err != nil {
+			r.log.Println("ERROR:", err)
+		}
+	}
+}
+
+// RepairBudgets returns the repair budgets of all files and directories,
+// sorted by siapath.
+func (r *Renter) RepairBudgets() []modules.RepairBudget {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	now := time.Now()
+	budgets := make([]modules.RepairBudget, 0, len(r.persist.RepairBudgets))
+	for _, b := range r.persist.RepairBudgets {
+		budgets = append(budgets, resetExpiredBudget(b, now))
+	}
+	sort.Slice(budgets, func(i, j int) bool {
+		return budgets[i].SiaPath < budgets[j].SiaPath
+	})
+	return budgets
+}
+
+// SetRepairBudget sets the monthly budget for uploading and repairing the file
+// or the files in the directory at siaPath. The amount already spent during
+// the current period is kept, so raising the budget allows repairs to resume
+// right away. A zero budget removes the budget.
+func (r *Renter) SetRepairBudget(siaPath string, monthly types.Currency) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := validateSiapath(siaPath); err != nil {
+		return err
+	}
+
+	id := r.mu.Lock()
+	if monthly.IsZero() {
+		delete(r.persist.RepairBudgets, siaPath)
+	} else {
+		if !r.siaPathExists(siaPath) {
+			r.mu.Unlock(id)
+			return errRepairBudgetUnknownPath
+		}
+		if r.persist.RepairBudgets == nil {
+			r.persist.RepairBudgets = make(map[string]modules.RepairBudget)
+		}
+		b, exists := r.persist.RepairBudgets[siaPath]
+		if !exists {
+			b = modules.RepairBudget{
+				SiaPath:     siaPath,
+				PeriodStart: time.Now(),
+			}
+		}
+		b.Monthly = monthly
+		r.persist.RepairBudgets[siaPath] = b
+	}
+	err := r.saveSync()
+	if err == nil {
+		r.repairBudgetsChanged = false
+	}
+	r.mu.Unlock(id)
+	if err != nil {
+		return errors.AddContext(err, "unable to save repair budget")
+	}
+
+	// The alerts of files that can be repaired again are removed by the next
+	// run of the repair loop, trigger it right away.
+	select {
+	case r.uploadHeap.newUploads <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// siaPathExists returns true if siaPath is a file or a directory of the
+// renter. The caller must hold the renter's lock.
+func (r *Renter) siaPathExists(siaPath string) bool {
+	if _, exists := r.files[siaPath]; exists {
+		return true
+	}
+	for path := range r.files {
+		if budgetApplies(siaPath, path) {
+			return true
+		}
+	}
+	info, err := os.Stat(filepath.Join(r.persistDir, siaPath))
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,133 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestBudgetApplies checks that budgets apply to the file they are set for
+// and to all the files in the directory they are set for.
+func TestBudgetApplies(t *testing.T) {
+	tests := []struct {
+		budgetPath string
+		siaPath    string
+		applies    bool
+	}{
+		{"foo", "foo", true},
+		{"foo", "foo/bar", true},
+		{"foo", "foo/bar/baz", true},
+		{"foo/bar", "foo/bar/baz", true},
+		{"foo", "foobar", false},
+		{"foo/bar", "foo", false},
+		{"foo", "bar/foo", false},
+	}
+	for _, test := range tests {
+		if budgetApplies(test.budgetPath, test.siaPath) != test.applies {
+			t.Errorf("budget for %v applying to %v should be %v", test.budgetPath, test.siaPath, test.applies)
+		}
+	}
+}
+
+// TestResetExpiredBudget checks that the amount spent is only reset once the
+// period ended.
+func TestResetExpiredBudget(t *testing.T) {
+	now := time.Now()
+	b := modules.RepairBudget{
+		Monthly:     types.NewCurrency64(100),
+		Spent:       types.NewCurrency64(60),
+		PeriodStart: now.Add(-repairBudgetPeriod / 2),
+	}
+	if reset := resetExpiredBudget(b, now); !reset.Spent.Equals(b.Spent) || !reset.PeriodStart.Equal(b.PeriodStart) {
+		t.Fatal("budget shouldn't be reset during the period")
+	}
+	b.PeriodStart = now.Add(-repairBudgetPeriod)
+	if reset := resetExpiredBudget(b, now); !reset.Spent.IsZero() || !reset.PeriodStart.Equal(now) {
+		t.Fatal("budget should be reset after the period")
+	}
+}
+
+// TestRepairBudgetExhausted checks that files stop being repaired once their
+// budget is used up and that raising the budget clears the alert.
+func TestRepairBudgetExhausted(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	if err := rt.renter.SetRepairBudget("photos", types.NewCurrency64(100)); err != errRepairBudgetUnknownPath {
+		t.Fatal("expected errRepairBudgetUnknownPath but got", err)
+	}
+	if err := rt.renter.CreateDir("photos"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.renter.SetRepairBudget("photos", types.NewCurrency64(100)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Spend the budget.
+	rt.renter.managedChargeRepairBudget("photos/cat.jpg", types.NewCurrency64(60))
+	if rt.renter.managedRepairBudgetExhausted("photos/cat.jpg") {
+		t.Fatal("budget shouldn't be exhausted yet")
+	}
+	if _, remaining := rt.renter.managedRepairBudget("photos/cat.jpg"); !remaining.Equals64(40) {
+		t.Fatal("expected 40 remaining but got", remaining)
+	}
+
+	// Charges are saved by the save loop, not right away.
+	id := rt.renter.mu.RLock()
+	changed := rt.renter.repairBudgetsChanged
+	rt.renter.mu.RUnlock(id)
+	if !changed {
+		t.Fatal("charged budget should be marked as changed")
+	}
+	if err := rt.renter.managedSaveRepairBudgets(); err != nil {
+		t.Fatal(err)
+	}
+	id = rt.renter.mu.RLock()
+	changed = rt.renter.repairBudgetsChanged
+	rt.renter.mu.RUnlock(id)
+	if changed {
+		t.Fatal("saved budget shouldn't be marked as changed")
+	}
+	rt.renter.managedChargeRepairBudget("photos/cat.jpg", types.NewCurrency64(60))
+	rt.renter.managedChargeRepairBudget("videos/dog.mp4", types.NewCurrency64(60))
+	if !rt.renter.managedRepairBudgetExhausted("photos/cat.jpg") {
+		t.Fatal("budget should be exhausted")
+	}
+	if rt.renter.managedRepairBudgetExhausted("videos/dog.mp4") {
+		t.Fatal("file without a budget shouldn't be limited")
+	}
+	if alerts := rt.renter.Alerts(); len(alerts) != 1 || alerts[0].Cause != alertCauseRepairBudget {
+		t.Fatal("expected a single repair budget alert", alerts)
+	}
+
+	// Raising the budget keeps the amount spent and resumes repairs.
+	if err := rt.renter.SetRepairBudget("photos", types.NewCurrency64(200)); err != nil {
+		t.Fatal(err)
+	}
+	if rt.renter.managedRepairBudgetExhausted("photos/cat.jpg") {
+		t.Fatal("budget shouldn't be exhausted after being raised")
+	}
+	if len(rt.renter.Alerts()) != 0 {
+		t.Fatal("alert should be removed once the file is repaired again")
+	}
+	budgets := rt.renter.RepairBudgets()
+	if len(budgets) != 1 || !budgets[0].Spent.Equals64(120) {
+		t.Fatal("unexpected budgets", budgets)
+	}
+
+	// Removing the budget.
+	if err := rt.renter.SetRepairBudget("photos", types.ZeroCurrency); err != nil {
+		t.Fatal(err)
+	}
+	if len(rt.renter.RepairBudgets()) != 0 {
+		t.Fatal("budget should be removed")
+	}
+}
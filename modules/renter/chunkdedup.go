@@ -0,0 +1,158 @@
+package renter
+
+// chunkdedup.go lets files that are uploaded with deduplication reference the
+// pieces of identical chunks that are already stored instead of uploading them
+// again.
+//
+// Every chunk of such a file is hashed when its data is read for the upload.
+// The chunk index maps the hashes to the chunks with that plaintext. Each of
+// those chunks is a reference to the pieces, so a chunk stays in the index as
+// long as one file referencing it is left. Deleting the file whose chunk was
+// shared first doesn't affect the other files, because a shared chunk keeps a
+// copy of the pieces and the key they are encrypted with in its own siafile.
+//
+// Two chunks can only share their pieces if they are erasure coded and
+// encrypted the same way, which is why the chunk hash is combined with the
+// number of data pieces and the cipher of the file. The piece keys of a chunk
+// are derived from the chunk index, so a shared chunk remembers the index its
+// pieces were uploaded for. Repairs of a shared chunk upload new pieces with
+// the same key.
+//
+// The chunk index isn't persisted. It is rebuilt from the chunk hashes in the
+// metadata of the files when the renter starts.
+
+import (
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+)
+
+// chunkRef refers to a chunk of a file in the chunk index.
+type chunkRef struct {
+	file  *siafile.SiaFile
+	index uint64
+}
+
+// dedupKey returns the key of a chunk in the chunk index.
+func dedupKey(file *siafile.SiaFile, chunkHash crypto.Hash) crypto.Hash {
+	return crypto.HashAll(chunkHash, file.ErasureCode().MinPieces(), file.MasterKey().Type())
+}
+
+// plaintextLength returns the number of bytes of the chunk's logical data
+// that belong to the file. The last chunk of a file is padded with zeros.
+func (uc *unfinishedUploadChunk) plaintextLength() uint64 {
+	length := uc.length
+	if fileSize := uc.renterFile.Size(); uint64(uc.offset)+length > fileSize {
+		length = fileSize - uint64(uc.offset)
+	}
+	return length
+}
+
+// indexChunkHashes adds the chunks of a file with deduplication to the chunk
+// index.
+func (r *Renter) indexChunkHashes(file *siafile.SiaFile) {
+	if !file.Dedup() {
+		return
+	}
+	for i, hash := range file.ChunkHashes() {
+		if hash != (crypto.Hash{}) {
+			r.addChunkRef(dedupKey(file, hash), chunkRef{file: file, index: uint64(i)})
+		}
+	}
+}
+
+// addChunkRef adds a reference to a chunk to the chunk index unless it is
+// already known.
+func (r *Renter) addChunkRef(key crypto.Hash, ref chunkRef) {
+	for _, existing := range r.chunkIndex[key] {
+		if existing == ref {
+			return
+		}
+	}
+	r.chunkIndex[key] = append(r.chunkIndex[key], ref)
+}
+
+// removeChunkRefs removes the references to the chunks of a file from the
+// chunk index. Chunks without references are dropped from the index.
+func (r *Renter) removeChunkRefs(file *siafile.SiaFile) {
+	if !file.Dedup() {
+		return
+	}
+	for _, hash := range file.ChunkHashes() {
+		if hash == (crypto.Hash{}) {
+			continue
+		}
+		key := dedupKey(file, hash)
+		refs := r.chunkIndex[key][:0]
+		for _, ref := range r.chunkIndex[key] {
+			if ref.file != file {
+				refs = append(refs, ref)
+			}
+		}
+		if len(refs) == 0 {
+			delete(r.chunkIndex, key)
+		} else {
+			r.chunkIndex[key] = refs
+		}
+	}
+}
+
+// managedShareChunk hashes the logical data of a chunk of a file with
+// deduplication and looks for an identical chunk whose pieces are all stored.
+// If there is one, its pieces are shared with the chunk and true is returned.
+// The chunk can only be shared if none of its pieces were uploaded yet.
+func (r *Renter) managedShareChunk(chunk *unfinishedUploadChunk) bool {
+	file := chunk.renterFile
+	if !file.Dedup() {
+		return false
+	}
+	hash := hashChunkData(chunk.logicalChunkData, chunk.plaintextLength())
+	if err := file.SetChunkHash(chunk.index, hash); err != nil {
+		r.log.Debugln("Unable to save the hash of a chunk:", err)
+		return false
+	}
+	key := dedupKey(file, hash)
+	ref := chunkRef{file: file, index: chunk.index}
+
+	// The chunk is only added to the index while the file is tracked by the
+	// renter, otherwise its reference would never be removed.
+	id := r.mu.Lock()
+	refs := append([]chunkRef(nil), r.chunkIndex[key]...)
+	if r.files[file.SiaPath()] == file {
+		r.addChunkRef(key, ref)
+	}
+	r.mu.Unlock(id)
+
+	chunk.mu.Lock()
+	empty := chunk.piecesCompleted == 0 && chunk.piecesRegistered == 0
+	chunk.mu.Unlock()
+	if !empty {
+		return false
+	}
+	for _, source := range refs {
+		if source == ref || source.file.Deleted() {
+			continue
+		}
+		pieces, sourceKey, keyIndex, err := source.file.SharedChunkPieces(source.index)
+		if err != nil {
+			continue
+		}
+		stored := 0
+		for _, pieceSet := range pieces {
+			if len(pieceSet) > 0 {
+				stored++
+			}
+		}
+		if stored < chunk.piecesNeeded {
+			continue
+		}
+		if err := file.ShareChunk(chunk.index, pieces, sourceKey, keyIndex); err != nil {
+			r.log.Debugln("Unable to share a chunk:", err)
+			return false
+		}
+		chunk.mu.Lock()
+		chunk.piecesCompleted = chunk.piecesNeeded
+		chunk.mu.Unlock()
+		return true
+	}
+	return false
+}
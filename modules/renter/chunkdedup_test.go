@@ -0,0 +1,104 @@
+package renter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/Hyperspace/types"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+// TestShareChunk checks that identical chunks of files with deduplication
+// share their pieces and that the shared pieces remain referenced after the
+// file they were uploaded for is deleted.
+func TestShareChunk(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	rsc, _ := siafile.NewRSCode(1, 1)
+	data := fastrand.Bytes(1000)
+	newFile := func(name string) (*siafile.SiaFile, *unfinishedUploadChunk) {
+		// The files need to use the same cipher to share chunks.
+		siaFilePath := filepath.Join(rt.dir, name+ShareExtension)
+		f, err := siafile.New(siaFilePath, name, "", newTestingWal(), rsc, crypto.GenerateSiaKey(crypto.TypeTwofish), uint64(len(data)), 0777)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.SetDedup(true); err != nil {
+			t.Fatal(err)
+		}
+		id := rt.renter.mu.Lock()
+		rt.renter.files[f.SiaPath()] = f
+		rt.renter.mu.Unlock(id)
+		piece := make([]byte, f.PieceSize())
+		copy(piece, data)
+		return f, &unfinishedUploadChunk{
+			renterFile:       f,
+			length:           f.ChunkSize(),
+			piecesNeeded:     rsc.NumPieces(),
+			logicalChunkData: [][]byte{piece},
+		}
+	}
+
+	// The chunk of the first file isn't stored yet.
+	f1, uc1 := newFile(t.Name() + "1")
+	if rt.renter.managedShareChunk(uc1) {
+		t.Fatal("chunk shouldn't be shared without an identical stored chunk")
+	}
+	for i := uint64(0); i < uint64(rsc.NumPieces()); i++ {
+		hpk := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+		if err := f1.AddPiece(hpk, 0, i, crypto.Hash{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The chunk of the second file is identical.
+	f2, uc2 := newFile(t.Name() + "2")
+	if !rt.renter.managedShareChunk(uc2) {
+		t.Fatal("identical chunk should be shared")
+	}
+	if f2.SharedChunks() != 1 || uc2.piecesCompleted != uc2.piecesNeeded {
+		t.Fatal("chunk wasn't shared", f2.SharedChunks(), uc2.piecesCompleted)
+	}
+	key := dedupKey(f1, f1.ChunkHashes()[0])
+	if len(rt.renter.chunkIndex[key]) != 2 {
+		t.Fatal("expected 2 references but got", len(rt.renter.chunkIndex[key]))
+	}
+
+	// Deleting the first file drops its reference. The second file still
+	// references the pieces, so a third file can share them.
+	if err := rt.renter.DeleteFile(f1.SiaPath()); err != nil {
+		t.Fatal(err)
+	}
+	if len(rt.renter.chunkIndex[key]) != 1 {
+		t.Fatal("expected 1 reference but got", len(rt.renter.chunkIndex[key]))
+	}
+	f3, uc3 := newFile(t.Name() + "3")
+	if !rt.renter.managedShareChunk(uc3) {
+		t.Fatal("chunk should be shared with the remaining reference")
+	}
+	pieces, err := f3.Pieces(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pieces[1]) != 1 || pieces[1][0].MerkleRoot != (crypto.Hash{1}) {
+		t.Fatal("wrong pieces shared", pieces)
+	}
+	if err := rt.renter.DeleteFile(f2.SiaPath()); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.renter.DeleteFile(f3.SiaPath()); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := rt.renter.chunkIndex[key]; exists {
+		t.Fatal("chunk without references should be dropped from the index")
+	}
+}
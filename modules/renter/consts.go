@@ -16,6 +16,25 @@ var (
 		Standard: uint64(3 * 1 << 28), // 768 MiB
 		Testing:  uint64(1 << 17),     // 128 KiB - 4 KiB sector size, need to test memory exhaustion
 	}).(uint64)
+
+	// maxContentHashPending is the maximum amount of plaintext that is held
+	// back for the content hash of a file while the chunks before it haven't
+	// been read yet.
+	maxContentHashPending = build.Select(build.Var{
+		Dev:      uint64(1 << 26), // 64 MiB
+		Standard: uint64(1 << 28), // 256 MiB
+		Testing:  uint64(1 << 17), // 128 KiB
+	}).(uint64)
+
+	// minConcurrentRepairs is the lowest limit of concurrent chunk repairs
+	// that can be set. With fewer concurrent repairs, a single slow host can
+	// stall the repair loop for long enough that it no longer keeps up with
+	// the hosts that go offline.
+	minConcurrentRepairs = build.Select(build.Var{
+		Dev:      uint64(2),
+		Standard: uint64(4),
+		Testing:  uint64(1),
+	}).(uint64)
 )
 
 var (
@@ -60,6 +79,40 @@ const (
 	// worker has experienced a download failure.
 	downloadFailureCooldown = time.Second * 3
 
+	// workerLatencySamples is the number of recent RPCs that a worker's
+	// latency estimate is based on.
+	workerLatencySamples = 20
+
+	// minWorkerLatencySamples is the number of RPCs that need to complete
+	// before a worker adapts its timeout to the host.
+	minWorkerLatencySamples = 3
+
+	// workerTimeoutMultiplier is the multiple of the median RPC duration
+	// after which a worker gives up on an RPC.
+	workerTimeoutMultiplier = 4
+
+	// repairsPerCPU is the number of concurrent chunk repairs per core that
+	// the renter allows by default.
+	repairsPerCPU = 4
+
+	// maxSpeedTestSectors is the maximum number of sectors a speed test
+	// downloads from every host. Every sector is paid for, so a speed test
+	// should stay cheap.
+	maxSpeedTestSectors = 4
+
+	// defaultManifestSamples is the number of pieces a manifest verification
+	// downloads if no number is given. maxManifestSamples limits the number of
+	// pieces, since every downloaded piece is paid for.
+	defaultManifestSamples = 10
+	maxManifestSamples     = 1000
+
+	// maxBatchFiles is the maximum number of files of a single batch upload.
+	maxBatchFiles = 10000
+
+	// throughputHistoryLen is the number of throughput samples the renter
+	// keeps, a day of samples at the standard sampling interval.
+	throughputHistoryLen = 24 * 60
+
 	// memoryPriorityLow is used to request low priority memory
 	memoryPriorityLow = false
 
@@ -101,6 +154,14 @@ var (
 		Testing:  3,
 	}).(int)
 
+	// maxRekeyAttempts is the number of consecutive attempts to rekey a chunk
+	// of a file after which the rekey of the file is cancelled.
+	maxRekeyAttempts = build.Select(build.Var{
+		Dev:      5,
+		Standard: 10,
+		Testing:  3,
+	}).(int)
+
 	// maxScheduledDownloads specifies the number of chunks that can be downloaded
 	// for auto repair at once. If the limit is reached new ones will only be scheduled
 	// once old ones are scheduled for upload
@@ -126,6 +187,41 @@ var (
 		Testing:  3 * time.Second,
 	}).(time.Duration)
 
+	// rebuildActiveChunkInterval defines how long a file rebuild waits before
+	// checking again whether a chunk that is being repaired by the repair loop
+	// has been released.
+	rebuildActiveChunkInterval = build.Select(build.Var{
+		Dev:      5 * time.Second,
+		Standard: 30 * time.Second,
+		Testing:  250 * time.Millisecond,
+	}).(time.Duration)
+
+	// recoveryHintRedundancy is the number of hosts a recovery hint is stored
+	// on. The hint can be restored as long as a single one of them is online,
+	// and every host costs a sector upload per sync.
+	recoveryHintRedundancy = build.Select(build.Var{
+		Dev:      3,
+		Standard: 5,
+		Testing:  2,
+	}).(int)
+
+	// repairBudgetPeriod is the length of the period after which repair
+	// budgets are reset.
+	repairBudgetPeriod = build.Select(build.Var{
+		Dev:      24 * time.Hour,
+		Standard: 30 * 24 * time.Hour,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// repairBudgetSaveInterval is the interval at which charged repair
+	// budgets are saved. Budgets are charged for every uploaded piece, which
+	// is too often to save them right away.
+	repairBudgetSaveInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 5 * time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
 	// RemoteRepairDownloadThreshold defines the threshold in percent under
 	// which the renter starts repairing a file that is not available on disk.
 	RemoteRepairDownloadThreshold = build.Select(build.Var{
@@ -148,4 +244,61 @@ var (
 		Standard: 5 * time.Minute,
 		Testing:  3 * time.Second,
 	}).(time.Duration)
+
+	// minWorkerRPCTimeout is the lowest timeout a worker uses for an upload
+	// or download RPC, no matter how quickly the host usually responds.
+	minWorkerRPCTimeout = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: 30 * time.Second,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+
+	// maxWorkerRPCTimeout is the timeout of a worker's RPCs before enough of
+	// them have completed to estimate the latency of the host. Adaptive
+	// timeouts never exceed it.
+	maxWorkerRPCTimeout = build.Select(build.Var{
+		Dev:      2 * time.Minute,
+		Standard: 10 * time.Minute,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// throughputSampleInterval is the interval at which the aggregate
+	// throughput of the workers is sampled.
+	throughputSampleInterval = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: time.Minute,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
+	// bandwidthBatchSize is the number of piece transfers that a worker
+	// combines into a single throughput measurement of its host.
+	bandwidthBatchSize = build.Select(build.Var{
+		Dev:      4,
+		Standard: 8,
+		Testing:  2,
+	}).(int)
+
+	// repairThroughputWindow is the period over which the throughput samples
+	// of the uploads are averaged to estimate the repair throughput.
+	repairThroughputWindow = build.Select(build.Var{
+		Dev:      5 * time.Minute,
+		Standard: 30 * time.Minute,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+
+	// contractMigrationCheckInterval is the interval at which a migration
+	// re-queues the chunks that still depend on the host of its contract.
+	contractMigrationCheckInterval = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// contractMigrationTimeout is the time a migration has to move the data
+	// of its contract before it fails and the contract is kept.
+	contractMigrationTimeout = build.Select(build.Var{
+		Dev:      30 * time.Minute,
+		Standard: 24 * time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
 )
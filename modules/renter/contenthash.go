@@ -0,0 +1,209 @@
+package renter
+
+// contenthash.go computes the content hashes of files and maintains the index
+// used to find files by their content hash.
+//
+// The content hash of a file is the blake2b hash of its plaintext. It is
+// computed from the logical data of the chunks as they are read for the
+// upload, so the data isn't read a second time. The data needs to be hashed
+// in the order of the file, but chunks are fetched concurrently, so a chunk
+// that is read before its predecessors is held back until they were hashed.
+// Chunks of the same file leave the upload heap in order, which keeps the
+// held back data small; a file whose chunks arrive too far out of order
+// doesn't get a content hash. The hashers aren't persisted, a file whose
+// upload is interrupted by a restart doesn't get a content hash either.
+
+import (
+	"bytes"
+	"hash"
+	"io"
+	"sort"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+var (
+	// errUnknownContentHash is returned if no file with the requested content
+	// hash is known to the renter.
+	errUnknownContentHash = errors.New("no file with that content hash")
+)
+
+// contentHasher computes the content hash of a file from the logical data of
+// its chunks. pending holds the plaintext of the chunks that arrived before
+// the chunk at index next.
+type contentHasher struct {
+	file         *siafile.SiaFile
+	h            hash.Hash
+	next         uint64
+	pending      map[uint64][]byte
+	pendingBytes uint64
+}
+
+// writeChunkData writes the first 'length' bytes of the logical data of a
+// chunk to w. The data is split into pieces, the last chunk of a file is
+// padded with zeros which are not part of the plaintext.
+func writeChunkData(w io.Writer, pieces [][]byte, length uint64) {
+	for _, piece := range pieces {
+		if length == 0 {
+			break
+		}
+		if uint64(len(piece)) > length {
+			piece = piece[:length]
+		}
+		w.Write(piece)
+		length -= uint64(len(piece))
+	}
+}
+
+// hashChunkData hashes the first 'length' bytes of the logical data of a
+// chunk.
+func hashChunkData(pieces [][]byte, length uint64) crypto.Hash {
+	h := crypto.NewHash()
+	writeChunkData(h, pieces, length)
+	var hash crypto.Hash
+	h.Sum(hash[:0])
+	return hash
+}
+
+// managedStartContentHash starts computing the content hash of a new file
+// from the chunks that are read for its upload.
+func (r *Renter) managedStartContentHash(file *siafile.SiaFile) {
+	if file.NumChunks() == 0 || file.Size() == 0 {
+		r.managedSetContentHash(file, crypto.HashBytes(nil))
+		return
+	}
+	r.contentHashersMu.Lock()
+	r.contentHashers[file.UID()] = &contentHasher{
+		file:    file,
+		h:       crypto.NewHash(),
+		pending: make(map[uint64][]byte),
+	}
+	r.contentHashersMu.Unlock()
+}
+
+// managedHashingContent returns true if the content hash of the file is still
+// being computed.
+func (r *Renter) managedHashingContent(file *siafile.SiaFile) bool {
+	r.contentHashersMu.Lock()
+	defer r.contentHashersMu.Unlock()
+	_, hashing := r.contentHashers[file.UID()]
+	return hashing
+}
+
+// managedHashChunk adds the logical data of a chunk that was read for an
+// upload to the content hash of its file. Once every chunk was hashed, the
+// content hash is stored in the metadata of the file.
+func (r *Renter) managedHashChunk(chunk *unfinishedUploadChunk) {
+	r.contentHashersMu.Lock()
+	ch, exists := r.contentHashers[chunk.id.fileUID]
+	if !exists || chunk.index < ch.next {
+		r.contentHashersMu.Unlock()
+		return
+	}
+	if chunk.index > ch.next {
+		if _, held := ch.pending[chunk.index]; !held {
+			length := chunk.plaintextLength()
+			if ch.pendingBytes+length > maxContentHashPending {
+				delete(r.contentHashers, chunk.id.fileUID)
+				r.contentHashersMu.Unlock()
+				r.log.Debugln("Chunks of", chunk.renterFile.SiaPath(), "arrived too far out of order, not computing its content hash")
+				return
+			}
+			var buf bytes.Buffer
+			buf.Grow(int(length))
+			writeChunkData(&buf, chunk.logicalChunkData, length)
+			ch.pending[chunk.index] = buf.Bytes()
+			ch.pendingBytes += length
+		}
+		r.contentHashersMu.Unlock()
+		return
+	}
+	writeChunkData(ch.h, chunk.logicalChunkData, chunk.plaintextLength())
+	ch.next++
+	for data, held := ch.pending[ch.next]; held; data, held = ch.pending[ch.next] {
+		ch.h.Write(data)
+		delete(ch.pending, ch.next)
+		ch.pendingBytes -= uint64(len(data))
+		ch.next++
+	}
+	if ch.next < ch.file.NumChunks() {
+		r.contentHashersMu.Unlock()
+		return
+	}
+	delete(r.contentHashers, chunk.id.fileUID)
+	r.contentHashersMu.Unlock()
+
+	var contentHash crypto.Hash
+	ch.h.Sum(contentHash[:0])
+	r.managedSetContentHash(ch.file, contentHash)
+}
+
+// managedSetContentHash stores the content hash in the metadata of the file
+// and adds the file to the index.
+func (r *Renter) managedSetContentHash(file *siafile.SiaFile, contentHash crypto.Hash) {
+	if err := file.SetContentHash(contentHash); err != nil {
+		r.log.Println("WARN: unable to save the content hash of", file.SiaPath(), err)
+		return
+	}
+	id := r.mu.Lock()
+	r.indexContentHash(file)
+	r.mu.Unlock(id)
+}
+
+// indexContentHash adds a file to the content hash index if its content hash
+// is known.
+func (r *Renter) indexContentHash(file *siafile.SiaFile) {
+	contentHash := file.ContentHash()
+	if contentHash == (crypto.Hash{}) {
+		return
+	}
+	r.contentIndex[contentHash] = append(r.contentIndex[contentHash], file)
+}
+
+// FilesByContentHash returns the paths of all the files with the given content
+// hash, sorted by their path. Files are indexed by the siafile instead of the
+// path, so renaming a file doesn't affect the index. Deleted files are removed
+// from the index when they are encountered.
+func (r *Renter) FilesByContentHash(contentHash crypto.Hash) []string {
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	var siaPaths []string
+	files := r.contentIndex[contentHash][:0]
+	for _, file := range r.contentIndex[contentHash] {
+		if file.Deleted() {
+			continue
+		}
+		files = append(files, file)
+		siaPaths = append(siaPaths, file.SiaPath())
+	}
+	if len(files) == 0 {
+		delete(r.contentIndex, contentHash)
+	} else {
+		r.contentIndex[contentHash] = files
+	}
+	sort.Strings(siaPaths)
+	return siaPaths
+}
+
+// DownloadByContentHash downloads the file with the given content hash. If
+// several files share the content hash, the first one by path is downloaded.
+// The paths of all the matching files are returned, starting with the path of
+// the downloaded file. The SiaPath of the parameters is ignored.
+func (r *Renter) DownloadByContentHash(contentHash crypto.Hash, p modules.RenterDownloadParameters) ([]string, error) {
+	siaPaths := r.FilesByContentHash(contentHash)
+	if len(siaPaths) == 0 {
+		return nil, errUnknownContentHash
+	}
+	p.SiaPath = siaPaths[0]
+	var err error
+	if p.Async {
+		err = r.DownloadAsync(p)
+	} else {
+		err = r.Download(p)
+	}
+	return siaPaths, err
+}
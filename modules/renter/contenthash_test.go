@@ -0,0 +1,138 @@
+package renter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+// TestHashChunkData checks that the padding of a chunk isn't hashed.
+func TestHashChunkData(t *testing.T) {
+	data := fastrand.Bytes(100)
+	pieces := [][]byte{make([]byte, 40), make([]byte, 40), make([]byte, 40)}
+	copy(pieces[0], data[:40])
+	copy(pieces[1], data[40:80])
+	copy(pieces[2], data[80:])
+	if hashChunkData(pieces, 100) != crypto.HashBytes(data) {
+		t.Fatal("chunk hash doesn't match the hash of the plaintext")
+	}
+}
+
+// testContentChunks returns the chunks of a file with the provided data as
+// they are read for an upload.
+func testContentChunks(f *siafile.SiaFile, data []byte) []*unfinishedUploadChunk {
+	var chunks []*unfinishedUploadChunk
+	for i := uint64(0); i < f.NumChunks(); i++ {
+		offset := i * f.ChunkSize()
+		buf := NewDownloadDestinationBuffer(f.ChunkSize(), f.PieceSize())
+		end := offset + f.ChunkSize()
+		if end > uint64(len(data)) {
+			end = uint64(len(data))
+		}
+		buf.WriteAt(data[offset:end], 0)
+		chunks = append(chunks, &unfinishedUploadChunk{
+			id:               uploadChunkID{fileUID: f.UID(), index: i},
+			renterFile:       f,
+			index:            i,
+			length:           f.ChunkSize(),
+			offset:           int64(offset),
+			logicalChunkData: buf.buf,
+		})
+	}
+	return chunks
+}
+
+// TestHashContent checks that the content hash of a file is the hash of its
+// plaintext, also if its chunks are read out of order, and that files whose
+// chunks arrive too far out of order aren't hashed.
+func TestHashContent(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	rsc, _ := siafile.NewRSCode(1, 1)
+	f := newFileTesting(t.Name(), newTestingWal(), rsc, 1, 0777, "")
+	data := fastrand.Bytes(int(3*f.ChunkSize() + f.ChunkSize()/2))
+	f = newFileTesting(t.Name(), newTestingWal(), rsc, uint64(len(data)), 0777, "")
+	rt.renter.managedStartContentHash(f)
+	chunks := testContentChunks(f, data)
+	for _, i := range []int{1, 0, 3, 0, 2} {
+		if f.ContentHash() != (crypto.Hash{}) {
+			t.Fatal("content hash was set before every chunk was hashed")
+		}
+		rt.renter.managedHashChunk(chunks[i])
+	}
+	if f.ContentHash() != crypto.HashBytes(data) {
+		t.Fatal("content hash doesn't match the hash of the plaintext")
+	}
+	if rt.renter.managedHashingContent(f) {
+		t.Fatal("hasher wasn't removed")
+	}
+	if paths := rt.renter.FilesByContentHash(crypto.HashBytes(data)); !reflect.DeepEqual(paths, []string{f.SiaPath()}) {
+		t.Fatal("file wasn't indexed", paths)
+	}
+
+	// The chunks of the second file arrive in reverse order, which holds
+	// back more data than allowed.
+	data = fastrand.Bytes(int(maxContentHashPending + 2*f.ChunkSize()))
+	f2 := newFileTesting(t.Name()+"2", newTestingWal(), rsc, uint64(len(data)), 0777, "")
+	rt.renter.managedStartContentHash(f2)
+	chunks = testContentChunks(f2, data)
+	for i := len(chunks) - 1; i >= 0; i-- {
+		rt.renter.managedHashChunk(chunks[i])
+	}
+	if f2.ContentHash() != (crypto.Hash{}) || rt.renter.managedHashingContent(f2) {
+		t.Fatal("file whose chunks arrived out of order shouldn't have a content hash")
+	}
+}
+
+// TestFilesByContentHash checks that files are found by their content hash
+// and that deleted files are removed from the index.
+func TestFilesByContentHash(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	contentHash := crypto.HashBytes(fastrand.Bytes(16))
+	rsc, _ := siafile.NewRSCode(1, 1)
+	f1 := newFileTesting(t.Name()+"b", newTestingWal(), rsc, 1000, 0777, "")
+	f2 := newFileTesting(t.Name()+"a", newTestingWal(), rsc, 1000, 0777, "")
+	for _, f := range []*siafile.SiaFile{f1, f2} {
+		if err := f.SetContentHash(contentHash); err != nil {
+			t.Fatal(err)
+		}
+		id := rt.renter.mu.Lock()
+		rt.renter.files[f.SiaPath()] = f
+		rt.renter.indexContentHash(f)
+		rt.renter.mu.Unlock(id)
+	}
+
+	siaPaths := rt.renter.FilesByContentHash(contentHash)
+	if !reflect.DeepEqual(siaPaths, []string{f2.SiaPath(), f1.SiaPath()}) {
+		t.Fatal("unexpected files", siaPaths)
+	}
+	if len(rt.renter.FilesByContentHash(crypto.Hash{1})) != 0 {
+		t.Fatal("no files should have that content hash")
+	}
+
+	if err := rt.renter.DeleteFile(f2.SiaPath()); err != nil {
+		t.Fatal(err)
+	}
+	siaPaths = rt.renter.FilesByContentHash(contentHash)
+	if !reflect.DeepEqual(siaPaths, []string{f1.SiaPath()}) {
+		t.Fatal("deleted file should have been removed from the index", siaPaths)
+	}
+}
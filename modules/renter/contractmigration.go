@@ -0,0 +1,230 @@
+package renter
+
+// A contract can be cancelled with migration, which moves the data stored with
+// its host to other hosts before the contract is cancelled. While the
+// migration is running the host is left out of the set of hosts used for
+// uploads and repairs, so its pieces don't count towards the redundancy of
+// their chunks and the repair uploads them to other hosts. The worker of the
+// host is kept, so the host still serves the downloads that fetch the data for
+// the repair. The contract is only cancelled once no chunk depends on the host
+// to reach full redundancy. If that doesn't happen before the migration times
+// out, the host is used again and the contract stays active. Migrations aren't
+// persisted, a migration that is interrupted by a shutdown leaves the contract
+// active as well.
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+var (
+	// errContractMigrating is returned when migrating a contract whose data
+	// is being migrated already.
+	errContractMigrating = errors.New("the data of the contract is being migrated already")
+
+	// errUnknownContract is returned if the renter has no active contract
+	// with the provided id.
+	errUnknownContract = errors.New("no active contract with that id")
+)
+
+// managedMigratingHosts returns the hosts whose data is being migrated, keyed
+// by the String() representation of their public key.
+func (r *Renter) managedMigratingHosts() map[string]struct{} {
+	r.contractMigrationsMu.Lock()
+	defer r.contractMigrationsMu.Unlock()
+	hosts := make(map[string]struct{})
+	for _, m := range r.contractMigrations {
+		if m.Status == modules.ContractMigrationInProgress {
+			hosts[m.HostPublicKey.String()] = struct{}{}
+		}
+	}
+	return hosts
+}
+
+// chunkStoresPiece returns true if the host stores a piece of the chunk.
+func chunkStoresPiece(f *siafile.SiaFile, chunkIndex uint64, pk types.SiaPublicKey) bool {
+	pieces, err := f.Pieces(chunkIndex)
+	if err != nil {
+		return false
+	}
+	for _, pieceSet := range pieces {
+		for _, piece := range pieceSet {
+			if piece.HostPubKey.String() == pk.String() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// managedQueueMigrationChunks queues the chunks that depend on the host for
+// repair and returns their number. The host has to be excluded from the hosts
+// used for uploads already, otherwise its pieces count towards the redundancy
+// of the chunks.
+func (r *Renter) managedQueueMigrationChunks(pk types.SiaPublicKey) uint64 {
+	lockID := r.mu.RLock()
+	var files []*siafile.SiaFile
+	for _, f := range r.files {
+		for _, hostKey := range f.HostPublicKeys() {
+			if hostKey.String() == pk.String() {
+				files = append(files, f)
+				break
+			}
+		}
+	}
+	r.mu.RUnlock(lockID)
+
+	var remaining uint64
+	hosts := r.managedRefreshHostsAndWorkers()
+	lockID = r.mu.RLock()
+	groups := r.redundancyGroups()
+	r.mu.RUnlock(lockID)
+	for _, f := range files {
+		id := r.mu.Lock()
+		minWorkers, _ := r.minUploadWorkers(f)
+		enoughWorkers := len(r.workerPool) >= minWorkers
+		unfinishedChunks := r.buildUnfinishedChunks(f, hosts, groups)
+		r.mu.Unlock(id)
+
+		// Without enough workers no chunks are built, which doesn't mean that
+		// the file doesn't depend on the host.
+		if !enoughWorkers {
+			for i := uint64(0); i < f.NumChunks(); i++ {
+				if chunkStoresPiece(f, i, pk) {
+					remaining++
+				}
+			}
+			continue
+		}
+		for _, uc := range unfinishedChunks {
+			if chunkStoresPiece(f, uc.index, pk) {
+				remaining++
+			}
+			r.uploadHeap.managedPush(uc)
+		}
+	}
+	select {
+	case r.uploadHeap.newUploads <- struct{}{}:
+	default:
+	}
+	return remaining
+}
+
+// managedFinishContractMigration sets the outcome of the migration, which
+// returns the host to the set of hosts used for uploads.
+func (r *Renter) managedFinishContractMigration(m *modules.ContractMigration, err error) {
+	r.contractMigrationsMu.Lock()
+	if err != nil {
+		m.Status = modules.ContractMigrationFailed
+		m.Error = err.Error()
+	} else {
+		m.Status = modules.ContractMigrationComplete
+	}
+	r.contractMigrationsMu.Unlock()
+	if err != nil {
+		r.log.Printf("Migration of contract %v failed: %v", m.ContractID, err)
+	} else {
+		r.log.Printf("Migrated the data of contract %v and cancelled it", m.ContractID)
+	}
+}
+
+// managedCancelMigratedContract cancels the contract of a migration. The
+// contract might have been renewed while its data was migrated, in which case
+// the contract that replaced it is cancelled.
+func (r *Renter) managedCancelMigratedContract(m *modules.ContractMigration) error {
+	if _, exists := r.hostContractor.ContractByID(m.ContractID); exists {
+		return r.hostContractor.CancelContract(m.ContractID)
+	}
+	c, exists := r.hostContractor.ContractByPublicKey(m.HostPublicKey)
+	if !exists {
+		return errUnknownContract
+	}
+	return r.hostContractor.CancelContract(c.ID)
+}
+
+// threadedMigrateContract repairs the chunks that depend on the host of the
+// migration until none are left and cancels the contract afterwards.
+func (r *Renter) threadedMigrateContract(m *modules.ContractMigration) {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	deadline := time.Now().Add(contractMigrationTimeout)
+	for {
+		remaining := r.managedQueueMigrationChunks(m.HostPublicKey)
+		r.contractMigrationsMu.Lock()
+		if remaining > m.ChunksTotal {
+			m.ChunksTotal = remaining
+		}
+		m.ChunksRemaining = remaining
+		r.contractMigrationsMu.Unlock()
+
+		if remaining == 0 {
+			r.managedFinishContractMigration(m, r.managedCancelMigratedContract(m))
+			return
+		}
+		if time.Now().After(deadline) {
+			r.managedFinishContractMigration(m, fmt.Errorf("%v chunks still depend on the host after %v", remaining, contractMigrationTimeout))
+			return
+		}
+
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(contractMigrationCheckInterval):
+		}
+	}
+}
+
+// MigrateContract cancels a contract after moving the data stored with its
+// host to other hosts. The migration runs in the background, its progress is
+// reported by ContractMigration.
+func (r *Renter) MigrateContract(id types.FileContractID) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	c, exists := r.hostContractor.ContractByID(id)
+	if !exists {
+		return errUnknownContract
+	}
+
+	r.contractMigrationsMu.Lock()
+	for _, m := range r.contractMigrations {
+		if m.Status == modules.ContractMigrationInProgress && (m.ContractID == id || m.HostPublicKey.String() == c.HostPublicKey.String()) {
+			r.contractMigrationsMu.Unlock()
+			return errContractMigrating
+		}
+	}
+	m := &modules.ContractMigration{
+		ContractID:    id,
+		HostPublicKey: c.HostPublicKey,
+		Status:        modules.ContractMigrationInProgress,
+		Started:       time.Now(),
+	}
+	r.contractMigrations[id] = m
+	r.contractMigrationsMu.Unlock()
+	r.log.Println("Migrating the data of contract", id)
+
+	go r.threadedMigrateContract(m)
+	return nil
+}
+
+// ContractMigration returns the most recent migration of the contract with
+// the provided id.
+func (r *Renter) ContractMigration(id types.FileContractID) (modules.ContractMigration, bool) {
+	r.contractMigrationsMu.Lock()
+	defer r.contractMigrationsMu.Unlock()
+	m, exists := r.contractMigrations[id]
+	if !exists {
+		return modules.ContractMigration{}, false
+	}
+	return *m, true
+}
@@ -0,0 +1,65 @@
+package contractor
+
+import (
+	"encoding/binary"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// alignEndHeight rounds endHeight up to the next multiple of alignment. An
+// alignment of zero leaves endHeight unchanged.
+func alignEndHeight(endHeight, alignment types.BlockHeight) types.BlockHeight {
+	if alignment == 0 || endHeight%alignment == 0 {
+		return endHeight
+	}
+	return endHeight + alignment - endHeight%alignment
+}
+
+// staggerEndHeight delays endHeight by up to stagger blocks. The delay is
+// derived from the public key of the host, so the contracts with a host keep
+// their place in the stagger window across renewals while the contracts with
+// different hosts are spread over the window.
+func staggerEndHeight(endHeight, stagger types.BlockHeight, hpk types.SiaPublicKey) types.BlockHeight {
+	if stagger == 0 {
+		return endHeight
+	}
+	h := crypto.HashObject(hpk)
+	return endHeight + types.BlockHeight(binary.LittleEndian.Uint64(h[:8])%uint64(stagger))
+}
+
+// fundedPeriod returns the number of blocks of storage that the renewal of a
+// contract ending at endHeight is funded for. Without alignment and stagger
+// every contract covers one period. Aligned and staggered contracts last
+// longer or shorter than a regular contract, so the period is scaled by
+// their actual duration.
+func fundedPeriod(a modules.Allowance, blockHeight, endHeight types.BlockHeight) types.BlockHeight {
+	if a.EndHeightAlignment == 0 && a.RenewStagger == 0 {
+		return a.Period
+	}
+	if endHeight <= blockHeight {
+		return a.Period
+	}
+	return a.Period * (endHeight - blockHeight) / (a.Period + a.RenewWindow)
+}
+
+// contractDuration returns the number of blocks from blockHeight until a
+// contract ends at endHeight. Aligned and staggered contracts can last up to
+// Period+RenewWindow+EndHeightAlignment+RenewStagger blocks.
+func contractDuration(blockHeight, endHeight types.BlockHeight) types.BlockHeight {
+	if endHeight <= blockHeight {
+		return 0
+	}
+	return endHeight - blockHeight
+}
+
+// alignedContractFunds scales funds meant for a contract that covers one
+// period to a new contract with the host that ends at the staggered endHeight.
+func alignedContractFunds(funds types.Currency, a modules.Allowance, blockHeight, endHeight types.BlockHeight, hpk types.SiaPublicKey) types.Currency {
+	if a.Period == 0 {
+		return funds
+	}
+	period := fundedPeriod(a, blockHeight, staggerEndHeight(endHeight, a.RenewStagger, hpk))
+	return funds.Mul64(uint64(period)).Div64(uint64(a.Period))
+}
@@ -2,16 +2,28 @@ package contractor
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 
+	"github.com/HyperspaceApp/Hyperspace/crypto"
 	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
 )
 
 var (
+	errAllowanceMinHosts   = errors.New("minimum hosts per chunk can't exceed the number of hosts")
+	errAllowanceDegraded   = errors.New("minimum hosts of degraded uploads can't exceed the number of hosts")
 	errAllowanceNoHosts    = errors.New("hosts must be non-zero")
+	errAllowanceParallel   = fmt.Errorf("contracts per host can't exceed %v", maxContractsPerHost)
+	errAllowancePlacement  = errors.New("unknown piece placement strategy")
 	errAllowanceNotSynced  = errors.New("you must be synced to set an allowance")
+	errAllowanceBias       = errors.New("renewal bias must be between 0 and 10")
+	errAllowanceRefresh    = errors.New("refresh threshold must be at least 0 and less than 1")
 	errAllowanceWindowSize = errors.New("renew window must be less than period")
 	errAllowanceZeroPeriod = errors.New("period must be non-zero")
+	errAllowanceTopUp      = errors.New("automatic top-ups need a non-zero top-up amount and maximum funds")
+	errAllowanceStagger    = errors.New("renew stagger must be less than the end height alignment and the period")
+	errAllowanceArbiter    = errors.New("arbiter must be an ed25519 public key")
 
 	// ErrAllowanceZeroWindow is returned when the caller requests a
 	// zero-length renewal window. This will happen if the caller sets the
@@ -19,6 +31,51 @@ var (
 	ErrAllowanceZeroWindow = errors.New("renew window must be non-zero")
 )
 
+// checkMaxFundsPerContract checks that the allowance can still be spent if no
+// contract is funded with more than its MaxFundsPerContract. A lower cap needs
+// more contracts, and every contract has to be formed with a different host.
+func (c *Contractor) checkMaxFundsPerContract(a modules.Allowance) error {
+	if a.MaxFundsPerContract.IsZero() {
+		return nil
+	}
+	contracts := contractsForAllowance(a)
+	if contracts > maxContractsPerAllowance {
+		return fmt.Errorf("a maximum of %v hastings per contract requires %v contracts, but at most %v contracts can be formed", a.MaxFundsPerContract, contracts, maxContractsPerAllowance)
+	}
+	if hosts := uint64(len(c.hdb.ActiveHosts())); contracts > hosts {
+		return fmt.Errorf("a maximum of %v hastings per contract requires %v contracts, but only %v hosts are available", a.MaxFundsPerContract, contracts, hosts)
+	}
+	return nil
+}
+
+// validPiecePlacement returns true if strategy is empty or one of the known
+// piece placement strategies.
+func validPiecePlacement(strategy string) bool {
+	switch strategy {
+	case "", modules.PiecePlacementCheapest, modules.PiecePlacementFastest, modules.PiecePlacementMostDiverse:
+		return true
+	}
+	return false
+}
+
+// AllowanceTransition returns the progress of the contractor towards the most
+// recently set allowance.
+func (c *Contractor) AllowanceTransition() modules.AllowanceTransition {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	transition := c.allowanceTransition
+	completed := transition.ContractsFormed + transition.ContractsRefreshed
+	total := completed + transition.ContractsNeeded + transition.RefreshesNeeded
+	if !transition.Active {
+		transition.Progress = 1
+	} else if total > 0 {
+		// An active transition that wasn't measured by contract maintenance
+		// yet has made no progress.
+		transition.Progress = float64(completed) / float64(total)
+	}
+	return transition
+}
+
 // SetAllowance sets the amount of money the Contractor is allowed to spend on
 // contracts over a given time period, divided among the number of hosts
 // specified. Note that Contractor can start forming contracts as soon as
@@ -53,18 +110,55 @@ func (c *Contractor) SetAllowance(a modules.Allowance) error {
 		return ErrAllowanceZeroWindow
 	} else if a.RenewWindow >= a.Period {
 		return errAllowanceWindowSize
+	} else if a.MinHostsPerChunk > a.Hosts {
+		return errAllowanceMinHosts
+	} else if a.DegradedUploadMinHosts > a.Hosts {
+		return errAllowanceDegraded
+	} else if !(a.RenewalBias >= 0 && a.RenewalBias <= maxRenewalBias) {
+		return errAllowanceBias
+	} else if !(a.RefreshThreshold >= 0 && a.RefreshThreshold < 1) {
+		return errAllowanceRefresh
+	} else if !validPiecePlacement(a.PiecePlacementStrategy) {
+		return errAllowancePlacement
+	} else if a.ContractsPerHost > maxContractsPerHost {
+		return errAllowanceParallel
+	} else if a.AutoTopUp && (a.TopUpAmount.IsZero() || a.TopUpMaxFunds.IsZero()) {
+		return errAllowanceTopUp
+	} else if a.RenewStagger >= a.Period || (a.EndHeightAlignment != 0 && a.RenewStagger >= a.EndHeightAlignment) {
+		return errAllowanceStagger
+	} else if a.Arbiter != nil && (a.Arbiter.Algorithm != types.SignatureEd25519 || len(a.Arbiter.Key) != crypto.PublicKeySize) {
+		return errAllowanceArbiter
 	} else if !c.cs.Synced() {
 		return errAllowanceNotSynced
 	}
+	if err := c.checkMaxFundsPerContract(a); err != nil {
+		return err
+	}
 
 	c.log.Println("INFO: setting allowance to", a)
 	c.mu.Lock()
+	// Setting any other allowance ends a trial and clears an expired one.
+	if !c.trial.Active || !reflect.DeepEqual(a, c.trial.Allowance) {
+		c.trial = modules.RenterTrial{}
+	}
 	// set the current period to the blockheight if the existing allowance is
 	// empty. the current period is set in the past by the renew window to make sure
 	// the first period aligns with the first period contracts in the same way
 	// that future periods align with contracts
 	if reflect.DeepEqual(c.allowance, modules.Allowance{}) {
 		c.currentPeriod = c.blockHeight - a.RenewWindow
+	} else {
+		// Changing an existing allowance starts a transition. Contract
+		// maintenance will converge towards the new allowance over multiple
+		// rounds instead of forming all missing contracts at once. Existing
+		// contracts are left untouched so in-progress uploads and downloads
+		// can continue.
+		c.allowanceTransition = modules.AllowanceTransition{
+			Active:          true,
+			OldAllowance:    c.allowance,
+			TargetAllowance: a,
+			StartHeight:     c.blockHeight,
+		}
 	}
 	c.allowance = a
 	err := c.saveSync()
@@ -129,7 +223,9 @@ func (c *Contractor) managedCancelAllowance() error {
 	// Clear out the allowance and save.
 	c.mu.Lock()
 	c.allowance = modules.Allowance{}
+	c.allowanceTransition = modules.AllowanceTransition{}
 	c.currentPeriod = 0
+	c.trial = modules.RenterTrial{}
 	err := c.saveSync()
 	c.mu.Unlock()
 	if err != nil {
@@ -158,3 +254,61 @@ func (c *Contractor) managedCancelAllowance() error {
 	}
 	return nil
 }
+
+// finishAllowanceTransition finishes the active allowance transition if no
+// more contracts need to be formed or refreshed. It returns true if the
+// transition was finished by this call.
+func (c *Contractor) finishAllowanceTransition() bool {
+	t := &c.allowanceTransition
+	if !t.Active || t.ContractsNeeded > 0 || t.RefreshesNeeded > 0 {
+		return false
+	}
+	t.Active = false
+	return true
+}
+
+// setTransitionRefreshes updates the active allowance transition with the
+// number of contracts that need to be refreshed in the current round of
+// maintenance.
+func (c *Contractor) setTransitionRefreshes(neededRefreshes int) {
+	if c.allowanceTransition.Active {
+		c.allowanceTransition.RefreshesNeeded = uint64(neededRefreshes)
+	}
+}
+
+// updateAllowanceTransition updates the active allowance transition with the
+// number of contracts that are still missing to reach the target allowance.
+// The transition is finished once no more contracts are needed. It returns
+// true if the transition was finished by this call.
+func (c *Contractor) updateAllowanceTransition(neededContracts int) bool {
+	if !c.allowanceTransition.Active {
+		return false
+	}
+	c.allowanceTransition.ContractsNeeded = 0
+	if neededContracts > 0 {
+		c.allowanceTransition.ContractsNeeded = uint64(neededContracts)
+	}
+	return c.finishAllowanceTransition()
+}
+
+// recordTransitionContract records a contract that was formed or refreshed
+// during the active allowance transition. It returns true if it was the last
+// contract of the transition.
+func (c *Contractor) recordTransitionContract(refresh bool) bool {
+	t := &c.allowanceTransition
+	if !t.Active {
+		return false
+	}
+	if refresh {
+		t.ContractsRefreshed++
+		if t.RefreshesNeeded > 0 {
+			t.RefreshesNeeded--
+		}
+	} else {
+		t.ContractsFormed++
+		if t.ContractsNeeded > 0 {
+			t.ContractsNeeded--
+		}
+	}
+	return c.finishAllowanceTransition()
+}
@@ -0,0 +1,217 @@
+package contractor
+
+// capacityproof.go contains the renter side of capacity challenges. Every host
+// the renter has a contract with is occasionally asked for random segments of
+// random sectors stored under the contract. The host has to send the segments
+// together with their Merkle proofs, which are checked against the roots of the
+// renter's copy of the contract. A host that doesn't keep the data of the
+// renter can't answer. Hosts that advertise unused capacity are also sent a
+// probe sector of random data, which they have to write to their unused
+// storage and prove as it was read back from disk. The probe only shows that
+// a sector of the advertised free space can be written, not that all of it
+// exists. Hosts that fail the challenge are penalized in the host weight.
+// Hosts that can't be reached, don't support the challenge or reject it
+// because of their cooldown are not.
+
+import (
+	"errors"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/proto"
+	"github.com/HyperspaceApp/Hyperspace/types"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+var (
+	// errCapacityProofMismatch is returned if a capacity proof doesn't answer
+	// every sector of the challenge.
+	errCapacityProofMismatch = errors.New("capacity proof doesn't match the challenge")
+
+	// errCapacityProofWrongSegment is returned if a segment of a capacity
+	// proof isn't part of the challenged sector.
+	errCapacityProofWrongSegment = errors.New("capacity proof contains a segment that isn't part of its sector")
+
+	// errCapacityProbeWrongSegment is returned if the segment of a capacity
+	// probe proof isn't part of the probe sector.
+	errCapacityProbeWrongSegment = errors.New("capacity probe proof contains a segment that isn't part of the probe sector")
+
+	// errNoContractWithSectors is returned if none of the contracts with a
+	// host stores all of the sectors that should be verified.
+	errNoContractWithSectors = errors.New("no contract with the host stores the sectors")
+)
+
+// newCapacityChallenge asks for a random segment of every sector.
+func newCapacityChallenge(roots []crypto.Hash) modules.CapacityChallenge {
+	challenge := modules.CapacityChallenge{
+		SectorRoots:    roots,
+		SegmentIndices: make([]uint64, len(roots)),
+	}
+	for i := range roots {
+		challenge.SegmentIndices[i] = fastrand.Uint64n(modules.SectorSize / crypto.SegmentSize)
+	}
+	return challenge
+}
+
+// newCapacityProbe creates a probe sector of random data and asks for a random
+// segment of it.
+func newCapacityProbe() modules.CapacityProbe {
+	return modules.CapacityProbe{
+		Sector:       fastrand.Bytes(int(modules.SectorSize)),
+		SegmentIndex: fastrand.Uint64n(modules.SectorSize / crypto.SegmentSize),
+	}
+}
+
+// verifyCapacityProbe checks that the proof of a capacity probe contains the
+// requested segment of the probe sector.
+func verifyCapacityProbe(probe modules.CapacityProbe, proof modules.CapacityProbeProof) error {
+	if len(proof.Segment) != crypto.SegmentSize {
+		return errCapacityProbeWrongSegment
+	}
+	if !crypto.VerifySegment(proof.Segment, proof.HashSet, modules.SectorSize/crypto.SegmentSize, probe.SegmentIndex, crypto.MerkleRoot(probe.Sector)) {
+		return errCapacityProbeWrongSegment
+	}
+	return nil
+}
+
+// verifyCapacityProof checks that a capacity proof contains the challenged
+// segments of every sector of the challenge.
+func verifyCapacityProof(challenge modules.CapacityChallenge, proof modules.CapacityProof) error {
+	if len(proof.Segments) != len(challenge.SectorRoots) || len(proof.HashSets) != len(challenge.SectorRoots) {
+		return errCapacityProofMismatch
+	}
+	for i, root := range challenge.SectorRoots {
+		if len(proof.Segments[i]) != crypto.SegmentSize {
+			return errCapacityProofWrongSegment
+		}
+		if !crypto.VerifySegment(proof.Segments[i], proof.HashSets[i], modules.SectorSize/crypto.SegmentSize, challenge.SegmentIndices[i], root) {
+			return errCapacityProofWrongSegment
+		}
+	}
+	return nil
+}
+
+// managedChallengeCapacity challenges the host of the contract to prove that
+// it stores the sectors of the contract and that it has the unused capacity it
+// advertises, and records the result in the hostdb.
+func (c *Contractor) managedChallengeCapacity(contract modules.RenterContract, host modules.HostDBEntry) {
+	roots, err := c.staticContracts.RandomSectorRoots(contract.ID, modules.NegotiateMaxCapacityChallengeSectors)
+	if err != nil {
+		return
+	}
+	var probe modules.CapacityProbe
+	if host.RemainingStorage >= modules.SectorSize {
+		probe = newCapacityProbe()
+	}
+	if len(roots) == 0 && len(probe.Sector) == 0 {
+		return
+	}
+	challenge := newCapacityChallenge(roots)
+	proof, probeProof, answered, err := c.staticContracts.CapacityProof(host, contract.ID, challenge, probe, c.tg.StopChan())
+	if !answered {
+		c.log.Debugf("Host %v didn't answer the capacity challenge: %v", host.PublicKey, err)
+		c.hdb.RecordCapacityProof(host.PublicKey, false, false)
+		return
+	}
+	if err == nil {
+		err = verifyCapacityProof(challenge, proof)
+	}
+	if err == nil && len(probe.Sector) != 0 {
+		err = verifyCapacityProbe(probe, probeProof)
+	}
+	if err != nil {
+		c.log.Debugf("Host %v failed the capacity challenge: %v", host.PublicKey, err)
+	}
+	c.hdb.RecordCapacityProof(host.PublicKey, true, err == nil)
+}
+
+// VerifySectorSegments downloads a random segment of each of the sectors from
+// the host together with its Merkle proof, and checks the segments against the
+// roots. Segments are paid for like any other download, but only a fraction of
+// the sectors is transferred. The download is paid with the contract that
+// stores the sectors, which isn't necessarily the primary contract with the
+// host. proto.ErrBadSectorData is returned if the host sent segments that
+// don't belong to the sectors.
+func (c *Contractor) VerifySectorSegments(pk types.SiaPublicKey, roots []crypto.Hash, cancel <-chan struct{}) error {
+	if len(roots) > modules.NegotiateMaxDownloadSegments {
+		return errors.New("too many sectors to verify at once")
+	}
+	id, err := c.managedContractWithSectors(pk, roots)
+	if err != nil {
+		return err
+	}
+	c.mu.RLock()
+	height := c.blockHeight
+	renewing := c.renewing[id]
+	c.mu.RUnlock()
+	if renewing {
+		return errors.New("currently renewing that contract")
+	}
+	_, host, err := c.managedDownloadContract(id, height)
+	if err != nil {
+		return err
+	}
+
+	request := newCapacityChallenge(roots)
+	_, segments, err := c.staticContracts.DownloadSegments(host, id, request, cancel)
+	if err != nil {
+		return err
+	}
+	if verifyCapacityProof(request, segments) != nil {
+		return proto.ErrBadSectorData
+	}
+	return nil
+}
+
+// managedContractWithSectors returns the id of the contract with the host that
+// stores all of the sectors. The primary contract with the host is preferred.
+func (c *Contractor) managedContractWithSectors(pk types.SiaPublicKey, roots []crypto.Hash) (types.FileContractID, error) {
+	c.mu.RLock()
+	primary, gotPrimary := c.pubKeysToContractID[string(pk.Key)]
+	c.mu.RUnlock()
+	ids := make([]types.FileContractID, 0, 1)
+	if gotPrimary {
+		ids = append(ids, primary)
+	}
+	for _, contract := range c.staticContracts.ViewAll() {
+		if contract.HostPublicKey.String() == pk.String() && contract.ID != primary {
+			ids = append(ids, contract.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return types.FileContractID{}, errors.New("failed to get filecontract id from key")
+	}
+	for _, id := range ids {
+		if stored, err := c.staticContracts.HasSectorRoots(id, roots); err == nil && stored {
+			return id, nil
+		}
+	}
+	return types.FileContractID{}, errNoContractWithSectors
+}
+
+// threadedChallengeCapacity sends a capacity challenge to the hosts of all
+// active contracts that weren't challenged within the capacityProofInterval.
+func (c *Contractor) threadedChallengeCapacity() {
+	if err := c.tg.Add(); err != nil {
+		return
+	}
+	defer c.tg.Done()
+	if !c.capacityProofLock.TryLock() {
+		return
+	}
+	defer c.capacityProofLock.Unlock()
+
+	for _, contract := range c.staticContracts.ViewAll() {
+		select {
+		case <-c.tg.StopChan():
+			return
+		default:
+		}
+		host, exists := c.hdb.Host(contract.HostPublicKey)
+		if !exists || time.Since(host.LastCapacityProof) < capacityProofInterval {
+			continue
+		}
+		c.managedChallengeCapacity(contract, host)
+	}
+}
@@ -0,0 +1,78 @@
+package contractor
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+// TestVerifyCapacityProof checks that only proofs containing the challenged
+// segments of the challenged sectors are accepted.
+func TestVerifyCapacityProof(t *testing.T) {
+	sectors := [][]byte{
+		fastrand.Bytes(int(modules.SectorSize)),
+		fastrand.Bytes(int(modules.SectorSize)),
+	}
+	roots := []crypto.Hash{crypto.MerkleRoot(sectors[0]), crypto.MerkleRoot(sectors[1])}
+	challenge := newCapacityChallenge(roots)
+	var proof modules.CapacityProof
+	for i, sector := range sectors {
+		segment, hashSet := crypto.MerkleProof(sector, challenge.SegmentIndices[i])
+		proof.Segments = append(proof.Segments, segment)
+		proof.HashSets = append(proof.HashSets, hashSet)
+	}
+	if err := verifyCapacityProof(challenge, proof); err != nil {
+		t.Fatal(err)
+	}
+
+	// A proof for another segment is rejected.
+	other := challenge
+	other.SegmentIndices = []uint64{(challenge.SegmentIndices[0] + 1) % (modules.SectorSize / crypto.SegmentSize), challenge.SegmentIndices[1]}
+	if err := verifyCapacityProof(other, proof); err != errCapacityProofWrongSegment {
+		t.Fatal("expected errCapacityProofWrongSegment but got", err)
+	}
+
+	// A tampered segment is rejected.
+	proof.Segments[1] = append([]byte(nil), proof.Segments[1]...)
+	proof.Segments[1][0]++
+	if err := verifyCapacityProof(challenge, proof); err != errCapacityProofWrongSegment {
+		t.Fatal("expected errCapacityProofWrongSegment but got", err)
+	}
+
+	// A proof that leaves out a sector is rejected.
+	proof.Segments, proof.HashSets = proof.Segments[:1], proof.HashSets[:1]
+	if err := verifyCapacityProof(challenge, proof); err != errCapacityProofMismatch {
+		t.Fatal("expected errCapacityProofMismatch but got", err)
+	}
+}
+
+// TestVerifyCapacityProbe checks that only the requested segment of the probe
+// sector is accepted.
+func TestVerifyCapacityProbe(t *testing.T) {
+	probe := newCapacityProbe()
+	segment, hashSet := crypto.MerkleProof(probe.Sector, probe.SegmentIndex)
+	proof := modules.CapacityProbeProof{Segment: segment, HashSet: hashSet}
+	if err := verifyCapacityProbe(probe, proof); err != nil {
+		t.Fatal(err)
+	}
+
+	// A proof for another segment is rejected.
+	other := probe
+	other.SegmentIndex = (probe.SegmentIndex + 1) % (modules.SectorSize / crypto.SegmentSize)
+	if err := verifyCapacityProbe(other, proof); err != errCapacityProbeWrongSegment {
+		t.Fatal("expected errCapacityProbeWrongSegment but got", err)
+	}
+
+	// A proof for a different sector is rejected, the host has to use the
+	// sector it received.
+	other = newCapacityProbe()
+	other.SegmentIndex = probe.SegmentIndex
+	if err := verifyCapacityProbe(other, proof); err != errCapacityProbeWrongSegment {
+		t.Fatal("expected errCapacityProbeWrongSegment but got", err)
+	}
+	if err := verifyCapacityProbe(probe, modules.CapacityProbeProof{}); err != errCapacityProbeWrongSegment {
+		t.Fatal("expected errCapacityProbeWrongSegment but got", err)
+	}
+}
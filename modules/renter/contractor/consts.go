@@ -1,6 +1,8 @@
 package contractor
 
 import (
+	"time"
+
 	"github.com/HyperspaceApp/Hyperspace/build"
 	"github.com/HyperspaceApp/Hyperspace/modules"
 	"github.com/HyperspaceApp/Hyperspace/types"
@@ -8,6 +10,15 @@ import (
 
 // Constants related to contract formation parameters.
 var (
+	// capacityProofInterval is the minimum amount of time between two
+	// capacity challenges sent to the same host. A challenge makes the host
+	// read a few sectors from disk, so hosts are challenged rarely.
+	capacityProofInterval = build.Select(build.Var{
+		Standard: time.Hour * 24,
+		Dev:      time.Minute * 10,
+		Testing:  time.Second * 5,
+	}).(time.Duration)
+
 	// consecutiveRenewalsBeforeReplacement is the number of times a contract
 	// attempt to be renewed before it is marked as !goodForRenew.
 	consecutiveRenewalsBeforeReplacement = build.Select(build.Var{
@@ -24,11 +35,116 @@ var (
 	// 100SC.
 	fileContractMinimumFunding = float64(0.15)
 
+	// integrityFailuresBeforeReplacement is the number of consecutive
+	// integrity scans a host needs to fail before its contract is replaced. A
+	// single bad sector might be caused by a transient problem on the host.
+	integrityFailuresBeforeReplacement = build.Select(build.Var{
+		Dev:      2,
+		Standard: 3,
+		Testing:  1,
+	}).(int)
+
+	// integrityReplacementBan is the number of blocks during which no new
+	// contract is formed with a host whose contract was replaced after it
+	// kept failing integrity checks.
+	integrityReplacementBan = build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: types.BlockHeight(30 * 144), // ~1 month
+		Testing:  types.BlockHeight(100),
+	}).(types.BlockHeight)
+
+	// integrityScanSectors is the number of random sectors that are
+	// downloaded from every host during an integrity scan.
+	integrityScanSectors = build.Select(build.Var{
+		Dev:      2,
+		Standard: 3,
+		Testing:  1,
+	}).(int)
+
+	// maxRecentMissedProofs is the number of storage proofs a host may have
+	// recently missed on-chain before the contractor stops using it. Fewer
+	// missed proofs only lower the score of the host.
+	maxRecentMissedProofs = build.Select(build.Var{
+		Dev:      uint64(2),
+		Standard: uint64(2),
+		Testing:  uint64(1),
+	}).(uint64)
+
+	// maxContractsPerAllowance is the maximum number of contracts an
+	// allowance may require. An allowance with a low MaxFundsPerContract
+	// would otherwise require a contract with nearly every host of the
+	// network.
+	maxContractsPerAllowance = uint64(1000)
+
+	// maxContractsPerHost is the maximum number of parallel contracts that an
+	// allowance may form with a single host.
+	maxContractsPerHost = uint64(8)
+
+	// maxTransitionContractsPerRound is the maximum number of contracts that
+	// are formed in a single maintenance round while the contractor is
+	// transitioning from one allowance to another. This prevents an allowance
+	// change from causing a burst of contract formations.
+	maxTransitionContractsPerRound = build.Select(build.Var{
+		Dev:      3,
+		Standard: 10,
+		Testing:  5,
+	}).(int)
+
 	// minContractFundRenewalThreshold defines the ratio of remaining funds to
 	// total contract cost below which the contractor will prematurely renew a
 	// contract.
 	minContractFundRenewalThreshold = float64(0.03) // 3%
 
+	// defaultRenewalBias is the renewal bias that is used if an allowance
+	// prefers renewals but doesn't set a bias.
+	defaultRenewalBias = 0.25
+
+	// maxRenewalBias is the strongest renewal bias that can be set. With the
+	// maximum bias, existing hosts may score more than an order of magnitude
+	// lower than the minimum score before their contracts are replaced.
+	maxRenewalBias = 10.0
+
+	// trialHosts is the number of hosts a trial allowance forms contracts
+	// with. It's enough to upload and download a small test file.
+	trialHosts = build.Select(build.Var{
+		Dev:      uint64(2),
+		Standard: uint64(3),
+		Testing:  uint64(2),
+	}).(uint64)
+
+	// trialPeriod is the period of a trial allowance. The trial ends once its
+	// contracts enter the renew window, which is half the period.
+	trialPeriod = build.Select(build.Var{
+		Dev:      types.BlockHeight(40),
+		Standard: types.BlockHeight(6 * 144), // 6 days
+		Testing:  types.BlockHeight(40),
+	}).(types.BlockHeight)
+
+	// webhookMaxAttempts is the number of times the delivery of an event to
+	// a webhook is attempted before the event is dropped.
+	webhookMaxAttempts = build.Select(build.Var{
+		Dev:      4,
+		Standard: 6,
+		Testing:  3,
+	}).(int)
+
+	// webhookRetryInterval is the time the contractor waits before retrying a
+	// failed webhook delivery for the first time. The interval doubles with
+	// every failed attempt, so with the standard settings an event is dropped
+	// after roughly 30 minutes.
+	webhookRetryInterval = build.Select(build.Var{
+		Dev:      5 * time.Second,
+		Standard: time.Minute,
+		Testing:  50 * time.Millisecond,
+	}).(time.Duration)
+
+	// webhookTimeout is the time a webhook has to respond to a delivery.
+	webhookTimeout = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: 30 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
 	// randomHostsBufferForScore defines how many extra hosts are queried when trying
 	// to figure out an appropriate minimum score for the hosts that we have.
 	randomHostsBufferForScore = build.Select(build.Var{
@@ -5,8 +5,10 @@ package contractor
 // contracts need to be renewed, and if contracts need to be blacklisted.
 
 import (
+	"bytes"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/HyperspaceApp/Hyperspace/build"
 	"github.com/HyperspaceApp/Hyperspace/modules"
@@ -26,18 +28,177 @@ var (
 type (
 	// fileContractRenewal is an instruction to renew a file contract.
 	fileContractRenewal struct {
-		id     types.FileContractID
-		amount types.Currency
+		id      types.FileContractID
+		amount  types.Currency
+		refresh bool
 	}
 )
 
+// renewalBias returns the strength of the bias towards existing contracts.
+// It is zero if the allowance doesn't prefer renewals.
+func renewalBias(allowance modules.Allowance) float64 {
+	if !allowance.PreferRenewal {
+		return 0
+	}
+	if allowance.RenewalBias == 0 {
+		return defaultRenewalBias
+	}
+	return allowance.RenewalBias
+}
+
+// contractRefreshThreshold returns the fraction of a contract's initial funds
+// below which the contract is refreshed. Without an explicit threshold, an
+// allowance that prefers renewals refreshes contracts earlier, so that
+// existing contracts absorb the funds the renter needs before they run dry.
+func contractRefreshThreshold(allowance modules.Allowance) float64 {
+	if allowance.RefreshThreshold != 0 {
+		return allowance.RefreshThreshold
+	}
+	return minContractFundRenewalThreshold * (1 + renewalBias(allowance))
+}
+
+// contractsForAllowance returns the number of contracts the contractor forms
+// for the allowance. Usually that is one contract per host of the allowance,
+// but if MaxFundsPerContract is lower than the funds a contract would receive,
+// more contracts are formed so that the allowance can still be spent.
+func contractsForAllowance(allowance modules.Allowance) uint64 {
+	contracts := allowance.Hosts
+	if allowance.MaxFundsPerContract.IsZero() {
+		return contracts
+	}
+	// New contracts receive a third of their share of the allowance.
+	perContract := allowance.MaxFundsPerContract.Mul64(3)
+	capped := allowance.Funds.Div(perContract)
+	if !capped.Mul(perContract).Equals(allowance.Funds) {
+		capped = capped.Add(types.NewCurrency64(1))
+	}
+	if capped.Cmp(types.NewCurrency64(contracts)) > 0 {
+		contracts, _ = capped.Uint64()
+	}
+	return contracts
+}
+
+// contractsPerHost returns the number of contracts the allowance forms with
+// every host.
+func contractsPerHost(allowance modules.Allowance) uint64 {
+	if allowance.ContractsPerHost == 0 {
+		return 1
+	}
+	return allowance.ContractsPerHost
+}
+
+// initialContractFunds returns the funds a new contract is formed with. The
+// parallel contracts with a host split the host's share of the allowance.
+func initialContractFunds(allowance modules.Allowance) types.Currency {
+	contracts := contractsForAllowance(allowance)
+	if contracts == 0 {
+		return types.ZeroCurrency
+	}
+	return capContractFunds(allowance, allowance.Funds.Div64(contracts).Div64(contractsPerHost(allowance)).Div64(3))
+}
+
+// excessParallelContracts returns the parallel contracts that exceed the
+// number of contracts per host of the allowance. The oldest parallel
+// contracts of every host are kept.
+func excessParallelContracts(contracts []modules.RenterContract, parallel map[types.FileContractID]struct{}, perHost uint64) map[types.FileContractID]struct{} {
+	byHost := make(map[string][]modules.RenterContract)
+	for _, contract := range contracts {
+		if contract.Utility.Locked && !contract.Utility.GoodForRenew && !contract.Utility.GoodForUpload {
+			// contract is canceled
+			continue
+		}
+		if _, exists := parallel[contract.ID]; exists {
+			pk := contract.HostPublicKey.String()
+			byHost[pk] = append(byHost[pk], contract)
+		}
+	}
+	excess := make(map[types.FileContractID]struct{})
+	for _, hostContracts := range byHost {
+		sort.Slice(hostContracts, func(i, j int) bool {
+			if hostContracts[i].StartHeight != hostContracts[j].StartHeight {
+				return hostContracts[i].StartHeight < hostContracts[j].StartHeight
+			}
+			return bytes.Compare(hostContracts[i].ID[:], hostContracts[j].ID[:]) < 0
+		})
+		for i, contract := range hostContracts {
+			// One of the host's contracts is its regular contract.
+			if uint64(i)+1 >= perHost {
+				excess[contract.ID] = struct{}{}
+			}
+		}
+	}
+	return excess
+}
+
+// capContractFunds clamps the funds of a contract to the MaxFundsPerContract of
+// the allowance.
+func capContractFunds(allowance modules.Allowance, funds types.Currency) types.Currency {
+	if !allowance.MaxFundsPerContract.IsZero() && funds.Cmp(allowance.MaxFundsPerContract) > 0 {
+		return allowance.MaxFundsPerContract
+	}
+	return funds
+}
+
+// renewalScoreFactor returns the factor by which the score of a host the
+// contractor already has a contract with may fall short of the minimum score
+// before the contract is replaced. Replacing a contract means paying the
+// contract price of a new host and the transaction fees of forming the
+// contract, so the bias grows with the share of a new contract's funds that
+// would be spent on those fees. The candidates are the hosts that could
+// replace the existing ones.
+func renewalScoreFactor(allowance modules.Allowance, candidates []modules.HostDBEntry, txnFee types.Currency) float64 {
+	bias := renewalBias(allowance)
+	if bias == 0 || allowance.Hosts == 0 {
+		return 1
+	}
+	var feeRatio float64
+	initialFunds := initialContractFunds(allowance)
+	if len(candidates) > 0 && !initialFunds.IsZero() {
+		var contractPrices types.Currency
+		for _, host := range candidates {
+			contractPrices = contractPrices.Add(host.ContractPrice)
+		}
+		fees := contractPrices.Div64(uint64(len(candidates))).Add(txnFee)
+		feeRatio, _ = big.NewRat(0, 1).SetFrac(fees.Big(), initialFunds.Big()).Float64()
+	}
+	return 1 + bias*(1+feeRatio)
+}
+
+// diversifyHostVersions orders the hosts so that hosts running versions the
+// contractor has few contracts with come first. 'versions' counts the
+// contracts per version and is updated as if a contract was formed with every
+// host. Among hosts with equally common versions the original order is kept.
+func diversifyHostVersions(hosts []modules.HostDBEntry, versions map[string]int) []modules.HostDBEntry {
+	remaining := append([]modules.HostDBEntry(nil), hosts...)
+	ordered := make([]modules.HostDBEntry, 0, len(hosts))
+	for len(remaining) > 0 {
+		next := 0
+		for i, host := range remaining {
+			if versions[host.Version] < versions[remaining[next].Version] {
+				next = i
+			}
+		}
+		versions[remaining[next].Version]++
+		ordered = append(ordered, remaining[next])
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+	return ordered
+}
+
 // managedCheckForDuplicates checks for static contracts that have the same host
-// key and moves the older one to old contracts
+// key and moves the older one to old contracts. Parallel contracts are not
+// duplicates.
 func (c *Contractor) managedCheckForDuplicates() {
 	// Build map for comparison
 	pubkeys := make(map[string]types.FileContractID)
 	var newContract, oldContract modules.RenterContract
 	for _, contract := range c.staticContracts.ViewAll() {
+		c.mu.RLock()
+		_, parallel := c.parallelContracts[contract.ID]
+		c.mu.RUnlock()
+		if parallel {
+			continue
+		}
 		id, exists := pubkeys[contract.HostPublicKey.String()]
 		if !exists {
 			pubkeys[contract.HostPublicKey.String()] = contract.ID
@@ -89,10 +250,17 @@ func (c *Contractor) managedEstimateRenewFundingRequirements(contract modules.Re
 		return types.ZeroCurrency, errors.New("could not find host in hostdb")
 	}
 
+	// Aligned and staggered contracts don't last exactly one period, their
+	// storage is funded for the duration of the renewed contract.
+	c.mu.RLock()
+	endHeight := staggerEndHeight(c.contractEndHeight(), allowance.RenewStagger, contract.HostPublicKey)
+	c.mu.RUnlock()
+	period := fundedPeriod(allowance, blockHeight, endHeight)
+
 	// Estimate the amount of money that's going to be needed for existing
 	// storage.
 	dataStored := contract.Transaction.FileContractRevisions[0].NewFileSize
-	maintenanceCost := types.NewCurrency64(dataStored).Mul64(uint64(allowance.Period)).Mul(host.StoragePrice)
+	maintenanceCost := types.NewCurrency64(dataStored).Mul64(uint64(period)).Mul(host.StoragePrice)
 
 	// For the upload and download estimates, we're going to need to know the
 	// amount of money that was spent on upload and download by this contract
@@ -144,7 +312,7 @@ func (c *Contractor) managedEstimateRenewFundingRequirements(contract modules.Re
 	}
 	// The estimated cost for new upload spending is the previous upload
 	// bandwidth plus the implied storage cost for all of the new data.
-	newUploadsCost := prevUploadSpending.Add(prevUploadDataEstimate.Mul64(uint64(allowance.Period)).Mul(host.StoragePrice))
+	newUploadsCost := prevUploadSpending.Add(prevUploadDataEstimate.Mul64(uint64(period)).Mul(host.StoragePrice))
 
 	// Estimate the amount of money that's going to be spent on downloads.
 	newDownloadsCost := prevDownloadSpending
@@ -173,11 +341,11 @@ func (c *Contractor) managedEstimateRenewFundingRequirements(contract modules.Re
 	// Check for a sane minimum. The contractor should not be forming contracts
 	// with less than 'fileContractMinimumFunding / (num contracts)' of the
 	// value of the allowance.
-	minimum := allowance.Funds.MulFloat(fileContractMinimumFunding).Div64(allowance.Hosts)
+	minimum := allowance.Funds.MulFloat(fileContractMinimumFunding).Div64(contractsForAllowance(allowance))
 	if estimatedCost.Cmp(minimum) < 0 {
 		estimatedCost = minimum
 	}
-	return estimatedCost, nil
+	return capContractFunds(allowance, estimatedCost), nil
 }
 
 // managedInterruptContractMaintenance will issue an interrupt signal to any
@@ -205,89 +373,161 @@ func (c *Contractor) managedInterruptContractMaintenance() {
 	}
 }
 
+// managedMinimumScore finds the minimum score that a host is allowed to have to
+// be considered good for upload. It is derived from a random set of hosts that
+// could be used for an allowance of hostCount hosts, which is returned as
+// well. If the hostdb has no hosts, the minimum score is zero.
+func (c *Contractor) managedMinimumScore(hostCount int) (types.Currency, []modules.HostDBEntry, error) {
+	hosts, err := c.hdb.RandomHosts(hostCount+randomHostsBufferForScore, nil, nil)
+	if err != nil {
+		return types.ZeroCurrency, nil, err
+	}
+	if len(hosts) == 0 {
+		return types.ZeroCurrency, hosts, nil
+	}
+	lowestScore := c.hdb.ScoreBreakdown(hosts[0]).Score
+	for i := 1; i < len(hosts); i++ {
+		score := c.hdb.ScoreBreakdown(hosts[i]).Score
+		if score.Cmp(lowestScore) < 0 {
+			lowestScore = score
+		}
+	}
+	// Set the minimum acceptable score to a factor of the lowest score.
+	return lowestScore.Div(scoreLeeway), hosts, nil
+}
+
+// managedUtilityMinimumScore returns the minimum score that the host of an
+// existing contract needs to have for the contract to stay useful. A new set
+// of hosts that could be used to match the allowance is pulled from the
+// hostdb, and the lowest scoring host of these new hosts is used as a
+// baseline.
+func (c *Contractor) managedUtilityMinimumScore(allowance modules.Allowance) (types.Currency, error) {
+	minScore, hosts, err := c.managedMinimumScore(int(contractsForAllowance(allowance)))
+	if err != nil {
+		return types.ZeroCurrency, err
+	}
+	if len(hosts) > 0 {
+		// If the allowance prefers renewals, the hosts of existing contracts
+		// get to fall further below the minimum before they are replaced.
+		_, maxTxnFee := c.tpool.FeeEstimation()
+		txnFee := maxTxnFee.Mul64(modules.EstimatedFileContractTransactionSetSize)
+		if factor := renewalScoreFactor(allowance, hosts, txnFee); factor > 1 {
+			minScore = minScore.MulFloat(1 / factor)
+		}
+	}
+	return minScore, nil
+}
+
+// managedCheckContractUtility figures out whether a contract is useful for
+// uploading, and whether it should be renewed, given the current state of its
+// host. If the contract shouldn't be renewed, the reason is returned as well.
+// excess are the parallel contracts that the allowance no longer asks for.
+func (c *Contractor) managedCheckContractUtility(contract modules.RenterContract, minScore types.Currency, excess map[types.FileContractID]struct{}) (u modules.ContractUtility, reason string) {
+	// Record current utility of the contract
+	u.GoodForRenew = contract.Utility.GoodForRenew
+	u.GoodForUpload = contract.Utility.GoodForUpload
+	u.Locked = contract.Utility.Locked
+
+	// Start the contract in good standing if the utility wasn't
+	// locked.
+	if !u.Locked {
+		u.GoodForUpload = true
+		u.GoodForRenew = true
+	} else if !u.GoodForRenew {
+		reason = "contract was marked as bad"
+	}
+
+	host, exists := c.hdb.Host(contract.HostPublicKey)
+	// Contract has no utility if the host is not in the database.
+	if !exists {
+		u.GoodForUpload = false
+		u.GoodForRenew = false
+		return u, "host is not in the hostdb"
+	}
+	// Contract has no utility if the filter of the hostdb excludes the
+	// host. The contract isn't canceled, so its data can still be
+	// downloaded until it expires.
+	if host.Filtered {
+		u.GoodForUpload = false
+		u.GoodForRenew = false
+		return u, "host is excluded by the hostdb filter"
+	}
+	// Contract has no utility if the score is poor.
+	if !minScore.IsZero() && c.hdb.ScoreBreakdown(host).Score.Cmp(minScore) < 0 {
+		u.GoodForUpload = false
+		u.GoodForRenew = false
+		return u, "host score is below the minimum score"
+	}
+	// Contract has no utility if the host is offline.
+	if isOffline(host) {
+		u.GoodForUpload = false
+		u.GoodForRenew = false
+		return u, "host is offline"
+	}
+	// Contract has no utility if the host keeps missing storage
+	// proofs.
+	if host.RecentMissedProofs >= maxRecentMissedProofs {
+		u.GoodForUpload = false
+		u.GoodForRenew = false
+		return u, "host missed too many storage proofs"
+	}
+	// Parallel contracts that the allowance no longer asks for are
+	// left to expire.
+	if _, exists := excess[contract.ID]; exists {
+		u.GoodForUpload = false
+		u.GoodForRenew = false
+		return u, "parallel contract is no longer needed"
+	}
+	// Contract should not be used for uploading if the time has come to
+	// renew the contract.
+	c.mu.RLock()
+	blockHeight := c.blockHeight
+	renewWindow := c.allowance.RenewWindow
+	c.mu.RUnlock()
+	if blockHeight+renewWindow >= contract.EndHeight {
+		u.GoodForUpload = false
+	}
+	return u, reason
+}
+
 // managedMarkContractsUtility checks every active contract in the contractor and
 // figures out whether the contract is useful for uploading, and whether the
 // contract should be renewed.
 func (c *Contractor) managedMarkContractsUtility() error {
-	// Pull a new set of hosts from the hostdb that could be used as a new set
-	// to match the allowance. The lowest scoring host of these new hosts will
-	// be used as a baseline for determining whether our existing contracts are
-	// worthwhile.
 	c.mu.RLock()
-	hostCount := int(c.allowance.Hosts)
+	allowance := c.allowance
+	excess := excessParallelContracts(c.staticContracts.ViewAll(), c.parallelContracts, contractsPerHost(c.allowance))
 	c.mu.RUnlock()
-	hosts, err := c.hdb.RandomHosts(hostCount+randomHostsBufferForScore, nil, nil)
+	minScore, err := c.managedUtilityMinimumScore(allowance)
 	if err != nil {
 		return err
 	}
 
-	// Find the minimum score that a host is allowed to have to be considered
-	// good for upload.
-	var minScore types.Currency
-	if len(hosts) > 0 {
-		lowestScore := c.hdb.ScoreBreakdown(hosts[0]).Score
-		for i := 1; i < len(hosts); i++ {
-			score := c.hdb.ScoreBreakdown(hosts[i]).Score
-			if score.Cmp(lowestScore) < 0 {
-				lowestScore = score
-			}
-		}
-		// Set the minimum acceptable score to a factor of the lowest score.
-		minScore = lowestScore.Div(scoreLeeway)
-	}
-
 	// Update utility fields for each contract.
 	for _, contract := range c.staticContracts.ViewAll() {
-		utility := func() (u modules.ContractUtility) {
-			// Record current utility of the contract
-			u.GoodForRenew = contract.Utility.GoodForRenew
-			u.GoodForUpload = contract.Utility.GoodForUpload
-			u.Locked = contract.Utility.Locked
-
-			// Start the contract in good standing if the utility wasn't
-			// locked.
-			if !u.Locked {
-				u.GoodForUpload = true
-				u.GoodForRenew = true
-			}
-
-			host, exists := c.hdb.Host(contract.HostPublicKey)
-			// Contract has no utility if the host is not in the database.
-			if !exists {
-				u.GoodForUpload = false
-				u.GoodForRenew = false
-				return
-			}
-			// Contract has no utility if the score is poor.
-			if !minScore.IsZero() && c.hdb.ScoreBreakdown(host).Score.Cmp(minScore) < 0 {
-				u.GoodForUpload = false
-				u.GoodForRenew = false
-				return
-			}
-			// Contract has no utility if the host is offline.
-			if isOffline(host) {
-				u.GoodForUpload = false
-				u.GoodForRenew = false
-				return
-			}
-			// Contract should not be used for uploading if the time has come to
-			// renew the contract.
-			c.mu.RLock()
-			blockHeight := c.blockHeight
-			renewWindow := c.allowance.RenewWindow
-			c.mu.RUnlock()
-			if blockHeight+renewWindow >= contract.EndHeight {
-				u.GoodForUpload = false
-				return
-			}
-			return
-		}()
+		utility, _ := c.managedCheckContractUtility(contract, minScore, excess)
 
 		// Apply changes.
 		err := c.managedUpdateContractUtility(contract.ID, utility)
 		if err != nil {
 			return err
 		}
+		wasGood := contract.Utility.GoodForUpload || contract.Utility.GoodForRenew
+		if wasGood && !utility.GoodForUpload && !utility.GoodForRenew {
+			c.managedNotifyWebhooks(modules.ContractEventBad, contract.ID, contract.HostPublicKey, types.FileContractID{})
+		}
+	}
+	return nil
+}
+
+// checkHostSettings returns an error if a contract lasting duration blocks
+// can't be formed or renewed with the host, because the host is too expensive
+// or its MaxDuration is too short.
+func checkHostSettings(host modules.HostDBEntry, duration types.BlockHeight) error {
+	if host.StoragePrice.Cmp(maxStoragePrice) > 0 {
+		return errTooExpensive
+	} else if host.MaxDuration < duration {
+		return errors.New("insufficient MaxDuration of host")
 	}
 	return nil
 }
@@ -295,16 +535,26 @@ func (c *Contractor) managedMarkContractsUtility() error {
 // managedNewContract negotiates an initial file contract with the specified
 // host, saves it, and returns it.
 func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFunding types.Currency, endHeight types.BlockHeight) (types.Currency, modules.RenterContract, error) {
-	// reject hosts that are too expensive
-	if host.StoragePrice.Cmp(maxStoragePrice) > 0 {
-		return types.ZeroCurrency, modules.RenterContract{}, errTooExpensive
-	}
-	// Determine if host settings align with allowance period
+	return c.managedFormContract(host, contractFunding, endHeight, false)
+}
+
+// managedNewParallelContract negotiates an additional file contract with a
+// host that the contractor already has a contract with.
+func (c *Contractor) managedNewParallelContract(host modules.HostDBEntry, contractFunding types.Currency, endHeight types.BlockHeight) (types.Currency, modules.RenterContract, error) {
+	return c.managedFormContract(host, contractFunding, endHeight, true)
+}
+
+// managedFormContract negotiates a file contract with the specified host. A
+// parallel contract is only formed if the contractor already has a contract
+// with the host, any other contract only if it doesn't.
+func (c *Contractor) managedFormContract(host modules.HostDBEntry, contractFunding types.Currency, endHeight types.BlockHeight, parallel bool) (types.Currency, modules.RenterContract, error) {
+	// Determine if host settings allow a contract until the staggered end
+	// height.
 	c.mu.Lock()
-	period := c.allowance.Period
+	blockHeight := c.blockHeight
+	endHeight = staggerEndHeight(endHeight, c.allowance.RenewStagger, host.PublicKey)
 	c.mu.Unlock()
-	if host.MaxDuration < period {
-		err := errors.New("unable to form contract with host due to insufficient MaxDuration of host")
+	if err := checkHostSettings(host, contractDuration(blockHeight, endHeight)); err != nil {
 		return types.ZeroCurrency, modules.RenterContract{}, err
 	}
 	// cap host.MaxCollateral
@@ -327,6 +577,9 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 		EndHeight:     endHeight,
 		RefundAddress: uc.UnlockHash(),
 	}
+	if c.allowance.Arbiter != nil {
+		params.Arbiter = *c.allowance.Arbiter
+	}
 	c.mu.RUnlock()
 
 	// create transaction builder and trigger contract formation.
@@ -344,7 +597,12 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 	c.mu.Lock()
 	c.contractIDToPubKey[contract.ID] = contract.HostPublicKey
 	_, exists := c.pubKeysToContractID[string(contract.HostPublicKey.Key)]
-	if exists {
+	if parallel && !exists {
+		c.mu.Unlock()
+		txnBuilder.Drop()
+		c.log.Println("WARN: Attempted to form a parallel contract with a host that we don't have a contract with.")
+		return contractFunding, modules.RenterContract{}, fmt.Errorf("We don't have a contract with host %v", contract.HostPublicKey)
+	} else if !parallel && exists {
 		c.mu.Unlock()
 		txnBuilder.Drop()
 		// We need to return a funding value because money was spent on this
@@ -352,26 +610,38 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 		c.log.Println("WARN: Attempted to form a new contract with a host that we already have a contrat with.")
 		return contractFunding, modules.RenterContract{}, fmt.Errorf("We already have a contract with host %v", contract.HostPublicKey)
 	}
-	c.pubKeysToContractID[string(contract.HostPublicKey.Key)] = contract.ID
+	if parallel {
+		c.parallelContracts[contract.ID] = struct{}{}
+	} else {
+		c.pubKeysToContractID[string(contract.HostPublicKey.Key)] = contract.ID
+	}
 	c.mu.Unlock()
 
 	contractValue := contract.RenterFunds
 	c.log.Printf("Formed contract %v with %v for %v", contract.ID, host.NetAddress, contractValue.HumanString())
+	c.managedNotifyWebhooks(modules.ContractEventFormed, contract.ID, contract.HostPublicKey, types.FileContractID{})
 	return contractFunding, contract, nil
 }
 
 // managedPrunePubkeyMap will delete any pubkeys in the pubKeysToContractID map
-// that no longer map to an active contract.
+// that no longer map to an active contract. If a host only has parallel
+// contracts left, one of them takes the place of the host's contract.
 func (c *Contractor) managedPrunePubkeyMap() {
 	allContracts := c.staticContracts.ViewAll()
-	pks := make(map[string]struct{})
+	pks := make(map[string]types.FileContractID)
+	ids := make(map[types.FileContractID]struct{})
 	for _, c := range allContracts {
-		pks[string(c.HostPublicKey.Key)] = struct{}{}
+		pks[string(c.HostPublicKey.Key)] = c.ID
+		ids[c.ID] = struct{}{}
 	}
 	c.mu.Lock()
-	for pk := range c.pubKeysToContractID {
-		if _, exists := pks[pk]; !exists {
+	for pk, id := range c.pubKeysToContractID {
+		remaining, exists := pks[pk]
+		if !exists {
 			delete(c.pubKeysToContractID, pk)
+		} else if _, active := ids[id]; !active {
+			c.pubKeysToContractID[pk] = remaining
+			delete(c.parallelContracts, remaining)
 		}
 	}
 	c.mu.Unlock()
@@ -391,20 +661,26 @@ func (c *Contractor) managedPrunedRedundantAddressRange() {
 		contracts = append(contracts, contract)
 	}
 
-	// Get all the public keys and map them to contract ids.
+	// Get all the public keys and map them to contract ids. Parallel
+	// contracts share the address of their host, so every host is only
+	// checked once.
 	pks := make([]types.SiaPublicKey, 0, len(allContracts))
-	cids := make(map[string]types.FileContractID)
+	cids := make(map[string][]types.FileContractID)
 	for _, contract := range contracts {
-		pks = append(pks, contract.HostPublicKey)
-		cids[contract.HostPublicKey.String()] = contract.ID
+		if _, exists := cids[contract.HostPublicKey.String()]; !exists {
+			pks = append(pks, contract.HostPublicKey)
+		}
+		cids[contract.HostPublicKey.String()] = append(cids[contract.HostPublicKey.String()], contract.ID)
 	}
 
 	// Let the hostdb filter out bad hosts and cancel contracts with those
 	// hosts.
 	badHosts := c.hdb.CheckForIPViolations(pks)
 	for _, host := range badHosts {
-		if err := c.managedCancelContract(cids[host.String()]); err != nil {
-			c.log.Print("WARNING: Wasn't able to cancel contract in managedPrunedRedundantAddressRange", err)
+		for _, id := range cids[host.String()] {
+			if err := c.managedCancelContract(id); err != nil {
+				c.log.Print("WARNING: Wasn't able to cancel contract in managedPrunedRedundantAddressRange", err)
+			}
 		}
 	}
 }
@@ -412,7 +688,7 @@ func (c *Contractor) managedPrunedRedundantAddressRange() {
 // managedRenew negotiates a new contract for data already stored with a host.
 // It returns the new contract. This is a blocking call that performs network
 // I/O.
-func (c *Contractor) managedRenew(sc *proto.SafeContract, contractFunding types.Currency, newEndHeight types.BlockHeight) (modules.RenterContract, error) {
+func (c *Contractor) managedRenew(sc *proto.SafeContract, contractFunding types.Currency, newEndHeight types.BlockHeight, refresh bool) (modules.RenterContract, error) {
 	// For convenience
 	contract := sc.Metadata()
 	// Sanity check - should not be renewing a bad contract.
@@ -425,14 +701,12 @@ func (c *Contractor) managedRenew(sc *proto.SafeContract, contractFunding types.
 	// Fetch the host associated with this contract.
 	host, ok := c.hdb.Host(contract.HostPublicKey)
 	c.mu.Lock()
-	period := c.allowance.Period
+	blockHeight := c.blockHeight
 	c.mu.Unlock()
 	if !ok {
 		return modules.RenterContract{}, errors.New("no record of that host")
-	} else if host.StoragePrice.Cmp(maxStoragePrice) > 0 {
-		return modules.RenterContract{}, errTooExpensive
-	} else if host.MaxDuration < period {
-		return modules.RenterContract{}, errors.New("insufficient MaxDuration of host")
+	} else if err := checkHostSettings(host, contractDuration(blockHeight, newEndHeight)); err != nil {
+		return modules.RenterContract{}, err
 	}
 
 	// cap host.MaxCollateral
@@ -454,6 +728,7 @@ func (c *Contractor) managedRenew(sc *proto.SafeContract, contractFunding types.
 		StartHeight:   c.blockHeight,
 		EndHeight:     newEndHeight,
 		RefundAddress: uc.UnlockHash(),
+		Refresh:       refresh,
 	}
 	c.mu.RUnlock()
 
@@ -470,10 +745,15 @@ func (c *Contractor) managedRenew(sc *proto.SafeContract, contractFunding types.
 
 	// Add a mapping from the contract's id to the public key of the host. This
 	// will destroy the previous mapping from pubKey to contract id but other
-	// modules are only interested in the most recent contract anyway.
+	// modules are only interested in the most recent contract anyway. A
+	// renewed parallel contract stays a parallel contract.
 	c.mu.Lock()
 	c.contractIDToPubKey[newContract.ID] = newContract.HostPublicKey
-	c.pubKeysToContractID[string(newContract.HostPublicKey.Key)] = newContract.ID
+	if _, parallel := c.parallelContracts[contract.ID]; parallel {
+		c.parallelContracts[newContract.ID] = struct{}{}
+	} else {
+		c.pubKeysToContractID[string(newContract.HostPublicKey.Key)] = newContract.ID
+	}
 	c.mu.Unlock()
 
 	return newContract, nil
@@ -529,7 +809,8 @@ func (c *Contractor) managedRenewContract(renewInstructions fileContractRenewal,
 	// before. Once it has failed for a certain number of blocks in a
 	// row and reached its second half of the renew window, we give up
 	// on renewing it and set goodForRenew to false.
-	newContract, errRenew := c.managedRenew(oldContract, amount, endHeight)
+	endHeight = staggerEndHeight(endHeight, allowance.RenewStagger, oldContract.Metadata().HostPublicKey)
+	newContract, errRenew := c.managedRenew(oldContract, amount, endHeight, renewInstructions.refresh)
 	if errRenew != nil {
 		// Increment the number of failed renews for the contract if it
 		// was the host's fault.
@@ -557,6 +838,7 @@ func (c *Contractor) managedRenewContract(renewInstructions fileContractRenewal,
 			c.log.Printf("WARN: failed to renew %v, marked as bad: %v\n",
 				oldContract.Metadata().HostPublicKey, errRenew)
 			c.staticContracts.Return(oldContract)
+			c.managedNotifyWebhooks(modules.ContractEventBad, md.ID, md.HostPublicKey, types.FileContractID{})
 			return types.ZeroCurrency, errors.AddContext(errRenew, "contract marked as bad for too many consecutive failed renew attempts")
 		}
 
@@ -608,9 +890,86 @@ func (c *Contractor) managedRenewContract(renewInstructions fileContractRenewal,
 	c.mu.Unlock()
 	// Delete the old contract.
 	c.staticContracts.Delete(oldContract)
+	c.managedNotifyWebhooks(modules.ContractEventRenewed, newContract.ID, newContract.HostPublicKey, id)
 	return amount, nil
 }
 
+// managedFormParallelContracts forms parallel contracts with the hosts of the
+// contracts that are good for upload, until every host has as many contracts
+// as the allowance asks for or the funds run out. Every contract is formed
+// with initialFunds. It returns the funds that were spent and whether
+// maintenance was interrupted.
+func (c *Contractor) managedFormParallelContracts(allowance modules.Allowance, funds, initialFunds types.Currency, endHeight types.BlockHeight) (fundsSpent types.Currency, stopped bool) {
+	// Count the contracts of every host that has a regular contract which is
+	// good for upload.
+	contracts := make(map[string]uint64)
+	hostKeys := make(map[string]types.SiaPublicKey)
+	for _, contract := range c.staticContracts.ViewAll() {
+		if !contract.Utility.GoodForUpload {
+			continue
+		}
+		contracts[contract.HostPublicKey.String()]++
+		c.mu.RLock()
+		_, parallel := c.parallelContracts[contract.ID]
+		c.mu.RUnlock()
+		if !parallel {
+			hostKeys[contract.HostPublicKey.String()] = contract.HostPublicKey
+		}
+	}
+
+	c.mu.RLock()
+	blockHeight := c.blockHeight
+	c.mu.RUnlock()
+	for pk, hostKey := range hostKeys {
+		host, ok := c.hdb.Host(hostKey)
+		if !ok {
+			continue
+		}
+		if c.staticDeps.Disrupt("customResolver") {
+			port := host.NetAddress.Port()
+			host.NetAddress = modules.NetAddress(fmt.Sprintf("127.0.0.1:%s", port))
+		}
+		hostFunds := alignedContractFunds(initialFunds, allowance, blockHeight, endHeight, hostKey)
+		targetFunds := alignedContractFunds(initialContractFunds(allowance), allowance, blockHeight, endHeight, hostKey)
+		for n := contracts[pk]; n < contractsPerHost(allowance); n++ {
+			if funds.Cmp(fundsSpent.Add(hostFunds)) < 0 {
+				c.log.Println("WARN: need to form parallel contracts, but unable to because of a low allowance")
+				return fundsSpent, false
+			}
+			spent, newContract, err := c.managedNewParallelContract(host, hostFunds, endHeight)
+			fundsSpent = fundsSpent.Add(spent)
+			if err != nil {
+				c.log.Printf("Attempted to form a parallel contract with %v, but negotiation failed: %v\n", host.NetAddress, err)
+				break
+			}
+			err = c.managedUpdateContractUtility(newContract.ID, modules.ContractUtility{
+				GoodForUpload: true,
+				GoodForRenew:  true,
+			})
+			if err != nil {
+				c.log.Println("Failed to update the contract utilities", err)
+				return fundsSpent, true
+			}
+			c.managedUpdateUnderfunded(types.FileContractID{}, newContract.ID, hostFunds, targetFunds)
+			c.mu.Lock()
+			err = c.saveSync()
+			c.mu.Unlock()
+			if err != nil {
+				c.log.Println("Unable to save the contractor:", err)
+			}
+
+			select {
+			case <-c.tg.StopChan():
+				return fundsSpent, true
+			case <-c.interruptMaintenance:
+				return fundsSpent, true
+			default:
+			}
+		}
+	}
+	return fundsSpent, false
+}
+
 // threadedContractMaintenance checks the set of contracts that the contractor
 // has against the allownace, renewing any contracts that need to be renewed,
 // dropping contracts which are no longer worthwhile, and adding contracts if
@@ -627,6 +986,11 @@ func (c *Contractor) threadedContractMaintenance() {
 	}
 	defer c.tg.Done()
 
+	// Don't touch the contracts while maintenance is paused.
+	if c.MaintenancePaused() {
+		return
+	}
+
 	// Archive contracts that need to be archived before doing additional
 	// maintenance, check for any duplicates caused by interruption, and then
 	// prune the pubkey map.
@@ -637,6 +1001,16 @@ func (c *Contractor) threadedContractMaintenance() {
 	// Deduplicate contracts which share the same subnet.
 	c.managedPrunedRedundantAddressRange()
 
+	// A trial that wasn't promoted before its contracts are due for renewal
+	// is over. Its allowance is cancelled, so its contracts are left to
+	// expire.
+	if c.managedTrialExpired() {
+		if err := c.managedEndTrial(); err != nil {
+			c.log.Println("WARNING: unable to end the expired trial:", err)
+			return
+		}
+	}
+
 	// Nothing to do if there are no hosts.
 	c.mu.RLock()
 	wantedHosts := c.allowance.Hosts
@@ -657,6 +1031,11 @@ func (c *Contractor) threadedContractMaintenance() {
 	}
 	defer c.maintenanceLock.Unlock()
 
+	// Maintenance might have been paused while waiting for the lock.
+	if c.MaintenancePaused() {
+		return
+	}
+
 	// Update the utility fields for this contract based on the most recent
 	// hostdb.
 	if err := c.managedMarkContractsUtility(); err != nil {
@@ -664,6 +1043,9 @@ func (c *Contractor) threadedContractMaintenance() {
 		return
 	}
 
+	// Top up the allowance before it is used for renewals and new contracts.
+	c.managedTopUpAllowance()
+
 	// The rest of this function needs to know a few of the stateful variables
 	// from the contractor, build those up under a lock so that the rest of the
 	// function can execute without lock contention.
@@ -688,6 +1070,8 @@ func (c *Contractor) threadedContractMaintenance() {
 	var renewSet []fileContractRenewal
 	var refreshSet []fileContractRenewal
 
+	refreshThreshold := contractRefreshThreshold(allowance)
+
 	// Iterate through the contracts again, figuring out which contracts to
 	// renew and how much extra funds to renew them with.
 	for _, contract := range c.staticContracts.ViewAll() {
@@ -715,8 +1099,8 @@ func (c *Contractor) threadedContractMaintenance() {
 		}
 
 		// Check if the contract is empty. We define a contract as being empty
-		// if less than 'minContractFundRenewalThreshold' funds are remaining
-		// (3% at time of writing), or if there is less than 3 sectors worth of
+		// if less than the allowance's refresh threshold of funds are
+		// remaining (3% by default), or if there is less than 3 sectors worth of
 		// storage+upload+download remaining.
 		host, _ := c.hdb.Host(contract.HostPublicKey)
 		blockBytes := types.NewCurrency64(modules.SectorSize * uint64(allowance.Period))
@@ -726,7 +1110,7 @@ func (c *Contractor) threadedContractMaintenance() {
 		sectorBandwidthPrice := sectorUploadBandwidthPrice.Add(sectorDownloadBandwidthPrice)
 		sectorPrice := sectorStoragePrice.Add(sectorBandwidthPrice)
 		percentRemaining, _ := big.NewRat(0, 1).SetFrac(contract.RenterFunds.Big(), contract.TotalCost.Big()).Float64()
-		if contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0 || percentRemaining < minContractFundRenewalThreshold {
+		if contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0 || percentRemaining < refreshThreshold {
 			// Renew the contract with double the amount of funds that the
 			// contract had previously. The reason that we double the funding
 			// instead of doing anything more clever is that we don't know what
@@ -739,9 +1123,12 @@ func (c *Contractor) threadedContractMaintenance() {
 			// does mean that a larger percentage of funds get locked away from
 			// the user in the event that the user stops uploading immediately
 			// after the renew.
+			// The doubled funding is still subject to the maximum funds per
+			// contract.
 			refreshSet = append(refreshSet, fileContractRenewal{
-				id:     contract.ID,
-				amount: contract.TotalCost.Mul64(2),
+				id:      contract.ID,
+				amount:  capContractFunds(allowance, contract.TotalCost.Mul64(2)),
+				refresh: true,
 			})
 		}
 	}
@@ -773,6 +1160,22 @@ func (c *Contractor) threadedContractMaintenance() {
 		fundsRemaining = allowance.Funds.Sub(spending.TotalAllocated)
 	}
 
+	// If the wallet can't cover the remaining funds and the allowance allows
+	// partial funding, only the wallet balance is available and the funding
+	// of every contract is reduced by the same ratio. Once the wallet covers
+	// the remaining funds again, the contracts that were funded partially are
+	// refreshed towards their full funding.
+	fundsNeeded := fundsRemaining
+	fundsRemaining, funding := c.managedCheckFunding(allowance, fundsRemaining)
+	fundsAvailable := fundsRemaining
+	if !funding.Underfunded {
+		scheduled := make(map[types.FileContractID]struct{})
+		for _, r := range append(renewSet, refreshSet...) {
+			scheduled[r.id] = struct{}{}
+		}
+		refreshSet = append(refreshSet, c.managedUnderfundedRefreshes(allowance, scheduled)...)
+	}
+
 	// Go through the contracts we've assembled for renewal. Any contracts that
 	// need to be renewed because they are expiring (renewSet) get priority over
 	// contracts that need to be renewed because they have exhausted their funds
@@ -783,8 +1186,11 @@ func (c *Contractor) threadedContractMaintenance() {
 	// user to the fact that they do not have enough money to keep their
 	// contracts going in the event that we run out of funds.
 	for _, renewal := range renewSet {
+		target := renewal.amount
+		renewal.amount = scaleFunds(renewal.amount, fundsAvailable, fundsNeeded)
+
 		// Skip this renewal if we don't have enough funds remaining.
-		if renewal.amount.Cmp(fundsRemaining) > 0 {
+		if renewal.amount.IsZero() || renewal.amount.Cmp(fundsRemaining) > 0 {
 			continue
 		}
 
@@ -793,6 +1199,12 @@ func (c *Contractor) threadedContractMaintenance() {
 		// 'fundsSpent' will return '0'.
 		fundsSpent, _ := c.managedRenewContract(renewal, currentPeriod, allowance, blockHeight, endHeight)
 		fundsRemaining = fundsRemaining.Sub(fundsSpent)
+		if !fundsSpent.IsZero() {
+			c.mu.RLock()
+			newID := c.renewedTo[renewal.id]
+			c.mu.RUnlock()
+			c.managedUpdateUnderfunded(renewal.id, newID, renewal.amount, target)
+		}
 
 		// Return here if an interrupt or kill signal has been sent.
 		select {
@@ -803,17 +1215,45 @@ func (c *Contractor) threadedContractMaintenance() {
 		default:
 		}
 	}
+	// While transitioning to a new allowance, only a limited number of
+	// contracts is refreshed and formed per round, so that a change of the
+	// funds doesn't cause a burst of renewals. Contracts that are about to
+	// expire are always renewed, since the data stored with them would be
+	// lost otherwise.
+	c.mu.Lock()
+	c.setTransitionRefreshes(len(refreshSet))
+	transitioning := c.allowanceTransition.Active
+	c.mu.Unlock()
+	transitionBudget := maxTransitionContractsPerRound
 	for _, renewal := range refreshSet {
+		if transitioning && transitionBudget <= 0 {
+			break
+		}
+		target := renewal.amount
+		renewal.amount = scaleFunds(renewal.amount, fundsAvailable, fundsNeeded)
+
 		// Skip this renewal if we don't have enough funds remaining.
-		if renewal.amount.Cmp(fundsRemaining) > 0 {
+		if renewal.amount.IsZero() || renewal.amount.Cmp(fundsRemaining) > 0 {
 			continue
 		}
 
 		// Renew one contract. The error is ignored because the renew function
 		// already will have logged the error, and in the event of an error,
-		// 'fundsSpent' will return '0'.
+		// 'fundsSpent' will return '0'. Only successful refreshes count
+		// against the budget of the transition.
 		fundsSpent, _ := c.managedRenewContract(renewal, currentPeriod, allowance, blockHeight, endHeight)
 		fundsRemaining = fundsRemaining.Sub(fundsSpent)
+		if !fundsSpent.IsZero() {
+			transitionBudget--
+			c.mu.Lock()
+			newID := c.renewedTo[renewal.id]
+			finishedTransition := c.recordTransitionContract(true)
+			c.mu.Unlock()
+			c.managedUpdateUnderfunded(renewal.id, newID, renewal.amount, target)
+			if finishedTransition {
+				c.log.Println("INFO: contract set has converged to the new allowance")
+			}
+		}
 
 		// Return here if an interrupt or kill signal has been sent.
 		select {
@@ -825,20 +1265,54 @@ func (c *Contractor) threadedContractMaintenance() {
 		}
 	}
 
-	// Count the number of contracts which are good for uploading, and then make
-	// more as needed to fill the gap.
-	uploadContracts := 0
-	for _, id := range c.staticContracts.IDs() {
-		if cu, ok := c.managedContractUtility(id); ok && cu.GoodForUpload {
-			uploadContracts++
+	// Count the number of hosts with contracts which are good for uploading,
+	// and then make more as needed to fill the gap.
+	uploadHosts := make(map[string]struct{})
+	for _, contract := range c.staticContracts.ViewAll() {
+		if cu, ok := c.managedContractUtility(contract.ID); ok && cu.GoodForUpload {
+			uploadHosts[contract.HostPublicKey.String()] = struct{}{}
+		}
+	}
+	uploadContracts := len(uploadHosts)
+	c.mu.Lock()
+	neededContracts := int(contractsForAllowance(c.allowance)) - uploadContracts
+	finishedTransition := c.updateAllowanceTransition(neededContracts)
+	transitioning = c.allowanceTransition.Active
+	c.mu.Unlock()
+	if finishedTransition {
+		c.log.Println("INFO: contract set has converged to the new allowance")
+	}
+
+	// Form the missing parallel contracts with the existing hosts. The funds
+	// of the contracts that still need to be formed with new hosts are held
+	// back.
+	if contractsPerHost(allowance) > 1 {
+		initialFunds := scaleFunds(initialContractFunds(allowance), fundsAvailable, fundsNeeded)
+		reserved := types.ZeroCurrency
+		if neededContracts > 0 {
+			reserved = initialFunds.Mul64(uint64(neededContracts))
+		}
+		if !initialFunds.IsZero() && fundsRemaining.Cmp(reserved) > 0 {
+			fundsSpent, stopped := c.managedFormParallelContracts(allowance, fundsRemaining.Sub(reserved), initialFunds, endHeight)
+			fundsRemaining = fundsRemaining.Sub(fundsSpent)
+			if stopped {
+				return
+			}
 		}
 	}
-	c.mu.RLock()
-	neededContracts := int(c.allowance.Hosts) - uploadContracts
-	c.mu.RUnlock()
 	if neededContracts <= 0 {
 		return
 	}
+	// The contracts that can't be formed within the budget of this round
+	// will be formed during the following rounds of maintenance.
+	if transitioning {
+		if transitionBudget <= 0 {
+			return
+		}
+		if neededContracts > transitionBudget {
+			neededContracts = transitionBudget
+		}
+	}
 
 	// Assemble two exclusion lists. The first one includes all hosts that we
 	// already have contracts with and the second one includes all hosts we
@@ -854,7 +1328,12 @@ func (c *Contractor) threadedContractMaintenance() {
 			addressBlacklist = append(addressBlacklist, contract.HostPublicKey)
 		}
 	}
-	initialContractFunds := c.allowance.Funds.Div64(c.allowance.Hosts).Div64(3)
+	// Hosts that were replaced because they kept failing integrity checks
+	// are skipped even once their old contracts were archived.
+	blacklist = append(blacklist, c.integrityBannedHosts()...)
+	targetFunds := initialContractFunds(c.allowance)
+	initialFunds := scaleFunds(targetFunds, fundsAvailable, fundsNeeded)
+	diversify := c.allowance.DiversifyVersions
 	c.mu.RUnlock()
 	hosts, err := c.hdb.RandomHosts(neededContracts*2+randomHostsBufferForScore, blacklist, addressBlacklist)
 	if err != nil {
@@ -862,14 +1341,35 @@ func (c *Contractor) threadedContractMaintenance() {
 		return
 	}
 
+	// Prefer hosts running versions that are rare among the hosts of the
+	// contracts that are good for upload.
+	if diversify {
+		versions := make(map[string]int)
+		for _, contract := range allContracts {
+			if !contract.Utility.GoodForUpload {
+				continue
+			}
+			if host, ok := c.hdb.Host(contract.HostPublicKey); ok {
+				versions[host.Version]++
+			}
+		}
+		hosts = diversifyHostVersions(hosts, versions)
+	}
+
 	// Form contracts with the hosts one at a time, until we have enough
 	// contracts.
 	for _, host := range hosts {
-		// Determine if we have enough money to form a new contract.
-		if fundsRemaining.Cmp(initialContractFunds) < 0 {
+		// Determine if we have enough money to form a new contract. Aligned
+		// and staggered contracts are funded for their actual duration.
+		hostFunds := alignedContractFunds(initialFunds, allowance, blockHeight, endHeight, host.PublicKey)
+		if hostFunds.IsZero() || fundsRemaining.Cmp(hostFunds) < 0 {
 			c.log.Println("WARN: need to form new contracts, but unable to because of a low allowance")
 			break
 		}
+		// Skip hosts that recently missed too many storage proofs.
+		if host.RecentMissedProofs >= maxRecentMissedProofs {
+			continue
+		}
 
 		// If we are using a custom resolver we need to replace the domain name
 		// with 127.0.0.1 to be able to form contracts.
@@ -879,7 +1379,7 @@ func (c *Contractor) threadedContractMaintenance() {
 		}
 
 		// Attempt forming a contract with this host.
-		fundsSpent, newContract, err := c.managedNewContract(host, initialContractFunds, endHeight)
+		fundsSpent, newContract, err := c.managedNewContract(host, hostFunds, endHeight)
 		if err != nil {
 			c.log.Printf("Attempted to form a contract with %v, but negotiation failed: %v\n", host.NetAddress, err)
 			continue
@@ -895,12 +1395,17 @@ func (c *Contractor) threadedContractMaintenance() {
 			c.log.Println("Failed to update the contract utilities", err)
 			return
 		}
+		c.managedUpdateUnderfunded(types.FileContractID{}, newContract.ID, hostFunds, alignedContractFunds(targetFunds, allowance, blockHeight, endHeight, host.PublicKey))
 		c.mu.Lock()
+		finishedTransition := c.recordTransitionContract(false)
 		err = c.saveSync()
 		c.mu.Unlock()
 		if err != nil {
 			c.log.Println("Unable to save the contractor:", err)
 		}
+		if finishedTransition {
+			c.log.Println("INFO: contract set has converged to the new allowance")
+		}
 
 		// Quit the loop if we've replaced all needed contracts.
 		neededContracts--
@@ -38,11 +38,53 @@ type Contractor struct {
 	interruptMaintenance chan struct{}
 	maintenanceLock      siasync.TryMutex
 
+	// Only one integrity scan should run at a time. integrityFailures counts
+	// the consecutive failed integrity scans of every host, keyed by the
+	// string of its public key. integrityReplacements contains the hosts
+	// whose contracts were replaced because of failed integrity scans, they
+	// are skipped when forming new contracts.
+	integrityFailures     map[string]int
+	integrityReplacements map[string]integrityReplacement
+	integrityScanLock     siasync.TryMutex
+	lastIntegrityScan     types.BlockHeight
+
+	// Only one round of capacity challenges should run at a time.
+	capacityProofLock siasync.TryMutex
+
 	allowance     modules.Allowance
 	blockHeight   types.BlockHeight
 	currentPeriod types.BlockHeight
 	lastChange    modules.ConsensusChangeID
 
+	// allowanceTransition tracks the progress of the contract set towards
+	// the most recently set allowance.
+	allowanceTransition modules.AllowanceTransition
+
+	// maintenancePaused prevents the contractor from forming, renewing and
+	// replacing contracts until maintenance is resumed.
+	maintenancePaused bool
+
+	// topUpErr is the reason why the most recent automatic top-up of the
+	// allowance failed.
+	topUpErr error
+
+	// onFundingChecked is called after the contractor checked whether the
+	// allowance needs a top-up and whether the wallet covers the allowance.
+	onFundingChecked func()
+
+	// funding is the result of the most recent comparison of the wallet
+	// balance to the unallocated funds of the allowance.
+	// underfundedContracts maps the contracts that were funded partially to
+	// the funding they would have had otherwise.
+	funding              modules.ContractorFunding
+	underfundedContracts map[types.FileContractID]types.Currency
+
+	// trial is the trial the contractor is running, if any.
+	trial modules.RenterTrial
+
+	// webhooks are notified of contract lifecycle events.
+	webhooks []modules.RenterWebhook
+
 	downloaders         map[types.FileContractID]*hostDownloader
 	editors             map[types.FileContractID]*hostEditor
 	numFailedRenews     map[types.FileContractID]types.BlockHeight
@@ -50,6 +92,11 @@ type Contractor struct {
 	contractIDToPubKey  map[types.FileContractID]types.SiaPublicKey
 	renewing            map[types.FileContractID]bool // prevent revising during renewal
 
+	// parallelContracts are the additional contracts formed with hosts that
+	// the contractor already has a contract with. pubKeysToContractID never
+	// maps to a parallel contract.
+	parallelContracts map[types.FileContractID]struct{}
+
 	// renewedFrom links the new contract's ID to the old contract's ID
 	// renewedTo links the old contract's ID to the new contract's ID
 	staticContracts *proto.ContractSet
@@ -65,6 +112,44 @@ func (c *Contractor) Allowance() modules.Allowance {
 	return c.allowance
 }
 
+// MaintenancePaused returns whether contract maintenance is paused.
+func (c *Contractor) MaintenancePaused() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.maintenancePaused
+}
+
+// SetMaintenancePaused pauses or resumes contract maintenance. Pausing waits
+// for a running round of maintenance to reach a point where it can stop
+// safely. Resuming starts a new round right away.
+func (c *Contractor) SetMaintenancePaused(paused bool) error {
+	if err := c.tg.Add(); err != nil {
+		return err
+	}
+	defer c.tg.Done()
+
+	c.mu.Lock()
+	if c.maintenancePaused == paused {
+		c.mu.Unlock()
+		return nil
+	}
+	c.maintenancePaused = paused
+	err := c.saveSync()
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if paused {
+		c.managedInterruptContractMaintenance()
+		c.log.Println("INFO: contract maintenance paused")
+		return nil
+	}
+	c.log.Println("INFO: contract maintenance resumed")
+	go c.threadedContractMaintenance()
+	return nil
+}
+
 // PeriodSpending returns the amount spent on contracts during the current
 // billing period.
 func (c *Contractor) PeriodSpending() modules.ContractorSpending {
@@ -205,17 +290,22 @@ func NewCustomContractor(cs consensusSet, w wallet, tp transactionPool, hdb host
 		tpool:      tp,
 		wallet:     w,
 
-		interruptMaintenance: make(chan struct{}),
+		interruptMaintenance:  make(chan struct{}),
+		integrityFailures:     make(map[string]int),
+		integrityReplacements: make(map[string]integrityReplacement),
 
 		staticContracts:     contractSet,
 		downloaders:         make(map[types.FileContractID]*hostDownloader),
 		editors:             make(map[types.FileContractID]*hostEditor),
 		oldContracts:        make(map[types.FileContractID]modules.RenterContract),
 		contractIDToPubKey:  make(map[types.FileContractID]types.SiaPublicKey),
+		parallelContracts:   make(map[types.FileContractID]struct{}),
 		pubKeysToContractID: make(map[string]types.FileContractID),
 		renewing:            make(map[types.FileContractID]bool),
 		renewedFrom:         make(map[types.FileContractID]types.FileContractID),
 		renewedTo:           make(map[types.FileContractID]types.FileContractID),
+
+		underfundedContracts: make(map[types.FileContractID]types.Currency),
 	}
 
 	// Close the contract set and logger upon shutdown.
@@ -234,20 +324,31 @@ func NewCustomContractor(cs consensusSet, w wallet, tp transactionPool, hdb host
 		return nil, err
 	}
 
-	// Subscribe to the consensus set.
-	err = cs.ConsensusSetSubscribe(c, c.lastChange, c.tg.StopChan())
+	// Subscribe to the consensus set. A consensus set in spv mode only sends
+	// header changes.
+	subscribe := func() error {
+		if cs.SpvMode() {
+			return cs.HeaderConsensusSetSubscribe(c, c.lastChange, c.tg.StopChan())
+		}
+		return cs.ConsensusSetSubscribe(c, c.lastChange, c.tg.StopChan())
+	}
+	err = subscribe()
 	if err == modules.ErrInvalidConsensusChangeID {
 		// Reset the contractor consensus variables and try rescanning.
 		c.blockHeight = 0
 		c.lastChange = modules.ConsensusChangeBeginning
-		err = cs.ConsensusSetSubscribe(c, c.lastChange, c.tg.StopChan())
+		err = subscribe()
 	}
 	if err != nil {
 		return nil, errors.New("contractor subscription failed: " + err.Error())
 	}
 	// Unsubscribe from the consensus set upon shutdown.
 	c.tg.OnStop(func() {
-		cs.Unsubscribe(c)
+		if cs.SpvMode() {
+			cs.HeaderUnsubscribe(c)
+		} else {
+			cs.Unsubscribe(c)
+		}
 	})
 
 	// We may have upgraded persist or resubscribed. Save now so that we don't
@@ -262,11 +363,15 @@ func NewCustomContractor(cs consensusSet, w wallet, tp transactionPool, hdb host
 	// Initialize the contractIDToPubKey map
 	for _, contract := range c.oldContracts {
 		c.contractIDToPubKey[contract.ID] = contract.HostPublicKey
-		c.pubKeysToContractID[string(contract.HostPublicKey.Key)] = contract.ID
+		if _, parallel := c.parallelContracts[contract.ID]; !parallel {
+			c.pubKeysToContractID[string(contract.HostPublicKey.Key)] = contract.ID
+		}
 	}
 	for _, contract := range c.staticContracts.ViewAll() {
 		c.contractIDToPubKey[contract.ID] = contract.HostPublicKey
-		c.pubKeysToContractID[string(contract.HostPublicKey.Key)] = contract.ID
+		if _, parallel := c.parallelContracts[contract.ID]; !parallel {
+			c.pubKeysToContractID[string(contract.HostPublicKey.Key)] = contract.ID
+		}
 	}
 
 	return c, nil
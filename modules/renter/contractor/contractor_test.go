@@ -20,10 +20,16 @@ type newStub struct{}
 func (newStub) ConsensusSetSubscribe(modules.ConsensusSetSubscriber, modules.ConsensusChangeID, <-chan struct{}) error {
 	return nil
 }
-func (newStub) Synced() bool                               { return true }
-func (newStub) Unsubscribe(modules.ConsensusSetSubscriber) { return }
+func (newStub) HeaderConsensusSetSubscribe(modules.HeaderConsensusSetSubscriber, modules.ConsensusChangeID, <-chan struct{}) error {
+	return nil
+}
+func (newStub) HeaderUnsubscribe(modules.HeaderConsensusSetSubscriber) { return }
+func (newStub) SpvMode() bool                                          { return false }
+func (newStub) Synced() bool                                           { return true }
+func (newStub) Unsubscribe(modules.ConsensusSetSubscriber)             { return }
 
 // wallet stubs
+func (newStub) ConfirmedBalance() (b types.Currency, err error)              { return }
 func (newStub) NextAddress() (uc types.UnlockConditions, err error)          { return }
 func (newStub) StartTransaction() (tb modules.TransactionBuilder, err error) { return }
 
@@ -41,6 +47,7 @@ func (newStub) IncrementFailedInteractions(key types.SiaPublicKey)
 func (newStub) RandomHosts(int, []types.SiaPublicKey, []types.SiaPublicKey) ([]modules.HostDBEntry, error) {
 	return nil, nil
 }
+func (newStub) RecordCapacityProof(types.SiaPublicKey, bool, bool) { return }
 func (newStub) ScoreBreakdown(modules.HostDBEntry) modules.HostScoreBreakdown {
 	return modules.HostScoreBreakdown{}
 }
@@ -103,6 +110,68 @@ func TestAllowance(t *testing.T) {
 	}
 }
 
+// TestAllowanceTransition tests the progress reporting of an allowance
+// transition.
+func TestAllowanceTransition(t *testing.T) {
+	c := &Contractor{}
+
+	// Without an active transition the progress should be complete.
+	if p := c.AllowanceTransition().Progress; p != 1 {
+		t.Fatal("expected progress of 1 but got", p)
+	}
+
+	// A transition that wasn't measured yet hasn't made any progress.
+	c.allowanceTransition = modules.AllowanceTransition{Active: true}
+	if p := c.AllowanceTransition().Progress; p != 0 {
+		t.Fatal("expected progress of 0 but got", p)
+	}
+
+	// Start a transition which still needs 3 contracts and 1 refresh.
+	c.setTransitionRefreshes(1)
+	if c.updateAllowanceTransition(3) {
+		t.Fatal("transition shouldn't be finished yet")
+	}
+	if p := c.AllowanceTransition().Progress; p != 0 {
+		t.Fatal("expected progress of 0 but got", p)
+	}
+
+	// Refresh one contract and form two.
+	if c.recordTransitionContract(true) {
+		t.Fatal("transition shouldn't be finished yet")
+	}
+	if p := c.AllowanceTransition().Progress; p != 0.25 {
+		t.Fatal("expected progress of 0.25 but got", p)
+	}
+	c.recordTransitionContract(false)
+	c.recordTransitionContract(false)
+	if p := c.AllowanceTransition().Progress; p != 0.75 {
+		t.Fatal("expected progress of 0.75 but got", p)
+	}
+
+	// The transition is finished once the last contract is formed.
+	if !c.recordTransitionContract(false) {
+		t.Fatal("transition should be finished")
+	}
+	transition := c.AllowanceTransition()
+	if transition.Active || transition.Progress != 1 || transition.ContractsNeeded != 0 || transition.ContractsFormed != 3 || transition.ContractsRefreshed != 1 {
+		t.Fatal("transition wasn't finished correctly:", transition)
+	}
+	if c.updateAllowanceTransition(0) || c.recordTransitionContract(false) {
+		t.Fatal("an inactive transition can't be finished again")
+	}
+
+	// Pending refreshes keep the transition active.
+	c.allowanceTransition = modules.AllowanceTransition{Active: true}
+	c.setTransitionRefreshes(2)
+	if c.updateAllowanceTransition(0) {
+		t.Fatal("transition with pending refreshes shouldn't be finished")
+	}
+	c.setTransitionRefreshes(0)
+	if !c.updateAllowanceTransition(0) {
+		t.Fatal("transition should be finished")
+	}
+}
+
 // stubHostDB mocks the hostDB dependency using zero-valued implementations of
 // its methods.
 type stubHostDB struct{}
@@ -117,6 +186,7 @@ func (stubHostDB) PublicKey() (spk types.SiaPublicKey)
 func (stubHostDB) RandomHosts(int, []types.SiaPublicKey, []types.SiaPublicKey) (hs []modules.HostDBEntry, _ error) {
 	return
 }
+func (stubHostDB) RecordCapacityProof(types.SiaPublicKey, bool, bool) { return }
 func (stubHostDB) ScoreBreakdown(modules.HostDBEntry) modules.HostScoreBreakdown {
 	return modules.HostScoreBreakdown{}
 }
@@ -319,10 +389,29 @@ func TestIntegrationSetAllowance(t *testing.T) {
 	if err != errAllowanceWindowSize {
 		t.Errorf("expected %q, got %q", errAllowanceWindowSize, err)
 	}
+	a.RenewWindow = 10
+	a.MinHostsPerChunk = 2
+	err = c.SetAllowance(a)
+	if err != errAllowanceMinHosts {
+		t.Errorf("expected %q, got %q", errAllowanceMinHosts, err)
+	}
+	a.MinHostsPerChunk = 1
+	a.RenewalBias = maxRenewalBias + 1
+	err = c.SetAllowance(a)
+	if err != errAllowanceBias {
+		t.Errorf("expected %q, got %q", errAllowanceBias, err)
+	}
+	a.RenewalBias = 0
+	a.RefreshThreshold = 1
+	err = c.SetAllowance(a)
+	if err != errAllowanceRefresh {
+		t.Errorf("expected %q, got %q", errAllowanceRefresh, err)
+	}
+	a.RefreshThreshold = 0
 
 	// reasonable values; should succeed
 	a.Funds = types.SiacoinPrecision.Mul64(100)
-	a.RenewWindow = 10
+	a.MinHostsPerChunk = 1
 	err = c.SetAllowance(a)
 	if err != nil {
 		t.Fatal(err)
@@ -641,6 +730,9 @@ type testWalletShim struct {
 
 // These stub implementations for the walletShim interface set their respective
 // booleans to true, allowing tests to verify that they have been called.
+func (ws *testWalletShim) ConfirmedBalance() (types.Currency, error) {
+	return types.ZeroCurrency, nil
+}
 func (ws *testWalletShim) NextAddress() (types.UnlockConditions, error) {
 	ws.nextAddressCalled = true
 	return types.UnlockConditions{}, nil
@@ -663,3 +755,490 @@ func TestWalletBridge(t *testing.T) {
 		t.Error("StartTransaction was not called on the shim")
 	}
 }
+
+// TestRecordIntegrityResult checks that hosts are only replaced after failing
+// enough consecutive integrity checks.
+func TestRecordIntegrityResult(t *testing.T) {
+	c := &Contractor{
+		integrityFailures: make(map[string]int),
+	}
+	hostKey := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	for i := 0; i < integrityFailuresBeforeReplacement-1; i++ {
+		if c.managedRecordIntegrityResult(hostKey, true) {
+			t.Fatal("host shouldn't be replaced yet")
+		}
+	}
+	// A successful check resets the counter.
+	if c.managedRecordIntegrityResult(hostKey, false) {
+		t.Fatal("host shouldn't be replaced after a successful check")
+	}
+	if c.integrityFailures[hostKey.String()] != 0 {
+		t.Fatal("failures should have been reset")
+	}
+	for i := 0; i < integrityFailuresBeforeReplacement-1; i++ {
+		c.managedRecordIntegrityResult(hostKey, true)
+	}
+	if !c.managedRecordIntegrityResult(hostKey, true) {
+		t.Fatal("host should be replaced")
+	}
+}
+
+// TestMaintenancePaused checks that pausing contract maintenance survives a
+// restart of the contractor.
+func TestMaintenancePaused(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	var stub newStub
+	dir := build.TempDir("contractor", t.Name())
+	c, err := New(stub, stub, stub, stub, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.MaintenancePaused() {
+		t.Fatal("maintenance shouldn't be paused by default")
+	}
+	if err := c.SetMaintenancePaused(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = New(stub, stub, stub, stub, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.MaintenancePaused() {
+		t.Fatal("paused state wasn't persisted")
+	}
+	if err := c.SetMaintenancePaused(false); err != nil {
+		t.Fatal(err)
+	}
+	if c.MaintenancePaused() {
+		t.Fatal("maintenance should have been resumed")
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRenewalScoreFactor checks that the score factor of existing contracts
+// only applies to allowances that prefer renewals and that it grows with the
+// fees of forming new contracts.
+func TestRenewalScoreFactor(t *testing.T) {
+	a := modules.Allowance{
+		Funds: types.SiacoinPrecision.Mul64(300),
+		Hosts: 10,
+	}
+	hosts := []modules.HostDBEntry{{}, {}}
+	if f := renewalScoreFactor(a, hosts, types.ZeroCurrency); f != 1 {
+		t.Fatal("allowance without preference for renewals should have a factor of 1, got", f)
+	}
+
+	// Without any fees the factor only depends on the bias.
+	a.PreferRenewal = true
+	if f := renewalScoreFactor(a, hosts, types.ZeroCurrency); f != 1+defaultRenewalBias {
+		t.Fatal("expected the default bias to be used, got", f)
+	}
+	a.RenewalBias = 2
+	if f := renewalScoreFactor(a, hosts, types.ZeroCurrency); f != 3 {
+		t.Fatal("expected a factor of 3, got", f)
+	}
+
+	// Each contract starts with 10 SC, so a contract price of 1 SC and a
+	// transaction fee of 1 SC spend a fifth of the funds on fees.
+	hosts[0].ContractPrice = types.SiacoinPrecision
+	hosts[1].ContractPrice = types.SiacoinPrecision
+	if f := renewalScoreFactor(a, hosts, types.SiacoinPrecision); f < 3.39 || f > 3.41 {
+		t.Fatal("expected a factor of 3.4, got", f)
+	}
+}
+
+// TestDiversifyHostVersions checks that hosts running rare versions are
+// preferred while the order of equally rare versions is kept.
+func TestDiversifyHostVersions(t *testing.T) {
+	host := func(version string, port string) modules.HostDBEntry {
+		var h modules.HostDBEntry
+		h.Version = version
+		h.NetAddress = modules.NetAddress("127.0.0.1:" + port)
+		return h
+	}
+	hosts := []modules.HostDBEntry{
+		host("1.0", "1"),
+		host("1.0", "2"),
+		host("1.1", "3"),
+		host("1.0", "4"),
+		host("1.2", "5"),
+	}
+	// The renter already has a contract with a host running 1.2.
+	ordered := diversifyHostVersions(hosts, map[string]int{"1.2": 1})
+	expected := []string{"1", "3", "2", "5", "4"}
+	if len(ordered) != len(expected) {
+		t.Fatal("hosts were lost", ordered)
+	}
+	for i, h := range ordered {
+		if h.NetAddress.Port() != expected[i] {
+			t.Fatalf("host %v should be %v but was %v", i, expected[i], h.NetAddress.Port())
+		}
+	}
+	if hosts[1].NetAddress.Port() != "2" {
+		t.Fatal("input was modified")
+	}
+}
+
+// TestMaxFundsPerContract checks that a cap on the funds per contract spreads
+// the allowance across more contracts and clamps the funds of every contract.
+func TestMaxFundsPerContract(t *testing.T) {
+	a := modules.Allowance{
+		Funds: types.SiacoinPrecision.Mul64(300),
+		Hosts: 10,
+	}
+	if n := contractsForAllowance(a); n != 10 {
+		t.Fatal("allowance without a cap should form a contract per host, got", n)
+	}
+	if funds := initialContractFunds(a); !funds.Equals(types.SiacoinPrecision.Mul64(10)) {
+		t.Fatal("expected contracts to start with 10 SC, got", funds)
+	}
+
+	// A cap above the funds of a contract doesn't change anything.
+	a.MaxFundsPerContract = types.SiacoinPrecision.Mul64(20)
+	if n := contractsForAllowance(a); n != 10 {
+		t.Fatal("cap above the contract funds shouldn't add contracts, got", n)
+	}
+
+	// A cap of 4 SC needs 100 SC / 4 SC = 25 contracts to spend the initial
+	// funding. A cap of 7 SC needs 15 contracts after rounding up.
+	a.MaxFundsPerContract = types.SiacoinPrecision.Mul64(4)
+	if n := contractsForAllowance(a); n != 25 {
+		t.Fatal("expected 25 contracts, got", n)
+	}
+	if funds := initialContractFunds(a); !funds.Equals(types.SiacoinPrecision.Mul64(4)) {
+		t.Fatal("expected contracts to start with 4 SC, got", funds)
+	}
+	a.MaxFundsPerContract = types.SiacoinPrecision.Mul64(7)
+	if n := contractsForAllowance(a); n != 15 {
+		t.Fatal("expected 15 contracts, got", n)
+	}
+	if funds := initialContractFunds(a); funds.Cmp(a.MaxFundsPerContract) > 0 {
+		t.Fatal("initial funds exceed the cap", funds)
+	}
+	if funds := capContractFunds(a, types.SiacoinPrecision.Mul64(50)); !funds.Equals(a.MaxFundsPerContract) {
+		t.Fatal("renewal funds should be capped, got", funds)
+	}
+}
+
+// TestParallelContracts checks that parallel contracts split the funds of
+// their host and that the newest parallel contracts are the first to go when
+// the allowance asks for fewer contracts per host.
+func TestParallelContracts(t *testing.T) {
+	a := modules.Allowance{
+		Funds:            types.SiacoinPrecision.Mul64(300),
+		Hosts:            10,
+		ContractsPerHost: 2,
+	}
+	if n := contractsForAllowance(a); n != 10 {
+		t.Fatal("parallel contracts shouldn't add hosts, got", n)
+	}
+	if funds := initialContractFunds(a); !funds.Equals(types.SiacoinPrecision.Mul64(5)) {
+		t.Fatal("expected contracts to start with 5 SC, got", funds)
+	}
+
+	host := types.SiaPublicKey{Key: []byte{1}}
+	other := types.SiaPublicKey{Key: []byte{2}}
+	contracts := []modules.RenterContract{
+		{ID: types.FileContractID{1}, HostPublicKey: host, StartHeight: 10},
+		{ID: types.FileContractID{2}, HostPublicKey: host, StartHeight: 30},
+		{ID: types.FileContractID{3}, HostPublicKey: host, StartHeight: 20},
+		{ID: types.FileContractID{4}, HostPublicKey: other, StartHeight: 20},
+		{ID: types.FileContractID{5}, HostPublicKey: other, StartHeight: 10, Utility: modules.ContractUtility{Locked: true}},
+	}
+	parallel := map[types.FileContractID]struct{}{
+		{2}: {},
+		{3}: {},
+		{4}: {},
+		{5}: {},
+	}
+	// Contract 1 is the regular contract of the host, 3 is its oldest parallel
+	// contract. The cancelled contract 5 doesn't count.
+	excess := excessParallelContracts(contracts, parallel, 2)
+	if len(excess) != 1 {
+		t.Fatal("expected one excess contract, got", excess)
+	}
+	if _, exists := excess[types.FileContractID{2}]; !exists {
+		t.Fatal("the newest parallel contract should be excess", excess)
+	}
+	if excess := excessParallelContracts(contracts, parallel, 1); len(excess) != 3 {
+		t.Fatal("every active parallel contract should be excess, got", excess)
+	}
+}
+
+// TestRecommendAllowance checks that the cheapest suitable hosts are picked
+// for the recommendation and that the costs add up.
+func TestRecommendAllowance(t *testing.T) {
+	host := func(storagePrice uint64) modules.HostDBEntry {
+		var h modules.HostDBEntry
+		h.AcceptingContracts = true
+		h.MaxDuration = 100
+		h.RemainingStorage = 1e6
+		h.StoragePrice = types.NewCurrency64(storagePrice)
+		h.UploadBandwidthPrice = types.NewCurrency64(2)
+		h.ContractPrice = types.NewCurrency64(1000)
+		return h
+	}
+	// The cheapest host doesn't accept contracts and the second cheapest
+	// doesn't have enough storage.
+	candidates := []modules.HostDBEntry{host(4), host(1), host(2), host(3), host(5)}
+	candidates[1].AcceptingContracts = false
+	candidates[2].RemainingStorage = 10
+
+	rec, err := recommendAllowance(candidates, 100, 2, 10, types.NewCurrency64(500))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Hosts 3 and 4 are used: storage is (3+4)*100*10, upload 2*2*100.
+	if !rec.StorageCost.Equals64(7000) || !rec.UploadCost.Equals64(400) || !rec.ContractFees.Equals64(2000) || !rec.TransactionFees.Equals64(1000) {
+		t.Fatal("unexpected breakdown", rec)
+	}
+	if !rec.Margin.Equals64(3466) || !rec.Funds.Equals64(13866) {
+		t.Fatal("unexpected funds", rec.Funds, rec.Margin)
+	}
+
+	if _, err := recommendAllowance(candidates, 100, 4, 10, types.ZeroCurrency); err == nil {
+		t.Fatal("expected an error if there aren't enough suitable hosts")
+	}
+	if _, err := recommendAllowance(candidates, 100, 2, 1000, types.ZeroCurrency); err == nil {
+		t.Fatal("expected an error if no host accepts the period")
+	}
+}
+
+// TestTopUpAmount checks that the allowance is only topped up below the
+// threshold and never above the maximum funds.
+func TestTopUpAmount(t *testing.T) {
+	a := modules.Allowance{
+		Funds:          types.NewCurrency64(100),
+		AutoTopUp:      true,
+		TopUpAmount:    types.NewCurrency64(30),
+		TopUpMaxFunds:  types.NewCurrency64(120),
+		TopUpThreshold: types.NewCurrency64(10),
+	}
+	if amount := topUpAmount(a, types.NewCurrency64(10)); !amount.IsZero() {
+		t.Fatal("allowance shouldn't be topped up at the threshold", amount)
+	}
+	if amount := topUpAmount(a, types.NewCurrency64(9)); !amount.Equals(types.NewCurrency64(20)) {
+		t.Fatal("top-up should be limited by the maximum funds", amount)
+	}
+	a.TopUpMaxFunds = types.NewCurrency64(200)
+	if amount := topUpAmount(a, types.ZeroCurrency); !amount.Equals(a.TopUpAmount) {
+		t.Fatal("expected a top-up of the full amount", amount)
+	}
+	a.Funds = a.TopUpMaxFunds
+	if amount := topUpAmount(a, types.ZeroCurrency); !amount.IsZero() {
+		t.Fatal("allowance shouldn't be topped up beyond the maximum funds", amount)
+	}
+	a.Funds, a.AutoTopUp = types.NewCurrency64(100), false
+	if amount := topUpAmount(a, types.ZeroCurrency); !amount.IsZero() {
+		t.Fatal("allowance shouldn't be topped up if automatic top-ups are disabled", amount)
+	}
+}
+
+// TestScaleFunds checks that the funds of contracts are reduced by the ratio
+// of the available funds to the needed funds, and that the reduced funds never
+// add up to more than the available funds.
+func TestScaleFunds(t *testing.T) {
+	needed := types.NewCurrency64(300)
+	if funds := scaleFunds(types.NewCurrency64(100), needed, needed); !funds.Equals64(100) {
+		t.Fatal("funds shouldn't be reduced if the needed funds are available", funds)
+	}
+	if funds := scaleFunds(types.NewCurrency64(100), types.NewCurrency64(400), needed); !funds.Equals64(100) {
+		t.Fatal("funds shouldn't be raised above the regular funding", funds)
+	}
+	if funds := scaleFunds(types.NewCurrency64(100), types.ZeroCurrency, types.ZeroCurrency); !funds.Equals64(100) {
+		t.Fatal("funds shouldn't be reduced if no funds are needed", funds)
+	}
+
+	// Split the needed funds over three contracts with a third of the funds
+	// available.
+	available := types.NewCurrency64(100)
+	total := types.ZeroCurrency
+	for _, amount := range []uint64{100, 150, 50} {
+		funds := scaleFunds(types.NewCurrency64(amount), available, needed)
+		if !funds.Equals64(amount / 3) {
+			t.Fatal("expected funds of", amount/3, "got", funds)
+		}
+		total = total.Add(funds)
+	}
+	if total.Cmp(available) > 0 {
+		t.Fatal("scaled funds exceed the available funds", total)
+	}
+	if funds := scaleFunds(types.NewCurrency64(100), types.NewCurrency64(1), needed); !funds.IsZero() {
+		t.Fatal("expected funds to be rounded down", funds)
+	}
+}
+
+// TestRenewHeight checks that contracts are due for renewal once the renew
+// window reaches their end height.
+func TestRenewHeight(t *testing.T) {
+	tests := []struct {
+		endHeight, renewWindow, want types.BlockHeight
+	}{
+		{100, 20, 80},
+		{100, 0, 100},
+		{100, 100, 0},
+		{10, 20, 0},
+	}
+	for _, test := range tests {
+		if got := renewHeight(test.endHeight, test.renewWindow); got != test.want {
+			t.Errorf("renewHeight(%v, %v): expected %v, got %v", test.endHeight, test.renewWindow, test.want, got)
+		}
+	}
+}
+
+// TestTrialExpired checks that a trial expires once its contracts enter the
+// renew window of the trial allowance.
+func TestTrialExpired(t *testing.T) {
+	a := trialAllowance(types.SiacoinPrecision)
+	if a.RenewWindow == 0 || a.RenewWindow >= a.Period {
+		t.Fatal("trial allowance has an invalid renew window", a)
+	}
+	c := &Contractor{
+		trial: modules.RenterTrial{
+			Active:      true,
+			Allowance:   a,
+			StartHeight: 100,
+			EndHeight:   100 + a.Period,
+		},
+	}
+	c.blockHeight = c.trial.EndHeight - a.RenewWindow - 1
+	if c.managedTrialExpired() {
+		t.Fatal("trial expired before its contracts are due for renewal")
+	}
+	c.blockHeight++
+	if !c.managedTrialExpired() {
+		t.Fatal("trial didn't expire once its contracts are due for renewal")
+	}
+	c.trial.Active = false
+	if c.managedTrialExpired() {
+		t.Fatal("inactive trial expired")
+	}
+}
+
+// TestEndHeightAlignment checks that the end heights of contracts are aligned
+// and staggered, and that their funding covers their actual duration.
+func TestEndHeightAlignment(t *testing.T) {
+	if h := alignEndHeight(1234, 0); h != 1234 {
+		t.Fatal("end height without alignment should be unchanged, got", h)
+	}
+	if h := alignEndHeight(1234, 1000); h != 2000 {
+		t.Fatal("end height should be rounded up to the alignment, got", h)
+	}
+	if h := alignEndHeight(2000, 1000); h != 2000 {
+		t.Fatal("aligned end height should be unchanged, got", h)
+	}
+
+	// The stagger of a host should be deterministic and within the window.
+	hpk := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte("foo")}
+	if h := staggerEndHeight(2000, 0, hpk); h != 2000 {
+		t.Fatal("end height without stagger should be unchanged, got", h)
+	}
+	h := staggerEndHeight(2000, 100, hpk)
+	if h < 2000 || h >= 2100 {
+		t.Fatal("staggered end height outside of the stagger window:", h)
+	} else if h != staggerEndHeight(2000, 100, hpk) {
+		t.Fatal("stagger of a host should be deterministic")
+	}
+
+	// The funded period should be scaled by the duration of the contract.
+	a := modules.Allowance{Period: 100, RenewWindow: 50}
+	if p := fundedPeriod(a, 0, 300); p != a.Period {
+		t.Fatal("contracts without alignment should be funded for one period, got", p)
+	}
+	a.EndHeightAlignment = 100
+	if p := fundedPeriod(a, 0, 300); p != 200 {
+		t.Fatal("contract lasting twice as long should be funded for two periods, got", p)
+	}
+	if p := fundedPeriod(a, 300, 300); p != a.Period {
+		t.Fatal("expired contract should be funded for one period, got", p)
+	}
+
+	// New contracts get the funds of their funded period.
+	funds := types.NewCurrency64(1000)
+	if f := alignedContractFunds(funds, a, 0, 300, hpk); !f.Equals(types.NewCurrency64(2000)) {
+		t.Fatal("contract lasting twice as long should get twice the funds, got", f)
+	}
+	a.EndHeightAlignment = 0
+	if f := alignedContractFunds(funds, a, 0, 300, hpk); !f.Equals(funds) {
+		t.Fatal("contract without alignment should get the funds of one period, got", f)
+	}
+
+	// Hosts need to accept the full duration of the contract, not just the
+	// period.
+	host := modules.HostDBEntry{}
+	host.MaxDuration = a.Period
+	if d := contractDuration(100, 300); d != 200 {
+		t.Fatal("wrong contract duration", d)
+	} else if d := contractDuration(300, 100); d != 0 {
+		t.Fatal("ended contract should have no duration", d)
+	}
+	if err := checkHostSettings(host, contractDuration(0, 150)); err == nil {
+		t.Fatal("host with a MaxDuration shorter than the contract should be rejected")
+	}
+	if err := checkHostSettings(host, contractDuration(50, 150)); err != nil {
+		t.Fatal(err)
+	}
+	host.StoragePrice = maxStoragePrice.Add(types.NewCurrency64(1))
+	if err := checkHostSettings(host, contractDuration(50, 150)); err != errTooExpensive {
+		t.Fatal("expected errTooExpensive, got", err)
+	}
+}
+
+// TestIntegrityBannedHosts checks that replaced hosts are skipped for
+// integrityReplacementBan blocks and that the replacements are persisted.
+func TestIntegrityBannedHosts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	var stub newStub
+	dir := build.TempDir("contractor", t.Name())
+	c, err := New(stub, stub, stub, stub, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hostKey := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	if err := c.managedRecordIntegrityReplacement(hostKey); err != nil {
+		t.Fatal(err)
+	}
+	if banned := c.integrityBannedHosts(); len(banned) != 1 || banned[0].String() != hostKey.String() {
+		t.Fatal("replaced host should be banned", banned)
+	}
+
+	// The replacement survives a restart.
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	c, err = New(stub, stub, stub, stub, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if banned := c.integrityBannedHosts(); len(banned) != 1 {
+		t.Fatal("replacement wasn't persisted", banned)
+	}
+
+	// The ban expires and the expired record is pruned with the next
+	// replacement.
+	c.mu.Lock()
+	c.blockHeight += integrityReplacementBan
+	c.mu.Unlock()
+	if banned := c.integrityBannedHosts(); len(banned) != 0 {
+		t.Fatal("ban should have expired", banned)
+	}
+	otherKey := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{2}}
+	if err := c.managedRecordIntegrityReplacement(otherKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := c.integrityReplacements[hostKey.String()]; exists || len(c.integrityReplacements) != 1 {
+		t.Fatal("expired replacement wasn't pruned", c.integrityReplacements)
+	}
+}
@@ -1,14 +1,31 @@
 package contractor
 
 import (
+	"errors"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
 	"github.com/HyperspaceApp/Hyperspace/modules"
 	"github.com/HyperspaceApp/Hyperspace/types"
 )
 
+var (
+	// errContractNotFound is returned if an operation refers to a contract
+	// that isn't part of the active contract set.
+	errContractNotFound = errors.New("no active contract with that id")
+
+	// errNoHostContracts is returned when cancelling the contracts of a host
+	// that the contractor has no active contracts with.
+	errNoHostContracts = errors.New("no active contracts with that host")
+)
+
 // contractEndHeight returns the height at which the Contractor's contracts
-// end. If there are no contracts, it returns zero.
+// end. If there are no contracts, it returns zero. If the allowance aligns the
+// end heights, the height is rounded up to the next multiple of the
+// alignment. The contracts of a host end up to RenewStagger blocks later, see
+// staggerEndHeight.
 func (c *Contractor) contractEndHeight() types.BlockHeight {
-	return c.currentPeriod + c.allowance.Period + c.allowance.RenewWindow
+	return alignEndHeight(c.currentPeriod+c.allowance.Period+c.allowance.RenewWindow, c.allowance.EndHeightAlignment)
 }
 
 // managedCancelContract cancels a contract by setting its utility fields to
@@ -44,11 +61,74 @@ func (c *Contractor) ContractByPublicKey(pk types.SiaPublicKey) (modules.RenterC
 	return c.staticContracts.View(id)
 }
 
+// ContractByID returns the active contract with the given id, if it exists.
+func (c *Contractor) ContractByID(id types.FileContractID) (modules.RenterContract, bool) {
+	return c.staticContracts.View(id)
+}
+
+// ContractRevision returns the signed transaction containing the latest
+// revision of the contract with the given id.
+func (c *Contractor) ContractRevision(id types.FileContractID) (types.Transaction, bool) {
+	return c.staticContracts.LatestRevision(id)
+}
+
 // CancelContract cancels the Contractor's contract by marking it !GoodForRenew
 // and !GoodForUpload
 func (c *Contractor) CancelContract(id types.FileContractID) error {
 	defer c.threadedContractMaintenance()
-	return c.managedCancelContract(id)
+	if err := c.managedCancelContract(id); err != nil {
+		return err
+	}
+	c.mu.RLock()
+	hostKey := c.contractIDToPubKey[id]
+	c.mu.RUnlock()
+	c.managedNotifyWebhooks(modules.ContractEventCancelled, id, hostKey, types.FileContractID{})
+	return nil
+}
+
+// CancelHostContracts cancels every active contract with the host, that is
+// every contract that is still goodForRenew. The contracts are cancelled one
+// by one through the same path as CancelContract, but contract maintenance
+// only runs once afterwards. If a contract can't be cancelled, the remaining
+// contracts are still cancelled and the first error is returned together with
+// the contracts that were cancelled.
+func (c *Contractor) CancelHostContracts(pk types.SiaPublicKey) ([]types.FileContractID, error) {
+	var ids []types.FileContractID
+	for _, rc := range c.staticContracts.ViewAll() {
+		if rc.HostPublicKey.String() == pk.String() && rc.Utility.GoodForRenew {
+			ids = append(ids, rc.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, errNoHostContracts
+	}
+
+	defer c.threadedContractMaintenance()
+	var cancelled []types.FileContractID
+	var firstErr error
+	for _, id := range ids {
+		if err := c.managedCancelContract(id); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		cancelled = append(cancelled, id)
+		c.managedNotifyWebhooks(modules.ContractEventCancelled, id, pk, types.FileContractID{})
+	}
+	return cancelled, firstErr
+}
+
+// SetContractMetadata replaces the metadata attached to an active contract.
+// The metadata is stored in the contract header and carried over when the
+// contract is renewed.
+func (c *Contractor) SetContractMetadata(id types.FileContractID, metadata map[string]string) error {
+	sc, exists := c.staticContracts.Acquire(id)
+	if !exists {
+		return errContractNotFound
+	}
+	defer c.staticContracts.Return(sc)
+	return sc.UpdateMetadata(metadata)
 }
 
 // Contracts returns the contracts formed by the contractor in the current
@@ -81,6 +161,26 @@ func (c *Contractor) ContractUtility(pk types.SiaPublicKey) (modules.ContractUti
 	return c.managedContractUtility(id)
 }
 
+// RandomSectorRoots returns the Merkle roots of up to n random sectors stored
+// under the contract with the given id.
+func (c *Contractor) RandomSectorRoots(id types.FileContractID, n int) ([]crypto.Hash, error) {
+	return c.staticContracts.RandomSectorRoots(id, n)
+}
+
+// SectorRoots fetches the Merkle roots of all sectors that the host stores
+// under the contract with the given id.
+func (c *Contractor) SectorRoots(id types.FileContractID, cancel <-chan struct{}) ([]crypto.Hash, error) {
+	contract, ok := c.staticContracts.View(id)
+	if !ok {
+		return nil, errors.New("no record of that contract")
+	}
+	host, ok := c.hdb.Host(contract.HostPublicKey)
+	if !ok {
+		return nil, errors.New("no record of that host")
+	}
+	return c.staticContracts.SectorRoots(host, id, cancel)
+}
+
 // ResolveIDToPubKey returns the ID of the most recent renewal of id.
 func (c *Contractor) ResolveIDToPubKey(id types.FileContractID) types.SiaPublicKey {
 	c.mu.RLock()
@@ -91,3 +191,14 @@ func (c *Contractor) ResolveIDToPubKey(id types.FileContractID) types.SiaPublicK
 	}
 	return pk
 }
+
+// AuditLog returns the paid operations recorded in the audit log of the
+// contract set.
+func (c *Contractor) AuditLog(start, end time.Time, categories []string) ([]modules.RenterAuditEntry, error) {
+	return c.staticContracts.AuditLog(start, end, categories)
+}
+
+// ExportAuditLog writes the audit log of the contract set as CSV to dst.
+func (c *Contractor) ExportAuditLog(dst string) error {
+	return c.staticContracts.ExportAuditLog(dst)
+}
@@ -13,6 +13,9 @@ import (
 type (
 	consensusSet interface {
 		ConsensusSetSubscribe(modules.ConsensusSetSubscriber, modules.ConsensusChangeID, <-chan struct{}) error
+		HeaderConsensusSetSubscribe(modules.HeaderConsensusSetSubscriber, modules.ConsensusChangeID, <-chan struct{}) error
+		HeaderUnsubscribe(modules.HeaderConsensusSetSubscriber)
+		SpvMode() bool
 		Synced() bool
 		Unsubscribe(modules.ConsensusSetSubscriber)
 	}
@@ -20,10 +23,12 @@ type (
 	// provide a shim to bridge the gap between modules.Wallet and
 	// transactionBuilder.
 	walletShim interface {
+		ConfirmedBalance() (types.Currency, error)
 		NextAddress() (types.UnlockConditions, error)
 		StartTransaction() (modules.TransactionBuilder, error)
 	}
 	wallet interface {
+		ConfirmedBalance() (types.Currency, error)
 		NextAddress() (types.UnlockConditions, error)
 		StartTransaction() (transactionBuilder, error)
 	}
@@ -55,6 +60,7 @@ type (
 		IncrementSuccessfulInteractions(key types.SiaPublicKey)
 		IncrementFailedInteractions(key types.SiaPublicKey)
 		RandomHosts(n int, blacklist, addressBlacklist []types.SiaPublicKey) ([]modules.HostDBEntry, error)
+		RecordCapacityProof(key types.SiaPublicKey, answered, valid bool)
 		ScoreBreakdown(modules.HostDBEntry) modules.HostScoreBreakdown
 	}
 
@@ -71,6 +77,9 @@ type WalletBridge struct {
 	W walletShim
 }
 
+// ConfirmedBalance returns the confirmed siacoin balance of the wallet.
+func (ws *WalletBridge) ConfirmedBalance() (types.Currency, error) { return ws.W.ConfirmedBalance() }
+
 // NextAddress computes and returns the next address of the wallet.
 func (ws *WalletBridge) NextAddress() (types.UnlockConditions, error) { return ws.W.NextAddress() }
 
@@ -3,6 +3,7 @@ package contractor
 import (
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/HyperspaceApp/Hyperspace/crypto"
 	"github.com/HyperspaceApp/Hyperspace/modules"
@@ -21,6 +22,13 @@ type Downloader interface {
 	// retrieve.
 	Sector(root crypto.Hash) ([]byte, error)
 
+	// SectorWithDeadline is like Sector, but fails once the deadline has
+	// passed. The connection to the host is shared by all clients of the
+	// Downloader, so only the RPC is limited and not the connection. It also
+	// returns the time it took the host to send the sector data, without the
+	// negotiation of the revision.
+	SectorWithDeadline(root crypto.Hash, deadline time.Time) ([]byte, time.Duration, error)
+
 	// Close terminates the connection to the host.
 	Close() error
 }
@@ -85,18 +93,27 @@ func (hd *hostDownloader) HostSettings() modules.HostExternalSettings {
 // the underlying contract to pay the host proportionally to the data
 // retrieve.
 func (hd *hostDownloader) Sector(root crypto.Hash) ([]byte, error) {
+	sector, _, err := hd.SectorWithDeadline(root, time.Time{})
+	return sector, err
+}
+
+// SectorWithDeadline retrieves the sector with the specified Merkle root like
+// Sector, but abandons the download once the deadline has passed.
+func (hd *hostDownloader) SectorWithDeadline(root crypto.Hash, deadline time.Time) ([]byte, time.Duration, error) {
 	hd.mu.Lock()
 	defer hd.mu.Unlock()
 	if hd.invalid {
-		return nil, errInvalidDownloader
+		return nil, 0, errInvalidDownloader
 	}
 
 	// Download the sector.
+	hd.downloader.SetDeadline(deadline)
+	defer hd.downloader.SetDeadline(time.Time{})
 	_, sector, err := hd.downloader.Sector(root)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return sector, nil
+	return sector, hd.downloader.TransferTime(), nil
 }
 
 // Downloader returns a Downloader object that can be used to download sectors
@@ -104,13 +121,46 @@ func (hd *hostDownloader) Sector(root crypto.Hash) ([]byte, error) {
 func (c *Contractor) Downloader(pk types.SiaPublicKey, cancel <-chan struct{}) (_ Downloader, err error) {
 	c.mu.RLock()
 	id, gotID := c.pubKeysToContractID[string(pk.Key)]
-	cachedDownloader, haveDownloader := c.downloaders[id]
-	height := c.blockHeight
-	renewing := c.renewing[id]
 	c.mu.RUnlock()
 	if !gotID {
 		return nil, errors.New("failed to get filecontract id from key")
 	}
+	return c.managedDownloader(id, cancel)
+}
+
+// DownloaderForContract returns a Downloader that downloads sectors with the
+// contract with the given id. Unlike Downloader, it can be used for the
+// parallel contracts with a host.
+func (c *Contractor) DownloaderForContract(id types.FileContractID, cancel <-chan struct{}) (_ Downloader, err error) {
+	return c.managedDownloader(id, cancel)
+}
+
+// managedDownloadContract returns the contract with the given id and its host
+// if the contract can be used to pay for downloads at the given height.
+func (c *Contractor) managedDownloadContract(id types.FileContractID, height types.BlockHeight) (modules.RenterContract, modules.HostDBEntry, error) {
+	contract, haveContract := c.staticContracts.View(id)
+	if !haveContract {
+		return modules.RenterContract{}, modules.HostDBEntry{}, errors.New("no record of that contract")
+	}
+	host, haveHost := c.hdb.Host(contract.HostPublicKey)
+	if height > contract.EndHeight {
+		return modules.RenterContract{}, modules.HostDBEntry{}, errors.New("contract has already ended")
+	} else if !haveHost {
+		return modules.RenterContract{}, modules.HostDBEntry{}, errors.New("no record of that host")
+	} else if host.DownloadBandwidthPrice.Cmp(maxDownloadPrice) > 0 {
+		return modules.RenterContract{}, modules.HostDBEntry{}, errTooExpensive
+	}
+	return contract, host, nil
+}
+
+// managedDownloader returns the cached Downloader of the contract or creates a
+// new one.
+func (c *Contractor) managedDownloader(id types.FileContractID, cancel <-chan struct{}) (_ Downloader, err error) {
+	c.mu.RLock()
+	cachedDownloader, haveDownloader := c.downloaders[id]
+	height := c.blockHeight
+	renewing := c.renewing[id]
+	c.mu.RUnlock()
 	if renewing {
 		return nil, errors.New("currently renewing that contract")
 	} else if haveDownloader {
@@ -122,17 +172,9 @@ func (c *Contractor) Downloader(pk types.SiaPublicKey, cancel <-chan struct{}) (
 	}
 
 	// Fetch the contract and host.
-	contract, haveContract := c.staticContracts.View(id)
-	if !haveContract {
-		return nil, errors.New("no record of that contract")
-	}
-	host, haveHost := c.hdb.Host(contract.HostPublicKey)
-	if height > contract.EndHeight {
-		return nil, errors.New("contract has already ended")
-	} else if !haveHost {
-		return nil, errors.New("no record of that host")
-	} else if host.DownloadBandwidthPrice.Cmp(maxDownloadPrice) > 0 {
-		return nil, errTooExpensive
+	contract, host, err := c.managedDownloadContract(id, height)
+	if err != nil {
+		return nil, err
 	}
 
 	// create downloader
@@ -3,6 +3,7 @@ package contractor
 import (
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/HyperspaceApp/Hyperspace/crypto"
 	"github.com/HyperspaceApp/Hyperspace/modules"
@@ -23,6 +24,13 @@ type Editor interface {
 	// returns the Merkle root of the data.
 	Upload(data []byte) (root crypto.Hash, err error)
 
+	// UploadWithDeadline is like Upload, but fails once the deadline has
+	// passed. The connection to the host is shared by all clients of the
+	// Editor, so only the RPC is limited and not the connection. It also
+	// returns the time it took to send the data, without the negotiation of
+	// the revision.
+	UploadWithDeadline(data []byte, deadline time.Time) (root crypto.Hash, transferTime time.Duration, err error)
+
 	// Address returns the address of the host.
 	Address() modules.NetAddress
 
@@ -97,18 +105,27 @@ func (he *hostEditor) Close() error {
 
 // Upload negotiates a revision that adds a sector to a file contract.
 func (he *hostEditor) Upload(data []byte) (_ crypto.Hash, err error) {
+	root, _, err := he.UploadWithDeadline(data, time.Time{})
+	return root, err
+}
+
+// UploadWithDeadline negotiates a revision that adds a sector to a file
+// contract, abandoning it once the deadline has passed.
+func (he *hostEditor) UploadWithDeadline(data []byte, deadline time.Time) (_ crypto.Hash, _ time.Duration, err error) {
 	he.mu.Lock()
 	defer he.mu.Unlock()
 	if he.invalid {
-		return crypto.Hash{}, errInvalidEditor
+		return crypto.Hash{}, 0, errInvalidEditor
 	}
 
 	// Perform the upload.
+	he.editor.SetDeadline(deadline)
+	defer he.editor.SetDeadline(time.Time{})
 	_, sectorRoot, err := he.editor.Upload(data)
 	if err != nil {
-		return crypto.Hash{}, err
+		return crypto.Hash{}, 0, err
 	}
-	return sectorRoot, nil
+	return sectorRoot, he.editor.TransferTime(), nil
 }
 
 // Editor returns a Editor object that can be used to upload, modify, and
@@ -116,13 +133,27 @@ func (he *hostEditor) Upload(data []byte) (_ crypto.Hash, err error) {
 func (c *Contractor) Editor(pk types.SiaPublicKey, cancel <-chan struct{}) (_ Editor, err error) {
 	c.mu.RLock()
 	id, gotID := c.pubKeysToContractID[string(pk.Key)]
-	cachedEditor, haveEditor := c.editors[id]
-	height := c.blockHeight
-	renewing := c.renewing[id]
 	c.mu.RUnlock()
 	if !gotID {
 		return nil, errors.New("failed to get filecontract id from key")
 	}
+	return c.managedEditor(id, cancel)
+}
+
+// EditorForContract returns an Editor that revises the contract with the given
+// id. Unlike Editor, it can be used for the parallel contracts with a host.
+func (c *Contractor) EditorForContract(id types.FileContractID, cancel <-chan struct{}) (_ Editor, err error) {
+	return c.managedEditor(id, cancel)
+}
+
+// managedEditor returns the cached Editor of the contract or creates a new
+// one.
+func (c *Contractor) managedEditor(id types.FileContractID, cancel <-chan struct{}) (_ Editor, err error) {
+	c.mu.RLock()
+	cachedEditor, haveEditor := c.editors[id]
+	height := c.blockHeight
+	renewing := c.renewing[id]
+	c.mu.RUnlock()
 	if renewing {
 		// Cannot use the editor if the contract is being renewed.
 		return nil, errors.New("currently renewing that contract")
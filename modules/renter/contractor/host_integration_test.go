@@ -6,6 +6,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -316,6 +317,90 @@ func TestIntegrationUploadDownload(t *testing.T) {
 	}
 }
 
+// TestIntegrationDrainingHost tests that a draining host rejects uploads to
+// existing contracts but keeps serving downloads.
+func TestIntegrationDrainingHost(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host and upload a sector
+	_, contract, err := c.managedNewContract(hostEntry, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	editor, err := c.Editor(contract.HostPublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root, err := editor.Upload(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the draining host rejects further uploads
+	if err := h.Drain(); err != nil {
+		t.Fatal(err)
+	}
+	editor, err = c.Editor(contract.HostPublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := editor.Upload(fastrand.Bytes(int(modules.SectorSize))); err == nil || !strings.Contains(err.Error(), "draining") {
+		t.Fatal("expected the draining host to reject the upload, got", err)
+	}
+	editor.Close()
+
+	// the data can still be downloaded
+	downloader, err := c.Downloader(contract.HostPublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retrieved, err := downloader.Sector(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, retrieved) {
+		t.Fatal("downloaded data does not match original")
+	}
+	if err := downloader.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// uploads are accepted again once the host stops draining
+	if err := h.Undrain(); err != nil {
+		t.Fatal(err)
+	}
+	editor, err = c.Editor(contract.HostPublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := editor.Upload(fastrand.Bytes(int(modules.SectorSize))); err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestIntegrationRenew tests that the contractor can renew a previously-
 // formed file contract.
 func TestIntegrationRenew(t *testing.T) {
@@ -368,7 +453,7 @@ func TestIntegrationRenew(t *testing.T) {
 	if !ok {
 		t.Fatal("failed to acquire contract")
 	}
-	contract, err = c.managedRenew(oldContract, types.SiacoinPrecision.Mul64(50), c.blockHeight+200)
+	contract, err = c.managedRenew(oldContract, types.SiacoinPrecision.Mul64(50), c.blockHeight+200, false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -402,7 +487,7 @@ func TestIntegrationRenew(t *testing.T) {
 		t.Fatal(err)
 	}
 	oldContract, _ = c.staticContracts.Acquire(contract.ID)
-	contract, err = c.managedRenew(oldContract, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	contract, err = c.managedRenew(oldContract, types.SiacoinPrecision.Mul64(50), c.blockHeight+100, false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -428,6 +513,104 @@ func TestIntegrationRenew(t *testing.T) {
 	}
 }
 
+// changedHostDB is a hostDB that reports different settings for a host than
+// the ones the host announced.
+type changedHostDB struct {
+	hostDB
+	host modules.HostDBEntry
+}
+
+func (hdb changedHostDB) Host(pk types.SiaPublicKey) (modules.HostDBEntry, bool) {
+	if pk.String() == hdb.host.PublicKey.String() {
+		return hdb.host, true
+	}
+	return hdb.hostDB.Host(pk)
+}
+
+// TestIntegrationRenewalSchedule tests that the renewal schedule expects a
+// contract to be renewed until its host becomes too expensive or stops
+// accepting contracts of the full period.
+func TestIntegrationRenewalSchedule(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host
+	_, contract, err := c.managedNewContract(hostEntry, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowance := modules.Allowance{
+		Funds:       types.SiacoinPrecision.Mul64(100),
+		Hosts:       1,
+		Period:      50,
+		RenewWindow: 20,
+	}
+	c.mu.Lock()
+	c.allowance = allowance
+	c.mu.Unlock()
+
+	// the schedule needs the initial scan of the hostdb to be complete
+	var schedule modules.ContractRenewalSchedule
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		schedule, err = c.RenewalSchedule(10)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schedule.Contracts) != 1 || schedule.Due != 0 {
+		t.Fatalf("expected 1 contract that isn't due yet, got %+v", schedule)
+	}
+	renewal := schedule.Contracts[0]
+	if renewal.ID != contract.ID || renewal.RenewHeight != contract.EndHeight-allowance.RenewWindow {
+		t.Fatalf("wrong renewal of the contract: %+v", renewal)
+	}
+	if !renewal.WillRenew || renewal.EstimatedCost.IsZero() {
+		t.Fatalf("contract should be renewed: %+v", renewal)
+	}
+	if schedule, err := c.RenewalSchedule(renewal.RenewHeight - schedule.BlockHeight); err != nil || schedule.Due != 1 {
+		t.Fatal("contract should be due within its renew height", schedule.Due, err)
+	}
+
+	// the contract is dropped once the host becomes too expensive or its
+	// MaxDuration is shorter than the period
+	tooExpensive := hostEntry
+	tooExpensive.StoragePrice = maxStoragePrice.Add(types.NewCurrency64(1))
+	shortDuration := hostEntry
+	shortDuration.MaxDuration = allowance.Period - 1
+	for _, changed := range []modules.HostDBEntry{tooExpensive, shortDuration} {
+		c.mu.Lock()
+		hdb := c.hdb
+		c.hdb = changedHostDB{hostDB: hdb, host: changed}
+		c.mu.Unlock()
+		schedule, err := c.RenewalSchedule(0)
+		c.mu.Lock()
+		c.hdb = hdb
+		c.mu.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if renewal := schedule.Contracts[0]; renewal.WillRenew || renewal.DropReason == "" {
+			t.Fatalf("contract shouldn't be renewed with the changed host: %+v", renewal)
+		}
+	}
+}
+
 // TestIntegrationDownloaderCaching tests that downloaders are properly cached
 // by the contractor. When two downloaders are requested for the same
 // contract, only one underlying downloader should be created.
@@ -610,6 +793,170 @@ func TestIntegrationEditorCaching(t *testing.T) {
 	d4.Close()
 }
 
+// TestIntegrationDownloaderForContract tests that the downloader of a parallel
+// contract downloads with that contract rather than with the first contract
+// of the host.
+func TestIntegrationDownloaderForContract(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract and a parallel contract with the host
+	_, contract, err := c.managedNewContract(hostEntry, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, parallel, err := c.managedNewParallelContract(hostEntry, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// upload a sector with the parallel contract
+	editor, err := c.EditorForContract(parallel.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root, err := editor.Upload(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// download it with the parallel contract
+	d, err := c.DownloaderForContract(parallel.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.(*hostDownloader).contractID != parallel.ID {
+		t.Fatal("downloader uses the wrong contract")
+	}
+	sector, err := d.Sector(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+	if !bytes.Equal(sector, data) {
+		t.Fatal("downloaded data doesn't match the uploaded data")
+	}
+
+	// only the parallel contract paid for the download
+	first, _ := c.staticContracts.View(contract.ID)
+	second, _ := c.staticContracts.View(parallel.ID)
+	if !first.DownloadSpending.IsZero() {
+		t.Fatal("the first contract of the host was used for the download")
+	}
+	if second.DownloadSpending.IsZero() {
+		t.Fatal("the parallel contract wasn't used for the download")
+	}
+}
+
+// TestIntegrationVerifySectorSegments tests that the contractor can verify
+// segments of sectors stored with a host, and that it only pays for the
+// segments.
+func TestIntegrationVerifySectorSegments(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host and upload a sector
+	_, contract, err := c.managedNewContract(hostEntry, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	editor, err := c.Editor(contract.HostPublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := editor.Upload(fastrand.Bytes(int(modules.SectorSize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// verify a segment of the sector
+	if err := c.VerifySectorSegments(contract.HostPublicKey, []crypto.Hash{root}, nil); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := c.staticContracts.View(contract.ID)
+	if updated.DownloadSpending.IsZero() {
+		t.Fatal("the segment wasn't paid for")
+	}
+	sectorPrice := hostEntry.DownloadBandwidthPrice.Mul64(modules.SectorSize)
+	if updated.DownloadSpending.Cmp(sectorPrice) >= 0 {
+		t.Fatal("paid as much as for the whole sector", updated.DownloadSpending, sectorPrice)
+	}
+
+	// a sector that isn't stored under the contract can't be verified
+	if err := c.VerifySectorSegments(contract.HostPublicKey, []crypto.Hash{{1}}, nil); err == nil {
+		t.Fatal("expected verification of an unknown sector to fail")
+	}
+	// the contract is still usable afterwards
+	if err := c.VerifySectorSegments(contract.HostPublicKey, []crypto.Hash{root, root}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// a sector stored under a parallel contract with the host is paid for
+	// with that contract instead of the primary one
+	_, parallel, err := c.managedNewParallelContract(hostEntry, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	editor, err = c.EditorForContract(parallel.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parallelRoot, err := editor.Upload(fastrand.Bytes(int(modules.SectorSize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Close(); err != nil {
+		t.Fatal(err)
+	}
+	primary, _ := c.staticContracts.View(contract.ID)
+	if err := c.VerifySectorSegments(contract.HostPublicKey, []crypto.Hash{parallelRoot}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if updated, _ := c.staticContracts.View(parallel.ID); updated.DownloadSpending.IsZero() {
+		t.Fatal("the segment wasn't paid for with the parallel contract")
+	}
+	if updated, _ := c.staticContracts.View(contract.ID); !updated.DownloadSpending.Equals(primary.DownloadSpending) {
+		t.Fatal("the segment was paid for with the primary contract")
+	}
+}
+
 // TestContractPresenceLeak tests that a renter can not tell from the response
 // of the host to RPCs if the host has the contract if the renter doesn't
 // own this contract. See https://gitlab.com/NebulousLabs/Sia/issues/2327.
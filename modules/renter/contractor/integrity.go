@@ -0,0 +1,139 @@
+package contractor
+
+import (
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/proto"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// integrityReplacement records a host whose contract was replaced because it
+// kept failing integrity checks.
+type integrityReplacement struct {
+	HostPublicKey types.SiaPublicKey `json:"hostpublickey"`
+	Height        types.BlockHeight  `json:"height"`
+}
+
+// managedRecordIntegrityReplacement records that the contract of the host was
+// replaced, so that no new contract is formed with the host for
+// integrityReplacementBan blocks. Expired records are pruned.
+func (c *Contractor) managedRecordIntegrityReplacement(hostKey types.SiaPublicKey) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, r := range c.integrityReplacements {
+		if c.blockHeight >= r.Height+integrityReplacementBan {
+			delete(c.integrityReplacements, key)
+		}
+	}
+	c.integrityReplacements[hostKey.String()] = integrityReplacement{
+		HostPublicKey: hostKey,
+		Height:        c.blockHeight,
+	}
+	return c.saveSync()
+}
+
+// integrityBannedHosts returns the hosts that were replaced because of failed
+// integrity checks within the last integrityReplacementBan blocks.
+func (c *Contractor) integrityBannedHosts() []types.SiaPublicKey {
+	var hosts []types.SiaPublicKey
+	for _, r := range c.integrityReplacements {
+		if c.blockHeight < r.Height+integrityReplacementBan {
+			hosts = append(hosts, r.HostPublicKey)
+		}
+	}
+	return hosts
+}
+
+// managedCheckContractIntegrity downloads a few random sectors of the
+// contract and verifies them against their Merkle roots. It returns true if
+// the host sent data that doesn't match. Errors unrelated to the data, like
+// the host being unreachable, are returned instead so that they don't count
+// as a failed integrity check.
+func (c *Contractor) managedCheckContractIntegrity(contract modules.RenterContract) (bool, error) {
+	roots, err := c.staticContracts.RandomSectorRoots(contract.ID, integrityScanSectors)
+	if err != nil {
+		return false, err
+	}
+	if len(roots) == 0 {
+		return false, nil
+	}
+	d, err := c.Downloader(contract.HostPublicKey, c.tg.StopChan())
+	if err != nil {
+		return false, err
+	}
+	defer d.Close()
+	for _, root := range roots {
+		_, err := d.Sector(root)
+		if err == proto.ErrBadSectorData {
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// managedRecordIntegrityResult updates the number of consecutive integrity
+// checks the host failed and returns true if the host should be replaced.
+func (c *Contractor) managedRecordIntegrityResult(hostKey types.SiaPublicKey, badData bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !badData {
+		delete(c.integrityFailures, hostKey.String())
+		return false
+	}
+	c.integrityFailures[hostKey.String()]++
+	if c.integrityFailures[hostKey.String()] < integrityFailuresBeforeReplacement {
+		return false
+	}
+	delete(c.integrityFailures, hostKey.String())
+	return true
+}
+
+// threadedIntegrityScan checks the integrity of the data stored by every host
+// the renter uploads to or renews with. If a host keeps returning bad data,
+// its contract is marked as !GoodForUpload and !GoodForRenew. The repair loop
+// of the renter then moves the affected pieces to other hosts and contract
+// maintenance forms a replacement contract with a different host.
+func (c *Contractor) threadedIntegrityScan() {
+	if err := c.tg.Add(); err != nil {
+		return
+	}
+	defer c.tg.Done()
+	if !c.integrityScanLock.TryLock() {
+		return
+	}
+	defer c.integrityScanLock.Unlock()
+
+	replaced := false
+	for _, contract := range c.staticContracts.ViewAll() {
+		select {
+		case <-c.tg.StopChan():
+			return
+		default:
+		}
+		if !contract.Utility.GoodForUpload && !contract.Utility.GoodForRenew {
+			continue
+		}
+		badData, err := c.managedCheckContractIntegrity(contract)
+		if err != nil {
+			c.log.Debugln("Unable to check integrity of contract", contract.ID, ":", err)
+			continue
+		}
+		if !c.managedRecordIntegrityResult(contract.HostPublicKey, badData) {
+			continue
+		}
+		c.log.Printf("WARN: host %v keeps failing integrity checks, replacing contract %v", contract.HostPublicKey, contract.ID)
+		if err := c.managedCancelContract(contract.ID); err != nil {
+			c.log.Println("Unable to mark contract as bad after failed integrity checks:", err)
+			continue
+		}
+		if err := c.managedRecordIntegrityReplacement(contract.HostPublicKey); err != nil {
+			c.log.Println("Unable to save the replaced host:", err)
+		}
+		c.managedNotifyWebhooks(modules.ContractEventBad, contract.ID, contract.HostPublicKey, types.FileContractID{})
+		replaced = true
+	}
+	if replaced {
+		c.threadedContractMaintenance()
+	}
+}
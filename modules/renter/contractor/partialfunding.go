@@ -0,0 +1,139 @@
+package contractor
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// scaleFunds reduces funds by the ratio of available to needed. The funds are
+// returned unchanged if the available funds cover the needed funds. Only
+// integer arithmetic is used, so the same inputs always result in the same
+// split, and the scaled funds of several contracts never add up to more than
+// the available funds.
+func scaleFunds(funds, available, needed types.Currency) types.Currency {
+	if needed.IsZero() || available.Cmp(needed) >= 0 {
+		return funds
+	}
+	return funds.Mul(available).Div(needed)
+}
+
+// managedCheckFunding compares the confirmed wallet balance to the funds that
+// remain unallocated in the allowance and records the result. If the
+// allowance allows partial funding and the wallet can't cover the unallocated
+// funds, the balance is returned as the funds available to the current round
+// of maintenance. Otherwise fundsRemaining is returned unchanged.
+func (c *Contractor) managedCheckFunding(allowance modules.Allowance, fundsRemaining types.Currency) (types.Currency, modules.ContractorFunding) {
+	funding := modules.ContractorFunding{
+		FundsNeeded:  fundsRemaining,
+		FundingRatio: 1,
+	}
+	if allowance.PartialFunding && !fundsRemaining.IsZero() {
+		balance, err := c.wallet.ConfirmedBalance()
+		if err != nil {
+			c.log.Println("WARN: unable to check the wallet balance for partial funding:", err)
+		} else {
+			funding.WalletBalance = balance
+			if balance.Cmp(fundsRemaining) < 0 {
+				funding.Underfunded = true
+				funding.FundingRatio, _ = big.NewRat(0, 1).SetFrac(balance.Big(), fundsRemaining.Big()).Float64()
+			}
+		}
+	}
+
+	c.mu.Lock()
+	c.funding = funding
+	c.mu.Unlock()
+	c.managedNotifyFundingChecked()
+	if !funding.Underfunded {
+		return fundsRemaining, funding
+	}
+	c.log.Printf("WARN: the wallet balance of %v only covers %.2f%% of the %v of unallocated allowance funds, contracts will be funded partially", funding.WalletBalance, funding.FundingRatio*100, fundsRemaining)
+	return funding.WalletBalance, funding
+}
+
+// managedUnderfundedRefreshes returns the refreshes that top up the contracts
+// which were funded partially to the funding they would have had otherwise.
+// Contracts that are already scheduled for a renewal, or that are no longer
+// good for renew, are skipped. Contracts that no longer exist are forgotten.
+func (c *Contractor) managedUnderfundedRefreshes(allowance modules.Allowance, scheduled map[types.FileContractID]struct{}) []fileContractRenewal {
+	c.mu.RLock()
+	targets := make(map[types.FileContractID]types.Currency, len(c.underfundedContracts))
+	for id, target := range c.underfundedContracts {
+		targets[id] = target
+	}
+	c.mu.RUnlock()
+
+	var refreshes []fileContractRenewal
+	var stale []types.FileContractID
+	for id, target := range targets {
+		contract, exists := c.staticContracts.View(id)
+		if !exists {
+			stale = append(stale, id)
+			continue
+		}
+		if _, ok := scheduled[id]; ok || !contract.Utility.GoodForRenew {
+			continue
+		}
+		if contract.TotalCost.Cmp(target) >= 0 {
+			stale = append(stale, id)
+			continue
+		}
+		refreshes = append(refreshes, fileContractRenewal{
+			id:      id,
+			amount:  capContractFunds(allowance, target),
+			refresh: true,
+		})
+	}
+	// Sort the refreshes so that the same contracts are topped up first in
+	// every round.
+	sort.Slice(refreshes, func(i, j int) bool {
+		return bytes.Compare(refreshes[i].id[:], refreshes[j].id[:]) < 0
+	})
+
+	if len(stale) > 0 {
+		c.mu.Lock()
+		for _, id := range stale {
+			delete(c.underfundedContracts, id)
+		}
+		err := c.saveSync()
+		c.mu.Unlock()
+		if err != nil {
+			c.log.Println("Unable to save the contractor:", err)
+		}
+	}
+	return refreshes
+}
+
+// managedUpdateUnderfunded records whether the contract with the id newID was
+// funded with less than its target funding. oldID is the contract that was
+// renewed into newID, it is no longer tracked.
+func (c *Contractor) managedUpdateUnderfunded(oldID, newID types.FileContractID, funds, target types.Currency) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, tracked := c.underfundedContracts[oldID]
+	underfunded := funds.Cmp(target) < 0
+	if !tracked && !underfunded {
+		return
+	}
+	delete(c.underfundedContracts, oldID)
+	if underfunded {
+		c.underfundedContracts[newID] = target
+	}
+	if err := c.saveSync(); err != nil {
+		c.log.Println("Unable to save the contractor:", err)
+	}
+}
+
+// ContractorFunding returns whether the contractor is funding its contracts
+// partially because the wallet balance doesn't cover the allowance.
+func (c *Contractor) ContractorFunding() modules.ContractorFunding {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	funding := c.funding
+	funding.UnderfundedContracts = uint64(len(c.underfundedContracts))
+	return funding
+}
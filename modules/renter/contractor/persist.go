@@ -14,24 +14,36 @@ import (
 
 // contractorPersist defines what Contractor data persists across sessions.
 type contractorPersist struct {
-	Allowance     modules.Allowance               `json:"allowance"`
-	BlockHeight   types.BlockHeight               `json:"blockheight"`
-	CurrentPeriod types.BlockHeight               `json:"currentperiod"`
-	LastChange    modules.ConsensusChangeID       `json:"lastchange"`
-	OldContracts  []modules.RenterContract        `json:"oldcontracts"`
-	RenewedFrom   map[string]types.FileContractID `json:"renewedfrom"`
-	RenewedTo     map[string]types.FileContractID `json:"renewedto"`
+	Allowance             modules.Allowance               `json:"allowance"`
+	AllowanceTransition   modules.AllowanceTransition     `json:"allowancetransition"`
+	BlockHeight           types.BlockHeight               `json:"blockheight"`
+	CurrentPeriod         types.BlockHeight               `json:"currentperiod"`
+	IntegrityReplacements []integrityReplacement          `json:"integrityreplacements"`
+	LastChange            modules.ConsensusChangeID       `json:"lastchange"`
+	MaintenancePaused     bool                            `json:"maintenancepaused"`
+	OldContracts          []modules.RenterContract        `json:"oldcontracts"`
+	ParallelContracts     []types.FileContractID          `json:"parallelcontracts"`
+	RenewedFrom           map[string]types.FileContractID `json:"renewedfrom"`
+	RenewedTo             map[string]types.FileContractID `json:"renewedto"`
+	Trial                 modules.RenterTrial             `json:"trial"`
+	UnderfundedContracts  map[string]types.Currency       `json:"underfundedcontracts"`
+	Webhooks              []modules.RenterWebhook         `json:"webhooks"`
 }
 
 // persistData returns the data in the Contractor that will be saved to disk.
 func (c *Contractor) persistData() contractorPersist {
 	data := contractorPersist{
-		Allowance:     c.allowance,
-		BlockHeight:   c.blockHeight,
-		CurrentPeriod: c.currentPeriod,
-		LastChange:    c.lastChange,
-		RenewedFrom:   make(map[string]types.FileContractID),
-		RenewedTo:     make(map[string]types.FileContractID),
+		Allowance:            c.allowance,
+		AllowanceTransition:  c.allowanceTransition,
+		BlockHeight:          c.blockHeight,
+		CurrentPeriod:        c.currentPeriod,
+		LastChange:           c.lastChange,
+		MaintenancePaused:    c.maintenancePaused,
+		RenewedFrom:          make(map[string]types.FileContractID),
+		RenewedTo:            make(map[string]types.FileContractID),
+		Trial:                c.trial,
+		UnderfundedContracts: make(map[string]types.Currency),
+		Webhooks:             c.webhooks,
 	}
 	for k, v := range c.renewedFrom {
 		data.RenewedFrom[k.String()] = v
@@ -39,9 +51,18 @@ func (c *Contractor) persistData() contractorPersist {
 	for k, v := range c.renewedTo {
 		data.RenewedTo[k.String()] = v
 	}
+	for k, v := range c.underfundedContracts {
+		data.UnderfundedContracts[k.String()] = v
+	}
 	for _, contract := range c.oldContracts {
 		data.OldContracts = append(data.OldContracts, contract)
 	}
+	for id := range c.parallelContracts {
+		data.ParallelContracts = append(data.ParallelContracts, id)
+	}
+	for _, r := range c.integrityReplacements {
+		data.IntegrityReplacements = append(data.IntegrityReplacements, r)
+	}
 	return data
 }
 
@@ -53,9 +74,13 @@ func (c *Contractor) load() error {
 		return err
 	}
 	c.allowance = data.Allowance
+	c.allowanceTransition = data.AllowanceTransition
 	c.blockHeight = data.BlockHeight
 	c.currentPeriod = data.CurrentPeriod
 	c.lastChange = data.LastChange
+	c.maintenancePaused = data.MaintenancePaused
+	c.trial = data.Trial
+	c.webhooks = data.Webhooks
 	var fcid types.FileContractID
 	for k, v := range data.RenewedFrom {
 		if err := fcid.LoadString(k); err != nil {
@@ -69,9 +94,21 @@ func (c *Contractor) load() error {
 		}
 		c.renewedTo[fcid] = v
 	}
+	for k, v := range data.UnderfundedContracts {
+		if err := fcid.LoadString(k); err != nil {
+			return err
+		}
+		c.underfundedContracts[fcid] = v
+	}
 	for _, contract := range data.OldContracts {
 		c.oldContracts[contract.ID] = contract
 	}
+	for _, id := range data.ParallelContracts {
+		c.parallelContracts[id] = struct{}{}
+	}
+	for _, r := range data.IntegrityReplacements {
+		c.integrityReplacements[r.HostPublicKey.String()] = r
+	}
 
 	return nil
 }
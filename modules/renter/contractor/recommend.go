@@ -0,0 +1,109 @@
+package contractor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+var (
+	errRecommendZeroHosts  = errors.New("number of hosts must be non-zero")
+	errRecommendZeroPeriod = errors.New("period must be non-zero")
+)
+
+// hostCost is the estimated cost of storing data with a single host for a
+// whole period.
+type hostCost struct {
+	storage  types.Currency
+	upload   types.Currency
+	contract types.Currency
+	total    types.Currency
+}
+
+// estimateHostCost estimates the cost of uploading dataSize bytes to the host
+// and storing them for period blocks, using the prices the host advertises.
+func estimateHostCost(host modules.HostDBEntry, dataSize uint64, period types.BlockHeight) hostCost {
+	var hc hostCost
+	hc.storage = host.StoragePrice.Mul64(dataSize).Mul64(uint64(period))
+	hc.upload = host.UploadBandwidthPrice.Mul64(dataSize)
+	hc.contract = host.ContractPrice
+	hc.total = hc.storage.Add(hc.upload).Add(hc.contract)
+	return hc
+}
+
+// recommendAllowance picks the 'hosts' cheapest candidates that can store
+// dataPerHost bytes for the period and sums up their costs. Like the renewal
+// estimate of existing contracts, the recommended funds include a 33% margin.
+func recommendAllowance(candidates []modules.HostDBEntry, dataPerHost, hosts uint64, period types.BlockHeight, txnFee types.Currency) (modules.AllowanceRecommendation, error) {
+	var costs []hostCost
+	for _, host := range candidates {
+		if !host.AcceptingContracts || host.MaxDuration < period || host.RemainingStorage < dataPerHost {
+			continue
+		}
+		if host.StoragePrice.Cmp(maxStoragePrice) > 0 || host.UploadBandwidthPrice.Cmp(maxUploadPrice) > 0 {
+			continue
+		}
+		costs = append(costs, estimateHostCost(host, dataPerHost, period))
+	}
+	if uint64(len(costs)) < hosts {
+		return modules.AllowanceRecommendation{}, fmt.Errorf("only %v of the %v hosts needed can store the data for the period", len(costs), hosts)
+	}
+	sort.Slice(costs, func(i, j int) bool {
+		return costs[i].total.Cmp(costs[j].total) < 0
+	})
+
+	rec := modules.AllowanceRecommendation{
+		Hosts:           hosts,
+		Period:          period,
+		TransactionFees: txnFee.Mul64(hosts),
+	}
+	for _, hc := range costs[:hosts] {
+		rec.StorageCost = rec.StorageCost.Add(hc.storage)
+		rec.UploadCost = rec.UploadCost.Add(hc.upload)
+		rec.ContractFees = rec.ContractFees.Add(hc.contract)
+	}
+	total := rec.StorageCost.Add(rec.UploadCost).Add(rec.ContractFees).Add(rec.TransactionFees)
+	rec.Margin = total.Div64(3)
+	rec.Funds = total.Add(rec.Margin)
+	return rec, nil
+}
+
+// RecommendAllowance estimates the cheapest allowance that stores dataSize
+// bytes at the given redundancy for period blocks, spread evenly across
+// 'hosts' hosts. Only online hosts that score well enough to be considered
+// good for upload are taken into account. The estimate doesn't change the
+// allowance.
+func (c *Contractor) RecommendAllowance(dataSize uint64, redundancy float64, hosts uint64, period types.BlockHeight) (modules.AllowanceRecommendation, error) {
+	if hosts == 0 {
+		return modules.AllowanceRecommendation{}, errRecommendZeroHosts
+	}
+	if period == 0 {
+		return modules.AllowanceRecommendation{}, errRecommendZeroPeriod
+	}
+
+	// Hosts store whole sectors.
+	dataPerHost := uint64(math.Ceil(float64(dataSize) * redundancy / float64(hosts)))
+	if rem := dataPerHost % modules.SectorSize; rem != 0 {
+		dataPerHost += modules.SectorSize - rem
+	}
+
+	minScore, _, err := c.managedMinimumScore(int(hosts))
+	if err != nil {
+		return modules.AllowanceRecommendation{}, err
+	}
+	var candidates []modules.HostDBEntry
+	for _, host := range c.hdb.ActiveHosts() {
+		if isOffline(host) || c.hdb.ScoreBreakdown(host).Score.Cmp(minScore) < 0 {
+			continue
+		}
+		candidates = append(candidates, host)
+	}
+
+	_, maxTxnFee := c.tpool.FeeEstimation()
+	txnFee := maxTxnFee.Mul64(modules.EstimatedFileContractTransactionSetSize)
+	return recommendAllowance(candidates, dataPerHost, hosts, period, txnFee)
+}
@@ -0,0 +1,136 @@
+package contractor
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/proto"
+)
+
+// RecoveryContracts returns the contracts of the contractor together with
+// their secret keys.
+func (c *Contractor) RecoveryContracts() []modules.RecoveryHintContract {
+	return c.staticContracts.RecoveryContracts()
+}
+
+// RecoverSector downloads a sector using a contract that the contractor
+// doesn't know about, e.g. one listed in a recovery hint. The download is paid
+// for with the contract, but the revision isn't stored by the contractor.
+func (c *Contractor) RecoverSector(contract modules.RecoveryHintContract, root crypto.Hash, cancel <-chan struct{}) ([]byte, error) {
+	c.mu.RLock()
+	height := c.blockHeight
+	c.mu.RUnlock()
+	if height > contract.EndHeight {
+		return nil, errors.New("contract has already ended")
+	}
+	host, ok := c.hdb.Host(contract.HostPublicKey)
+	if !ok {
+		return nil, errors.New("no record of that host")
+	}
+	return proto.RecoverSector(host, contract, root, cancel)
+}
+
+// managedRecoveryHost returns the host of a contract that could be recovered,
+// i.e. that hasn't ended, isn't part of the contract set, wasn't renewed or
+// archived and whose host doesn't have an active contract with the renter. A
+// hint can list contracts that were renewed or replaced since the hint was
+// stored, recovering those would only add contracts that can't be revised.
+func (c *Contractor) managedRecoveryHost(contract modules.RecoveryHintContract) (modules.HostDBEntry, bool) {
+	c.mu.RLock()
+	height := c.blockHeight
+	_, renewed := c.renewedTo[contract.ID]
+	_, archived := c.oldContracts[contract.ID]
+	activeID, hasContract := c.pubKeysToContractID[string(contract.HostPublicKey.Key)]
+	c.mu.RUnlock()
+	if height > contract.EndHeight || renewed || archived {
+		return modules.HostDBEntry{}, false
+	}
+	if _, exists := c.staticContracts.View(contract.ID); exists {
+		return modules.HostDBEntry{}, false
+	}
+	if hasContract {
+		if _, active := c.staticContracts.View(activeID); active {
+			return modules.HostDBEntry{}, false
+		}
+	}
+	return c.hdb.Host(contract.HostPublicKey)
+}
+
+// newestFirst returns a copy of the contracts sorted by their end height, the
+// most recent first. A hint can list several contracts with the same host if
+// they were renewed, only the most recent one should be recovered.
+func newestFirst(contracts []modules.RecoveryHintContract) []modules.RecoveryHintContract {
+	sorted := append([]modules.RecoveryHintContract(nil), contracts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].EndHeight > sorted[j].EndHeight
+	})
+	return sorted
+}
+
+// RecoverableContracts returns the contracts that could be recovered. The
+// hosts aren't contacted, fetching the revisions would use up the rate limit
+// of the sector roots RPC that recovering the contracts needs, so the size of
+// the contracts is unknown and hosts that failed their last scan are skipped.
+func (c *Contractor) RecoverableContracts(contracts []modules.RecoveryHintContract) []modules.RecoverableContract {
+	var recoverable []modules.RecoverableContract
+	listed := make(map[string]struct{})
+	for _, contract := range newestFirst(contracts) {
+		host, ok := c.managedRecoveryHost(contract)
+		if !ok {
+			continue
+		}
+		if n := len(host.ScanHistory); n > 0 && !host.ScanHistory[n-1].Success {
+			continue
+		}
+		// Only the most recent contract of a host is recovered.
+		if _, exists := listed[host.PublicKey.String()]; exists {
+			continue
+		}
+		listed[host.PublicKey.String()] = struct{}{}
+		recoverable = append(recoverable, modules.RecoverableContract{
+			HostPublicKey: contract.HostPublicKey,
+			ID:            contract.ID,
+			EndHeight:     contract.EndHeight,
+		})
+	}
+	return recoverable
+}
+
+// RecoverContracts adds the contracts that can be recovered to the contract
+// set and returns the recovered contracts. Contracts that can't be recovered
+// are skipped, and so are older contracts with the host of a recovered
+// contract.
+func (c *Contractor) RecoverContracts(contracts []modules.RecoveryHintContract) []modules.RecoverableContract {
+	var recovered []modules.RecoverableContract
+	for _, contract := range newestFirst(contracts) {
+		host, ok := c.managedRecoveryHost(contract)
+		if !ok {
+			continue
+		}
+		c.mu.RLock()
+		height := c.blockHeight
+		c.mu.RUnlock()
+		rc, err := c.staticContracts.RecoverContract(host, contract, height, c.tg.StopChan())
+		if err != nil {
+			c.log.Println("WARN: unable to recover contract", contract.ID, err)
+			continue
+		}
+
+		// Add a mapping from the contract's id to the public key of the host.
+		c.mu.Lock()
+		c.contractIDToPubKey[rc.ID] = rc.HostPublicKey
+		c.pubKeysToContractID[string(rc.HostPublicKey.Key)] = rc.ID
+		c.mu.Unlock()
+
+		c.log.Printf("Recovered contract %v with %v", rc.ID, host.NetAddress)
+		recovered = append(recovered, modules.RecoverableContract{
+			HostPublicKey: rc.HostPublicKey,
+			ID:            rc.ID,
+			EndHeight:     rc.EndHeight,
+			Size:          rc.Transaction.FileContractRevisions[0].NewFileSize,
+		})
+	}
+	return recovered
+}
@@ -0,0 +1,67 @@
+package contractor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/proto"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// recoveryHostDB is a stubHostDB that knows every host. Hosts listed in
+// offline failed their last scan.
+type recoveryHostDB struct {
+	stubHostDB
+	offline map[string]bool
+}
+
+func (hdb recoveryHostDB) Host(pk types.SiaPublicKey) (modules.HostDBEntry, bool) {
+	entry := modules.HostDBEntry{PublicKey: pk}
+	entry.ScanHistory = modules.HostDBScans{{Success: !hdb.offline[pk.String()]}}
+	return entry, true
+}
+
+// TestRecoverableContracts checks that contracts that ended, were renewed or
+// archived, or whose host failed its last scan aren't recoverable, and that
+// only the most recent contract of a host is listed.
+func TestRecoverableContracts(t *testing.T) {
+	cs, err := proto.NewContractSet(filepath.Join(build.TempDir("contractor", t.Name()), "contracts"), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	hostKey := func(b byte) types.SiaPublicKey {
+		return types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{b}}
+	}
+	offlineKey := hostKey(5)
+	hdb := recoveryHostDB{offline: map[string]bool{offlineKey.String(): true}}
+	c := &Contractor{
+		blockHeight:         100,
+		hdb:                 hdb,
+		oldContracts:        make(map[types.FileContractID]modules.RenterContract),
+		pubKeysToContractID: make(map[string]types.FileContractID),
+		renewedTo:           make(map[types.FileContractID]types.FileContractID),
+		staticContracts:     cs,
+	}
+	contracts := []modules.RecoveryHintContract{
+		{ID: types.FileContractID{1}, HostPublicKey: hostKey(1), EndHeight: 200},
+		{ID: types.FileContractID{2}, HostPublicKey: hostKey(2), EndHeight: 99},
+		{ID: types.FileContractID{3}, HostPublicKey: hostKey(3), EndHeight: 200},
+		{ID: types.FileContractID{4}, HostPublicKey: hostKey(4), EndHeight: 200},
+		{ID: types.FileContractID{5}, HostPublicKey: hostKey(5), EndHeight: 200},
+		{ID: types.FileContractID{6}, HostPublicKey: hostKey(1), EndHeight: 300},
+	}
+	c.renewedTo[types.FileContractID{3}] = types.FileContractID{7}
+	c.oldContracts[types.FileContractID{4}] = modules.RenterContract{ID: types.FileContractID{4}}
+
+	recoverable := c.RecoverableContracts(contracts)
+	if len(recoverable) != 1 {
+		t.Fatal("expected 1 recoverable contract, got", recoverable)
+	}
+	if rc := recoverable[0]; rc.ID != (types.FileContractID{6}) || rc.EndHeight != 300 {
+		t.Fatal("the most recent contract of the host should be recoverable", rc)
+	}
+}
@@ -0,0 +1,99 @@
+package contractor
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// renewHeight returns the height at which the contractor starts to renew a
+// contract that ends at endHeight.
+func renewHeight(endHeight, renewWindow types.BlockHeight) types.BlockHeight {
+	if endHeight < renewWindow {
+		return 0
+	}
+	return endHeight - renewWindow
+}
+
+// RenewalSchedule returns when the active contracts are due for renewal and
+// whether they are expected to be renewed, judged by the current state and
+// prices of their hosts. The utility of the contracts isn't changed. The
+// contracts are sorted by their renew height, Due counts the contracts that
+// are due for renewal within the next within blocks.
+func (c *Contractor) RenewalSchedule(within types.BlockHeight) (modules.ContractRenewalSchedule, error) {
+	c.mu.RLock()
+	allowance := c.allowance
+	blockHeight := c.blockHeight
+	excess := excessParallelContracts(c.staticContracts.ViewAll(), c.parallelContracts, contractsPerHost(c.allowance))
+	schedule := modules.ContractRenewalSchedule{
+		BlockHeight:       blockHeight,
+		Within:            within,
+		MaintenancePaused: c.maintenancePaused,
+		Contracts:         []modules.ContractRenewal{},
+		TargetEndHeight:   c.contractEndHeight(),
+		RenewStagger:      allowance.RenewStagger,
+	}
+	c.mu.RUnlock()
+
+	minScore := types.ZeroCurrency
+	if allowance.Hosts > 0 {
+		var err error
+		minScore, err = c.managedUtilityMinimumScore(allowance)
+		if err != nil {
+			return modules.ContractRenewalSchedule{}, err
+		}
+	}
+	for _, contract := range c.staticContracts.ViewAll() {
+		utility, reason := c.managedCheckContractUtility(contract, minScore, excess)
+		renewal := modules.ContractRenewal{
+			ID:            contract.ID,
+			HostPublicKey: contract.HostPublicKey,
+			EndHeight:     contract.EndHeight,
+			RenewHeight:   renewHeight(contract.EndHeight, allowance.RenewWindow),
+			WillRenew:     utility.GoodForRenew,
+			DropReason:    reason,
+		}
+		c.mu.RLock()
+		renewal.FailedRenewals = uint64(c.numFailedRenews[contract.ID])
+		c.mu.RUnlock()
+
+		// Maintenance doesn't renew any contracts without an allowance, and
+		// managedRenew rejects hosts that became too expensive or no longer
+		// accept contracts until the staggered end height. The contracts
+		// whose renewal can't be estimated are skipped as well.
+		if allowance.Hosts == 0 {
+			renewal.WillRenew = false
+			renewal.DropReason = "no allowance is set"
+		} else if renewal.WillRenew {
+			// The utility check already made sure that the host is known.
+			host, _ := c.hdb.Host(contract.HostPublicKey)
+			endHeight := staggerEndHeight(schedule.TargetEndHeight, allowance.RenewStagger, contract.HostPublicKey)
+			if err := checkHostSettings(host, contractDuration(blockHeight, endHeight)); err != nil {
+				renewal.WillRenew = false
+				renewal.DropReason = err.Error()
+			}
+		}
+		if renewal.WillRenew {
+			cost, err := c.managedEstimateRenewFundingRequirements(contract, blockHeight, allowance)
+			if err != nil {
+				renewal.WillRenew = false
+				renewal.DropReason = "unable to estimate the renewal cost: " + err.Error()
+			}
+			renewal.EstimatedCost = cost
+		}
+		if renewal.RenewHeight <= blockHeight+within {
+			schedule.Due++
+		}
+		schedule.Contracts = append(schedule.Contracts, renewal)
+	}
+	sort.Slice(schedule.Contracts, func(i, j int) bool {
+		ci, cj := schedule.Contracts[i], schedule.Contracts[j]
+		if ci.RenewHeight != cj.RenewHeight {
+			return ci.RenewHeight < cj.RenewHeight
+		}
+		return bytes.Compare(ci.ID[:], cj.ID[:]) < 0
+	})
+	return schedule, nil
+}
@@ -0,0 +1,116 @@
+package contractor
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+var (
+	// errTopUpInsufficientBalance is returned if the wallet can't cover the
+	// funds that a top-up would add to the allowance.
+	errTopUpInsufficientBalance = errors.New("wallet balance is too low to top up the allowance")
+)
+
+// topUpAmount returns the amount that the funds of the allowance should be
+// raised by, given the funds that remain unallocated in the current period.
+// It returns zero if no top-up is needed.
+func topUpAmount(a modules.Allowance, remaining types.Currency) types.Currency {
+	if !a.AutoTopUp || a.TopUpAmount.IsZero() {
+		return types.ZeroCurrency
+	}
+	if remaining.Cmp(a.TopUpThreshold) >= 0 || a.Funds.Cmp(a.TopUpMaxFunds) >= 0 {
+		return types.ZeroCurrency
+	}
+	amount := a.TopUpAmount
+	if ceiling := a.TopUpMaxFunds.Sub(a.Funds); amount.Cmp(ceiling) > 0 {
+		amount = ceiling
+	}
+	return amount
+}
+
+// managedTopUpAllowance raises the funds of the allowance if automatic
+// top-ups are enabled and the unallocated funds of the current period dropped
+// below the threshold. The top-up is skipped if the wallet can't cover the
+// unallocated funds after the top-up.
+func (c *Contractor) managedTopUpAllowance() {
+	defer c.managedNotifyFundingChecked()
+
+	c.mu.RLock()
+	allowance := c.allowance
+	c.mu.RUnlock()
+
+	spending := c.PeriodSpending()
+	var remaining types.Currency
+	if spending.TotalAllocated.Cmp(allowance.Funds) < 0 {
+		remaining = allowance.Funds.Sub(spending.TotalAllocated)
+	}
+	amount := topUpAmount(allowance, remaining)
+	if amount.IsZero() {
+		c.mu.Lock()
+		c.topUpErr = nil
+		c.mu.Unlock()
+		return
+	}
+
+	// The wallet needs to be able to pay for all the funds that haven't been
+	// allocated yet, not only for the top-up.
+	balance, err := c.wallet.ConfirmedBalance()
+	if err == nil && balance.Cmp(remaining.Add(amount)) < 0 {
+		err = errTopUpInsufficientBalance
+	}
+	if err != nil {
+		c.log.Println("WARN: unable to top up the allowance:", err)
+		c.mu.Lock()
+		c.topUpErr = err
+		c.mu.Unlock()
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Don't overwrite an allowance that was changed in the meantime.
+	if !reflect.DeepEqual(allowance, c.allowance) {
+		return
+	}
+	c.allowance.Funds = c.allowance.Funds.Add(amount)
+	if c.allowanceTransition.Active {
+		c.allowanceTransition.TargetAllowance = c.allowance
+	}
+	c.topUpErr = nil
+	if err := c.saveSync(); err != nil {
+		c.log.Println("Unable to save contractor after topping up the allowance:", err)
+	}
+	c.log.Printf("INFO: topped up the allowance by %v to %v", amount, c.allowance.Funds)
+}
+
+// AllowanceTopUpError returns the reason why the most recent automatic top-up
+// of the allowance failed, or nil if no top-up is failing.
+func (c *Contractor) AllowanceTopUpError() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.topUpErr
+}
+
+// OnFundingChecked registers a function that is called after every check of
+// the automatic top-up of the allowance and of the wallet balance for partial
+// funding, so the caller can react to a change of the funding without
+// polling.
+func (c *Contractor) OnFundingChecked(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onFundingChecked = fn
+}
+
+// managedNotifyFundingChecked calls the function registered with
+// OnFundingChecked.
+func (c *Contractor) managedNotifyFundingChecked() {
+	c.mu.RLock()
+	fn := c.onFundingChecked
+	c.mu.RUnlock()
+	if fn != nil {
+		fn()
+	}
+}
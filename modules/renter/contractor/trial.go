@@ -0,0 +1,165 @@
+package contractor
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+var (
+	errTrialActive        = errors.New("a trial is already running")
+	errTrialAllowanceSet  = errors.New("a trial can't be started while an allowance is set")
+	errTrialNoFunds       = errors.New("funds of the trial must be non-zero")
+	errTrialNotActive     = errors.New("no trial is running")
+	errTrialSameAllowance = errors.New("the allowance of a promoted trial must differ from the trial allowance")
+)
+
+// trialAllowance returns the allowance of a trial with the provided funds. It
+// forms contracts with a few hosts for a short period.
+func trialAllowance(funds types.Currency) modules.Allowance {
+	return modules.Allowance{
+		Funds:       funds,
+		Hosts:       trialHosts,
+		Period:      trialPeriod,
+		RenewWindow: trialPeriod / 2,
+	}
+}
+
+// managedTrialExpired returns true if a trial is running whose contracts are
+// within their renew window. The contracts of the trial aren't renewed, the
+// trial needs to be promoted to continue using them.
+func (c *Contractor) managedTrialExpired() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.trial.Active && c.blockHeight+c.trial.Allowance.RenewWindow >= c.trial.EndHeight
+}
+
+// managedEndTrial ends the trial by cancelling its allowance. The trial is
+// still reported as expired until another trial is started or an allowance
+// is set.
+func (c *Contractor) managedEndTrial() error {
+	c.mu.RLock()
+	trial := c.trial
+	c.mu.RUnlock()
+	if err := c.managedCancelAllowance(); err != nil {
+		return err
+	}
+	trial.Active = false
+	trial.Expired = true
+	c.mu.Lock()
+	c.trial = trial
+	err := c.saveSync()
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	c.log.Println("INFO: the trial expired without being promoted")
+	return nil
+}
+
+// StartTrial sets an allowance with the provided funds that forms contracts
+// with a few hosts for a short period. The contracts are enough to upload and
+// download a test file. The trial ends when its contracts enter the renew
+// window unless it is promoted to a full allowance with PromoteTrial.
+func (c *Contractor) StartTrial(funds types.Currency) error {
+	if funds.IsZero() {
+		return errTrialNoFunds
+	}
+	a := trialAllowance(funds)
+	c.mu.Lock()
+	if c.trial.Active {
+		c.mu.Unlock()
+		return errTrialActive
+	} else if !reflect.DeepEqual(c.allowance, modules.Allowance{}) {
+		c.mu.Unlock()
+		return errTrialAllowanceSet
+	}
+	c.trial = modules.RenterTrial{
+		Active:      true,
+		Allowance:   a,
+		StartHeight: c.blockHeight,
+		EndHeight:   c.blockHeight + a.Period,
+	}
+	c.mu.Unlock()
+
+	if err := c.SetAllowance(a); err != nil {
+		c.mu.Lock()
+		c.trial = modules.RenterTrial{}
+		c.mu.Unlock()
+		return err
+	}
+	c.log.Println("INFO: started a trial with", funds.HumanString(), "for", a.Hosts, "hosts")
+	return nil
+}
+
+// PromoteTrial replaces the allowance of the running trial with a. The
+// contracts of the trial are kept and topped up to the funding a contract of
+// the new allowance would have, instead of forming new contracts with other
+// hosts.
+func (c *Contractor) PromoteTrial(a modules.Allowance) error {
+	c.mu.RLock()
+	trial := c.trial
+	c.mu.RUnlock()
+	if !trial.Active {
+		return errTrialNotActive
+	} else if reflect.DeepEqual(a, trial.Allowance) {
+		return errTrialSameAllowance
+	}
+
+	// Record the contracts of the trial as underfunded so that maintenance
+	// refreshes them to the funding of the new allowance. They are recorded
+	// before the allowance is set, so the round of maintenance started by
+	// SetAllowance already tops them up. The previous targets are restored if
+	// the allowance is rejected.
+	target := initialContractFunds(a)
+	previous := make(map[types.FileContractID]types.Currency)
+	var added []types.FileContractID
+	c.mu.Lock()
+	for _, contract := range c.staticContracts.ViewAll() {
+		if !contract.Utility.GoodForRenew || contract.TotalCost.Cmp(target) >= 0 {
+			continue
+		}
+		if old, exists := c.underfundedContracts[contract.ID]; exists {
+			previous[contract.ID] = old
+		} else {
+			added = append(added, contract.ID)
+		}
+		c.underfundedContracts[contract.ID] = target
+	}
+	c.mu.Unlock()
+
+	if err := c.SetAllowance(a); err != nil {
+		c.mu.Lock()
+		if !reflect.DeepEqual(c.allowance, a) {
+			for id, old := range previous {
+				c.underfundedContracts[id] = old
+			}
+			for _, id := range added {
+				delete(c.underfundedContracts, id)
+			}
+		}
+		c.mu.Unlock()
+		return err
+	}
+	c.log.Println("INFO: promoted the trial to the allowance", a)
+	return nil
+}
+
+// Trial returns the trial the contractor is running and what its contracts
+// have cost so far.
+func (c *Contractor) Trial() modules.RenterTrial {
+	c.mu.RLock()
+	trial := c.trial
+	c.mu.RUnlock()
+	if !trial.Active {
+		return trial
+	}
+	trial.Expired = c.managedTrialExpired()
+	for _, contract := range c.staticContracts.ViewAll() {
+		trial.Contracts++
+		trial.Spent = trial.Spent.Add(contract.TotalCost)
+	}
+	return trial
+}
@@ -0,0 +1,149 @@
+package contractor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestPromoteTrial checks that a rejected promotion leaves the contracts of
+// the trial alone and that a promotion tops them up to the funding of the new
+// allowance.
+func TestPromoteTrial(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	_, c, m, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.StartTrial(types.SiacoinPrecision.Mul64(50)); err != nil {
+		t.Fatal(err)
+	}
+	// Maintenance is skipped until the initial scan of the hostdb is done,
+	// mining a block starts another round.
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if len(c.Contracts()) == 0 {
+			if _, err := m.AddBlock(); err != nil {
+				return err
+			}
+			return errors.New("no contract formed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An invalid allowance is rejected and no top-ups remain recorded.
+	if err := c.PromoteTrial(modules.Allowance{Funds: types.SiacoinPrecision.Mul64(1000), Period: 100, RenewWindow: 50}); err != errAllowanceNoHosts {
+		t.Fatal("expected errAllowanceNoHosts, got", err)
+	}
+	c.mu.RLock()
+	underfunded := len(c.underfundedContracts)
+	c.mu.RUnlock()
+	if underfunded != 0 || !c.Trial().Active {
+		t.Fatal("rejected promotion changed the trial", underfunded, c.Trial())
+	}
+
+	// A valid promotion tops up the contract of the trial. The wallet might
+	// only fund part of the top-up, so the contract only needs to be
+	// refreshed with more funds.
+	trialContract := c.Contracts()[0]
+	a := modules.Allowance{
+		Funds:       types.SiacoinPrecision.Mul64(1000),
+		Hosts:       1,
+		Period:      100,
+		RenewWindow: 50,
+	}
+	if err := c.PromoteTrial(a); err != nil {
+		t.Fatal(err)
+	}
+	if c.Trial().Active {
+		t.Fatal("trial should have ended")
+	}
+	err = build.Retry(200, 100*time.Millisecond, func() error {
+		for _, contract := range c.Contracts() {
+			if contract.ID != trialContract.ID && contract.TotalCost.Cmp(trialContract.TotalCost) > 0 {
+				return nil
+			}
+		}
+		return errors.New("contract wasn't topped up")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestTrialEndsOnExpiry checks that maintenance ends a trial that expires
+// without being promoted and leaves its contracts to expire.
+func TestTrialEndsOnExpiry(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	_, c, m, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.StartTrial(types.SiacoinPrecision.Mul64(50)); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if len(c.Contracts()) == 0 {
+			if _, err := m.AddBlock(); err != nil {
+				return err
+			}
+			return errors.New("no contract formed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mine until the contracts of the trial are due for renewal.
+	trial := c.Trial()
+	for {
+		c.mu.RLock()
+		due := c.blockHeight+trial.Allowance.RenewWindow >= trial.EndHeight
+		c.mu.RUnlock()
+		if due {
+			break
+		}
+		if _, err := m.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if trial := c.Trial(); trial.Active || !trial.Expired {
+			return errors.New("trial wasn't ended")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := c.Allowance(); a.Hosts != 0 {
+		t.Fatal("allowance of the trial wasn't cancelled", a)
+	}
+	for _, contract := range c.Contracts() {
+		if contract.Utility.GoodForRenew {
+			t.Fatal("contract of the expired trial is still renewed")
+		}
+	}
+
+	// Another trial can be started afterwards.
+	if err := c.StartTrial(types.SiacoinPrecision.Mul64(50)); err != nil {
+		t.Fatal(err)
+	}
+}
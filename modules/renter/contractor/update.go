@@ -45,20 +45,13 @@ func (c *Contractor) managedArchiveContracts() {
 	}
 }
 
-// ProcessConsensusChange will be called by the consensus set every time there
-// is a change in the blockchain. Updates will always be called in order.
-func (c *Contractor) ProcessConsensusChange(cc modules.ConsensusChange) {
+// managedProcessChange updates the contractor after the consensus set
+// reverted and applied the given number of blocks, excluding the genesis
+// block. It is shared by full and header-only consensus changes.
+func (c *Contractor) managedProcessChange(reverted, applied int, id modules.ConsensusChangeID, synced bool) {
 	c.mu.Lock()
-	for _, block := range cc.RevertedBlocks {
-		if block.ID() != types.GenesisID {
-			c.blockHeight--
-		}
-	}
-	for _, block := range cc.AppliedBlocks {
-		if block.ID() != types.GenesisID {
-			c.blockHeight++
-		}
-	}
+	c.blockHeight -= types.BlockHeight(reverted)
+	c.blockHeight += types.BlockHeight(applied)
 
 	// If we have entered the next period, update currentPeriod
 	// NOTE: "period" refers to the duration of contracts, whereas "cycle"
@@ -69,7 +62,15 @@ func (c *Contractor) ProcessConsensusChange(cc modules.ConsensusChange) {
 		c.currentPeriod += cycleLen
 	}
 
-	c.lastChange = cc.ID
+	// Check the integrity of the data stored on the hosts once every
+	// IntegrityScanInterval blocks. A scan that is due while maintenance is
+	// paused runs after maintenance is resumed.
+	integrityScanDue := synced && !c.maintenancePaused && c.allowance.IntegrityScanInterval != 0 && c.blockHeight >= c.lastIntegrityScan+c.allowance.IntegrityScanInterval
+	if integrityScanDue {
+		c.lastIntegrityScan = c.blockHeight
+	}
+
+	c.lastChange = id
 	err := c.save()
 	if err != nil {
 		c.log.Println("Unable to save while processing a consensus change:", err)
@@ -79,7 +80,46 @@ func (c *Contractor) ProcessConsensusChange(cc modules.ConsensusChange) {
 	// Perform contract maintenance if our blockchain is synced. Use a separate
 	// goroutine so that the rest of the contractor is not blocked during
 	// maintenance.
-	if cc.Synced {
+	if synced {
 		go c.threadedContractMaintenance()
+		go c.threadedChallengeCapacity()
+	}
+	if integrityScanDue {
+		go c.threadedIntegrityScan()
+	}
+}
+
+// ProcessConsensusChange will be called by the consensus set every time there
+// is a change in the blockchain. Updates will always be called in order.
+func (c *Contractor) ProcessConsensusChange(cc modules.ConsensusChange) {
+	var reverted, applied int
+	for _, block := range cc.RevertedBlocks {
+		if block.ID() != types.GenesisID {
+			reverted++
+		}
+	}
+	for _, block := range cc.AppliedBlocks {
+		if block.ID() != types.GenesisID {
+			applied++
+		}
+	}
+	c.managedProcessChange(reverted, applied, cc.ID, cc.Synced)
+}
+
+// ProcessHeaderConsensusChange is called instead of ProcessConsensusChange
+// when the consensus set runs in spv mode. The contractor only tracks the
+// block height, so the headers carry all of the information it needs.
+func (c *Contractor) ProcessHeaderConsensusChange(hcc modules.HeaderConsensusChange) {
+	var reverted, applied int
+	for _, pbh := range hcc.RevertedBlockHeaders {
+		if pbh.BlockHeader.ID() != types.GenesisID {
+			reverted++
+		}
+	}
+	for _, pbh := range hcc.AppliedBlockHeaders {
+		if pbh.BlockHeader.ID() != types.GenesisID {
+			applied++
+		}
 	}
+	c.managedProcessChange(reverted, applied, hcc.ID, hcc.Synced)
 }
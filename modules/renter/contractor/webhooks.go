@@ -0,0 +1,239 @@
+package contractor
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+const (
+	// WebhookSignatureHeader is the HTTP header that carries the hex encoded
+	// HMAC-SHA256 of the request body, keyed with the secret of the webhook.
+	WebhookSignatureHeader = "Hyperspace-Signature"
+
+	// WebhookSecretMask replaces the secret of a webhook when the webhooks
+	// are listed.
+	WebhookSecretMask = "********"
+)
+
+var (
+	// errUnknownContractEvent is returned if a webhook subscribes to an event
+	// that doesn't exist.
+	errUnknownContractEvent = errors.New("unknown contract event")
+
+	// errWebhookNotFound is returned when unregistering a webhook that wasn't
+	// registered.
+	errWebhookNotFound = errors.New("no webhook with that url")
+)
+
+// validateWebhook checks that a webhook has an absolute http(s) URL and only
+// subscribes to known events.
+func validateWebhook(hook modules.RenterWebhook) error {
+	u, err := url.Parse(hook.URL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %v", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("webhook url needs to be an absolute http or https url")
+	}
+	for _, event := range hook.Events {
+		known := false
+		for _, e := range modules.ContractEvents {
+			known = known || e == event
+		}
+		if !known {
+			return fmt.Errorf("%v: %v", errUnknownContractEvent, event)
+		}
+	}
+	return nil
+}
+
+// subscribed returns true if the webhook wants to receive the event.
+func subscribed(hook modules.RenterWebhook, event string) bool {
+	if len(hook.Events) == 0 {
+		return true
+	}
+	for _, e := range hook.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// signWebhookPayload returns the hex encoded HMAC-SHA256 of the payload.
+func signWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverWebhook POSTs the payload to the webhook. Any response outside of the
+// 2xx range counts as a failed delivery. The request is aborted once stop is
+// closed, so a slow webhook doesn't hold up the shutdown of the contractor.
+func deliverWebhook(hook modules.RenterWebhook, payload []byte, stop <-chan struct{}) error {
+	req, err := http.NewRequest("POST", hook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, signWebhookPayload(hook.Secret, payload))
+	client := http.Client{Timeout: webhookTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %v", resp.Status)
+	}
+	return nil
+}
+
+// RegisterWebhook registers a webhook for contract lifecycle events and
+// persists it. A webhook with the same URL is replaced.
+func (c *Contractor) RegisterWebhook(hook modules.RenterWebhook) error {
+	if err := validateWebhook(hook); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.webhooks {
+		if c.webhooks[i].URL == hook.URL {
+			c.webhooks[i] = hook
+			return c.saveSync()
+		}
+	}
+	c.webhooks = append(c.webhooks, hook)
+	return c.saveSync()
+}
+
+// UnregisterWebhook removes the webhook with the given URL. Deliveries that are
+// already in progress are still completed.
+func (c *Contractor) UnregisterWebhook(hookURL string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.webhooks {
+		if c.webhooks[i].URL == hookURL {
+			c.webhooks = append(c.webhooks[:i], c.webhooks[i+1:]...)
+			return c.saveSync()
+		}
+	}
+	return errWebhookNotFound
+}
+
+// Webhooks returns the registered webhooks.
+func (c *Contractor) Webhooks() []modules.RenterWebhook {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	hooks := make([]modules.RenterWebhook, len(c.webhooks))
+	for i, hook := range c.webhooks {
+		hooks[i] = maskWebhook(hook)
+	}
+	return hooks
+}
+
+// maskWebhook returns a copy of the webhook without its secrets. The secret is
+// replaced by WebhookSecretMask and a password in the URL by "xxxxx".
+func maskWebhook(hook modules.RenterWebhook) modules.RenterWebhook {
+	hook.Events = append([]string(nil), hook.Events...)
+	if hook.Secret != "" {
+		hook.Secret = WebhookSecretMask
+	}
+	if u, err := url.Parse(hook.URL); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+			hook.URL = u.String()
+		}
+	}
+	return hook
+}
+
+// managedNotifyWebhooks sends a contract event to every webhook that is
+// subscribed to it. The deliveries happen in the background, so this never
+// blocks the state transition that caused the event.
+func (c *Contractor) managedNotifyWebhooks(event string, id types.FileContractID, hostKey types.SiaPublicKey, renewedFrom types.FileContractID) {
+	c.mu.RLock()
+	var hooks []modules.RenterWebhook
+	for _, hook := range c.webhooks {
+		if subscribed(hook, event) {
+			hooks = append(hooks, hook)
+		}
+	}
+	height := c.blockHeight
+	c.mu.RUnlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(modules.ContractEvent{
+		Event:         event,
+		ContractID:    id,
+		HostPublicKey: hostKey,
+		RenewedFrom:   renewedFrom,
+		BlockHeight:   height,
+		Timestamp:     time.Now(),
+	})
+	if err != nil {
+		c.log.Println("WARN: unable to encode contract event:", err)
+		return
+	}
+	for _, hook := range hooks {
+		go c.threadedDeliverWebhook(hook, payload)
+	}
+}
+
+// threadedDeliverWebhook delivers a payload to a webhook, retrying with an
+// exponential backoff if the delivery fails. The event is dropped after
+// webhookMaxAttempts failed attempts, or when the contractor shuts down.
+func (c *Contractor) threadedDeliverWebhook(hook modules.RenterWebhook, payload []byte) {
+	if err := c.tg.Add(); err != nil {
+		return
+	}
+	defer c.tg.Done()
+
+	interval := webhookRetryInterval
+	for attempt := 1; ; attempt++ {
+		err := deliverWebhook(hook, payload, c.tg.StopChan())
+		if err == nil {
+			return
+		}
+		select {
+		case <-c.tg.StopChan():
+			return
+		default:
+		}
+		if attempt >= webhookMaxAttempts {
+			c.log.Printf("WARN: dropping contract event for webhook %v after %v failed attempts: %v", hook.URL, attempt, err)
+			return
+		}
+		c.log.Debugf("Delivery to webhook %v failed, retrying in %v: %v", hook.URL, interval, err)
+		select {
+		case <-time.After(interval):
+		case <-c.tg.StopChan():
+			return
+		}
+		interval *= 2
+	}
+}
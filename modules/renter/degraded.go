@@ -0,0 +1,57 @@
+package renter
+
+// degraded.go tracks files that were uploaded with fewer contracts than their
+// erasure code needs. Such uploads are only accepted if the allowance allows
+// degraded uploads. The pieces that couldn't be placed are left for the repair
+// loop, which works on the chunks of degraded files before any other chunks
+// that are spread across enough hosts.
+
+import (
+	"fmt"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+)
+
+// alertCauseDegradedUpload is the cause of the alerts registered for files
+// that haven't reached their full redundancy since a degraded upload.
+const alertCauseDegradedUpload = "degraded upload"
+
+// degradedUploadAlertID returns the id of the alert that is registered while
+// the file at siaPath is degraded.
+func degradedUploadAlertID(siaPath string) string {
+	return "degradedupload:" + siaPath
+}
+
+// degradedUploadMinContracts returns the number of contracts an upload with
+// the erasure code ec needs if degraded uploads are allowed. Fewer contracts
+// than the number of data pieces would leave the file unrecoverable.
+func degradedUploadMinContracts(a modules.Allowance, ec modules.ErasureCoder) int {
+	minContracts := int(a.DegradedUploadMinHosts)
+	if minContracts < ec.MinPieces() {
+		minContracts = ec.MinPieces()
+	}
+	return minContracts
+}
+
+// managedUpdateDegraded clears the degraded flag of a file once it reached its
+// full redundancy. An alert is registered for as long as the file is degraded.
+func (r *Renter) managedUpdateDegraded(f *siafile.SiaFile, offline, goodForRenew map[string]bool) {
+	if !f.Degraded() {
+		return
+	}
+	ec := f.ErasureCode()
+	target := float64(ec.NumPieces()) / float64(ec.MinPieces())
+	redundancy := f.Redundancy(offline, goodForRenew)
+	if redundancy < target {
+		msg := fmt.Sprintf("%v was uploaded with fewer contracts than needed and has a redundancy of %.2f instead of %.2f", f.SiaPath(), redundancy, target)
+		r.managedRegisterAlert(degradedUploadAlertID(f.SiaPath()), alertCauseDegradedUpload, msg)
+		return
+	}
+	if err := f.SetDegraded(false); err != nil {
+		r.log.Println("WARN: unable to clear the degraded flag of", f.SiaPath(), err)
+		return
+	}
+	r.managedUnregisterAlert(degradedUploadAlertID(f.SiaPath()))
+	r.log.Println("Degraded upload reached its full redundancy:", f.SiaPath())
+}
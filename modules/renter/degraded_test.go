@@ -0,0 +1,39 @@
+package renter
+
+import (
+	"container/heap"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+)
+
+// TestDegradedUploadMinContracts checks that degraded uploads always need
+// enough contracts to recover the file.
+func TestDegradedUploadMinContracts(t *testing.T) {
+	rsc, _ := siafile.NewRSCode(10, 20)
+	if n := degradedUploadMinContracts(modules.Allowance{}, rsc); n != 10 {
+		t.Fatal("expected the number of data pieces, got", n)
+	}
+	if n := degradedUploadMinContracts(modules.Allowance{DegradedUploadMinHosts: 5}, rsc); n != 10 {
+		t.Fatal("minimum can't be below the number of data pieces, got", n)
+	}
+	if n := degradedUploadMinContracts(modules.Allowance{DegradedUploadMinHosts: 15}, rsc); n != 15 {
+		t.Fatal("expected the configured minimum, got", n)
+	}
+}
+
+// TestUploadHeapDegraded checks that the chunks of degraded uploads are
+// repaired before healthier chunks of other files, but after chunks that
+// aren't spread across enough hosts.
+func TestUploadHeapDegraded(t *testing.T) {
+	var uch uploadChunkHeap
+	heap.Push(&uch, &unfinishedUploadChunk{index: 1, piecesCompleted: 1, piecesNeeded: 10})
+	heap.Push(&uch, &unfinishedUploadChunk{index: 2, piecesCompleted: 8, piecesNeeded: 10, degraded: true})
+	heap.Push(&uch, &unfinishedUploadChunk{index: 3, piecesCompleted: 9, piecesNeeded: 10, minimumHosts: 5})
+	for _, index := range []uint64{3, 2, 1} {
+		if uc := heap.Pop(&uch).(*unfinishedUploadChunk); uc.index != index {
+			t.Fatalf("expected chunk %v, got %v", index, uc.index)
+		}
+	}
+}
@@ -1,5 +1,27 @@
 package renter
 
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+var (
+	// errDirRedundancyPieces is returned if only one of the erasure code
+	// parameters of a directory is set.
+	errDirRedundancyPieces = errors.New("datapieces and paritypieces must either both be set or both be zero")
+
+	// errUnknownDir is returned if no directory exists at the provided path.
+	errUnknownDir = errors.New("no directory known with that path")
+)
+
 // CreateDir creates a directory for the renter
 func (r *Renter) CreateDir(siaPath string) error {
 	err := r.tg.Add()
@@ -20,6 +42,54 @@ func (r *Renter) CreateDir(siaPath string) error {
 // 	return nil
 // }
 
+// DirInfo returns the directory located at siaPath together with its
+// subdirectories and the siapaths of the files it contains. The empty siapath
+// is the top level directory of the renter.
+func (r *Renter) DirInfo(siaPath string) (modules.DirectoryInfo, []modules.DirectoryInfo, []string, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.DirectoryInfo{}, nil, nil, err
+	}
+	defer r.tg.Done()
+	if siaPath != "" {
+		if err := validateSiapath(siaPath); err != nil {
+			return modules.DirectoryInfo{}, nil, nil, err
+		}
+	}
+	lockID := r.mu.RLock()
+	defer r.mu.RUnlock(lockID)
+	dirPath := filepath.Join(r.persistDir, siaPath)
+	if _, err := os.Stat(filepath.Join(dirPath, SiaDirMetadata)); err != nil {
+		return modules.DirectoryInfo{}, nil, nil, errUnknownDir
+	}
+	dir := r.dirInfo(siaPath)
+
+	// Only directories with a metadata file are directories of the renter,
+	// the others contain data the renter stages internally.
+	entries, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return modules.DirectoryInfo{}, nil, nil, err
+	}
+	var subDirs []modules.DirectoryInfo
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dirPath, entry.Name(), SiaDirMetadata)); err != nil {
+			continue
+		}
+		subDirs = append(subDirs, r.dirInfo(path.Join(siaPath, entry.Name())))
+	}
+
+	var files []string
+	for fileSiaPath := range r.files {
+		if parentSiaPath(fileSiaPath) == siaPath {
+			files = append(files, fileSiaPath)
+		}
+	}
+	sort.Strings(files)
+	return dir, subDirs, files, nil
+}
+
 // DirList returns directories and files stored in the directory located at `path`
 //
 // TODO: Implement
@@ -35,3 +105,90 @@ func (r *Renter) CreateDir(siaPath string) error {
 // files within func (r *Renter) RenameDir(currentPath, newPath string) error {
 //  return nil
 // }
+
+// SetDirRedundancy sets the erasure code that new uploads into the directory
+// located at siaPath and its subdirectories use, unless an upload specifies
+// its own. The directory is created if it doesn't exist. Setting both
+// parameters to zero makes the directory inherit the erasure code of its
+// parent again. Files that were uploaded already keep their redundancy.
+func (r *Renter) SetDirRedundancy(siaPath string, dataPieces, parityPieces int) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if (dataPieces == 0) != (parityPieces == 0) {
+		return errDirRedundancyPieces
+	} else if dataPieces != 0 {
+		if _, err := siafile.NewRSCode(dataPieces, parityPieces); err != nil {
+			return err
+		}
+	}
+	lockID := r.mu.Lock()
+	defer r.mu.Unlock(lockID)
+	dirPath := r.persistDir
+	if siaPath != "" {
+		if err := r.createDir(siaPath); err != nil {
+			return err
+		}
+		dirPath = filepath.Join(r.persistDir, siaPath)
+	} else if err := createDirMetadata(dirPath); err != nil {
+		return err
+	}
+	md, err := loadDirMetadata(dirPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to load directory metadata")
+	}
+	md.DataPieces = dataPieces
+	md.ParityPieces = parityPieces
+	return saveDirMetadata(dirPath, md)
+}
+
+// dirInfo returns the directory located at siaPath and its effective
+// redundancy. The caller must hold the renter's lock.
+func (r *Renter) dirInfo(siaPath string) modules.DirectoryInfo {
+	dataPieces, parityPieces, source, set := r.dirRedundancy(siaPath)
+	return modules.DirectoryInfo{
+		SiaPath:      siaPath,
+		DataPieces:   dataPieces,
+		ParityPieces: parityPieces,
+		Redundancy:   float64(dataPieces+parityPieces) / float64(dataPieces),
+		Inherited:    !set || source != siaPath,
+	}
+}
+
+// dirRedundancy returns the erasure code parameters of new uploads into the
+// directory located at siaPath. They are set by the directory itself or by its
+// closest parent that sets them, whose siapath is returned as well. If no
+// directory sets them, the defaults are returned and set is false. The caller
+// must hold the renter's lock.
+func (r *Renter) dirRedundancy(siaPath string) (dataPieces, parityPieces int, source string, set bool) {
+	for {
+		md, err := loadDirMetadata(filepath.Join(r.persistDir, siaPath))
+		if err == nil && md.DataPieces != 0 {
+			return md.DataPieces, md.ParityPieces, siaPath, true
+		}
+		if siaPath == "" {
+			return defaultDataPieces, defaultParityPieces, "", false
+		}
+		siaPath = parentSiaPath(siaPath)
+	}
+}
+
+// managedUploadErasureCode returns the erasure code of a new upload to siaPath
+// that doesn't specify its own, which is the one of the directory the file is
+// uploaded into.
+func (r *Renter) managedUploadErasureCode(siaPath string) (modules.ErasureCoder, error) {
+	lockID := r.mu.RLock()
+	dataPieces, parityPieces, _, _ := r.dirRedundancy(parentSiaPath(siaPath))
+	r.mu.RUnlock(lockID)
+	return siafile.NewRSCode(dataPieces, parityPieces)
+}
+
+// parentSiaPath returns the siapath of the directory containing siaPath. The
+// top level directory of the renter has the empty siapath.
+func parentSiaPath(siaPath string) string {
+	if dir := path.Dir(siaPath); dir != "." {
+		return dir
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package renter
+
+import (
+	"testing"
+)
+
+// TestDirRedundancy checks that the erasure code set for a directory is
+// inherited by its subdirectories and used for new uploads into them.
+func TestDirRedundancy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	if err := rt.renter.CreateDir("foo/bar"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.renter.SetDirRedundancy("foo", 2, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// The directory sets its own redundancy, the subdirectory inherits it and
+	// the top level directory uses the defaults.
+	dir, dirs, _, err := rt.renter.DirInfo("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir.DataPieces != 2 || dir.ParityPieces != 3 || dir.Inherited {
+		t.Fatal("unexpected redundancy of the directory:", dir)
+	}
+	if len(dirs) != 1 || dirs[0].SiaPath != "foo/bar" || dirs[0].DataPieces != 2 || dirs[0].ParityPieces != 3 || !dirs[0].Inherited {
+		t.Fatal("unexpected subdirectories:", dirs)
+	}
+	root, _, _, err := rt.renter.DirInfo("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.DataPieces != defaultDataPieces || root.ParityPieces != defaultParityPieces || !root.Inherited {
+		t.Fatal("unexpected redundancy of the top level directory:", root)
+	}
+
+	// New uploads into the subdirectory use the inherited erasure code.
+	ec, err := rt.renter.managedUploadErasureCode("foo/bar/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ec.MinPieces() != 2 || ec.NumPieces() != 5 {
+		t.Fatal("unexpected erasure code:", ec.MinPieces(), ec.NumPieces())
+	}
+
+	// Invalid parameters are rejected and unknown directories aren't found.
+	if err := rt.renter.SetDirRedundancy("foo", 2, 0); err != errDirRedundancyPieces {
+		t.Fatal("expected errDirRedundancyPieces, got", err)
+	}
+	if _, _, _, err := rt.renter.DirInfo("baz"); err != errUnknownDir {
+		t.Fatal("expected errUnknownDir, got", err)
+	}
+
+	// Clearing the redundancy restores the defaults.
+	if err := rt.renter.SetDirRedundancy("foo", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	ec, err = rt.renter.managedUploadErasureCode("foo/bar/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ec.MinPieces() != defaultDataPieces || ec.NumPieces() != defaultDataPieces+defaultParityPieces {
+		t.Fatal("unexpected erasure code after clearing the redundancy:", ec.MinPieces(), ec.NumPieces())
+	}
+}
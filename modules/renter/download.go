@@ -124,6 +124,7 @@ package renter
 // heap.
 
 import (
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -131,12 +132,14 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/HyperspaceApp/Hyperspace/crypto"
 	"github.com/HyperspaceApp/Hyperspace/modules"
 	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
 	"github.com/HyperspaceApp/Hyperspace/persist"
 	"github.com/HyperspaceApp/Hyperspace/types"
 
 	"github.com/HyperspaceApp/errors"
+	"github.com/HyperspaceApp/fastrand"
 )
 
 type (
@@ -159,6 +162,7 @@ type (
 		destination           downloadDestination
 		destinationString     string // The string reported to the user to indicate the download's destination.
 		staticDestinationType string // "memory buffer", "http stream", "file", etc.
+		staticID              string // Identifies the download in the download history.
 		staticLength          uint64 // Length to download starting from the offset.
 		staticOffset          uint64 // Offset within the file to start the download.
 		staticSiaPath         string // The path of the siafile at the time the download started.
@@ -168,6 +172,11 @@ type (
 		staticOverdrive     int           // How many extra pieces to download to prevent slow hosts from being a bottleneck.
 		staticPriority      uint64        // Downloads with higher priority will complete first.
 
+		// Throttle settings, see downloadthrottle.go.
+		nextChunkTime time.Time // The earliest time the next chunk of a throttled download is fetched.
+		paused        bool      // Set if no new chunks are fetched.
+		throttle      uint64    // Bytes per second the download is limited to, 0 if unlimited.
+
 		// Utilities.
 		log           *persist.Logger // Same log as the renter.
 		memoryManager *memoryManager  // Same memoryManager used across the renter.
@@ -187,6 +196,7 @@ type (
 		offset        uint64        // Offset within the file to start the download. Must be less than the total filesize.
 		overdrive     int           // How many extra pieces to download to prevent slow hosts from being a bottleneck.
 		priority      uint64        // Files with a higher priority will be downloaded first.
+		wholePack     bool          // Whether offset and length refer to the whole pack of a packed file instead of the file.
 	}
 )
 
@@ -260,6 +270,16 @@ func (r *Renter) DownloadAsync(p modules.RenterDownloadParameters) error {
 	return err
 }
 
+// tailRange returns the offset and length of the last tail bytes of a file of
+// the given size. Only the chunks overlapping the range are fetched, so the
+// cost of the download doesn't depend on the size of the file.
+func tailRange(size, tail uint64) (offset, length uint64) {
+	if tail >= size {
+		return 0, size
+	}
+	return size - tail, tail
+}
+
 // managedDownload performs a file download using the passed parameters and
 // returns the download object and an error that indicates if the download
 // setup was successful.
@@ -286,6 +306,12 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (*download,
 	if p.Destination != "" && !filepath.IsAbs(p.Destination) {
 		return nil, errors.New("destination must be an absolute path")
 	}
+	if p.Tail != 0 {
+		if p.Offset != 0 || p.Length != 0 {
+			return nil, errors.New("tail cannot be combined with offset or length")
+		}
+		p.Offset, p.Length = tailRange(file.Size(), p.Tail)
+	}
 	if p.Offset == file.Size() && file.Size() != 0 {
 		return nil, errors.New("offset equals filesize")
 	}
@@ -356,7 +382,13 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 	if params.offset < 0 {
 		return nil, errors.New("download offset cannot be a negative number")
 	}
-	if params.offset+params.length > params.file.Size() {
+	// The data of a packed file is located at its offset within the pack.
+	pack, packed := params.file.Packed()
+	size := params.file.Size()
+	if packed && params.wholePack {
+		size = pack.Length
+	}
+	if params.offset+params.length > size {
 		return nil, errors.New("download is requesting data past the boundary of the file")
 	}
 
@@ -369,6 +401,7 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 		destination:           params.destination,
 		destinationString:     params.destinationString,
 		staticDestinationType: params.destinationType,
+		staticID:              hex.EncodeToString(fastrand.Bytes(8)),
 		staticLatencyTarget:   params.latencyTarget,
 		staticLength:          params.length,
 		staticOffset:          params.offset,
@@ -398,15 +431,41 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 	// For each chunk, assemble a mapping from the contract id to the index of
 	// the piece within the chunk that the contract is responsible for.
 	chunkMaps := make([]map[string]downloadPieceInfo, maxChunk-minChunk+1)
+	chunkKeys := make([]crypto.CipherKey, maxChunk-minChunk+1)
+
+	// When cost optimized downloads are enabled, every chunk is fetched from
+	// the cheapest set of hosts that is sufficient to recover it. The other
+	// hosts only step in if one of those fails.
+	id := r.mu.RLock()
+	costOptimized := r.persist.CostOptimizedDownloads
+	r.mu.RUnlock(id)
+	var sectorPrices map[string]types.Currency
+	preferredHosts := make([]map[string]struct{}, maxChunk-minChunk+1)
+	if costOptimized {
+		sectorPrices = r.managedSectorDownloadPrices(params.file)
+	}
+	ec := params.file.ErasureCode()
 	for chunkIndex := minChunk; chunkIndex <= maxChunk; chunkIndex++ {
 		// Create the map.
 		chunkMaps[chunkIndex-minChunk] = make(map[string]downloadPieceInfo)
-		// Get the pieces for the chunk.
-		pieces, err := params.file.Pieces(uint64(chunkIndex))
+		// Get the pieces for the chunk together with their key. The key might
+		// differ between chunks while the file is being rekeyed.
+		pieces, key, err := params.file.ChunkPiecesAndKey(uint64(chunkIndex))
 		if err != nil {
 			return nil, err
 		}
+		chunkKeys[chunkIndex-minChunk] = key
+		if costOptimized {
+			// If the prices of too many hosts are unknown, there is no
+			// preference and the fastest hosts are used.
+			preferredHosts[chunkIndex-minChunk], _, _ = cheapestChunkHosts(pieces, sectorPrices, ec.MinPieces())
+		}
 		for pieceIndex, pieceSet := range pieces {
+			// Pieces beyond the erasure code's NumPieces were dropped by a
+			// concurrent change of the file's redundancy.
+			if pieceIndex >= ec.NumPieces() {
+				break
+			}
 			for _, piece := range pieceSet {
 				// Sanity check - the same worker should not have two pieces for
 				// the same chunk.
@@ -428,14 +487,15 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 	for i := minChunk; i <= maxChunk; i++ {
 		udc := &unfinishedDownloadChunk{
 			destination: params.destination,
-			erasureCode: params.file.ErasureCode(),
-			masterKey:   params.file.MasterKey(),
+			erasureCode: ec,
+			masterKey:   chunkKeys[i-minChunk],
 
-			staticChunkIndex: i,
-			staticCacheID:    fmt.Sprintf("%v:%v", d.staticSiaPath, i),
-			staticChunkMap:   chunkMaps[i-minChunk],
-			staticChunkSize:  params.file.ChunkSize(),
-			staticPieceSize:  params.file.PieceSize(),
+			staticChunkIndex:     i,
+			staticCacheID:        fmt.Sprintf("%v:%v", d.staticSiaPath, i),
+			staticChunkMap:       chunkMaps[i-minChunk],
+			staticPreferredHosts: preferredHosts[i-minChunk],
+			staticChunkSize:      params.file.ChunkSize(),
+			staticPieceSize:      params.file.PieceSize(),
 
 			// TODO: 25ms is just a guess for a good default. Really, we want to
 			// set the latency target such that slower workers will pick up the
@@ -450,8 +510,8 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 			staticNeedsMemory:   params.needsMemory,
 			staticPriority:      params.priority,
 
-			physicalChunkData: make([][]byte, params.file.ErasureCode().NumPieces()),
-			pieceUsage:        make([]bool, params.file.ErasureCode().NumPieces()),
+			physicalChunkData: make([][]byte, ec.NumPieces()),
+			pieceUsage:        make([]bool, ec.NumPieces()),
 
 			download:          d,
 			renterFile:        params.file,
@@ -472,6 +532,12 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 		} else {
 			udc.staticFetchLength = params.file.ChunkSize() - udc.staticFetchOffset
 		}
+		if packed && !params.wholePack {
+			udc.staticFetchOffset += pack.Offset
+			if udc.staticFetchOffset+udc.staticFetchLength > params.file.ChunkSize() {
+				udc.staticFetchLength = params.file.ChunkSize() - udc.staticFetchOffset
+			}
+		}
 		// Set the writeOffset within the destination for where the data should
 		// be written.
 		udc.staticWriteOffset = writeOffset
@@ -518,6 +584,7 @@ func (r *Renter) DownloadHistory() []modules.DownloadInfo {
 			Length:          d.staticLength,
 			Offset:          d.staticOffset,
 			SiaPath:         d.staticSiaPath,
+			ID:              d.staticID,
 
 			Completed:            d.staticComplete(),
 			EndTime:              d.endTime,
@@ -525,6 +592,9 @@ func (r *Renter) DownloadHistory() []modules.DownloadInfo {
 			StartTime:            d.staticStartTime,
 			StartTimeUnix:        d.staticStartTime.UnixNano(),
 			TotalDataTransferred: atomic.LoadUint64(&d.atomicTotalDataTransferred),
+
+			Paused:   d.paused,
+			Throttle: d.throttle,
 		}
 		// Release download lock before calling d.Err(), which will acquire the
 		// lock. The error needs to be checked separately because we need to
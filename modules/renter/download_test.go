@@ -300,3 +300,21 @@ func checkDownloadHistory(downloads []*download, check []int64) bool {
 	}
 	return true
 }
+
+// TestTailRange checks that the tail of a file is clamped to the file size.
+func TestTailRange(t *testing.T) {
+	tests := []struct {
+		size, tail, offset, length uint64
+	}{
+		{100, 10, 90, 10},
+		{100, 100, 0, 100},
+		{100, 1000, 0, 100},
+		{0, 10, 0, 0},
+	}
+	for _, test := range tests {
+		offset, length := tailRange(test.size, test.tail)
+		if offset != test.offset || length != test.length {
+			t.Errorf("tail %v of %v bytes: expected offset %v and length %v, got %v and %v", test.tail, test.size, test.offset, test.length, offset, length)
+		}
+	}
+}
@@ -50,6 +50,10 @@ type unfinishedDownloadChunk struct {
 	staticPieceSize   uint64
 	staticWriteOffset int64 // Offset within the writer to write the completed data.
 
+	// staticPreferredHosts is the set of hosts the chunk should be fetched
+	// from if possible. A nil set means that every host is equally good.
+	staticPreferredHosts map[string]struct{}
+
 	// Fetch + Write instructions - read only or otherwise thread safe.
 	staticLatencyTarget time.Duration
 	staticNeedsMemory   bool // Set to true if memory was not pre-allocated for this chunk.
@@ -62,6 +66,7 @@ type unfinishedDownloadChunk struct {
 	pieceUsage        []bool    // Which pieces are being actively fetched.
 	piecesCompleted   int       // Number of pieces that have successfully completed.
 	piecesRegistered  int       // Number of pieces that workers are actively fetching.
+	preferenceRelaxed bool      // Whether workers of hosts outside staticPreferredHosts may fetch pieces.
 	recoveryComplete  bool      // Whether or not the recovery has completed and the chunk memory released.
 	workersRemaining  int       // Number of workers still able to fetch the chunk.
 	workersStandby    []*worker // Set of workers that are able to work on this download, but are not needed unless other workers fail.
@@ -120,6 +125,11 @@ func (udc *unfinishedDownloadChunk) managedCleanUp() {
 		udc.mu.Unlock()
 		return
 	}
+	// The standby workers would be put back on standby if there is no
+	// preferred worker left that could help.
+	if udc.unprocessedWorkers() <= 0 {
+		udc.preferenceRelaxed = true
+	}
 
 	// Assemble a list of standby workers, release the udc lock, and then queue
 	// the chunk into the workers. The lock needs to be released early because
@@ -136,6 +146,23 @@ func (udc *unfinishedDownloadChunk) managedCleanUp() {
 	}
 }
 
+// unprocessedWorkers returns the number of workers that have neither
+// registered for a piece of the chunk nor been put on standby yet. The caller
+// needs to hold the udc lock.
+func (udc *unfinishedDownloadChunk) unprocessedWorkers() int {
+	return udc.workersRemaining - udc.piecesRegistered - len(udc.workersStandby)
+}
+
+// preferredWorker returns true if the chunk should be fetched from the host of
+// the worker when possible.
+func (udc *unfinishedDownloadChunk) preferredWorker(w *worker) bool {
+	if udc.staticPreferredHosts == nil {
+		return true
+	}
+	_, preferred := udc.staticPreferredHosts[string(w.contract.HostPublicKey.Key)]
+	return preferred
+}
+
 // managedRemoveWorker will decrement a worker from the set of remaining workers
 // in the udc. After a worker has been removed, the udc needs to be cleaned up.
 func (udc *unfinishedDownloadChunk) managedRemoveWorker() {
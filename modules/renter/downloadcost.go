@@ -0,0 +1,132 @@
+package renter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+var (
+	// errInsufficientPricedPieces is returned if a chunk doesn't have enough
+	// pieces on reachable hosts to estimate the cost of recovering it.
+	errInsufficientPricedPieces = errors.New("not enough pieces on reachable hosts to recover chunk")
+)
+
+// DownloadCost estimates the cost of downloading the whole file at siaPath
+// given the current download prices of the hosts storing it. No data is
+// fetched from the hosts.
+func (r *Renter) DownloadCost(siaPath string) (types.Currency, error) {
+	if err := r.tg.Add(); err != nil {
+		return types.ZeroCurrency, err
+	}
+	defer r.tg.Done()
+
+	// Lookup the file associated with the nickname.
+	lockID := r.mu.RLock()
+	file, exists := r.files[siaPath]
+	r.mu.RUnlock(lockID)
+	if !exists {
+		return types.ZeroCurrency, ErrUnknownPath
+	}
+
+	// Sum up the cost of the cheapest sufficient set of pieces of every chunk.
+	sectorPrices := r.managedSectorDownloadPrices(file)
+	var totalCost types.Currency
+	minPieces := file.ErasureCode().MinPieces()
+	for chunkIndex := uint64(0); chunkIndex < file.NumChunks(); chunkIndex++ {
+		pieces, err := file.Pieces(chunkIndex)
+		if err != nil {
+			return types.ZeroCurrency, err
+		}
+		chunkCost, err := cheapestChunkDownloadCost(pieces, sectorPrices, minPieces)
+		if err != nil {
+			return types.ZeroCurrency, errors.AddContext(err, fmt.Sprintf("unable to estimate download cost of chunk %v", chunkIndex))
+		}
+		totalCost = totalCost.Add(chunkCost)
+	}
+	return totalCost, nil
+}
+
+// managedSectorDownloadPrices returns the price of fetching a single sector
+// from every host that stores a piece of the file. Hosts without a contract or
+// which are offline will not get a worker assigned, so they can't serve any
+// pieces and are left out.
+func (r *Renter) managedSectorDownloadPrices(file *siafile.SiaFile) map[string]types.Currency {
+	sectorPrices := make(map[string]types.Currency)
+	for _, pk := range file.HostPublicKeys() {
+		if _, ok := r.hostContractor.ContractByPublicKey(pk); !ok {
+			continue
+		}
+		if r.managedIsOffline(pk) {
+			continue
+		}
+		host, ok := r.hostDB.Host(pk)
+		if !ok {
+			continue
+		}
+		sectorPrices[string(pk.Key)] = host.DownloadBandwidthPrice.Mul64(modules.SectorSize)
+	}
+	return sectorPrices
+}
+
+// cheapestChunkDownloadCost returns the cost of downloading the minPieces
+// cheapest unique pieces of a chunk.
+func cheapestChunkDownloadCost(pieces [][]siafile.Piece, sectorPrices map[string]types.Currency, minPieces int) (types.Currency, error) {
+	_, cost, err := cheapestChunkHosts(pieces, sectorPrices, minPieces)
+	return cost, err
+}
+
+// cheapestChunkHosts returns the set of hosts which can serve the minPieces
+// cheapest unique pieces of a chunk together with the cost of downloading
+// them. Hosts are mapped to pieces the same way managedNewDownload builds the
+// chunk map, so every host serves at most one piece. Hosts that are missing
+// from sectorPrices are ignored.
+func cheapestChunkHosts(pieces [][]siafile.Piece, sectorPrices map[string]types.Currency, minPieces int) (map[string]struct{}, types.Currency, error) {
+	// Assign every host to the piece it would be responsible for.
+	hostPieces := make(map[string]int)
+	for pieceIndex, pieceSet := range pieces {
+		for _, piece := range pieceSet {
+			hostPieces[string(piece.HostPubKey.Key)] = pieceIndex
+		}
+	}
+
+	// Find the cheapest host for every piece.
+	type pieceHost struct {
+		host  string
+		price types.Currency
+	}
+	cheapest := make(map[int]pieceHost)
+	for host, pieceIndex := range hostPieces {
+		price, ok := sectorPrices[host]
+		if !ok {
+			continue
+		}
+		if ph, exists := cheapest[pieceIndex]; !exists || price.Cmp(ph.price) < 0 {
+			cheapest[pieceIndex] = pieceHost{host: host, price: price}
+		}
+	}
+	if len(cheapest) < minPieces {
+		return nil, types.ZeroCurrency, errInsufficientPricedPieces
+	}
+
+	// Pick the cheapest pieces and add up their prices.
+	candidates := make([]pieceHost, 0, len(cheapest))
+	for _, ph := range cheapest {
+		candidates = append(candidates, ph)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].price.Cmp(candidates[j].price) < 0
+	})
+	hosts := make(map[string]struct{}, minPieces)
+	var cost types.Currency
+	for _, ph := range candidates[:minPieces] {
+		hosts[ph.host] = struct{}{}
+		cost = cost.Add(ph.price)
+	}
+	return hosts, cost, nil
+}
@@ -0,0 +1,99 @@
+package renter
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestCheapestChunkDownloadCost checks that the cheapest sufficient set of
+// pieces is selected when estimating the download cost of a chunk.
+func TestCheapestChunkDownloadCost(t *testing.T) {
+	hostKey := func(b byte) types.SiaPublicKey {
+		return types.SiaPublicKey{Key: []byte{b}}
+	}
+	// Piece 0 lives on hosts 1 and 2, piece 1 on host 3 and piece 2 on host 4.
+	pieces := [][]siafile.Piece{
+		{{HostPubKey: hostKey(1)}, {HostPubKey: hostKey(2)}},
+		{{HostPubKey: hostKey(3)}},
+		{{HostPubKey: hostKey(4)}},
+	}
+	prices := map[string]types.Currency{
+		string(hostKey(1).Key): types.NewCurrency64(50),
+		string(hostKey(2).Key): types.NewCurrency64(10),
+		string(hostKey(3).Key): types.NewCurrency64(30),
+		string(hostKey(4).Key): types.NewCurrency64(20),
+	}
+
+	// With a single required piece, the cheapest host of piece 0 wins.
+	cost, err := cheapestChunkDownloadCost(pieces, prices, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost.Cmp64(10) != 0 {
+		t.Fatal("expected cost of 10 but got", cost)
+	}
+	// Two pieces should use hosts 2 and 4.
+	cost, err = cheapestChunkDownloadCost(pieces, prices, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost.Cmp64(30) != 0 {
+		t.Fatal("expected cost of 30 but got", cost)
+	}
+	// Without a price for host 4, piece 1 has to be used instead.
+	delete(prices, string(hostKey(4).Key))
+	cost, err = cheapestChunkDownloadCost(pieces, prices, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost.Cmp64(40) != 0 {
+		t.Fatal("expected cost of 40 but got", cost)
+	}
+	// Three pieces can't be recovered anymore.
+	if _, err := cheapestChunkDownloadCost(pieces, prices, 3); err != errInsufficientPricedPieces {
+		t.Fatal("expected errInsufficientPricedPieces but got", err)
+	}
+}
+
+// TestCheapestChunkHosts checks that the hosts serving the cheapest sufficient
+// set of pieces are picked with at most one host per piece.
+func TestCheapestChunkHosts(t *testing.T) {
+	hostKey := func(b byte) types.SiaPublicKey {
+		return types.SiaPublicKey{Key: []byte{b}}
+	}
+	// Piece 0 lives on hosts 1 and 2, piece 1 on hosts 3 and 4 and piece 2 on
+	// host 5.
+	pieces := [][]siafile.Piece{
+		{{HostPubKey: hostKey(1)}, {HostPubKey: hostKey(2)}},
+		{{HostPubKey: hostKey(3)}, {HostPubKey: hostKey(4)}},
+		{{HostPubKey: hostKey(5)}},
+	}
+	prices := map[string]types.Currency{
+		string(hostKey(1).Key): types.NewCurrency64(10),
+		string(hostKey(2).Key): types.NewCurrency64(5),
+		string(hostKey(3).Key): types.NewCurrency64(40),
+		string(hostKey(4).Key): types.NewCurrency64(50),
+		string(hostKey(5).Key): types.NewCurrency64(20),
+	}
+	hosts, cost, err := cheapestChunkHosts(pieces, prices, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost.Cmp64(25) != 0 {
+		t.Fatal("expected cost of 25 but got", cost)
+	}
+	if len(hosts) != 2 {
+		t.Fatal("expected 2 hosts but got", len(hosts))
+	}
+	for _, b := range []byte{2, 5} {
+		if _, ok := hosts[string(hostKey(b).Key)]; !ok {
+			t.Fatalf("host %v should be in the cheapest set", b)
+		}
+	}
+	// Without enough priced pieces no set can be found.
+	if _, _, err := cheapestChunkHosts(pieces, prices, 4); err != errInsufficientPricedPieces {
+		t.Fatal("expected errInsufficientPricedPieces but got", err)
+	}
+}
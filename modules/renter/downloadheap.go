@@ -136,21 +136,72 @@ func (r *Renter) managedDistributeDownloadChunkToWorkers(udc *unfinishedDownload
 	udc.managedCleanUp()
 }
 
-// managedNextDownloadChunk will fetch the next chunk from the download heap. If
-// the download heap is empty, 'nil' will be returned.
-func (r *Renter) managedNextDownloadChunk() *unfinishedDownloadChunk {
+// managedNextDownloadChunk will fetch the next chunk from the download heap.
+// Chunks of paused downloads are moved out of the heap until the download is
+// resumed, chunks of throttled downloads that have to wait are skipped and
+// stay in the heap. If no chunk can be fetched, 'nil' will be returned
+// together with the time until the next chunk of a throttled download can be
+// fetched, which is zero if there is no such chunk.
+func (r *Renter) managedNextDownloadChunk() (*unfinishedDownloadChunk, time.Duration) {
 	r.downloadHeapMu.Lock()
 	defer r.downloadHeapMu.Unlock()
 
-	for {
-		if r.downloadHeap.Len() <= 0 {
-			return nil
+	// Paused downloads that failed in the meantime won't be resumed anymore.
+	for d := range r.pausedDownloadChunks {
+		if d.staticComplete() {
+			delete(r.pausedDownloadChunks, d)
+		}
+	}
+
+	// The chunks of a download need to be fetched in order, so all chunks of
+	// a download are skipped once one of them was.
+	var skipped []*unfinishedDownloadChunk
+	skippedDownloads := make(map[*download]struct{})
+	defer func() {
+		for _, udc := range skipped {
+			heap.Push(r.downloadHeap, udc)
 		}
+	}()
+	var wait time.Duration
+	now := time.Now()
+	for r.downloadHeap.Len() > 0 {
 		nextChunk := heap.Pop(r.downloadHeap).(*unfinishedDownloadChunk)
-		if !nextChunk.download.staticComplete() {
-			return nextChunk
+		d := nextChunk.download
+		if d.staticComplete() {
+			continue
+		}
+		if d.managedPaused() {
+			r.pausedDownloadChunks[d] = append(r.pausedDownloadChunks[d], nextChunk)
+			continue
+		}
+		if _, skip := skippedDownloads[d]; skip {
+			skipped = append(skipped, nextChunk)
+			continue
+		}
+		ready, chunkWait := d.managedScheduleChunk(nextChunk.staticFetchLength, now)
+		if !ready {
+			skippedDownloads[d] = struct{}{}
+			skipped = append(skipped, nextChunk)
+			if chunkWait > 0 && (wait == 0 || chunkWait < wait) {
+				wait = chunkWait
+			}
+			continue
 		}
+		return nextChunk, 0
 	}
+	return nil, wait
+}
+
+// managedResumeDownloadChunks moves the chunks of a download that were set
+// aside while it was paused back into the download heap. It needs to be
+// called after the pause of the download was removed.
+func (r *Renter) managedResumeDownloadChunks(d *download) {
+	r.downloadHeapMu.Lock()
+	defer r.downloadHeapMu.Unlock()
+	for _, udc := range r.pausedDownloadChunks[d] {
+		heap.Push(r.downloadHeap, udc)
+	}
+	delete(r.pausedDownloadChunks, d)
 }
 
 // threadedDownloadLoop utilizes the worker pool to make progress on any queued
@@ -175,6 +226,7 @@ LOOP:
 		// reset after a certain amount of time has passed.
 		r.managedUpdateWorkerPool()
 		workerUpdateTime := time.Now()
+		var throttleWait time.Duration
 
 		// Pull downloads out of the heap. Will break if the heap is empty, and
 		// will reset to the top of the outer loop if a reset condition is met.
@@ -189,9 +241,10 @@ LOOP:
 			}
 
 			// Get the next chunk.
-			nextChunk := r.managedNextDownloadChunk()
+			nextChunk, wait := r.managedNextDownloadChunk()
 			if nextChunk == nil {
 				// Break out of the inner loop and wait for more work.
+				throttleWait = wait
 				break
 			}
 
@@ -209,11 +262,17 @@ LOOP:
 			r.managedDistributeDownloadChunkToWorkers(nextChunk)
 		}
 
-		// Wait for more work.
+		// Wait for more work. If chunks of throttled downloads are waiting,
+		// the heap is checked again once the first of them can be fetched.
+		var throttleChan <-chan time.Time
+		if throttleWait > 0 {
+			throttleChan = time.After(throttleWait)
+		}
 		select {
 		case <-r.tg.StopChan():
 			return
 		case <-r.newDownloads:
+		case <-throttleChan:
 		}
 	}
 }
@@ -0,0 +1,256 @@
+package renter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+var (
+	// errRepairCipherUnsupported is returned if a local copy of a file can't
+	// be verified because the file is encrypted with a cipher that uses a
+	// random nonce. The encrypted pieces can't be recreated from the local
+	// data, so their Merkle roots can't be compared.
+	errRepairCipherUnsupported = errors.New("local copies of files encrypted with twofish can't be verified")
+)
+
+// downloadDestinationOffset is a downloadDestination that writes to a fixed
+// offset within a file. It is used to patch single chunks of a local copy of a
+// file. Closing it doesn't close the underlying file, which is shared by the
+// downloads of all the chunks that are repaired.
+type downloadDestinationOffset struct {
+	file   *os.File
+	offset int64
+}
+
+// Close implements the downloadDestination interface.
+func (dd downloadDestinationOffset) Close() error {
+	return nil
+}
+
+// WriteAt writes data to the file, shifted by the offset of the destination.
+func (dd downloadDestinationOffset) WriteAt(data []byte, offset int64) (int, error) {
+	return dd.file.WriteAt(data, dd.offset+offset)
+}
+
+// verifyLocalChunk compares the data of a chunk in a local copy of a file
+// against the Merkle roots of the pieces stored on the hosts. The pieces are
+// recreated by erasure coding and encrypting the local data. The data pieces
+// cover the whole chunk, so the parity pieces are only checked if some data
+// pieces weren't uploaded. 'verifiable' is false if none of the pieces of the
+// chunk are available.
+//
+// The chunk of a packed file is shared with the other members of its pack, so
+// its logical data is assembled from the local copy at the offset of the file
+// and the local copies of the other members. If the pack can't be assembled
+// because another member is missing locally, the chunk is reported as not
+// matching, since the file is small enough to simply be downloaded again.
+func verifyLocalChunk(file *siafile.SiaFile, local io.ReaderAt, chunkIndex uint64, members []packMember) (verifiable, matches bool, err error) {
+	pieces, key, err := file.ChunkPiecesAndKey(chunkIndex)
+	if err != nil {
+		return false, false, err
+	}
+	ec := file.ErasureCode()
+	dataPiecesAvailable := 0
+	piecesAvailable := 0
+	for i, pieceSet := range pieces {
+		if len(pieceSet) == 0 {
+			continue
+		}
+		piecesAvailable++
+		if i < ec.MinPieces() {
+			dataPiecesAvailable++
+		}
+	}
+	if piecesAvailable == 0 {
+		return false, false, nil
+	}
+
+	// The tail of the last chunk is padded with zeros, the same way it is
+	// when the chunk is uploaded.
+	chunkSize := file.ChunkSize()
+	buf := NewDownloadDestinationBuffer(chunkSize, file.PieceSize())
+	if pack, packed := file.Packed(); packed {
+		assembled, err := readLocalPack(buf, file, local, pack, members)
+		if err != nil {
+			return false, false, err
+		} else if !assembled {
+			return true, false, nil
+		}
+	} else {
+		sr := io.NewSectionReader(local, int64(chunkIndex*chunkSize), int64(chunkSize))
+		if _, err := buf.ReadFrom(sr); err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return false, false, errors.AddContext(err, "failed to read local chunk")
+		}
+	}
+	shards, err := ec.EncodeShards(buf.buf, file.PieceSize())
+	if err != nil {
+		return false, false, errors.AddContext(err, "failed to encode local chunk")
+	}
+
+	checkParity := dataPiecesAvailable < ec.MinPieces()
+	for i, pieceSet := range pieces {
+		if len(pieceSet) == 0 || (i >= ec.MinPieces() && !checkParity) {
+			continue
+		}
+		root := crypto.MerkleRoot(key.Derive(chunkIndex, uint64(i)).EncryptBytes(shards[i]))
+		for _, piece := range pieceSet {
+			if piece.MerkleRoot != root {
+				return true, false, nil
+			}
+		}
+	}
+	return true, true, nil
+}
+
+// readLocalPack assembles the logical data of the chunk of a pack. The data of
+// file is read from local, the data of the other members from their local
+// copies. 'assembled' is false if the members don't cover the whole pack or if
+// the local copy of another member can't be read.
+func readLocalPack(buf downloadDestinationBuffer, file *siafile.SiaFile, local io.ReaderAt, pack siafile.PackedChunk, members []packMember) (assembled bool, err error) {
+	data := make([]byte, file.Size())
+	if _, err := local.ReadAt(data, 0); err != nil && err != io.EOF {
+		return false, errors.AddContext(err, "failed to read local file")
+	}
+	if _, err := buf.WriteAt(data, int64(pack.Offset)); err != nil {
+		return false, err
+	}
+	covered := file.Size()
+	for _, m := range members {
+		if m.file == file {
+			continue
+		}
+		if _, err := readPackMember(buf, m); err != nil {
+			return false, nil
+		}
+		covered += m.file.Size()
+	}
+	return covered == pack.Length, nil
+}
+
+// RepairDownload verifies a local copy of a file chunk by chunk and downloads
+// the chunks which don't match the pieces on the hosts again, patching the
+// local file in place. If the size of the local file doesn't match the size of
+// the file, it is truncated or extended first. Extended regions are zeroed and
+// therefore only repaired if the chunks they belong to don't match.
+func (r *Renter) RepairDownload(siaPath, localPath string) (modules.DownloadRepairResult, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.DownloadRepairResult{}, err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.RLock()
+	file, exists := r.files[siaPath]
+	r.mu.RUnlock(id)
+	if !exists {
+		return modules.DownloadRepairResult{}, ErrUnknownPath
+	}
+	if !filepath.IsAbs(localPath) {
+		return modules.DownloadRepairResult{}, errors.New("destination must be an absolute path")
+	}
+	if file.MasterKey().Type() == crypto.TypeTwofish {
+		return modules.DownloadRepairResult{}, errRepairCipherUnsupported
+	}
+
+	osFile, err := os.OpenFile(localPath, os.O_RDWR, 0)
+	if err != nil {
+		return modules.DownloadRepairResult{}, err
+	}
+	defer osFile.Close()
+	fi, err := osFile.Stat()
+	if err != nil {
+		return modules.DownloadRepairResult{}, err
+	}
+	var result modules.DownloadRepairResult
+	if uint64(fi.Size()) != file.Size() {
+		result.SizeMismatch = true
+		if err := osFile.Truncate(int64(file.Size())); err != nil {
+			return result, errors.AddContext(err, "unable to resize local file")
+		}
+	}
+
+	for chunkIndex := uint64(0); chunkIndex < file.NumChunks(); chunkIndex++ {
+		if chunkIndex*file.ChunkSize() >= file.Size() {
+			break
+		}
+		verifiable, matches, err := r.managedVerifyLocalChunk(file, osFile, chunkIndex)
+		if err != nil {
+			return result, errors.AddContext(err, fmt.Sprintf("unable to verify chunk %v", chunkIndex))
+		}
+		if !verifiable {
+			result.ChunksUnavailable++
+			continue
+		}
+		result.ChunksVerified++
+		if matches {
+			continue
+		}
+		if err := r.managedRepairLocalChunk(file, osFile, chunkIndex); err != nil {
+			return result, errors.AddContext(err, fmt.Sprintf("unable to repair chunk %v", chunkIndex))
+		}
+		result.ChunksRepaired++
+	}
+	if err := osFile.Sync(); err != nil {
+		return result, err
+	}
+	r.log.Printf("Verified local copy %v of %v, repaired %v chunks", localPath, siaPath, result.ChunksRepaired)
+	return result, nil
+}
+
+// managedVerifyLocalChunk calls verifyLocalChunk after acquiring the memory
+// needed to recreate the pieces of the chunk.
+func (r *Renter) managedVerifyLocalChunk(file *siafile.SiaFile, local io.ReaderAt, chunkIndex uint64) (verifiable, matches bool, err error) {
+	ec := file.ErasureCode()
+	memoryNeeded := file.PieceSize()*uint64(ec.NumPieces()+ec.MinPieces()) + uint64(ec.NumPieces())*file.MasterKey().Type().Overhead()
+	if !r.memoryManager.Request(memoryNeeded, memoryPriorityLow) {
+		return false, false, errors.New("unable to acquire memory to verify chunk")
+	}
+	defer r.memoryManager.Return(memoryNeeded)
+	var members []packMember
+	if pack, packed := file.Packed(); packed {
+		id := r.mu.RLock()
+		members = r.packs[pack.ID]
+		r.mu.RUnlock(id)
+	}
+	return verifyLocalChunk(file, local, chunkIndex, members)
+}
+
+// managedRepairLocalChunk downloads a single chunk of a file and writes it to
+// the chunk's offset within the local file.
+func (r *Renter) managedRepairLocalChunk(file *siafile.SiaFile, local *os.File, chunkIndex uint64) error {
+	offset := chunkIndex * file.ChunkSize()
+	length := file.ChunkSize()
+	if offset+length > file.Size() {
+		length = file.Size() - offset
+	}
+	d, err := r.managedNewDownload(downloadParams{
+		destination:       downloadDestinationOffset{file: local, offset: int64(offset)},
+		destinationType:   "file",
+		destinationString: local.Name(),
+		file:              file,
+
+		latencyTarget: 25e3 * time.Millisecond,
+		length:        length,
+		needsMemory:   true,
+		offset:        offset,
+		overdrive:     3,
+		priority:      5,
+	})
+	if err != nil {
+		return err
+	}
+	select {
+	case <-d.completeChan:
+	case <-r.tg.StopChan():
+		return errors.New("repair download interrupted by stop call")
+	}
+	return d.Err()
+}
@@ -0,0 +1,213 @@
+package renter
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/Hyperspace/types"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+// TestVerifyLocalChunk checks that corrupt chunks of a local copy of a file
+// are detected by comparing them against the Merkle roots of the pieces.
+func TestVerifyLocalChunk(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a file with 3 chunks, the last one being partial.
+	rc, err := siafile.NewRSCode(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := hex.EncodeToString(fastrand.Bytes(8))
+	siaFilePath := filepath.Join(os.TempDir(), "siafiles", name)
+	if err := os.MkdirAll(filepath.Dir(siaFilePath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	key := crypto.GenerateSiaKey(crypto.TypeThreefish)
+	chunkSize := (modules.SectorSize - key.Type().Overhead()) * uint64(rc.MinPieces())
+	fileSize := 2*chunkSize + chunkSize/2
+	sf, err := siafile.New(siaFilePath, name, "", newTestingWal(), rc, key, fileSize, 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(fileSize))
+
+	// Upload the data piece of the first two chunks. The last chunk doesn't
+	// have any pieces.
+	for chunkIndex := uint64(0); chunkIndex < 2; chunkIndex++ {
+		piece := make([]byte, sf.PieceSize())
+		copy(piece, data[chunkIndex*chunkSize:])
+		root := crypto.MerkleRoot(sf.ChunkKey(chunkIndex).Derive(chunkIndex, 0).EncryptBytes(piece))
+		if err := sf.AddPiece(types.SiaPublicKey{Key: []byte{1}}, chunkIndex, 0, root); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// An intact local copy matches.
+	localPath := filepath.Join(build.TempDir("renter", t.Name()), "local")
+	if err := os.MkdirAll(filepath.Dir(localPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(localPath, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	local, err := os.Open(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for chunkIndex := uint64(0); chunkIndex < 2; chunkIndex++ {
+		verifiable, matches, err := verifyLocalChunk(sf, local, chunkIndex, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !verifiable || !matches {
+			t.Fatalf("chunk %v should match", chunkIndex)
+		}
+	}
+	if verifiable, _, err := verifyLocalChunk(sf, local, 2, nil); err != nil || verifiable {
+		t.Fatal("chunk without pieces shouldn't be verifiable", err)
+	}
+	local.Close()
+
+	// Corrupt a single byte of the second chunk.
+	corrupt := append([]byte(nil), data...)
+	corrupt[chunkSize+1]++
+	if err := ioutil.WriteFile(localPath, corrupt, 0600); err != nil {
+		t.Fatal(err)
+	}
+	local, err = os.Open(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Close()
+	if _, matches, err := verifyLocalChunk(sf, local, 0, nil); err != nil || !matches {
+		t.Fatal("first chunk should still match", err)
+	}
+	if _, matches, err := verifyLocalChunk(sf, local, 1, nil); err != nil || matches {
+		t.Fatal("corrupt chunk shouldn't match", err)
+	}
+
+	// Patching the chunk through a downloadDestinationOffset writes it to the
+	// chunk's offset.
+	rw, err := os.OpenFile(localPath, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rw.Close()
+	dd := downloadDestinationOffset{file: rw, offset: int64(chunkSize)}
+	if _, err := dd.WriteAt(data[chunkSize:2*chunkSize], 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, matches, err := verifyLocalChunk(sf, local, 1, nil); err != nil || !matches {
+		t.Fatal("patched chunk should match", err)
+	}
+	patched, err := ioutil.ReadFile(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(patched, data) {
+		t.Fatal("patched file doesn't match the original data")
+	}
+}
+
+// TestVerifyLocalPackedChunk checks that the local copy of a packed file is
+// verified at its offset within the pack, using the local copies of the other
+// files of the pack.
+func TestVerifyLocalPackedChunk(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rc, err := siafile.NewRSCode(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	key := crypto.GenerateSiaKey(crypto.TypeThreefish)
+	pc := siafile.PackedChunk{Length: 600}
+	var members []packMember
+	var packData []byte
+	for _, size := range []uint64{100, 200, 300} {
+		name := hex.EncodeToString(fastrand.Bytes(8))
+		data := fastrand.Bytes(int(size))
+		localPath := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(localPath, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		sf, err := siafile.New(filepath.Join(dir, name+ShareExtension), name, localPath, newTestingWal(), rc, crypto.GenerateSiaKey(crypto.TypeThreefish), size, 0777)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sf.SetPacked(key, pc); err != nil {
+			t.Fatal(err)
+		}
+		members = append(members, packMember{file: sf, offset: pc.Offset})
+		packData = append(packData, data...)
+		pc.Offset += size
+	}
+
+	// Every file of the pack gets the data piece of the pack.
+	piece := make([]byte, members[0].file.PieceSize())
+	copy(piece, packData)
+	root := crypto.MerkleRoot(key.Derive(0, 0).EncryptBytes(piece))
+	for _, m := range members {
+		if err := m.file.AddPiece(types.SiaPublicKey{Key: []byte{1}}, 0, 0, root); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	open := func(m packMember) *os.File {
+		local, err := os.Open(m.file.LocalPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return local
+	}
+
+	// The local copy of every file matches at its own offset.
+	for i, m := range members {
+		local := open(m)
+		verifiable, matches, err := verifyLocalChunk(m.file, local, 0, members)
+		local.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !verifiable || !matches {
+			t.Fatalf("local copy of packed file %v should match", i)
+		}
+	}
+
+	// The local copy of another file of the pack doesn't match.
+	local := open(members[2])
+	defer local.Close()
+	if _, matches, err := verifyLocalChunk(members[1].file, local, 0, members); err != nil || matches {
+		t.Fatal("local copy of a different file shouldn't match", err)
+	}
+
+	// If a member of the pack is missing or not available locally, the pack
+	// can't be assembled.
+	if _, matches, err := verifyLocalChunk(members[2].file, local, 0, members[1:]); err != nil || matches {
+		t.Fatal("pack without all members shouldn't match", err)
+	}
+	if err := os.Remove(members[0].file.LocalPath()); err != nil {
+		t.Fatal(err)
+	}
+	if verifiable, matches, err := verifyLocalChunk(members[2].file, local, 0, members); err != nil || !verifiable || matches {
+		t.Fatal("pack with a missing local copy shouldn't match", err)
+	}
+}
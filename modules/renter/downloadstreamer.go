@@ -130,6 +130,8 @@ func (s *streamer) Seek(offset int64, whence int) (int64, error) {
 		newOffset = s.offset
 	case io.SeekEnd:
 		newOffset = int64(s.file.Size())
+	default:
+		return s.offset, errors.New("invalid whence")
 	}
 	newOffset += offset
 
@@ -0,0 +1,120 @@
+package renter
+
+// Downloads can be throttled or paused individually. The limit is enforced
+// when the chunks of the download are taken from the download heap: a chunk of
+// a throttled download is only handed to the workers once the previous chunks
+// of the download had enough time to be fetched at the download's rate, and
+// the chunks of a paused download aren't handed out at all. Skipped chunks of
+// throttled downloads stay in the heap, the chunks of paused downloads are set
+// aside until the download is resumed, so the other downloads are scheduled
+// as usual and the skipped download continues where it left off. Chunks that
+// were handed to the workers already aren't interrupted, so the rate is
+// averaged over whole chunks.
+
+import (
+	"time"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+var (
+	// errDownloadComplete is returned when throttling a download that
+	// completed already.
+	errDownloadComplete = errors.New("download is already complete")
+
+	// errUnknownDownload is returned if no download in the download history
+	// has the provided id.
+	errUnknownDownload = errors.New("no download with that id")
+)
+
+// managedPaused returns true if no new chunks of the download should be
+// fetched.
+func (d *download) managedPaused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.paused
+}
+
+// managedScheduleChunk returns whether a chunk of the download that fetches
+// length bytes may be handed to the workers now. If the download is throttled
+// and the chunk may be handed out later, the time until then is returned as
+// well. The caller has to hand the chunk to the workers if it may be handed
+// out, since it counts towards the throttle of the download.
+func (d *download) managedScheduleChunk(length uint64, now time.Time) (bool, time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.paused {
+		return false, 0
+	}
+	if d.throttle == 0 {
+		return true, 0
+	}
+	if now.Before(d.nextChunkTime) {
+		return false, d.nextChunkTime.Sub(now)
+	}
+	d.nextChunkTime = now.Add(time.Duration(float64(length) / float64(d.throttle) * float64(time.Second)))
+	return true, 0
+}
+
+// managedDownloadByID returns the download of the download history with the
+// provided id.
+func (r *Renter) managedDownloadByID(id string) (*download, error) {
+	r.downloadHistoryMu.Lock()
+	defer r.downloadHistoryMu.Unlock()
+	for _, d := range r.downloadHistory {
+		if d.staticID == id {
+			return d, nil
+		}
+	}
+	return nil, errUnknownDownload
+}
+
+// managedSetDownloadThrottle sets the pause and the throttle of the download
+// with the provided id and notifies the download loop, which might be waiting
+// for the download.
+func (r *Renter) managedSetDownloadThrottle(id string, paused bool, bytesPerSecond uint64) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	d, err := r.managedDownloadByID(id)
+	if err != nil {
+		return err
+	}
+	if d.staticComplete() {
+		return errDownloadComplete
+	}
+	d.mu.Lock()
+	d.paused = paused
+	d.throttle = bytesPerSecond
+	d.nextChunkTime = time.Time{}
+	d.mu.Unlock()
+	if !paused {
+		r.managedResumeDownloadChunks(d)
+	}
+
+	select {
+	case r.newDownloads <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// ThrottleDownload limits the download with the provided id to bytesPerSecond.
+// A limit of zero pauses the download.
+func (r *Renter) ThrottleDownload(id string, bytesPerSecond uint64) error {
+	return r.managedSetDownloadThrottle(id, bytesPerSecond == 0, bytesPerSecond)
+}
+
+// PauseDownload stops fetching new chunks of the download with the provided
+// id. The chunks that were fetched already are kept, so the download
+// continues where it left off once it is resumed.
+func (r *Renter) PauseDownload(id string) error {
+	return r.ThrottleDownload(id, 0)
+}
+
+// ResumeDownload removes the pause and the throttle of the download with the
+// provided id.
+func (r *Renter) ResumeDownload(id string) error {
+	return r.managedSetDownloadThrottle(id, false, 0)
+}
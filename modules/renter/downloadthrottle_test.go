@@ -0,0 +1,69 @@
+package renter
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNextDownloadChunkThrottle tests that the chunks of paused and throttled
+// downloads are skipped without holding up the chunks of other downloads.
+func TestNextDownloadChunkThrottle(t *testing.T) {
+	r := &Renter{
+		downloadHeap:         new(downloadChunkHeap),
+		pausedDownloadChunks: make(map[*download][]*unfinishedDownloadChunk),
+	}
+	start := time.Now()
+	throttled := &download{
+		completeChan:    make(chan struct{}),
+		staticStartTime: start,
+		paused:          true,
+	}
+	other := &download{
+		completeChan:    make(chan struct{}),
+		staticStartTime: start.Add(time.Second),
+	}
+	for i := uint64(0); i < 2; i++ {
+		r.managedAddChunkToDownloadHeap(&unfinishedDownloadChunk{
+			download:          throttled,
+			staticChunkIndex:  i,
+			staticFetchLength: 1e6,
+			staticNeedsMemory: true,
+		})
+	}
+	r.managedAddChunkToDownloadHeap(&unfinishedDownloadChunk{
+		download:          other,
+		staticNeedsMemory: true,
+	})
+
+	// The chunks of the paused download are skipped.
+	if udc, _ := r.managedNextDownloadChunk(); udc == nil || udc.download != other {
+		t.Fatal("expected the chunk of the other download")
+	}
+	if udc, wait := r.managedNextDownloadChunk(); udc != nil || wait != 0 {
+		t.Fatal("paused download shouldn't be scheduled", udc, wait)
+	}
+	if r.downloadHeap.Len() != 0 || len(r.pausedDownloadChunks[throttled]) != 2 {
+		t.Fatal("chunks of the paused download should be kept out of the heap", r.downloadHeap.Len())
+	}
+
+	// Throttled, the first chunk is fetched right away and the second one
+	// once the first had time to be fetched at the throttled rate.
+	throttled.paused = false
+	throttled.throttle = 1e6
+	r.managedResumeDownloadChunks(throttled)
+	if r.downloadHeap.Len() != 2 || len(r.pausedDownloadChunks) != 0 {
+		t.Fatal("chunks of the resumed download should be back in the heap", r.downloadHeap.Len())
+	}
+	udc, _ := r.managedNextDownloadChunk()
+	if udc == nil || udc.staticChunkIndex != 0 {
+		t.Fatal("expected the first chunk of the throttled download")
+	}
+	udc, wait := r.managedNextDownloadChunk()
+	if udc != nil || wait <= 0 || wait > time.Second {
+		t.Fatal("second chunk should wait for the throttle", udc, wait)
+	}
+	throttled.nextChunkTime = time.Now()
+	if udc, _ := r.managedNextDownloadChunk(); udc == nil || udc.staticChunkIndex != 1 {
+		t.Fatal("expected the second chunk of the throttled download")
+	}
+}
@@ -77,11 +77,15 @@ func (r *Renter) DeleteFile(nickname string) error {
 		return ErrUnknownPath
 	}
 	delete(r.files, nickname)
+	r.removeChunkRefs(f)
+	r.removePackMember(f)
 
 	r.saveSync()
 	r.mu.Unlock(lockID)
 
-	// TODO: delete the sectors of the file as well.
+	// TODO: delete the sectors of the file as well. Sectors of chunks that
+	// are still referenced in the chunk index need to be kept.
+	r.managedUnregisterAlert(degradedUploadAlertID(nickname))
 
 	// mark the file as deleted
 	return f.Delete()
@@ -128,7 +132,7 @@ func (r *Renter) FileList(filter ...*regexp.Regexp) []modules.FileInfo {
 			continue
 		}
 		goodForRenew[string(pk.Key)] = ok && contract.Utility.GoodForRenew
-		offline[string(pk.Key)] = r.hostContractor.IsOffline(pk)
+		offline[string(pk.Key)] = r.managedIsOffline(pk)
 		contracts[string(pk.Key)] = contract
 	}
 
@@ -139,23 +143,43 @@ func (r *Renter) FileList(filter ...*regexp.Regexp) []modules.FileInfo {
 		_, err := os.Stat(localPath)
 		onDisk := !os.IsNotExist(err)
 		redundancy := f.Redundancy(offline, goodForRenew)
+		_, minHosts := r.minUploadWorkers(f)
+		budget, budgetRemaining := r.managedRepairBudget(f.SiaPath())
+		_, packed := f.Packed()
 		fileList = append(fileList, modules.FileInfo{
 			AccessTime:     f.AccessTime(),
-			Available:      f.Available(offline),
+			Available:      f.Available(offline, minHosts),
 			ChangeTime:     f.ChangeTime(),
 			CipherType:     f.MasterKey().Type().String(),
+			ContentHash:    f.ContentHash(),
 			CreateTime:     f.CreateTime(),
+			Degraded:       f.Degraded(),
 			Expiration:     f.Expiration(contracts),
 			Filesize:       f.Size(),
+			HostSpread:     f.HostSpread(offline, goodForRenew),
 			LocalPath:      localPath,
 			ModTime:        f.ModTime(),
 			OnDisk:         onDisk,
 			Recoverable:    onDisk || redundancy >= 1,
 			Redundancy:     redundancy,
+			Rekeying:       f.Rekeying(),
 			Renewing:       true,
 			SiaPath:        f.SiaPath(),
 			UploadedBytes:  f.UploadedBytes(),
 			UploadProgress: f.UploadProgress(),
+
+			RepairBudget:          budget,
+			RepairBudgetRemaining: budgetRemaining,
+
+			SpareHosts:        f.SpareHosts(),
+			TolerableHostLoss: f.TolerableHostLoss(offline, goodForRenew),
+
+			RedundancyGroup: f.RedundancyGroup(),
+
+			Dedup:        f.Dedup(),
+			SharedChunks: f.SharedChunks(),
+
+			Packed: packed,
 		})
 	}
 	return fileList
@@ -186,7 +210,7 @@ func (r *Renter) File(siaPath string) (modules.FileInfo, error) {
 			continue
 		}
 		goodForRenew[string(pk.Key)] = ok && contract.Utility.GoodForRenew
-		offline[string(pk.Key)] = r.hostContractor.IsOffline(pk)
+		offline[string(pk.Key)] = r.managedIsOffline(pk)
 		contracts[string(pk.Key)] = contract
 	}
 
@@ -196,28 +220,141 @@ func (r *Renter) File(siaPath string) (modules.FileInfo, error) {
 	_, err := os.Stat(localPath)
 	onDisk := !os.IsNotExist(err)
 	redundancy := file.Redundancy(offline, goodForRenew)
+	_, minHosts := r.minUploadWorkers(file)
+	budget, budgetRemaining := r.managedRepairBudget(file.SiaPath())
+	_, packed := file.Packed()
 	fileInfo = modules.FileInfo{
 		AccessTime:     file.AccessTime(),
-		Available:      file.Available(offline),
+		Available:      file.Available(offline, minHosts),
 		ChangeTime:     file.ChangeTime(),
 		CipherType:     file.MasterKey().Type().String(),
+		ContentHash:    file.ContentHash(),
 		CreateTime:     file.CreateTime(),
+		Degraded:       file.Degraded(),
 		Expiration:     file.Expiration(contracts),
 		Filesize:       file.Size(),
+		HostSpread:     file.HostSpread(offline, goodForRenew),
 		LocalPath:      localPath,
 		ModTime:        file.ModTime(),
 		OnDisk:         onDisk,
 		Recoverable:    onDisk || redundancy >= 1,
 		Redundancy:     redundancy,
+		Rekeying:       file.Rekeying(),
 		Renewing:       renewing,
 		SiaPath:        file.SiaPath(),
 		UploadedBytes:  file.UploadedBytes(),
 		UploadProgress: file.UploadProgress(),
+
+		RepairBudget:          budget,
+		RepairBudgetRemaining: budgetRemaining,
+
+		SpareHosts:        file.SpareHosts(),
+		TolerableHostLoss: file.TolerableHostLoss(offline, goodForRenew),
+
+		RedundancyGroup: file.RedundancyGroup(),
+
+		Dedup:        file.Dedup(),
+		SharedChunks: file.SharedChunks(),
+
+		Packed: packed,
 	}
 
 	return fileInfo, nil
 }
 
+// EffectiveRedundancy returns the redundancy of a file when the hosts that
+// share an address range are treated as a single host, since they are likely
+// run by the same operator and can fail together. The address ranges are the
+// subnets of the hostdb's address filter, hosts of the same operator that use
+// different subnets aren't detected.
+func (r *Renter) EffectiveRedundancy(siaPath string) (modules.FileEffectiveRedundancy, error) {
+	lockID := r.mu.RLock()
+	file, exists := r.files[siaPath]
+	r.mu.RUnlock(lockID)
+	if !exists {
+		return modules.FileEffectiveRedundancy{}, ErrUnknownPath
+	}
+
+	// Only the hosts the renter has a contract with store pieces that count.
+	goodForRenew := make(map[string]bool)
+	offline := make(map[string]bool)
+	var pks []types.SiaPublicKey
+	for _, pk := range file.HostPublicKeys() {
+		contract, ok := r.hostContractor.ContractByPublicKey(pk)
+		if !ok {
+			continue
+		}
+		goodForRenew[string(pk.Key)] = contract.Utility.GoodForRenew
+		offline[string(pk.Key)] = r.managedIsOffline(pk)
+		if contract.Utility.GoodForRenew && !offline[string(pk.Key)] {
+			pks = append(pks, pk)
+		}
+	}
+	addressRanges := r.hostDB.AddressRanges(pks)
+	distinctRanges := make(map[string]struct{})
+	for _, addressRange := range addressRanges {
+		distinctRanges[addressRange] = struct{}{}
+	}
+
+	return modules.FileEffectiveRedundancy{
+		SiaPath:             file.SiaPath(),
+		Redundancy:          file.Redundancy(offline, goodForRenew),
+		EffectiveRedundancy: file.EffectiveRedundancy(offline, goodForRenew, addressRanges),
+		Hosts:               uint64(len(pks)),
+		AddressRanges:       uint64(len(distinctRanges)),
+	}, nil
+}
+
+// LostFiles returns the files with chunks that don't have enough pieces on
+// online hosts to be recovered. Files that are still available on disk are
+// left out, since the repair loop can upload them again. The offline status
+// of every host is only looked up once, even if it stores pieces of many
+// files.
+func (r *Renter) LostFiles() []modules.LostFileInfo {
+	lockID := r.mu.RLock()
+	files := make([]*siafile.SiaFile, 0, len(r.files))
+	for _, file := range r.files {
+		files = append(files, file)
+	}
+	r.mu.RUnlock(lockID)
+
+	pks := make(map[string]types.SiaPublicKey)
+	for _, f := range files {
+		for _, pk := range f.HostPublicKeys() {
+			pks[string(pk.Key)] = pk
+		}
+	}
+	// Hosts without a contract are left out of the map, which marks their
+	// pieces as lost.
+	offline := make(map[string]bool)
+	for _, pk := range pks {
+		if _, ok := r.hostContractor.ContractByPublicKey(pk); !ok {
+			continue
+		}
+		offline[string(pk.Key)] = r.managedIsOffline(pk)
+	}
+
+	lostFiles := []modules.LostFileInfo{}
+	for _, f := range files {
+		chunks := f.UnrecoverableChunks(offline)
+		if len(chunks) == 0 {
+			continue
+		}
+		localPath := f.LocalPath()
+		if _, err := os.Stat(localPath); !os.IsNotExist(err) {
+			continue
+		}
+		lostFiles = append(lostFiles, modules.LostFileInfo{
+			SiaPath:             f.SiaPath(),
+			Filesize:            f.Size(),
+			LocalPath:           localPath,
+			NumChunks:           f.NumChunks(),
+			UnrecoverableChunks: chunks,
+		})
+	}
+	return lostFiles
+}
+
 // RenameFile takes an existing file and changes the nickname. The original
 // file must exist, and there must not be any file that already has the
 // replacement nickname.
@@ -105,7 +105,7 @@ func TestFileAvailable(t *testing.T) {
 	f := newFileTesting(t.Name(), newTestingWal(), rsc, 100, 0777, "")
 	neverOffline := make(map[string]bool)
 
-	if f.Available(neverOffline) {
+	if f.Available(neverOffline, 0) {
 		t.Error("file should not be available")
 	}
 
@@ -113,17 +113,55 @@ func TestFileAvailable(t *testing.T) {
 		f.AddPiece(types.SiaPublicKey{}, i, 0, crypto.Hash{})
 	}
 
-	if !f.Available(neverOffline) {
+	if !f.Available(neverOffline, 0) {
 		t.Error("file should be available")
 	}
 
 	specificOffline := make(map[string]bool)
 	specificOffline[string(types.SiaPublicKey{}.Key)] = true
-	if f.Available(specificOffline) {
+	if f.Available(specificOffline, 0) {
 		t.Error("file should not be available")
 	}
 }
 
+// TestFileAvailableMinHosts checks that a file whose chunks have enough
+// pieces isn't available until the pieces are spread across the minimum
+// number of hosts.
+func TestFileAvailableMinHosts(t *testing.T) {
+	rsc, _ := siafile.NewRSCode(1, 2)
+	f := newFileTesting(t.Name(), newTestingWal(), rsc, 100, 0777, "")
+	neverOffline := make(map[string]bool)
+	host1 := types.SiaPublicKey{Key: []byte{1}}
+	host2 := types.SiaPublicKey{Key: []byte{2}}
+
+	// A single piece is enough to recover the chunk.
+	if err := f.AddPiece(host1, 0, 0, crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if !f.Available(neverOffline, 1) {
+		t.Fatal("file should be available")
+	}
+	if f.Available(neverOffline, 2) {
+		t.Fatal("file shouldn't be available on a single host")
+	}
+	// Another piece on the same host doesn't spread the chunk.
+	if err := f.AddPiece(host1, 0, 1, crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if f.Available(neverOffline, 2) {
+		t.Fatal("file shouldn't be available on a single host")
+	}
+	if err := f.AddPiece(host2, 0, 2, crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if !f.Available(neverOffline, 2) {
+		t.Fatal("file should be available once spread across 2 hosts")
+	}
+	if f.Available(map[string]bool{string(host2.Key): true}, 2) {
+		t.Fatal("offline hosts shouldn't count towards the spread")
+	}
+}
+
 // TestFileUploadedBytes tests that uploadedBytes() returns a value equal to
 // the number of sectors stored via contract times the size of each sector.
 func TestFileUploadedBytes(t *testing.T) {
@@ -0,0 +1,91 @@
+package renter
+
+import (
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// fileHistory converts the history records of a file into events and sums up
+// the net change of the events between start and end. Hosts are resolved
+// through the public key table of the file.
+func fileHistory(records []siafile.HistoryRecord, table []types.SiaPublicKey, start, end time.Time) modules.FileHistory {
+	resolve := func(indices []uint32) []types.SiaPublicKey {
+		pks := make([]types.SiaPublicKey, 0, len(indices))
+		for _, i := range indices {
+			if int(i) < len(table) {
+				pks = append(pks, table[i])
+			}
+		}
+		return pks
+	}
+
+	var history modules.FileHistory
+	hostDelta := make(map[uint32]int)
+	var hostOrder []uint32
+	for _, record := range records {
+		if record.Time.Before(start) || (!end.IsZero() && record.Time.After(end)) {
+			continue
+		}
+		history.Events = append(history.Events, modules.FileHistoryEvent{
+			Time:        record.Time,
+			Redundancy:  record.Redundancy,
+			PiecesAdded: record.PiecesAdded,
+			PiecesLost:  record.PiecesLost,
+			HostsAdded:  resolve(record.HostsAdded),
+			HostsLost:   resolve(record.HostsLost),
+		})
+		history.PiecesAdded += record.PiecesAdded
+		history.PiecesLost += record.PiecesLost
+		for _, i := range record.HostsAdded {
+			if _, exists := hostDelta[i]; !exists {
+				hostOrder = append(hostOrder, i)
+			}
+			hostDelta[i]++
+		}
+		for _, i := range record.HostsLost {
+			if _, exists := hostDelta[i]; !exists {
+				hostOrder = append(hostOrder, i)
+			}
+			hostDelta[i]--
+		}
+	}
+
+	// A host that was added and lost again within the range cancels out.
+	var added, lost []uint32
+	for _, i := range hostOrder {
+		if hostDelta[i] > 0 {
+			added = append(added, i)
+		} else if hostDelta[i] < 0 {
+			lost = append(lost, i)
+		}
+	}
+	history.HostsAdded = resolve(added)
+	history.HostsLost = resolve(lost)
+	return history
+}
+
+// FileHistory returns the changes of the distribution of a file's pieces
+// across hosts between start and end, as recorded by the repair loop. A zero
+// end includes all the events after start.
+func (r *Renter) FileHistory(siaPath string, start, end time.Time) (modules.FileHistory, error) {
+	id := r.mu.RLock()
+	file, exists := r.files[siaPath]
+	r.mu.RUnlock(id)
+	if !exists {
+		return modules.FileHistory{}, ErrUnknownPath
+	}
+	return fileHistory(file.History(), file.HostPublicKeys(), start, end), nil
+}
+
+// managedRecordFileHistories adds a history record to every file whose
+// distribution of pieces changed since the last run of the repair loop.
+func (r *Renter) managedRecordFileHistories(files []*siafile.SiaFile, offline, goodForRenew map[string]bool) {
+	for _, file := range files {
+		if _, err := file.RecordHistory(offline, goodForRenew); err != nil {
+			r.log.Println("WARN: unable to record the history of", file.SiaPath(), err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package hostdb
+
+// addressrange.go makes the prefix lengths of the subnets that the hosts of
+// the renter can't share configurable. IPv4 and IPv6 addresses have separate
+// prefix lengths, since an IPv6 subnet of the same length as an IPv4 subnet is
+// usually assigned to a single customer. The prefix lengths apply to the hosts
+// selected for new contracts and to the check of the existing contracts.
+
+import (
+	"errors"
+	"net"
+
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/hostdb/hosttree"
+)
+
+var (
+	errIPv4FilterRange = errors.New("the IPv4 prefix length has to be between 1 and 32")
+	errIPv6FilterRange = errors.New("the IPv6 prefix length has to be between 1 and 128")
+)
+
+// newAddressFilter returns an address filter that uses the prefix lengths of
+// the hostdb.
+func (hdb *HostDB) newAddressFilter() *hosttree.Filter {
+	hdb.mu.RLock()
+	ipv4Range, ipv6Range := hdb.ipv4FilterRange, hdb.ipv6FilterRange
+	hdb.mu.RUnlock()
+	return hosttree.NewFilterWithRanges(hdb.deps.Resolver(), ipv4Range, ipv6Range)
+}
+
+// AddressFilterRanges returns the prefix lengths of the IPv4 and IPv6 subnets
+// that hosts can't share.
+func (hdb *HostDB) AddressFilterRanges() (ipv4Range, ipv6Range int) {
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	return hdb.ipv4FilterRange, hdb.ipv6FilterRange
+}
+
+// SetAddressFilterRanges sets the prefix lengths of the IPv4 and IPv6 subnets
+// that hosts can't share. Existing contracts with hosts that share a subnet
+// are cancelled by the contractor during its next maintenance.
+func (hdb *HostDB) SetAddressFilterRanges(ipv4Range, ipv6Range int) error {
+	if err := hdb.tg.Add(); err != nil {
+		return err
+	}
+	defer hdb.tg.Done()
+	if ipv4Range < 1 || ipv4Range > 8*net.IPv4len {
+		return errIPv4FilterRange
+	}
+	if ipv6Range < 1 || ipv6Range > 8*net.IPv6len {
+		return errIPv6FilterRange
+	}
+
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	hdb.ipv4FilterRange = ipv4Range
+	hdb.ipv6FilterRange = ipv6Range
+	hdb.hostTree.SetFilterRanges(ipv4Range, ipv6Range)
+	return hdb.saveSync()
+}
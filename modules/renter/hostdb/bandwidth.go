@@ -0,0 +1,52 @@
+package hostdb
+
+import (
+	"math"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// updateBandwidthAverage adds a measurement of a host's throughput to its
+// exponential moving average. A measurement of zero isn't a measurement and
+// leaves the average unchanged.
+func updateBandwidthAverage(average, measurement float64) float64 {
+	if measurement <= 0 || math.IsNaN(measurement) || math.IsInf(measurement, 0) {
+		return average
+	}
+	if average == 0 {
+		return measurement
+	}
+	return average + bandwidthAverageWeight*(measurement-average)
+}
+
+// bandwidthAdjustments penalizes hosts whose measured throughput is below
+// minBandwidth. Uploads and downloads are penalized separately, and hosts the
+// renter hasn't transferred data with yet aren't penalized at all.
+func bandwidthAdjustments(entry modules.HostDBEntry) float64 {
+	adjustment := func(bandwidth float64) float64 {
+		if bandwidth == 0 || bandwidth >= minBandwidth {
+			return 1
+		}
+		return math.Max(bandwidth/minBandwidth, minBandwidthAdjustment)
+	}
+	return adjustment(entry.MeasuredUploadBandwidth) * adjustment(entry.MeasuredDownloadBandwidth)
+}
+
+// UpdateBandwidth adds a measurement of the upload and download throughput of
+// the host with the provided public key, in bytes per second, to the moving
+// averages of the host. A throughput of zero means that the direction wasn't
+// measured.
+func (hdb *HostDB) UpdateBandwidth(pk types.SiaPublicKey, up, down float64) {
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	host, exists := hdb.hostTree.Select(pk)
+	if !exists {
+		return
+	}
+	host.MeasuredUploadBandwidth = updateBandwidthAverage(host.MeasuredUploadBandwidth, up)
+	host.MeasuredDownloadBandwidth = updateBandwidthAverage(host.MeasuredDownloadBandwidth, down)
+	if err := hdb.hostTree.Modify(host); err != nil {
+		hdb.log.Println("ERROR: unable to update the bandwidth of a host:", err)
+	}
+}
@@ -0,0 +1,62 @@
+package hostdb
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestUpdateBandwidthAverage checks that a single measurement only moves the
+// average of a host's throughput by a fraction.
+func TestUpdateBandwidthAverage(t *testing.T) {
+	if avg := updateBandwidthAverage(0, 1e6); avg != 1e6 {
+		t.Fatal("first measurement should become the average:", avg)
+	}
+	if avg := updateBandwidthAverage(1e6, 0); avg != 1e6 {
+		t.Fatal("missing measurement changed the average:", avg)
+	}
+	avg := updateBandwidthAverage(1e6, 1e3)
+	if avg < 0.8e6 || avg >= 1e6 {
+		t.Fatal("slow measurement moved the average too much:", avg)
+	}
+	for i := 0; i < 100; i++ {
+		avg = updateBandwidthAverage(avg, 1e3)
+	}
+	if avg > 2e3 {
+		t.Fatal("consistently slow measurements didn't lower the average:", avg)
+	}
+}
+
+// TestHostWeightBandwidthDifferences checks that hosts with consistently poor
+// throughput have less weight than fast hosts and hosts that haven't been
+// measured.
+func TestHostWeightBandwidthDifferences(t *testing.T) {
+	hdb := bareHostDB()
+	var entry modules.HostDBEntry
+	entry.Version = build.Version
+	entry.RemainingStorage = 250e3
+	entry.StoragePrice = types.NewCurrency64(1000).Mul(types.SiacoinPrecision)
+	entry.Collateral = types.NewCurrency64(1000).Mul(types.SiacoinPrecision)
+
+	fast := entry
+	fast.MeasuredUploadBandwidth = 10 * minBandwidth
+	fast.MeasuredDownloadBandwidth = 10 * minBandwidth
+	slow := entry
+	slow.MeasuredUploadBandwidth = minBandwidth / 4
+	slow.MeasuredDownloadBandwidth = 2 * minBandwidth
+
+	unmeasuredWeight := hdb.calculateHostWeight(entry)
+	fastWeight := hdb.calculateHostWeight(fast)
+	slowWeight := hdb.calculateHostWeight(slow)
+	if fastWeight.Cmp(unmeasuredWeight) != 0 {
+		t.Error("fast host should have the same weight as an unmeasured host")
+	}
+	if slowWeight.Cmp(fastWeight) >= 0 {
+		t.Error("slow host should have less weight than a fast host")
+	}
+	if adj := bandwidthAdjustments(slow); adj != 0.25 {
+		t.Error("unexpected bandwidth adjustment:", adj)
+	}
+}
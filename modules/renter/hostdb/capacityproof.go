@@ -0,0 +1,32 @@
+package hostdb
+
+// capacityproof.go records the results of the capacity challenges that the
+// contractor sends to the hosts it has contracts with. Hosts that fail the
+// challenge are penalized in the host weight, hosts that don't answer it are
+// not.
+
+import (
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// RecordCapacityProof records the outcome of a capacity challenge sent to the
+// host. 'answered' is false if the host couldn't be reached or doesn't support
+// the challenge, in which case only the time of the challenge is recorded.
+func (hdb *HostDB) RecordCapacityProof(key types.SiaPublicKey, answered, valid bool) {
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+
+	host, haveHost := hdb.hostTree.Select(key)
+	if !haveHost {
+		return
+	}
+	host.LastCapacityProof = time.Now()
+	if answered && valid {
+		host.CapacityProofFailures = 0
+	} else if answered {
+		host.CapacityProofFailures++
+	}
+	hdb.hostTree.Modify(host)
+}
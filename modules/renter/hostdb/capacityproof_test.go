@@ -0,0 +1,85 @@
+package hostdb
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestRecordCapacityProof checks that only answered challenges change the
+// number of failed capacity challenges of a host.
+func TestRecordCapacityProof(t *testing.T) {
+	hdb := bareHostDB()
+	entry := makeHostDBEntry()
+	if err := hdb.hostTree.Insert(entry); err != nil {
+		t.Fatal(err)
+	}
+	failures := func() uint64 {
+		host, exists := hdb.hostTree.Select(entry.PublicKey)
+		if !exists {
+			t.Fatal("host is missing from the host tree")
+		}
+		if host.LastCapacityProof.IsZero() {
+			t.Fatal("time of the challenge wasn't recorded")
+		}
+		return host.CapacityProofFailures
+	}
+
+	hdb.RecordCapacityProof(entry.PublicKey, true, false)
+	hdb.RecordCapacityProof(entry.PublicKey, true, false)
+	if n := failures(); n != 2 {
+		t.Fatal("expected 2 failures but got", n)
+	}
+	hdb.RecordCapacityProof(entry.PublicKey, false, false)
+	if n := failures(); n != 2 {
+		t.Fatal("unanswered challenge shouldn't count as a failure", n)
+	}
+	hdb.RecordCapacityProof(entry.PublicKey, true, true)
+	if n := failures(); n != 0 {
+		t.Fatal("valid proof should reset the failures", n)
+	}
+}
+
+// TestUpdateEntryKeepsCapacityProofs checks that a scan doesn't overwrite the
+// results of capacity challenges that were recorded while the host was being
+// scanned.
+func TestUpdateEntryKeepsCapacityProofs(t *testing.T) {
+	hdb := bareHostDB()
+	entry := makeHostDBEntry()
+	if err := hdb.hostTree.Insert(entry); err != nil {
+		t.Fatal(err)
+	}
+	// The scan starts with the entry as it is now.
+	scanned, _ := hdb.hostTree.Select(entry.PublicKey)
+	hdb.RecordCapacityProof(entry.PublicKey, true, false)
+	hdb.updateEntry(scanned, nil)
+	host, _ := hdb.hostTree.Select(entry.PublicKey)
+	if host.CapacityProofFailures != 1 || host.LastCapacityProof.IsZero() {
+		t.Fatal("scan overwrote the capacity challenge", host.CapacityProofFailures, host.LastCapacityProof)
+	}
+}
+
+// TestHostWeightCapacityProofFailures checks that failed capacity challenges
+// lower the weight of a host.
+func TestHostWeightCapacityProofFailures(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	hdb := bareHostDB()
+	var entry modules.HostDBEntry
+	entry.RemainingStorage = 250e3
+	entry.StoragePrice = types.NewCurrency64(1000).Mul(types.SiacoinPrecision)
+	entry.Collateral = types.NewCurrency64(1000).Mul(types.SiacoinPrecision)
+	entry2 := entry
+	entry2.CapacityProofFailures = 1
+
+	w1 := hdb.calculateHostWeight(entry)
+	w2 := hdb.calculateHostWeight(entry2)
+	if w1.Cmp(w2) <= 0 {
+		t.Error("Failed capacity challenges should lower the weight of a host", w1, w2)
+	}
+	if capacityAdjustments(entry) != 1 {
+		t.Error("A host without failed challenges shouldn't be penalized")
+	}
+}
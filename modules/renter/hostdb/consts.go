@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/types"
 )
 
 const (
@@ -22,6 +23,10 @@ const (
 	// scan.
 	hostScanDeadline = 2 * time.Minute
 
+	// defaultMaxScanHistoryLen is the default number of most recent scans that
+	// are kept in the scan history of a host.
+	defaultMaxScanHistoryLen = 250
+
 	// maxHostDowntime specifies the maximum amount of time that a host is
 	// allowed to be offline while still being in the hostdb.
 	maxHostDowntime = 10 * 24 * time.Hour
@@ -61,41 +66,155 @@ const (
 	// scanCheckInterval is the interval used when waiting for the scanList to
 	// empty itself and for waiting on the consensus set to be synced.
 	scanCheckInterval = time.Second
+
+	// flakyScanWindow is the number of most recent scans that are considered
+	// when deciding whether a host is flaky. A host is flaky if its recent
+	// scans contain both successes and failures.
+	flakyScanWindow = 5
+
+	// scanJitterDivisor bounds the random delay added to the interval between
+	// two scans of a host to a fraction of the interval.
+	scanJitterDivisor = 10
+
+	// maxCapacityProofPenalty caps the number of failed capacity challenges
+	// that count towards the capacity penalty of a host.
+	maxCapacityProofPenalty = 10
+
+	// maxMissedProofPenalty caps the number of missed storage proofs that
+	// count towards the missed proof penalty of a host.
+	maxMissedProofPenalty = 5
+
+	// maxReputationWeight caps the weight of the external reputation feed. A
+	// host's weight is adjusted by at most this fraction in either direction,
+	// which is small compared to the penalties of the local scans, so the
+	// feed can't override what the renter observed itself.
+	maxReputationWeight = 0.5
+
+	// maxReputationFeedSize is the maximum size in bytes of the response of
+	// a reputation feed.
+	maxReputationFeedSize = 10 << 20
+
+	// reputationFeedTimeout is the amount of time a reputation feed has to
+	// respond.
+	reputationFeedTimeout = 30 * time.Second
+
+	// bandwidthAverageWeight is the weight of a new measurement of a host's
+	// throughput in its exponential moving average. A small weight keeps a
+	// single slow transfer from ruining the score of a host.
+	bandwidthAverageWeight = 0.1
+
+	// minBandwidth is the throughput in bytes per second below which a host
+	// is penalized. The penalty grows linearly as the throughput falls
+	// towards zero, down to minBandwidthAdjustment.
+	minBandwidth = 500e3
+
+	// minBandwidthAdjustment is the largest penalty for a slow direction of
+	// transfers.
+	minBandwidthAdjustment = 0.1
 )
 
 var (
-	// hostCheckupQuantity specifies the number of hosts that get scanned every
-	// time there is a regular scanning operation.
+	// hostCheckupQuantity specifies the maximum number of hosts that get
+	// queued for a scan every time the hostdb checks which hosts are due.
 	hostCheckupQuantity = build.Select(build.Var{
 		Standard: int(2500),
 		Dev:      int(6),
 		Testing:  int(5),
 	}).(int)
 
-	// scanningThreads is the number of threads that will be probing hosts for
-	// their settings and checking for reliability.
-
-	maxScanningThreads = build.Select(build.Var{
-		Standard: int(80),
-		Dev:      int(4),
+	// defaultMaxScanningThreads is the default number of threads that will be
+	// probing hosts for their settings and checking for reliability.
+	defaultMaxScanningThreads = build.Select(build.Var{
+		Standard: int(200),
+		Dev:      int(8),
 		Testing:  int(3),
 	}).(int)
 )
 
 var (
-	// maxScanSleep is the maximum amount of time that the hostdb will sleep
-	// between performing scans of the hosts.
-	maxScanSleep = build.Select(build.Var{
-		Standard: time.Hour * 6,
-		Dev:      time.Minute * 10,
+	// missedProofWindow is the number of blocks after which a host that
+	// hasn't missed another storage proof is forgiven for the proofs it
+	// missed.
+	missedProofWindow = build.Select(build.Var{
+		Standard: types.BlockHeight(4320),
+		Dev:      types.BlockHeight(100),
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
+
+	// reputationFeedInterval is the amount of time between two downloads of
+	// the external reputation feed.
+	reputationFeedInterval = build.Select(build.Var{
+		Standard: time.Hour,
+		Dev:      time.Minute * 5,
 		Testing:  time.Second * 5,
 	}).(time.Duration)
 
-	// minScanSleep is the minimum amount of time that the hostdb will sleep
-	// between performing scans of the hosts.
-	minScanSleep = build.Select(build.Var{
-		Standard: time.Hour + time.Minute*20,
-		Dev:      time.Minute * 3,
+	// reputationFeedExpiry is the amount of time the scores of a reputation
+	// feed are used after the last successful download. Once they expire,
+	// the hosts are scored using only local evidence until the feed is
+	// reachable again.
+	reputationFeedExpiry = build.Select(build.Var{
+		Standard: time.Hour * 24,
+		Dev:      time.Hour,
+		Testing:  time.Second * 30,
+	}).(time.Duration)
+
+	// flakyScanInterval is the amount of time between two scans of a host that
+	// recently went online or offline.
+	flakyScanInterval = build.Select(build.Var{
+		Standard: time.Hour,
+		Dev:      time.Minute * 2,
+		Testing:  time.Second * 1,
+	}).(time.Duration)
+
+	// importScanFreshness is the maximum age of the scans of imported hosts.
+	// Older scans say little about the current state of a host and are
+	// dropped during the import.
+	importScanFreshness = build.Select(build.Var{
+		Standard: time.Hour * 24 * 7,
+		Dev:      time.Hour * 24,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// maxOfflineScanInterval caps the exponential backoff of the scan interval
+	// of hosts that keep failing their scans.
+	maxOfflineScanInterval = build.Select(build.Var{
+		Standard: time.Hour * 48,
+		Dev:      time.Hour,
+		Testing:  time.Second * 4,
+	}).(time.Duration)
+
+	// offlineScanInterval is the amount of time between two scans of a host
+	// that failed its most recent scan. The interval doubles with every
+	// additional failed scan.
+	offlineScanInterval = build.Select(build.Var{
+		Standard: time.Hour,
+		Dev:      time.Minute * 2,
+		Testing:  time.Second * 1,
+	}).(time.Duration)
+
+	// defaultUptimeHalfLife is the default age at which the uptime and
+	// downtime measured between two scans counts half as much towards the
+	// uptime of a host as the most recent measurements.
+	defaultUptimeHalfLife = build.Select(build.Var{
+		Standard: time.Hour * 24 * 30,
+		Dev:      time.Hour * 24,
+		Testing:  time.Hour * 24,
+	}).(time.Duration)
+
+	// scanScheduleInterval is the amount of time the hostdb sleeps before
+	// checking which hosts are due for a scan again.
+	scanScheduleInterval = build.Select(build.Var{
+		Standard: time.Minute * 10,
+		Dev:      time.Minute,
 		Testing:  time.Second * 1,
 	}).(time.Duration)
+
+	// stableScanInterval is the amount of time between two scans of a host
+	// that has passed all of its recent scans.
+	stableScanInterval = build.Select(build.Var{
+		Standard: time.Hour * 6,
+		Dev:      time.Minute * 10,
+		Testing:  time.Second * 5,
+	}).(time.Duration)
 )
@@ -0,0 +1,98 @@
+package hostdb
+
+// filter.go implements the filter of hosts of the hostdb. A blacklist excludes
+// the listed hosts from new contracts and a whitelist excludes all other
+// hosts. Filtered hosts stay in the hostdb and are still scanned, but
+// RandomHosts never returns them and Host marks them as filtered, which makes
+// the contractor stop renewing and uploading to their contracts. The
+// contracts aren't canceled, so their data can be downloaded until they
+// expire.
+
+import (
+	"errors"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+var (
+	errEmptyWhitelist    = errors.New("a whitelist needs at least one host")
+	errUnknownFilterMode = errors.New("unknown filter mode")
+)
+
+// isFiltered returns whether the filter excludes the host with the provided
+// public key.
+func (hdb *HostDB) isFiltered(pk types.SiaPublicKey) bool {
+	_, listed := hdb.filteredHosts[pk.String()]
+	switch hdb.filterMode {
+	case modules.HostDBFilterBlacklist:
+		return listed
+	case modules.HostDBFilterWhitelist:
+		return !listed
+	}
+	return false
+}
+
+// filteredKeys returns the public keys of the hosts of the host tree that the
+// filter excludes.
+func (hdb *HostDB) filteredKeys() (keys []types.SiaPublicKey) {
+	switch hdb.filterMode {
+	case modules.HostDBFilterBlacklist:
+		for _, pk := range hdb.filteredHosts {
+			keys = append(keys, pk)
+		}
+	case modules.HostDBFilterWhitelist:
+		for _, entry := range hdb.hostTree.All() {
+			if hdb.isFiltered(entry.PublicKey) {
+				keys = append(keys, entry.PublicKey)
+			}
+		}
+	}
+	return keys
+}
+
+// Filter returns the filter of hosts the hostdb applies when selecting hosts
+// for new contracts.
+func (hdb *HostDB) Filter() modules.HostDBFilter {
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	filter := modules.HostDBFilter{
+		Mode: hdb.filterMode,
+	}
+	if filter.Mode == "" {
+		filter.Mode = modules.HostDBFilterDisabled
+	}
+	for _, pk := range hdb.filteredHosts {
+		filter.Hosts = append(filter.Hosts, pk)
+	}
+	return filter
+}
+
+// SetFilterMode sets the mode of the filter and the hosts it lists, replacing
+// the previous filter. The hosts are ignored if the filter is disabled.
+func (hdb *HostDB) SetFilterMode(mode string, hosts []types.SiaPublicKey) error {
+	if err := hdb.tg.Add(); err != nil {
+		return err
+	}
+	defer hdb.tg.Done()
+	switch mode {
+	case modules.HostDBFilterDisabled:
+		hosts = nil
+	case modules.HostDBFilterBlacklist:
+	case modules.HostDBFilterWhitelist:
+		if len(hosts) == 0 {
+			return errEmptyWhitelist
+		}
+	default:
+		return errUnknownFilterMode
+	}
+
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	hdb.filterMode = mode
+	hdb.filteredHosts = make(map[string]types.SiaPublicKey, len(hosts))
+	for _, pk := range hosts {
+		hdb.filteredHosts[pk.String()] = pk
+	}
+	return hdb.saveSync()
+}
@@ -0,0 +1,96 @@
+package hostdb
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestFilterMode checks that RandomHosts and Host honor the filter of hosts.
+func TestFilterMode(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	hdbt, err := newHDBTesterDeps(t.Name(), &disableScanLoopDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hdbt.hdb.Close()
+
+	var entries []modules.HostDBEntry
+	for i := 0; i < 5; i++ {
+		entry := makeHostDBEntry()
+		if err := hdbt.hdb.hostTree.Insert(entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	selected := func() map[string]struct{} {
+		hosts, err := hdbt.hdb.RandomHosts(len(entries), nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys := make(map[string]struct{})
+		for _, host := range hosts {
+			keys[host.PublicKey.String()] = struct{}{}
+		}
+		return keys
+	}
+
+	// A blacklisted host is never selected.
+	blacklisted := entries[0].PublicKey
+	if err := hdbt.hdb.SetFilterMode(modules.HostDBFilterBlacklist, []types.SiaPublicKey{blacklisted}); err != nil {
+		t.Fatal(err)
+	}
+	keys := selected()
+	if _, exists := keys[blacklisted.String()]; exists || len(keys) != len(entries)-1 {
+		t.Fatal("blacklist wasn't honored:", len(keys))
+	}
+	if host, _ := hdbt.hdb.Host(blacklisted); !host.Filtered {
+		t.Fatal("blacklisted host isn't marked as filtered")
+	}
+	if host, _ := hdbt.hdb.Host(entries[1].PublicKey); host.Filtered {
+		t.Fatal("host that isn't blacklisted is marked as filtered")
+	}
+
+	// Only whitelisted hosts are selected.
+	whitelist := []types.SiaPublicKey{entries[1].PublicKey, entries[2].PublicKey}
+	if err := hdbt.hdb.SetFilterMode(modules.HostDBFilterWhitelist, whitelist); err != nil {
+		t.Fatal(err)
+	}
+	keys = selected()
+	if len(keys) != len(whitelist) {
+		t.Fatal("whitelist wasn't honored:", len(keys))
+	}
+	for _, pk := range whitelist {
+		if _, exists := keys[pk.String()]; !exists {
+			t.Fatal("whitelisted host wasn't selected")
+		}
+	}
+	if filter := hdbt.hdb.Filter(); filter.Mode != modules.HostDBFilterWhitelist || len(filter.Hosts) != len(whitelist) {
+		t.Fatal("unexpected filter:", filter)
+	}
+
+	// Invalid filters are rejected and leave the filter untouched.
+	if err := hdbt.hdb.SetFilterMode(modules.HostDBFilterWhitelist, nil); err != errEmptyWhitelist {
+		t.Fatal("expected errEmptyWhitelist, got", err)
+	}
+	if err := hdbt.hdb.SetFilterMode("graylist", nil); err != errUnknownFilterMode {
+		t.Fatal("expected errUnknownFilterMode, got", err)
+	}
+	if filter := hdbt.hdb.Filter(); filter.Mode != modules.HostDBFilterWhitelist {
+		t.Fatal("filter changed:", filter.Mode)
+	}
+
+	// Disabling the filter makes all hosts available again.
+	if err := hdbt.hdb.SetFilterMode(modules.HostDBFilterDisabled, whitelist); err != nil {
+		t.Fatal(err)
+	}
+	if keys := selected(); len(keys) != len(entries) {
+		t.Fatal("disabled filter still excludes hosts:", len(keys))
+	}
+	if filter := hdbt.hdb.Filter(); len(filter.Hosts) != 0 {
+		t.Fatal("disabled filter still lists hosts")
+	}
+}
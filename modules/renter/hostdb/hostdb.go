@@ -34,6 +34,12 @@ var (
 // host based on their hosting parameters, and then can select hosts at random
 // for uploading files.
 type HostDB struct {
+	// atomicUptimeHalfLife is the half-life of the decay of the uptime of the
+	// hosts in nanoseconds. It is accessed atomically because the weight
+	// function of the host tree reads it while the hostdb's lock might be
+	// held.
+	atomicUptimeHalfLife int64
+
 	// dependencies
 	cs         modules.ConsensusSet
 	deps       modules.Dependencies
@@ -48,16 +54,62 @@ type HostDB struct {
 	// random.
 	hostTree *hosttree.HostTree
 
+	// customWeight is the weight function provided by the dependencies. If
+	// it is nil, the hosts are weighed by calculateHostWeight.
+	customWeight modules.HostWeightFunc
+
 	// the scanPool is a set of hosts that need to be scanned. There are a
 	// handful of goroutines constantly waiting on the channel for hosts to
 	// scan. The scan map is used to prevent duplicates from entering the scan
-	// pool.
+	// pool. During the initial scan, the first unscannedQueued hosts of the
+	// scan list are hosts that have never been scanned.
 	initialScanComplete  bool
 	initialScanLatencies []time.Duration
+	maxScanningThreads   int
 	scanList             []modules.HostDBEntry
 	scanMap              map[string]struct{}
 	scanWait             bool
 	scanningThreads      int
+	unscannedQueued      int
+
+	// importedHosts contains the hosts whose scan history was imported from
+	// another node and that haven't been scanned by this node yet. They are
+	// part of the initial scan even though they have a scan history.
+	importedHosts map[string]struct{}
+
+	// scanningHosts contains the hosts that were taken from the scan list and
+	// are currently being scanned. rescanPending contains the hosts of an
+	// active rescan that haven't been scanned yet.
+	scanningHosts map[string]struct{}
+	rescanPending map[string]struct{}
+	rescanStatus  modules.HostDBRescanStatus
+
+	// missedProofHosts contains the hosts that recently missed a storage
+	// proof. Their count is reset once they haven't missed a proof for
+	// missedProofWindow blocks.
+	missedProofHosts map[string]struct{}
+
+	// reputationFeed is the external reputation feed and reputationScores
+	// the cached scores it returned, keyed by the public keys of the hosts.
+	// They are protected by their own lock since the weight function of the
+	// host tree reads them while the hostdb's lock might be held.
+	reputationFeed   modules.HostDBReputationFeed
+	reputationScores map[string]float64
+	reputationMu     sync.Mutex
+
+	// maxScanHistoryLen is the number of most recent scans that are kept in
+	// the scan history of a host.
+	maxScanHistoryLen int
+
+	// ipv4FilterRange and ipv6FilterRange are the prefix lengths of the
+	// subnets that hosts can't share.
+	ipv4FilterRange int
+	ipv6FilterRange int
+
+	// filterMode is the mode of the filter of hosts and filteredHosts the
+	// hosts it lists, keyed by their public keys.
+	filterMode    string
+	filteredHosts map[string]types.SiaPublicKey
 
 	blockHeight types.BlockHeight
 	lastChange  modules.ConsensusChangeID
@@ -87,7 +139,21 @@ func NewCustomHostDB(g modules.Gateway, cs modules.ConsensusSet, persistDir stri
 		gateway:    g,
 		persistDir: persistDir,
 
-		scanMap: make(map[string]struct{}),
+		atomicUptimeHalfLife: int64(defaultUptimeHalfLife),
+
+		maxScanHistoryLen:  defaultMaxScanHistoryLen,
+		maxScanningThreads: defaultMaxScanningThreads,
+
+		ipv4FilterRange: hosttree.DefaultIPv4FilterRange,
+		ipv6FilterRange: hosttree.DefaultIPv6FilterRange,
+
+		filteredHosts:    make(map[string]types.SiaPublicKey),
+		importedHosts:    make(map[string]struct{}),
+		missedProofHosts: make(map[string]struct{}),
+		rescanPending:    make(map[string]struct{}),
+		reputationScores: make(map[string]float64),
+		scanningHosts:    make(map[string]struct{}),
+		scanMap:          make(map[string]struct{}),
 	}
 
 	// Create the persist directory if it does not yet exist.
@@ -114,8 +180,10 @@ func NewCustomHostDB(g modules.Gateway, cs modules.ConsensusSet, persistDir stri
 		return nil, err
 	}
 
-	// The host tree is used to manage hosts and query them at random.
-	hdb.hostTree = hosttree.New(hdb.calculateHostWeight, deps.Resolver())
+	// The host tree is used to manage hosts and query them at random. The
+	// dependencies may replace the default weighting of the hosts.
+	hdb.customWeight = deps.HostWeightFunc()
+	hdb.hostTree = hosttree.New(hdb.hostWeight, deps.Resolver())
 
 	// Load the prior persistence structures.
 	hdb.mu.Lock()
@@ -147,7 +215,14 @@ func NewCustomHostDB(g modules.Gateway, cs modules.ConsensusSet, persistDir stri
 		return hdb, nil
 	}
 
-	err = cs.ConsensusSetSubscribe(hdb, hdb.lastChange, hdb.tg.StopChan())
+	// A consensus set in spv mode only sends header changes.
+	subscribe := func() error {
+		if cs.SpvMode() {
+			return cs.HeaderConsensusSetSubscribe(hdb, hdb.lastChange, hdb.tg.StopChan())
+		}
+		return cs.ConsensusSetSubscribe(hdb, hdb.lastChange, hdb.tg.StopChan())
+	}
+	err = subscribe()
 	if err == modules.ErrInvalidConsensusChangeID {
 		// Subscribe again using the new ID. This will cause a triggered scan
 		// on all of the hosts, but that should be acceptable.
@@ -155,13 +230,17 @@ func NewCustomHostDB(g modules.Gateway, cs modules.ConsensusSet, persistDir stri
 		hdb.blockHeight = 0
 		hdb.lastChange = modules.ConsensusChangeBeginning
 		hdb.mu.Unlock()
-		err = cs.ConsensusSetSubscribe(hdb, hdb.lastChange, hdb.tg.StopChan())
+		err = subscribe()
 	}
 	if err != nil {
 		return nil, errors.New("hostdb subscription failed: " + err.Error())
 	}
 	err = hdb.tg.OnStop(func() error {
-		cs.Unsubscribe(hdb)
+		if cs.SpvMode() {
+			cs.HeaderUnsubscribe(hdb)
+		} else {
+			cs.Unsubscribe(hdb)
+		}
 		return nil
 	})
 	if err != nil {
@@ -176,6 +255,7 @@ func NewCustomHostDB(g modules.Gateway, cs modules.ConsensusSet, persistDir stri
 	} else {
 		hdb.initialScanComplete = true
 	}
+	go hdb.threadedRefreshReputationFeed()
 
 	return hdb, nil
 }
@@ -231,7 +311,7 @@ func (hdb *HostDB) CheckForIPViolations(hosts []types.SiaPublicKey) []types.SiaP
 	}
 
 	// Create a filter.
-	filter := hosttree.NewFilter(hdb.deps.Resolver())
+	filter := hdb.newAddressFilter()
 
 	var badHosts []types.SiaPublicKey
 	for _, host := range hosts {
@@ -253,6 +333,49 @@ func (hdb *HostDB) CheckForIPViolations(hosts []types.SiaPublicKey) []types.SiaP
 	return badHosts
 }
 
+// AddressRanges groups hosts by the address ranges that CheckForIPViolations
+// doesn't allow to be shared. It maps the keys of the hosts to an identifier
+// of their group. Hosts that share a subnet, directly or through other hosts,
+// end up in the same group. Only the subnets of the addresses are compared,
+// hosts in different subnets of the same network are in different groups. Hosts that aren't in the hostdb or whose
+// addresses can't be resolved form a group of their own.
+func (hdb *HostDB) AddressRanges(hosts []types.SiaPublicKey) map[string]string {
+	filter := hdb.newAddressFilter()
+
+	// Every host starts out as a group of its own. Groups are merged by
+	// pointing one of them at the other.
+	parent := make(map[string]string)
+	var root func(group string) string
+	root = func(group string) string {
+		if parent[group] == group {
+			return group
+		}
+		parent[group] = root(parent[group])
+		return parent[group]
+	}
+	subnetGroups := make(map[string]string)
+	for _, host := range hosts {
+		group := host.String()
+		parent[group] = group
+		node, exists := hdb.hostTree.Select(host)
+		if !exists {
+			continue
+		}
+		for _, subnet := range filter.Subnets(node.NetAddress) {
+			if other, exists := subnetGroups[subnet]; exists {
+				parent[root(other)] = root(group)
+			}
+			subnetGroups[subnet] = group
+		}
+	}
+
+	ranges := make(map[string]string)
+	for _, host := range hosts {
+		ranges[string(host.Key)] = root(host.String())
+	}
+	return ranges
+}
+
 // Close closes the hostdb, terminating its scanning threads
 func (hdb *HostDB) Close() error {
 	return hdb.tg.Stop()
@@ -267,6 +390,7 @@ func (hdb *HostDB) Host(spk types.SiaPublicKey) (modules.HostDBEntry, bool) {
 	}
 	hdb.mu.RLock()
 	updateHostHistoricInteractions(&host, hdb.blockHeight)
+	host.Filtered = hdb.isFiltered(spk)
 	hdb.mu.RUnlock()
 	return host, exists
 }
@@ -290,9 +414,12 @@ func (hdb *HostDB) InitialScanComplete() (complete bool, err error) {
 func (hdb *HostDB) RandomHosts(n int, blacklist, addressBlacklist []types.SiaPublicKey) ([]modules.HostDBEntry, error) {
 	hdb.mu.RLock()
 	initialScanComplete := hdb.initialScanComplete
+	filtered := hdb.filteredKeys()
 	hdb.mu.RUnlock()
 	if !initialScanComplete {
 		return []modules.HostDBEntry{}, ErrInitialScanIncomplete
 	}
+	// Hosts excluded by the filter are treated like blacklisted hosts.
+	blacklist = append(filtered, blacklist...)
 	return hdb.hostTree.SelectRandom(n, blacklist, addressBlacklist), nil
 }
@@ -42,6 +42,15 @@ type hdbTester struct {
 func bareHostDB() *HostDB {
 	hdb := &HostDB{
 		log: persist.NewLogger(ioutil.Discard),
+
+		atomicUptimeHalfLife: int64(defaultUptimeHalfLife),
+
+		maxScanHistoryLen:  defaultMaxScanHistoryLen,
+		maxScanningThreads: defaultMaxScanningThreads,
+
+		filteredHosts:    make(map[string]types.SiaPublicKey),
+		missedProofHosts: make(map[string]struct{}),
+		reputationScores: make(map[string]float64),
 	}
 	hdb.hostTree = hosttree.New(hdb.calculateHostWeight, &modules.ProductionResolver{})
 	return hdb
@@ -422,6 +431,95 @@ func TestRandomHosts(t *testing.T) {
 	}
 }
 
+// customHostWeightDeps is a dependency that disables the scan loop and
+// weighs the hosts with a custom weight function.
+type customHostWeightDeps struct {
+	disableScanLoopDeps
+	weightFn modules.HostWeightFunc
+}
+
+// HostWeightFunc returns the custom weight function.
+func (d *customHostWeightDeps) HostWeightFunc() modules.HostWeightFunc {
+	return d.weightFn
+}
+
+// TestRandomHostsCustomWeight checks that RandomHosts selects hosts using the
+// weight function of the dependencies.
+func TestRandomHostsCustomWeight(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	favored := makeHostDBEntry()
+	weightFn := func(entry modules.HostDBEntry) types.Currency {
+		if entry.PublicKey.String() == favored.PublicKey.String() {
+			return types.NewCurrency64(1e6)
+		}
+		return types.NewCurrency64(1)
+	}
+	hdbt, err := newHDBTesterDeps(t.Name(), &customHostWeightDeps{weightFn: weightFn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hdbt.hdb.Close()
+
+	// Insert the favored host and a few hosts that are identical to it
+	// according to the default weighting.
+	nEntries := 10
+	if err := hdbt.hdb.hostTree.Insert(favored); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < nEntries; i++ {
+		if err := hdbt.hdb.hostTree.Insert(makeHostDBEntry()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The favored host should be selected nearly every time, instead of once
+	// in nEntries selections.
+	var selected int
+	draws := 100
+	for i := 0; i < draws; i++ {
+		hosts, err := hdbt.hdb.RandomHosts(1, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hosts) != 1 {
+			t.Fatal("expected one host, got", len(hosts))
+		}
+		if hosts[0].PublicKey.String() == favored.PublicKey.String() {
+			selected++
+		}
+	}
+	if selected < draws*9/10 {
+		t.Fatalf("favored host was selected %v out of %v times", selected, draws)
+	}
+}
+
+// TestScoreBreakdownCustomWeight checks that the score breakdown and the
+// estimated score of a host use the weight function of the dependencies.
+func TestScoreBreakdownCustomWeight(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	weight := types.NewCurrency64(12345)
+	weightFn := func(modules.HostDBEntry) types.Currency {
+		return weight
+	}
+	hdbt, err := newHDBTesterDeps(t.Name(), &customHostWeightDeps{weightFn: weightFn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hdbt.hdb.Close()
+
+	entry := makeHostDBEntry()
+	if sb := hdbt.hdb.ScoreBreakdown(entry); !sb.Score.Equals(weight) {
+		t.Fatal("score breakdown doesn't use the custom weight", sb.Score)
+	}
+	if sb := hdbt.hdb.EstimateHostScore(entry); !sb.Score.Equals(weight) {
+		t.Fatal("estimated score doesn't use the custom weight", sb.Score)
+	}
+}
+
 // TestRemoveNonexistingHostFromHostTree checks that the host tree interface
 // correctly responds to having a nonexisting host removed from the host tree.
 func TestRemoveNonexistingHostFromHostTree(t *testing.T) {
@@ -672,3 +770,104 @@ func TestCheckForIPViolations(t *testing.T) {
 		t.Error("Hdb returned violation for wrong host")
 	}
 }
+
+// TestSetAddressFilterRanges checks that CheckForIPViolations groups IPv4 and
+// IPv6 addresses by the prefix lengths of the hostdb.
+func TestSetAddressFilterRanges(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	entry1 := makeHostDBEntry()
+	entry1.NetAddress = "host1:1234"
+	entry2 := makeHostDBEntry()
+	entry2.NetAddress = "host2:1234"
+	entry3 := makeHostDBEntry()
+	entry3.NetAddress = "host3:1234"
+
+	hdbt, err := newHDBTesterDeps(t.Name(), &testCheckForIPViolationsDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdbt.hdb.hostTree.Insert(entry1)
+	hdbt.hdb.hostTree.Insert(entry2)
+	hdbt.hdb.hostTree.Insert(entry3)
+	hosts := []types.SiaPublicKey{entry1.PublicKey, entry2.PublicKey, entry3.PublicKey}
+
+	// host3 shares an IPv4 subnet with host1 and an IPv6 subnet with host2.
+	// It only stops violating the rules once neither subnet is shared.
+	tests := []struct {
+		ipv4Range, ipv6Range int
+		violations           int
+	}{
+		{32, 128, 0},
+		{24, 128, 1},
+		{32, 54, 1},
+		{24, 54, 1},
+	}
+	for _, test := range tests {
+		if err := hdbt.hdb.SetAddressFilterRanges(test.ipv4Range, test.ipv6Range); err != nil {
+			t.Fatal(err)
+		}
+		badHosts := hdbt.hdb.CheckForIPViolations(hosts)
+		if len(badHosts) != test.violations {
+			t.Fatalf("Got %v violations with /%v and /%v, should be %v", len(badHosts), test.ipv4Range, test.ipv6Range, test.violations)
+		}
+		if len(badHosts) > 0 && !bytes.Equal(badHosts[0].Key, entry3.PublicKey.Key) {
+			t.Error("Hdb returned violation for wrong host")
+		}
+	}
+
+	// Invalid prefix lengths are rejected and leave the ranges untouched.
+	if err := hdbt.hdb.SetAddressFilterRanges(33, 54); err != errIPv4FilterRange {
+		t.Fatal("expected errIPv4FilterRange, got", err)
+	}
+	if err := hdbt.hdb.SetAddressFilterRanges(24, 0); err != errIPv6FilterRange {
+		t.Fatal("expected errIPv6FilterRange, got", err)
+	}
+	if ipv4Range, ipv6Range := hdbt.hdb.AddressFilterRanges(); ipv4Range != 24 || ipv6Range != 54 {
+		t.Fatalf("ranges changed to /%v and /%v", ipv4Range, ipv6Range)
+	}
+}
+
+// TestAddressRanges checks that hosts are grouped with all the hosts they
+// share a subnet with, even if the subnet is shared through another host.
+func TestAddressRanges(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	entry1 := makeHostDBEntry()
+	entry1.NetAddress = "host1:1234"
+	entry2 := makeHostDBEntry()
+	entry2.NetAddress = "host2:1234"
+	entry3 := makeHostDBEntry()
+	entry3.NetAddress = "host3:1234"
+	unknown := makeHostDBEntry()
+
+	hdbt, err := newHDBTesterDeps(t.Name(), &testCheckForIPViolationsDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdbt.hdb.hostTree.Insert(entry1)
+	hdbt.hdb.hostTree.Insert(entry2)
+	hdbt.hdb.hostTree.Insert(entry3)
+
+	// entry1 and entry2 don't share a subnet.
+	ranges := hdbt.hdb.AddressRanges([]types.SiaPublicKey{entry1.PublicKey, entry2.PublicKey, unknown.PublicKey})
+	if len(ranges) != 3 {
+		t.Fatal("expected 3 hosts but got", len(ranges))
+	}
+	if ranges[string(entry1.PublicKey.Key)] == ranges[string(entry2.PublicKey.Key)] {
+		t.Error("hosts in different subnets shouldn't share a range")
+	}
+	if ranges[string(unknown.PublicKey.Key)] == ranges[string(entry1.PublicKey.Key)] {
+		t.Error("unknown host shouldn't share a range")
+	}
+
+	// entry3 shares a subnet with both of them.
+	ranges = hdbt.hdb.AddressRanges([]types.SiaPublicKey{entry1.PublicKey, entry2.PublicKey, entry3.PublicKey})
+	if ranges[string(entry1.PublicKey.Key)] != ranges[string(entry2.PublicKey.Key)] || ranges[string(entry2.PublicKey.Key)] != ranges[string(entry3.PublicKey.Key)] {
+		t.Error("all hosts should share a range", ranges)
+	}
+}
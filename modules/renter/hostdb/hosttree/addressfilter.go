@@ -1,30 +1,59 @@
 package hosttree
 
 import (
-	"fmt"
 	"net"
 
 	"github.com/HyperspaceApp/Hyperspace/modules"
 )
 
 const (
-	ipv4FilterRange = 24
-	ipv6FilterRange = 54
+	// DefaultIPv4FilterRange and DefaultIPv6FilterRange are the prefix
+	// lengths of the subnets that hosts aren't allowed to share by default.
+	DefaultIPv4FilterRange = 24
+	DefaultIPv6FilterRange = 54
 )
 
 // Filter filters host addresses which belong to the same subnet to
 // avoid selecting hosts from the same region.
 type Filter struct {
-	filter   map[string]struct{}
-	resolver modules.Resolver
+	filter    map[string]struct{}
+	ipv4Range int
+	ipv6Range int
+	resolver  modules.Resolver
 }
 
-// NewFilter creates a new addressFilter object.
+// NewFilter creates a new addressFilter object which uses the default prefix
+// lengths.
 func NewFilter(resolver modules.Resolver) *Filter {
+	return NewFilterWithRanges(resolver, DefaultIPv4FilterRange, DefaultIPv6FilterRange)
+}
+
+// NewFilterWithRanges creates a new addressFilter object which groups IPv4
+// and IPv6 addresses into subnets of the provided prefix lengths.
+func NewFilterWithRanges(resolver modules.Resolver, ipv4Range, ipv6Range int) *Filter {
 	return &Filter{
-		filter:   make(map[string]struct{}),
-		resolver: resolver,
+		filter:    make(map[string]struct{}),
+		ipv4Range: ipv4Range,
+		ipv6Range: ipv6Range,
+		resolver:  resolver,
+	}
+}
+
+// subnet returns the subnet of the address. IPv4 addresses are masked in
+// their 4 byte form, even if they are stored in 16 bytes, so that the prefix
+// length applies to the IPv4 address itself.
+func (af *Filter) subnet(ip net.IP) (string, bool) {
+	var ipnet net.IPNet
+	if ip4 := ip.To4(); ip4 != nil {
+		ipnet.Mask = net.CIDRMask(af.ipv4Range, 8*net.IPv4len)
+		ipnet.IP = ip4.Mask(ipnet.Mask)
+	} else if ip16 := ip.To16(); ip16 != nil {
+		ipnet.Mask = net.CIDRMask(af.ipv6Range, 8*net.IPv6len)
+		ipnet.IP = ip16.Mask(ipnet.Mask)
+	} else {
+		return "", false
 	}
+	return ipnet.String(), true
 }
 
 // Add adds a host to the filter. This will resolve the hostname into one
@@ -33,29 +62,28 @@ func NewFilter(resolver modules.Resolver) *Filter {
 // addresses of a host can't be resolved it will be handled as if the host
 // had no addresses associated with it.
 func (af *Filter) Add(host modules.NetAddress) {
+	for _, subnet := range af.Subnets(host) {
+		af.filter[subnet] = struct{}{}
+	}
+}
+
+// Subnets returns the subnets used by the addresses of a host, the same
+// subnets that Add adds to the filter. If the addresses of the host can't be
+// resolved, no subnets are returned.
+func (af *Filter) Subnets(host modules.NetAddress) []string {
 	// Translate the hostname to one or multiple IPs. If the argument is an IP
 	// address LookupIP will just return that IP.
 	addresses, err := af.resolver.LookupIP(host.Host())
 	if err != nil {
-		return
+		return nil
 	}
-	// If any of the addresses is blocked we ignore the host.
+	var subnets []string
 	for _, ip := range addresses {
-		// Set the filterRange according to the type of IP address.
-		var filterRange int
-		if ip.To4() != nil {
-			filterRange = ipv4FilterRange
-		} else {
-			filterRange = ipv6FilterRange
+		if subnet, ok := af.subnet(ip); ok {
+			subnets = append(subnets, subnet)
 		}
-		// Get the subnet.
-		_, ipnet, err := net.ParseCIDR(fmt.Sprintf("%s/%d", ip.String(), filterRange))
-		if err != nil {
-			continue
-		}
-		// Add the subnet to the map.
-		af.filter[ipnet.String()] = struct{}{}
 	}
+	return subnets
 }
 
 // Filtered checks if a host uses a subnet that is already in use by a host
@@ -74,26 +102,19 @@ func (af *Filter) Filtered(host modules.NetAddress) bool {
 		return true
 	}
 	// If the hostname is associated with 2 addresses of the same type, we
-	// filter it.
-	if (len(addresses) == 2) && (len(addresses[0]) == len(addresses[1])) {
+	// filter it. The type can't be told apart by the length of the
+	// addresses, since IPv4 addresses might be stored in 16 bytes.
+	if (len(addresses) == 2) && ((addresses[0].To4() == nil) == (addresses[1].To4() == nil)) {
 		return true
 	}
 	// If any of the addresses is blocked we ignore the host.
 	for _, ip := range addresses {
-		// Set the filterRange according to the type of IP address.
-		var filterRange int
-		if ip.To4() != nil {
-			filterRange = ipv4FilterRange
-		} else {
-			filterRange = ipv6FilterRange
-		}
-		// Get the subnet.
-		_, ipnet, err := net.ParseCIDR(fmt.Sprintf("%s/%d", ip.String(), filterRange))
-		if err != nil {
+		subnet, ok := af.subnet(ip)
+		if !ok {
 			continue
 		}
 		// Check if the subnet is in the map. If it is, we filter the host.
-		if _, exists := af.filter[ipnet.String()]; exists {
+		if _, exists := af.filter[subnet]; exists {
 			return true
 		}
 	}
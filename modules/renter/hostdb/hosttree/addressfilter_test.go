@@ -42,6 +42,10 @@ func (testTwoAddressesResolver) LookupIP(host string) ([]net.IP, error) {
 		return []net.IP{ipv4Localhost, ipv4Localhost}, nil
 	case "ipv6.ipv6":
 		return []net.IP{ipv6Localhost, ipv6Localhost}, nil
+	case "ipv4in16.ipv6":
+		return []net.IP{ipv4Localhost.To16(), ipv6Localhost}, nil
+	case "ipv4in16.ipv4":
+		return []net.IP{ipv4Localhost.To16(), ipv4Localhost}, nil
 	default:
 		panic("shouldn't happen")
 	}
@@ -125,11 +129,15 @@ func TestTwoAddresses(t *testing.T) {
 	hostInvalid1 := modules.NetAddress("ipv4.ipv4:1234")
 	hostInvalid2 := modules.NetAddress("ipv6.ipv6:1234")
 
+	// IPv4 addresses stored in 16 bytes are still IPv4 addresses.
+	hostValid3 := modules.NetAddress("ipv4in16.ipv6:1234")
+	hostInvalid3 := modules.NetAddress("ipv4in16.ipv4:1234")
+
 	// Check hosts.
-	if filter.Filtered(hostValid1) || filter.Filtered(hostValid2) {
+	if filter.Filtered(hostValid1) || filter.Filtered(hostValid2) || filter.Filtered(hostValid3) {
 		t.Fatal("Valid hosts were filtered.")
 	}
-	if !filter.Filtered(hostInvalid1) || !filter.Filtered(hostInvalid2) {
+	if !filter.Filtered(hostInvalid1) || !filter.Filtered(hostInvalid2) || !filter.Filtered(hostInvalid3) {
 		t.Fatal("Invalid hosts weren't filtered.")
 	}
 }
@@ -246,3 +254,29 @@ func TestFilterIPv6(t *testing.T) {
 		t.Error("host9 wasn't filtered")
 	}
 }
+
+// TestFilterRanges tests filtering addresses with custom prefix lengths.
+func TestFilterRanges(t *testing.T) {
+	filter := NewFilterWithRanges(testFilterIPv4Resolver{}, 16, DefaultIPv6FilterRange)
+
+	// host1 and host3 share a /16, host4 doesn't.
+	filter.Add(modules.NetAddress("host1:1234"))
+	if !filter.Filtered(modules.NetAddress("host3:1234")) {
+		t.Error("host3 wasn't filtered")
+	}
+	if filter.Filtered(modules.NetAddress("host4:1234")) {
+		t.Error("host4 was filtered")
+	}
+
+	// host8 and host9 share a /64 but not a /80.
+	filter = NewFilterWithRanges(testFilterIPv6Resolver{}, DefaultIPv4FilterRange, 64)
+	filter.Add(modules.NetAddress("host8:1234"))
+	if !filter.Filtered(modules.NetAddress("host9:1234")) {
+		t.Error("host9 wasn't filtered")
+	}
+	filter = NewFilterWithRanges(testFilterIPv6Resolver{}, DefaultIPv4FilterRange, 80)
+	filter.Add(modules.NetAddress("host8:1234"))
+	if filter.Filtered(modules.NetAddress("host9:1234")) {
+		t.Error("host9 was filtered")
+	}
+}
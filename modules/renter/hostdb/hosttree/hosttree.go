@@ -52,6 +52,11 @@ type (
 		// hostnames to IP addresses.
 		resolver modules.Resolver
 
+		// ipv4FilterRange and ipv6FilterRange are the prefix lengths of the
+		// subnets that the hosts returned by SelectRandom can't share.
+		ipv4FilterRange int
+		ipv6FilterRange int
+
 		// weightFn calculates the weight of a hostEntry
 		weightFn WeightFunc
 
@@ -100,9 +105,21 @@ func New(wf WeightFunc, resolver modules.Resolver) *HostTree {
 		},
 		resolver: resolver,
 		weightFn: wf,
+
+		ipv4FilterRange: DefaultIPv4FilterRange,
+		ipv6FilterRange: DefaultIPv6FilterRange,
 	}
 }
 
+// SetFilterRanges sets the prefix lengths of the subnets that the hosts
+// returned by SelectRandom can't share.
+func (ht *HostTree) SetFilterRanges(ipv4Range, ipv6Range int) {
+	ht.mu.Lock()
+	defer ht.mu.Unlock()
+	ht.ipv4FilterRange = ipv4Range
+	ht.ipv6FilterRange = ipv6Range
+}
+
 // recursiveInsert inserts an entry into the appropriate place in the tree. The
 // running time of recursiveInsert is log(n) in the maximum number of elements
 // that have ever been in the tree.
@@ -302,7 +319,7 @@ func (ht *HostTree) SelectRandom(n int, blacklist, addressBlacklist []types.SiaP
 	var removedEntries []*hostEntry
 
 	// Create a filter.
-	filter := NewFilter(ht.resolver)
+	filter := NewFilterWithRanges(ht.resolver, ht.ipv4FilterRange, ht.ipv6FilterRange)
 
 	// Add the hosts from the addressBlacklist to the filter.
 	for _, pubkey := range addressBlacklist {
@@ -1,8 +1,11 @@
 package hostdb
 
 import (
+	"errors"
 	"math"
 	"math/big"
+	"sync/atomic"
+	"time"
 
 	"github.com/HyperspaceApp/Hyperspace/build"
 	"github.com/HyperspaceApp/Hyperspace/modules"
@@ -54,6 +57,16 @@ var (
 	tbMonth = uint64(4032) * uint64(1e12)
 )
 
+// capacityAdjustments penalizes hosts that failed their most recent capacity
+// challenges. Every failed challenge in a row halves the weight of the host.
+func capacityAdjustments(entry modules.HostDBEntry) float64 {
+	failures := entry.CapacityProofFailures
+	if failures > maxCapacityProofPenalty {
+		failures = maxCapacityProofPenalty
+	}
+	return math.Pow(0.5, float64(failures))
+}
+
 // collateralAdjustments improves the host's weight according to the amount of
 // collateral that they have provided.
 func (hdb *HostDB) collateralAdjustments(entry modules.HostDBEntry) float64 {
@@ -278,6 +291,97 @@ func (hdb *HostDB) lifetimeAdjustments(entry modules.HostDBEntry) float64 {
 	return base
 }
 
+// errUptimeHalfLife is returned when setting a half-life for the decay of the
+// uptime that isn't positive.
+var errUptimeHalfLife = errors.New("uptime half-life has to be positive")
+
+// uptimeHalfLife returns the half-life of the decay of the uptime of the
+// hosts.
+func (hdb *HostDB) uptimeHalfLife() time.Duration {
+	return time.Duration(atomic.LoadInt64(&hdb.atomicUptimeHalfLife))
+}
+
+// SetUptimeHalfLife sets the age at which the uptime and downtime of a host
+// count half as much towards its uptime as the most recent measurements. The
+// weights of all hosts are updated right away.
+func (hdb *HostDB) SetUptimeHalfLife(halfLife time.Duration) error {
+	if err := hdb.tg.Add(); err != nil {
+		return err
+	}
+	defer hdb.tg.Done()
+	if halfLife <= 0 {
+		return errUptimeHalfLife
+	}
+	atomic.StoreInt64(&hdb.atomicUptimeHalfLife, int64(halfLife))
+	hdb.mu.Lock()
+	err := hdb.saveSync()
+	hdb.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	hdb.managedUpdateHostWeights()
+	return nil
+}
+
+// decayedUptime returns the fraction of time that the host was online. The
+// time between two scans is weighted by its age, halving the weight every
+// uptime half-life, so that recent failures aren't masked by a long history of
+// good behavior. Only the ratio of the weighted uptime and downtime matters,
+// so a host that has only been scanned recently isn't penalized for its short
+// history. The bool is false if no time has been measured for the host.
+func (hdb *HostDB) decayedUptime(entry modules.HostDBEntry) (float64, bool) {
+	if len(entry.ScanHistory) == 0 {
+		return 0, false
+	}
+
+	// Weigh everything relative to the most recent scan and integrate the
+	// weight over the time between two scans.
+	latest := entry.ScanHistory[0].Timestamp
+	for _, scan := range entry.ScanHistory[1:] {
+		if scan.Timestamp.After(latest) {
+			latest = scan.Timestamp
+		}
+	}
+	halfLife := hdb.uptimeHalfLife().Seconds()
+	weight := func(t time.Time) float64 {
+		return math.Exp2(-latest.Sub(t).Seconds() / halfLife)
+	}
+	decayed := func(start, end time.Time) float64 {
+		return halfLife / math.Ln2 * (weight(end) - weight(start))
+	}
+
+	// The historic uptime and downtime were compressed from scans that are
+	// older than the remaining scan history. They are weighted as if they
+	// ended with the oldest remaining scan.
+	oldestWeight := weight(entry.ScanHistory[0].Timestamp)
+	uptime := entry.HistoricUptime.Seconds() * oldestWeight
+	downtime := entry.HistoricDowntime.Seconds() * oldestWeight
+	recentTime := entry.ScanHistory[0].Timestamp
+	recentSuccess := entry.ScanHistory[0].Success
+	for _, scan := range entry.ScanHistory[1:] {
+		if recentTime.After(scan.Timestamp) {
+			if build.DEBUG {
+				hdb.log.Critical("Host entry scan history not sorted.")
+			} else {
+				hdb.log.Print("WARNING: Host entry scan history not sorted.")
+			}
+			// Ignore the unsorted scan entry.
+			continue
+		}
+		if recentSuccess {
+			uptime += decayed(recentTime, scan.Timestamp)
+		} else {
+			downtime += decayed(recentTime, scan.Timestamp)
+		}
+		recentTime = scan.Timestamp
+		recentSuccess = scan.Success
+	}
+	if uptime <= 0 && downtime <= 0 {
+		return 0, false
+	}
+	return uptime / (uptime + downtime), true
+}
+
 // uptimeAdjustments penalizes the host for having poor uptime, and for being
 // offline.
 //
@@ -307,38 +411,14 @@ func (hdb *HostDB) uptimeAdjustments(entry modules.HostDBEntry) float64 {
 		return 0.05
 	}
 
-	// Compute the total measured uptime and total measured downtime for this
-	// host.
-	downtime := entry.HistoricDowntime
-	uptime := entry.HistoricUptime
-	recentTime := entry.ScanHistory[0].Timestamp
-	recentSuccess := entry.ScanHistory[0].Success
-	for _, scan := range entry.ScanHistory[1:] {
-		if recentTime.After(scan.Timestamp) {
-			if build.DEBUG {
-				hdb.log.Critical("Host entry scan history not sorted.")
-			} else {
-				hdb.log.Print("WARNING: Host entry scan history not sorted.")
-			}
-			// Ignore the unsorted scan entry.
-			continue
-		}
-		if recentSuccess {
-			uptime += scan.Timestamp.Sub(recentTime)
-		} else {
-			downtime += scan.Timestamp.Sub(recentTime)
-		}
-		recentTime = scan.Timestamp
-		recentSuccess = scan.Success
-	}
-	// Sanity check against 0 total time.
-	if uptime == 0 && downtime == 0 {
+	// Compute the decayed uptime of the host.
+	uptimeRatio, measured := hdb.decayedUptime(entry)
+	if !measured {
 		return 0.001 // Shouldn't happen.
 	}
 
-	// Compute the uptime ratio, but shift by 0.02 to acknowledge fully that
-	// 98% uptime and 100% uptime is valued the same.
-	uptimeRatio := float64(uptime) / float64(uptime+downtime)
+	// Shift the uptime ratio by 0.02 to acknowledge fully that 98% uptime and
+	// 100% uptime is valued the same.
 	if uptimeRatio > 0.98 {
 		uptimeRatio = 0.98
 	}
@@ -370,17 +450,23 @@ func (hdb *HostDB) uptimeAdjustments(entry modules.HostDBEntry) float64 {
 // calculateHostWeight returns the weight of a host according to the settings of
 // the host database entry.
 func (hdb *HostDB) calculateHostWeight(entry modules.HostDBEntry) types.Currency {
+	bandwidthPenalty := bandwidthAdjustments(entry)
+	capacityPenalty := capacityAdjustments(entry)
 	collateralReward := hdb.collateralAdjustments(entry)
 	interactionPenalty := hdb.interactionAdjustments(entry)
 	lifetimePenalty := hdb.lifetimeAdjustments(entry)
+	missedProofPenalty := missedProofAdjustments(entry)
 	pricePenalty := hdb.priceAdjustments(entry)
+	reputationAdjustment := hdb.reputationAdjustments(entry)
 	storageRemainingPenalty := storageRemainingAdjustments(entry)
 	uptimePenalty := hdb.uptimeAdjustments(entry)
 	versionPenalty := versionAdjustments(entry)
 
 	// Combine the adjustments.
-	fullPenalty := collateralReward * interactionPenalty * lifetimePenalty *
-		pricePenalty * storageRemainingPenalty * uptimePenalty * versionPenalty
+	fullPenalty := bandwidthPenalty * capacityPenalty * collateralReward *
+		interactionPenalty * lifetimePenalty * missedProofPenalty *
+		pricePenalty * reputationAdjustment * storageRemainingPenalty *
+		uptimePenalty * versionPenalty
 
 	// Return a types.Currency.
 	weight := baseWeight.MulFloat(fullPenalty)
@@ -391,13 +477,22 @@ func (hdb *HostDB) calculateHostWeight(entry modules.HostDBEntry) types.Currency
 	return weight
 }
 
+// hostWeight returns the weight that the host tree uses to select the host,
+// which is the custom weight of the dependencies if they provide one.
+func (hdb *HostDB) hostWeight(entry modules.HostDBEntry) types.Currency {
+	if hdb.customWeight != nil {
+		return hdb.customWeight(entry)
+	}
+	return hdb.calculateHostWeight(entry)
+}
+
 // calculateConversionRate calculates the conversion rate of the provided
 // host score, comparing it to the hosts in the database and returning what
 // percentage of contracts it is likely to participate in.
 func (hdb *HostDB) calculateConversionRate(score types.Currency) float64 {
 	var totalScore types.Currency
 	for _, h := range hdb.ActiveHosts() {
-		totalScore = totalScore.Add(hdb.calculateHostWeight(h))
+		totalScore = totalScore.Add(hdb.hostWeight(h))
 	}
 	if totalScore.IsZero() {
 		totalScore = types.NewCurrency64(1)
@@ -426,16 +521,26 @@ func (hdb *HostDB) EstimateHostScore(entry modules.HostDBEntry) modules.HostScor
 	if estimatedScore.IsZero() {
 		estimatedScore = types.NewCurrency64(1)
 	}
+	// A custom weight doesn't use the adjustments, so the host is scored
+	// like it would be selected.
+	if hdb.customWeight != nil {
+		estimatedScore = hdb.customWeight(entry)
+	}
 
 	// Compile the estimates into a host score breakdown.
 	return modules.HostScoreBreakdown{
 		Score:          estimatedScore,
 		ConversionRate: hdb.calculateConversionRate(estimatedScore),
+		DecayedUptime:  1,
 
 		AgeAdjustment:              1,
+		BandwidthAdjustment:        1,
 		BurnAdjustment:             1,
+		CapacityAdjustment:         1,
 		CollateralAdjustment:       collateralReward,
+		MissedProofAdjustment:      1,
 		PriceAdjustment:            pricePenalty,
+		ReputationAdjustment:       1,
 		StorageRemainingAdjustment: storageRemainingPenalty,
 		UptimeAdjustment:           1,
 		VersionAdjustment:          versionPenalty,
@@ -448,16 +553,23 @@ func (hdb *HostDB) ScoreBreakdown(entry modules.HostDBEntry) modules.HostScoreBr
 	hdb.mu.Lock()
 	defer hdb.mu.Unlock()
 
-	score := hdb.calculateHostWeight(entry)
+	score := hdb.hostWeight(entry)
+	decayedUptime, _ := hdb.decayedUptime(entry)
 	return modules.HostScoreBreakdown{
 		Score:          score,
 		ConversionRate: hdb.calculateConversionRate(score),
+		DecayedUptime:  decayedUptime,
+		MissedProofs:   entry.RecentMissedProofs,
 
 		AgeAdjustment:              hdb.lifetimeAdjustments(entry),
+		BandwidthAdjustment:        bandwidthAdjustments(entry),
 		BurnAdjustment:             1,
+		CapacityAdjustment:         capacityAdjustments(entry),
 		CollateralAdjustment:       hdb.collateralAdjustments(entry),
 		InteractionAdjustment:      hdb.interactionAdjustments(entry),
+		MissedProofAdjustment:      missedProofAdjustments(entry),
 		PriceAdjustment:            hdb.priceAdjustments(entry),
+		ReputationAdjustment:       hdb.reputationAdjustments(entry),
 		StorageRemainingAdjustment: storageRemainingAdjustments(entry),
 		UptimeAdjustment:           hdb.uptimeAdjustments(entry),
 		VersionAdjustment:          versionAdjustments(entry),
@@ -1,6 +1,7 @@
 package hostdb
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -286,3 +287,119 @@ func TestHostWeightUptimeDifferences4(t *testing.T) {
 		t.Error("Been around longer should have more weight")
 	}
 }
+
+// TestDecayedUptime checks that recent downtime outweighs a long history of
+// good uptime and that hosts with a short history aren't penalized for it.
+func TestDecayedUptime(t *testing.T) {
+	hdb := bareHostDB()
+	now := time.Now()
+
+	// A host that was online for a long time and offline for the last two
+	// half-lives.
+	var entry modules.HostDBEntry
+	entry.HistoricUptime = 10 * defaultUptimeHalfLife
+	entry.ScanHistory = modules.HostDBScans{
+		{Timestamp: now.Add(-12 * defaultUptimeHalfLife), Success: true},
+		{Timestamp: now.Add(-2 * defaultUptimeHalfLife), Success: false},
+		{Timestamp: now.Add(-defaultUptimeHalfLife), Success: false},
+		{Timestamp: now, Success: false},
+	}
+	uptime, measured := hdb.decayedUptime(entry)
+	if !measured {
+		t.Fatal("uptime should have been measured")
+	}
+	// Without the decay the host would have an uptime of 20 / 22.
+	if uptime > 0.5 {
+		t.Fatal("recent downtime should dominate the uptime, got", uptime)
+	}
+
+	// A host with only a few recent successful scans has full uptime.
+	entry = modules.HostDBEntry{}
+	entry.ScanHistory = modules.HostDBScans{
+		{Timestamp: now.Add(-time.Hour * 2), Success: true},
+		{Timestamp: now.Add(-time.Hour), Success: true},
+		{Timestamp: now, Success: true},
+	}
+	if uptime, _ := hdb.decayedUptime(entry); uptime != 1 {
+		t.Fatal("expected an uptime of 1 but got", uptime)
+	}
+
+	// Without any measured time the uptime is unknown.
+	entry.ScanHistory = entry.ScanHistory[:1]
+	if _, measured := hdb.decayedUptime(entry); measured {
+		t.Fatal("uptime shouldn't be measured for a single scan")
+	}
+}
+
+// TestSetUptimeHalfLife checks that the half-life of the uptime decay can be
+// changed and is persisted.
+func TestSetUptimeHalfLife(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	hdbt, err := newHDBTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A host that was online for a long time and offline recently.
+	now := time.Now()
+	var entry modules.HostDBEntry
+	entry.ScanHistory = modules.HostDBScans{
+		{Timestamp: now.Add(-10 * defaultUptimeHalfLife), Success: true},
+		{Timestamp: now.Add(-defaultUptimeHalfLife), Success: false},
+		{Timestamp: now, Success: false},
+	}
+	before := hdbt.hdb.ScoreBreakdown(entry).DecayedUptime
+
+	if err := hdbt.hdb.SetUptimeHalfLife(0); err != errUptimeHalfLife {
+		t.Fatal("expected errUptimeHalfLife, got", err)
+	}
+	halfLife := 100 * defaultUptimeHalfLife
+	if err := hdbt.hdb.SetUptimeHalfLife(halfLife); err != nil {
+		t.Fatal(err)
+	}
+	// With a longer half-life the old uptime counts for more.
+	if after := hdbt.hdb.ScoreBreakdown(entry).DecayedUptime; after <= before {
+		t.Fatalf("expected the uptime to increase from %v, got %v", before, after)
+	}
+
+	// The half-life survives a restart.
+	if err := hdbt.hdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	hdbt.hdb, err = NewCustomHostDB(hdbt.gateway, hdbt.cs, filepath.Join(hdbt.persistDir, modules.RenterDir), &quitAfterLoadDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdbt.hdb.uptimeHalfLife() != halfLife {
+		t.Fatal("half-life wasn't persisted:", hdbt.hdb.uptimeHalfLife())
+	}
+}
+
+// TestScoreBreakdownMatchesWeight checks that the adjustments of the score
+// breakdown multiply to the weight of the host.
+func TestScoreBreakdownMatchesWeight(t *testing.T) {
+	hdb := bareHostDB()
+	hdb.blockHeight = 10000
+	entry := makeHostDBEntry()
+	entry.FirstSeen = 9000
+	entry.RemainingStorage = 250e3
+	entry.StoragePrice = types.NewCurrency64(1000).Mul(types.SiacoinPrecision).Div64(4032).Div64(1e9)
+	entry.Collateral = entry.StoragePrice.Mul64(2)
+	entry.Version = build.Version
+	entry.HistoricSuccessfulInteractions = 10
+	entry.HistoricFailedInteractions = 2
+
+	sb := hdb.ScoreBreakdown(entry)
+	if sb.Score.Cmp(hdb.calculateHostWeight(entry)) != 0 {
+		t.Fatal("score of the breakdown doesn't match the weight of the host")
+	}
+	product := sb.BandwidthAdjustment * sb.CapacityAdjustment * sb.CollateralAdjustment *
+		sb.InteractionAdjustment * sb.AgeAdjustment * sb.MissedProofAdjustment *
+		sb.PriceAdjustment * sb.ReputationAdjustment * sb.StorageRemainingAdjustment *
+		sb.UptimeAdjustment * sb.VersionAdjustment * sb.BurnAdjustment
+	if sb.Score.Cmp(baseWeight.MulFloat(product)) != 0 {
+		t.Fatal("adjustments of the breakdown don't multiply to the score:", sb.Score, baseWeight.MulFloat(product))
+	}
+}
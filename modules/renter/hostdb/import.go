@@ -0,0 +1,101 @@
+package hostdb
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+var (
+	// errImportNotSynced is returned if hosts are imported before the
+	// consensus set is synced. Until then the hostdb doesn't know all the
+	// announcements that imported hosts are validated against.
+	errImportNotSynced = errors.New("consensus set needs to be synced before hosts can be imported")
+)
+
+// freshScans returns the scans that are no older than importScanFreshness,
+// sorted by time. Scans from the future are dropped as well. A scan history
+// needs at least two scans, so nil is returned if fewer scans are left.
+func freshScans(scans modules.HostDBScans, now time.Time) modules.HostDBScans {
+	var fresh modules.HostDBScans
+	for _, scan := range scans {
+		if scan.Timestamp.After(now) || now.Sub(scan.Timestamp) > importScanFreshness {
+			continue
+		}
+		fresh = append(fresh, scan)
+	}
+	if len(fresh) < 2 {
+		return nil
+	}
+	sort.Sort(fresh)
+	return fresh
+}
+
+// mergeImportedHost returns the local entry of a host updated with the data of
+// an imported entry. Only the settings and the recent scans are taken over.
+// The address stays the one announced on the local blockchain, and the
+// interactions, which reflect the experience of the other renter, are not
+// imported. If the host was already scanned by this node or none of the
+// imported scans are fresh, false is returned.
+func mergeImportedHost(local, imported modules.HostDBEntry, now time.Time) (modules.HostDBEntry, bool) {
+	if len(local.ScanHistory) > 0 || local.HistoricUptime != 0 || local.HistoricDowntime != 0 {
+		return local, false
+	}
+	scans := freshScans(imported.ScanHistory, now)
+	if scans == nil {
+		return local, false
+	}
+	netAddress := local.NetAddress
+	local.HostExternalSettings = imported.HostExternalSettings
+	local.NetAddress = netAddress
+	local.ScanHistory = scans
+	return local, true
+}
+
+// ExportHosts returns all hosts known to the hostdb together with their scan
+// histories, so that they can be imported by another node.
+func (hdb *HostDB) ExportHosts() []modules.HostDBEntry {
+	return hdb.hostTree.All()
+}
+
+// ImportHosts seeds the hostdb with hosts exported by another node and returns
+// the number of hosts that were imported. Only hosts that were announced on
+// the local blockchain and haven't been scanned by this node yet are imported.
+// The imported hosts are scanned again, and the initial scan isn't complete
+// before they are.
+func (hdb *HostDB) ImportHosts(hosts []modules.HostDBEntry) (int, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return 0, err
+	}
+	defer hdb.tg.Done()
+	if !hdb.cs.Synced() {
+		return 0, errImportNotSynced
+	}
+
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	now := time.Now()
+	var imported int
+	for _, host := range hosts {
+		local, exists := hdb.hostTree.Select(host.PublicKey)
+		if !exists {
+			hdb.log.Debugln("Not importing host that wasn't announced on the blockchain:", host.PublicKey)
+			continue
+		}
+		entry, ok := mergeImportedHost(local, host, now)
+		if !ok {
+			continue
+		}
+		if err := hdb.hostTree.Modify(entry); err != nil {
+			hdb.log.Println("ERROR: unable to modify host entry of host tree during an import:", err)
+			continue
+		}
+		hdb.importedHosts[entry.PublicKey.String()] = struct{}{}
+		hdb.queueScan(entry)
+		imported++
+	}
+	hdb.log.Printf("Imported %v of %v hosts", imported, len(hosts))
+	return imported, hdb.saveSync()
+}
@@ -0,0 +1,60 @@
+package hostdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// TestMergeImportedHost checks that only fresh scans are imported, that the
+// locally announced address is kept and that hosts scanned by this node are
+// left alone.
+func TestMergeImportedHost(t *testing.T) {
+	now := time.Now()
+	local := makeHostDBEntry()
+	local.ScanHistory = nil
+	local.NetAddress = "127.0.0.1:1"
+
+	imported := local
+	imported.NetAddress = "127.0.0.1:2"
+	imported.Version = "9.9.9"
+	imported.HistoricUptime = time.Hour
+	imported.RecentSuccessfulInteractions = 100
+	imported.ScanHistory = modules.HostDBScans{
+		{Timestamp: now.Add(-2 * importScanFreshness), Success: false},
+		{Timestamp: now.Add(-importScanFreshness / 2), Success: true},
+		{Timestamp: now.Add(-importScanFreshness / 4), Success: true},
+		{Timestamp: now.Add(time.Hour), Success: true},
+	}
+
+	entry, ok := mergeImportedHost(local, imported, now)
+	if !ok {
+		t.Fatal("host should be imported")
+	}
+	if len(entry.ScanHistory) != 2 || !entry.ScanHistory[0].Success {
+		t.Fatal("stale and future scans should be dropped", entry.ScanHistory)
+	}
+	if entry.NetAddress != local.NetAddress {
+		t.Fatal("imported address should be ignored")
+	}
+	if entry.Version != imported.Version {
+		t.Fatal("settings should be imported")
+	}
+	if entry.HistoricUptime != 0 || entry.RecentSuccessfulInteractions != 0 {
+		t.Fatal("historic data and interactions shouldn't be imported")
+	}
+
+	// Hosts without enough fresh scans aren't imported.
+	imported.ScanHistory = imported.ScanHistory[:2]
+	if _, ok := mergeImportedHost(local, imported, now); ok {
+		t.Fatal("host with a single fresh scan shouldn't be imported")
+	}
+
+	// Hosts that were scanned by this node keep their own data.
+	local.ScanHistory = modules.HostDBScans{{Timestamp: now, Success: false}}
+	imported.ScanHistory = entry.ScanHistory
+	if _, ok := mergeImportedHost(local, imported, now); ok {
+		t.Fatal("host with a local scan history shouldn't be imported")
+	}
+}
@@ -0,0 +1,121 @@
+package hostdb
+
+// missedproofs.go watches the blockchain for file contracts that expired
+// without a storage proof. A host that misses proofs on-chain is a risk even
+// if it answers every scan, so the missed proofs are counted towards the
+// score of the host. Contracts are matched to hosts through the payout
+// address of the host, which is the address of the host's valid proof output.
+//
+// Only the full consensus set reports the file contracts that were removed,
+// so hosts aren't penalized for missed proofs while the consensus set runs in
+// spv mode.
+
+import (
+	"math"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// findMissedProofs returns the file contracts of a consensus change that
+// expired without a storage proof and cost the host some of its collateral.
+// Contracts that didn't lose the host anything, like contracts that were
+// never used, aren't counted since the host has no reason to submit a proof
+// for them.
+func findMissedProofs(cc modules.ConsensusChange, height types.BlockHeight) (missed []types.FileContract) {
+	proven := make(map[types.FileContractID]struct{})
+	for _, block := range cc.AppliedBlocks {
+		for _, txn := range block.Transactions {
+			for _, sp := range txn.StorageProofs {
+				proven[sp.ParentID] = struct{}{}
+			}
+		}
+	}
+	// A revision removes the old version of a contract and adds the new one
+	// under the same id.
+	applied := make(map[types.FileContractID]struct{})
+	for _, diff := range cc.FileContractDiffs {
+		if diff.Direction == modules.DiffApply {
+			applied[diff.ID] = struct{}{}
+		}
+	}
+	for _, diff := range cc.FileContractDiffs {
+		if diff.Direction != modules.DiffRevert {
+			continue
+		}
+		if _, exists := applied[diff.ID]; exists {
+			continue
+		}
+		if _, exists := proven[diff.ID]; exists {
+			continue
+		}
+		fc := diff.FileContract
+		if fc.WindowEnd > height || len(fc.ValidProofOutputs) < 2 || len(fc.MissedProofOutputs) < 2 {
+			continue
+		}
+		if fc.MissedProofOutputs[1].Value.Cmp(fc.ValidProofOutputs[1].Value) >= 0 {
+			continue
+		}
+		missed = append(missed, fc)
+	}
+	return missed
+}
+
+// missedProofAdjustments penalizes hosts that recently missed storage proofs.
+// Every missed proof divides the weight of the host by four.
+func missedProofAdjustments(entry modules.HostDBEntry) float64 {
+	missed := entry.RecentMissedProofs
+	if missed > maxMissedProofPenalty {
+		missed = maxMissedProofPenalty
+	}
+	return math.Pow(0.25, float64(missed))
+}
+
+// updateMissedProofs records the storage proofs missed in a consensus change
+// for the hosts that were paid by the contracts, and resets the count of the
+// hosts that haven't missed a proof for missedProofWindow blocks.
+func (hdb *HostDB) updateMissedProofs(cc modules.ConsensusChange) {
+	for pk := range hdb.missedProofHosts {
+		var spk types.SiaPublicKey
+		spk.LoadString(pk)
+		entry, exists := hdb.hostTree.Select(spk)
+		if !exists {
+			delete(hdb.missedProofHosts, pk)
+			continue
+		}
+		if entry.LastMissedProof+missedProofWindow > hdb.blockHeight {
+			continue
+		}
+		entry.RecentMissedProofs = 0
+		if err := hdb.hostTree.Modify(entry); err != nil {
+			hdb.log.Println("ERROR: unable to reset the missed proofs of a host:", err)
+		}
+		delete(hdb.missedProofHosts, pk)
+	}
+
+	missed := findMissedProofs(cc, hdb.blockHeight)
+	if len(missed) == 0 {
+		return
+	}
+	hosts := make(map[types.UnlockHash]types.SiaPublicKey)
+	for _, entry := range hdb.hostTree.All() {
+		if entry.UnlockHash != (types.UnlockHash{}) {
+			hosts[entry.UnlockHash] = entry.PublicKey
+		}
+	}
+	for _, fc := range missed {
+		spk, exists := hosts[fc.ValidProofOutputs[1].UnlockHash]
+		if !exists {
+			continue
+		}
+		entry, _ := hdb.hostTree.Select(spk)
+		entry.RecentMissedProofs++
+		entry.LastMissedProof = hdb.blockHeight
+		if err := hdb.hostTree.Modify(entry); err != nil {
+			hdb.log.Println("ERROR: unable to record a missed proof of a host:", err)
+			continue
+		}
+		hdb.missedProofHosts[spk.String()] = struct{}{}
+		hdb.log.Printf("Host %v missed a storage proof, %v recent missed proofs", spk, entry.RecentMissedProofs)
+	}
+}
@@ -0,0 +1,92 @@
+package hostdb
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// missedProofContract returns a file contract that pays the host at uh and
+// costs the host some collateral if the proof is missed.
+func missedProofContract(uh types.UnlockHash, windowEnd types.BlockHeight) types.FileContract {
+	return types.FileContract{
+		WindowEnd: windowEnd,
+		ValidProofOutputs: []types.SiacoinOutput{
+			{Value: types.NewCurrency64(10)},
+			{Value: types.NewCurrency64(20), UnlockHash: uh},
+		},
+		MissedProofOutputs: []types.SiacoinOutput{
+			{Value: types.NewCurrency64(10)},
+			{Value: types.NewCurrency64(15), UnlockHash: uh},
+			{Value: types.NewCurrency64(5)},
+		},
+	}
+}
+
+// TestFindMissedProofs checks that only contracts that expired without a
+// proof and cost the host collateral are reported.
+func TestFindMissedProofs(t *testing.T) {
+	missed := missedProofContract(types.UnlockHash{1}, 10)
+	unused := missedProofContract(types.UnlockHash{1}, 10)
+	unused.MissedProofOutputs[1].Value = unused.ValidProofOutputs[1].Value
+	notExpired := missedProofContract(types.UnlockHash{1}, 20)
+
+	cc := modules.ConsensusChange{
+		AppliedBlocks: []types.Block{{
+			Transactions: []types.Transaction{{
+				StorageProofs: []types.StorageProof{{ParentID: types.FileContractID{2}}},
+			}},
+		}},
+		FileContractDiffs: []modules.FileContractDiff{
+			{Direction: modules.DiffRevert, ID: types.FileContractID{1}, FileContract: missed},
+			{Direction: modules.DiffRevert, ID: types.FileContractID{2}, FileContract: missed},
+			{Direction: modules.DiffRevert, ID: types.FileContractID{3}, FileContract: missed},
+			{Direction: modules.DiffApply, ID: types.FileContractID{3}, FileContract: missed},
+			{Direction: modules.DiffRevert, ID: types.FileContractID{4}, FileContract: unused},
+			{Direction: modules.DiffRevert, ID: types.FileContractID{5}, FileContract: notExpired},
+		},
+	}
+	if fcs := findMissedProofs(cc, 15); len(fcs) != 1 {
+		t.Fatal("expected 1 missed proof, got", len(fcs))
+	}
+}
+
+// TestUpdateMissedProofs checks that missed proofs are counted for the host
+// that was paid by the contract, lower its score, and are forgiven after
+// missedProofWindow blocks.
+func TestUpdateMissedProofs(t *testing.T) {
+	hdb := bareHostDB()
+	entry := makeHostDBEntry()
+	entry.UnlockHash = types.UnlockHash{1}
+	if err := hdb.hostTree.Insert(entry); err != nil {
+		t.Fatal(err)
+	}
+	hdb.blockHeight = 10
+	scoreBefore := hdb.ScoreBreakdown(entry).Score
+
+	hdb.updateMissedProofs(modules.ConsensusChange{
+		FileContractDiffs: []modules.FileContractDiff{{
+			Direction:    modules.DiffRevert,
+			FileContract: missedProofContract(entry.UnlockHash, 10),
+		}},
+	})
+	entry, _ = hdb.hostTree.Select(entry.PublicKey)
+	if entry.RecentMissedProofs != 1 || entry.LastMissedProof != 10 {
+		t.Fatal("missed proof wasn't recorded", entry.RecentMissedProofs, entry.LastMissedProof)
+	}
+	breakdown := hdb.ScoreBreakdown(entry)
+	if breakdown.MissedProofs != 1 || breakdown.Score.Cmp(scoreBefore) >= 0 {
+		t.Fatal("missed proof should lower the score of the host", breakdown.MissedProofs, breakdown.Score, scoreBefore)
+	}
+
+	hdb.blockHeight += missedProofWindow
+	hdb.updateMissedProofs(modules.ConsensusChange{})
+	entry, _ = hdb.hostTree.Select(entry.PublicKey)
+	if entry.RecentMissedProofs != 0 {
+		t.Fatal("missed proofs should have been forgiven")
+	}
+	if len(hdb.missedProofHosts) != 0 {
+		t.Fatal("host should have been removed from the missed proof hosts")
+	}
+}
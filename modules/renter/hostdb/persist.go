@@ -2,6 +2,7 @@ package hostdb
 
 import (
 	"path/filepath"
+	"sync/atomic"
 	"time"
 
 	"github.com/HyperspaceApp/Hyperspace/modules"
@@ -24,9 +25,23 @@ var (
 
 // hdbPersist defines what HostDB data persists across sessions.
 type hdbPersist struct {
-	AllHosts    []modules.HostDBEntry
-	BlockHeight types.BlockHeight
-	LastChange  modules.ConsensusChangeID
+	AllHosts      []modules.HostDBEntry
+	BlockHeight   types.BlockHeight
+	ImportedHosts []string
+	LastChange    modules.ConsensusChangeID
+
+	ReputationFeedURL    string
+	ReputationFeedWeight float64
+
+	MaxScanHistoryLen  int
+	MaxScanningThreads int
+	UptimeHalfLife     time.Duration
+
+	IPv4FilterRange int
+	IPv6FilterRange int
+
+	FilterMode    string
+	FilteredHosts []types.SiaPublicKey
 }
 
 // persistData returns the data in the hostdb that will be saved to disk.
@@ -34,6 +49,22 @@ func (hdb *HostDB) persistData() (data hdbPersist) {
 	data.AllHosts = hdb.hostTree.All()
 	data.BlockHeight = hdb.blockHeight
 	data.LastChange = hdb.lastChange
+	data.MaxScanHistoryLen = hdb.maxScanHistoryLen
+	data.MaxScanningThreads = hdb.maxScanningThreads
+	data.UptimeHalfLife = hdb.uptimeHalfLife()
+	data.IPv4FilterRange = hdb.ipv4FilterRange
+	data.IPv6FilterRange = hdb.ipv6FilterRange
+	data.FilterMode = hdb.filterMode
+	for _, pk := range hdb.filteredHosts {
+		data.FilteredHosts = append(data.FilteredHosts, pk)
+	}
+	hdb.reputationMu.Lock()
+	data.ReputationFeedURL = hdb.reputationFeed.URL
+	data.ReputationFeedWeight = hdb.reputationFeed.Weight
+	hdb.reputationMu.Unlock()
+	for pk := range hdb.importedHosts {
+		data.ImportedHosts = append(data.ImportedHosts, pk)
+	}
 	return data
 }
 
@@ -54,6 +85,29 @@ func (hdb *HostDB) load() error {
 	// Set the hostdb internal values.
 	hdb.blockHeight = data.BlockHeight
 	hdb.lastChange = data.LastChange
+	if data.MaxScanHistoryLen != 0 {
+		hdb.maxScanHistoryLen = data.MaxScanHistoryLen
+	}
+	if data.MaxScanningThreads != 0 {
+		hdb.maxScanningThreads = data.MaxScanningThreads
+	}
+	if data.UptimeHalfLife != 0 {
+		atomic.StoreInt64(&hdb.atomicUptimeHalfLife, int64(data.UptimeHalfLife))
+	}
+	if data.IPv4FilterRange != 0 && data.IPv6FilterRange != 0 {
+		hdb.ipv4FilterRange = data.IPv4FilterRange
+		hdb.ipv6FilterRange = data.IPv6FilterRange
+		hdb.hostTree.SetFilterRanges(hdb.ipv4FilterRange, hdb.ipv6FilterRange)
+	}
+	hdb.filterMode = data.FilterMode
+	for _, pk := range data.FilteredHosts {
+		hdb.filteredHosts[pk.String()] = pk
+	}
+	hdb.reputationFeed.URL = data.ReputationFeedURL
+	hdb.reputationFeed.Weight = data.ReputationFeedWeight
+	for _, pk := range data.ImportedHosts {
+		hdb.importedHosts[pk] = struct{}{}
+	}
 
 	// Load each of the hosts into the host tree.
 	for _, host := range data.AllHosts {
@@ -61,9 +115,13 @@ func (hdb *HostDB) load() error {
 		if err != nil {
 			hdb.log.Debugln("ERROR: could not insert host while loading:", host.NetAddress)
 		}
+		if host.RecentMissedProofs > 0 {
+			hdb.missedProofHosts[host.PublicKey.String()] = struct{}{}
+		}
 
 		// Make sure that all hosts have gone through the initial scanning.
-		if len(host.ScanHistory) < 2 {
+		_, imported := hdb.importedHosts[host.PublicKey.String()]
+		if len(host.ScanHistory) < 2 || imported {
 			hdb.queueScan(host)
 		}
 	}
@@ -0,0 +1,186 @@
+package hostdb
+
+// reputation.go folds the scores of an external reputation feed, e.g. a
+// community-maintained list of reliable hosts, into the weights of the hosts.
+// The feed is an HTTP endpoint that returns a score between 0 and 1 for every
+// host it knows. The scores are downloaded periodically and cached, and they
+// only adjust the weights within the bounds set by the weight of the feed.
+// Hosts that aren't listed, and all hosts while the feed is unreachable for
+// longer than reputationFeedExpiry, are scored using only local evidence.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+var (
+	errReputationFeedStatus = errors.New("reputation feed returned an unexpected status")
+	errReputationWeight     = fmt.Errorf("weight of the reputation feed must be between 0 and %v", maxReputationWeight)
+)
+
+type (
+	// reputationFeedHost is the score of a host listed by a reputation feed.
+	reputationFeedHost struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		Score     float64            `json:"score"`
+	}
+
+	// reputationFeedResponse is the response of a reputation feed.
+	reputationFeedResponse struct {
+		Hosts []reputationFeedHost `json:"hosts"`
+	}
+)
+
+// fetchReputationFeed downloads the scores of the reputation feed at url.
+// Scores outside of the range of 0 to 1 are clamped.
+func fetchReputationFeed(url string) (map[string]float64, error) {
+	client := http.Client{Timeout: reputationFeedTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%v: %v", errReputationFeedStatus, resp.Status)
+	}
+	var feed reputationFeedResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxReputationFeedSize)).Decode(&feed); err != nil {
+		return nil, err
+	}
+	scores := make(map[string]float64, len(feed.Hosts))
+	for _, host := range feed.Hosts {
+		if math.IsNaN(host.Score) {
+			continue
+		}
+		scores[host.PublicKey.String()] = math.Max(0, math.Min(1, host.Score))
+	}
+	return scores, nil
+}
+
+// reputationAdjustments adjusts the weight of hosts listed by the reputation
+// feed. A score of 1 increases the weight by the weight of the feed, a score
+// of 0 decreases it by the same fraction and a score of 0.5 is neutral.
+func (hdb *HostDB) reputationAdjustments(entry modules.HostDBEntry) float64 {
+	hdb.reputationMu.Lock()
+	defer hdb.reputationMu.Unlock()
+	if hdb.reputationFeed.URL == "" || time.Since(hdb.reputationFeed.LastUpdate) > reputationFeedExpiry {
+		return 1
+	}
+	score, exists := hdb.reputationScores[entry.PublicKey.String()]
+	if !exists {
+		return 1
+	}
+	return 1 + hdb.reputationFeed.Weight*(2*score-1)
+}
+
+// managedUpdateHostWeights recalculates the weights of all hosts in the host
+// tree after the reputation scores changed.
+func (hdb *HostDB) managedUpdateHostWeights() {
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	for _, entry := range hdb.hostTree.All() {
+		if err := hdb.hostTree.Modify(entry); err != nil {
+			hdb.log.Println("ERROR: unable to update the weight of a host:", err)
+		}
+	}
+}
+
+// managedRefreshReputationFeed downloads the scores of the reputation feed.
+// If the download fails, the cached scores are kept until they expire.
+func (hdb *HostDB) managedRefreshReputationFeed() {
+	hdb.reputationMu.Lock()
+	url := hdb.reputationFeed.URL
+	hdb.reputationMu.Unlock()
+	if url == "" {
+		return
+	}
+	scores, err := fetchReputationFeed(url)
+
+	hdb.reputationMu.Lock()
+	if hdb.reputationFeed.URL != url {
+		// The feed was replaced during the download.
+		hdb.reputationMu.Unlock()
+		return
+	}
+	if err != nil {
+		hdb.reputationFeed.LastError = err.Error()
+		hdb.log.Println("WARN: unable to download the reputation feed:", err)
+	} else {
+		hdb.reputationScores = scores
+		hdb.reputationFeed.Hosts = uint64(len(scores))
+		hdb.reputationFeed.LastUpdate = time.Now()
+		hdb.reputationFeed.LastError = ""
+	}
+	hdb.reputationMu.Unlock()
+	hdb.managedUpdateHostWeights()
+}
+
+// threadedRefreshReputationFeed periodically downloads the scores of the
+// reputation feed.
+func (hdb *HostDB) threadedRefreshReputationFeed() {
+	if err := hdb.tg.Add(); err != nil {
+		return
+	}
+	defer hdb.tg.Done()
+	for {
+		hdb.managedRefreshReputationFeed()
+		select {
+		case <-hdb.tg.StopChan():
+			return
+		case <-time.After(reputationFeedInterval):
+		}
+	}
+}
+
+// ReputationFeed returns the external reputation feed and the state of its
+// cached scores.
+func (hdb *HostDB) ReputationFeed() modules.HostDBReputationFeed {
+	hdb.reputationMu.Lock()
+	defer hdb.reputationMu.Unlock()
+	return hdb.reputationFeed
+}
+
+// SetReputationFeed sets the external reputation feed whose scores adjust the
+// weights of the hosts by at most weight in either direction. The scores of
+// the previous feed are dropped and the new feed is downloaded right away. An
+// empty url disables the feed.
+func (hdb *HostDB) SetReputationFeed(url string, weight float64) error {
+	if err := hdb.tg.Add(); err != nil {
+		return err
+	}
+	defer hdb.tg.Done()
+	if url == "" {
+		weight = 0
+	} else if math.IsNaN(weight) || weight < 0 || weight > maxReputationWeight {
+		return errReputationWeight
+	}
+
+	hdb.reputationMu.Lock()
+	hdb.reputationFeed = modules.HostDBReputationFeed{
+		URL:    url,
+		Weight: weight,
+	}
+	hdb.reputationScores = make(map[string]float64)
+	hdb.reputationMu.Unlock()
+
+	hdb.mu.Lock()
+	err := hdb.saveSync()
+	hdb.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if url == "" {
+		hdb.managedUpdateHostWeights()
+		return nil
+	}
+	hdb.managedRefreshReputationFeed()
+	return nil
+}
@@ -0,0 +1,77 @@
+package hostdb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// TestReputationFeed tests that the scores of a reputation feed adjust the
+// weights of the listed hosts within the bounds of the feed's weight and that
+// the hosts fall back to local scoring once the scores expire.
+func TestReputationFeed(t *testing.T) {
+	hdb := bareHostDB()
+	good := makeHostDBEntry()
+	bad := makeHostDBEntry()
+	unlisted := makeHostDBEntry()
+	for _, entry := range []modules.HostDBEntry{good, bad, unlisted} {
+		if err := hdb.hostTree.Insert(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	localWeight := hdb.calculateHostWeight(unlisted)
+
+	feed := reputationFeedResponse{
+		Hosts: []reputationFeedHost{
+			{PublicKey: good.PublicKey, Score: 2},
+			{PublicKey: bad.PublicKey, Score: 0},
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(w).Encode(feed)
+	}))
+
+	hdb.reputationFeed.URL = server.URL
+	hdb.reputationFeed.Weight = maxReputationWeight
+	hdb.managedRefreshReputationFeed()
+	if rf := hdb.ReputationFeed(); rf.Hosts != 2 || rf.LastError != "" {
+		t.Fatal("feed wasn't downloaded", rf)
+	}
+
+	// The score of the good host is clamped to 1.
+	if adj := hdb.ScoreBreakdown(good).ReputationAdjustment; adj != 1+maxReputationWeight {
+		t.Fatal("unexpected adjustment of the good host", adj)
+	}
+	if adj := hdb.ScoreBreakdown(bad).ReputationAdjustment; adj != 1-maxReputationWeight {
+		t.Fatal("unexpected adjustment of the bad host", adj)
+	}
+	if adj := hdb.ScoreBreakdown(unlisted).ReputationAdjustment; adj != 1 {
+		t.Fatal("unlisted host shouldn't be adjusted", adj)
+	}
+	goodEntry, _ := hdb.hostTree.Select(good.PublicKey)
+	if hdb.calculateHostWeight(goodEntry).Cmp(localWeight) <= 0 {
+		t.Fatal("good host should weigh more than an unlisted host")
+	}
+
+	// An unreachable feed keeps the cached scores.
+	server.Close()
+	hdb.managedRefreshReputationFeed()
+	if rf := hdb.ReputationFeed(); rf.Hosts != 2 || rf.LastError == "" {
+		t.Fatal("failed download wasn't reported", rf)
+	}
+	if adj := hdb.reputationAdjustments(good); adj != 1+maxReputationWeight {
+		t.Fatal("cached scores weren't used", adj)
+	}
+
+	// Expired scores fall back to local scoring.
+	hdb.reputationMu.Lock()
+	hdb.reputationFeed.LastUpdate = time.Now().Add(-reputationFeedExpiry - time.Second)
+	hdb.reputationMu.Unlock()
+	if adj := hdb.reputationAdjustments(good); adj != 1 {
+		t.Fatal("expired scores shouldn't be used", adj)
+	}
+}
@@ -0,0 +1,69 @@
+package hostdb
+
+import (
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// finishRescan marks the host as scanned for the active rescan. The caller
+// must hold the hostdb's lock.
+func (hdb *HostDB) finishRescan(key string) {
+	if _, pending := hdb.rescanPending[key]; !pending {
+		return
+	}
+	delete(hdb.rescanPending, key)
+	hdb.rescanStatus.Done++
+	if len(hdb.rescanPending) == 0 {
+		hdb.rescanStatus.Active = false
+		hdb.rescanStatus.EndTime = time.Now()
+		hdb.log.Printf("Rescan of %v hosts complete", hdb.rescanStatus.Total)
+	}
+}
+
+// RescanHosts queues a scan of every host known to the hostdb, regardless of
+// when it is due. The scans go through the same scan list and scanning threads
+// as the regular scans, so hosts that are already queued or being scanned
+// aren't scanned twice; their ongoing scans count towards the rescan. Calling
+// RescanHosts while a rescan is active has no effect.
+func (hdb *HostDB) RescanHosts() error {
+	if err := hdb.tg.Add(); err != nil {
+		return err
+	}
+	defer hdb.tg.Done()
+
+	allHosts := hdb.hostTree.All()
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	if hdb.rescanStatus.Active {
+		return nil
+	}
+	hdb.rescanStatus = modules.HostDBRescanStatus{
+		Active:    len(allHosts) > 0,
+		Total:     uint64(len(allHosts)),
+		StartTime: time.Now(),
+	}
+	if len(allHosts) == 0 {
+		hdb.rescanStatus.EndTime = hdb.rescanStatus.StartTime
+		return nil
+	}
+	for _, host := range allHosts {
+		key := host.PublicKey.String()
+		hdb.rescanPending[key] = struct{}{}
+		if _, scanning := hdb.scanningHosts[key]; scanning {
+			continue
+		}
+		hdb.queueScan(host)
+	}
+	hdb.log.Printf("Rescanning %v hosts", len(allHosts))
+	return nil
+}
+
+// RescanStatus returns the progress of the most recent rescan.
+func (hdb *HostDB) RescanStatus() modules.HostDBRescanStatus {
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	status := hdb.rescanStatus
+	status.Pending = uint64(len(hdb.rescanPending))
+	return status
+}
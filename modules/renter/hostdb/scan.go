@@ -5,6 +5,7 @@ package hostdb
 // settings of the hosts.
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"sort"
@@ -17,22 +18,33 @@ import (
 	"github.com/HyperspaceApp/fastrand"
 )
 
+var errMaxScanningThreads = errors.New("at least one scanning thread is required")
+
 // queueScan will add a host to the queue to be scanned. The host will be added
 // at a random position which means that the order in which queueScan is called
 // is not necessarily the order in which the hosts get scanned. That guarantees
-// a random scan order during the initial scan.
+// a random scan order during the initial scan. During the initial scan, hosts
+// that have never been scanned are queued ahead of the other hosts.
 func (hdb *HostDB) queueScan(entry modules.HostDBEntry) {
 	// If this entry is already in the scan pool, can return immediately.
 	_, exists := hdb.scanMap[entry.PublicKey.String()]
 	if exists {
 		return
 	}
-	// Add the entry to a random position in the waitlist.
+	// Add the entry to a random position in its part of the waitlist.
 	hdb.scanMap[entry.PublicKey.String()] = struct{}{}
 	hdb.scanList = append(hdb.scanList, entry)
-	if len(hdb.scanList) > 1 {
-		i := len(hdb.scanList) - 1
-		j := fastrand.Intn(i)
+	i := len(hdb.scanList) - 1
+	if !hdb.initialScanComplete && len(entry.ScanHistory) == 0 {
+		// Move the first host that has been scanned before to the end to
+		// make room for the unscanned host.
+		hdb.scanList[i], hdb.scanList[hdb.unscannedQueued] = hdb.scanList[hdb.unscannedQueued], hdb.scanList[i]
+		i = hdb.unscannedQueued
+		hdb.unscannedQueued++
+		j := fastrand.Intn(i + 1)
+		hdb.scanList[i], hdb.scanList[j] = hdb.scanList[j], hdb.scanList[i]
+	} else if i > hdb.unscannedQueued {
+		j := hdb.unscannedQueued + fastrand.Intn(i-hdb.unscannedQueued)
 		hdb.scanList[i], hdb.scanList[j] = hdb.scanList[j], hdb.scanList[i]
 	}
 	// Check if any thread is currently emptying the waitlist. If not, spawn a
@@ -44,8 +56,8 @@ func (hdb *HostDB) queueScan(entry modules.HostDBEntry) {
 
 	// Sanity check - the scan map and the scan list should have the same
 	// length.
-	if build.DEBUG && len(hdb.scanMap) > len(hdb.scanList)+maxScanningThreads {
-		hdb.log.Critical("The hostdb scan map has seemingly grown too large:", len(hdb.scanMap), len(hdb.scanList), maxScanningThreads)
+	if build.DEBUG && len(hdb.scanMap) > len(hdb.scanList)+hdb.maxScanningThreads {
+		hdb.log.Critical("The hostdb scan map has seemingly grown too large:", len(hdb.scanMap), len(hdb.scanList), hdb.maxScanningThreads)
 	}
 
 	hdb.scanWait = true
@@ -88,7 +100,11 @@ func (hdb *HostDB) queueScan(entry modules.HostDBEntry) {
 			// Get the next host, shrink the scan list.
 			entry := hdb.scanList[0]
 			hdb.scanList = hdb.scanList[1:]
+			if hdb.unscannedQueued > 0 {
+				hdb.unscannedQueued--
+			}
 			delete(hdb.scanMap, entry.PublicKey.String())
+			hdb.scanningHosts[entry.PublicKey.String()] = struct{}{}
 			scansRemaining := len(hdb.scanList)
 
 			// Grab the most recent entry for this host.
@@ -107,7 +123,7 @@ func (hdb *HostDB) queueScan(entry modules.HostDBEntry) {
 			}
 
 			// Create new worker thread.
-			if hdb.scanningThreads < maxScanningThreads || !starterThread {
+			if hdb.scanningThreads < hdb.maxScanningThreads || !starterThread {
 				starterThread = true
 				hdb.scanningThreads++
 				if err := hdb.tg.Add(); err != nil {
@@ -137,6 +153,54 @@ func (hdb *HostDB) queueScan(entry modules.HostDBEntry) {
 	}()
 }
 
+// scanInterval returns the amount of time the hostdb should wait before
+// scanning a host with the provided scan history and number of consecutive
+// failed scans again. Hosts that pass all of their recent scans are scanned
+// rarely, hosts that recently changed their status are scanned often and
+// hosts that keep failing are scanned with an exponential backoff.
+func scanInterval(history modules.HostDBScans, failures uint64) time.Duration {
+	if len(history) == 0 {
+		return 0
+	}
+	window := history
+	if len(window) > flakyScanWindow {
+		window = window[len(window)-flakyScanWindow:]
+	}
+	if failures == 0 {
+		for _, scan := range window {
+			if !scan.Success {
+				return flakyScanInterval
+			}
+		}
+		return stableScanInterval
+	}
+	for _, scan := range window {
+		if scan.Success {
+			// The host was online within the window.
+			return flakyScanInterval
+		}
+	}
+	interval := offlineScanInterval
+	for i := uint64(1); i < failures && interval < maxOfflineScanInterval; i++ {
+		interval *= 2
+	}
+	if interval > maxOfflineScanInterval {
+		interval = maxOfflineScanInterval
+	}
+	return interval
+}
+
+// jitterScanInterval adds a random delay of up to a scanJitterDivisor-th of
+// the interval, which spreads out the scans of hosts that went offline at the
+// same time.
+func jitterScanInterval(interval time.Duration) time.Duration {
+	maxJitter := int(interval / scanJitterDivisor)
+	if maxJitter <= 0 {
+		return interval
+	}
+	return interval + time.Duration(fastrand.Intn(maxJitter))
+}
+
 // updateEntry updates an entry in the hostdb after a scan has taken place.
 //
 // CAUTION: This function will automatically add multiple entries to a new host
@@ -148,6 +212,7 @@ func (hdb *HostDB) updateEntry(entry modules.HostDBEntry, netErr error) {
 	if netErr != nil && !hdb.gateway.Online() {
 		return
 	}
+	delete(hdb.importedHosts, entry.PublicKey.String())
 
 	// Grab the host from the host tree, and update it with the neew settings.
 	newEntry, exists := hdb.hostTree.Select(entry.PublicKey)
@@ -198,6 +263,20 @@ func (hdb *HostDB) updateEntry(entry modules.HostDBEntry, netErr error) {
 		newEntry.ScanHistory = append(newEntry.ScanHistory, modules.HostDBScan{Timestamp: newTimestamp, Success: netErr == nil})
 	}
 
+	// A successful scan resets the backoff of the host. The first failure
+	// counts the failed scans at the end of the scan history, which covers
+	// hosts whose failures weren't counted yet.
+	switch {
+	case netErr == nil:
+		newEntry.ScanFailures = 0
+	case newEntry.ScanFailures == 0:
+		for i := len(newEntry.ScanHistory) - 1; i >= 0 && !newEntry.ScanHistory[i].Success; i-- {
+			newEntry.ScanFailures++
+		}
+	default:
+		newEntry.ScanFailures++
+	}
+
 	// Check whether any of the recent scans demonstrate uptime. The pruning and
 	// compression of the history ensure that there are only relatively recent
 	// scans represented.
@@ -222,17 +301,17 @@ func (hdb *HostDB) updateEntry(entry modules.HostDBEntry, netErr error) {
 		return
 	}
 
-	// Compress any old scans into the historic values.
+	// Compress any old scans into the historic values, and any scans beyond
+	// the maximum length of the scan history.
+	pruneScanHistory(&newEntry, hdb.maxScanHistoryLen)
 	for len(newEntry.ScanHistory) > minScans && time.Now().Sub(newEntry.ScanHistory[0].Timestamp) > maxHostDowntime {
-		timePassed := newEntry.ScanHistory[1].Timestamp.Sub(newEntry.ScanHistory[0].Timestamp)
-		if newEntry.ScanHistory[0].Success {
-			newEntry.HistoricUptime += timePassed
-		} else {
-			newEntry.HistoricDowntime += timePassed
-		}
-		newEntry.ScanHistory = newEntry.ScanHistory[1:]
+		compressOldestScan(&newEntry)
 	}
 
+	// Schedule the next scan of the host.
+	interval := jitterScanInterval(scanInterval(newEntry.ScanHistory, newEntry.ScanFailures))
+	newEntry.NextScan = newEntry.ScanHistory[len(newEntry.ScanHistory)-1].Timestamp.Add(interval)
+
 	// Add the updated entry
 	if !exists {
 		err := hdb.hostTree.Insert(newEntry)
@@ -254,6 +333,9 @@ func (hdb *HostDB) updateEntry(entry modules.HostDBEntry, netErr error) {
 // managedScanHost will connect to a host and grab the settings, verifying
 // uptime and updating to the host's preferences.
 func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
+	// Let the dependencies observe the scan.
+	hdb.deps.Disrupt("scanHost")
+
 	// Request settings from the queued host entry.
 	netAddr := entry.NetAddress
 	pubKey := entry.PublicKey
@@ -335,6 +417,8 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	// Update the host tree to have a new entry, including the new error. Then
 	// delete the entry from the scan map as the scan has been successful.
 	hdb.updateEntry(entry, err)
+	delete(hdb.scanningHosts, pubKey.String())
+	hdb.finishRescan(pubKey.String())
 
 	// Add the scan to the initialScanLatencies if it was successful.
 	if success && len(hdb.initialScanLatencies) < minScansForSpeedup {
@@ -349,17 +433,19 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 }
 
 // waitForScans is a helper function that blocks until the hostDB's scanList is
-// empty.
-func (hdb *HostDB) managedWaitForScans() {
+// empty and the scans of the hosts taken from it have finished. It returns
+// false if the hostdb is shutting down.
+func (hdb *HostDB) managedWaitForScans() bool {
 	for {
 		hdb.mu.Lock()
-		length := len(hdb.scanList)
+		length := len(hdb.scanList) + len(hdb.scanningHosts)
 		hdb.mu.Unlock()
 		if length == 0 {
-			break
+			return true
 		}
 		select {
 		case <-hdb.tg.StopChan():
+			return false
 		case <-time.After(scanCheckInterval):
 		}
 	}
@@ -417,16 +503,20 @@ func (hdb *HostDB) threadedScan() {
 
 	// The initial scan might have been interrupted. Queue one scan for every
 	// announced host that was missed by the initial scan and wait for the
-	// scans to finish before starting the scan loop.
+	// scans to finish before starting the scan loop. Imported hosts need to be
+	// scanned by this node as well.
 	allHosts := hdb.hostTree.All()
 	hdb.mu.Lock()
 	for _, host := range allHosts {
-		if len(host.ScanHistory) == 0 && host.HistoricUptime == 0 && host.HistoricDowntime == 0 {
+		_, imported := hdb.importedHosts[host.PublicKey.String()]
+		if imported || (len(host.ScanHistory) == 0 && host.HistoricUptime == 0 && host.HistoricDowntime == 0) {
 			hdb.queueScan(host)
 		}
 	}
 	hdb.mu.Unlock()
-	hdb.managedWaitForScans()
+	if !hdb.managedWaitForScans() {
+		return
+	}
 
 	// Set the flag to indicate that the initial scan is complete.
 	hdb.mu.Lock()
@@ -434,54 +524,53 @@ func (hdb *HostDB) threadedScan() {
 	hdb.mu.Unlock()
 
 	for {
-		// Set up a scan for the hostCheckupQuanity most valuable hosts in the
-		// hostdb. Hosts that fail their scans will be docked significantly,
-		// pushing them further back in the hierarchy, ensuring that for the
-		// most part only online hosts are getting scanned unless there are
-		// fewer than hostCheckupQuantity of them.
-
-		// Grab a set of hosts to scan, grab hosts that are active, inactive,
-		// and offline to get high diversity.
-		var onlineHosts, offlineHosts []modules.HostDBEntry
-		allHosts := hdb.hostTree.All()
-		for i := len(allHosts) - 1; i >= 0; i-- {
-			if len(onlineHosts) >= hostCheckupQuantity && len(offlineHosts) >= hostCheckupQuantity {
-				break
-			}
-
-			// Figure out if the host is online or offline.
-			host := allHosts[i]
-			online := len(host.ScanHistory) > 0 && host.ScanHistory[len(host.ScanHistory)-1].Success
-			if online && len(onlineHosts) < hostCheckupQuantity {
-				onlineHosts = append(onlineHosts, host)
-			} else if !online && len(offlineHosts) < hostCheckupQuantity {
-				offlineHosts = append(offlineHosts, host)
+		// Queue a scan for every host that is due. Hosts that have never been
+		// scanned have no NextScan and are always due. The most overdue hosts
+		// are scanned first.
+		now := time.Now()
+		var dueHosts []modules.HostDBEntry
+		for _, host := range hdb.hostTree.All() {
+			if !host.NextScan.After(now) {
+				dueHosts = append(dueHosts, host)
 			}
 		}
-
-		// Queue the scans for each host.
-		hdb.log.Println("Performing scan on", len(onlineHosts), "online hosts and", len(offlineHosts), "offline hosts.")
-		hdb.mu.Lock()
-		for _, host := range onlineHosts {
-			hdb.queueScan(host)
+		sort.Slice(dueHosts, func(i, j int) bool {
+			return dueHosts[i].NextScan.Before(dueHosts[j].NextScan)
+		})
+		if len(dueHosts) > hostCheckupQuantity {
+			dueHosts = dueHosts[:hostCheckupQuantity]
+		}
+		if len(dueHosts) > 0 {
+			hdb.log.Debugln("Performing scan on", len(dueHosts), "hosts.")
 		}
-		for _, host := range offlineHosts {
+		hdb.mu.Lock()
+		for _, host := range dueHosts {
 			hdb.queueScan(host)
 		}
 		hdb.mu.Unlock()
 
-		// Sleep for a random amount of time before doing another round of
-		// scanning. The minimums and maximums keep the scan time reasonable,
-		// while the randomness prevents the scanning from always happening at
-		// the same time of day or week.
-		sleepRange := uint64(maxScanSleep - minScanSleep)
-		sleepTime := minScanSleep + time.Duration(fastrand.Uint64n(sleepRange))
-
-		// Sleep until it's time for the next scan cycle.
+		// Sleep until it's time to check for due hosts again.
 		select {
 		case <-hdb.tg.StopChan():
 			return
-		case <-time.After(sleepTime):
+		case <-time.After(scanScheduleInterval):
 		}
 	}
 }
+
+// SetMaxScanningThreads sets the number of threads that scan hosts
+// concurrently. Lowering the number doesn't stop the threads that are running
+// already, it only prevents new threads from being started.
+func (hdb *HostDB) SetMaxScanningThreads(n int) error {
+	if err := hdb.tg.Add(); err != nil {
+		return err
+	}
+	defer hdb.tg.Done()
+	if n < 1 {
+		return errMaxScanningThreads
+	}
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	hdb.maxScanningThreads = n
+	return hdb.saveSync()
+}
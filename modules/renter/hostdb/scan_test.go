@@ -2,6 +2,7 @@ package hostdb
 
 import (
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -182,3 +183,211 @@ func TestUpdateEntry(t *testing.T) {
 		t.Error("host not reporting historic uptime?")
 	}
 }
+
+// TestScanInterval checks that the scan interval of a host depends on its
+// recent scan history.
+func TestScanInterval(t *testing.T) {
+	history := func(results ...bool) modules.HostDBScans {
+		var scans modules.HostDBScans
+		for i, success := range results {
+			scans = append(scans, modules.HostDBScan{
+				Timestamp: time.Unix(int64(i), 0),
+				Success:   success,
+			})
+		}
+		return scans
+	}
+	// intervalOf returns the scan interval of a host with the provided scan
+	// results, counting the failed scans at the end as consecutive failures.
+	intervalOf := func(results ...bool) time.Duration {
+		var failures uint64
+		for i := len(results) - 1; i >= 0 && !results[i]; i-- {
+			failures++
+		}
+		return scanInterval(history(results...), failures)
+	}
+
+	// Hosts without a scan are due immediately.
+	if interval := scanInterval(nil, 0); interval != 0 {
+		t.Fatal("hosts without scans should be scanned immediately, got", interval)
+	}
+	// Stable hosts are scanned rarely.
+	if interval := intervalOf(true, true, true); interval != stableScanInterval {
+		t.Fatal("expected stable scan interval but got", interval)
+	}
+	// A failure that dropped out of the window doesn't make a host flaky.
+	if interval := intervalOf(false, true, true, true, true, true); interval != stableScanInterval {
+		t.Fatal("expected stable scan interval but got", interval)
+	}
+	// Hosts that recently changed their status are scanned often.
+	if interval := intervalOf(true, false, true); interval != flakyScanInterval {
+		t.Fatal("expected flaky scan interval but got", interval)
+	}
+	if interval := intervalOf(true, true, false); interval != flakyScanInterval {
+		t.Fatal("expected flaky scan interval but got", interval)
+	}
+	// Hosts that keep failing back off exponentially.
+	if interval := intervalOf(false); interval != offlineScanInterval {
+		t.Fatal("expected offline scan interval but got", interval)
+	}
+	expected := offlineScanInterval * 2
+	if expected > maxOfflineScanInterval {
+		expected = maxOfflineScanInterval
+	}
+	if interval := intervalOf(false, false); interval != expected {
+		t.Fatalf("expected scan interval %v but got %v", expected, interval)
+	}
+	if interval := intervalOf(false, false, false, false, false, false, false, false, false, false); interval != maxOfflineScanInterval {
+		t.Fatal("backoff should be capped at the max offline scan interval, got", interval)
+	}
+	// The backoff follows the counted failures, which outlast the pruned
+	// scans of the history.
+	if interval := scanInterval(history(false), 2); interval != expected {
+		t.Fatalf("expected scan interval %v but got %v", expected, interval)
+	}
+	// A host that passed its last scan has no backoff.
+	if interval := scanInterval(history(false, false, true), 0); interval != flakyScanInterval {
+		t.Fatal("expected flaky scan interval but got", interval)
+	}
+}
+
+// TestJitterScanInterval checks that the jitter of the scan interval is
+// bounded by a fraction of the interval.
+func TestJitterScanInterval(t *testing.T) {
+	interval := time.Hour
+	maxInterval := interval + interval/scanJitterDivisor
+	for i := 0; i < 100; i++ {
+		if jittered := jitterScanInterval(interval); jittered < interval || jittered >= maxInterval {
+			t.Fatalf("jittered interval %v is outside of [%v, %v)", jittered, interval, maxInterval)
+		}
+	}
+	if jittered := jitterScanInterval(0); jittered != 0 {
+		t.Fatal("hosts without scans should stay due immediately, got", jittered)
+	}
+}
+
+// countScansDeps is a dependency that disables the scan loop and counts the
+// scans that run concurrently. Every scan is delayed to make them overlap.
+type countScansDeps struct {
+	disableScanLoopDeps
+	mu     sync.Mutex
+	active int
+	peak   int
+}
+
+// Disrupt delays every scan and records the number of concurrent scans.
+func (d *countScansDeps) Disrupt(s string) bool {
+	if s != "scanHost" {
+		return d.disableScanLoopDeps.Disrupt(s)
+	}
+	d.mu.Lock()
+	d.active++
+	if d.active > d.peak {
+		d.peak = d.active
+	}
+	d.mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	d.mu.Lock()
+	d.active--
+	d.mu.Unlock()
+	return false
+}
+
+// resetPeak returns the peak number of concurrent scans and resets it.
+func (d *countScansDeps) resetPeak() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	peak := d.peak
+	d.peak = 0
+	return peak
+}
+
+// TestParallelScans checks that the hostdb scans up to maxScanningThreads
+// hosts concurrently and that more threads finish the scans faster.
+func TestParallelScans(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	deps := &countScansDeps{}
+	hdbt, err := newHDBTesterDeps(t.Name(), deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hdbt.hdb.Close()
+
+	// scanHosts queues nHosts hosts and returns the time it took to scan all
+	// of them.
+	nHosts := 10
+	scanHosts := func() time.Duration {
+		start := time.Now()
+		hdbt.hdb.mu.Lock()
+		for i := 0; i < nHosts; i++ {
+			hdbt.hdb.queueScan(makeHostDBEntry())
+		}
+		hdbt.hdb.mu.Unlock()
+		if !hdbt.hdb.managedWaitForScans() {
+			t.Fatal("hostdb shut down")
+		}
+		return time.Since(start)
+	}
+
+	if err := hdbt.hdb.SetMaxScanningThreads(0); err != errMaxScanningThreads {
+		t.Fatal("expected errMaxScanningThreads, got", err)
+	}
+	if err := hdbt.hdb.SetMaxScanningThreads(1); err != nil {
+		t.Fatal(err)
+	}
+	serial := scanHosts()
+	if peak := deps.resetPeak(); peak > 2 {
+		// The pool may start one thread too many to avoid a deadlock.
+		t.Fatal("too many concurrent scans:", peak)
+	}
+
+	if err := hdbt.hdb.SetMaxScanningThreads(nHosts); err != nil {
+		t.Fatal(err)
+	}
+	parallel := scanHosts()
+	if peak := deps.resetPeak(); peak <= 2 || peak > nHosts+1 {
+		t.Fatal("unexpected number of concurrent scans:", peak)
+	}
+	if parallel >= serial/2 {
+		t.Fatalf("parallel scans took %v, serial scans %v", parallel, serial)
+	}
+}
+
+// TestQueueScanUnscannedFirst checks that hosts that have never been scanned
+// are queued ahead of the other hosts during the initial scan.
+func TestQueueScanUnscannedFirst(t *testing.T) {
+	hdb := bareHostDB()
+	hdb.scanMap = make(map[string]struct{})
+	// Prevent queueScan from starting the threads that empty the scan list.
+	hdb.scanWait = true
+
+	unscanned := make(map[string]struct{})
+	for i := 0; i < 20; i++ {
+		entry := makeHostDBEntry()
+		if i%2 == 0 {
+			entry.ScanHistory = nil
+			unscanned[entry.PublicKey.String()] = struct{}{}
+		}
+		hdb.queueScan(entry)
+	}
+	if hdb.unscannedQueued != len(unscanned) {
+		t.Fatal("wrong number of unscanned hosts:", hdb.unscannedQueued)
+	}
+	for i, entry := range hdb.scanList {
+		_, isUnscanned := unscanned[entry.PublicKey.String()]
+		if isUnscanned != (i < len(unscanned)) {
+			t.Fatal("unscanned hosts aren't at the front of the scan list")
+		}
+	}
+
+	// After the initial scan, hosts are queued in random order.
+	hdb.initialScanComplete = true
+	entry := makeHostDBEntry()
+	entry.ScanHistory = nil
+	hdb.queueScan(entry)
+	if hdb.unscannedQueued != len(unscanned) {
+		t.Fatal("unscanned host was prioritized after the initial scan")
+	}
+}
@@ -0,0 +1,57 @@
+package hostdb
+
+// scanhistory.go bounds the length of the scan histories of the hosts. The
+// scan history of a host would otherwise grow with every scan, and with it the
+// hostdb's persistence. Scans beyond the maximum length are compressed into the
+// historic uptime and downtime of the host when the next scan is added, the
+// same way old scans are compressed, so the uptime of the host still accounts
+// for them. The time of the first scan is kept separately.
+
+import (
+	"fmt"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+var errMaxScanHistoryLen = fmt.Errorf("scan history has to keep at least %v scans", minScans)
+
+// compressOldestScan folds the oldest scan of the entry's scan history into
+// the historic uptime or downtime of the entry.
+func compressOldestScan(entry *modules.HostDBEntry) {
+	timePassed := entry.ScanHistory[1].Timestamp.Sub(entry.ScanHistory[0].Timestamp)
+	if entry.ScanHistory[0].Success {
+		entry.HistoricUptime += timePassed
+	} else {
+		entry.HistoricDowntime += timePassed
+	}
+	entry.ScanHistory = entry.ScanHistory[1:]
+}
+
+// pruneScanHistory compresses the oldest scans of the entry's scan history
+// until at most maxLen scans remain. The time of the first scan is recorded
+// before it is compressed.
+func pruneScanHistory(entry *modules.HostDBEntry, maxLen int) {
+	if entry.FirstScan.IsZero() && len(entry.ScanHistory) > 0 {
+		entry.FirstScan = entry.ScanHistory[0].Timestamp
+	}
+	for len(entry.ScanHistory) > maxLen && len(entry.ScanHistory) > minScans {
+		compressOldestScan(entry)
+	}
+}
+
+// SetMaxScanHistoryLen sets the number of most recent scans that are kept in
+// the scan history of a host. Longer scan histories are pruned the next time
+// the host is scanned.
+func (hdb *HostDB) SetMaxScanHistoryLen(n int) error {
+	if err := hdb.tg.Add(); err != nil {
+		return err
+	}
+	defer hdb.tg.Done()
+	if n < minScans {
+		return errMaxScanHistoryLen
+	}
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	hdb.maxScanHistoryLen = n
+	return hdb.saveSync()
+}
@@ -0,0 +1,51 @@
+package hostdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// TestPruneScanHistory checks that pruning the scan history keeps the most
+// recent scans, the time of the first scan and the total uptime and downtime.
+func TestPruneScanHistory(t *testing.T) {
+	start := time.Now().Add(-10 * time.Hour)
+	var entry modules.HostDBEntry
+	for i := 0; i < 10; i++ {
+		entry.ScanHistory = append(entry.ScanHistory, modules.HostDBScan{
+			Timestamp: start.Add(time.Duration(i) * time.Hour),
+			Success:   i%3 != 0,
+		})
+	}
+	latest := entry.ScanHistory[len(entry.ScanHistory)-1]
+
+	pruneScanHistory(&entry, 4)
+	if len(entry.ScanHistory) != 4 {
+		t.Fatal("scan history wasn't pruned:", len(entry.ScanHistory))
+	}
+	if entry.ScanHistory[3] != latest {
+		t.Fatal("most recent scan wasn't kept")
+	}
+	if !entry.FirstScan.Equal(start) {
+		t.Fatal("time of the first scan wasn't kept:", entry.FirstScan, start)
+	}
+	// Scans 0 and 3 failed, the other four compressed scans succeeded.
+	if entry.HistoricUptime != 4*time.Hour || entry.HistoricDowntime != 2*time.Hour {
+		t.Fatal("compressed scans weren't added to the historic values:", entry.HistoricUptime, entry.HistoricDowntime)
+	}
+
+	// The scan history is never pruned below minScans.
+	pruneScanHistory(&entry, 1)
+	if len(entry.ScanHistory) != minScans {
+		t.Fatal("scan history was pruned below minScans:", len(entry.ScanHistory))
+	}
+	if !entry.FirstScan.Equal(start) {
+		t.Fatal("time of the first scan changed")
+	}
+
+	hdb := bareHostDB()
+	if err := hdb.SetMaxScanHistoryLen(minScans - 1); err != errMaxScanHistoryLen {
+		t.Fatal("expected errMaxScanHistoryLen, got", err)
+	}
+}
@@ -112,5 +112,45 @@ func (hdb *HostDB) ProcessConsensusChange(cc modules.ConsensusChange) {
 		}
 	}
 
+	// Penalize hosts that missed storage proofs.
+	hdb.updateMissedProofs(cc)
+
 	hdb.lastChange = cc.ID
 }
+
+// ProcessHeaderConsensusChange is called instead of ProcessConsensusChange
+// when the consensus set runs in spv mode. The full blocks aren't available,
+// so hosts are found through the announcements that are sent along with the
+// headers.
+func (hdb *HostDB) ProcessHeaderConsensusChange(hcc modules.HeaderConsensusChange) {
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+
+	// Update the hostdb's understanding of the block height.
+	for _, pbh := range hcc.RevertedBlockHeaders {
+		if hdb.blockHeight > 0 || pbh.BlockHeader.ID() != types.GenesisID {
+			hdb.blockHeight--
+		}
+	}
+	for _, pbh := range hcc.AppliedBlockHeaders {
+		if hdb.blockHeight > 0 || pbh.BlockHeader.ID() != types.GenesisID {
+			hdb.blockHeight++
+		}
+	}
+
+	// Add hosts announced in blocks that were applied. The announcements
+	// aren't committed to by the header, but a host that was announced by a
+	// peer with the wrong key fails the scan, since the host settings are
+	// signed by the host.
+	for _, pbh := range hcc.AppliedBlockHeaders {
+		for _, ha := range pbh.Announcements {
+			var host modules.HostDBEntry
+			host.NetAddress = ha.NetAddress
+			host.PublicKey = ha.PublicKey
+			hdb.log.Debugln("Found a host in a header announcement:", host.NetAddress, host.PublicKey)
+			hdb.insertBlockchainHost(host)
+		}
+	}
+
+	hdb.lastChange = hcc.ID
+}
@@ -52,3 +52,46 @@ func TestFindHostAnnouncements(t *testing.T) {
 		t.Error("host announcement found when there was an invalid encoding of a host announcement")
 	}
 }
+
+// TestProcessHeaderConsensusChange checks that the hostdb tracks the block
+// height and finds announced hosts when it is sent header changes.
+func TestProcessHeaderConsensusChange(t *testing.T) {
+	hdb := bareHostDB()
+	hdb.scanMap = make(map[string]struct{})
+	hdb.scanWait = true // keep the hostdb from scanning the hosts
+
+	_, pk := crypto.GenerateKeyPair()
+	ha := modules.HostAnnouncement{
+		Specifier:  modules.PrefixHostAnnouncement,
+		NetAddress: "foo.com:1234",
+		PublicKey:  types.Ed25519PublicKey(pk),
+	}
+	hdb.ProcessHeaderConsensusChange(modules.HeaderConsensusChange{
+		ID: modules.ConsensusChangeID{1},
+		AppliedBlockHeaders: []modules.ProcessedBlockHeader{
+			{BlockHeader: types.BlockHeader{Nonce: types.BlockNonce{1}}},
+			{BlockHeader: types.BlockHeader{Nonce: types.BlockNonce{2}}, Announcements: []modules.HostAnnouncement{ha}},
+		},
+	})
+	if hdb.blockHeight != 2 {
+		t.Fatal("expected a block height of 2, got", hdb.blockHeight)
+	}
+	if hdb.lastChange != (modules.ConsensusChangeID{1}) {
+		t.Fatal("last change wasn't updated")
+	}
+	host, exists := hdb.hostTree.Select(ha.PublicKey)
+	if !exists {
+		t.Fatal("announced host wasn't inserted")
+	} else if host.NetAddress != ha.NetAddress || host.FirstSeen != 2 {
+		t.Fatal("announced host has the wrong entry", host)
+	}
+
+	hdb.ProcessHeaderConsensusChange(modules.HeaderConsensusChange{
+		RevertedBlockHeaders: []modules.ProcessedBlockHeader{
+			{BlockHeader: types.BlockHeader{Nonce: types.BlockNonce{2}}},
+		},
+	})
+	if hdb.blockHeight != 1 {
+		t.Fatal("expected a block height of 1, got", hdb.blockHeight)
+	}
+}
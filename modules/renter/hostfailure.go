@@ -0,0 +1,85 @@
+package renter
+
+import (
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+var (
+	// errHostFailureSimulationDisabled is returned if a host failure is
+	// simulated on a production build of the renter.
+	errHostFailureSimulationDisabled = errors.New("simulating host failures is only supported by debug and dev builds")
+
+	// errHostFailureNotSimulated is returned when trying to restore a host
+	// which isn't currently marked as failed.
+	errHostFailureNotSimulated = errors.New("no failure is being simulated for this host")
+)
+
+// hostFailureSimulationEnabled indicates whether the renter allows host
+// failures to be simulated. Standard builds don't, so that a host can't be
+// dropped from the worker pool by accident.
+var hostFailureSimulationEnabled = build.DEBUG || build.Release != "standard"
+
+// managedHostFailureSimulated returns true if the host with the provided key
+// is marked as failed.
+func (r *Renter) managedHostFailureSimulated(hostKey types.SiaPublicKey) bool {
+	r.simulatedHostFailuresMu.Lock()
+	defer r.simulatedHostFailuresMu.Unlock()
+	_, failed := r.simulatedHostFailures[string(hostKey.Key)]
+	return failed
+}
+
+// managedIsOffline reports whether the renter should treat the host as
+// offline, either because the contractor considers it offline or because a
+// failure is being simulated for it.
+func (r *Renter) managedIsOffline(hostKey types.SiaPublicKey) bool {
+	return r.hostContractor.IsOffline(hostKey) || r.managedHostFailureSimulated(hostKey)
+}
+
+// SimulateHostFailure makes the renter treat the host as failed without
+// touching its contract. The host's worker is removed from the worker pool,
+// so it isn't used for uploads and downloads anymore, and the pieces it
+// stores are considered offline which causes them to be repaired.
+func (r *Renter) SimulateHostFailure(hostKey types.SiaPublicKey) error {
+	if !hostFailureSimulationEnabled {
+		return errHostFailureSimulationDisabled
+	}
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	r.simulatedHostFailuresMu.Lock()
+	r.simulatedHostFailures[string(hostKey.Key)] = struct{}{}
+	r.simulatedHostFailuresMu.Unlock()
+	r.log.Println("Simulating failure of host", hostKey.String())
+
+	r.managedUpdateWorkerPool()
+	return nil
+}
+
+// RestoreSimulatedHost reverts a previous call to SimulateHostFailure and
+// allows the renter to use the host again.
+func (r *Renter) RestoreSimulatedHost(hostKey types.SiaPublicKey) error {
+	if !hostFailureSimulationEnabled {
+		return errHostFailureSimulationDisabled
+	}
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	r.simulatedHostFailuresMu.Lock()
+	_, failed := r.simulatedHostFailures[string(hostKey.Key)]
+	delete(r.simulatedHostFailures, string(hostKey.Key))
+	r.simulatedHostFailuresMu.Unlock()
+	if !failed {
+		return errHostFailureNotSimulated
+	}
+	r.log.Println("Restoring host after simulated failure", hostKey.String())
+
+	r.managedUpdateWorkerPool()
+	return nil
+}
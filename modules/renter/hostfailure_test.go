@@ -0,0 +1,51 @@
+package renter
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestSimulateHostFailure checks that simulated host failures can be set and
+// restored and that the affected hosts are treated as offline.
+func TestSimulateHostFailure(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	// The host isn't part of the hostdb, so the contractor considers it
+	// offline regardless of the simulation. Only the simulation is checked
+	// against that.
+	hostKey := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       []byte{1, 2, 3},
+	}
+	contractorOffline := rt.renter.hostContractor.IsOffline(hostKey)
+	if rt.renter.managedHostFailureSimulated(hostKey) || rt.renter.managedIsOffline(hostKey) != contractorOffline {
+		t.Fatal("host failure shouldn't be simulated yet")
+	}
+	if err := rt.renter.RestoreSimulatedHost(hostKey); err != errHostFailureNotSimulated {
+		t.Fatal("expected errHostFailureNotSimulated but got", err)
+	}
+
+	// Simulate the failure.
+	if err := rt.renter.SimulateHostFailure(hostKey); err != nil {
+		t.Fatal(err)
+	}
+	if !rt.renter.managedHostFailureSimulated(hostKey) || !rt.renter.managedIsOffline(hostKey) {
+		t.Fatal("host should be treated as failed")
+	}
+
+	// Restore the host again.
+	if err := rt.renter.RestoreSimulatedHost(hostKey); err != nil {
+		t.Fatal(err)
+	}
+	if rt.renter.managedHostFailureSimulated(hostKey) || rt.renter.managedIsOffline(hostKey) != contractorOffline {
+		t.Fatal("host shouldn't be treated as failed after being restored")
+	}
+}
@@ -0,0 +1,353 @@
+package renter
+
+// localbackup.go periodically snapshots the metadata of the renter's files and
+// directories to a local directory, keeping a rotating set of the most recent
+// snapshots. The snapshots allow the metadata to be recovered locally if it is
+// corrupted, independent of any backups stored on hosts.
+//
+// The renter's lock is held while the directory metadata and the siafiles are
+// copied, so files can't be added, removed or renamed and directories can't
+// change during a snapshot. Every siafile is copied while it is locked as
+// well, so a copy can't contain a partially applied update even if the file is
+// uploaded to concurrently. A snapshot is written to a temporary directory that is only renamed once all
+// the files were copied, so an interrupted snapshot is never mistaken for a
+// complete one.
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+const (
+	// localSnapshotPrefix is the prefix of the directories of the local
+	// snapshots.
+	localSnapshotPrefix = "snapshot-"
+
+	// localSnapshotTimeFormat is the format of the time in the names of the
+	// local snapshots. It sorts the snapshots by the time they were taken,
+	// the nanoseconds keep the names of snapshots taken within the same
+	// second apart.
+	localSnapshotTimeFormat = "20060102T150405.000000000Z"
+
+	// localSnapshotTempSuffix is the suffix of a snapshot that is still
+	// being written.
+	localSnapshotTempSuffix = ".tmp"
+)
+
+var (
+	// errLocalBackupPath is returned if the local backup directory isn't an
+	// absolute path or is inside of the renter's directory.
+	errLocalBackupPath = errors.New("local backup directory must be an absolute path outside of the renter directory")
+
+	// errLocalBackupCount is returned if local backups are enabled without
+	// keeping any snapshots.
+	errLocalBackupCount = errors.New("at least one snapshot has to be kept")
+
+	// errUnknownSnapshot is returned if no local snapshot has the provided
+	// name.
+	errUnknownSnapshot = errors.New("no local snapshot with that name")
+)
+
+// copyFile copies the file at src to dst, creating the parent directories of
+// dst.
+func copyFile(src, dst string) (err error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, out.Close())
+	}()
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Sync()
+}
+
+// localSnapshots returns the complete snapshots in dir, oldest first.
+func localSnapshots(dir string) ([]modules.RenterLocalSnapshot, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var snapshots []modules.RenterLocalSnapshot
+	for _, fi := range fis {
+		if !fi.IsDir() || !strings.HasPrefix(fi.Name(), localSnapshotPrefix) {
+			continue
+		}
+		t, err := time.Parse(localSnapshotTimeFormat, strings.TrimPrefix(fi.Name(), localSnapshotPrefix))
+		if err != nil {
+			// Also skips the snapshots that are still being written.
+			continue
+		}
+		var files uint64
+		filepath.Walk(filepath.Join(dir, fi.Name()), func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && filepath.Ext(path) == ShareExtension {
+				files++
+			}
+			return nil
+		})
+		snapshots = append(snapshots, modules.RenterLocalSnapshot{
+			Name:  fi.Name(),
+			Time:  t,
+			Files: files,
+		})
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.Before(snapshots[j].Time)
+	})
+	return snapshots, nil
+}
+
+// managedLocalSnapshot writes a snapshot of the metadata of all the files and
+// directories to the local backup directory and removes the oldest snapshots
+// that exceed the configured count.
+func (r *Renter) managedLocalSnapshot(now time.Time) error {
+	id := r.mu.RLock()
+	config := r.persist.LocalBackup
+	r.mu.RUnlock(id)
+
+	name := localSnapshotPrefix + now.UTC().Format(localSnapshotTimeFormat)
+	dst := filepath.Join(config.Path, name)
+	tmp := dst + localSnapshotTempSuffix
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmp, 0700); err != nil {
+		return err
+	}
+	if err := r.managedCopyMetadata(tmp); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+
+	// Remove the oldest snapshots.
+	snapshots, err := localSnapshots(config.Path)
+	if err != nil {
+		return err
+	}
+	for len(snapshots) > int(config.Count) {
+		if err := os.RemoveAll(filepath.Join(config.Path, snapshots[0].Name)); err != nil {
+			return err
+		}
+		snapshots = snapshots[1:]
+	}
+	r.log.Println("Stored local snapshot of the file metadata:", dst)
+	return nil
+}
+
+// managedCopyMetadata copies the metadata of all the directories and files to
+// dir. The renter's lock is held for the whole copy, so all of the metadata is
+// copied at the same point in time.
+func (r *Renter) managedCopyMetadata(dir string) error {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+
+	// Copy the directory metadata.
+	err := filepath.Walk(r.persistDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || info.Name() != SiaDirMetadata {
+			return nil
+		}
+		rel, err := filepath.Rel(r.persistDir, path)
+		if err != nil {
+			return err
+		}
+		return copyFile(path, filepath.Join(dir, rel))
+	})
+	if err != nil {
+		return errors.AddContext(err, "unable to copy directory metadata")
+	}
+	// Copy the siafiles.
+	for _, f := range r.files {
+		if err := f.SaveCopy(dir); err != nil {
+			return errors.AddContext(err, "unable to copy "+f.SiaPath())
+		}
+	}
+	return nil
+}
+
+// threadedLocalBackupLoop takes the local snapshots at the configured
+// interval. The loop is woken up whenever the configuration changes.
+func (r *Renter) threadedLocalBackupLoop() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		id := r.mu.RLock()
+		config := r.persist.LocalBackup
+		r.mu.RUnlock(id)
+
+		var wait <-chan time.Time
+		if config.Interval > 0 && config.Path != "" {
+			var last time.Time
+			snapshots, err := localSnapshots(config.Path)
+			if err != nil {
+				r.log.Println("WARN: unable to list local snapshots:", err)
+			} else if len(snapshots) > 0 {
+				last = snapshots[len(snapshots)-1].Time
+			}
+			if next := last.Add(config.Interval); time.Now().Before(next) {
+				wait = time.After(time.Until(next))
+			} else if err := r.managedLocalSnapshot(time.Now()); err != nil {
+				r.log.Println("WARN: unable to store local snapshot:", err)
+				wait = time.After(config.Interval)
+			} else {
+				continue
+			}
+		}
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-r.localBackupConfigChanged:
+		case <-wait:
+		}
+	}
+}
+
+// LocalBackups returns the configuration of the local metadata backups and
+// the snapshots that were taken, oldest first.
+func (r *Renter) LocalBackups() (modules.RenterLocalBackups, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterLocalBackups{}, err
+	}
+	defer r.tg.Done()
+	id := r.mu.RLock()
+	config := r.persist.LocalBackup
+	r.mu.RUnlock(id)
+
+	backups := modules.RenterLocalBackups{Config: config}
+	if config.Path == "" {
+		return backups, nil
+	}
+	snapshots, err := localSnapshots(config.Path)
+	if err != nil {
+		return modules.RenterLocalBackups{}, err
+	}
+	backups.Snapshots = snapshots
+	return backups, nil
+}
+
+// SetLocalBackupConfig sets the directory, the number of snapshots kept and
+// the interval of the local metadata backups. A zero interval disables the
+// snapshots, the existing snapshots are kept.
+func (r *Renter) SetLocalBackupConfig(config modules.RenterLocalBackupConfig) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if config.Interval > 0 {
+		rel, err := filepath.Rel(r.persistDir, config.Path)
+		if !filepath.IsAbs(config.Path) || err != nil || !strings.HasPrefix(rel, "..") {
+			return errLocalBackupPath
+		}
+		if config.Count == 0 {
+			return errLocalBackupCount
+		}
+	}
+
+	id := r.mu.Lock()
+	r.persist.LocalBackup = config
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return errors.AddContext(err, "unable to save local backup config")
+	}
+	select {
+	case r.localBackupConfigChanged <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// RestoreLocalBackup replaces the metadata of the files in a local snapshot
+// with their versions in the snapshot. Files that aren't part of the snapshot
+// are left alone. The number of files that were restored is returned.
+func (r *Renter) RestoreLocalBackup(name string) (uint64, error) {
+	if err := r.tg.Add(); err != nil {
+		return 0, err
+	}
+	defer r.tg.Done()
+	id := r.mu.RLock()
+	config := r.persist.LocalBackup
+	r.mu.RUnlock(id)
+	if config.Path == "" || name == "" || filepath.Base(name) != name || !strings.HasPrefix(name, localSnapshotPrefix) {
+		return 0, errUnknownSnapshot
+	}
+	snapshotDir := filepath.Join(config.Path, name)
+	if fi, err := os.Stat(snapshotDir); err != nil || !fi.IsDir() {
+		return 0, errUnknownSnapshot
+	}
+
+	var restored uint64
+	err := filepath.Walk(snapshotDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(snapshotDir, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(r.persistDir, rel)
+		if info.Name() == SiaDirMetadata {
+			return copyFile(path, dst)
+		}
+		if filepath.Ext(path) != ShareExtension {
+			return nil
+		}
+
+		// Replace the file the renter is tracking at the same siapath.
+		siaPath := filepath.ToSlash(strings.TrimSuffix(rel, ShareExtension))
+		if err := r.DeleteFile(siaPath); err != nil && err != ErrUnknownPath {
+			return errors.AddContext(err, "unable to remove "+siaPath)
+		}
+		if err := copyFile(path, dst); err != nil {
+			return err
+		}
+		sf, err := siafile.LoadSiaFile(dst, r.wal)
+		if err != nil {
+			return errors.AddContext(err, "unable to load "+siaPath)
+		}
+		id := r.mu.Lock()
+		r.files[sf.SiaPath()] = sf
+		r.indexContentHash(sf)
+		r.indexChunkHashes(sf)
+		r.indexPack(sf)
+		r.mu.Unlock(id)
+		restored++
+		return nil
+	})
+	if err != nil {
+		return restored, err
+	}
+	r.log.Printf("Restored %v files from local snapshot %v", restored, name)
+	return restored, nil
+}
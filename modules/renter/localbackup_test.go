@@ -0,0 +1,106 @@
+package renter
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+// TestLocalBackup checks that local snapshots are rotated and that restoring
+// a snapshot brings back the files it contains.
+func TestLocalBackup(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	backupDir := filepath.Join(rt.dir, "backups")
+	if err := rt.renter.SetLocalBackupConfig(modules.RenterLocalBackupConfig{Path: "backups", Count: 2, Interval: time.Hour}); err != errLocalBackupPath {
+		t.Fatal("expected errLocalBackupPath, got", err)
+	}
+	if err := rt.renter.SetLocalBackupConfig(modules.RenterLocalBackupConfig{Path: backupDir, Interval: time.Hour}); err != errLocalBackupCount {
+		t.Fatal("expected errLocalBackupCount, got", err)
+	}
+	if err := rt.renter.SetLocalBackupConfig(modules.RenterLocalBackupConfig{Path: backupDir, Count: 2, Interval: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first snapshot is taken right away.
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		backups, err := rt.renter.LocalBackups()
+		if err != nil {
+			return err
+		}
+		if len(backups.Snapshots) != 1 {
+			return errors.New("no snapshot was taken")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := newTestingFile()
+	f.Rename("foo", filepath.Join(rt.renter.persistDir, "foo"+ShareExtension))
+	id := rt.renter.mu.Lock()
+	rt.renter.files[f.SiaPath()] = f
+	rt.renter.mu.Unlock(id)
+
+	// Only the two most recent snapshots are kept.
+	now := time.Now()
+	for i := 1; i <= 3; i++ {
+		if err := rt.renter.managedLocalSnapshot(now.Add(time.Duration(i) * time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, err := rt.renter.LocalBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups.Snapshots) != 2 || backups.Snapshots[1].Files != 1 {
+		t.Fatal("unexpected snapshots", backups.Snapshots)
+	}
+
+	// Snapshots taken within the same second don't replace each other.
+	second := now.Add(4 * time.Hour).Truncate(time.Second)
+	for _, at := range []time.Time{second, second.Add(time.Millisecond)} {
+		if err := rt.renter.managedLocalSnapshot(at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sameSecond, err := rt.renter.LocalBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sameSecond.Snapshots) != 2 || !sameSecond.Snapshots[0].Time.Equal(second) || sameSecond.Snapshots[0].Name == sameSecond.Snapshots[1].Name {
+		t.Fatal("snapshot taken within the same second replaced the previous one", sameSecond.Snapshots)
+	}
+	backups = sameSecond
+
+	// Restore the deleted file.
+	if err := rt.renter.DeleteFile("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.renter.RestoreLocalBackup("../foo"); err != errUnknownSnapshot {
+		t.Fatal("expected errUnknownSnapshot, got", err)
+	}
+	restored, err := rt.renter.RestoreLocalBackup(backups.Snapshots[1].Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != 1 {
+		t.Fatal("expected 1 restored file, got", restored)
+	}
+	if _, err := rt.renter.File("foo"); err != nil {
+		t.Fatal("restored file isn't tracked by the renter", err)
+	}
+}
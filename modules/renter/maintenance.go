@@ -0,0 +1,34 @@
+package renter
+
+// MaintenancePaused returns whether renter maintenance is paused.
+func (r *Renter) MaintenancePaused() bool {
+	return r.hostContractor.MaintenancePaused()
+}
+
+// PauseMaintenance stops the contractor from forming, renewing and replacing
+// contracts and stops the repair loop from picking up new chunks. Chunks that
+// were already handed to the workers are finished and downloads keep working.
+// The paused state is persisted by the contractor, so it survives a restart.
+func (r *Renter) PauseMaintenance() error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.hostContractor.SetMaintenancePaused(true)
+}
+
+// ResumeMaintenance resumes contract maintenance and wakes up the repair loop.
+func (r *Renter) ResumeMaintenance() error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := r.hostContractor.SetMaintenancePaused(false); err != nil {
+		return err
+	}
+	select {
+	case r.uploadHeap.newUploads <- struct{}{}:
+	default:
+	}
+	return nil
+}
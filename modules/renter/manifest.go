@@ -0,0 +1,240 @@
+package renter
+
+// A manifest lists the Merkle roots of the pieces of a file and the hosts that
+// stored them when it was exported. It is signed with a key derived from the
+// wallet seed.
+//
+// Verifying a file against a manifest is a spot check rather than a full
+// download. The samples are spread over the chunks of the file: every sampled
+// chunk contributes a random piece, and chunks are only sampled again once
+// every chunk was sampled. Instead of the whole sector of a piece, a host
+// listed in the manifest only sends a random segment of it together with its
+// Merkle proof, which is checked against the root in the manifest. A host that
+// lost the sector can't answer. If a fraction f of the pieces is missing, n
+// samples miss all of them with a probability of (1-f)^n, which is what the
+// reported confidence is based on.
+
+import (
+	"math"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/proto"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+var (
+	// manifestSpecifier is used to derive the key that manifests are signed
+	// with from the wallet seed.
+	manifestSpecifier = types.Specifier{'M', 'a', 'n', 'i', 'f', 'e', 's', 't'}
+
+	errManifestForeignKey   = errors.New("manifest wasn't signed by this renter")
+	errManifestNoPieces     = errors.New("manifest doesn't list any uploaded pieces")
+	errManifestBadSignature = errors.New("manifest signature is invalid")
+	errManifestNoHost       = errors.New("none of the hosts listed in the manifest provided the piece")
+	errManifestTooMany      = errors.New("too many samples requested")
+)
+
+// manifestKeys derives the key pair that manifests are signed with from the
+// wallet seed.
+func manifestKeys(seed modules.Seed) (crypto.SecretKey, crypto.PublicKey) {
+	return crypto.GenerateKeyPairDeterministic(crypto.HashAll(manifestSpecifier, seed))
+}
+
+// manifestSigHash returns the hash of a manifest that is signed. It covers
+// every field but the signature.
+func manifestSigHash(m modules.RenterFileManifest) crypto.Hash {
+	m.Signature = crypto.Signature{}
+	return crypto.HashObject(m)
+}
+
+// manifestConfidence returns the probability that the samples detect a file of
+// which modules.ManifestCorruptionThreshold of the pieces are missing or
+// corrupt.
+func manifestConfidence(samples int) float64 {
+	return 1 - math.Pow(1-modules.ManifestCorruptionThreshold, float64(samples))
+}
+
+// manifestPieceIndex identifies a piece of a manifest.
+type manifestPieceIndex struct{ chunk, piece int }
+
+// sampleManifestPieces picks up to n uploaded pieces of the manifest without
+// replacement. The chunks are visited in random order and contribute one
+// random piece each per round, so the samples are spread over all chunks
+// before any chunk is sampled twice.
+func sampleManifestPieces(m modules.RenterFileManifest, n uint64) []manifestPieceIndex {
+	var chunks [][]manifestPieceIndex
+	for i, chunk := range m.Chunks {
+		var pieces []manifestPieceIndex
+		for j, piece := range chunk.Pieces {
+			if piece.MerkleRoot != (crypto.Hash{}) {
+				pieces = append(pieces, manifestPieceIndex{i, j})
+			}
+		}
+		if len(pieces) > 0 {
+			chunks = append(chunks, pieces)
+		}
+	}
+	chunkOrder := fastrand.Perm(len(chunks))
+	pieceOrders := make([][]int, len(chunks))
+	for i := range chunks {
+		pieceOrders[i] = fastrand.Perm(len(chunks[i]))
+	}
+
+	var picked []manifestPieceIndex
+	for round := 0; uint64(len(picked)) < n; round++ {
+		added := false
+		for _, ci := range chunkOrder {
+			if uint64(len(picked)) == n {
+				break
+			}
+			if round < len(chunks[ci]) {
+				picked = append(picked, chunks[ci][pieceOrders[ci][round]])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	return picked
+}
+
+// managedManifestKeys returns the key pair that manifests are signed with.
+func (r *Renter) managedManifestKeys() (crypto.SecretKey, types.SiaPublicKey, error) {
+	seed, _, err := r.wallet.PrimarySeed()
+	if err != nil {
+		return crypto.SecretKey{}, types.SiaPublicKey{}, errors.AddContext(err, "unable to get the wallet seed")
+	}
+	sk, pk := manifestKeys(seed)
+	return sk, types.Ed25519PublicKey(pk), nil
+}
+
+// FileManifest exports a signed manifest of the pieces of a file.
+func (r *Renter) FileManifest(siaPath string) (modules.RenterFileManifest, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterFileManifest{}, err
+	}
+	defer r.tg.Done()
+	id := r.mu.RLock()
+	file, exists := r.files[siaPath]
+	r.mu.RUnlock(id)
+	if !exists {
+		return modules.RenterFileManifest{}, ErrUnknownPath
+	}
+	sk, pk, err := r.managedManifestKeys()
+	if err != nil {
+		return modules.RenterFileManifest{}, err
+	}
+
+	ec := file.ErasureCode()
+	m := modules.RenterFileManifest{
+		SiaPath:      file.SiaPath(),
+		FileSize:     file.Size(),
+		ContentHash:  file.ContentHash(),
+		DataPieces:   uint64(ec.MinPieces()),
+		ParityPieces: uint64(ec.NumPieces() - ec.MinPieces()),
+		Height:       r.cs.Height(),
+		Chunks:       make([]modules.RenterManifestChunk, file.NumChunks()),
+		PublicKey:    pk,
+	}
+	for chunkIndex := range m.Chunks {
+		pieces, err := file.Pieces(uint64(chunkIndex))
+		if err != nil {
+			return modules.RenterFileManifest{}, err
+		}
+		// A piece may have been uploaded more than once. The manifest lists
+		// the root of the first upload and every host storing that root.
+		m.Chunks[chunkIndex].Pieces = make([]modules.RenterManifestPiece, len(pieces))
+		for pieceIndex, pieceSet := range pieces {
+			if len(pieceSet) == 0 {
+				continue
+			}
+			mp := &m.Chunks[chunkIndex].Pieces[pieceIndex]
+			mp.MerkleRoot = pieceSet[0].MerkleRoot
+			for _, piece := range pieceSet {
+				if piece.MerkleRoot == mp.MerkleRoot {
+					mp.Hosts = append(mp.Hosts, piece.HostPubKey)
+				}
+			}
+		}
+	}
+	m.Signature = crypto.SignHash(manifestSigHash(m), sk)
+	return m, nil
+}
+
+// managedVerifyManifestPiece downloads a random segment of a piece with its
+// Merkle proof from the hosts listed in the manifest until one of them
+// provides it.
+func (r *Renter) managedVerifyManifestPiece(piece modules.RenterManifestPiece) (types.SiaPublicKey, error) {
+	err := errManifestNoHost
+	for _, host := range piece.Hosts {
+		dErr := r.hostContractor.VerifySectorSegments(host, []crypto.Hash{piece.MerkleRoot}, r.tg.StopChan())
+		if dErr == nil {
+			return host, nil
+		}
+		// A host that sent data which doesn't match the root fails the
+		// sample right away.
+		if errors.Contains(dErr, proto.ErrBadSectorData) {
+			return host, dErr
+		}
+		err = errors.Compose(err, dErr)
+	}
+	return types.SiaPublicKey{}, err
+}
+
+// VerifyManifest checks the signature of a manifest and downloads randomly
+// sampled pieces of the file from the hosts listed in the manifest.
+func (r *Renter) VerifyManifest(m modules.RenterFileManifest, samples uint64) (modules.RenterManifestVerification, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterManifestVerification{}, err
+	}
+	defer r.tg.Done()
+	if samples == 0 {
+		samples = defaultManifestSamples
+	} else if samples > maxManifestSamples {
+		return modules.RenterManifestVerification{}, errManifestTooMany
+	}
+
+	// Verify the signature first.
+	_, pk, err := r.managedManifestKeys()
+	if err != nil {
+		return modules.RenterManifestVerification{}, err
+	}
+	if m.PublicKey.String() != pk.String() {
+		return modules.RenterManifestVerification{}, errManifestForeignKey
+	}
+	var cpk crypto.PublicKey
+	copy(cpk[:], pk.Key)
+	if err := crypto.VerifyHash(manifestSigHash(m), cpk, m.Signature); err != nil {
+		return modules.RenterManifestVerification{}, errManifestBadSignature
+	}
+
+	result := modules.RenterManifestVerification{
+		SiaPath: m.SiaPath,
+	}
+	picked := sampleManifestPieces(m, samples)
+	if len(picked) == 0 {
+		return modules.RenterManifestVerification{}, errManifestNoPieces
+	}
+	result.Confidence = manifestConfidence(len(picked))
+	for _, c := range picked {
+		sample := modules.RenterManifestSample{
+			ChunkIndex: uint64(c.chunk),
+			PieceIndex: uint64(c.piece),
+		}
+		sample.Host, err = r.managedVerifyManifestPiece(m.Chunks[c.chunk].Pieces[c.piece])
+		if err != nil {
+			sample.Error = err.Error()
+			result.Failed++
+		} else {
+			sample.Passed = true
+			result.Passed++
+		}
+		result.Samples = append(result.Samples, sample)
+	}
+	return result, nil
+}
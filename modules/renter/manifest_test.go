@@ -0,0 +1,113 @@
+package renter
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+// TestManifestSignature checks that the signature of a manifest survives the
+// JSON encoding of the API and covers the pieces listed in the manifest.
+func TestManifestSignature(t *testing.T) {
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+	sk, pk := manifestKeys(seed)
+	m := modules.RenterFileManifest{
+		SiaPath:      "foo",
+		FileSize:     1000,
+		DataPieces:   1,
+		ParityPieces: 1,
+		Chunks: []modules.RenterManifestChunk{{
+			Pieces: []modules.RenterManifestPiece{
+				{MerkleRoot: crypto.Hash{1}, Hosts: []types.SiaPublicKey{{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}}},
+				{},
+			},
+		}},
+		PublicKey: types.Ed25519PublicKey(pk),
+	}
+	m.Signature = crypto.SignHash(manifestSigHash(m), sk)
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded modules.RenterFileManifest
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if err := crypto.VerifyHash(manifestSigHash(decoded), pk, decoded.Signature); err != nil {
+		t.Fatal("signature should be valid after decoding", err)
+	}
+	decoded.Chunks[0].Pieces[0].MerkleRoot = crypto.Hash{2}
+	if crypto.VerifyHash(manifestSigHash(decoded), pk, decoded.Signature) == nil {
+		t.Fatal("signature should be invalid after changing a piece")
+	}
+
+	// The same seed always derives the same key.
+	if _, pk2 := manifestKeys(seed); pk2 != pk {
+		t.Fatal("manifest keys should be deterministic")
+	}
+}
+
+// TestManifestConfidence checks the confidence implied by the number of
+// samples.
+func TestManifestConfidence(t *testing.T) {
+	if manifestConfidence(0) != 0 {
+		t.Fatal("no samples shouldn't imply any confidence")
+	}
+	if c := manifestConfidence(1); math.Abs(c-modules.ManifestCorruptionThreshold) > 1e-9 {
+		t.Fatal("wrong confidence for a single sample", c)
+	}
+	if manifestConfidence(100) >= manifestConfidence(300) {
+		t.Fatal("more samples should imply more confidence")
+	}
+}
+
+// TestSampleManifestPieces checks that the samples are spread over the chunks
+// of a manifest and never pick a piece twice.
+func TestSampleManifestPieces(t *testing.T) {
+	root := crypto.Hash{1}
+	m := modules.RenterFileManifest{
+		Chunks: []modules.RenterManifestChunk{
+			{Pieces: []modules.RenterManifestPiece{{MerkleRoot: root}, {MerkleRoot: root}, {MerkleRoot: root}}},
+			{Pieces: []modules.RenterManifestPiece{{}, {MerkleRoot: root}}},
+			{Pieces: []modules.RenterManifestPiece{{}, {}}},
+			{Pieces: []modules.RenterManifestPiece{{MerkleRoot: root}, {MerkleRoot: root}}},
+		},
+	}
+
+	// Every chunk with pieces is sampled once before any is sampled twice.
+	picked := sampleManifestPieces(m, 3)
+	chunks := make(map[int]bool)
+	for _, p := range picked {
+		chunks[p.chunk] = true
+	}
+	if len(picked) != 3 || len(chunks) != 3 || chunks[2] {
+		t.Fatal("samples should cover every chunk with pieces once", picked)
+	}
+
+	// Asking for more samples than there are pieces returns every piece
+	// exactly once, and never a piece that wasn't uploaded.
+	picked = sampleManifestPieces(m, 100)
+	if len(picked) != 6 {
+		t.Fatal("expected every uploaded piece to be sampled", picked)
+	}
+	seen := make(map[manifestPieceIndex]bool)
+	for _, p := range picked {
+		if seen[p] {
+			t.Fatal("piece was sampled twice", p)
+		}
+		seen[p] = true
+		if m.Chunks[p.chunk].Pieces[p.piece].MerkleRoot == (crypto.Hash{}) {
+			t.Fatal("sampled a piece that wasn't uploaded", p)
+		}
+	}
+	if len(sampleManifestPieces(modules.RenterFileManifest{}, 10)) != 0 {
+		t.Fatal("a manifest without pieces shouldn't have samples")
+	}
+}
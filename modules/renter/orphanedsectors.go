@@ -0,0 +1,221 @@
+package renter
+
+import (
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+// sectorReferences maps the Merkle root of every sector referenced by a file
+// to the string form of the public keys of the hosts that the file expects
+// to store it.
+type sectorReferences map[crypto.Hash][]string
+
+// addFile adds the pieces of all chunks of the file to the references.
+func (refs sectorReferences) addFile(f *siafile.SiaFile) error {
+	for chunkIndex := uint64(0); chunkIndex < f.NumChunks(); chunkIndex++ {
+		pieceSets, err := f.Pieces(chunkIndex)
+		if err != nil {
+			return err
+		}
+		for _, pieceSet := range pieceSets {
+			for _, piece := range pieceSet {
+				refs[piece.MerkleRoot] = append(refs[piece.MerkleRoot], piece.HostPubKey.String())
+			}
+		}
+	}
+	return nil
+}
+
+// uploadingSector identifies a sector that is being uploaded to a host.
+type uploadingSector struct {
+	root    crypto.Hash
+	hostKey string
+}
+
+// managedTrackUploadingSector records that the sector with the provided root
+// is being uploaded to the host. The returned function stops tracking the
+// upload, it is called once the sector was added to its file or the upload
+// failed.
+func (r *Renter) managedTrackUploadingSector(root crypto.Hash, hostKey types.SiaPublicKey) func() {
+	us := uploadingSector{root: root, hostKey: hostKey.String()}
+	r.uploadingSectorsMu.Lock()
+	r.uploadingSectors[us]++
+	r.uploadingSectorsMu.Unlock()
+	return func() {
+		r.uploadingSectorsMu.Lock()
+		defer r.uploadingSectorsMu.Unlock()
+		if r.uploadingSectors[us] > 1 {
+			r.uploadingSectors[us]--
+		} else {
+			delete(r.uploadingSectors, us)
+		}
+	}
+}
+
+// addUploadingSectors adds the sectors that are being uploaded to the
+// references.
+func (refs sectorReferences) addUploadingSectors(sectors map[uploadingSector]int) {
+	for us := range sectors {
+		refs[us.root] = append(refs[us.root], us.hostKey)
+	}
+}
+
+// orphanedSectors returns the roots that no file references. Sectors that are
+// referenced by a file but only with other hosts than hostKey are counted as
+// reassociable. Sectors that are stored more than once are reported once.
+func (refs sectorReferences) orphanedSectors(roots []crypto.Hash, hostKey types.SiaPublicKey) (orphaned []crypto.Hash, reassociable uint64) {
+	seen := make(map[crypto.Hash]struct{}, len(roots))
+	for _, root := range roots {
+		if _, ok := seen[root]; ok {
+			continue
+		}
+		seen[root] = struct{}{}
+		hosts, ok := refs[root]
+		if !ok {
+			orphaned = append(orphaned, root)
+			continue
+		}
+		onHost := false
+		for _, host := range hosts {
+			if host == hostKey.String() {
+				onHost = true
+				break
+			}
+		}
+		if !onHost {
+			reassociable++
+		}
+	}
+	return orphaned, reassociable
+}
+
+// wastedSpend estimates the share of the storage and upload spending of a
+// contract that went to orphaned sectors, assuming that every sector of the
+// contract cost the same.
+func wastedSpend(c modules.RenterContract, sectors, orphaned uint64) types.Currency {
+	if sectors == 0 {
+		return types.ZeroCurrency
+	}
+	return c.StorageSpending.Add(c.UploadSpending).Mul64(orphaned).Div64(sectors)
+}
+
+// managedSectorReferences scans the piece mappings of all files. The pinned
+// sector of the recovery hint and the sectors that are still being uploaded
+// count as referenced as well. The uploading sectors are collected before the
+// files, so a sector whose upload finishes in between is found in its file.
+func (r *Renter) managedSectorReferences() (sectorReferences, error) {
+	refs := make(sectorReferences)
+	r.uploadingSectorsMu.Lock()
+	refs.addUploadingSectors(r.uploadingSectors)
+	r.uploadingSectorsMu.Unlock()
+
+	lockID := r.mu.RLock()
+	files := make([]*siafile.SiaFile, 0, len(r.files))
+	for _, file := range r.files {
+		files = append(files, file)
+	}
+	hintSector := r.persist.RecoveryHintSector
+	hintHosts := append([]types.SiaPublicKey(nil), r.persist.RecoveryHintHosts...)
+	r.mu.RUnlock(lockID)
+
+	refs.addRecoveryHint(hintSector, hintHosts)
+	for _, f := range files {
+		if err := refs.addFile(f); err != nil {
+			return nil, errors.AddContext(err, "unable to scan the pieces of "+f.SiaPath())
+		}
+	}
+	return refs, nil
+}
+
+// OrphanedSectors asks the hosts of all active contracts for the Merkle roots
+// of the sectors they store and reports the sectors that no file references.
+// These sectors are left behind by failed uploads or crashes, the renter paid
+// for them without being able to use them. Contracts whose host can't be
+// reached are reported with an error. The references are collected after the
+// roots were fetched, so sectors uploaded in the meantime are referenced by
+// their files or still tracked as uploading.
+func (r *Renter) OrphanedSectors() (modules.RenterOrphanedSectors, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterOrphanedSectors{}, err
+	}
+	defer r.tg.Done()
+
+	contracts := r.hostContractor.Contracts()
+	roots := make([][]crypto.Hash, len(contracts))
+	errs := make([]error, len(contracts))
+	for i, c := range contracts {
+		roots[i], errs[i] = r.hostContractor.SectorRoots(c.ID, r.tg.StopChan())
+	}
+	refs, err := r.managedSectorReferences()
+	if err != nil {
+		return modules.RenterOrphanedSectors{}, err
+	}
+
+	report := modules.RenterOrphanedSectors{
+		Contracts: []modules.RenterContractOrphanedSectors{},
+	}
+	for i, c := range contracts {
+		contract := modules.RenterContractOrphanedSectors{
+			ContractID:    c.ID,
+			HostPublicKey: c.HostPublicKey,
+		}
+		if errs[i] != nil {
+			contract.Error = errs[i].Error()
+			report.Contracts = append(report.Contracts, contract)
+			continue
+		}
+		contract.Sectors = uint64(len(roots[i]))
+		contract.OrphanedSectors, contract.ReassociableSectors = refs.orphanedSectors(roots[i], c.HostPublicKey)
+		orphaned := uint64(len(contract.OrphanedSectors))
+		contract.WastedSpend = wastedSpend(c, contract.Sectors, orphaned)
+
+		report.OrphanedSectors += orphaned
+		report.ReassociableSectors += contract.ReassociableSectors
+		report.ReclaimableBytes += orphaned * modules.SectorSize
+		report.WastedSpend = report.WastedSpend.Add(contract.WastedSpend)
+		report.Contracts = append(report.Contracts, contract)
+	}
+	return report, nil
+}
+
+// ReclaimOrphanedSectors re-associates every active contract with the files
+// that reference its sectors, see ReassociateContract, and reports the
+// sectors that are still orphaned afterwards. Sectors of chunks that are still
+// being uploaded aren't reported. No file will ever reference the reported
+// sectors again, so they are droppable. Sectors can't be removed from a
+// contract, they are dropped once the contract expires.
+func (r *Renter) ReclaimOrphanedSectors() (modules.RenterOrphanedSectorsReclaim, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterOrphanedSectorsReclaim{}, err
+	}
+	defer r.tg.Done()
+
+	result := modules.RenterOrphanedSectorsReclaim{
+		Files: []string{},
+	}
+	files := make(map[string]struct{})
+	for _, c := range r.hostContractor.Contracts() {
+		reassociation, err := r.ReassociateContract(c.ID)
+		result.ChunksReassociated += reassociation.ChunksReassociated
+		result.PiecesReassociated += reassociation.PiecesReassociated
+		for _, siaPath := range reassociation.Files {
+			if _, ok := files[siaPath]; !ok {
+				files[siaPath] = struct{}{}
+				result.Files = append(result.Files, siaPath)
+			}
+		}
+		if err != nil {
+			r.log.Printf("WARN: unable to re-associate contract %v: %v", c.ID, err)
+		}
+	}
+	droppable, err := r.OrphanedSectors()
+	if err != nil {
+		return result, err
+	}
+	result.Droppable = droppable
+	return result, nil
+}
@@ -0,0 +1,109 @@
+package renter
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestOrphanedSectors checks that sectors no file references are reported as
+// orphaned and that sectors only referenced with other hosts are counted as
+// reassociable.
+func TestOrphanedSectors(t *testing.T) {
+	rsc, _ := siafile.NewRSCode(1, 1)
+	f := newFileTesting(t.Name(), newTestingWal(), rsc, 1000, 0777, "")
+	host := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	otherHost := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{2}}
+
+	first, second, orphan := crypto.Hash{1}, crypto.Hash{2}, crypto.Hash{3}
+	if err := f.AddPiece(host, 0, 0, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.AddPiece(otherHost, 0, 1, second); err != nil {
+		t.Fatal(err)
+	}
+	refs := make(sectorReferences)
+	if err := refs.addFile(f); err != nil {
+		t.Fatal(err)
+	}
+
+	// The orphan is stored twice but should only be reported once.
+	orphaned, reassociable := refs.orphanedSectors([]crypto.Hash{first, second, orphan, orphan}, host)
+	if len(orphaned) != 1 || orphaned[0] != orphan {
+		t.Fatal("expected the orphan to be reported once, got", orphaned)
+	}
+	if reassociable != 1 {
+		t.Fatal("expected the second sector to be reassociable, got", reassociable)
+	}
+	if orphaned, reassociable = refs.orphanedSectors([]crypto.Hash{first, second}, otherHost); len(orphaned) != 0 || reassociable != 1 {
+		t.Fatalf("expected no orphans and 1 reassociable sector, got %v and %v", orphaned, reassociable)
+	}
+}
+
+// TestWastedSpend checks that the wasted spend is the share of the storage
+// and upload spending of the orphaned sectors.
+func TestWastedSpend(t *testing.T) {
+	c := modules.RenterContract{
+		StorageSpending:  types.NewCurrency64(300),
+		UploadSpending:   types.NewCurrency64(100),
+		DownloadSpending: types.NewCurrency64(1000),
+	}
+	if ws := wastedSpend(c, 4, 1); !ws.Equals(types.NewCurrency64(100)) {
+		t.Fatal("expected a wasted spend of 100, got", ws)
+	}
+	if ws := wastedSpend(c, 0, 0); !ws.IsZero() {
+		t.Fatal("contract without sectors shouldn't waste anything, got", ws)
+	}
+}
+
+// TestRecoveryHintReferences checks that the pinned sector of the recovery
+// hint isn't reported as orphaned by the hosts storing it.
+func TestRecoveryHintReferences(t *testing.T) {
+	host := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	otherHost := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{2}}
+	hint := crypto.Hash{1}
+
+	refs := make(sectorReferences)
+	refs.addRecoveryHint(crypto.Hash{}, []types.SiaPublicKey{host})
+	if len(refs) != 0 {
+		t.Fatal("a missing hint shouldn't be referenced")
+	}
+	refs.addRecoveryHint(hint, []types.SiaPublicKey{host})
+	if orphaned, _ := refs.orphanedSectors([]crypto.Hash{hint}, host); len(orphaned) != 0 {
+		t.Fatal("pinned hint was reported as orphaned")
+	}
+	if orphaned, reassociable := refs.orphanedSectors([]crypto.Hash{hint}, otherHost); len(orphaned) != 0 || reassociable != 1 {
+		t.Fatalf("expected the hint to be reassociable with another host, got %v and %v", orphaned, reassociable)
+	}
+}
+
+// TestUploadingSectorReferences checks that sectors are referenced while they
+// are being uploaded and that the references are dropped once every upload
+// of the sector is done.
+func TestUploadingSectorReferences(t *testing.T) {
+	host := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	root := crypto.Hash{1}
+	r := &Renter{uploadingSectors: make(map[uploadingSector]int)}
+	references := func() sectorReferences {
+		refs := make(sectorReferences)
+		refs.addUploadingSectors(r.uploadingSectors)
+		return refs
+	}
+
+	untrack := r.managedTrackUploadingSector(root, host)
+	untrackRetry := r.managedTrackUploadingSector(root, host)
+	if orphaned, reassociable := references().orphanedSectors([]crypto.Hash{root}, host); len(orphaned) != 0 || reassociable != 0 {
+		t.Fatalf("uploading sector should be referenced by its host, got %v and %v", orphaned, reassociable)
+	}
+	untrack()
+	if orphaned, _ := references().orphanedSectors([]crypto.Hash{root}, host); len(orphaned) != 0 {
+		t.Fatal("sector should be referenced until all of its uploads are done")
+	}
+	untrackRetry()
+	if orphaned, _ := references().orphanedSectors([]crypto.Hash{root}, host); len(orphaned) != 1 {
+		t.Fatal("sector should be orphaned once its uploads are done")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/crypto"
 	"github.com/HyperspaceApp/Hyperspace/encoding"
 	"github.com/HyperspaceApp/Hyperspace/modules"
 	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
@@ -25,10 +26,15 @@ const (
 	logFile = modules.RenterDir + ".log"
 	// PersistFilename is the filename to be used when persisting renter information to a JSON file
 	PersistFilename = "renter.json"
+	// recoveryHintFile is the file a restored recovery hint is written to.
+	recoveryHintFile = "recoveryhint.dat"
 	// ShareExtension is the extension to be used
 	ShareExtension = ".sia"
 	// SiaDirMetadata is the name of the metadata file for the sia directory
 	SiaDirMetadata = ".siadir"
+	// uploadStreamDir is the directory the data of streamed uploads is
+	// staged in.
+	uploadStreamDir = "uploadstreams"
 	// walFile is the filename of the renter's writeaheadlog's file.
 	walFile = modules.RenterDir + ".wal"
 )
@@ -48,6 +54,11 @@ var (
 		Version: persistVersion,
 	}
 
+	dirMetadataHeader = persist.Metadata{
+		Header:  "Sia Directory Metadata",
+		Version: persistVersion,
+	}
+
 	shareHeader  = [15]byte{'S', 'i', 'a', ' ', 'S', 'h', 'a', 'r', 'e', 'd', ' ', 'F', 'i', 'l', 'e'}
 	shareVersion = "0.4"
 
@@ -59,9 +70,41 @@ var (
 type (
 	// persist contains all of the persistent renter data.
 	persistence struct {
-		MaxDownloadSpeed int64
-		MaxUploadSpeed   int64
-		StreamCacheSize  uint64
+		MaxDownloadSpeed       int64
+		MaxUploadSpeed         int64
+		StreamCacheSize        uint64
+		CostOptimizedDownloads bool
+		MaxConcurrentRepairs   uint64
+
+		// RepairBudgets are the monthly budgets for uploading and
+		// repairing files, keyed by the siapath of the file or directory.
+		RepairBudgets map[string]modules.RepairBudget
+
+		// ScheduledUploads are the uploads that start at a later time,
+		// together with the outcome of the ones that were started.
+		ScheduledUploads []modules.ScheduledUpload
+
+		// LocalBackup configures the snapshots of the file metadata that
+		// are stored in a local directory.
+		LocalBackup modules.RenterLocalBackupConfig
+
+		// RecoveryHintSector is the sector of the most recent recovery hint
+		// that was stored or restored, RecoveryHintHosts are the hosts
+		// storing it. The sector is pinned since no file references it.
+		RecoveryHintSector crypto.Hash
+		RecoveryHintHosts  []types.SiaPublicKey
+	}
+
+	// siaDirMetadata is the metadata of a directory of the renter.
+	siaDirMetadata struct {
+		LastUpdate    int64
+		MinRedundancy float64
+
+		// DataPieces and ParityPieces are the erasure code of new uploads
+		// into the directory and its subdirectories. Both are zero if the
+		// directory inherits the erasure code of its parent.
+		DataPieces   int
+		ParityPieces int
 	}
 )
 
@@ -217,17 +260,23 @@ func createDirMetadata(path string) error {
 	}
 
 	// TODO: update to get actual min redundancy
-	data := struct {
-		LastUpdate    int64
-		MinRedundancy float64
-	}{time.Now().UnixNano(), float64(0)}
-
-	metadataHeader := persist.Metadata{
-		Header:  "Sia Directory Metadata",
-		Version: persistVersion,
+	data := siaDirMetadata{
+		LastUpdate:    time.Now().UnixNano(),
+		MinRedundancy: float64(0),
 	}
+	return persist.SaveJSON(dirMetadataHeader, data, fullPath)
+}
 
-	return persist.SaveJSON(metadataHeader, data, fullPath)
+// loadDirMetadata loads the metadata file of the directory.
+func loadDirMetadata(path string) (md siaDirMetadata, err error) {
+	err = persist.LoadJSON(dirMetadataHeader, &md, filepath.Join(path, SiaDirMetadata))
+	return
+}
+
+// saveDirMetadata stores the metadata file of the directory.
+func saveDirMetadata(path string, md siaDirMetadata) error {
+	md.LastUpdate = time.Now().UnixNano()
+	return persist.SaveJSON(dirMetadataHeader, md, filepath.Join(path, SiaDirMetadata))
 }
 
 // saveSync stores the current renter data to disk and then syncs to disk.
@@ -261,6 +310,9 @@ func (r *Renter) loadSiaFiles() error {
 			return nil
 		}
 		r.files[sf.SiaPath()] = sf
+		r.indexContentHash(sf)
+		r.indexChunkHashes(sf)
+		r.indexPack(sf)
 		return nil
 	})
 }
@@ -274,6 +326,7 @@ func (r *Renter) loadSettings() error {
 		r.persist.MaxDownloadSpeed = DefaultMaxDownloadSpeed
 		r.persist.MaxUploadSpeed = DefaultMaxUploadSpeed
 		r.persist.StreamCacheSize = DefaultStreamCacheSize
+		r.persist.MaxConcurrentRepairs = defaultMaxConcurrentRepairs()
 		err = r.saveSync()
 		if err != nil {
 			return err
@@ -291,6 +344,12 @@ func (r *Renter) loadSettings() error {
 		return err
 	}
 
+	// Settings that were saved before the number of concurrent repairs was
+	// configurable don't have a limit yet.
+	if r.persist.MaxConcurrentRepairs == 0 {
+		r.persist.MaxConcurrentRepairs = defaultMaxConcurrentRepairs()
+	}
+
 	// Set the bandwidth limits on the contractor, which was already initialized
 	// without bandwidth limits.
 	return r.setBandwidthLimits(r.persist.MaxDownloadSpeed, r.persist.MaxUploadSpeed)
@@ -0,0 +1,158 @@
+package renter
+
+// pieceplacement.go decides which workers are offered the pieces of a chunk
+// first. By default every worker is offered the chunk at once and the workers
+// that get to it first upload the pieces. With a piece placement strategy set
+// in the allowance, only the best ranked workers are offered the chunk, one per
+// missing piece, and the other candidates are put on standby. A standby worker
+// only uploads a piece if one of the preferred workers fails.
+//
+// The strategies only choose among the hosts that the uploader would use
+// anyway. A host that already stores a piece of the chunk, that isn't
+// goodForUpload, or whose worker is on cooldown is never a candidate, and the
+// contractor's address filter already keeps hosts in redundant IP ranges out
+// of the contract set. The most-diverse strategy goes further and prefers
+// hosts whose address range doesn't store a piece of the chunk yet.
+
+import (
+	"sort"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// placementCandidate is a worker that could upload a piece of a chunk along
+// with the values that the strategies rank it by.
+type placementCandidate struct {
+	worker       *worker
+	cost         types.Currency
+	knownCost    bool
+	rtt          time.Duration
+	addressRange string
+}
+
+// rankPlacementCandidates sorts the candidates by the preference of the
+// strategy. usedRanges contains the address ranges of the hosts that already
+// store pieces of the chunk.
+func rankPlacementCandidates(strategy string, candidates []placementCandidate, usedRanges map[string]struct{}) {
+	switch strategy {
+	case modules.PiecePlacementCheapest:
+		// Hosts missing from the hostdb are ranked last.
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if candidates[i].knownCost != candidates[j].knownCost {
+				return candidates[i].knownCost
+			}
+			return candidates[i].cost.Cmp(candidates[j].cost) < 0
+		})
+	case modules.PiecePlacementFastest:
+		// Hosts without a measured latency are ranked first so that every
+		// host gets measured eventually.
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].rtt < candidates[j].rtt
+		})
+	case modules.PiecePlacementMostDiverse:
+		// Pick one host of every unused range first, then the rest in their
+		// original order.
+		used := make(map[string]struct{}, len(usedRanges))
+		for r := range usedRanges {
+			used[r] = struct{}{}
+		}
+		ranked := make([]placementCandidate, 0, len(candidates))
+		var rest []placementCandidate
+		for _, c := range candidates {
+			if _, exists := used[c.addressRange]; exists {
+				rest = append(rest, c)
+				continue
+			}
+			used[c.addressRange] = struct{}{}
+			ranked = append(ranked, c)
+		}
+		copy(candidates, append(ranked, rest...))
+	}
+}
+
+// managedPlaceChunk splits the workers into the ones that are offered the
+// chunk right away and the ones that are put on standby. Workers that can't
+// upload a piece of the chunk are left out entirely.
+func (r *Renter) managedPlaceChunk(uc *unfinishedUploadChunk, workers []*worker) (preferred, standby []*worker) {
+	allowance := r.hostContractor.Allowance()
+	if allowance.PiecePlacementStrategy == "" {
+		return workers, nil
+	}
+
+	// Collect the workers that would accept a piece of the chunk.
+	uc.mu.Lock()
+	needed := uc.piecesNeeded - uc.piecesCompleted - uc.piecesRegistered
+	unusedHosts := make(map[string]struct{}, len(uc.unusedHosts))
+	for host := range uc.unusedHosts {
+		unusedHosts[host] = struct{}{}
+	}
+	uc.mu.Unlock()
+	var candidates []placementCandidate
+	for _, w := range workers {
+		if _, exists := unusedHosts[w.hostPubKey.String()]; !exists {
+			continue
+		}
+		utility, exists := r.hostContractor.ContractUtility(w.contract.HostPublicKey)
+		if !exists || !utility.GoodForUpload {
+			continue
+		}
+		w.mu.Lock()
+		onCooldown := w.onUploadCooldown()
+		w.mu.Unlock()
+		if onCooldown {
+			continue
+		}
+		candidates = append(candidates, placementCandidate{worker: w})
+	}
+
+	// Fill in the values that the strategy ranks by.
+	usedRanges := make(map[string]struct{})
+	switch allowance.PiecePlacementStrategy {
+	case modules.PiecePlacementCheapest:
+		for i, c := range candidates {
+			host, exists := r.hostDB.Host(c.worker.contract.HostPublicKey)
+			if !exists {
+				continue
+			}
+			storage := host.StoragePrice.Mul64(modules.SectorSize).Mul64(uint64(allowance.Period))
+			candidates[i].cost = storage.Add(host.UploadBandwidthPrice.Mul64(modules.SectorSize))
+			candidates[i].knownCost = true
+		}
+	case modules.PiecePlacementFastest:
+		for i, c := range candidates {
+			candidates[i].rtt, _ = c.worker.uploadLatency.managedEstimate()
+		}
+	case modules.PiecePlacementMostDiverse:
+		hosts := make([]types.SiaPublicKey, 0, len(candidates))
+		for _, c := range candidates {
+			hosts = append(hosts, c.worker.contract.HostPublicKey)
+		}
+		var pieceHosts []types.SiaPublicKey
+		if pieces, err := uc.renterFile.Pieces(uc.index); err == nil {
+			for _, pieceSet := range pieces {
+				for _, piece := range pieceSet {
+					pieceHosts = append(pieceHosts, piece.HostPubKey)
+				}
+			}
+		}
+		ranges := r.hostDB.AddressRanges(append(hosts, pieceHosts...))
+		for _, pk := range pieceHosts {
+			usedRanges[ranges[string(pk.Key)]] = struct{}{}
+		}
+		for i, c := range candidates {
+			candidates[i].addressRange = ranges[string(c.worker.contract.HostPublicKey.Key)]
+		}
+	}
+	rankPlacementCandidates(allowance.PiecePlacementStrategy, candidates, usedRanges)
+
+	for i, c := range candidates {
+		if i < needed {
+			preferred = append(preferred, c.worker)
+		} else {
+			standby = append(standby, c.worker)
+		}
+	}
+	return preferred, standby
+}
@@ -0,0 +1,48 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestRankPlacementCandidates checks the order in which each piece placement
+// strategy offers a chunk to the workers.
+func TestRankPlacementCandidates(t *testing.T) {
+	workers := make([]*worker, 4)
+	for i := range workers {
+		workers[i] = new(worker)
+	}
+	candidates := func() []placementCandidate {
+		return []placementCandidate{
+			{worker: workers[0], cost: types.NewCurrency64(30), knownCost: true, rtt: 3 * time.Second, addressRange: "a"},
+			{worker: workers[1], cost: types.NewCurrency64(10), knownCost: true, rtt: 0, addressRange: "a"},
+			{worker: workers[2], rtt: 2 * time.Second, addressRange: "b"},
+			{worker: workers[3], cost: types.NewCurrency64(20), knownCost: true, rtt: time.Second, addressRange: "c"},
+		}
+	}
+	tests := []struct {
+		strategy string
+		expected []int
+	}{
+		{"", []int{0, 1, 2, 3}},
+		{modules.PiecePlacementCheapest, []int{1, 3, 0, 2}},
+		{modules.PiecePlacementFastest, []int{1, 3, 2, 0}},
+		{modules.PiecePlacementMostDiverse, []int{0, 3, 1, 2}},
+	}
+	usedRanges := map[string]struct{}{"b": {}}
+	for _, test := range tests {
+		c := candidates()
+		rankPlacementCandidates(test.strategy, c, usedRanges)
+		for i, expected := range test.expected {
+			if c[i].worker != workers[expected] {
+				t.Fatalf("%q: expected worker %v at position %v", test.strategy, expected, i)
+			}
+		}
+	}
+	if len(usedRanges) != 1 {
+		t.Fatal("the used ranges shouldn't be modified")
+	}
+}
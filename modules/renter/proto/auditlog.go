@@ -0,0 +1,230 @@
+package proto
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+// auditLog is an append-only log of the operations that spent money. Every
+// entry is a line of JSON. Once the log reaches auditLogMaxSize it is rotated,
+// keeping the auditLogRotations most recent files.
+type auditLog struct {
+	file *os.File
+	path string
+	size int64
+	mu   sync.Mutex
+}
+
+// rotatedPath returns the path of the i-th rotated audit log file. 0 is the
+// current file.
+func (al *auditLog) rotatedPath(i int) string {
+	if i == 0 {
+		return al.path
+	}
+	return fmt.Sprintf("%v.%v", al.path, i)
+}
+
+// rotate moves the current file to the first rotated file, shifting the older
+// files and removing the oldest one.
+func (al *auditLog) rotate() error {
+	if err := al.file.Close(); err != nil {
+		return err
+	}
+	err := os.Remove(al.rotatedPath(auditLogRotations))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := auditLogRotations - 1; i >= 0; i-- {
+		err := os.Rename(al.rotatedPath(i), al.rotatedPath(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	al.file, err = os.OpenFile(al.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	al.size = 0
+	return err
+}
+
+// append adds an entry to the log.
+func (al *auditLog) append(entry modules.RenterAuditEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	if al.size+int64(len(b)) > auditLogMaxSize && al.size > 0 {
+		if err := al.rotate(); err != nil {
+			return errors.AddContext(err, "unable to rotate the audit log")
+		}
+	}
+	n, err := al.file.Write(b)
+	al.size += int64(n)
+	return err
+}
+
+// entries returns the entries of all the log files that match the filter,
+// oldest first.
+func (al *auditLog) entries(filter func(modules.RenterAuditEntry) bool) ([]modules.RenterAuditEntry, error) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+
+	var entries []modules.RenterAuditEntry
+	for i := auditLogRotations; i >= 0; i-- {
+		f, err := os.Open(al.rotatedPath(i))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var entry modules.RenterAuditEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				// A crash can leave a partial line at the end of a file.
+				continue
+			}
+			if filter(entry) {
+				entries = append(entries, entry)
+			}
+		}
+		err = errors.Compose(scanner.Err(), f.Close())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// close closes the current log file.
+func (al *auditLog) close() error {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	return al.file.Close()
+}
+
+// openAuditLog opens the audit log at path, creating it if necessary.
+func openAuditLog(path string) (*auditLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, errors.Compose(err, f.Close())
+	}
+	return &auditLog{
+		file: f,
+		path: path,
+		size: stat.Size(),
+	}, nil
+}
+
+// managedRecordAudit adds an entry for the contract to the audit log. An
+// error is returned if the log can't be written, so the spending of the
+// contracts never silently diverges from the log.
+func (cs *ContractSet) managedRecordAudit(category string, contract modules.RenterContract, bytes uint64, cost types.Currency) error {
+	err := cs.auditLog.append(modules.RenterAuditEntry{
+		Timestamp:     time.Now(),
+		Category:      category,
+		ContractID:    contract.ID,
+		HostPublicKey: contract.HostPublicKey,
+		Bytes:         bytes,
+		Cost:          cost,
+	})
+	return errors.AddContext(err, "unable to write to the audit log")
+}
+
+// managedCommitTxns applies the unapplied transactions of the contract and
+// adds the spending they recover to the audit log. The transactions belong to
+// uploads and downloads that were paid for but interrupted before the
+// contract was updated, so they weren't audited yet. The size of a recovered
+// download isn't known, it is recorded with 0 bytes.
+func (cs *ContractSet) managedCommitTxns(sc *SafeContract) error {
+	before := sc.Metadata()
+	numRoots := sc.merkleRoots.len()
+	if err := sc.commitTxns(); err != nil {
+		return err
+	}
+	after := sc.Metadata()
+
+	uploadBefore := before.UploadSpending.Add(before.StorageSpending)
+	uploadAfter := after.UploadSpending.Add(after.StorageSpending)
+	if uploadAfter.Cmp(uploadBefore) > 0 {
+		var bytes uint64
+		if n := sc.merkleRoots.len() - numRoots; n > 0 {
+			bytes = uint64(n) * modules.SectorSize
+		}
+		if err := cs.managedRecordAudit(modules.AuditCategoryUpload, after, bytes, uploadAfter.Sub(uploadBefore)); err != nil {
+			return err
+		}
+	}
+	if after.DownloadSpending.Cmp(before.DownloadSpending) > 0 {
+		return cs.managedRecordAudit(modules.AuditCategoryDownload, after, 0, after.DownloadSpending.Sub(before.DownloadSpending))
+	}
+	return nil
+}
+
+// AuditLog returns the entries of the audit log between start and end that
+// belong to one of the categories. A zero end time and an empty list of
+// categories don't filter anything.
+func (cs *ContractSet) AuditLog(start, end time.Time, categories []string) ([]modules.RenterAuditEntry, error) {
+	return cs.auditLog.entries(func(entry modules.RenterAuditEntry) bool {
+		if entry.Timestamp.Before(start) || (!end.IsZero() && entry.Timestamp.After(end)) {
+			return false
+		}
+		if len(categories) == 0 {
+			return true
+		}
+		for _, category := range categories {
+			if entry.Category == category {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ExportAuditLog writes the whole audit log as CSV to the file at dst. Costs
+// are in hastings.
+func (cs *ContractSet) ExportAuditLog(dst string) (err error) {
+	entries, err := cs.AuditLog(time.Time{}, time.Time{}, nil)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"timestamp", "category", "contractid", "hostpublickey", "bytes", "cost"})
+	for _, entry := range entries {
+		w.Write([]string{
+			entry.Timestamp.Format(time.RFC3339),
+			entry.Category,
+			entry.ContractID.String(),
+			entry.HostPublicKey.String(),
+			strconv.FormatUint(entry.Bytes, 10),
+			entry.Cost.String(),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,169 @@
+package proto
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestAuditLog checks that the audit log filters its entries, survives a
+// restart of the contract set and only keeps a limited number of rotated
+// files.
+func TestAuditLog(t *testing.T) {
+	testDir := build.TempDir(t.Name())
+	cs, err := NewContractSet(testDir, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contract := modules.RenterContract{ID: types.FileContractID{1}}
+	if err := cs.managedRecordAudit(modules.AuditCategoryFormation, contract, 0, types.NewCurrency64(10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.managedRecordAudit(modules.AuditCategoryUpload, contract, modules.SectorSize, types.NewCurrency64(20)); err != nil {
+		t.Fatal(err)
+	}
+	between := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	if err := cs.managedRecordAudit(modules.AuditCategoryDownload, contract, modules.SectorSize, types.NewCurrency64(30)); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := cs.AuditLog(time.Time{}, time.Time{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 3 {
+		t.Fatal("expected 3 entries but got", len(entries))
+	}
+	if entries[0].Category != modules.AuditCategoryFormation || entries[2].Cost.Cmp64(30) != 0 {
+		t.Fatal("entries aren't ordered oldest first", entries)
+	}
+	entries, err = cs.AuditLog(time.Time{}, time.Time{}, []string{modules.AuditCategoryUpload, modules.AuditCategoryDownload})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 2 {
+		t.Fatal("expected 2 entries but got", len(entries))
+	}
+	entries, err = cs.AuditLog(between, time.Time{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 1 || entries[0].Category != modules.AuditCategoryDownload {
+		t.Fatal("start time wasn't applied", entries)
+	}
+
+	// The entries survive a restart.
+	if err := cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	cs, err = NewContractSet(testDir, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+	entries, err = cs.AuditLog(time.Time{}, between, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 2 {
+		t.Fatal("expected 2 entries after restart but got", len(entries))
+	}
+
+	// The export contains a header and a row per entry.
+	dst := filepath.Join(testDir, "audit.csv")
+	if err := cs.ExportAuditLog(dst); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(f).ReadAll()
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(records) != 4 || records[3][1] != modules.AuditCategoryDownload {
+		t.Fatal("unexpected export", records)
+	}
+
+	// Fill the log until it rotated more often than files are kept. An entry
+	// is a few hundred bytes, so every file holds only a few entries during
+	// testing.
+	for i := 0; i < 1000; i++ {
+		if err := cs.managedRecordAudit(modules.AuditCategoryUpload, contract, modules.SectorSize, types.NewCurrency64(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(cs.auditLog.rotatedPath(auditLogRotations)); err != nil {
+		t.Fatal("oldest rotated file is missing:", err)
+	}
+	if _, err := os.Stat(cs.auditLog.rotatedPath(auditLogRotations + 1)); !os.IsNotExist(err) {
+		t.Fatal("too many rotated files are kept")
+	}
+	entries, err = cs.AuditLog(time.Time{}, time.Time{}, []string{modules.AuditCategoryFormation})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 0 {
+		t.Fatal("oldest entries should have been rotated out")
+	}
+}
+
+// TestAuditLogCommitTxns checks that the spending of an upload that is only
+// recovered from the WAL is added to the audit log when it is committed.
+func TestAuditLogCommitTxns(t *testing.T) {
+	cs, err := NewContractSet(build.TempDir(t.Name()), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	rev := types.FileContractRevision{
+		NewRevisionNumber:    1,
+		NewValidProofOutputs: []types.SiacoinOutput{{}, {}},
+		UnlockConditions: types.UnlockConditions{
+			PublicKeys: []types.SiaPublicKey{{}, {}},
+		},
+	}
+	header := contractHeader{
+		Transaction: types.Transaction{FileContractRevisions: []types.FileContractRevision{rev}},
+	}
+	c, err := cs.managedInsertContract(header, []crypto.Hash{{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := cs.mustAcquire(t, c.ID)
+	defer cs.Return(sc)
+
+	rev.NewRevisionNumber++
+	if _, err := sc.recordUploadIntent(rev, crypto.Hash{2}, types.NewCurrency64(7), types.NewCurrency64(17)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.managedCommitTxns(sc); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := cs.AuditLog(time.Time{}, time.Time{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 1 {
+		t.Fatal("expected 1 entry but got", len(entries))
+	}
+	if e := entries[0]; e.Category != modules.AuditCategoryUpload || e.ContractID != c.ID || e.Bytes != modules.SectorSize || e.Cost.Cmp64(24) != 0 {
+		t.Fatal("recovered upload wasn't audited correctly", e)
+	}
+
+	// Committing again doesn't add another entry.
+	if err := cs.managedCommitTxns(sc); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = cs.AuditLog(time.Time{}, time.Time{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 1 {
+		t.Fatal("expected 1 entry but got", len(entries))
+	}
+}
@@ -0,0 +1,71 @@
+package proto
+
+import (
+	"net"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/encoding"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+// CapacityProof challenges the host to prove that it still stores the sectors
+// of the challenge under the contract and that it can write the probe sector
+// to its unused storage. answered is false if the challenge couldn't be sent,
+// e.g. because the host is offline or doesn't support the RPC, or if the host
+// rejected it because the renter challenged it too recently. Otherwise the
+// host is expected to answer it, since the renter only asks for sectors that
+// are part of the contract and only sends a probe to hosts that advertise
+// unused capacity.
+func (cs *ContractSet) CapacityProof(host modules.HostDBEntry, id types.FileContractID, challenge modules.CapacityChallenge, probe modules.CapacityProbe, cancel <-chan struct{}) (proof modules.CapacityProof, probeProof modules.CapacityProbeProof, answered bool, err error) {
+	sc, ok := cs.Acquire(id)
+	if !ok {
+		return modules.CapacityProof{}, modules.CapacityProbeProof{}, false, errors.New("no contract with that id")
+	}
+	sc.headerMu.Lock()
+	sk := sc.header.SecretKey
+	sc.headerMu.Unlock()
+	cs.Return(sc)
+
+	conn, err := (&net.Dialer{
+		Cancel:  cancel,
+		Timeout: connTimeout,
+	}).Dial("tcp", string(host.NetAddress))
+	if err != nil {
+		return modules.CapacityProof{}, modules.CapacityProbeProof{}, false, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	// prove that the renter owns the contract
+	extendDeadline(conn, modules.NegotiateRecentRevisionTime)
+	if err := encoding.WriteObject(conn, modules.RPCCapacityProof); err != nil {
+		return modules.CapacityProof{}, modules.CapacityProbeProof{}, false, errors.New("couldn't initiate RPC: " + err.Error())
+	}
+	if _, _, err := readRecentRevision(conn, id, sk, host.Version); err != nil {
+		return modules.CapacityProof{}, modules.CapacityProbeProof{}, false, err
+	}
+
+	// send the challenge and the probe and read the proofs
+	extendDeadline(conn, modules.NegotiateCapacityProofTime)
+	if err := encoding.WriteObject(conn, challenge); err != nil {
+		return modules.CapacityProof{}, modules.CapacityProbeProof{}, false, errors.AddContext(err, "couldn't send capacity challenge")
+	}
+	if err := encoding.WriteObject(conn, probe); err != nil {
+		return modules.CapacityProof{}, modules.CapacityProbeProof{}, false, errors.AddContext(err, "couldn't send capacity probe")
+	}
+	if err := modules.ReadNegotiationAcceptance(conn); err != nil {
+		// A host on cooldown didn't attempt the challenge.
+		answered := err.Error() != modules.ErrCapacityProofCooldown.Error()
+		return modules.CapacityProof{}, modules.CapacityProbeProof{}, answered, err
+	}
+	maxSegmentLen := uint64(16 + crypto.SegmentSize + 8 + crypto.HashSize*64)
+	if err := encoding.ReadObject(conn, &proof, 16+uint64(len(challenge.SectorRoots))*maxSegmentLen); err != nil {
+		return modules.CapacityProof{}, modules.CapacityProbeProof{}, true, errors.AddContext(err, "couldn't read capacity proof")
+	}
+	if err := encoding.ReadObject(conn, &probeProof, maxSegmentLen); err != nil {
+		return modules.CapacityProof{}, modules.CapacityProbeProof{}, true, errors.AddContext(err, "couldn't read capacity probe proof")
+	}
+	return proof, probeProof, true, nil
+}
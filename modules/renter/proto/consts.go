@@ -9,6 +9,14 @@ import (
 )
 
 const (
+	// auditLogFilename is the name of the file of the audit log in the
+	// contracts directory.
+	auditLogFilename = "audit.log"
+
+	// auditLogRotations is the number of rotated audit log files that are
+	// kept in addition to the current one.
+	auditLogRotations = 3
+
 	// contractExtension is the extension given to contract files.
 	contractExtension = ".contract"
 
@@ -16,12 +24,24 @@ const (
 	// once to avoid using up all the ram.
 	rootsDiskLoadBulkSize = 1024 * crypto.HashSize // 32 kib
 
+	// maxContractMetadataSize is the maximum combined size of the keys and
+	// values of the metadata attached to a contract. The metadata is stored in
+	// the contract header, which has a fixed size.
+	maxContractMetadataSize = 1 << 10 // 1 kib
+
 	// remainingFile is a constant used to indicate that a fileSection can access
 	// the whole remaining file instead of being bound to a certain end offset.
 	remainingFile = -1
 )
 
 var (
+	// auditLogMaxSize is the size at which the audit log is rotated.
+	auditLogMaxSize = build.Select(build.Var{
+		Dev:      int64(1 << 20),  // 1 MiB
+		Standard: int64(64 << 20), // 64 MiB
+		Testing:  int64(1 << 12),  // 4 KiB
+	}).(int64)
+
 	// connTimeout determines the number of seconds before a dial-up or
 	// revision negotiation times out.
 	connTimeout = build.Select(build.Var{
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 
 	"github.com/HyperspaceApp/Hyperspace/crypto"
@@ -23,6 +24,16 @@ const (
 	updateNameSetRoot   = "setRoot"
 )
 
+var (
+	// errEmptyMetadataKey is returned if a metadata entry of a contract has
+	// an empty key.
+	errEmptyMetadataKey = errors.New("contract metadata keys can't be empty")
+
+	// errMetadataTooLarge is returned if the metadata of a contract doesn't
+	// fit into the contract header.
+	errMetadataTooLarge = errors.New("contract metadata is too large")
+)
+
 type updateSetHeader struct {
 	ID     types.FileContractID
 	Header contractHeader
@@ -36,6 +47,13 @@ type v132UpdateSetHeader struct {
 	Header v132ContractHeader
 }
 
+// v022UpdateSetHeader contains the legacy v022ContractHeader, which was used
+// before metadata could be attached to contracts.
+type v022UpdateSetHeader struct {
+	ID     types.FileContractID
+	Header v022ContractHeader
+}
+
 type updateSetRoot struct {
 	ID    types.FileContractID
 	Root  crypto.Hash
@@ -60,6 +78,33 @@ type contractHeader struct {
 	ContractFee      types.Currency
 	TxnFee           types.Currency
 	Utility          modules.ContractUtility
+
+	// Metadata contains the key/value pairs attached to the contract by the
+	// renter, sorted by key.
+	Metadata []contractMetadataEntry
+}
+
+// contractMetadataEntry is a single key/value pair of the metadata of a
+// contract. The encoding package doesn't support maps.
+type contractMetadataEntry struct {
+	Key   string
+	Value string
+}
+
+// v022ContractHeader is a contractHeader without the Metadata field. Headers
+// on disk are padded with zeros, so only the headers in the WAL need to be
+// converted.
+type v022ContractHeader struct {
+	Transaction      types.Transaction
+	SecretKey        crypto.SecretKey
+	StartHeight      types.BlockHeight
+	DownloadSpending types.Currency
+	StorageSpending  types.Currency
+	UploadSpending   types.Currency
+	TotalCost        types.Currency
+	ContractFee      types.Currency
+	TxnFee           types.Currency
+	Utility          modules.ContractUtility
 }
 
 // v132ContractHeader is a contractHeader without the Utility field. This field
@@ -86,13 +131,27 @@ type v132ContractHeader struct {
 // validate returns an error if the contractHeader is invalid.
 func (h *contractHeader) validate() error {
 	if len(h.Transaction.FileContractRevisions) > 0 &&
-		len(h.Transaction.FileContractRevisions[0].NewValidProofOutputs) > 0 &&
-		len(h.Transaction.FileContractRevisions[0].UnlockConditions.PublicKeys) == 2 {
-		return nil
+		len(h.Transaction.FileContractRevisions[0].NewValidProofOutputs) > 0 {
+		// Contracts with an arbiter have a third public key.
+		if n := len(h.Transaction.FileContractRevisions[0].UnlockConditions.PublicKeys); n == 2 || n == 3 {
+			return nil
+		}
 	}
 	return errors.New("invalid contract")
 }
 
+// metadata returns the metadata of the contract as a map.
+func (h *contractHeader) metadata() map[string]string {
+	if len(h.Metadata) == 0 {
+		return nil
+	}
+	md := make(map[string]string, len(h.Metadata))
+	for _, e := range h.Metadata {
+		md[e.Key] = e.Value
+	}
+	return md
+}
+
 func (h *contractHeader) copyTransaction() (txn types.Transaction) {
 	encoding.Unmarshal(encoding.Marshal(h.Transaction), &txn)
 	return
@@ -155,6 +214,7 @@ func (c *SafeContract) Metadata() modules.RenterContract {
 		ContractFee:      h.ContractFee,
 		TxnFee:           h.TxnFee,
 		Utility:          h.Utility,
+		Metadata:         h.metadata(),
 	}
 }
 
@@ -194,6 +254,64 @@ func (c *SafeContract) UpdateUtility(utility modules.ContractUtility) error {
 	return nil
 }
 
+// UpdateMetadata replaces the metadata attached to the contract. Passing an
+// empty map removes all metadata.
+func (c *SafeContract) UpdateMetadata(metadata map[string]string) error {
+	// Sort the entries to get a deterministic encoding.
+	var size int
+	entries := make([]contractMetadataEntry, 0, len(metadata))
+	for k, v := range metadata {
+		if k == "" {
+			return errEmptyMetadataKey
+		}
+		size += len(k) + len(v)
+		entries = append(entries, contractMetadataEntry{Key: k, Value: v})
+	}
+	if size > maxContractMetadataSize {
+		return errMetadataTooLarge
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	if len(entries) == 0 {
+		entries = nil
+	}
+
+	// Get current header
+	c.headerMu.Lock()
+	newHeader := c.header
+	c.headerMu.Unlock()
+
+	// Construct new header. The metadata is checked against the remaining
+	// space in the header, since transactions can vary in size.
+	newHeader.Metadata = entries
+	if len(encoding.Marshal(newHeader)) > contractHeaderSize {
+		return errMetadataTooLarge
+	}
+
+	// Record the intent to change the header in the wal.
+	t, err := c.wal.NewTransaction([]writeaheadlog.Update{
+		c.makeUpdateSetHeader(newHeader),
+	})
+	if err != nil {
+		return err
+	}
+	// Signal that the setup is completed.
+	if err := <-t.SignalSetupComplete(); err != nil {
+		return err
+	}
+	// Apply the change.
+	if err := c.applySetHeader(newHeader); err != nil {
+		return err
+	}
+	// Sync the change to disk.
+	if err := c.headerFile.Sync(); err != nil {
+		return err
+	}
+	// Signal that the update has been applied.
+	return t.SignalUpdatesApplied()
+}
+
 // Utility returns the contract utility for the contract.
 func (c *SafeContract) Utility() modules.ContractUtility {
 	c.headerMu.Lock()
@@ -604,8 +722,26 @@ func (mrs *MerkleRootSet) UnmarshalJSON(b []byte) error {
 
 func unmarshalHeader(b []byte, u *updateSetHeader) error {
 	// Try unmarshaling the header.
-	if err := encoding.Unmarshal(b, u); err != nil {
+	if err := encoding.Unmarshal(b, u); err == nil {
+		return nil
+	}
+	// COMPATv0.2.2 - try unmarshaling the header without metadata.
+	var v022u v022UpdateSetHeader
+	if err := encoding.Unmarshal(b, &v022u); err != nil {
 		return err
 	}
+	u.ID = v022u.ID
+	u.Header = contractHeader{
+		Transaction:      v022u.Header.Transaction,
+		SecretKey:        v022u.Header.SecretKey,
+		StartHeight:      v022u.Header.StartHeight,
+		DownloadSpending: v022u.Header.DownloadSpending,
+		StorageSpending:  v022u.Header.StorageSpending,
+		UploadSpending:   v022u.Header.UploadSpending,
+		TotalCost:        v022u.Header.TotalCost,
+		ContractFee:      v022u.Header.ContractFee,
+		TxnFee:           v022u.Header.TxnFee,
+		Utility:          v022u.Header.Utility,
+	}
 	return nil
 }
@@ -6,10 +6,12 @@ import (
 	"sync"
 
 	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/crypto"
 	"github.com/HyperspaceApp/Hyperspace/modules"
 	"github.com/HyperspaceApp/Hyperspace/types"
 
 	"github.com/HyperspaceApp/errors"
+	"github.com/HyperspaceApp/fastrand"
 	"github.com/HyperspaceApp/writeaheadlog"
 	"gitlab.com/NebulousLabs/ratelimit"
 )
@@ -18,6 +20,7 @@ import (
 // purpose is to serialize modifications to individual contracts, as well as
 // to provide operations on the set as a whole.
 type ContractSet struct {
+	auditLog  *auditLog
 	contracts map[types.FileContractID]*SafeContract
 	pubKeys   map[string]types.FileContractID
 	deps      modules.Dependencies
@@ -106,6 +109,68 @@ func (cs *ContractSet) Return(c *SafeContract) {
 	c.mu.Unlock()
 }
 
+// LatestRevision returns a copy of the signed transaction containing the most
+// recent revision of the contract. The contract is acquired while the copy is
+// made, so an upload or download that is revising the contract at the same
+// time can't cause a partially updated revision to be returned.
+func (cs *ContractSet) LatestRevision(id types.FileContractID) (types.Transaction, bool) {
+	sc, ok := cs.Acquire(id)
+	if !ok {
+		return types.Transaction{}, false
+	}
+	defer cs.Return(sc)
+	sc.headerMu.Lock()
+	defer sc.headerMu.Unlock()
+	return sc.header.copyTransaction(), true
+}
+
+// RandomSectorRoots returns up to n distinct Merkle roots of sectors that are
+// stored in the contract, chosen at random.
+func (cs *ContractSet) RandomSectorRoots(id types.FileContractID, n int) ([]crypto.Hash, error) {
+	sc, ok := cs.Acquire(id)
+	if !ok {
+		return nil, errors.New("no contract with that id")
+	}
+	defer cs.Return(sc)
+	numRoots := sc.merkleRoots.len()
+	if n > numRoots {
+		n = numRoots
+	}
+	roots := make([]crypto.Hash, 0, n)
+	for _, i := range fastrand.Perm(numRoots)[:n] {
+		root, err := sc.merkleRoots.merkleRootsFromIndexFromDisk(i, i+1)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, root...)
+	}
+	return roots, nil
+}
+
+// HasSectorRoots returns true if every one of the roots belongs to a sector
+// that is stored in the contract.
+func (cs *ContractSet) HasSectorRoots(id types.FileContractID, roots []crypto.Hash) (bool, error) {
+	sc, ok := cs.Acquire(id)
+	if !ok {
+		return false, errors.New("no contract with that id")
+	}
+	defer cs.Return(sc)
+	contractRoots, err := sc.merkleRoots.merkleRoots()
+	if err != nil {
+		return false, err
+	}
+	stored := make(map[crypto.Hash]struct{}, len(contractRoots))
+	for _, root := range contractRoots {
+		stored[root] = struct{}{}
+	}
+	for _, root := range roots {
+		if _, exists := stored[root]; !exists {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 // RateLimits sets the bandwidth limits for connections created by the
 // contractSet.
 func (cs *ContractSet) RateLimits() (readBPS int64, writeBPS int64, packetSize uint64) {
@@ -150,7 +215,7 @@ func (cs *ContractSet) Close() error {
 		c.headerFile.Close()
 	}
 	_, err := cs.wal.CloseIncomplete()
-	return err
+	return errors.Compose(err, cs.auditLog.close())
 }
 
 // NewContractSet returns a ContractSet storing its contracts in the specified
@@ -176,7 +241,14 @@ func NewContractSet(dir string, deps modules.Dependencies) (*ContractSet, error)
 		return nil, err
 	}
 
+	// Open the audit log.
+	auditLog, err := openAuditLog(filepath.Join(dir, auditLogFilename))
+	if err != nil {
+		return nil, err
+	}
+
 	cs := &ContractSet{
+		auditLog:  auditLog,
 		contracts: make(map[types.FileContractID]*SafeContract),
 		pubKeys:   make(map[string]types.FileContractID),
 
@@ -113,6 +113,7 @@ func TestContractSet(t *testing.T) {
 		func() { cs.IDs() },
 		func() { cs.View(id1); cs.View(id2) },
 		func() { cs.ViewAll() },
+		func() { cs.LatestRevision(id1); cs.LatestRevision(id2) },
 		func() { cs.Return(cs.mustAcquire(t, id1)) },
 		func() { cs.Return(cs.mustAcquire(t, id2)) },
 		func() {
@@ -149,3 +150,271 @@ func TestContractSet(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+// TestContractSetLatestRevision checks that LatestRevision never returns a
+// revision that is being modified concurrently.
+func TestContractSetLatestRevision(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := build.TempDir(t.Name())
+	cs, err := NewContractSet(testDir, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := contractHeader{Transaction: types.Transaction{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID:             types.FileContractID{1},
+			NewValidProofOutputs: []types.SiacoinOutput{{}, {}},
+			UnlockConditions: types.UnlockConditions{
+				PublicKeys: []types.SiaPublicKey{{}, {}},
+			},
+		}},
+		TransactionSignatures: []types.TransactionSignature{{}},
+	}}
+	id := header.ID()
+	if _, err := cs.managedInsertContract(header, []crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cs.LatestRevision(types.FileContractID{2}); ok {
+		t.Fatal("LatestRevision should fail for unknown contract")
+	}
+
+	// Update the revision number and the signature in two steps while other
+	// threads read the latest revision. The signature always has to match the
+	// revision number.
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c := cs.mustAcquire(t, id)
+			c.header.Transaction.FileContractRevisions[0].NewRevisionNumber++
+			time.Sleep(time.Duration(fastrand.Intn(100)))
+			c.header.Transaction.TransactionSignatures[0].Timelock = types.BlockHeight(c.header.LastRevision().NewRevisionNumber)
+			cs.Return(c)
+		}()
+		go func() {
+			defer wg.Done()
+			txn, ok := cs.LatestRevision(id)
+			if !ok {
+				t.Error("contract should exist")
+				return
+			}
+			if uint64(txn.TransactionSignatures[0].Timelock) != txn.FileContractRevisions[0].NewRevisionNumber {
+				t.Error("LatestRevision returned a torn revision")
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The returned transaction shouldn't share memory with the contract.
+	txn, _ := cs.LatestRevision(id)
+	txn.FileContractRevisions[0].NewRevisionNumber = 0
+	if txn, _ = cs.LatestRevision(id); txn.FileContractRevisions[0].NewRevisionNumber != 50 {
+		t.Fatal("expected revision number 50 but got", txn.FileContractRevisions[0].NewRevisionNumber)
+	}
+}
+
+// TestContractSetMetadata checks that the metadata of a contract survives
+// concurrent updates and reloading the contract set.
+func TestContractSetMetadata(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := build.TempDir(t.Name())
+	cs, err := NewContractSet(testDir, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := contractHeader{Transaction: types.Transaction{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID:             types.FileContractID{1},
+			NewValidProofOutputs: []types.SiacoinOutput{{}, {}},
+			UnlockConditions: types.UnlockConditions{
+				PublicKeys: []types.SiaPublicKey{{}, {}},
+			},
+		}},
+	}}
+	id := header.ID()
+	if _, err := cs.managedInsertContract(header, []crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// invalid metadata is rejected
+	c := cs.mustAcquire(t, id)
+	if err := c.UpdateMetadata(map[string]string{"": "foo"}); err != errEmptyMetadataKey {
+		t.Fatal("expected errEmptyMetadataKey, got", err)
+	}
+	if err := c.UpdateMetadata(map[string]string{"foo": string(make([]byte, maxContractMetadataSize))}); err != errMetadataTooLarge {
+		t.Fatal("expected errMetadataTooLarge, got", err)
+	}
+	cs.Return(c)
+
+	// concurrent acquire/mutate/return cycles
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := cs.mustAcquire(t, id)
+			defer cs.Return(c)
+			md := c.Metadata().Metadata
+			if md == nil {
+				md = make(map[string]string)
+			}
+			md[string('a'+byte(i))] = "bar"
+			if err := c.UpdateMetadata(md); err != nil {
+				t.Error(err)
+			}
+			c.header.Transaction.FileContractRevisions[0].NewRevisionNumber++
+			time.Sleep(time.Duration(fastrand.Intn(100)))
+		}(i)
+	}
+	wg.Wait()
+	if rc, _ := cs.View(id); len(rc.Metadata) != 20 {
+		t.Fatal("expected 20 metadata entries, got", len(rc.Metadata))
+	}
+
+	// the metadata is persisted
+	cs.Close()
+	cs, err = NewContractSet(testDir, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, ok := cs.View(id)
+	if !ok {
+		t.Fatal("contract missing after reload")
+	}
+	if len(rc.Metadata) != 20 || rc.Metadata["a"] != "bar" {
+		t.Fatal("metadata wasn't persisted", rc.Metadata)
+	}
+
+	// an empty map removes the metadata
+	c = cs.mustAcquire(t, id)
+	if err := c.UpdateMetadata(nil); err != nil {
+		t.Fatal(err)
+	}
+	cs.Return(c)
+	if rc, _ := cs.View(id); rc.Metadata != nil {
+		t.Fatal("metadata wasn't removed", rc.Metadata)
+	}
+}
+
+// TestContractSetSpending tests that the spending of a contract accumulates
+// across revisions and is persisted in the contract header.
+func TestContractSetSpending(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := build.TempDir(t.Name())
+	cs, err := NewContractSet(testDir, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := contractHeader{Transaction: types.Transaction{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID:             types.FileContractID{1},
+			NewValidProofOutputs: []types.SiacoinOutput{{}, {}},
+			UnlockConditions: types.UnlockConditions{
+				PublicKeys: []types.SiaPublicKey{{}, {}},
+			},
+		}},
+	}}
+	id := header.ID()
+	if _, err := cs.managedInsertContract(header, []crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// revise the contract a few times for uploads and downloads
+	revise := func(upload bool, storageCost, bandwidthCost uint64) {
+		sc := cs.mustAcquire(t, id)
+		defer cs.Return(sc)
+		txn := sc.header.copyTransaction()
+		txn.FileContractRevisions[0].NewRevisionNumber++
+		rev := txn.FileContractRevisions[0]
+		if upload {
+			root := crypto.Hash{byte(rev.NewRevisionNumber)}
+			walTxn, err := sc.recordUploadIntent(rev, root, types.NewCurrency64(storageCost), types.NewCurrency64(bandwidthCost))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := sc.commitUpload(walTxn, txn, root, types.NewCurrency64(storageCost), types.NewCurrency64(bandwidthCost)); err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+		walTxn, err := sc.recordDownloadIntent(rev, types.NewCurrency64(bandwidthCost))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sc.commitDownload(walTxn, txn, types.NewCurrency64(bandwidthCost)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	revise(true, 10, 1)
+	revise(false, 0, 2)
+	revise(true, 20, 3)
+	revise(false, 0, 4)
+
+	checkSpending := func(rc modules.RenterContract) {
+		t.Helper()
+		if !rc.StorageSpending.Equals64(30) {
+			t.Error("wrong storage spending:", rc.StorageSpending)
+		}
+		if !rc.UploadSpending.Equals64(4) {
+			t.Error("wrong upload spending:", rc.UploadSpending)
+		}
+		if !rc.DownloadSpending.Equals64(6) {
+			t.Error("wrong download spending:", rc.DownloadSpending)
+		}
+	}
+	rc, _ := cs.View(id)
+	checkSpending(rc)
+
+	// the spending survives a restart
+	cs.Close()
+	cs, err = NewContractSet(testDir, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, ok := cs.View(id)
+	if !ok {
+		t.Fatal("contract missing after reload")
+	}
+	checkSpending(rc)
+}
+
+// TestHasSectorRoots checks that HasSectorRoots only reports roots that are
+// all stored in the contract.
+func TestHasSectorRoots(t *testing.T) {
+	cs, err := NewContractSet(build.TempDir(t.Name()), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	header := contractHeader{Transaction: types.Transaction{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID:             types.FileContractID{1},
+			NewValidProofOutputs: []types.SiacoinOutput{{}, {}},
+			UnlockConditions: types.UnlockConditions{
+				PublicKeys: []types.SiaPublicKey{{}, {}},
+			},
+		}},
+	}}
+	roots := []crypto.Hash{{1}, {2}, {3}}
+	if _, err := cs.managedInsertContract(header, roots); err != nil {
+		t.Fatal(err)
+	}
+
+	if stored, err := cs.HasSectorRoots(header.ID(), roots[1:]); err != nil || !stored {
+		t.Fatal("stored roots weren't found", stored, err)
+	}
+	if stored, err := cs.HasSectorRoots(header.ID(), []crypto.Hash{{1}, {4}}); err != nil || stored {
+		t.Fatal("root that isn't stored was found", stored, err)
+	}
+	if _, err := cs.HasSectorRoots(types.FileContractID{2}, roots); err == nil {
+		t.Fatal("expected an error for an unknown contract")
+	}
+}
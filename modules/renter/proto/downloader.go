@@ -11,8 +11,13 @@ import (
 	"github.com/HyperspaceApp/Hyperspace/types"
 
 	"github.com/HyperspaceApp/errors"
+	"github.com/HyperspaceApp/writeaheadlog"
 )
 
+// ErrBadSectorData is returned by a Downloader if the data sent by the host
+// doesn't match the Merkle root of the requested sector.
+var ErrBadSectorData = errors.New("host sent bad sector data")
+
 // A Downloader retrieves sectors by calling the download RPC on a host.
 // Downloaders are NOT thread- safe; calls to Sector must be serialized.
 type Downloader struct {
@@ -20,10 +25,29 @@ type Downloader struct {
 	conn        net.Conn
 	contractID  types.FileContractID
 	contractSet *ContractSet
+	deadline    time.Time
 	deps        modules.Dependencies
 	hdb         hostDB
 	host        modules.HostDBEntry
 	once        sync.Once
+
+	// transferTime is the time it took the host to send the sector data of
+	// the last call to Sector.
+	transferTime time.Duration
+}
+
+// SetDeadline sets the time by which the following calls to Sector have to
+// finish. The zero value removes the deadline. The connection to the host is
+// kept open in between, so a cached Downloader can serve RPCs with different
+// deadlines.
+func (hd *Downloader) SetDeadline(t time.Time) {
+	hd.deadline = t
+}
+
+// TransferTime returns the time it took the host to send the sector data of
+// the last call to Sector, which excludes the negotiation of the revision.
+func (hd *Downloader) TransferTime() time.Duration {
+	return hd.transferTime
 }
 
 // Sector retrieves the sector with the specified Merkle root, and revises
@@ -43,19 +67,16 @@ func (hd *Downloader) Sector(root crypto.Hash) (_ modules.RenterContract, _ []by
 	contract := sc.header // for convenience
 
 	// calculate price
-	sectorPrice := hd.host.DownloadBandwidthPrice.Mul64(modules.SectorSize)
-	if contract.RenterFunds().Cmp(sectorPrice) < 0 {
-		return modules.RenterContract{}, nil, errors.New("contract has insufficient funds to support download")
+	sectorPrice, err := downloadPrice(hd.host, contract.RenterFunds(), modules.SectorSize)
+	if err != nil {
+		return modules.RenterContract{}, nil, err
 	}
-	// To mitigate small errors (e.g. differing block heights), fudge the
-	// price and collateral by 0.2%.
-	sectorPrice = sectorPrice.MulFloat(1 + hostPriceLeeway)
 
 	// create the download revision
 	rev := newDownloadRevision(contract.LastRevision(), sectorPrice)
 
 	// initiate download by confirming host settings
-	extendDeadline(hd.conn, modules.NegotiateSettingsTime)
+	extendDeadlineBefore(hd.conn, modules.NegotiateSettingsTime, hd.deadline)
 	if err := startDownload(hd.conn, hd.host); err != nil {
 		return modules.RenterContract{}, nil, err
 	}
@@ -69,7 +90,7 @@ func (hd *Downloader) Sector(root crypto.Hash) (_ modules.RenterContract, _ []by
 	}
 
 	// send download action
-	extendDeadline(hd.conn, 2*time.Minute) // TODO: Constant.
+	extendDeadlineBefore(hd.conn, 2*time.Minute, hd.deadline) // TODO: Constant.
 	err = encoding.WriteObject(hd.conn, []modules.DownloadAction{{
 		MerkleRoot: root,
 		Offset:     0,
@@ -96,7 +117,7 @@ func (hd *Downloader) Sector(root crypto.Hash) (_ modules.RenterContract, _ []by
 	}
 
 	// send the revision to the host for approval
-	extendDeadline(hd.conn, connTimeout)
+	extendDeadlineBefore(hd.conn, connTimeout, hd.deadline)
 	signedTxn, err := negotiateRevision(hd.conn, rev, contract.SecretKey)
 	if err == modules.ErrStopResponse {
 		// if host gracefully closed, close our connection as well; this will
@@ -114,26 +135,54 @@ func (hd *Downloader) Sector(root crypto.Hash) (_ modules.RenterContract, _ []by
 	}
 
 	// read sector data, completing one iteration of the download loop
-	extendDeadline(hd.conn, modules.NegotiateDownloadTime)
+	extendDeadlineBefore(hd.conn, modules.NegotiateDownloadTime, hd.deadline)
 	var sectors [][]byte
+	start := time.Now()
 	if err := encoding.ReadObject(hd.conn, &sectors, modules.SectorSize+16); err != nil {
 		return modules.RenterContract{}, nil, err
-	} else if len(sectors) != 1 {
+	}
+	hd.transferTime = time.Since(start)
+	if len(sectors) != 1 {
 		return modules.RenterContract{}, nil, errors.New("host did not send enough sectors")
 	}
 	sector := sectors[0]
 	if uint64(len(sector)) != modules.SectorSize {
 		return modules.RenterContract{}, nil, errors.New("host did not send enough sector data")
 	} else if crypto.MerkleRoot(sector) != root {
-		return modules.RenterContract{}, nil, errors.New("host sent bad sector data")
+		return modules.RenterContract{}, nil, ErrBadSectorData
 	}
 
 	// update contract and metrics
-	if err := sc.commitDownload(walTxn, signedTxn, sectorPrice); err != nil {
+	meta, err := hd.contractSet.managedCommitDownload(sc, walTxn, signedTxn, sectorPrice, modules.SectorSize)
+	if err != nil {
 		return modules.RenterContract{}, nil, err
 	}
+	return meta, sector, nil
+}
 
-	return sc.Metadata(), sector, nil
+// downloadPrice returns the price of downloading size bytes from the host and
+// checks that the renter funds of the contract can pay for it. To mitigate
+// small errors (e.g. differing block heights), the price is fudged by 0.2%.
+func downloadPrice(host modules.HostDBEntry, renterFunds types.Currency, size uint64) (types.Currency, error) {
+	price := host.DownloadBandwidthPrice.Mul64(size)
+	if renterFunds.Cmp(price) < 0 {
+		return types.Currency{}, errors.New("contract has insufficient funds to support download")
+	}
+	return price.MulFloat(1 + hostPriceLeeway), nil
+}
+
+// managedCommitDownload commits the payment for a download of size bytes that
+// was recorded with recordDownloadIntent and signed by the host, and adds it
+// to the audit log.
+func (cs *ContractSet) managedCommitDownload(sc *SafeContract, walTxn *writeaheadlog.Transaction, signedTxn types.Transaction, price types.Currency, size uint64) (modules.RenterContract, error) {
+	if err := sc.commitDownload(walTxn, signedTxn, price); err != nil {
+		return modules.RenterContract{}, err
+	}
+	meta := sc.Metadata()
+	if err := cs.managedRecordAudit(modules.AuditCategoryDownload, meta, size, price); err != nil {
+		return modules.RenterContract{}, err
+	}
+	return meta, nil
 }
 
 // shutdown terminates the revision loop and signals the goroutine spawned in
@@ -181,7 +230,7 @@ func (cs *ContractSet) NewDownloader(host modules.HostDBEntry, id types.FileCont
 		}
 	}()
 
-	conn, closeChan, err := initiateRevisionLoop(host, sc, modules.RPCDownload, cancel, cs.rl)
+	conn, closeChan, err := cs.managedInitiateRevisionLoop(host, sc, modules.RPCDownload, cancel)
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to initiate revision loop")
 	}
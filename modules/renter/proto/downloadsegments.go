@@ -0,0 +1,85 @@
+package proto
+
+import (
+	"net"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/encoding"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+// DownloadSegments downloads the segments of the request together with their
+// Merkle proofs, and revises the contract to pay the host for them like for a
+// download of the same size. The proofs aren't checked, the caller has to
+// verify them against the roots of the request.
+func (cs *ContractSet) DownloadSegments(host modules.HostDBEntry, id types.FileContractID, request modules.CapacityChallenge, cancel <-chan struct{}) (_ modules.RenterContract, _ modules.CapacityProof, err error) {
+	sc, ok := cs.Acquire(id)
+	if !ok {
+		return modules.RenterContract{}, modules.CapacityProof{}, errors.New("no contract with that id")
+	}
+	defer cs.Return(sc)
+	contract := sc.header // for convenience
+
+	// calculate price
+	size := modules.SegmentDownloadSize * uint64(len(request.SectorRoots))
+	price, err := downloadPrice(host, contract.RenterFunds(), size)
+	if err != nil {
+		return modules.RenterContract{}, modules.CapacityProof{}, err
+	}
+
+	conn, err := (&net.Dialer{
+		Cancel:  cancel,
+		Timeout: connTimeout,
+	}).Dial("tcp", string(host.NetAddress))
+	if err != nil {
+		return modules.RenterContract{}, modules.CapacityProof{}, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	// prove that the renter owns the contract and that both sides agree on
+	// its revision
+	extendDeadline(conn, modules.NegotiateRecentRevisionTime)
+	if err := encoding.WriteObject(conn, modules.RPCDownloadSegments); err != nil {
+		return modules.RenterContract{}, modules.CapacityProof{}, errors.New("couldn't initiate RPC: " + err.Error())
+	}
+	if err := cs.managedVerifyRecentRevision(conn, sc, host.Version); err != nil {
+		return modules.RenterContract{}, modules.CapacityProof{}, err
+	}
+	for _, txn := range sc.unappliedTxns {
+		txn.SignalUpdatesApplied()
+	}
+	sc.unappliedTxns = nil
+	contract = sc.header
+
+	// send the request and pay for it
+	extendDeadline(conn, modules.NegotiateDownloadTime)
+	if err := encoding.WriteObject(conn, request); err != nil {
+		return modules.RenterContract{}, modules.CapacityProof{}, errors.AddContext(err, "couldn't send segment request")
+	}
+	rev := newDownloadRevision(contract.LastRevision(), price)
+	walTxn, err := sc.recordDownloadIntent(rev, price)
+	if err != nil {
+		return modules.RenterContract{}, modules.CapacityProof{}, err
+	}
+	signedTxn, err := negotiateRevision(conn, rev, contract.SecretKey)
+	if err != nil {
+		return modules.RenterContract{}, modules.CapacityProof{}, err
+	}
+
+	// read the segments
+	var segments modules.CapacityProof
+	maxLen := uint64(16 + len(request.SectorRoots)*(16+crypto.SegmentSize+8+crypto.HashSize*64))
+	if err := encoding.ReadObject(conn, &segments, maxLen); err != nil {
+		return modules.RenterContract{}, modules.CapacityProof{}, errors.AddContext(err, "couldn't read segments")
+	}
+
+	// update contract and metrics
+	meta, err := cs.managedCommitDownload(sc, walTxn, signedTxn, price, size)
+	if err != nil {
+		return modules.RenterContract{}, modules.CapacityProof{}, err
+	}
+	return meta, segments, nil
+}
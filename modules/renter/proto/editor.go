@@ -31,12 +31,24 @@ type Editor struct {
 	contractSet *ContractSet
 	conn        net.Conn
 	closeChan   chan struct{}
+	deadline    time.Time
 	deps        modules.Dependencies
 	hdb         hostDB
 	host        modules.HostDBEntry
 	once        sync.Once
 
 	height types.BlockHeight
+
+	// transferTime is the time it took to send the sector data of the last
+	// call to Upload, until the host responded with its signature of the
+	// revision.
+	transferTime time.Duration
+}
+
+// SetDeadline sets the time by which the following calls to Upload have to
+// finish. The zero value removes the deadline.
+func (he *Editor) SetDeadline(t time.Time) {
+	he.deadline = t
 }
 
 // shutdown terminates the revision loop and signals the goroutine spawned in
@@ -57,6 +69,14 @@ func (he *Editor) Close() error {
 	return he.conn.Close()
 }
 
+// TransferTime returns the time it took to send the sector data of the last
+// call to Upload. Writing the data returns as soon as it is buffered by the
+// connection, so the time is measured until the host's response to the
+// revision, which the host sends after receiving all of the data.
+func (he *Editor) TransferTime() time.Duration {
+	return he.transferTime
+}
+
 // Upload negotiates a revision that adds a sector to a file contract.
 func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash, err error) {
 	// Acquire the contract.
@@ -118,7 +138,7 @@ func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash,
 	}()
 
 	// initiate revision
-	extendDeadline(he.conn, modules.NegotiateSettingsTime)
+	extendDeadlineBefore(he.conn, modules.NegotiateSettingsTime, he.deadline)
 	if err := startRevision(he.conn, he.host); err != nil {
 		return modules.RenterContract{}, crypto.Hash{}, err
 	}
@@ -132,7 +152,8 @@ func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash,
 	}
 
 	// send actions
-	extendDeadline(he.conn, modules.NegotiateFileContractRevisionTime)
+	extendDeadlineBefore(he.conn, modules.NegotiateFileContractRevisionTime, he.deadline)
+	start := time.Now()
 	if err := encoding.WriteObject(he.conn, actions); err != nil {
 		return modules.RenterContract{}, crypto.Hash{}, err
 	}
@@ -144,8 +165,9 @@ func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash,
 	}
 
 	// send revision to host and exchange signatures
-	extendDeadline(he.conn, connTimeout)
+	extendDeadlineBefore(he.conn, connTimeout, he.deadline)
 	signedTxn, err := negotiateRevision(he.conn, rev, contract.SecretKey)
+	he.transferTime = time.Since(start)
 	if err == modules.ErrStopResponse {
 		// if host gracefully closed, close our connection as well; this will
 		// cause the next operation to fail
@@ -165,8 +187,13 @@ func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash,
 	if err != nil {
 		return modules.RenterContract{}, crypto.Hash{}, err
 	}
+	meta := sc.Metadata()
+	err = he.contractSet.managedRecordAudit(modules.AuditCategoryUpload, meta, modules.SectorSize, sectorStoragePrice.Add(sectorBandwidthPrice))
+	if err != nil {
+		return modules.RenterContract{}, crypto.Hash{}, err
+	}
 
-	return sc.Metadata(), sectorRoot, nil
+	return meta, sectorRoot, nil
 }
 
 // NewEditor initiates the contract revision process with a host, and returns
@@ -190,7 +217,7 @@ func (cs *ContractSet) NewEditor(host modules.HostDBEntry, id types.FileContract
 		}
 	}()
 
-	conn, closeChan, err := initiateRevisionLoop(host, sc, modules.RPCReviseContract, cancel, cs.rl)
+	conn, closeChan, err := cs.managedInitiateRevisionLoop(host, sc, modules.RPCReviseContract, cancel)
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to initiate revision loop")
 	}
@@ -213,9 +240,9 @@ func (cs *ContractSet) NewEditor(host modules.HostDBEntry, id types.FileContract
 	}, nil
 }
 
-// initiateRevisionLoop initiates either the editor or downloader loop with
-// host, depending on which rpc was passed.
-func initiateRevisionLoop(host modules.HostDBEntry, contract *SafeContract, rpc types.Specifier, cancel <-chan struct{}, rl *ratelimit.RateLimit) (net.Conn, chan struct{}, error) {
+// managedInitiateRevisionLoop initiates either the editor or downloader loop
+// with host, depending on which rpc was passed.
+func (cs *ContractSet) managedInitiateRevisionLoop(host modules.HostDBEntry, contract *SafeContract, rpc types.Specifier, cancel <-chan struct{}) (net.Conn, chan struct{}, error) {
 	c, err := (&net.Dialer{
 		Cancel:  cancel,
 		Timeout: 45 * time.Second, // TODO: Constant
@@ -223,7 +250,7 @@ func initiateRevisionLoop(host modules.HostDBEntry, contract *SafeContract, rpc
 	if err != nil {
 		return nil, nil, err
 	}
-	conn := ratelimit.NewRLConn(c, rl, cancel)
+	conn := ratelimit.NewRLConn(c, cs.rl, cancel)
 
 	closeChan := make(chan struct{})
 	go func() {
@@ -242,7 +269,7 @@ func initiateRevisionLoop(host modules.HostDBEntry, contract *SafeContract, rpc
 		close(closeChan)
 		return nil, closeChan, errors.New("couldn't initiate RPC: " + err.Error())
 	}
-	if err := verifyRecentRevision(conn, contract, host.Version); err != nil {
+	if err := cs.managedVerifyRecentRevision(conn, contract, host.Version); err != nil {
 		conn.Close() // TODO: close gracefully if host has entered revision loop
 		close(closeChan)
 		return nil, closeChan, err
@@ -21,13 +21,8 @@ func (cs *ContractSet) FormContract(params ContractParams, txnBuilder transactio
 	// Create our key.
 	ourSK, ourPK := crypto.GenerateKeyPair()
 	// Create unlock conditions.
-	uc := types.UnlockConditions{
-		PublicKeys: []types.SiaPublicKey{
-			types.Ed25519PublicKey(ourPK),
-			host.PublicKey,
-		},
-		SignaturesRequired: 2,
-	}
+	uc := modules.ContractUnlockConditions(types.Ed25519PublicKey(ourPK), host.PublicKey, params.Arbiter)
+	arbitrated := len(params.Arbiter.Key) > 0
 
 	// Calculate the anticipated transaction fee.
 	_, maxFee := tpool.FeeEstimation()
@@ -118,7 +113,11 @@ func (cs *ContractSet) FormContract(params ContractParams, txnBuilder transactio
 
 	// Allot time for sending RPC ID + verifySettings.
 	extendDeadline(conn, modules.NegotiateSettingsTime)
-	if err = encoding.WriteObject(conn, modules.RPCFormContract); err != nil {
+	rpc := modules.RPCFormContract
+	if arbitrated {
+		rpc = modules.RPCFormArbitratedContract
+	}
+	if err = encoding.WriteObject(conn, rpc); err != nil {
 		return modules.RenterContract{}, err
 	}
 
@@ -144,6 +143,11 @@ func (cs *ContractSet) FormContract(params ContractParams, txnBuilder transactio
 	if err = encoding.WriteObject(conn, ourSK.PublicKey()); err != nil {
 		return modules.RenterContract{}, errors.New("couldn't send our public key: " + err.Error())
 	}
+	if arbitrated {
+		if err = encoding.WriteObject(conn, params.Arbiter); err != nil {
+			return modules.RenterContract{}, errors.New("couldn't send the arbiter's public key: " + err.Error())
+		}
+	}
 
 	// Read acceptance and txn signed by host.
 	if err = modules.ReadNegotiationAcceptance(conn); err != nil {
@@ -269,5 +273,8 @@ func (cs *ContractSet) FormContract(params ContractParams, txnBuilder transactio
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
+	if err := cs.managedRecordAudit(modules.AuditCategoryFormation, meta, 0, header.ContractFee.Add(header.TxnFee)); err != nil {
+		return modules.RenterContract{}, err
+	}
 	return meta, nil
 }
@@ -15,6 +15,16 @@ import (
 // extendDeadline is a helper function for extending the connection timeout.
 func extendDeadline(conn net.Conn, d time.Duration) { _ = conn.SetDeadline(time.Now().Add(d)) }
 
+// extendDeadlineBefore is like extendDeadline, but never extends the deadline
+// past 'before'. A zero 'before' doesn't limit the deadline.
+func extendDeadlineBefore(conn net.Conn, d time.Duration, before time.Time) {
+	deadline := time.Now().Add(d)
+	if !before.IsZero() && before.Before(deadline) {
+		deadline = before
+	}
+	_ = conn.SetDeadline(deadline)
+}
+
 // startRevision is run at the beginning of each revision iteration. It reads
 // the host's settings confirms that the values are acceptable, and writes an acceptance.
 func startRevision(conn net.Conn, host modules.HostDBEntry) error {
@@ -66,38 +76,49 @@ func verifySettings(conn net.Conn, host modules.HostDBEntry) (modules.HostDBEntr
 	return host, nil
 }
 
-// verifyRecentRevision confirms that the host and contractor agree upon the current
-// state of the contract being revised.
-func verifyRecentRevision(conn net.Conn, contract *SafeContract, hostVersion string) error {
+// readRecentRevision proves ownership of the contract to the host by signing
+// its challenge with the secret key of the contract, and reads the most recent
+// revision of the contract together with the host's signatures.
+func readRecentRevision(conn net.Conn, id types.FileContractID, sk crypto.SecretKey, hostVersion string) (types.FileContractRevision, []types.TransactionSignature, error) {
 	// send contract ID
-	if err := encoding.WriteObject(conn, contract.header.ID()); err != nil {
-		return errors.New("couldn't send contract ID: " + err.Error())
+	if err := encoding.WriteObject(conn, id); err != nil {
+		return types.FileContractRevision{}, nil, errors.New("couldn't send contract ID: " + err.Error())
 	}
 	// read challenge
 	var challenge crypto.Hash
 	if err := encoding.ReadObject(conn, &challenge, 32); err != nil {
-		return errors.New("couldn't read challenge: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't read challenge: " + err.Error())
 	}
 	if build.VersionCmp(hostVersion, "1.3.0") >= 0 {
 		crypto.SecureWipe(challenge[:16])
 	}
 	// sign and return
-	sig := crypto.SignHash(challenge, contract.header.SecretKey)
+	sig := crypto.SignHash(challenge, sk)
 	if err := encoding.WriteObject(conn, sig); err != nil {
-		return errors.New("couldn't send challenge response: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't send challenge response: " + err.Error())
 	}
 	// read acceptance
 	if err := modules.ReadNegotiationAcceptance(conn); err != nil {
-		return errors.New("host did not accept revision request: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("host did not accept revision request: " + err.Error())
 	}
 	// read last revision and signatures
 	var lastRevision types.FileContractRevision
 	var hostSignatures []types.TransactionSignature
 	if err := encoding.ReadObject(conn, &lastRevision, 2048); err != nil {
-		return errors.New("couldn't read last revision: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't read last revision: " + err.Error())
 	}
 	if err := encoding.ReadObject(conn, &hostSignatures, 2048); err != nil {
-		return errors.New("couldn't read host signatures: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't read host signatures: " + err.Error())
+	}
+	return lastRevision, hostSignatures, nil
+}
+
+// managedVerifyRecentRevision confirms that the host and contractor agree upon
+// the current state of the contract being revised.
+func (cs *ContractSet) managedVerifyRecentRevision(conn net.Conn, contract *SafeContract, hostVersion string) error {
+	lastRevision, hostSignatures, err := readRecentRevision(conn, contract.header.ID(), contract.header.SecretKey, hostVersion)
+	if err != nil {
+		return err
 	}
 	// Check that the unlock hashes match; if they do not, something is
 	// seriously wrong. Otherwise, check that the revision numbers match.
@@ -107,7 +128,7 @@ func verifyRecentRevision(conn net.Conn, contract *SafeContract, hostVersion str
 	} else if lastRevision.NewRevisionNumber != ourRev.NewRevisionNumber {
 		// If the revision number doesn't match try to commit potential
 		// unapplied transactions and check again.
-		if err := contract.commitTxns(); err != nil {
+		if err := cs.managedCommitTxns(contract); err != nil {
 			return errors.AddContext(err, "failed to commit transactions")
 		}
 		ourRev = contract.header.LastRevision()
@@ -42,6 +42,15 @@ type ContractParams struct {
 	StartHeight   types.BlockHeight
 	EndHeight     types.BlockHeight
 	RefundAddress types.UnlockHash
+
+	// Refresh is set if a contract is renewed early because it ran out of
+	// funds. It only affects how the renewal is recorded in the audit log.
+	Refresh bool
+
+	// Arbiter is added to the unlock conditions of a new contract if it is
+	// not empty, see modules.ContractUnlockConditions. Renewed contracts keep
+	// the unlock conditions of the contract they renew.
+	Arbiter types.SiaPublicKey
 	// TODO: add optional keypair
 }
 
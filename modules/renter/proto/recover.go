@@ -0,0 +1,136 @@
+package proto
+
+import (
+	"net"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/encoding"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+// RecoveryContracts returns the contracts of the set together with their
+// secret keys, which is everything needed to revise them without the set.
+func (cs *ContractSet) RecoveryContracts() []modules.RecoveryHintContract {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	contracts := make([]modules.RecoveryHintContract, 0, len(cs.contracts))
+	for _, sc := range cs.contracts {
+		sc.headerMu.Lock()
+		contracts = append(contracts, modules.RecoveryHintContract{
+			ID:            sc.header.ID(),
+			HostPublicKey: sc.header.HostPublicKey(),
+			EndHeight:     sc.header.EndHeight(),
+			SecretKey:     sc.header.SecretKey,
+		})
+		sc.headerMu.Unlock()
+	}
+	return contracts
+}
+
+// RecoverSector downloads a single sector from the host of a contract that is
+// not part of any contract set. Instead of comparing the most recent revision
+// with a local copy, the revision sent by the host is verified and used to
+// pay for the download. The revised contract isn't stored anywhere, the next
+// one to use the contract needs to fetch the revision from the host again.
+func RecoverSector(host modules.HostDBEntry, contract modules.RecoveryHintContract, root crypto.Hash, cancel <-chan struct{}) (_ []byte, err error) {
+	conn, err := (&net.Dialer{
+		Cancel:  cancel,
+		Timeout: connTimeout,
+	}).Dial("tcp", string(host.NetAddress))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	// fetch the most recent revision of the contract
+	extendDeadline(conn, modules.NegotiateRecentRevisionTime)
+	if err := encoding.WriteObject(conn, modules.RPCDownload); err != nil {
+		return nil, errors.New("couldn't initiate RPC: " + err.Error())
+	}
+	lastRevision, hostSignatures, err := readRecentRevision(conn, contract.ID, contract.SecretKey, host.Version)
+	if err != nil {
+		return nil, err
+	}
+	if lastRevision.ParentID != contract.ID {
+		return nil, errors.New("host sent the revision of a different contract")
+	} else if len(lastRevision.NewValidProofOutputs) != 2 || len(lastRevision.NewMissedProofOutputs) != 3 {
+		return nil, errors.New("host sent a revision with unexpected outputs")
+	}
+	if err := modules.VerifyFileContractRevisionTransactionSignatures(lastRevision, hostSignatures, contract.EndHeight-1); err != nil {
+		return nil, errors.AddContext(err, "host sent an invalid revision")
+	}
+
+	// calculate price
+	sectorPrice, err := downloadPrice(host, lastRevision.NewValidProofOutputs[0].Value, modules.SectorSize)
+	if err != nil {
+		return nil, err
+	}
+	rev := newDownloadRevision(lastRevision, sectorPrice)
+
+	// initiate download by confirming host settings
+	extendDeadline(conn, modules.NegotiateSettingsTime)
+	if err := startDownload(conn, host); err != nil {
+		return nil, err
+	}
+
+	// send download action and the revision paying for it
+	extendDeadline(conn, connTimeout)
+	err = encoding.WriteObject(conn, []modules.DownloadAction{{
+		MerkleRoot: root,
+		Offset:     0,
+		Length:     modules.SectorSize,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := negotiateRevision(conn, rev, contract.SecretKey); err != nil && err != modules.ErrStopResponse {
+		return nil, err
+	}
+
+	// read sector data
+	extendDeadline(conn, modules.NegotiateDownloadTime)
+	var sectors [][]byte
+	if err := encoding.ReadObject(conn, &sectors, modules.SectorSize+16); err != nil {
+		return nil, err
+	} else if len(sectors) != 1 || uint64(len(sectors[0])) != modules.SectorSize {
+		return nil, errors.New("host did not send enough sector data")
+	} else if crypto.MerkleRoot(sectors[0]) != root {
+		return nil, ErrBadSectorData
+	}
+	return sectors[0], nil
+}
+
+// RecoverContract adds a contract that is not part of the set to the set. The
+// most recent revision and the Merkle roots of the contract are fetched from
+// the host. How much was spent on the contract before it was lost is unknown,
+// so the remaining renter funds are recorded as its total cost and the height
+// the contract is recovered at as its start height.
+func (cs *ContractSet) RecoverContract(host modules.HostDBEntry, contract modules.RecoveryHintContract, height types.BlockHeight, cancel <-chan struct{}) (modules.RenterContract, error) {
+	if _, exists := cs.View(contract.ID); exists {
+		return modules.RenterContract{}, errors.New("contract is already part of the set")
+	}
+	lastRevision, hostSignatures, roots, err := fetchSectorRoots(host, contract.ID, contract.SecretKey, cancel)
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+	if len(lastRevision.NewValidProofOutputs) != 2 {
+		return modules.RenterContract{}, errors.New("host sent a revision with unexpected outputs")
+	}
+	header := contractHeader{
+		Transaction: types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{lastRevision},
+			TransactionSignatures: hostSignatures,
+		},
+		SecretKey:   contract.SecretKey,
+		StartHeight: height,
+		TotalCost:   lastRevision.NewValidProofOutputs[0].Value,
+		Utility: modules.ContractUtility{
+			GoodForUpload: true,
+			GoodForRenew:  true,
+		},
+	}
+	return cs.managedInsertContract(header, roots)
+}
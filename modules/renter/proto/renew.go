@@ -23,89 +23,27 @@ func (cs *ContractSet) Renew(oldContract *SafeContract, params ContractParams, t
 	ourSK := contract.SecretKey
 	lastRev := contract.LastRevision()
 
-	// Calculate additional basePrice and baseCollateral. If the contract height
-	// did not increase, basePrice and baseCollateral are zero.
-	var basePrice, baseCollateral types.Currency
-	if endHeight+host.WindowSize > lastRev.NewWindowEnd {
-		timeExtension := uint64((endHeight + host.WindowSize) - lastRev.NewWindowEnd)
-		basePrice = host.StoragePrice.Mul64(lastRev.NewFileSize).Mul64(timeExtension)    // cost of data already covered by contract, i.e. lastrevision.Filesize
-		baseCollateral = host.Collateral.Mul64(lastRev.NewFileSize).Mul64(timeExtension) // same but collateral
-	}
-
 	// Calculate the anticipated transaction fee.
 	_, maxFee := tpool.FeeEstimation()
 	txnFee := maxFee.Mul64(modules.EstimatedFileContractTransactionSetSize)
 
-	// Underflow check.
-	if funding.Cmp(host.ContractPrice.Add(txnFee).Add(basePrice)) <= 0 {
-		return modules.RenterContract{}, errors.New("insufficient funds to cover contract fee and transaction fee during contract renewal")
-	}
-	// Divide by zero check.
-	if host.StoragePrice.IsZero() {
-		host.StoragePrice = types.NewCurrency64(1)
-	}
-
-	// Calculate the payouts for the renter, host, and whole contract.
-	renterPayout := funding.Sub(host.ContractPrice).Sub(txnFee).Sub(basePrice) // renter payout is pre-tax
-	maxStorageSize := renterPayout.Div(host.StoragePrice)
-	hostCollateral := maxStorageSize.Mul(host.Collateral)
-	if hostCollateral.Cmp(host.MaxCollateral) > 0 {
-		hostCollateral = host.MaxCollateral
-	}
-
-	// Determine the host payout and the total payout for the contract.
-	hostPayout := hostCollateral.Add(host.ContractPrice).Add(basePrice)
-	totalPayout := renterPayout.Add(hostPayout)
-
-	// check for negative currency
-	if totalPayout.Cmp(hostPayout) < 0 {
-		return modules.RenterContract{}, errors.New("insufficient funds to pay both siafund fee and also host payout")
-	} else if hostCollateral.Cmp(baseCollateral) < 0 {
-		return modules.RenterContract{}, errors.New("new collateral smaller than base collateral")
-	}
-
-	// create file contract
-	fc := types.FileContract{
-		FileSize:       lastRev.NewFileSize,
-		FileMerkleRoot: lastRev.NewFileMerkleRoot,
-		WindowStart:    endHeight,
-		WindowEnd:      endHeight + host.WindowSize,
-		Payout:         totalPayout,
-		UnlockHash:     lastRev.NewUnlockHash,
-		RevisionNumber: 0,
-		ValidProofOutputs: []types.SiacoinOutput{
-			// renter
-			{Value: totalPayout.Sub(hostPayout), UnlockHash: refundAddress},
-			// host
-			{Value: hostPayout, UnlockHash: host.UnlockHash},
-		},
-		MissedProofOutputs: []types.SiacoinOutput{
-			// renter
-			{Value: totalPayout.Sub(hostPayout), UnlockHash: refundAddress},
-			// host gets its unused collateral back, plus the contract price
-			{Value: hostCollateral.Sub(baseCollateral).Add(host.ContractPrice), UnlockHash: host.UnlockHash},
-			// void gets the spent storage fees, plus the collateral being risked
-			{Value: basePrice.Add(baseCollateral), UnlockHash: types.UnlockHash{}},
-		},
+	// Check that the funding covers the renewal at the prices known to the
+	// hostdb before contacting the host. The contract itself is created
+	// with the prices the host sends during the settings exchange, which
+	// can be lower than the regular prices if the host grants a renewal
+	// discount.
+	if _, _, err := renewalContract(host, lastRev, funding, endHeight, refundAddress, txnFee); err != nil {
+		return modules.RenterContract{}, err
 	}
 
-	// build transaction containing fc
+	// fund the transaction
 	err = txnBuilder.FundSiacoins(funding)
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
-	txnBuilder.AddFileContract(fc)
 	// add miner fee
 	txnBuilder.AddMinerFee(txnFee)
 
-	// Create initial transaction set.
-	txn, parentTxns := txnBuilder.View()
-	unconfirmedParents, err := txnBuilder.UnconfirmedParents()
-	if err != nil {
-		return modules.RenterContract{}, err
-	}
-	txnSet := append(unconfirmedParents, append(parentTxns, txn)...)
-
 	// Increase Successful/Failed interactions accordingly
 	defer func() {
 		// A revision mismatch might not be the host's fault.
@@ -128,13 +66,13 @@ func (cs *ContractSet) Renew(oldContract *SafeContract, params ContractParams, t
 	}
 	defer func() { _ = conn.Close() }()
 
-	// allot time for sending RPC ID, verifyRecentRevision, and verifySettings
+	// allot time for sending RPC ID, managedVerifyRecentRevision, and verifySettings
 	extendDeadline(conn, modules.NegotiateRecentRevisionTime+modules.NegotiateSettingsTime)
 	if err = encoding.WriteObject(conn, modules.RPCRenewContract); err != nil {
 		return modules.RenterContract{}, errors.New("couldn't initiate RPC: " + err.Error())
 	}
 	// verify that both parties are renewing the same contract
-	err = verifyRecentRevision(conn, oldContract, host.Version)
+	err = cs.managedVerifyRecentRevision(conn, oldContract, host.Version)
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
@@ -148,6 +86,27 @@ func (cs *ContractSet) Renew(oldContract *SafeContract, params ContractParams, t
 		return modules.RenterContract{}, errors.New("host is not accepting contracts")
 	}
 
+	// The host might lower its prices for the renewal, but it must not ask
+	// for more than the prices the host was picked with.
+	if host.ContractPrice.Cmp(params.Host.ContractPrice) > 0 || host.StoragePrice.Cmp(params.Host.StoragePrice) > 0 {
+		return modules.RenterContract{}, errors.New("host raised its prices during the renewal")
+	}
+
+	// create the file contract using the negotiated prices
+	fc, basePrice, err := renewalContract(host, lastRev, funding, endHeight, refundAddress, txnFee)
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+	txnBuilder.AddFileContract(fc)
+
+	// Create initial transaction set.
+	txn, parentTxns := txnBuilder.View()
+	unconfirmedParents, err := txnBuilder.UnconfirmedParents()
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+	txnSet := append(unconfirmedParents, append(parentTxns, txn)...)
+
 	// allot time for negotiation
 	extendDeadline(conn, modules.NegotiateRenewContractTime)
 
@@ -281,6 +240,10 @@ func (cs *ContractSet) Renew(oldContract *SafeContract, params ContractParams, t
 			GoodForRenew:  true,
 		},
 	}
+	// The metadata is carried over to the renewed contract.
+	oldContract.headerMu.Lock()
+	header.Metadata = oldContract.header.Metadata
+	oldContract.headerMu.Unlock()
 
 	// Get old roots
 	oldRoots, err := oldContract.merkleRoots.merkleRoots()
@@ -293,5 +256,80 @@ func (cs *ContractSet) Renew(oldContract *SafeContract, params ContractParams, t
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
+	category := modules.AuditCategoryRenewal
+	if params.Refresh {
+		category = modules.AuditCategoryRefresh
+	}
+	if err := cs.managedRecordAudit(category, meta, 0, header.ContractFee.Add(header.TxnFee).Add(header.StorageSpending)); err != nil {
+		return modules.RenterContract{}, err
+	}
 	return meta, nil
 }
+
+// renewalContract creates the file contract of a renewal from the last
+// revision of the old contract and the prices of the host. The base price of
+// the storage that is already covered by the old contract is returned as well.
+func renewalContract(host modules.HostDBEntry, lastRev types.FileContractRevision, funding types.Currency, endHeight types.BlockHeight, refundAddress types.UnlockHash, txnFee types.Currency) (types.FileContract, types.Currency, error) {
+	// Calculate additional basePrice and baseCollateral. If the contract height
+	// did not increase, basePrice and baseCollateral are zero.
+	var basePrice, baseCollateral types.Currency
+	if endHeight+host.WindowSize > lastRev.NewWindowEnd {
+		timeExtension := uint64((endHeight + host.WindowSize) - lastRev.NewWindowEnd)
+		basePrice = host.StoragePrice.Mul64(lastRev.NewFileSize).Mul64(timeExtension)    // cost of data already covered by contract, i.e. lastrevision.Filesize
+		baseCollateral = host.Collateral.Mul64(lastRev.NewFileSize).Mul64(timeExtension) // same but collateral
+	}
+
+	// Underflow check.
+	if funding.Cmp(host.ContractPrice.Add(txnFee).Add(basePrice)) <= 0 {
+		return types.FileContract{}, types.ZeroCurrency, errors.New("insufficient funds to cover contract fee and transaction fee during contract renewal")
+	}
+	// Divide by zero check.
+	if host.StoragePrice.IsZero() {
+		host.StoragePrice = types.NewCurrency64(1)
+	}
+
+	// Calculate the payouts for the renter, host, and whole contract.
+	renterPayout := funding.Sub(host.ContractPrice).Sub(txnFee).Sub(basePrice) // renter payout is pre-tax
+	maxStorageSize := renterPayout.Div(host.StoragePrice)
+	hostCollateral := maxStorageSize.Mul(host.Collateral)
+	if hostCollateral.Cmp(host.MaxCollateral) > 0 {
+		hostCollateral = host.MaxCollateral
+	}
+
+	// Determine the host payout and the total payout for the contract.
+	hostPayout := hostCollateral.Add(host.ContractPrice).Add(basePrice)
+	totalPayout := renterPayout.Add(hostPayout)
+
+	// check for negative currency
+	if totalPayout.Cmp(hostPayout) < 0 {
+		return types.FileContract{}, types.ZeroCurrency, errors.New("insufficient funds to pay both siafund fee and also host payout")
+	} else if hostCollateral.Cmp(baseCollateral) < 0 {
+		return types.FileContract{}, types.ZeroCurrency, errors.New("new collateral smaller than base collateral")
+	}
+
+	// create file contract
+	fc := types.FileContract{
+		FileSize:       lastRev.NewFileSize,
+		FileMerkleRoot: lastRev.NewFileMerkleRoot,
+		WindowStart:    endHeight,
+		WindowEnd:      endHeight + host.WindowSize,
+		Payout:         totalPayout,
+		UnlockHash:     lastRev.NewUnlockHash,
+		RevisionNumber: 0,
+		ValidProofOutputs: []types.SiacoinOutput{
+			// renter
+			{Value: totalPayout.Sub(hostPayout), UnlockHash: refundAddress},
+			// host
+			{Value: hostPayout, UnlockHash: host.UnlockHash},
+		},
+		MissedProofOutputs: []types.SiacoinOutput{
+			// renter
+			{Value: totalPayout.Sub(hostPayout), UnlockHash: refundAddress},
+			// host gets its unused collateral back, plus the contract price
+			{Value: hostCollateral.Sub(baseCollateral).Add(host.ContractPrice), UnlockHash: host.UnlockHash},
+			// void gets the spent storage fees, plus the collateral being risked
+			{Value: basePrice.Add(baseCollateral), UnlockHash: types.UnlockHash{}},
+		},
+	}
+	return fc, basePrice, nil
+}
@@ -0,0 +1,77 @@
+package proto
+
+import (
+	"net"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/encoding"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+// SectorRoots asks the host for the Merkle roots of all sectors it stores
+// under the contract. Unlike the roots of the local copy of the contract,
+// which may be incomplete after the renter's metadata was restored, the roots
+// are verified against the most recent revision signed by the host.
+func (cs *ContractSet) SectorRoots(host modules.HostDBEntry, id types.FileContractID, cancel <-chan struct{}) (_ []crypto.Hash, err error) {
+	sc, ok := cs.Acquire(id)
+	if !ok {
+		return nil, errors.New("no contract with that id")
+	}
+	sc.headerMu.Lock()
+	sk := sc.header.SecretKey
+	sc.headerMu.Unlock()
+	cs.Return(sc)
+
+	_, _, roots, err := fetchSectorRoots(host, id, sk, cancel)
+	return roots, err
+}
+
+// fetchSectorRoots fetches the most recent revision of the contract and the
+// Merkle roots of its sectors from the host, using the secret key to prove
+// that the renter is a party to the contract. The revision is verified against
+// the host's signatures and the roots against the revision.
+func fetchSectorRoots(host modules.HostDBEntry, id types.FileContractID, sk crypto.SecretKey, cancel <-chan struct{}) (_ types.FileContractRevision, _ []types.TransactionSignature, _ []crypto.Hash, err error) {
+	conn, err := (&net.Dialer{
+		Cancel:  cancel,
+		Timeout: connTimeout,
+	}).Dial("tcp", string(host.NetAddress))
+	if err != nil {
+		return types.FileContractRevision{}, nil, nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	// fetch the most recent revision of the contract
+	extendDeadline(conn, modules.NegotiateRecentRevisionTime)
+	if err := encoding.WriteObject(conn, modules.RPCSectorRoots); err != nil {
+		return types.FileContractRevision{}, nil, nil, errors.New("couldn't initiate RPC: " + err.Error())
+	}
+	lastRevision, hostSignatures, err := readRecentRevision(conn, id, sk, host.Version)
+	if err != nil {
+		return types.FileContractRevision{}, nil, nil, err
+	}
+	if lastRevision.ParentID != id {
+		return types.FileContractRevision{}, nil, nil, errors.New("host sent the revision of a different contract")
+	}
+	if err := modules.VerifyFileContractRevisionTransactionSignatures(lastRevision, hostSignatures, lastRevision.NewWindowStart-1); err != nil {
+		return types.FileContractRevision{}, nil, nil, errors.AddContext(err, "host sent an invalid revision")
+	}
+
+	// read the roots and check that they match the revision; the host
+	// rejects the request if the roots were requested too often recently
+	extendDeadline(conn, modules.NegotiateSectorRootsTime)
+	if err := modules.ReadNegotiationAcceptance(conn); err != nil {
+		return types.FileContractRevision{}, nil, nil, errors.AddContext(err, "host rejected the sector roots request")
+	}
+	numRoots := lastRevision.NewFileSize / modules.SectorSize
+	var roots []crypto.Hash
+	if err := encoding.ReadObject(conn, &roots, 8+numRoots*crypto.HashSize); err != nil {
+		return types.FileContractRevision{}, nil, nil, errors.AddContext(err, "couldn't read sector roots")
+	}
+	if uint64(len(roots)) != numRoots || (numRoots > 0 && cachedMerkleRoot(roots) != lastRevision.NewFileMerkleRoot) {
+		return types.FileContractRevision{}, nil, nil, errors.New("host sent sector roots that don't match the contract")
+	}
+	return lastRevision, hostSignatures, roots, nil
+}
@@ -0,0 +1,119 @@
+package renter
+
+import (
+	"bytes"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+var (
+	// errReassociateNoContract is returned when re-associating a contract
+	// that isn't part of the active contract set.
+	errReassociateNoContract = errors.New("no active contract with that id")
+)
+
+// reassociatePieces adds the host to every piece of the file that has a copy
+// among the host's sectors but isn't known to be stored on the host. The
+// sectors that are referenced by the file are marked in 'held'. It returns the
+// number of chunks and pieces that were re-associated.
+func reassociatePieces(f *siafile.SiaFile, hostKey types.SiaPublicKey, held map[crypto.Hash]bool) (chunks, pieces uint64, err error) {
+	for chunkIndex := uint64(0); chunkIndex < f.NumChunks(); chunkIndex++ {
+		pieceSets, err := f.Pieces(chunkIndex)
+		if err != nil {
+			return chunks, pieces, err
+		}
+		added := false
+		for pieceIndex, pieceSet := range pieceSets {
+			var root crypto.Hash
+			found, onHost := false, false
+			for _, piece := range pieceSet {
+				if _, ok := held[piece.MerkleRoot]; !ok {
+					continue
+				}
+				held[piece.MerkleRoot] = true
+				if bytes.Equal(piece.HostPubKey.Key, hostKey.Key) {
+					onHost = true
+				} else {
+					root, found = piece.MerkleRoot, true
+				}
+			}
+			if !found || onHost {
+				continue
+			}
+			if err := f.AddPiece(hostKey, chunkIndex, uint64(pieceIndex), root); err != nil {
+				return chunks, pieces, err
+			}
+			added = true
+			pieces++
+		}
+		if added {
+			chunks++
+		}
+	}
+	return chunks, pieces, nil
+}
+
+// ReassociateContract fetches the Merkle roots of the sectors that the host
+// stores under a contract and adds the host to the pieces of every file that
+// references one of these sectors. This restores the mapping from files to
+// hosts if the contract was recovered but the files weren't updated. Sectors
+// that no file references are reported as orphaned.
+func (r *Renter) ReassociateContract(id types.FileContractID) (modules.RenterContractReassociation, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterContractReassociation{}, err
+	}
+	defer r.tg.Done()
+
+	var hostKey types.SiaPublicKey
+	var found bool
+	for _, c := range r.hostContractor.Contracts() {
+		if c.ID == id {
+			hostKey, found = c.HostPublicKey, true
+			break
+		}
+	}
+	if !found {
+		return modules.RenterContractReassociation{}, errReassociateNoContract
+	}
+	roots, err := r.hostContractor.SectorRoots(id, r.tg.StopChan())
+	if err != nil {
+		return modules.RenterContractReassociation{}, errors.AddContext(err, "unable to fetch the sector roots from the host")
+	}
+	held := make(map[crypto.Hash]bool, len(roots))
+	for _, root := range roots {
+		held[root] = false
+	}
+
+	lockID := r.mu.RLock()
+	files := make([]*siafile.SiaFile, 0, len(r.files))
+	for _, file := range r.files {
+		files = append(files, file)
+	}
+	r.mu.RUnlock(lockID)
+
+	var result modules.RenterContractReassociation
+	for _, f := range files {
+		chunks, pieces, err := reassociatePieces(f, hostKey, held)
+		result.ChunksReassociated += chunks
+		result.PiecesReassociated += pieces
+		if pieces > 0 {
+			result.Files = append(result.Files, f.SiaPath())
+		}
+		if err != nil {
+			return result, errors.AddContext(err, "unable to re-associate the pieces of "+f.SiaPath())
+		}
+	}
+	for _, root := range roots {
+		if !held[root] {
+			result.OrphanedSectors = append(result.OrphanedSectors, root)
+			held[root] = true // report sectors that are stored twice once
+		}
+	}
+	r.log.Printf("Re-associated %v chunks with contract %v, %v sectors are orphaned", result.ChunksReassociated, id, len(result.OrphanedSectors))
+	return result, nil
+}
@@ -0,0 +1,59 @@
+package renter
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestReassociatePieces checks that a host is added to the pieces whose
+// sectors it stores, and that the sectors referenced by the file are marked.
+func TestReassociatePieces(t *testing.T) {
+	rsc, _ := siafile.NewRSCode(1, 1)
+	f := newFileTesting(t.Name(), newTestingWal(), rsc, 1000, 0777, "")
+	oldHost := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	newHost := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{2}}
+
+	// The file stores both pieces of its chunk on a host it lost the contract
+	// with. The recovered contract stores the first piece and a sector that
+	// isn't referenced by any file.
+	first, second, orphan := crypto.Hash{1}, crypto.Hash{2}, crypto.Hash{3}
+	if err := f.AddPiece(oldHost, 0, 0, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.AddPiece(oldHost, 0, 1, second); err != nil {
+		t.Fatal(err)
+	}
+	held := map[crypto.Hash]bool{first: false, orphan: false}
+	chunks, pieces, err := reassociatePieces(f, newHost, held)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunks != 1 || pieces != 1 {
+		t.Fatalf("expected 1 chunk and 1 piece to be re-associated, got %v and %v", chunks, pieces)
+	}
+	if !held[first] || held[orphan] {
+		t.Fatal("referenced sectors weren't marked correctly", held)
+	}
+	pieceSets, err := f.Pieces(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pieceSets[0]) != 2 || pieceSets[0][1].HostPubKey.String() != newHost.String() || pieceSets[0][1].MerkleRoot != first {
+		t.Fatal("host wasn't added to the first piece", pieceSets[0])
+	}
+	if len(pieceSets[1]) != 1 {
+		t.Fatal("host was added to a piece it doesn't store", pieceSets[1])
+	}
+
+	// Re-associating again doesn't add the host twice.
+	chunks, pieces, err = reassociatePieces(f, newHost, held)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunks != 0 || pieces != 0 {
+		t.Fatalf("expected nothing to be re-associated, got %v chunks and %v pieces", chunks, pieces)
+	}
+}
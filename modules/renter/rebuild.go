@@ -0,0 +1,271 @@
+package renter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+var (
+	// errNoRebuild is returned when requesting the rebuild status of a file
+	// that was never rebuilt.
+	errNoRebuild = errors.New("the file hasn't been rebuilt")
+
+	// errRebuildActive is returned if a rebuild is requested for a file that
+	// is already being rebuilt.
+	errRebuildActive = errors.New("the file is already being rebuilt")
+
+	// errRebuildInsufficientWorkers is returned if there aren't enough workers
+	// to bring a file to full redundancy.
+	errRebuildInsufficientWorkers = errors.New("not enough workers to rebuild the file")
+
+	// errRebuildRekeying is returned if a rebuild is requested for a file whose
+	// masterkey is being rotated. The rekey repairs the file once it is done.
+	errRebuildRekeying = errors.New("can't rebuild a file while it is being rekeyed")
+)
+
+// fileRebuild tracks a user-requested rebuild of a file. The rebuild thread
+// hands the chunks of the file to the workers, and the chunks report back once
+// the workers are done with them.
+type fileRebuild struct {
+	cancel    chan struct{}
+	cancelled bool
+
+	chunksFailed   uint64
+	chunksFinished uint64
+	chunksPending  uint64 // chunks handed to the workers that haven't finished yet.
+	chunksTotal    uint64
+	queued         bool // whether all chunks have been handed to the workers.
+
+	mu sync.Mutex
+}
+
+// newFileRebuild creates the tracker for a rebuild of a file with the given
+// number of chunks.
+func newFileRebuild(chunks uint64) *fileRebuild {
+	return &fileRebuild{
+		cancel:      make(chan struct{}),
+		chunksTotal: chunks,
+	}
+}
+
+// active returns true if the rebuild is still queuing or repairing chunks.
+func (rb *fileRebuild) active() bool {
+	return !rb.queued || rb.chunksPending > 0
+}
+
+// managedCancel stops the rebuild from handing any more chunks to the workers.
+func (rb *fileRebuild) managedCancel() {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	if !rb.cancelled {
+		rb.cancelled = true
+		close(rb.cancel)
+	}
+}
+
+// managedChunkFinished is called once the workers are done with a chunk of the
+// rebuild.
+func (rb *fileRebuild) managedChunkFinished(fullyRepaired bool) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.chunksPending--
+	rb.chunksFinished++
+	if !fullyRepaired {
+		rb.chunksFailed++
+	}
+}
+
+// managedChunkQueued is called before a chunk of the rebuild is handed to the
+// workers.
+func (rb *fileRebuild) managedChunkQueued() {
+	rb.mu.Lock()
+	rb.chunksPending++
+	rb.mu.Unlock()
+}
+
+// managedChunkSkipped is called for chunks which are already at full
+// redundancy.
+func (rb *fileRebuild) managedChunkSkipped() {
+	rb.mu.Lock()
+	rb.chunksFinished++
+	rb.mu.Unlock()
+}
+
+// managedDoneQueuing marks the rebuild as done handing out chunks.
+func (rb *fileRebuild) managedDoneQueuing() {
+	rb.mu.Lock()
+	rb.queued = true
+	rb.mu.Unlock()
+}
+
+// managedStatus returns the progress of the rebuild.
+func (rb *fileRebuild) managedStatus() modules.FileRebuildStatus {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return modules.FileRebuildStatus{
+		Active:         rb.active(),
+		Cancelled:      rb.cancelled,
+		ChunksFailed:   rb.chunksFailed,
+		ChunksFinished: rb.chunksFinished,
+		ChunksTotal:    rb.chunksTotal,
+	}
+}
+
+// managedRebuild returns the most recent rebuild of the file at siaPath.
+func (r *Renter) managedRebuild(siaPath string) (*siafile.SiaFile, *fileRebuild, error) {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	file, exists := r.files[siaPath]
+	if !exists {
+		return nil, nil, ErrUnknownPath
+	}
+	return file, r.fileRebuilds[file.UID()], nil
+}
+
+// CancelRebuild stops an active rebuild of a file. Chunks which were already
+// handed to the workers are still finished.
+func (r *Renter) CancelRebuild(siaPath string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	_, rb, err := r.managedRebuild(siaPath)
+	if err != nil {
+		return err
+	}
+	if rb == nil || !rb.managedStatus().Active {
+		return errors.New("the file isn't being rebuilt")
+	}
+	rb.managedCancel()
+	return nil
+}
+
+// RebuildFile repairs every chunk of a file that isn't at full redundancy,
+// regardless of the repair threshold. Chunks that aren't available locally
+// are downloaded from the hosts and the missing pieces are uploaded to hosts
+// that don't store a piece of the chunk yet. The rebuild happens in the
+// background, its progress can be retrieved with RebuildStatus.
+func (r *Renter) RebuildFile(siaPath string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	file, _, err := r.managedRebuild(siaPath)
+	if err != nil {
+		return err
+	}
+	if file.Rekeying() {
+		return errRebuildRekeying
+	}
+	hosts := r.managedRefreshHostsAndWorkers()
+	id := r.mu.Lock()
+	minWorkers, _ := r.minUploadWorkers(file)
+	if len(r.workerPool) < minWorkers {
+		r.mu.Unlock(id)
+		return errRebuildInsufficientWorkers
+	}
+	if rb := r.fileRebuilds[file.UID()]; rb != nil && rb.managedStatus().Active {
+		r.mu.Unlock(id)
+		return errRebuildActive
+	}
+	rb := newFileRebuild(file.NumChunks())
+	r.fileRebuilds[file.UID()] = rb
+	chunks := r.buildUnfinishedChunks(file, hosts, r.redundancyGroups())
+	r.mu.Unlock(id)
+
+	go r.threadedRebuildFile(file, rb, chunks, hosts)
+	return nil
+}
+
+// RebuildStatus returns the progress of the most recent rebuild of a file.
+func (r *Renter) RebuildStatus(siaPath string) (modules.FileRebuildStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.FileRebuildStatus{}, err
+	}
+	defer r.tg.Done()
+
+	_, rb, err := r.managedRebuild(siaPath)
+	if err != nil {
+		return modules.FileRebuildStatus{}, err
+	}
+	if rb == nil {
+		return modules.FileRebuildStatus{}, errNoRebuild
+	}
+	return rb.managedStatus(), nil
+}
+
+// threadedRebuildFile hands the unfinished chunks of a file to the workers one
+// at a time. Chunks that are currently being repaired by the repair loop are
+// waited for and rebuilt afterwards, since the repair loop might not bring
+// them to full redundancy.
+func (r *Renter) threadedRebuildFile(file *siafile.SiaFile, rb *fileRebuild, chunks []*unfinishedUploadChunk, hosts map[string]struct{}) {
+	defer rb.managedDoneQueuing()
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	unfinished := make(map[uint64]*unfinishedUploadChunk)
+	for _, chunk := range chunks {
+		unfinished[chunk.index] = chunk
+	}
+	for chunkIndex := uint64(0); chunkIndex < file.NumChunks(); chunkIndex++ {
+		chunk, exists := unfinished[chunkIndex]
+		if !exists {
+			rb.managedChunkSkipped()
+			continue
+		}
+		stale := false
+		for !r.uploadHeap.managedTryActivate(chunk.id) {
+			stale = true
+			select {
+			case <-rb.cancel:
+				return
+			case <-r.tg.StopChan():
+				return
+			case <-time.After(rebuildActiveChunkInterval):
+			}
+		}
+		select {
+		case <-rb.cancel:
+			r.uploadHeap.mu.Lock()
+			delete(r.uploadHeap.activeChunks, chunk.id)
+			r.uploadHeap.mu.Unlock()
+			return
+		default:
+		}
+
+		// The repair loop might have uploaded pieces of the chunk while we
+		// were waiting, so the chunk needs to be rebuilt to avoid uploading
+		// pieces to hosts which already store one.
+		if stale {
+			id := r.mu.Lock()
+			refreshed := r.buildUnfinishedChunks(file, hosts, r.redundancyGroups())
+			r.mu.Unlock(id)
+			chunk = nil
+			for _, c := range refreshed {
+				if c.index == chunkIndex {
+					chunk = c
+					break
+				}
+			}
+			if chunk == nil {
+				r.uploadHeap.mu.Lock()
+				delete(r.uploadHeap.activeChunks, chunkUploadID(file, chunkIndex))
+				r.uploadHeap.mu.Unlock()
+				rb.managedChunkSkipped()
+				continue
+			}
+		}
+		chunk.rebuild = rb
+		rb.managedChunkQueued()
+		r.managedPrepareNextChunk(chunk, hosts)
+	}
+}
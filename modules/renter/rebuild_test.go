@@ -0,0 +1,46 @@
+package renter
+
+import (
+	"testing"
+)
+
+// TestFileRebuildStatus checks that a file rebuild stays active until all of
+// its chunks are done and that chunks which couldn't be fully repaired are
+// reported.
+func TestFileRebuildStatus(t *testing.T) {
+	rb := newFileRebuild(3)
+	if status := rb.managedStatus(); !status.Active || status.ChunksTotal != 3 {
+		t.Fatal("unexpected status of new rebuild", status)
+	}
+
+	// One chunk is already at full redundancy, the other two are queued.
+	rb.managedChunkSkipped()
+	rb.managedChunkQueued()
+	rb.managedChunkQueued()
+	rb.managedDoneQueuing()
+	if status := rb.managedStatus(); !status.Active || status.ChunksFinished != 1 {
+		t.Fatal("rebuild should be active until the queued chunks are done", status)
+	}
+	rb.managedChunkFinished(true)
+	rb.managedChunkFinished(false)
+	status := rb.managedStatus()
+	if status.Active {
+		t.Fatal("rebuild should be done")
+	}
+	if status.ChunksFinished != 3 || status.ChunksFailed != 1 {
+		t.Fatal("unexpected chunk counts", status)
+	}
+
+	// Cancelling twice shouldn't panic.
+	rb = newFileRebuild(1)
+	rb.managedCancel()
+	rb.managedCancel()
+	select {
+	case <-rb.cancel:
+	default:
+		t.Fatal("cancel channel should be closed")
+	}
+	if !rb.managedStatus().Cancelled {
+		t.Fatal("rebuild should be cancelled")
+	}
+}
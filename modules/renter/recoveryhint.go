@@ -0,0 +1,452 @@
+package renter
+
+// A recovery hint lists the contracts of the renter together with their secret
+// keys, so that a renter that lost all of its local state can locate its
+// contracts and hosts from the wallet seed alone.
+//
+// The hint is encrypted with a key derived from the seed, padded to a full
+// sector and uploaded to recoveryHintRedundancy hosts. To find it again, a
+// pointer is published in the arbitrary data of a transaction. The pointer
+// starts with modules.PrefixNonSia, which makes it a standard transaction,
+// recoveryHintSpecifier and an identifier derived from the seed, followed by
+// the encrypted Merkle root of the sector and the contracts with the hosts
+// storing it. Downloading the hint only needs one of these contracts, which
+// the restoring renter doesn't have in its contract set.
+//
+// No file references the sector of the hint, so the renter pins the sector of
+// the most recent hint it stored or restored. Pinned sectors count as
+// referenced by their hosts, which keeps them out of the orphaned sectors.
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/encoding"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+var (
+	// recoveryHintSpecifier prefixes the arbitrary data of the transactions
+	// that point to a recovery hint.
+	recoveryHintSpecifier = types.Specifier{'R', 'e', 'c', 'o', 'v', 'e', 'r', 'y', 'H', 'i', 'n', 't'}
+
+	errRecoveryHintNoContracts = errors.New("renter has no contracts to store a recovery hint with")
+	errRecoveryHintNoHosts     = errors.New("unable to store the recovery hint on any host")
+	errRecoveryHintNotFound    = errors.New("no recovery hint of the seed was found on the blockchain")
+	errRecoveryHintNotSaved    = errors.New("no recovery hint was synced or restored yet")
+	errRecoveryHintNotSynced   = errors.New("consensus set needs to be synced before a recovery hint can be restored")
+	errRecoveryHintSpv         = errors.New("recovery hints can't be restored in spv mode, finding the pointer requires the full blocks")
+	errRecoveryHintTooLarge    = errors.New("recovery hint doesn't fit into a sector")
+)
+
+// recoveryHintPointer locates a recovery hint. It is published on the
+// blockchain.
+type recoveryHintPointer struct {
+	SectorRoot crypto.Hash
+	Contracts  []modules.RecoveryHintContract
+}
+
+// recoveryHintKeys derives the identifier that marks the pointers of a seed on
+// the blockchain and the key that the hint and its pointers are encrypted
+// with.
+func recoveryHintKeys(seed modules.Seed) (crypto.Hash, crypto.CipherKey) {
+	id := crypto.HashAll(recoveryHintSpecifier, "id", seed)
+	key := crypto.NewWalletKey(crypto.HashAll(recoveryHintSpecifier, "key", seed))
+	return id, key
+}
+
+// encodeRecoveryHint encrypts the hint and pads it to a sector.
+func encodeRecoveryHint(hint modules.RenterRecoveryHint, key crypto.CipherKey) ([]byte, error) {
+	data := encoding.Marshal(key.EncryptBytes(encoding.Marshal(hint)))
+	if uint64(len(data)) > modules.SectorSize {
+		return nil, errRecoveryHintTooLarge
+	}
+	sector := make([]byte, modules.SectorSize)
+	copy(sector, data)
+	return sector, nil
+}
+
+// decodeRecoveryHint decrypts the hint stored in a sector.
+func decodeRecoveryHint(sector []byte, key crypto.CipherKey) (modules.RenterRecoveryHint, error) {
+	var ct crypto.Ciphertext
+	if err := encoding.NewDecoder(bytes.NewReader(sector)).Decode(&ct); err != nil {
+		return modules.RenterRecoveryHint{}, errors.AddContext(err, "unable to read recovery hint")
+	}
+	plaintext, err := key.DecryptBytes(ct)
+	if err != nil {
+		return modules.RenterRecoveryHint{}, errors.AddContext(err, "unable to decrypt recovery hint")
+	}
+	var hint modules.RenterRecoveryHint
+	if err := encoding.Unmarshal(plaintext, &hint); err != nil {
+		return modules.RenterRecoveryHint{}, errors.AddContext(err, "unable to decode recovery hint")
+	}
+	return hint, nil
+}
+
+// encodeRecoveryHintPointer returns the arbitrary data of a pointer.
+func encodeRecoveryHintPointer(pointer recoveryHintPointer, id crypto.Hash, key crypto.CipherKey) []byte {
+	return encoding.MarshalAll(modules.PrefixNonSia, recoveryHintSpecifier, id, key.EncryptBytes(encoding.Marshal(pointer)))
+}
+
+// decodeRecoveryHintPointer decodes the pointer in the arbitrary data of a
+// transaction. False is returned if the data isn't a pointer of the seed the
+// identifier and the key were derived from.
+func decodeRecoveryHintPointer(arb []byte, id crypto.Hash, key crypto.CipherKey) (recoveryHintPointer, bool) {
+	var prefix, specifier types.Specifier
+	var pointerID crypto.Hash
+	var ct crypto.Ciphertext
+	if err := encoding.UnmarshalAll(arb, &prefix, &specifier, &pointerID, &ct); err != nil {
+		return recoveryHintPointer{}, false
+	} else if prefix != modules.PrefixNonSia || specifier != recoveryHintSpecifier || pointerID != id {
+		return recoveryHintPointer{}, false
+	}
+	plaintext, err := key.DecryptBytes(ct)
+	if err != nil {
+		return recoveryHintPointer{}, false
+	}
+	var pointer recoveryHintPointer
+	if err := encoding.Unmarshal(plaintext, &pointer); err != nil {
+		return recoveryHintPointer{}, false
+	}
+	return pointer, true
+}
+
+// managedSaveRecoveryHint merges the hint with the one that was saved before
+// and writes it to the renter directory. The sector of the hint is pinned
+// with the given hosts, which unpins the sector of the previous hint.
+func (r *Renter) managedSaveRecoveryHint(hint modules.RenterRecoveryHint, root crypto.Hash, hosts []types.SiaPublicKey) (modules.RenterRecoveryHint, error) {
+	saved, _, err := r.managedLoadRecoveryHint()
+	if err != nil {
+		return modules.RenterRecoveryHint{}, err
+	}
+	hint = mergeRecoveryHints(saved, hint)
+	if err := encoding.WriteFile(filepath.Join(r.persistDir, recoveryHintFile), hint); err != nil {
+		return modules.RenterRecoveryHint{}, errors.AddContext(err, "unable to save recovery hint")
+	}
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	r.persist.RecoveryHintSector = root
+	r.persist.RecoveryHintHosts = hosts
+	if err := r.saveSync(); err != nil {
+		return modules.RenterRecoveryHint{}, errors.AddContext(err, "unable to pin the recovery hint")
+	}
+	return hint, nil
+}
+
+// addRecoveryHint adds the pinned sector of the recovery hint to the
+// references.
+func (refs sectorReferences) addRecoveryHint(root crypto.Hash, hosts []types.SiaPublicKey) {
+	if root == (crypto.Hash{}) {
+		return
+	}
+	for _, host := range hosts {
+		refs[root] = append(refs[root], host.String())
+	}
+}
+
+// managedStoreRecoveryHint uploads the sector containing the hint to a host
+// and returns its Merkle root together with the contract it is stored under.
+func (r *Renter) managedStoreRecoveryHint(hostKey types.SiaPublicKey, sector []byte) (crypto.Hash, types.FileContractID, error) {
+	editor, err := r.hostContractor.Editor(hostKey, r.tg.StopChan())
+	if err != nil {
+		return crypto.Hash{}, types.FileContractID{}, err
+	}
+	defer editor.Close()
+	root, err := editor.Upload(sector)
+	return root, editor.ContractID(), err
+}
+
+// managedPublishRecoveryHintPointer publishes the pointer in a transaction
+// that is funded by the wallet.
+func (r *Renter) managedPublishRecoveryHintPointer(pointer recoveryHintPointer, id crypto.Hash, key crypto.CipherKey) (_ types.TransactionID, err error) {
+	txnBuilder, err := r.wallet.StartTransaction()
+	if err != nil {
+		return types.TransactionID{}, err
+	}
+	defer func() {
+		if err != nil {
+			txnBuilder.Drop()
+		}
+	}()
+	data := encodeRecoveryHintPointer(pointer, id, key)
+	_, feePerByte := r.tpool.FeeEstimation()
+	fee := feePerByte.Mul64(uint64(len(data) + 500)) // the data plus the inputs and outputs funding the fee
+	if err := txnBuilder.FundSiacoins(fee); err != nil {
+		return types.TransactionID{}, err
+	}
+	txnBuilder.AddMinerFee(fee)
+	txnBuilder.AddArbitraryData(data)
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		return types.TransactionID{}, err
+	}
+	if err := r.tpool.AcceptTransactionSet(txnSet); err != nil {
+		return types.TransactionID{}, err
+	}
+	return txnSet[len(txnSet)-1].ID(), nil
+}
+
+// managedFindRecoveryHintPointer searches the blockchain for the most recent
+// pointer of a seed, starting at the current block.
+func (r *Renter) managedFindRecoveryHintPointer(id crypto.Hash, key crypto.CipherKey) (recoveryHintPointer, bool) {
+	for height := r.cs.Height(); ; height-- {
+		block, exists := r.cs.BlockAtHeight(height)
+		if !exists {
+			return recoveryHintPointer{}, false
+		}
+		var pointer recoveryHintPointer
+		var found bool
+		for _, txn := range block.Transactions {
+			for _, arb := range txn.ArbitraryData {
+				if p, ok := decodeRecoveryHintPointer(arb, id, key); ok {
+					pointer, found = p, true
+				}
+			}
+		}
+		if found {
+			return pointer, true
+		}
+		if height == 0 {
+			return recoveryHintPointer{}, false
+		}
+	}
+}
+
+// SyncRecoveryHint stores a recovery hint listing the current contracts on up
+// to recoveryHintRedundancy hosts and publishes a pointer to it. Every sync
+// stores a new sector, so it should be repeated after contracts were formed
+// or renewed rather than periodically.
+func (r *Renter) SyncRecoveryHint() (modules.RenterRecoveryHintSync, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterRecoveryHintSync{}, err
+	}
+	defer r.tg.Done()
+	seed, _, err := r.wallet.PrimarySeed()
+	if err != nil {
+		return modules.RenterRecoveryHintSync{}, errors.AddContext(err, "unable to get the wallet seed")
+	}
+	id, key := recoveryHintKeys(seed)
+
+	hint := modules.RenterRecoveryHint{
+		Height:    r.cs.Height(),
+		Contracts: r.hostContractor.RecoveryContracts(),
+	}
+	if len(hint.Contracts) == 0 {
+		return modules.RenterRecoveryHintSync{}, errRecoveryHintNoContracts
+	}
+	sector, err := encodeRecoveryHint(hint, key)
+	if err != nil {
+		return modules.RenterRecoveryHintSync{}, err
+	}
+	hintContracts := make(map[types.FileContractID]modules.RecoveryHintContract, len(hint.Contracts))
+	for _, c := range hint.Contracts {
+		hintContracts[c.ID] = c
+	}
+
+	var pointer recoveryHintPointer
+	var result modules.RenterRecoveryHintSync
+	for _, c := range r.hostContractor.Contracts() {
+		if len(pointer.Contracts) >= recoveryHintRedundancy {
+			break
+		}
+		if !c.Utility.GoodForUpload || r.hostContractor.IsOffline(c.HostPublicKey) {
+			continue
+		}
+		root, contractID, err := r.managedStoreRecoveryHint(c.HostPublicKey, sector)
+		if err != nil {
+			r.log.Debugln("Unable to store recovery hint on host", c.HostPublicKey, err)
+			continue
+		}
+		hc, ok := hintContracts[contractID]
+		if !ok {
+			// The contract was renewed after the hint was created.
+			continue
+		}
+		pointer.SectorRoot = root
+		pointer.Contracts = append(pointer.Contracts, hc)
+		result.Hosts = append(result.Hosts, c.HostPublicKey)
+	}
+	if len(pointer.Contracts) == 0 {
+		return modules.RenterRecoveryHintSync{}, errRecoveryHintNoHosts
+	}
+	result.SectorRoot = pointer.SectorRoot
+	result.TransactionID, err = r.managedPublishRecoveryHintPointer(pointer, id, key)
+	if err != nil {
+		return modules.RenterRecoveryHintSync{}, errors.AddContext(err, "unable to publish recovery hint pointer")
+	}
+	if _, err := r.managedSaveRecoveryHint(hint, result.SectorRoot, result.Hosts); err != nil {
+		return modules.RenterRecoveryHintSync{}, err
+	}
+	r.log.Printf("Stored recovery hint with %v contracts on %v hosts", len(hint.Contracts), len(pointer.Contracts))
+	return result, nil
+}
+
+// mergeRecoveryHints merges the contracts of an older hint into the latest
+// hint. The older hint might list contracts that were dropped from the latest
+// one, e.g. because the hint was synced from another renter with the same
+// seed. The merged hint can list contracts that ended, were renewed or were
+// replaced by a contract with the same host, the contractor skips those when
+// listing and recovering the contracts.
+func mergeRecoveryHints(older, latest modules.RenterRecoveryHint) modules.RenterRecoveryHint {
+	merged := modules.RenterRecoveryHint{
+		Height:    latest.Height,
+		Contracts: append([]modules.RecoveryHintContract(nil), latest.Contracts...),
+	}
+	if older.Height > merged.Height {
+		merged.Height = older.Height
+	}
+	listed := make(map[types.FileContractID]struct{}, len(latest.Contracts))
+	for _, c := range latest.Contracts {
+		listed[c.ID] = struct{}{}
+	}
+	for _, c := range older.Contracts {
+		if _, exists := listed[c.ID]; !exists {
+			merged.Contracts = append(merged.Contracts, c)
+			listed[c.ID] = struct{}{}
+		}
+	}
+	return merged
+}
+
+// managedLoadRecoveryHint loads the saved hint. The boolean is false if no
+// hint was stored or restored yet.
+func (r *Renter) managedLoadRecoveryHint() (modules.RenterRecoveryHint, bool, error) {
+	var hint modules.RenterRecoveryHint
+	err := encoding.ReadFile(filepath.Join(r.persistDir, recoveryHintFile), &hint)
+	if os.IsNotExist(err) {
+		return modules.RenterRecoveryHint{}, false, nil
+	} else if err != nil {
+		return modules.RenterRecoveryHint{}, false, errors.AddContext(err, "unable to load recovery hint")
+	}
+	return hint, true, nil
+}
+
+// managedRestoreRecoveryHint finds the most recent pointer of the seed on the
+// blockchain and downloads the hint from any of the hosts it lists, unless
+// the hint of the pointer is saved already. The saved hint always belongs to
+// the pinned sector, which is compared with the sector of the pointer.
+func (r *Renter) managedRestoreRecoveryHint(seed modules.Seed) (modules.RenterRecoveryHint, error) {
+	if r.cs.SpvMode() {
+		return modules.RenterRecoveryHint{}, errRecoveryHintSpv
+	}
+	if !r.cs.Synced() {
+		return modules.RenterRecoveryHint{}, errRecoveryHintNotSynced
+	}
+	id, key := recoveryHintKeys(seed)
+	pointer, ok := r.managedFindRecoveryHintPointer(id, key)
+	if !ok {
+		return modules.RenterRecoveryHint{}, errRecoveryHintNotFound
+	}
+	saved, exists, err := r.managedLoadRecoveryHint()
+	if err != nil {
+		return modules.RenterRecoveryHint{}, err
+	}
+	lockID := r.mu.RLock()
+	pinned := r.persist.RecoveryHintSector
+	r.mu.RUnlock(lockID)
+	if exists && pinned == pointer.SectorRoot {
+		return saved, nil
+	}
+
+	var sector []byte
+	for _, c := range pointer.Contracts {
+		sector, err = r.hostContractor.RecoverSector(c, pointer.SectorRoot, r.tg.StopChan())
+		if err == nil {
+			break
+		}
+		r.log.Debugln("Unable to download recovery hint from host", c.HostPublicKey, err)
+	}
+	if err != nil {
+		return modules.RenterRecoveryHint{}, errors.AddContext(err, "unable to download the recovery hint from any host")
+	}
+	hint, err := decodeRecoveryHint(sector, key)
+	if err != nil {
+		return modules.RenterRecoveryHint{}, err
+	}
+	hosts := make([]types.SiaPublicKey, 0, len(pointer.Contracts))
+	for _, c := range pointer.Contracts {
+		hosts = append(hosts, c.HostPublicKey)
+	}
+	hint, err = r.managedSaveRecoveryHint(hint, pointer.SectorRoot, hosts)
+	if err != nil {
+		return modules.RenterRecoveryHint{}, err
+	}
+	r.log.Printf("Restored recovery hint with %v contracts", len(hint.Contracts))
+	return hint, nil
+}
+
+// RestoreRecoveryHint restores the most recent recovery hint of the seed. The
+// restored hint is written to the renter directory, since the secret keys it
+// contains are needed to restore the metadata from the contracts.
+func (r *Renter) RestoreRecoveryHint(seed modules.Seed) (modules.RenterRecoveryHint, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterRecoveryHint{}, err
+	}
+	defer r.tg.Done()
+	return r.managedRestoreRecoveryHint(seed)
+}
+
+// managedRecoveryHint restores the most recent hint of the wallet's primary
+// seed, so that contracts that were added to the hint since it was saved are
+// picked up. If that isn't possible, e.g. because the wallet is locked, the
+// saved hint is used.
+func (r *Renter) managedRecoveryHint() (modules.RenterRecoveryHint, error) {
+	seed, _, err := r.wallet.PrimarySeed()
+	if err == nil {
+		var hint modules.RenterRecoveryHint
+		hint, err = r.managedRestoreRecoveryHint(seed)
+		if err == nil {
+			return hint, nil
+		}
+	} else {
+		err = errors.AddContext(err, "unable to get the wallet seed")
+	}
+	saved, exists, loadErr := r.managedLoadRecoveryHint()
+	if loadErr != nil {
+		return modules.RenterRecoveryHint{}, errors.Compose(err, loadErr)
+	} else if !exists {
+		return modules.RenterRecoveryHint{}, err
+	}
+	r.log.Debugln("Using the saved recovery hint:", err)
+	return saved, nil
+}
+
+// RecoverableContracts lists the contracts of the saved recovery hint that
+// could be recovered. It doesn't restore the hint or contact the hosts, so it
+// neither changes the saved hint nor uses up the rate limits of the hosts.
+func (r *Renter) RecoverableContracts() ([]modules.RecoverableContract, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	hint, exists, err := r.managedLoadRecoveryHint()
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, errRecoveryHintNotSaved
+	}
+	return r.hostContractor.RecoverableContracts(hint.Contracts), nil
+}
+
+// RecoverContracts adds the recoverable contracts of the recovery hint to the
+// contract set and returns the contracts that were recovered. Since the hint
+// is restored from the seed if necessary, this works even if the local
+// contracts were lost.
+func (r *Renter) RecoverContracts() ([]modules.RecoverableContract, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	hint, err := r.managedRecoveryHint()
+	if err != nil {
+		return nil, err
+	}
+	recovered := r.hostContractor.RecoverContracts(hint.Contracts)
+	r.log.Printf("Recovered %v of the %v contracts of the recovery hint", len(recovered), len(hint.Contracts))
+	return recovered, nil
+}
@@ -0,0 +1,104 @@
+package renter
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+// TestRecoveryHintEncoding checks that a hint and its pointer can only be
+// decoded with the keys of the seed they were encoded with.
+func TestRecoveryHintEncoding(t *testing.T) {
+	var seed, otherSeed modules.Seed
+	fastrand.Read(seed[:])
+	fastrand.Read(otherSeed[:])
+	id, key := recoveryHintKeys(seed)
+	otherID, otherKey := recoveryHintKeys(otherSeed)
+
+	sk, _ := crypto.GenerateKeyPair()
+	contract := modules.RecoveryHintContract{
+		ID:        types.FileContractID{1},
+		EndHeight: 100,
+		SecretKey: sk,
+	}
+	hint := modules.RenterRecoveryHint{
+		Height:    10,
+		Contracts: []modules.RecoveryHintContract{contract},
+	}
+	sector, err := encodeRecoveryHint(hint, key)
+	if err != nil {
+		t.Fatal(err)
+	} else if uint64(len(sector)) != modules.SectorSize {
+		t.Fatal("hint wasn't padded to a sector")
+	}
+	decoded, err := decodeRecoveryHint(sector, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Height != hint.Height || len(decoded.Contracts) != 1 || decoded.Contracts[0].SecretKey != sk {
+		t.Fatal("decoded hint doesn't match", decoded)
+	}
+	if _, err := decodeRecoveryHint(sector, otherKey); err == nil {
+		t.Fatal("hint shouldn't decrypt with the key of another seed")
+	}
+
+	pointer := recoveryHintPointer{
+		SectorRoot: crypto.MerkleRoot(sector),
+		Contracts:  hint.Contracts,
+	}
+	arb := encodeRecoveryHintPointer(pointer, id, key)
+	decodedPointer, ok := decodeRecoveryHintPointer(arb, id, key)
+	if !ok || decodedPointer.SectorRoot != pointer.SectorRoot || len(decodedPointer.Contracts) != 1 {
+		t.Fatal("pointer wasn't decoded", decodedPointer)
+	}
+	if _, ok := decodeRecoveryHintPointer(arb, otherID, otherKey); ok {
+		t.Fatal("pointer of another seed shouldn't be decoded")
+	}
+	if _, ok := decodeRecoveryHintPointer(arb[:len(arb)-1], id, key); ok {
+		t.Fatal("truncated pointer shouldn't be decoded")
+	}
+}
+
+// TestMergeRecoveryHints checks that merging keeps the contracts of both hints
+// once and prefers the contracts of the latest hint.
+func TestMergeRecoveryHints(t *testing.T) {
+	older := modules.RenterRecoveryHint{
+		Height: 10,
+		Contracts: []modules.RecoveryHintContract{
+			{ID: types.FileContractID{1}, EndHeight: 100},
+			{ID: types.FileContractID{2}, EndHeight: 100},
+		},
+	}
+	latest := modules.RenterRecoveryHint{
+		Height: 20,
+		Contracts: []modules.RecoveryHintContract{
+			{ID: types.FileContractID{2}, EndHeight: 200},
+			{ID: types.FileContractID{3}, EndHeight: 200},
+		},
+	}
+	merged := mergeRecoveryHints(older, latest)
+	if merged.Height != latest.Height {
+		t.Fatal("expected the height of the latest hint, got", merged.Height)
+	}
+	endHeights := make(map[types.FileContractID]types.BlockHeight)
+	for _, c := range merged.Contracts {
+		if _, exists := endHeights[c.ID]; exists {
+			t.Fatal("contract was merged twice", c.ID)
+		}
+		endHeights[c.ID] = c.EndHeight
+	}
+	if len(endHeights) != 3 || endHeights[types.FileContractID{1}] != 100 || endHeights[types.FileContractID{2}] != 200 || endHeights[types.FileContractID{3}] != 200 {
+		t.Fatal("unexpected merged contracts", merged.Contracts)
+	}
+	if len(latest.Contracts) != 2 {
+		t.Fatal("merging modified the latest hint")
+	}
+
+	// Nothing is lost if no hint was saved before.
+	if merged := mergeRecoveryHints(modules.RenterRecoveryHint{}, latest); merged.Height != latest.Height || len(merged.Contracts) != len(latest.Contracts) {
+		t.Fatal("unexpected merge with an empty hint", merged)
+	}
+}
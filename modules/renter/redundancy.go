@@ -0,0 +1,151 @@
+package renter
+
+import (
+	"fmt"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+var (
+	// errRedundancyRekeying is returned if the redundancy of a file is
+	// changed while its masterkey is being rotated.
+	errRedundancyRekeying = errors.New("can't change the redundancy of a file while it is being rekeyed")
+
+	// errRedundancyUnchanged is returned if the new erasure code parameters
+	// match the current ones.
+	errRedundancyUnchanged = errors.New("the file already uses the requested redundancy")
+
+	// errSchemeDataPieces and errSchemeParityPieces are returned if the
+	// erasure code parameters passed to ErasureSchemeInfo are invalid.
+	errSchemeDataPieces   = errors.New("the number of data pieces must be positive")
+	errSchemeParityPieces = errors.New("the number of parity pieces can't be negative")
+)
+
+// maxErasureCodePieces is the maximum total number of pieces supported by the
+// Reed-Solomon erasure code.
+const maxErasureCodePieces = 256
+
+// ErasureSchemeInfo returns the storage overhead and fault tolerance of a file
+// uploaded with the provided erasure code parameters. Every piece of a chunk
+// is stored on a different host, so a chunk stays recoverable as long as no
+// more hosts than there are parity pieces are lost.
+func (r *Renter) ErasureSchemeInfo(dataPieces, parityPieces int) (modules.ErasureSchemeInfo, error) {
+	if dataPieces <= 0 {
+		return modules.ErasureSchemeInfo{}, errSchemeDataPieces
+	}
+	if parityPieces < 0 {
+		return modules.ErasureSchemeInfo{}, errSchemeParityPieces
+	}
+	if dataPieces+parityPieces > maxErasureCodePieces {
+		return modules.ErasureSchemeInfo{}, fmt.Errorf("the erasure code supports at most %v pieces, got %v", maxErasureCodePieces, dataPieces+parityPieces)
+	}
+
+	// Every piece is stored in a full sector, but the encryption of the
+	// pieces takes up a few bytes of the sector.
+	pieceSize := modules.SectorSize - crypto.TypeDefaultRenter.Overhead()
+	numPieces := uint64(dataPieces + parityPieces)
+	return modules.ErasureSchemeInfo{
+		DataPieces:        dataPieces,
+		ParityPieces:      parityPieces,
+		ChunkSize:         uint64(dataPieces) * pieceSize,
+		StorageMultiplier: float64(numPieces*modules.SectorSize) / float64(uint64(dataPieces)*pieceSize),
+		HostsTolerated:    uint64(parityPieces),
+		MinRedundancy:     1,
+		FullRedundancy:    float64(numPieces) / float64(dataPieces),
+	}, nil
+}
+
+// SetFileRedundancy changes the number of parity pieces of an existing file.
+// The data is not uploaded again. If the number of pieces grows, the repair
+// loop encodes the new parity pieces from the local copy of the file or from
+// the pieces on the hosts. If it shrinks, the surplus pieces are dropped from
+// the file. The number of data pieces determines the size of the file's
+// chunks and can't be changed without uploading the file again.
+func (r *Renter) SetFileRedundancy(siaPath string, dataPieces, parityPieces int) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.RLock()
+	file, exists := r.files[siaPath]
+	r.mu.RUnlock(id)
+	if !exists {
+		return ErrUnknownPath
+	}
+	if file.Rekeying() {
+		return errRedundancyRekeying
+	}
+	oldEC := file.ErasureCode()
+	if dataPieces != oldEC.MinPieces() {
+		return siafile.ErrDataPiecesChanged
+	}
+	if dataPieces+parityPieces == oldEC.NumPieces() {
+		return errRedundancyUnchanged
+	}
+	ec, err := siafile.NewRSCode(dataPieces, parityPieces)
+	if err != nil {
+		return errors.AddContext(err, "invalid erasure code parameters")
+	}
+
+	// Adding pieces requires the same number of contracts as uploading a file
+	// with the new erasure code.
+	if ec.NumPieces() > oldEC.NumPieces() {
+		numContracts := len(r.hostContractor.Contracts())
+		requiredContracts := (ec.NumPieces() + ec.MinPieces()) / 2
+		if numContracts < requiredContracts && build.Release != "testing" {
+			return fmt.Errorf("not enough contracts to increase the redundancy: got %v, needed %v", numContracts, requiredContracts)
+		}
+	}
+	if err := file.SetErasureCode(ec); err != nil {
+		return errors.AddContext(err, "unable to update the erasure code of the file")
+	}
+
+	// Chunks that are currently being repaired keep the erasure code they
+	// were built with. Everything else is pushed to the repair loop with the
+	// new number of pieces right away.
+	hosts := r.managedRefreshHostsAndWorkers()
+	id = r.mu.Lock()
+	unfinishedChunks := r.buildUnfinishedChunks(file, hosts, r.redundancyGroups())
+	r.mu.Unlock(id)
+	for i := 0; i < len(unfinishedChunks); i++ {
+		r.uploadHeap.managedPush(unfinishedChunks[i])
+	}
+	select {
+	case r.uploadHeap.newUploads <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// SetFileSpareHosts sets the number of hosts beyond the number of pieces that
+// the chunks of a file are spread across. The spare hosts store additional
+// copies of the pieces with the fewest copies, which increases the number of
+// hosts that can be lost without losing the file. The copies are uploaded by
+// the repair loop once every chunk has all of its pieces.
+func (r *Renter) SetFileSpareHosts(siaPath string, spareHosts uint64) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.RLock()
+	file, exists := r.files[siaPath]
+	r.mu.RUnlock(id)
+	if !exists {
+		return ErrUnknownPath
+	}
+	if err := file.SetSpareHosts(spareHosts); err != nil {
+		return errors.AddContext(err, "unable to set the spare hosts of the file")
+	}
+	select {
+	case r.uploadHeap.newUploads <- struct{}{}:
+	default:
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package renter
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// TestErasureSchemeInfo checks the overhead and fault tolerance reported for
+// an erasure code and that invalid parameters are rejected.
+func TestErasureSchemeInfo(t *testing.T) {
+	r := new(Renter)
+	info, err := r.ErasureSchemeInfo(10, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.HostsTolerated != 20 || info.MinRedundancy != 1 || info.FullRedundancy != 3 {
+		t.Fatal("wrong redundancy", info)
+	}
+	if info.StorageMultiplier < info.FullRedundancy || info.ChunkSize > 10*modules.SectorSize {
+		t.Fatal("storage multiplier should include the encryption overhead", info)
+	}
+
+	// A scheme without parity pieces is legal, but doesn't tolerate any
+	// host losses.
+	info, err = r.ErasureSchemeInfo(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.HostsTolerated != 0 || info.FullRedundancy != 1 {
+		t.Fatal("wrong redundancy", info)
+	}
+
+	if _, err := r.ErasureSchemeInfo(0, 1); err != errSchemeDataPieces {
+		t.Fatal("expected errSchemeDataPieces, got", err)
+	}
+	if _, err := r.ErasureSchemeInfo(1, -1); err != errSchemeParityPieces {
+		t.Fatal("expected errSchemeParityPieces, got", err)
+	}
+	if _, err := r.ErasureSchemeInfo(200, 57); err == nil {
+		t.Fatal("expected an error for too many pieces")
+	}
+}
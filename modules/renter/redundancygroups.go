@@ -0,0 +1,289 @@
+package renter
+
+// redundancygroups.go keeps the pieces of files in different redundancy groups
+// on different hosts, so that the loss of a single host can't affect more than
+// one group. The rule is applied when the repair loop picks the hosts for new
+// pieces, and again when a worker places a piece, since the chunks of other
+// groups might have been assigned to the host since the hosts were picked. If
+// there aren't enough hosts to keep the groups apart, the pieces are placed
+// on as few shared hosts as possible and an alert reports the overlap.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/Hyperspace/types"
+	"github.com/HyperspaceApp/errors"
+)
+
+// alertCauseRedundancyGroupOverlap is the cause of the alert registered while
+// a redundancy group has to share hosts with other groups.
+const alertCauseRedundancyGroupOverlap = "not enough hosts to separate redundancy groups"
+
+// redundancyGroupAlertID returns the id of the overlap alert of a group.
+func redundancyGroupAlertID(group string) string {
+	return "redundancygroup-" + group
+}
+
+// redundancyGroups is a snapshot of the hosts that store pieces of the files
+// in each redundancy group, keyed by group and the String() representation of
+// the host's SiaPublicKey. It is built once per build of the upload heap and
+// records the groups that had to be placed on hosts of other groups.
+type redundancyGroups struct {
+	hosts    map[string]map[string]types.SiaPublicKey
+	overlaps map[string]string
+}
+
+// groupPlacement counts the pieces of a redundancy group that are being
+// uploaded to a host.
+type groupPlacement struct {
+	hostKey types.SiaPublicKey
+	pieces  int
+}
+
+// managedPlaceGroupPiece records that a piece of the chunk is uploaded to the
+// host. It returns false without recording the piece if pieces of another
+// group are being uploaded to the host, unless the host already stores pieces
+// of the file.
+func (r *Renter) managedPlaceGroupPiece(uc *unfinishedUploadChunk, hostKey types.SiaPublicKey) bool {
+	group := uc.redundancyGroup
+	if group == "" {
+		return true
+	}
+	host := hostKey.String()
+	var stored bool
+	for _, pk := range uc.renterFile.HostPublicKeys() {
+		if pk.String() == host {
+			stored = true
+			break
+		}
+	}
+	r.groupPlacementsMu.Lock()
+	defer r.groupPlacementsMu.Unlock()
+	if !stored {
+		for name, placements := range r.groupPlacements {
+			if _, placed := placements[host]; placed && name != group {
+				return false
+			}
+		}
+	}
+	if _, exists := r.groupPlacements[group]; !exists {
+		r.groupPlacements[group] = make(map[string]*groupPlacement)
+	}
+	p, exists := r.groupPlacements[group][host]
+	if !exists {
+		p = &groupPlacement{hostKey: hostKey}
+		r.groupPlacements[group][host] = p
+	}
+	p.pieces++
+	return true
+}
+
+// managedReleaseGroupPiece removes the placement of a piece of the chunk once
+// its upload finished. Uploaded pieces are part of the file afterwards.
+func (r *Renter) managedReleaseGroupPiece(uc *unfinishedUploadChunk, hostKey types.SiaPublicKey) {
+	group := uc.redundancyGroup
+	if group == "" {
+		return
+	}
+	host := hostKey.String()
+	r.groupPlacementsMu.Lock()
+	defer r.groupPlacementsMu.Unlock()
+	p, exists := r.groupPlacements[group][host]
+	if !exists {
+		return
+	}
+	p.pieces--
+	if p.pieces > 0 {
+		return
+	}
+	delete(r.groupPlacements[group], host)
+	if len(r.groupPlacements[group]) == 0 {
+		delete(r.groupPlacements, group)
+	}
+}
+
+// redundancyGroups returns a snapshot of the redundancy groups of all files,
+// including the hosts that pieces are being uploaded to.
+func (r *Renter) redundancyGroups() *redundancyGroups {
+	groups := &redundancyGroups{
+		hosts:    make(map[string]map[string]types.SiaPublicKey),
+		overlaps: make(map[string]string),
+	}
+	for _, f := range r.files {
+		group := f.RedundancyGroup()
+		if group == "" {
+			continue
+		}
+		if _, exists := groups.hosts[group]; !exists {
+			groups.hosts[group] = make(map[string]types.SiaPublicKey)
+		}
+		for _, pk := range f.HostPublicKeys() {
+			groups.hosts[group][pk.String()] = pk
+		}
+	}
+	r.groupPlacementsMu.Lock()
+	for group, placements := range r.groupPlacements {
+		if _, exists := groups.hosts[group]; !exists {
+			groups.hosts[group] = make(map[string]types.SiaPublicKey)
+		}
+		for host, p := range placements {
+			groups.hosts[group][host] = p.hostKey
+		}
+	}
+	r.groupPlacementsMu.Unlock()
+	return groups
+}
+
+// uploadHosts returns the subset of hosts that may receive new pieces of f.
+// Hosts that store pieces of files in other redundancy groups are excluded.
+// If that leaves fewer hosts than the file has pieces, the hosts of the
+// fewest other groups are added until there are enough, and the overlap is
+// recorded.
+func (groups *redundancyGroups) uploadHosts(f *siafile.SiaFile, hosts map[string]struct{}) map[string]struct{} {
+	group := f.RedundancyGroup()
+	if group == "" {
+		return hosts
+	}
+	own := groups.hosts[group]
+	taken := make(map[string]int)
+	for name, groupHosts := range groups.hosts {
+		if name == group {
+			continue
+		}
+		for host := range groupHosts {
+			// Hosts that already store pieces of this group stay usable, the
+			// overlap exists either way.
+			if _, ok := own[host]; !ok {
+				taken[host]++
+			}
+		}
+	}
+	available := make(map[string]struct{}, len(hosts))
+	var shared []string
+	for host := range hosts {
+		if _, ok := taken[host]; ok {
+			shared = append(shared, host)
+		} else {
+			available[host] = struct{}{}
+		}
+	}
+
+	needed := f.ErasureCode().NumPieces()
+	if len(available) >= needed {
+		return available
+	}
+	free := len(available)
+	sort.Slice(shared, func(i, j int) bool {
+		if taken[shared[i]] != taken[shared[j]] {
+			return taken[shared[i]] < taken[shared[j]]
+		}
+		return shared[i] < shared[j]
+	})
+	for _, host := range shared {
+		if len(available) >= needed {
+			break
+		}
+		available[host] = struct{}{}
+	}
+	groups.overlaps[group] = fmt.Sprintf("redundancy group %q needs %v hosts but only %v hosts aren't used by other groups, pieces of %v are placed on %v hosts of other groups", group, needed, free, f.SiaPath(), len(available)-free)
+	return available
+}
+
+// managedUpdateRedundancyGroupAlerts registers an alert for every group that
+// overlapped while the upload heap was built and removes the alerts of all
+// other groups. Since the snapshot covers all files, the alert of a group
+// stays registered as long as any of its files overlaps.
+func (r *Renter) managedUpdateRedundancyGroupAlerts(groups *redundancyGroups) {
+	r.alertsMu.Lock()
+	for id := range r.alerts {
+		if strings.HasPrefix(id, redundancyGroupAlertID("")) {
+			if _, overlapping := groups.overlaps[strings.TrimPrefix(id, redundancyGroupAlertID(""))]; !overlapping {
+				delete(r.alerts, id)
+			}
+		}
+	}
+	r.alertsMu.Unlock()
+	for group, msg := range groups.overlaps {
+		r.managedRegisterAlert(redundancyGroupAlertID(group), alertCauseRedundancyGroupOverlap, msg)
+	}
+}
+
+// SetFileRedundancyGroup adds a file to a redundancy group. An empty group
+// removes the file from its group. Pieces that were uploaded before are kept,
+// only new pieces are placed according to the group.
+func (r *Renter) SetFileRedundancyGroup(siaPath, group string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.RLock()
+	file, exists := r.files[siaPath]
+	r.mu.RUnlock(id)
+	if !exists {
+		return ErrUnknownPath
+	}
+	if err := file.SetRedundancyGroup(group); err != nil {
+		return errors.AddContext(err, "unable to set the redundancy group of the file")
+	}
+	select {
+	case r.uploadHeap.newUploads <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// RedundancyGroups returns the files and hosts of every redundancy group,
+// sorted by name. Hosts that store pieces of more than one group are listed
+// as overlapping hosts of every group they belong to.
+func (r *Renter) RedundancyGroups() []modules.RedundancyGroup {
+	id := r.mu.RLock()
+	groupHosts := r.redundancyGroups().hosts
+	groupFiles := make(map[string][]string)
+	for siaPath, f := range r.files {
+		if group := f.RedundancyGroup(); group != "" {
+			groupFiles[group] = append(groupFiles[group], siaPath)
+		}
+	}
+	r.mu.RUnlock(id)
+
+	// Count the groups of every host.
+	hostGroups := make(map[string]int)
+	for _, hosts := range groupHosts {
+		for host := range hosts {
+			hostGroups[host]++
+		}
+	}
+
+	groups := make([]modules.RedundancyGroup, 0, len(groupHosts))
+	for name, hosts := range groupHosts {
+		group := modules.RedundancyGroup{
+			Name:             name,
+			Files:            groupFiles[name],
+			Hosts:            []types.SiaPublicKey{},
+			OverlappingHosts: []types.SiaPublicKey{},
+		}
+		sort.Strings(group.Files)
+		for host, pk := range hosts {
+			group.Hosts = append(group.Hosts, pk)
+			if hostGroups[host] > 1 {
+				group.OverlappingHosts = append(group.OverlappingHosts, pk)
+			}
+		}
+		sort.Slice(group.Hosts, func(i, j int) bool {
+			return group.Hosts[i].String() < group.Hosts[j].String()
+		})
+		sort.Slice(group.OverlappingHosts, func(i, j int) bool {
+			return group.OverlappingHosts[i].String() < group.OverlappingHosts[j].String()
+		})
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
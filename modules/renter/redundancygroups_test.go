@@ -0,0 +1,185 @@
+package renter
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/Hyperspace/types"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+// TestRedundancyGroupUploadHosts checks that the hosts of other redundancy
+// groups are excluded from the upload hosts of a file as long as enough hosts
+// remain, that only the missing hosts are added otherwise, and that the
+// overlap is reported while any file of the group overlaps.
+func TestRedundancyGroupUploadHosts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	rsc, _ := siafile.NewRSCode(1, 1)
+	f1 := newFileTesting("group/a", newTestingWal(), rsc, 1000, 0777, "")
+	f2 := newFileTesting("group/b", newTestingWal(), rsc, 1000, 0777, "")
+	if err := f1.SetRedundancyGroup("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f2.SetRedundancyGroup("b"); err != nil {
+		t.Fatal(err)
+	}
+	pks := make([]types.SiaPublicKey, 4)
+	hosts := make(map[string]struct{})
+	for i := range pks {
+		pks[i] = types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+		hosts[pks[i].String()] = struct{}{}
+	}
+	// The first two hosts store the pieces of group a.
+	for i := 0; i < 2; i++ {
+		if err := f1.AddPiece(pks[i], 0, uint64(i), crypto.Hash{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	id := rt.renter.mu.Lock()
+	rt.renter.files[f1.SiaPath()] = f1
+	rt.renter.files[f2.SiaPath()] = f2
+	groups := rt.renter.redundancyGroups()
+	rt.renter.mu.Unlock(id)
+	available := groups.uploadHosts(f2, hosts)
+	if len(available) != 2 {
+		t.Fatal("expected 2 hosts, got", len(available))
+	}
+	for i := 0; i < 2; i++ {
+		if _, ok := available[pks[i].String()]; ok {
+			t.Fatal("host of group a is available to group b")
+		}
+	}
+	rt.renter.managedUpdateRedundancyGroupAlerts(groups)
+	alertExists := func() bool {
+		rt.renter.alertsMu.Lock()
+		defer rt.renter.alertsMu.Unlock()
+		_, exists := rt.renter.alerts[redundancyGroupAlertID("b")]
+		return exists
+	}
+	if alertExists() {
+		t.Fatal("overlap reported without an overlap")
+	}
+
+	// Without enough hosts only as many hosts of other groups as needed are
+	// used, preferring the hosts of the fewest groups, and the overlap is
+	// reported.
+	f3 := newFileTesting("group/c", newTestingWal(), rsc, 1000, 0777, "")
+	if err := f3.SetRedundancyGroup("c"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f3.AddPiece(pks[1], 0, 0, crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	delete(hosts, pks[3].String())
+	id = rt.renter.mu.Lock()
+	rt.renter.files[f3.SiaPath()] = f3
+	groups = rt.renter.redundancyGroups()
+	rt.renter.mu.Unlock(id)
+	available = groups.uploadHosts(f2, hosts)
+	if len(available) != 2 {
+		t.Fatal("expected 2 hosts, got", len(available))
+	}
+	if _, ok := available[pks[2].String()]; !ok {
+		t.Fatal("free host isn't available")
+	}
+	if _, ok := available[pks[0].String()]; !ok {
+		t.Fatal("host of the fewest other groups isn't available")
+	}
+
+	// A file of the group that fits on the free hosts doesn't clear the
+	// alert of the overlapping file.
+	smallRSC, _ := siafile.NewRSCode(1, 0)
+	small := newFileTesting("group/small", newTestingWal(), smallRSC, 1000, 0777, "")
+	if err := small.SetRedundancyGroup("b"); err != nil {
+		t.Fatal(err)
+	}
+	groups.uploadHosts(small, hosts)
+	rt.renter.managedUpdateRedundancyGroupAlerts(groups)
+	if !alertExists() {
+		t.Fatal("overlap wasn't reported")
+	}
+
+	// The alert is removed once the group no longer overlaps.
+	hosts[pks[3].String()] = struct{}{}
+	id = rt.renter.mu.Lock()
+	delete(rt.renter.files, f3.SiaPath())
+	groups = rt.renter.redundancyGroups()
+	rt.renter.mu.Unlock(id)
+	groups.uploadHosts(f2, hosts)
+	rt.renter.managedUpdateRedundancyGroupAlerts(groups)
+	if alertExists() {
+		t.Fatal("alert wasn't removed")
+	}
+
+	// Overlapping hosts are listed for both groups.
+	if err := f2.AddPiece(pks[0], 0, 0, crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	listed := rt.renter.RedundancyGroups()
+	if len(listed) != 2 || listed[0].Name != "a" || listed[1].Name != "b" {
+		t.Fatal("unexpected listed", listed)
+	}
+	for _, g := range listed {
+		if len(g.Files) != 1 || len(g.OverlappingHosts) != 1 || g.OverlappingHosts[0].String() != pks[0].String() {
+			t.Fatal("unexpected group", g)
+		}
+	}
+}
+
+// TestRedundancyGroupPlacements checks that a host can't receive pieces of a
+// group while pieces of another group are being uploaded to it, unless it
+// stores pieces of the file already, and that the hosts of the pieces being
+// uploaded are part of the snapshot of the groups.
+func TestRedundancyGroupPlacements(t *testing.T) {
+	rsc, _ := siafile.NewRSCode(1, 1)
+	f1 := newFileTesting("group/a", newTestingWal(), rsc, 1000, 0777, "")
+	f2 := newFileTesting("group/b", newTestingWal(), rsc, 1000, 0777, "")
+	uc1 := &unfinishedUploadChunk{renterFile: f1, redundancyGroup: "a"}
+	uc2 := &unfinishedUploadChunk{renterFile: f2, redundancyGroup: "b"}
+	r := &Renter{
+		files:           map[string]*siafile.SiaFile{f1.SiaPath(): f1, f2.SiaPath(): f2},
+		groupPlacements: make(map[string]map[string]*groupPlacement),
+	}
+	pk := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+
+	if !r.managedPlaceGroupPiece(uc1, pk) || !r.managedPlaceGroupPiece(uc1, pk) {
+		t.Fatal("pieces of the same group should share the host")
+	}
+	if r.managedPlaceGroupPiece(uc2, pk) {
+		t.Fatal("host received pieces of two groups")
+	}
+	if _, exists := r.redundancyGroups().hosts["a"][pk.String()]; !exists {
+		t.Fatal("host of the pieces being uploaded isn't part of the snapshot")
+	}
+
+	// The host stays placed until both pieces are released.
+	r.managedReleaseGroupPiece(uc1, pk)
+	if r.managedPlaceGroupPiece(uc2, pk) {
+		t.Fatal("host was released too early")
+	}
+	r.managedReleaseGroupPiece(uc1, pk)
+	if len(r.groupPlacements) != 0 {
+		t.Fatal("placements weren't removed", r.groupPlacements)
+	}
+
+	// A host that already stores pieces of the file can receive more of them
+	// regardless of other groups.
+	if !r.managedPlaceGroupPiece(uc2, pk) {
+		t.Fatal("released host can't be placed")
+	}
+	if err := f1.AddPiece(pk, 0, 0, crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if !r.managedPlaceGroupPiece(uc1, pk) {
+		t.Fatal("host of the file can't receive more of its pieces")
+	}
+}
@@ -0,0 +1,268 @@
+package renter
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+var (
+	// errRekeyInsufficientPieces is returned if not enough pieces of a chunk
+	// could be uploaded under the new masterkey to recover it.
+	errRekeyInsufficientPieces = errors.New("not enough pieces could be uploaded to rekey the chunk")
+
+	// errRekeyActive is returned if a rekey is requested for a file that is
+	// already being rekeyed.
+	errRekeyActive = errors.New("the file is already being rekeyed")
+
+	// errRekeyNotActive is returned if a rekey is cancelled for a file that
+	// isn't being rekeyed.
+	errRekeyNotActive = errors.New("the file is not being rekeyed")
+)
+
+// alertCauseRekeyFailed is the cause of the alerts registered for files whose
+// rekey was cancelled after failing too often.
+const alertCauseRekeyFailed = "rekey failed"
+
+// rekeyFailedAlertID returns the id of the alert that is registered when the
+// rekey of the file at siaPath is cancelled after failing too often.
+func rekeyFailedAlertID(siaPath string) string {
+	return "rekeyfailed:" + siaPath
+}
+
+// RekeyFile rotates the masterkey of a file. Every chunk is downloaded from the
+// hosts, encrypted under a new key and uploaded again, so the original file
+// doesn't need to be available locally. The rekey happens in the background
+// and is resumed by the repair loop if it is interrupted. The file is still
+// repaired while it is being rekeyed.
+func (r *Renter) RekeyFile(siaPath string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.RLock()
+	file, exists := r.files[siaPath]
+	r.mu.RUnlock(id)
+	if !exists {
+		return ErrUnknownPath
+	}
+	if file.Rekeying() {
+		return errRekeyActive
+	}
+	// A cancelled rekey might still be uploading pieces under its key.
+	id = r.mu.RLock()
+	_, active := r.activeRekeys[file.UID()]
+	r.mu.RUnlock(id)
+	if active {
+		return errRekeyActive
+	}
+	if _, err := file.StartRekey(crypto.GenerateSiaKey(file.MasterKey().Type())); err != nil {
+		return errors.AddContext(err, "unable to start rekey")
+	}
+	r.managedUnregisterAlert(rekeyFailedAlertID(siaPath))
+	r.managedResumeRekey(file)
+	return nil
+}
+
+// CancelRekey stops rotating the masterkey of a file. The chunks that were
+// rekeyed already keep their new key.
+func (r *Renter) CancelRekey(siaPath string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.RLock()
+	file, exists := r.files[siaPath]
+	r.mu.RUnlock(id)
+	if !exists {
+		return ErrUnknownPath
+	}
+	if !file.Rekeying() {
+		return errRekeyNotActive
+	}
+	return r.managedCancelRekey(file)
+}
+
+// managedCancelRekey cancels the rekey of the file and resets its failures.
+// The thread rekeying the file stops before its next chunk.
+func (r *Renter) managedCancelRekey(file *siafile.SiaFile) error {
+	if err := file.CancelRekey(); err != nil {
+		return errors.AddContext(err, "unable to cancel rekey")
+	}
+	id := r.mu.Lock()
+	delete(r.rekeyFailures, file.UID())
+	r.mu.Unlock(id)
+	r.log.Println("Cancelled rekey of", file.SiaPath())
+	return nil
+}
+
+// managedRekeyFailed counts a failed attempt to rekey a chunk of the file. The
+// rekey is cancelled and an alert is registered once maxRekeyAttempts
+// consecutive attempts failed.
+func (r *Renter) managedRekeyFailed(file *siafile.SiaFile, err error) {
+	id := r.mu.Lock()
+	r.rekeyFailures[file.UID()]++
+	failures := r.rekeyFailures[file.UID()]
+	r.mu.Unlock(id)
+	if failures < maxRekeyAttempts {
+		return
+	}
+	if cancelErr := r.managedCancelRekey(file); cancelErr != nil {
+		r.log.Println("Unable to cancel failing rekey of", file.SiaPath(), ":", cancelErr)
+		return
+	}
+	msg := fmt.Sprintf("rekey of %v was cancelled after %v failed attempts: %v", file.SiaPath(), failures, err)
+	r.managedRegisterAlert(rekeyFailedAlertID(file.SiaPath()), alertCauseRekeyFailed, msg)
+}
+
+// managedResumeRekey spawns a thread that continues rekeying the file unless
+// one is already running.
+func (r *Renter) managedResumeRekey(file *siafile.SiaFile) {
+	id := r.mu.Lock()
+	_, active := r.activeRekeys[file.UID()]
+	if !active {
+		r.activeRekeys[file.UID()] = struct{}{}
+	}
+	r.mu.Unlock(id)
+	if !active {
+		go r.threadedRekeyFile(file)
+	}
+}
+
+// threadedRekeyFile rekeys all the chunks of the file that are still encrypted
+// with the old masterkey. Each chunk is persisted as soon as it is done, so an
+// interrupted rekey only has to redo the chunk it was working on. A failed
+// chunk is retried the next time the repair loop resumes the rekey.
+func (r *Renter) threadedRekeyFile(file *siafile.SiaFile) {
+	defer func() {
+		id := r.mu.Lock()
+		delete(r.activeRekeys, file.UID())
+		r.mu.Unlock(id)
+	}()
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	// StartRekey returns the key that was persisted when the rekey began.
+	newKey, err := file.StartRekey(crypto.GenerateSiaKey(file.MasterKey().Type()))
+	if err != nil {
+		r.log.Println("Unable to resume rekey of", file.SiaPath(), ":", err)
+		return
+	}
+	for chunkIndex := uint64(0); chunkIndex < file.NumChunks(); chunkIndex++ {
+		// Stop if the rekey was cancelled.
+		if !file.Rekeying() {
+			return
+		}
+		if file.ChunkRekeyed(chunkIndex) {
+			continue
+		}
+		if err := r.managedRekeyChunk(file, chunkIndex, newKey); err != nil {
+			if !file.Rekeying() {
+				return
+			}
+			r.log.Printf("Unable to rekey chunk %v of %v: %v", chunkIndex, file.SiaPath(), err)
+			r.managedRekeyFailed(file, err)
+			return
+		}
+		id := r.mu.Lock()
+		delete(r.rekeyFailures, file.UID())
+		r.mu.Unlock(id)
+	}
+	if err := file.FinishRekey(); err != nil {
+		r.log.Println("Unable to finish rekey of", file.SiaPath(), ":", err)
+		return
+	}
+	r.log.Println("Rekeyed", file.SiaPath())
+}
+
+// managedRekeyChunk downloads a chunk, encrypts its pieces with newKey and
+// uploads them to the hosts that stored the old pieces. The new pieces are
+// added to the contracts next to the old ones instead of overwriting them,
+// since downloads that started before the chunk was rekeyed still fetch the
+// old pieces with the old key. Once the file points to the new pieces, the
+// old sectors are orphaned sectors that can be reclaimed.
+func (r *Renter) managedRekeyChunk(file *siafile.SiaFile, chunkIndex uint64, newKey crypto.CipherKey) error {
+	ec := file.ErasureCode()
+	memoryNeeded := file.PieceSize()*uint64(ec.NumPieces()+ec.MinPieces()) + uint64(ec.NumPieces())*newKey.Type().Overhead()
+	if !r.memoryManager.Request(memoryNeeded, memoryPriorityLow) {
+		return errors.New("unable to acquire memory for rekey")
+	}
+	defer r.memoryManager.Return(memoryNeeded)
+
+	data, err := r.managedDownloadChunkData(file, chunkIndex, file.ChunkSize(), int64(chunkIndex*file.ChunkSize()))
+	if err != nil {
+		return errors.AddContext(err, "unable to download chunk")
+	}
+	shards, err := ec.EncodeShards(data, file.PieceSize())
+	if err != nil {
+		return errors.AddContext(err, "unable to encode chunk")
+	}
+	oldPieces, _, err := file.ChunkPiecesAndKey(chunkIndex)
+	if err != nil {
+		return err
+	}
+
+	// Upload every piece to the hosts that stored it before, skipping hosts
+	// that can't be uploaded to anymore. Missing pieces are repaired by the
+	// repair loop.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	newPieces := make([][]siafile.Piece, len(oldPieces))
+	for pieceIndex, pieceSet := range oldPieces {
+		piece := newKey.Derive(chunkIndex, uint64(pieceIndex)).EncryptBytes(shards[pieceIndex])
+		for _, oldPiece := range pieceSet {
+			hostKey := oldPiece.HostPubKey
+			utility, exists := r.hostContractor.ContractUtility(hostKey)
+			if !exists || !utility.GoodForUpload || r.managedIsOffline(hostKey) {
+				continue
+			}
+			wg.Add(1)
+			go func(pieceIndex int, hostKey types.SiaPublicKey, piece []byte) {
+				defer wg.Done()
+				root, err := r.managedUploadRekeyedPiece(hostKey, piece)
+				if err != nil {
+					r.log.Debugln("Failed to upload rekeyed piece:", err)
+					return
+				}
+				mu.Lock()
+				newPieces[pieceIndex] = append(newPieces[pieceIndex], siafile.Piece{
+					HostPubKey: hostKey,
+					MerkleRoot: root,
+				})
+				mu.Unlock()
+			}(pieceIndex, hostKey, piece)
+		}
+	}
+	wg.Wait()
+
+	uploaded := 0
+	for _, pieceSet := range newPieces {
+		if len(pieceSet) > 0 {
+			uploaded++
+		}
+	}
+	if uploaded < ec.MinPieces() {
+		return errRekeyInsufficientPieces
+	}
+	return file.ReplaceChunkPieces(chunkIndex, newPieces)
+}
+
+// managedUploadRekeyedPiece uploads a single piece to a host and returns its
+// Merkle root.
+func (r *Renter) managedUploadRekeyedPiece(hostKey types.SiaPublicKey, piece []byte) (crypto.Hash, error) {
+	e, err := r.hostContractor.Editor(hostKey, r.tg.StopChan())
+	if err != nil {
+		return crypto.Hash{}, errors.AddContext(err, "unable to acquire an editor")
+	}
+	defer e.Close()
+	return e.Upload(piece)
+}
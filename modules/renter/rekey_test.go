@@ -0,0 +1,63 @@
+package renter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+)
+
+// TestRekeyFailures checks that a rekey is cancelled and an alert is
+// registered once too many consecutive attempts to rekey a chunk failed.
+func TestRekeyFailures(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	rsc, _ := siafile.NewRSCode(1, 1)
+	siaFilePath := filepath.Join(rt.dir, t.Name()+ShareExtension)
+	f, err := siafile.New(siaFilePath, t.Name(), "", newTestingWal(), rsc, crypto.GenerateSiaKey(crypto.TypeTwofish), 1000, 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := rt.renter.mu.Lock()
+	rt.renter.files[f.SiaPath()] = f
+	rt.renter.mu.Unlock(id)
+	if err := rt.renter.CancelRekey(f.SiaPath()); err != errRekeyNotActive {
+		t.Fatal("expected errRekeyNotActive but got", err)
+	}
+	if _, err := f.StartRekey(crypto.GenerateSiaKey(crypto.TypeTwofish)); err != nil {
+		t.Fatal(err)
+	}
+
+	alertRegistered := func() bool {
+		for _, alert := range rt.renter.Alerts() {
+			if alert.Cause == alertCauseRekeyFailed {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i < maxRekeyAttempts-1; i++ {
+		rt.renter.managedRekeyFailed(f, errRekeyInsufficientPieces)
+	}
+	if !f.Rekeying() || alertRegistered() {
+		t.Fatal("rekey shouldn't be cancelled yet")
+	}
+	rt.renter.managedRekeyFailed(f, errRekeyInsufficientPieces)
+	if f.Rekeying() {
+		t.Fatal("rekey should be cancelled")
+	}
+	if !alertRegistered() {
+		t.Fatal("expected an alert for the cancelled rekey")
+	}
+	if rt.renter.rekeyFailures[f.UID()] != 0 {
+		t.Fatal("failures should be reset")
+	}
+}
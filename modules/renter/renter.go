@@ -22,12 +22,16 @@ package renter
 
 import (
 	"fmt"
+	"math"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/crypto"
 	"github.com/HyperspaceApp/Hyperspace/modules"
 	"github.com/HyperspaceApp/Hyperspace/modules/renter/contractor"
 	"github.com/HyperspaceApp/Hyperspace/modules/renter/hostdb"
@@ -47,6 +51,7 @@ var (
 	errNilGateway    = errors.New("cannot create hostdb with nil gateway")
 	errNilHdb        = errors.New("cannot create renter with nil hostdb")
 	errNilTpool      = errors.New("cannot create renter with nil transaction pool")
+	errNilWallet     = errors.New("cannot create renter with nil wallet")
 )
 
 var (
@@ -68,6 +73,10 @@ type hostDB interface {
 	// from.
 	ActiveHosts() []modules.HostDBEntry
 
+	// AddressRanges maps the keys of the hosts to the address range they
+	// share with other hosts.
+	AddressRanges([]types.SiaPublicKey) map[string]string
+
 	// AllHosts returns the full list of hosts known to the hostdb, sorted in
 	// order of preference.
 	AllHosts() []modules.HostDBEntry
@@ -78,13 +87,61 @@ type hostDB interface {
 	// Close closes the hostdb.
 	Close() error
 
+	// ExportHosts returns all hosts together with their scan histories.
+	ExportHosts() []modules.HostDBEntry
+
 	// Host returns the HostDBEntry for a given host.
 	Host(types.SiaPublicKey) (modules.HostDBEntry, bool)
 
+	// ImportHosts seeds the hostdb with hosts exported by another node.
+	ImportHosts([]modules.HostDBEntry) (int, error)
+
 	// initialScanComplete returns a boolean indicating if the initial scan of the
 	// hostdb is completed.
 	InitialScanComplete() (bool, error)
 
+	// RescanHosts queues a scan of every host known to the hostdb.
+	RescanHosts() error
+
+	// RescanStatus returns the progress of the most recent rescan.
+	RescanStatus() modules.HostDBRescanStatus
+
+	// ReputationFeed returns the external reputation feed of the hostdb.
+	ReputationFeed() modules.HostDBReputationFeed
+
+	// SetReputationFeed sets the external reputation feed of the hostdb.
+	SetReputationFeed(url string, weight float64) error
+
+	// SetMaxScanHistoryLen sets the maximum length of the scan history of a
+	// host.
+	SetMaxScanHistoryLen(n int) error
+
+	// UpdateBandwidth adds a measurement of the upload and download
+	// throughput of a host to its moving averages.
+	UpdateBandwidth(pk types.SiaPublicKey, up, down float64)
+
+	// SetUptimeHalfLife sets the half-life of the decay of the uptime of
+	// the hosts.
+	SetUptimeHalfLife(halfLife time.Duration) error
+
+	// SetMaxScanningThreads sets the number of threads that scan hosts
+	// concurrently.
+	SetMaxScanningThreads(n int) error
+
+	// AddressFilterRanges returns the prefix lengths of the subnets that
+	// hosts can't share.
+	AddressFilterRanges() (ipv4Range, ipv6Range int)
+
+	// SetAddressFilterRanges sets the prefix lengths of the subnets that
+	// hosts can't share.
+	SetAddressFilterRanges(ipv4Range, ipv6Range int) error
+
+	// Filter returns the filter of hosts of the hostdb.
+	Filter() modules.HostDBFilter
+
+	// SetFilterMode sets the filter of hosts of the hostdb.
+	SetFilterMode(mode string, hosts []types.SiaPublicKey) error
+
 	// RandomHosts returns a set of random hosts, weighted by their estimated
 	// usefulness / attractiveness to the renter. RandomHosts will not return
 	// any offline or inactive hosts.
@@ -111,18 +168,57 @@ type hostContractor interface {
 	// Allowance returns the current allowance
 	Allowance() modules.Allowance
 
+	// AllowanceTransition returns the progress of the transition towards the
+	// most recently set allowance.
+	AllowanceTransition() modules.AllowanceTransition
+
+	// AllowanceTopUpError returns the reason why the most recent automatic
+	// top-up of the allowance failed, or nil if no top-up is failing.
+	AllowanceTopUpError() error
+
+	// OnFundingChecked registers a function that is called after every check
+	// of the automatic top-up and of the partial funding of the allowance.
+	OnFundingChecked(fn func())
+
+	// ContractorFunding returns whether the contractor is funding contracts
+	// partially because the wallet balance is too low.
+	ContractorFunding() modules.ContractorFunding
+
+	// RenewalSchedule returns when the active contracts are due for renewal
+	// and whether they are expected to be renewed.
+	RenewalSchedule(within types.BlockHeight) (modules.ContractRenewalSchedule, error)
+
+	// StartTrial sets a trial allowance with the provided funds.
+	StartTrial(funds types.Currency) error
+
+	// PromoteTrial replaces the trial allowance with a full allowance.
+	PromoteTrial(a modules.Allowance) error
+
+	// Trial returns the trial the contractor is running.
+	Trial() modules.RenterTrial
+
 	// Close closes the hostContractor.
 	Close() error
 
 	// CancelContract cancels the Renter's contract
 	CancelContract(id types.FileContractID) error
 
+	// CancelHostContracts cancels every active contract with a host and
+	// returns the contracts that were cancelled.
+	CancelHostContracts(pk types.SiaPublicKey) ([]types.FileContractID, error)
+
+	// SetContractMetadata replaces the metadata attached to a contract.
+	SetContractMetadata(id types.FileContractID, metadata map[string]string) error
+
 	// Contracts returns the staticContracts of the renter's hostContractor.
 	Contracts() []modules.RenterContract
 
 	// OldContracts returns the oldContracts of the renter's hostContractor.
 	OldContracts() []modules.RenterContract
 
+	// ContractByID returns the active contract with the given id.
+	ContractByID(types.FileContractID) (modules.RenterContract, bool)
+
 	// ContractByPublicKey returns the contract associated with the host key.
 	ContractByPublicKey(types.SiaPublicKey) (modules.RenterContract, bool)
 
@@ -130,6 +226,10 @@ type hostContractor interface {
 	// with a bool indicating if it exists.
 	ContractUtility(types.SiaPublicKey) (modules.ContractUtility, bool)
 
+	// ContractRevision returns the signed transaction containing the latest
+	// revision of a contract.
+	ContractRevision(types.FileContractID) (types.Transaction, bool)
+
 	// CurrentPeriod returns the height at which the current allowance period
 	// began.
 	CurrentPeriod() types.BlockHeight
@@ -142,16 +242,55 @@ type hostContractor interface {
 	// insertion, deletion, and modification of sectors.
 	Editor(types.SiaPublicKey, <-chan struct{}) (contractor.Editor, error)
 
+	// EditorForContract creates an Editor for the contract with the given
+	// id, which may be one of several parallel contracts with its host.
+	EditorForContract(types.FileContractID, <-chan struct{}) (contractor.Editor, error)
+
 	// IsOffline reports whether the specified host is considered offline.
 	IsOffline(types.SiaPublicKey) bool
 
+	// MaintenancePaused returns whether contract maintenance is paused.
+	MaintenancePaused() bool
+
 	// Downloader creates a Downloader from the specified contract ID,
 	// allowing the retrieval of sectors.
 	Downloader(types.SiaPublicKey, <-chan struct{}) (contractor.Downloader, error)
 
+	// DownloaderForContract creates a Downloader for the contract with the
+	// given id, which may be one of several parallel contracts with its host.
+	DownloaderForContract(types.FileContractID, <-chan struct{}) (contractor.Downloader, error)
+
+	// VerifySectorSegments downloads a random segment of each sector from
+	// the host with a Merkle proof and checks it against the sector's root.
+	VerifySectorSegments(types.SiaPublicKey, []crypto.Hash, <-chan struct{}) error
+
+	// RandomSectorRoots returns the Merkle roots of up to n random sectors
+	// stored under the contract.
+	RandomSectorRoots(id types.FileContractID, n int) ([]crypto.Hash, error)
+
+	// RecoveryContracts returns the contracts together with their secret
+	// keys.
+	RecoveryContracts() []modules.RecoveryHintContract
+
+	// RecoverSector downloads a sector using a contract that isn't part of
+	// the contract set.
+	RecoverSector(contract modules.RecoveryHintContract, root crypto.Hash, cancel <-chan struct{}) ([]byte, error)
+
+	// RecoverableContracts returns the contracts that could be recovered,
+	// without contacting their hosts.
+	RecoverableContracts(contracts []modules.RecoveryHintContract) []modules.RecoverableContract
+
+	// RecoverContracts adds the contracts that can be recovered to the
+	// contract set.
+	RecoverContracts(contracts []modules.RecoveryHintContract) []modules.RecoverableContract
+
 	// ResolveIDToPubKey returns the public key of a host given a contract id.
 	ResolveIDToPubKey(types.FileContractID) types.SiaPublicKey
 
+	// SectorRoots fetches the Merkle roots of all sectors that the host
+	// stores under the contract.
+	SectorRoots(id types.FileContractID, cancel <-chan struct{}) ([]crypto.Hash, error)
+
 	// RateLimits Gets the bandwidth limits for connections created by the
 	// contractor and its submodules.
 	RateLimits() (readBPS int64, writeBPS int64, packetSize uint64)
@@ -159,6 +298,29 @@ type hostContractor interface {
 	// SetRateLimits sets the bandwidth limits for connections created by the
 	// contractor and its submodules.
 	SetRateLimits(int64, int64, uint64)
+
+	// SetMaintenancePaused pauses or resumes the formation, renewal and
+	// replacement of contracts.
+	SetMaintenancePaused(bool) error
+
+	// RecommendAllowance estimates the cheapest allowance that stores the
+	// data at the given redundancy across the given number of hosts.
+	RecommendAllowance(dataSize uint64, redundancy float64, hosts uint64, period types.BlockHeight) (modules.AllowanceRecommendation, error)
+
+	// RegisterWebhook registers a webhook for contract lifecycle events.
+	RegisterWebhook(modules.RenterWebhook) error
+
+	// UnregisterWebhook removes a webhook.
+	UnregisterWebhook(url string) error
+
+	// Webhooks returns the registered webhooks.
+	Webhooks() []modules.RenterWebhook
+
+	// AuditLog returns the paid operations recorded in the audit log.
+	AuditLog(start, end time.Time, categories []string) ([]modules.RenterAuditEntry, error)
+
+	// ExportAuditLog writes the audit log as CSV to a file.
+	ExportAuditLog(dst string) error
 }
 
 // A Renter is responsible for tracking all of the files that a user has
@@ -175,11 +337,30 @@ type Renter struct {
 	//
 	files map[string]*siafile.SiaFile
 
+	// contentIndex maps the content hashes of files to the files. Several
+	// files can have the same content.
+	contentIndex map[crypto.Hash][]*siafile.SiaFile
+
+	// contentHashers compute the content hashes of the files that are being
+	// uploaded, keyed by the UID of the file.
+	contentHashers   map[string]*contentHasher
+	contentHashersMu sync.Mutex
+
+	// chunkIndex maps the hashes of the chunks of files with deduplication to
+	// the chunks. Every chunk is a reference to the pieces of the chunks with
+	// that hash.
+	chunkIndex map[crypto.Hash][]chunkRef
+
+	// packs maps the IDs of the packs of batch uploads to the files stored
+	// in them.
+	packs map[crypto.Hash][]packMember
+
 	// Download management. The heap has a separate mutex because it is always
 	// accessed in isolation.
-	downloadHeapMu sync.Mutex         // Used to protect the downloadHeap.
-	downloadHeap   *downloadChunkHeap // A heap of priority-sorted chunks to download.
-	newDownloads   chan struct{}      // Used to notify download loop that new downloads are available.
+	downloadHeapMu       sync.Mutex                               // Used to protect the downloadHeap and the pausedDownloadChunks.
+	downloadHeap         *downloadChunkHeap                       // A heap of priority-sorted chunks to download.
+	pausedDownloadChunks map[*download][]*unfinishedDownloadChunk // The chunks of paused downloads, kept out of the heap until they resume.
+	newDownloads         chan struct{}                            // Used to notify download loop that new downloads are available.
 
 	// Download history. The history list has its own mutex because it is always
 	// accessed in isolation.
@@ -189,16 +370,82 @@ type Renter struct {
 	downloadHistory   []*download
 	downloadHistoryMu sync.Mutex
 
-	// Upload management.
-	uploadHeap uploadHeap
+	// Upload management. newScheduledUploads wakes up the scheduled upload
+	// loop when an upload is scheduled. startingScheduledUploads contains
+	// the ids of the scheduled uploads that are being handed to Upload.
+	uploadHeap               uploadHeap
+	newScheduledUploads      chan struct{}
+	startingScheduledUploads map[string]struct{}
 
 	// List of workers that can be used for uploading and/or downloading.
 	memoryManager *memoryManager
+	repairLimiter *repairLimiter
 	workerPool    map[types.FileContractID]*worker
 
+	// throughputHistory counts the bytes transferred by the workers and keeps
+	// the recent throughput samples. The repair estimate uses it as well.
+	throughputHistory *throughputHistory
+
+	// Hosts which are treated as failed for testing purposes, keyed by the
+	// string of their public key. The set has its own mutex because it is
+	// consulted from within the worker and repair code.
+	simulatedHostFailures   map[string]struct{}
+	simulatedHostFailuresMu sync.Mutex
+
+	// The most recent migration of each contract that was cancelled with
+	// migration, keyed by the id the contract had when the migration started.
+	contractMigrations   map[types.FileContractID]*modules.ContractMigration
+	contractMigrationsMu sync.Mutex
+
+	// Files whose masterkey is currently being rotated, keyed by their UID.
+	// rekeyFailures counts the consecutive failed attempts to rekey a chunk
+	// of each file.
+	activeRekeys  map[string]struct{}
+	rekeyFailures map[string]int
+
+	// The most recent user-requested rebuild of each file, keyed by the UID
+	// of the file.
+	fileRebuilds map[string]*fileRebuild
+
+	// The batch uploads since the renter was started, oldest first.
+	uploadBatches []*uploadBatch
+
+	// The paths of the staged data of the streamed uploads that are being
+	// written. Only one stream at a time writes to the same staged data.
+	activeUploadStreams map[string]struct{}
+	uploadStreamMu      sync.Mutex
+
+	// Alerts that need the attention of the user, keyed by an id that
+	// identifies their cause. The alerts have their own mutex because they
+	// are registered from the repair code.
+	alerts   map[string]modules.RenterAlert
+	alertsMu sync.Mutex
+
+	// groupPlacements are the hosts that pieces of files in redundancy
+	// groups are being uploaded to, keyed by group and host. The pieces
+	// aren't part of the files until the uploads finish, so the placements
+	// keep other groups off the hosts in the meantime.
+	groupPlacements   map[string]map[string]*groupPlacement
+	groupPlacementsMu sync.Mutex
+
+	// uploadingSectors counts the uploads of every sector that a worker is
+	// uploading to a host, keyed by the Merkle root and the host. The sectors
+	// may already be stored by the host before they are added to their file,
+	// so they aren't orphaned yet.
+	uploadingSectors   map[uploadingSector]int
+	uploadingSectorsMu sync.Mutex
+
+	// localBackupConfigChanged wakes up the local backup loop when the
+	// configuration of the local snapshots changed.
+	localBackupConfigChanged chan struct{}
+
 	// Cache the last price estimation result.
 	lastEstimation modules.RenterPriceEstimation
 
+	// repairBudgetsChanged is set when a repair budget is charged and cleared
+	// once the budgets are saved.
+	repairBudgetsChanged bool
+
 	// Utilities.
 	staticStreamCache *streamCache
 	cs                modules.ConsensusSet
@@ -213,6 +460,7 @@ type Renter struct {
 	tg                threadgroup.ThreadGroup
 	tpool             modules.TransactionPool
 	wal               *writeaheadlog.WAL
+	wallet            modules.Wallet
 }
 
 // Close closes the Renter and its dependencies
@@ -295,6 +543,21 @@ func (r *Renter) PriceEstimation() modules.RenterPriceEstimation {
 	return est
 }
 
+// RecommendAllowance estimates the cheapest allowance that stores dataSize
+// bytes at the given redundancy for period blocks. The data is spread across as
+// many hosts as the default erasure code of the renter needs to reach the
+// redundancy, which can't exceed the number of pieces of an erasure code.
+func (r *Renter) RecommendAllowance(dataSize uint64, redundancy float64, period types.BlockHeight) (modules.AllowanceRecommendation, error) {
+	if math.IsNaN(redundancy) || math.IsInf(redundancy, 0) || redundancy < 1 {
+		return modules.AllowanceRecommendation{}, errors.New("redundancy must be a number of at least 1")
+	}
+	if pieces := math.Ceil(redundancy * float64(defaultDataPieces)); pieces > maxErasureCodePieces {
+		return modules.AllowanceRecommendation{}, fmt.Errorf("redundancy %v needs %v pieces, at most %v are supported", redundancy, pieces, maxErasureCodePieces)
+	}
+	hosts := uint64(math.Ceil(redundancy * float64(defaultDataPieces)))
+	return r.hostContractor.RecommendAllowance(dataSize, redundancy, hosts, period)
+}
+
 // setBandwidthLimits will change the bandwidth limits of the renter based on
 // the persist values for the bandwidth.
 func (r *Renter) setBandwidthLimits(downloadSpeed int64, uploadSpeed int64) error {
@@ -328,9 +591,18 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	if s.StreamCacheSize <= 0 {
 		return errors.New("stream cache size needs to be 1 or larger")
 	}
+	if s.MaxConcurrentRepairs < minConcurrentRepairs {
+		return fmt.Errorf("max concurrent repairs needs to be %v or larger", minConcurrentRepairs)
+	}
 
-	// Set allowance.
-	err := r.hostContractor.SetAllowance(s.Allowance)
+	// Set allowance. Setting a new allowance while a trial is running
+	// promotes the trial, which keeps and tops up its contracts.
+	var err error
+	if trial := r.hostContractor.Trial(); trial.Active && !reflect.DeepEqual(s.Allowance, trial.Allowance) && !reflect.DeepEqual(s.Allowance, modules.Allowance{}) {
+		err = r.hostContractor.PromoteTrial(s.Allowance)
+	} else {
+		err = r.hostContractor.SetAllowance(s.Allowance)
+	}
 	if err != nil {
 		return err
 	}
@@ -350,6 +622,13 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	}
 	r.persist.StreamCacheSize = s.StreamCacheSize
 
+	// Set the download strategy and the repair concurrency.
+	id := r.mu.Lock()
+	r.persist.CostOptimizedDownloads = s.CostOptimizedDownloads
+	r.persist.MaxConcurrentRepairs = s.MaxConcurrentRepairs
+	r.mu.Unlock(id)
+	r.repairLimiter.SetLimit(s.MaxConcurrentRepairs)
+
 	// Save the changes.
 	err = r.saveSync()
 	if err != nil {
@@ -402,13 +681,68 @@ func (r *Renter) ActiveHosts() []modules.HostDBEntry { return r.hostDB.ActiveHos
 // AllHosts returns an array of all hosts
 func (r *Renter) AllHosts() []modules.HostDBEntry { return r.hostDB.AllHosts() }
 
+// ExportHosts returns all hosts known to the hostdb with their scan histories.
+func (r *Renter) ExportHosts() []modules.HostDBEntry { return r.hostDB.ExportHosts() }
+
 // Host returns the host associated with the given public key
 func (r *Renter) Host(spk types.SiaPublicKey) (modules.HostDBEntry, bool) { return r.hostDB.Host(spk) }
 
+// ImportHosts seeds the hostdb with hosts exported by another node.
+func (r *Renter) ImportHosts(hosts []modules.HostDBEntry) (int, error) {
+	return r.hostDB.ImportHosts(hosts)
+}
+
 // InitialScanComplete returns a boolean indicating if the initial scan of the
 // hostdb is completed.
 func (r *Renter) InitialScanComplete() (bool, error) { return r.hostDB.InitialScanComplete() }
 
+// RescanHosts queues a scan of every host known to the hostdb.
+func (r *Renter) RescanHosts() error { return r.hostDB.RescanHosts() }
+
+// RescanStatus returns the progress of the most recent rescan of the hostdb.
+func (r *Renter) RescanStatus() modules.HostDBRescanStatus { return r.hostDB.RescanStatus() }
+
+// ReputationFeed returns the external reputation feed of the hostdb.
+func (r *Renter) ReputationFeed() modules.HostDBReputationFeed { return r.hostDB.ReputationFeed() }
+
+// SetReputationFeed sets the external reputation feed of the hostdb.
+func (r *Renter) SetReputationFeed(url string, weight float64) error {
+	return r.hostDB.SetReputationFeed(url, weight)
+}
+
+// SetMaxScanHistoryLen sets the maximum length of the scan history of a host.
+func (r *Renter) SetMaxScanHistoryLen(n int) error { return r.hostDB.SetMaxScanHistoryLen(n) }
+
+// SetUptimeHalfLife sets the half-life of the decay of the uptime of the
+// hosts.
+func (r *Renter) SetUptimeHalfLife(halfLife time.Duration) error {
+	return r.hostDB.SetUptimeHalfLife(halfLife)
+}
+
+// SetMaxScanningThreads sets the number of threads that scan hosts
+// concurrently.
+func (r *Renter) SetMaxScanningThreads(n int) error { return r.hostDB.SetMaxScanningThreads(n) }
+
+// AddressFilterRanges returns the prefix lengths of the IPv4 and IPv6 subnets
+// that hosts can't share.
+func (r *Renter) AddressFilterRanges() (ipv4Range, ipv6Range int) {
+	return r.hostDB.AddressFilterRanges()
+}
+
+// SetAddressFilterRanges sets the prefix lengths of the IPv4 and IPv6 subnets
+// that hosts can't share.
+func (r *Renter) SetAddressFilterRanges(ipv4Range, ipv6Range int) error {
+	return r.hostDB.SetAddressFilterRanges(ipv4Range, ipv6Range)
+}
+
+// Filter returns the filter of hosts of the hostdb.
+func (r *Renter) Filter() modules.HostDBFilter { return r.hostDB.Filter() }
+
+// SetFilterMode sets the filter of hosts of the hostdb.
+func (r *Renter) SetFilterMode(mode string, hosts []types.SiaPublicKey) error {
+	return r.hostDB.SetFilterMode(mode, hosts)
+}
+
 // ScoreBreakdown returns the score breakdown
 func (r *Renter) ScoreBreakdown(e modules.HostDBEntry) modules.HostScoreBreakdown {
 	return r.hostDB.ScoreBreakdown(e)
@@ -424,9 +758,112 @@ func (r *Renter) CancelContract(id types.FileContractID) error {
 	return r.hostContractor.CancelContract(id)
 }
 
+// CancelHostContracts cancels every active contract with a host. The files
+// that store pieces on the host lose redundancy, so they are queued for repair
+// right away instead of waiting for the next iteration of the repair loop.
+func (r *Renter) CancelHostContracts(pk types.SiaPublicKey) (modules.RenterHostContractsCancel, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterHostContractsCancel{}, err
+	}
+	defer r.tg.Done()
+	cancelled, err := r.hostContractor.CancelHostContracts(pk)
+	if len(cancelled) == 0 {
+		return modules.RenterHostContractsCancel{}, err
+	}
+
+	lockID := r.mu.RLock()
+	files := make([]*siafile.SiaFile, 0, len(r.files))
+	for _, file := range r.files {
+		files = append(files, file)
+	}
+	r.mu.RUnlock(lockID)
+	var affected []*siafile.SiaFile
+	for _, f := range files {
+		for _, hostKey := range f.HostPublicKeys() {
+			if hostKey.String() == pk.String() {
+				affected = append(affected, f)
+				break
+			}
+		}
+	}
+
+	hosts := r.managedRefreshHostsAndWorkers()
+	lockID = r.mu.RLock()
+	groups := r.redundancyGroups()
+	r.mu.RUnlock(lockID)
+	for _, f := range affected {
+		id := r.mu.Lock()
+		unfinishedChunks := r.buildUnfinishedChunks(f, hosts, groups)
+		r.mu.Unlock(id)
+		for i := 0; i < len(unfinishedChunks); i++ {
+			r.uploadHeap.managedPush(unfinishedChunks[i])
+		}
+	}
+	select {
+	case r.uploadHeap.newUploads <- struct{}{}:
+	default:
+	}
+	return modules.RenterHostContractsCancel{
+		Contracts:     cancelled,
+		AffectedFiles: uint64(len(affected)),
+	}, err
+}
+
+// SetContractMetadata replaces the key/value pairs attached to a contract.
+func (r *Renter) SetContractMetadata(id types.FileContractID, metadata map[string]string) error {
+	return r.hostContractor.SetContractMetadata(id, metadata)
+}
+
+// RegisterWebhook registers a webhook that is notified when a contract is
+// formed, renewed, cancelled or marked as bad.
+func (r *Renter) RegisterWebhook(hook modules.RenterWebhook) error {
+	return r.hostContractor.RegisterWebhook(hook)
+}
+
+// UnregisterWebhook removes the webhook with the given URL.
+func (r *Renter) UnregisterWebhook(url string) error {
+	return r.hostContractor.UnregisterWebhook(url)
+}
+
+// Webhooks returns the webhooks that are notified of contract lifecycle
+// events.
+func (r *Renter) Webhooks() []modules.RenterWebhook {
+	return r.hostContractor.Webhooks()
+}
+
+// AuditLog returns the operations that spent money between start and end,
+// filtered by category.
+func (r *Renter) AuditLog(start, end time.Time, categories []string) ([]modules.RenterAuditEntry, error) {
+	for _, category := range categories {
+		known := false
+		for _, c := range modules.AuditCategories {
+			known = known || c == category
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown audit log category %q", category)
+		}
+	}
+	return r.hostContractor.AuditLog(start, end, categories)
+}
+
+// ExportAuditLog writes the audit log as CSV to the file at dst, which needs
+// to be an absolute path.
+func (r *Renter) ExportAuditLog(dst string) error {
+	if !filepath.IsAbs(dst) {
+		return errors.New("destination of the audit log export must be an absolute path")
+	}
+	return r.hostContractor.ExportAuditLog(dst)
+}
+
 // Contracts returns an array of host contractor's staticContracts
 func (r *Renter) Contracts() []modules.RenterContract { return r.hostContractor.Contracts() }
 
+// ContractRevision returns the signed transaction containing the latest
+// revision of a contract.
+func (r *Renter) ContractRevision(id types.FileContractID) (types.Transaction, bool) {
+	return r.hostContractor.ContractRevision(id)
+}
+
 // OldContracts returns an array of host contractor's oldContracts
 func (r *Renter) OldContracts() []modules.RenterContract {
 	return r.hostContractor.OldContracts()
@@ -441,20 +878,51 @@ func (r *Renter) ContractUtility(pk types.SiaPublicKey) (modules.ContractUtility
 	return r.hostContractor.ContractUtility(pk)
 }
 
+// AllowanceTransition returns the progress of the host contractor towards
+// the most recently set allowance.
+func (r *Renter) AllowanceTransition() modules.AllowanceTransition {
+	return r.hostContractor.AllowanceTransition()
+}
+
+// ContractorFunding returns whether the host contractor is funding contracts
+// partially because the wallet balance is too low.
+func (r *Renter) ContractorFunding() modules.ContractorFunding {
+	return r.hostContractor.ContractorFunding()
+}
+
+// RenewalSchedule returns when the contracts of the host contractor are due
+// for renewal and whether they are expected to be renewed.
+func (r *Renter) RenewalSchedule(within types.BlockHeight) (modules.ContractRenewalSchedule, error) {
+	return r.hostContractor.RenewalSchedule(within)
+}
+
 // PeriodSpending returns the host contractor's period spending
 func (r *Renter) PeriodSpending() modules.ContractorSpending { return r.hostContractor.PeriodSpending() }
 
 // Settings returns the host contractor's allowance
 func (r *Renter) Settings() modules.RenterSettings {
 	download, upload, _ := r.hostContractor.RateLimits()
+	id := r.mu.RLock()
+	costOptimized := r.persist.CostOptimizedDownloads
+	maxRepairs := r.persist.MaxConcurrentRepairs
+	r.mu.RUnlock(id)
 	return modules.RenterSettings{
-		Allowance:        r.hostContractor.Allowance(),
-		MaxDownloadSpeed: download,
-		MaxUploadSpeed:   upload,
-		StreamCacheSize:  r.staticStreamCache.cacheSize,
+		Allowance:              r.hostContractor.Allowance(),
+		MaxDownloadSpeed:       download,
+		MaxUploadSpeed:         upload,
+		StreamCacheSize:        r.staticStreamCache.cacheSize,
+		CostOptimizedDownloads: costOptimized,
+		MaxConcurrentRepairs:   maxRepairs,
 	}
 }
 
+// ActiveRepairs returns the number of chunks that are currently being
+// repaired.
+func (r *Renter) ActiveRepairs() uint64 {
+	active, _ := r.repairLimiter.Status()
+	return active
+}
+
 // ProcessConsensusChange returns the process consensus change
 func (r *Renter) ProcessConsensusChange(cc modules.ConsensusChange) {
 	id := r.mu.Lock()
@@ -462,6 +930,14 @@ func (r *Renter) ProcessConsensusChange(cc modules.ConsensusChange) {
 	r.mu.Unlock(id)
 }
 
+// ProcessHeaderConsensusChange is called instead of ProcessConsensusChange
+// when the consensus set runs in spv mode.
+func (r *Renter) ProcessHeaderConsensusChange(hcc modules.HeaderConsensusChange) {
+	id := r.mu.Lock()
+	r.lastEstimation = modules.RenterPriceEstimation{}
+	r.mu.Unlock(id)
+}
+
 // validateSiapath checks that a Siapath is a legal filename.
 // ../ is disallowed to prevent directory traversal, and paths must not begin
 // with / or be empty.
@@ -502,7 +978,7 @@ func validateSiapath(hyperspacepath string) error {
 var _ modules.Renter = (*Renter)(nil)
 
 // NewCustomRenter initializes a renter and returns it.
-func NewCustomRenter(g modules.Gateway, cs modules.ConsensusSet, tpool modules.TransactionPool, hdb hostDB, hc hostContractor, persistDir string, deps modules.Dependencies) (*Renter, error) {
+func NewCustomRenter(g modules.Gateway, cs modules.ConsensusSet, wallet modules.Wallet, tpool modules.TransactionPool, hdb hostDB, hc hostContractor, persistDir string, deps modules.Dependencies) (*Renter, error) {
 	if g == nil {
 		return nil, errNilGateway
 	}
@@ -512,6 +988,9 @@ func NewCustomRenter(g modules.Gateway, cs modules.ConsensusSet, tpool modules.T
 	if tpool == nil {
 		return nil, errNilTpool
 	}
+	if wallet == nil {
+		return nil, errNilWallet
+	}
 	if hc == nil {
 		return nil, errNilContractor
 	}
@@ -527,16 +1006,39 @@ func NewCustomRenter(g modules.Gateway, cs modules.ConsensusSet, tpool modules.T
 		// download heap loop, searching for a chunk that's not there. This is
 		// preferable to the alternative, where in rare cases the download heap
 		// will miss work altogether.
-		newDownloads: make(chan struct{}, 1),
-		downloadHeap: new(downloadChunkHeap),
+		newDownloads:         make(chan struct{}, 1),
+		downloadHeap:         new(downloadChunkHeap),
+		pausedDownloadChunks: make(map[*download][]*unfinishedDownloadChunk),
 
 		uploadHeap: uploadHeap{
 			activeChunks: make(map[uploadChunkID]struct{}),
 			newUploads:   make(chan struct{}, 1),
 		},
+		newScheduledUploads:      make(chan struct{}, 1),
+		startingScheduledUploads: make(map[string]struct{}),
 
 		workerPool: make(map[types.FileContractID]*worker),
 
+		simulatedHostFailures: make(map[string]struct{}),
+		contractMigrations:    make(map[types.FileContractID]*modules.ContractMigration),
+
+		contentIndex:   make(map[crypto.Hash][]*siafile.SiaFile),
+		contentHashers: make(map[string]*contentHasher),
+
+		groupPlacements: make(map[string]map[string]*groupPlacement),
+		chunkIndex:   make(map[crypto.Hash][]chunkRef),
+		packs:        make(map[crypto.Hash][]packMember),
+
+		activeRekeys:  make(map[string]struct{}),
+		rekeyFailures: make(map[string]int),
+		fileRebuilds:  make(map[string]*fileRebuild),
+		alerts:        make(map[string]modules.RenterAlert),
+
+		activeUploadStreams: make(map[string]struct{}),
+		uploadingSectors:    make(map[uploadingSector]int),
+
+		localBackupConfigChanged: make(chan struct{}, 1),
+
 		cs:             cs,
 		deps:           deps,
 		g:              g,
@@ -545,6 +1047,7 @@ func NewCustomRenter(g modules.Gateway, cs modules.ConsensusSet, tpool modules.T
 		persistDir:     persistDir,
 		mu:             siasync.New(modules.SafeMutexDelay, 1),
 		tpool:          tpool,
+		wallet:         wallet,
 	}
 	r.memoryManager = newMemoryManager(defaultMemory, r.tg.StopChan())
 
@@ -566,16 +1069,32 @@ func NewCustomRenter(g modules.Gateway, cs modules.ConsensusSet, tpool modules.T
 	// Initialize the streaming cache.
 	r.staticStreamCache = newStreamCache(r.persist.StreamCacheSize)
 
+	// Limit the number of concurrent repairs.
+	r.repairLimiter = newRepairLimiter(r.persist.MaxConcurrentRepairs, r.tg.StopChan())
+	r.throughputHistory = newThroughputHistory(time.Now())
+
 	// Subscribe to the consensus set.
-	err = cs.ConsensusSetSubscribe(r, modules.ConsensusChangeRecent, r.tg.StopChan())
+	if cs.SpvMode() {
+		err = cs.HeaderConsensusSetSubscribe(r, modules.ConsensusChangeRecent, r.tg.StopChan())
+	} else {
+		err = cs.ConsensusSetSubscribe(r, modules.ConsensusChangeRecent, r.tg.StopChan())
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	// Keep the alerts about the funding of the allowance up to date.
+	hc.OnFundingChecked(r.managedUpdateFundingAlerts)
+	r.managedUpdateFundingAlerts()
+
 	// Spin up the workers for the work pool.
 	r.managedUpdateWorkerPool()
 	go r.threadedDownloadLoop()
 	go r.threadedUploadLoop()
+	go r.threadedScheduledUploadLoop()
+	go r.threadedLocalBackupLoop()
+	go r.threadedSampleThroughput()
+	go r.threadedSaveRepairBudgets()
 
 	// Kill workers on shutdown.
 	r.tg.OnStop(func() error {
@@ -586,6 +1105,11 @@ func NewCustomRenter(g modules.Gateway, cs modules.ConsensusSet, tpool modules.T
 		r.mu.RUnlock(id)
 		return nil
 	})
+	// Save the repair budgets charged since the last save once all threads
+	// have returned.
+	r.tg.AfterStop(func() error {
+		return r.managedSaveRepairBudgets()
+	})
 
 	return r, nil
 }
@@ -601,5 +1125,5 @@ func New(g modules.Gateway, cs modules.ConsensusSet, wallet modules.Wallet, tpoo
 		return nil, err
 	}
 
-	return NewCustomRenter(g, cs, tpool, hdb, hc, persistDir, modules.ProdDependencies)
+	return NewCustomRenter(g, cs, wallet, tpool, hdb, hc, persistDir, modules.ProdDependencies)
 }
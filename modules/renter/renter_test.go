@@ -1,6 +1,7 @@
 package renter
 
 import (
+	"math"
 	"path/filepath"
 	"reflect"
 	"testing"
@@ -101,15 +102,18 @@ func newRenterTester(name string) (*renterTester, error) {
 
 // stubHostDB is the minimal implementation of the hostDB interface. It can be
 // embedded in other mock hostDB types, removing the need to reimplement all
-// of the hostDB's methods on every mock.
-type stubHostDB struct{}
+// of the hostDB's methods on every mock. The embedded interface is nil, the
+// methods that aren't implemented below panic when called.
+type stubHostDB struct {
+	hostDB
+}
 
 func (stubHostDB) ActiveHosts() []modules.HostDBEntry   { return nil }
 func (stubHostDB) AllHosts() []modules.HostDBEntry      { return nil }
 func (stubHostDB) AverageContractPrice() types.Currency { return types.Currency{} }
 func (stubHostDB) Close() error                         { return nil }
 func (stubHostDB) IsOffline(modules.NetAddress) bool    { return true }
-func (stubHostDB) RandomHosts(int, []types.SiaPublicKey) ([]modules.HostDBEntry, error) {
+func (stubHostDB) RandomHosts(int, []types.SiaPublicKey, []types.SiaPublicKey) ([]modules.HostDBEntry, error) {
 	return []modules.HostDBEntry{}, nil
 }
 func (stubHostDB) EstimateHostScore(modules.HostDBEntry) modules.HostScoreBreakdown {
@@ -228,3 +232,14 @@ func TestRenterSiapathValidate(t *testing.T) {
 		}
 	}
 }
+
+// TestRecommendAllowanceRedundancy checks that redundancies which can't be
+// turned into a number of hosts are rejected before the contractor is asked.
+func TestRecommendAllowanceRedundancy(t *testing.T) {
+	r := new(Renter)
+	for _, redundancy := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), 0.5, maxErasureCodePieces + 1} {
+		if _, err := r.RecommendAllowance(modules.SectorSize, redundancy, 100); err == nil {
+			t.Error("redundancy should have been rejected:", redundancy)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package renter
+
+// repairestimate.go estimates how long the repair loop needs to work through
+// its queue. The repair throughput is the upload throughput of the workers
+// over the last repairThroughputWindow, taken from the throughput history. The
+// chunks in the upload heap are then walked in the order in which they are
+// repaired, and every file is done once its last chunk is done.
+//
+// The estimate is rough. The heap is rebuilt by every iteration of the repair
+// loop, chunks that are already being repaired aren't counted, and the
+// throughput changes with the hosts that the workers upload to.
+
+import (
+	"sort"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// estimateDuration returns the time needed to upload the given number of
+// bytes at the throughput, or 0 if the throughput is unknown.
+func estimateDuration(bytes uint64, throughput float64) time.Duration {
+	if throughput <= 0 {
+		return 0
+	}
+	return time.Duration(float64(bytes) / throughput * float64(time.Second))
+}
+
+// repairEstimate walks through the queued chunks in the order in which they
+// are repaired and estimates when every file is done.
+func repairEstimate(queue []*unfinishedUploadChunk, throughput float64) modules.RenterRepairEstimate {
+	queue = append([]*unfinishedUploadChunk(nil), queue...)
+	sort.SliceStable(queue, func(i, j int) bool {
+		return uploadChunkHeap(queue).Less(i, j)
+	})
+
+	estimate := modules.RenterRepairEstimate{
+		Files:      []modules.FileRepairEstimate{},
+		Throughput: throughput,
+	}
+	files := make(map[string]int)
+	for position, uc := range queue {
+		if uc.piecesCompleted < uc.piecesNeeded {
+			estimate.BytesToRepair += uint64(uc.piecesNeeded-uc.piecesCompleted) * modules.SectorSize
+		}
+		siaPath := uc.renterFile.SiaPath()
+		i, exists := files[siaPath]
+		if !exists {
+			i = len(estimate.Files)
+			files[siaPath] = i
+			estimate.Files = append(estimate.Files, modules.FileRepairEstimate{
+				SiaPath:       siaPath,
+				QueuePosition: uint64(position),
+			})
+		}
+		f := &estimate.Files[i]
+		f.Degraded = f.Degraded || uc.degraded
+		if uc.piecesCompleted < uc.piecesNeeded {
+			f.BytesToRepair += uint64(uc.piecesNeeded-uc.piecesCompleted) * modules.SectorSize
+		}
+		// The file is done once everything up to its last chunk is done.
+		f.EstimatedDuration = estimateDuration(estimate.BytesToRepair, throughput)
+	}
+	estimate.EstimatedDuration = estimateDuration(estimate.BytesToRepair, throughput)
+	return estimate
+}
+
+// RepairEstimate returns the approximate time until the files in the repair
+// queue are repaired.
+func (r *Renter) RepairEstimate() modules.RenterRepairEstimate {
+	r.uploadHeap.mu.Lock()
+	queue := append([]*unfinishedUploadChunk(nil), r.uploadHeap.heap...)
+	r.uploadHeap.mu.Unlock()
+	return repairEstimate(queue, r.throughputHistory.managedUploadThroughput(time.Now()))
+}
@@ -0,0 +1,38 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+)
+
+// TestRepairEstimate checks that the files are estimated in the order in which
+// their chunks are repaired.
+func TestRepairEstimate(t *testing.T) {
+	rsc, _ := siafile.NewRSCode(1, 1)
+	healthy := newFileTesting("healthy", newTestingWal(), rsc, 1000, 0777, "")
+	degraded := newFileTesting("degraded", newTestingWal(), rsc, 1000, 0777, "")
+	queue := []*unfinishedUploadChunk{
+		{renterFile: healthy, piecesNeeded: 2, piecesCompleted: 1},
+		{renterFile: degraded, piecesNeeded: 2, piecesCompleted: 0, degraded: true},
+		{renterFile: healthy, piecesNeeded: 2, piecesCompleted: 0},
+	}
+	throughput := float64(modules.SectorSize)
+	estimate := repairEstimate(queue, throughput)
+	if estimate.BytesToRepair != 5*modules.SectorSize || estimate.EstimatedDuration != 5*time.Second {
+		t.Fatal("unexpected total estimate", estimate.BytesToRepair, estimate.EstimatedDuration)
+	}
+	if len(estimate.Files) != 2 {
+		t.Fatal("expected 2 files, got", len(estimate.Files))
+	}
+	// The degraded file is repaired first.
+	d, h := estimate.Files[0], estimate.Files[1]
+	if d.SiaPath != "degraded" || !d.Degraded || d.QueuePosition != 0 || d.EstimatedDuration != 2*time.Second {
+		t.Fatal("unexpected estimate of the degraded file", d)
+	}
+	if h.SiaPath != "healthy" || h.QueuePosition != 1 || h.BytesToRepair != 3*modules.SectorSize || h.EstimatedDuration != 5*time.Second {
+		t.Fatal("unexpected estimate of the healthy file", h)
+	}
+}
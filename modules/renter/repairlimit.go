@@ -0,0 +1,107 @@
+package renter
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/HyperspaceApp/Hyperspace/build"
+)
+
+// repairLimiter limits the number of chunks that are repaired at the same
+// time. The memory manager already bounds the amount of memory used by the
+// repair, but a few small chunks can fit into memory while still keeping every
+// core busy with erasure coding and saturating the upload bandwidth. Downloads
+// are not limited by the repairLimiter.
+//
+// A slot is requested before the memory of a chunk is requested and returned
+// when the chunk is released from the set of active chunks.
+type repairLimiter struct {
+	active uint64
+	limit  uint64
+
+	// wake is closed and replaced whenever a slot is returned or the limit
+	// is raised, waking up the threads that are waiting for a slot.
+	wake chan struct{}
+
+	mu   sync.Mutex
+	stop <-chan struct{}
+}
+
+// defaultMaxConcurrentRepairs returns the default limit of concurrent chunk
+// repairs. Most of the time of a repair is spent waiting for hosts, so the
+// default limit allows for several repairs per core.
+func defaultMaxConcurrentRepairs() uint64 {
+	limit := uint64(runtime.NumCPU()) * repairsPerCPU
+	if limit < minConcurrentRepairs {
+		limit = minConcurrentRepairs
+	}
+	return limit
+}
+
+// notify wakes up all the threads that are waiting for a slot.
+func (rl *repairLimiter) notify() {
+	close(rl.wake)
+	rl.wake = make(chan struct{})
+}
+
+// Request blocks until a repair slot is available. If 'false' is returned, the
+// renter shut down before a slot became available.
+func (rl *repairLimiter) Request() bool {
+	for {
+		rl.mu.Lock()
+		if rl.active < rl.limit {
+			rl.active++
+			rl.mu.Unlock()
+			return true
+		}
+		wake := rl.wake
+		rl.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-rl.stop:
+			return false
+		}
+	}
+}
+
+// Return returns a repair slot to the limiter.
+func (rl *repairLimiter) Return() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.active == 0 {
+		build.Critical("repair limiter being used incorrectly, too many slots returned")
+		return
+	}
+	rl.active--
+	rl.notify()
+}
+
+// SetLimit changes the number of concurrent repairs. Lowering the limit
+// doesn't interrupt running repairs, new repairs just wait until the number of
+// active repairs dropped below the new limit.
+func (rl *repairLimiter) SetLimit(limit uint64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if limit > rl.limit {
+		rl.notify()
+	}
+	rl.limit = limit
+}
+
+// Status returns the number of active repairs and the limit.
+func (rl *repairLimiter) Status() (active, limit uint64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.active, rl.limit
+}
+
+// newRepairLimiter creates a repairLimiter that allows for 'limit' concurrent
+// repairs.
+func newRepairLimiter(limit uint64, stopChan <-chan struct{}) *repairLimiter {
+	return &repairLimiter{
+		limit: limit,
+		wake:  make(chan struct{}),
+		stop:  stopChan,
+	}
+}
@@ -0,0 +1,62 @@
+package renter
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRepairLimiter checks that the repairLimiter blocks requests beyond the
+// limit until a slot is returned or the limit is raised.
+func TestRepairLimiter(t *testing.T) {
+	stop := make(chan struct{})
+	rl := newRepairLimiter(2, stop)
+	if !rl.Request() || !rl.Request() {
+		t.Fatal("requests within the limit should succeed")
+	}
+
+	// A third request blocks until a slot is returned.
+	granted := make(chan bool)
+	go func() {
+		granted <- rl.Request()
+	}()
+	select {
+	case <-granted:
+		t.Fatal("request beyond the limit shouldn't succeed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	rl.Return()
+	if !<-granted {
+		t.Fatal("request should succeed after a slot was returned")
+	}
+
+	// Raising the limit wakes up waiting requests.
+	go func() {
+		granted <- rl.Request()
+	}()
+	time.Sleep(50 * time.Millisecond)
+	rl.SetLimit(3)
+	if !<-granted {
+		t.Fatal("request should succeed after the limit was raised")
+	}
+	if active, limit := rl.Status(); active != 3 || limit != 3 {
+		t.Fatal("unexpected status", active, limit)
+	}
+
+	// Lowering the limit doesn't affect active repairs, but blocks new ones.
+	rl.SetLimit(1)
+	rl.Return()
+	go func() {
+		granted <- rl.Request()
+	}()
+	select {
+	case <-granted:
+		t.Fatal("request beyond the lowered limit shouldn't succeed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Waiting requests fail on shutdown.
+	close(stop)
+	if <-granted {
+		t.Fatal("request shouldn't succeed after shutdown")
+	}
+}
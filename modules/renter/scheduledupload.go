@@ -0,0 +1,211 @@
+package renter
+
+// scheduledupload.go lets the user queue uploads that start at a later time,
+// e.g. to upload large files during off-peak hours. The scheduled uploads are
+// part of the renter's persistence, so they survive a restart. At the start
+// time, the upload is handed to Upload like any other upload, and it is only
+// marked as started once Upload succeeded. While it is being started, it
+// can't be cancelled anymore. The scheduled uploads that were started or
+// failed to start are kept, so that the user can find out what happened to
+// them.
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+
+	"github.com/HyperspaceApp/errors"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+var (
+	// errNoStartTime is returned if an upload is scheduled without a start
+	// time.
+	errNoStartTime = errors.New("scheduled upload needs a start time")
+
+	// errScheduledUploadNotPending is returned when cancelling a scheduled
+	// upload that was started already.
+	errScheduledUploadNotPending = errors.New("scheduled upload was already started")
+
+	// errUnknownScheduledUpload is returned if no scheduled upload has the
+	// provided id.
+	errUnknownScheduledUpload = errors.New("no scheduled upload with that id")
+)
+
+// scheduledUploadParams converts a scheduled upload into the parameters of
+// Upload.
+func scheduledUploadParams(su modules.ScheduledUpload) (modules.FileUploadParams, error) {
+	up := modules.FileUploadParams{
+		Source:    su.Source,
+		SiaPath:   su.SiaPath,
+		Overwrite: su.Overwrite,
+	}
+	if su.DataPieces != 0 || su.ParityPieces != 0 {
+		ec, err := siafile.NewRSCode(su.DataPieces, su.ParityPieces)
+		if err != nil {
+			return modules.FileUploadParams{}, err
+		}
+		up.ErasureCode = ec
+	}
+	return up, nil
+}
+
+// managedStartDueUploads starts the pending scheduled uploads whose start
+// time is before now. It returns the start time of the next pending upload,
+// or the zero time if there is none.
+func (r *Renter) managedStartDueUploads(now time.Time) time.Time {
+	// Remember the due uploads while they are started, so that they can't be
+	// cancelled anymore.
+	id := r.mu.Lock()
+	var due []modules.ScheduledUpload
+	for _, su := range r.persist.ScheduledUploads {
+		if _, starting := r.startingScheduledUploads[su.ID]; starting {
+			continue
+		}
+		if su.Status == modules.ScheduledUploadPending && !su.StartTime.After(now) {
+			r.startingScheduledUploads[su.ID] = struct{}{}
+			due = append(due, su)
+		}
+	}
+	r.mu.Unlock(id)
+
+	started := make(map[string]struct{})
+	failed := make(map[string]error)
+	for _, su := range due {
+		up, err := scheduledUploadParams(su)
+		if err == nil {
+			err = r.Upload(up)
+		}
+		if err != nil {
+			r.log.Printf("WARN: scheduled upload of %v to %v failed: %v", su.Source, su.SiaPath, err)
+			failed[su.ID] = err
+			continue
+		}
+		started[su.ID] = struct{}{}
+		r.log.Printf("Started scheduled upload of %v to %v", su.Source, su.SiaPath)
+	}
+
+	// Record the outcome of the due uploads.
+	id = r.mu.Lock()
+	defer r.mu.Unlock(id)
+	for _, su := range due {
+		delete(r.startingScheduledUploads, su.ID)
+	}
+	var next time.Time
+	for i := range r.persist.ScheduledUploads {
+		su := &r.persist.ScheduledUploads[i]
+		if err, exists := failed[su.ID]; exists {
+			su.Status = modules.ScheduledUploadFailed
+			su.Error = err.Error()
+		} else if _, exists := started[su.ID]; exists {
+			su.Status = modules.ScheduledUploadStarted
+		}
+		if su.Status == modules.ScheduledUploadPending && (next.IsZero() || su.StartTime.Before(next)) {
+			next = su.StartTime
+		}
+	}
+	if len(due) > 0 {
+		if err := r.saveSync(); err != nil {
+			r.log.Println("ERROR: unable to save the status of the scheduled uploads:", err)
+		}
+	}
+	return next
+}
+
+// threadedScheduledUploadLoop starts the scheduled uploads at their start
+// times. The loop is woken up whenever an upload is scheduled.
+func (r *Renter) threadedScheduledUploadLoop() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		var wait <-chan time.Time
+		if next := r.managedStartDueUploads(time.Now()); !next.IsZero() {
+			wait = time.After(time.Until(next))
+		}
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-r.newScheduledUploads:
+		case <-wait:
+		}
+	}
+}
+
+// CancelScheduledUpload removes a scheduled upload that hasn't been started
+// yet.
+func (r *Renter) CancelScheduledUpload(id string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	lockID := r.mu.Lock()
+	defer r.mu.Unlock(lockID)
+	for i, su := range r.persist.ScheduledUploads {
+		if su.ID != id {
+			continue
+		}
+		if _, starting := r.startingScheduledUploads[id]; starting || su.Status != modules.ScheduledUploadPending {
+			return errScheduledUploadNotPending
+		}
+		r.persist.ScheduledUploads = append(r.persist.ScheduledUploads[:i], r.persist.ScheduledUploads[i+1:]...)
+		if err := r.saveSync(); err != nil {
+			return errors.AddContext(err, "unable to save scheduled uploads")
+		}
+		return nil
+	}
+	return errUnknownScheduledUpload
+}
+
+// ScheduleUpload queues an upload that starts at the start time of su. The
+// siapath, the source and the erasure coding parameters are checked right
+// away, but the source is only read once the upload starts. An upload whose
+// source is gone by then is recorded as failed.
+func (r *Renter) ScheduleUpload(su modules.ScheduledUpload) (modules.ScheduledUpload, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.ScheduledUpload{}, err
+	}
+	defer r.tg.Done()
+	if su.StartTime.IsZero() {
+		return modules.ScheduledUpload{}, errNoStartTime
+	}
+	if err := validateSiapath(su.SiaPath); err != nil {
+		return modules.ScheduledUpload{}, err
+	}
+	if err := validateSource(su.Source); err != nil {
+		return modules.ScheduledUpload{}, err
+	}
+	if _, err := scheduledUploadParams(su); err != nil {
+		return modules.ScheduledUpload{}, errors.AddContext(err, "invalid erasure coding parameters")
+	}
+	su.ID = hex.EncodeToString(fastrand.Bytes(8))
+	su.Status = modules.ScheduledUploadPending
+	su.Error = ""
+
+	id := r.mu.Lock()
+	r.persist.ScheduledUploads = append(r.persist.ScheduledUploads, su)
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return modules.ScheduledUpload{}, errors.AddContext(err, "unable to save scheduled upload")
+	}
+
+	select {
+	case r.newScheduledUploads <- struct{}{}:
+	default:
+	}
+	return su, nil
+}
+
+// ScheduledUploads returns the uploads that were scheduled, including the
+// ones that were started already or failed to start.
+func (r *Renter) ScheduledUploads() []modules.ScheduledUpload {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	return append([]modules.ScheduledUpload(nil), r.persist.ScheduledUploads...)
+}
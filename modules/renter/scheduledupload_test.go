@@ -0,0 +1,84 @@
+package renter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// TestScheduledUploads checks that scheduled uploads can be cancelled before
+// they start but not while they are started, and that an upload whose source
+// was deleted before its start time is recorded as failed.
+func TestScheduledUploads(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	testUploadPath, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(testUploadPath)
+	source := filepath.Join(testUploadPath, "file")
+	if err := ioutil.WriteFile(source, []byte("foo"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	startTime := time.Now().Add(time.Hour)
+	if _, err := rt.renter.ScheduleUpload(modules.ScheduledUpload{SiaPath: "foo", Source: source}); err != errNoStartTime {
+		t.Fatal("expected errNoStartTime, got", err)
+	}
+	cancelled, err := rt.renter.ScheduleUpload(modules.ScheduledUpload{SiaPath: "foo", Source: source, StartTime: startTime})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.renter.CancelScheduledUpload(cancelled.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.renter.CancelScheduledUpload(cancelled.ID); err != errUnknownScheduledUpload {
+		t.Fatal("expected errUnknownScheduledUpload, got", err)
+	}
+
+	// Delete the source before the upload starts.
+	su, err := rt.renter.ScheduleUpload(modules.ScheduledUpload{SiaPath: "foo", Source: source, StartTime: startTime})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := rt.renter.managedStartDueUploads(time.Now()); !next.Equal(su.StartTime) {
+		t.Fatal("expected the start time of the pending upload, got", next)
+	}
+
+	// An upload that is being started can't be cancelled.
+	id := rt.renter.mu.Lock()
+	rt.renter.startingScheduledUploads[su.ID] = struct{}{}
+	rt.renter.mu.Unlock(id)
+	if err := rt.renter.CancelScheduledUpload(su.ID); err != errScheduledUploadNotPending {
+		t.Fatal("expected errScheduledUploadNotPending, got", err)
+	}
+	id = rt.renter.mu.Lock()
+	delete(rt.renter.startingScheduledUploads, su.ID)
+	rt.renter.mu.Unlock(id)
+
+	if err := os.Remove(source); err != nil {
+		t.Fatal(err)
+	}
+	if next := rt.renter.managedStartDueUploads(startTime); !next.IsZero() {
+		t.Fatal("no upload should be pending anymore, got", next)
+	}
+	uploads := rt.renter.ScheduledUploads()
+	if len(uploads) != 1 || uploads[0].Status != modules.ScheduledUploadFailed || uploads[0].Error == "" {
+		t.Fatal("upload should have been recorded as failed", uploads)
+	}
+	if err := rt.renter.CancelScheduledUpload(su.ID); err != errScheduledUploadNotPending {
+		t.Fatal("expected errScheduledUploadNotPending, got", err)
+	}
+}
@@ -43,28 +43,28 @@ func NewFromFileData(fd FileData) (*SiaFile, error) {
 	ecType, ecParams := marshalErasureCoder(fd.ErasureCode)
 	file := &SiaFile{
 		staticMetadata: metadata{
-			AccessTime:              currentTime,
-			ChunkOffset:             defaultReservedMDPages * pageSize,
-			ChangeTime:              currentTime,
-			CreateTime:              currentTime,
-			StaticFileSize:          int64(fd.FileSize),
-			LocalPath:               fd.RepairPath,
-			StaticMasterKey:         mk.Key(),
-			StaticMasterKeyType:     mk.Type(),
-			Mode:                    fd.Mode,
-			ModTime:                 currentTime,
-			staticErasureCode:       fd.ErasureCode,
-			StaticErasureCodeType:   ecType,
-			StaticErasureCodeParams: ecParams,
-			StaticPieceSize:         fd.PieceSize,
-			SiaPath:                 fd.Name,
+			AccessTime:            currentTime,
+			ChunkOffset:           defaultReservedMDPages * pageSize,
+			ChangeTime:            currentTime,
+			CreateTime:            currentTime,
+			StaticFileSize:        int64(fd.FileSize),
+			LocalPath:             fd.RepairPath,
+			MasterKey:             mk.Key(),
+			MasterKeyType:         mk.Type(),
+			Mode:                  fd.Mode,
+			ModTime:               currentTime,
+			erasureCode:           fd.ErasureCode,
+			StaticErasureCodeType: ecType,
+			ErasureCodeParams:     ecParams,
+			StaticPieceSize:       fd.PieceSize,
+			SiaPath:               fd.Name,
 		},
 		deleted:   fd.Deleted,
 		staticUID: fd.UID,
 	}
 	file.staticChunks = make([]chunk, len(fd.Chunks))
 	for i := range file.staticChunks {
-		file.staticChunks[i].Pieces = make([][]Piece, file.staticMetadata.staticErasureCode.NumPieces())
+		file.staticChunks[i].Pieces = make([][]Piece, file.staticMetadata.erasureCode.NumPieces())
 	}
 
 	// Populate the pubKeyTable of the file and add the pieces.
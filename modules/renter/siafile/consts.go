@@ -13,12 +13,24 @@ const (
 	// larger than that, new pages are added on demand.
 	defaultReservedMDPages = 1
 
+	// maxHistoryRecords is the number of history records kept in the
+	// metadata of a file. Older records are dropped.
+	maxHistoryRecords = 32
+
+	// chunkFlagRekeyed is set in the first byte of a chunk's ExtensionInfo if
+	// its pieces are encrypted with the RekeyMasterKey of the file.
+	chunkFlagRekeyed = 1 << 0
+
 	// updateInsertName is the name of a siaFile update that inserts data at a specific index.
 	updateInsertName = "SiaFile-Insert"
 
 	// updateDeleteName is the name of a siaFile update that deletes the
 	// specified file.
 	updateDeleteName = "SiaFile-Delete"
+
+	// updateTruncateName is the name of a siaFile update that truncates the
+	// specified file to a specific size.
+	updateTruncateName = "SiaFile-Truncate"
 )
 
 var (
@@ -30,7 +42,7 @@ var (
 // to the SiaFile package.
 func IsSiaFileUpdate(update writeaheadlog.Update) bool {
 	switch update.Name {
-	case updateInsertName, updateDeleteName:
+	case updateInsertName, updateDeleteName, updateTruncateName:
 		return true
 	default:
 		return false
@@ -0,0 +1,220 @@
+package siafile
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/errors"
+	"github.com/HyperspaceApp/writeaheadlog"
+)
+
+var (
+	// errChunkNotEmpty is returned when trying to share the pieces of another
+	// chunk with a chunk that already has pieces of its own.
+	errChunkNotEmpty = errors.New("the chunk already has pieces")
+
+	// errDedupDisabled is returned when trying to share a chunk of a file that
+	// wasn't uploaded with deduplication.
+	errDedupDisabled = errors.New("deduplication is not enabled for the file")
+)
+
+type (
+	// SharedChunk is the key of a chunk whose pieces were taken from an
+	// identical chunk of another file. The piece keys are derived from
+	// KeyIndex, the index of the chunk the pieces were uploaded for.
+	SharedChunk struct {
+		Key      []byte            `json:"key"`
+		KeyType  crypto.CipherType `json:"keytype"`
+		KeyIndex uint64            `json:"keyindex"`
+	}
+
+	// sharedChunkKey is the CipherKey of a shared chunk. It derives the piece
+	// keys from the index of the chunk the pieces were uploaded for instead of
+	// the index of the chunk within the file.
+	sharedChunkKey struct {
+		crypto.CipherKey
+		keyIndex uint64
+	}
+)
+
+// Derive derives the key of a piece of the shared chunk. The chunk index is
+// ignored in favor of the index the pieces were uploaded for.
+func (sk sharedChunkKey) Derive(_, pieceIndex uint64) crypto.CipherKey {
+	return sk.CipherKey.Derive(sk.keyIndex, pieceIndex)
+}
+
+// sharedChunkKey returns the key of a shared chunk.
+func (sf *SiaFile) sharedChunkKey(sc SharedChunk) crypto.CipherKey {
+	sk, err := crypto.NewSiaKey(sc.KeyType, sc.Key)
+	if err != nil {
+		// The key was validated before it was stored in the metadata.
+		panic(errors.AddContext(err, "failed to create key of shared chunk"))
+	}
+	return sharedChunkKey{CipherKey: sk, keyIndex: sc.KeyIndex}
+}
+
+// ChunkHashes returns the hashes of the plaintext of the chunks of the file.
+// The hashes of chunks which weren't read yet are empty.
+func (sf *SiaFile) ChunkHashes() []crypto.Hash {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return append([]crypto.Hash(nil), sf.staticMetadata.ChunkHashes...)
+}
+
+// Dedup returns true if the chunks of the file may reference identical chunks
+// of other files instead of being uploaded again.
+func (sf *SiaFile) Dedup() bool {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.Dedup
+}
+
+// SetDedup enables or disables the deduplication of the file's chunks. Chunks
+// which are already shared stay shared.
+func (sf *SiaFile) SetDedup(dedup bool) error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.New("can't change a deleted file")
+	}
+	sf.staticMetadata.Dedup = dedup
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveHeader()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetChunkHash sets the hash of the plaintext of a chunk.
+func (sf *SiaFile) SetChunkHash(chunkIndex uint64, hash crypto.Hash) error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.New("can't set the chunk hash of a deleted file")
+	}
+	if chunkIndex >= uint64(len(sf.staticChunks)) {
+		return fmt.Errorf("chunkIndex %v out of bounds (%v)", chunkIndex, len(sf.staticChunks))
+	}
+	if len(sf.staticMetadata.ChunkHashes) != len(sf.staticChunks) {
+		sf.staticMetadata.ChunkHashes = make([]crypto.Hash, len(sf.staticChunks))
+	}
+	if sf.staticMetadata.ChunkHashes[chunkIndex] == hash {
+		return nil
+	}
+	sf.staticMetadata.ChunkHashes[chunkIndex] = hash
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveHeader()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SharedChunkPieces returns the pieces of a chunk together with what another
+// file needs to decrypt them: the key they are encrypted with and the chunk
+// index the piece keys are derived from. If the chunk is shared itself, the
+// key of the chunk it was shared from is returned.
+func (sf *SiaFile) SharedChunkPieces(chunkIndex uint64) ([][]Piece, crypto.CipherKey, uint64, error) {
+	pieces, key, err := sf.ChunkPiecesAndKey(chunkIndex)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if sk, ok := key.(sharedChunkKey); ok {
+		return pieces, sk.CipherKey, sk.keyIndex, nil
+	}
+	return pieces, key, chunkIndex, nil
+}
+
+// ShareChunk sets the pieces of a chunk to the pieces of an identical chunk of
+// another file which are encrypted with key and whose piece keys are derived
+// from keyIndex. The chunk may not have any pieces of its own yet. Pieces
+// that are added to the chunk later, e.g. by the repair loop, are encrypted
+// with the same key.
+func (sf *SiaFile) ShareChunk(chunkIndex uint64, pieces [][]Piece, key crypto.CipherKey, keyIndex uint64) error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.New("can't share a chunk of a deleted file")
+	}
+	if !sf.staticMetadata.Dedup {
+		return errDedupDisabled
+	}
+	if sf.rekeying() {
+		return ErrRekeyInProgress
+	}
+	if chunkIndex >= uint64(len(sf.staticChunks)) {
+		return fmt.Errorf("chunkIndex %v out of bounds (%v)", chunkIndex, len(sf.staticChunks))
+	}
+	// The piece size depends on the overhead of the cipher.
+	if key.Type() != sf.staticMetadata.MasterKeyType {
+		return errors.New("the shared chunk needs to be encrypted with a key of the same type as the masterkey")
+	}
+	for _, pieceSet := range sf.staticChunks[chunkIndex].Pieces {
+		if len(pieceSet) > 0 {
+			return errChunkNotEmpty
+		}
+	}
+
+	// Add hosts we don't know yet to the public key table. Pieces beyond the
+	// number of pieces of the chunk are dropped.
+	tableChanged := false
+	chunkPieces := make([][]Piece, len(sf.staticChunks[chunkIndex].Pieces))
+	for pieceIndex := range chunkPieces {
+		if pieceIndex >= len(pieces) {
+			break
+		}
+		for _, piece := range pieces[pieceIndex] {
+			known := false
+			for _, hpk := range sf.pubKeyTable {
+				if hpk.Algorithm == piece.HostPubKey.Algorithm && bytes.Equal(hpk.Key, piece.HostPubKey.Key) {
+					known = true
+					break
+				}
+			}
+			if !known {
+				sf.pubKeyTable = append(sf.pubKeyTable, piece.HostPubKey)
+				tableChanged = true
+			}
+		}
+		chunkPieces[pieceIndex] = append([]Piece(nil), pieces[pieceIndex]...)
+	}
+	sf.staticChunks[chunkIndex].Pieces = chunkPieces
+	if sf.staticMetadata.SharedChunks == nil {
+		sf.staticMetadata.SharedChunks = make(map[uint64]SharedChunk)
+	}
+	sf.staticMetadata.SharedChunks[chunkIndex] = SharedChunk{
+		Key:      key.Key(),
+		KeyType:  key.Type(),
+		KeyIndex: keyIndex,
+	}
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	var updates []writeaheadlog.Update
+	var err error
+	if tableChanged {
+		updates, err = sf.saveHeader()
+	} else {
+		updates, err = sf.saveMetadata()
+	}
+	if err != nil {
+		return err
+	}
+	chunksUpdate, err := sf.saveChunks()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(append(updates, chunksUpdate)...)
+}
+
+// SharedChunks returns the number of chunks of the file whose pieces were
+// taken from another file.
+func (sf *SiaFile) SharedChunks() uint64 {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return uint64(len(sf.staticMetadata.SharedChunks))
+}
@@ -0,0 +1,100 @@
+package siafile
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+// TestShareChunk tests sharing the pieces of a chunk between files and makes
+// sure that the piece keys of the shared chunk match the ones of the chunk it
+// was shared from, also after reloading the file from disk.
+func TestShareChunk(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create two encrypted files with 2 chunks.
+	rc, err := NewRSCode(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(os.TempDir(), "siafiles")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	wal := newTestWAL()
+	fileSize := 2 * (modules.SectorSize - crypto.TypeTwofish.Overhead())
+	newFile := func() *SiaFile {
+		siaPath := hex.EncodeToString(fastrand.Bytes(8))
+		sf, err := New(filepath.Join(dir, siaPath), siaPath, "", wal, rc, crypto.GenerateSiaKey(crypto.TypeTwofish), fileSize, 0777)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sf
+	}
+	source, sf := newFile(), newFile()
+
+	// The first chunk of the source is identical to the second chunk of sf.
+	hostKey := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+	if err := source.AddPiece(hostKey, 0, 0, crypto.Hash{1}); err != nil {
+		t.Fatal(err)
+	}
+	pieces, key, keyIndex, err := source.SharedChunkPieces(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.ShareChunk(1, pieces, key, keyIndex); err != errDedupDisabled {
+		t.Fatal("expected errDedupDisabled but got", err)
+	}
+	if err := sf.SetDedup(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.ShareChunk(1, pieces, key, keyIndex); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.ShareChunk(1, pieces, key, keyIndex); err != errChunkNotEmpty {
+		t.Fatal("expected errChunkNotEmpty but got", err)
+	}
+	if err := sf.SetChunkHash(1, crypto.Hash{2}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The shared chunk should survive reloading the file.
+	sf, err = LoadSiaFile(sf.siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.SharedChunks() != 1 {
+		t.Fatal("expected 1 shared chunk but got", sf.SharedChunks())
+	}
+	if hashes := sf.ChunkHashes(); len(hashes) != 2 || hashes[1] != (crypto.Hash{2}) {
+		t.Fatal("wrong chunk hashes", hashes)
+	}
+	sharedPieces, err := sf.Pieces(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sharedPieces[0]) != 1 || sharedPieces[0][0].MerkleRoot != (crypto.Hash{1}) {
+		t.Fatal("the pieces weren't shared", sharedPieces)
+	}
+	if !bytes.Equal(sf.ChunkKey(1).Derive(1, 1).Key(), source.ChunkKey(0).Derive(0, 1).Key()) {
+		t.Fatal("the piece keys of the shared chunk don't match the source")
+	}
+	if bytes.Equal(sf.ChunkKey(0).Derive(0, 1).Key(), source.ChunkKey(0).Derive(0, 1).Key()) {
+		t.Fatal("the chunk that isn't shared shouldn't use the key of the source")
+	}
+
+	// Sharing the shared chunk again should refer to the original source.
+	if _, _, keyIndex, err := sf.SharedChunkPieces(1); err != nil || keyIndex != 0 {
+		t.Fatal("expected the key index of the source but got", keyIndex, err)
+	}
+}
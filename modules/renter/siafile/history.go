@@ -0,0 +1,94 @@
+package siafile
+
+import (
+	"time"
+)
+
+// HistoryRecord describes how the distribution of a file's pieces across hosts
+// changed since the previous record. Only pieces on hosts that are online and
+// goodForRenew are counted. Hosts are referenced by their index in the public
+// key table of the file, which never shrinks, so the indices stay valid.
+type HistoryRecord struct {
+	Time        time.Time `json:"time"`
+	Redundancy  float64   `json:"redundancy"`
+	PiecesAdded uint64    `json:"piecesadded"`
+	PiecesLost  uint64    `json:"pieceslost"`
+	HostsAdded  []uint32  `json:"hostsadded"`
+	HostsLost   []uint32  `json:"hostslost"`
+}
+
+// History returns the history records of the file, starting with the oldest.
+func (sf *SiaFile) History() []HistoryRecord {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return append([]HistoryRecord(nil), sf.staticMetadata.History...)
+}
+
+// RecordHistory compares the number of usable pieces every host stores against
+// the snapshot taken when the last record was added. If any pieces were added
+// or lost, a new record is appended to the history and the snapshot is
+// replaced. The history is bounded by maxHistoryRecords. It returns true if a
+// record was added.
+func (sf *SiaFile) RecordHistory(offlineMap map[string]bool, goodForRenewMap map[string]bool) (bool, error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return false, nil
+	}
+
+	tableIndices := make(map[string]int, len(sf.pubKeyTable))
+	for i, pk := range sf.pubKeyTable {
+		tableIndices[string(pk.Key)] = i
+	}
+	hostPieces := make([]uint64, len(sf.pubKeyTable))
+	for _, chunk := range sf.staticChunks {
+		for _, pieceSet := range chunk.Pieces {
+			for _, piece := range pieceSet {
+				key := string(piece.HostPubKey.Key)
+				if offlineMap[key] || !goodForRenewMap[key] {
+					continue
+				}
+				hostPieces[tableIndices[key]]++
+			}
+		}
+	}
+
+	// The snapshot is shorter than the table if hosts were added since it was
+	// taken.
+	var record HistoryRecord
+	snapshot := sf.staticMetadata.HistorySnapshot
+	for i, pieces := range hostPieces {
+		var previous uint64
+		if i < len(snapshot) {
+			previous = snapshot[i]
+		}
+		if pieces > previous {
+			record.PiecesAdded += pieces - previous
+		} else {
+			record.PiecesLost += previous - pieces
+		}
+		if previous == 0 && pieces > 0 {
+			record.HostsAdded = append(record.HostsAdded, uint32(i))
+		} else if previous > 0 && pieces == 0 {
+			record.HostsLost = append(record.HostsLost, uint32(i))
+		}
+	}
+	if record.PiecesAdded == 0 && record.PiecesLost == 0 {
+		return false, nil
+	}
+	record.Time = time.Now()
+	record.Redundancy = sf.redundancy(offlineMap, goodForRenewMap)
+
+	history := append(sf.staticMetadata.History, record)
+	if len(history) > maxHistoryRecords {
+		history = append([]HistoryRecord(nil), history[len(history)-maxHistoryRecords:]...)
+	}
+	sf.staticMetadata.History = history
+	sf.staticMetadata.HistorySnapshot = hostPieces
+
+	updates, err := sf.saveMetadata()
+	if err != nil {
+		return false, err
+	}
+	return true, sf.createAndApplyTransaction(updates...)
+}
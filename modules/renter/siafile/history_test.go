@@ -0,0 +1,84 @@
+package siafile
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestRecordHistory checks that a history record is only added if the
+// distribution of the usable pieces changed and that the history is bounded.
+func TestRecordHistory(t *testing.T) {
+	sf := newTestFile()
+	hostKey := func(b byte) types.SiaPublicKey {
+		return types.SiaPublicKey{Key: []byte{b}}
+	}
+	offline := map[string]bool{
+		string(hostKey(1).Key): false,
+		string(hostKey(2).Key): false,
+	}
+	goodForRenew := map[string]bool{
+		string(hostKey(1).Key): true,
+		string(hostKey(2).Key): true,
+	}
+
+	// A new file has nothing to record.
+	if added, err := sf.RecordHistory(offline, goodForRenew); err != nil || added {
+		t.Fatal("empty file shouldn't have a history record", added, err)
+	}
+
+	if err := sf.AddPiece(hostKey(1), 0, 0, crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.AddPiece(hostKey(1), 0, 1, crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.AddPiece(hostKey(2), 0, 2, crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if added, err := sf.RecordHistory(offline, goodForRenew); err != nil || !added {
+		t.Fatal("expected a history record", added, err)
+	}
+	history := sf.History()
+	if len(history) != 1 {
+		t.Fatal("expected 1 record but got", len(history))
+	}
+	if history[0].PiecesAdded != 3 || history[0].PiecesLost != 0 || len(history[0].HostsAdded) != 2 {
+		t.Fatal("unexpected record", history[0])
+	}
+
+	// Nothing changed.
+	if added, err := sf.RecordHistory(offline, goodForRenew); err != nil || added {
+		t.Fatal("unchanged file shouldn't get a history record", added, err)
+	}
+
+	// Host 1 is no longer goodForRenew.
+	goodForRenew[string(hostKey(1).Key)] = false
+	if added, err := sf.RecordHistory(offline, goodForRenew); err != nil || !added {
+		t.Fatal("expected a history record", added, err)
+	}
+	history = sf.History()
+	if len(history) != 2 {
+		t.Fatal("expected 2 records but got", len(history))
+	}
+	lost := history[1]
+	if lost.PiecesLost != 2 || lost.PiecesAdded != 0 || len(lost.HostsLost) != 1 || len(lost.HostsAdded) != 0 {
+		t.Fatal("unexpected record", lost)
+	}
+	lostKey := hostKey(1)
+	if sf.pubKeyTable[lost.HostsLost[0]].String() != lostKey.String() {
+		t.Fatal("wrong host was lost")
+	}
+
+	// The history is bounded.
+	for i := 0; i < maxHistoryRecords; i++ {
+		goodForRenew[string(hostKey(1).Key)] = !goodForRenew[string(hostKey(1).Key)]
+		if _, err := sf.RecordHistory(offline, goodForRenew); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(sf.History()) != maxHistoryRecords {
+		t.Fatalf("expected %v records but got %v", maxHistoryRecords, len(sf.History()))
+	}
+}
@@ -22,12 +22,63 @@ type (
 		LocalPath       string   `json:"localpath"` // file to the local copy of the file used for repairing
 		SiaPath         string   `json:"siapath"`   // the path of the file on the Sia network
 
+		// ContentHash identifies the plaintext of the file. It is the hash of
+		// the plaintext and is set once every chunk of the file was read for
+		// its upload. Files uploaded before it was introduced have an empty
+		// content hash.
+		ContentHash crypto.Hash `json:"contenthash"`
+
+		// Dedup is set if the chunks of the file may reference the pieces of
+		// identical chunks of other files instead of being uploaded again.
+		// ChunkHashes contains the hash of the plaintext of every chunk that
+		// was read, SharedChunks the key of every chunk whose pieces were
+		// taken from another file or which kept the new key of a cancelled
+		// rekey.
+		Dedup        bool                   `json:"dedup"`
+		ChunkHashes  []crypto.Hash          `json:"chunkhashes"`
+		SharedChunks map[uint64]SharedChunk `json:"sharedchunks"`
+
+		// Pack is set if the file was uploaded as part of a batch and its
+		// data is stored within a chunk that is shared with other small
+		// files. The file has a single chunk whose key is the key of the
+		// pack.
+		Pack *PackedChunk `json:"pack"`
+
+		// SpareHosts is the number of hosts beyond the number of pieces that
+		// every chunk should be spread across. The spare hosts store
+		// additional copies of the pieces with the fewest copies.
+		SpareHosts uint64 `json:"sparehosts"`
+
+		// RedundancyGroup is the name of the redundancy group of the file.
+		// New pieces of the file are kept off the hosts that store pieces of
+		// files in other redundancy groups. Empty if the file isn't part of
+		// a group.
+		RedundancyGroup string `json:"redundancygroup"`
+
+		// Degraded is set if the file was uploaded with fewer contracts than
+		// its erasure code needs. It is cleared once the file reaches its full
+		// redundancy.
+		Degraded bool `json:"degraded"`
+
+		// History contains the most recent changes of the distribution of
+		// the file's pieces across hosts. HistorySnapshot is the number of
+		// usable pieces stored on each host of the pubKeyTable when the last
+		// record was added, it is the baseline of the next record.
+		History         []HistoryRecord `json:"history"`
+		HistorySnapshot []uint64        `json:"historysnapshot"`
+
 		// fields for encryption
-		StaticMasterKey      []byte            `json:"masterkey"` // masterkey used to encrypt pieces
-		StaticMasterKeyType  crypto.CipherType `json:"masterkeytype"`
+		MasterKey            []byte            `json:"masterkey"` // masterkey used to encrypt pieces
+		MasterKeyType        crypto.CipherType `json:"masterkeytype"`
 		StaticSharingKey     []byte            `json:"sharingkey"` // key used to encrypt shared pieces
 		StaticSharingKeyType crypto.CipherType `json:"sharingkeytype"`
 
+		// While the masterkey is being rotated, the chunks flagged as rekeyed
+		// are already encrypted with the RekeyMasterKey. Once every chunk is
+		// rekeyed, it replaces the MasterKey.
+		RekeyMasterKey     []byte            `json:"rekeymasterkey"`
+		RekeyMasterKeyType crypto.CipherType `json:"rekeymasterkeytype"`
+
 		// The following fields are the usual unix timestamps of files.
 		ModTime    time.Time `json:"modtime"`    // time of last content modification
 		ChangeTime time.Time `json:"changetime"` // time of last metadata modification
@@ -65,13 +116,16 @@ type (
 		//   0 - Invalid / Missing Code
 		//   1 - Reed Solomon Code
 		//
-		// erasureCodeParams specifies possible parameters for a certain
+		// ErasureCodeParams specifies possible parameters for a certain
 		// StaticErasureCodeType. Currently params will be parsed as follows:
 		//   Reed Solomon Code - 4 bytes dataPieces / 4 bytes parityPieces
+		// The number of parityPieces can be changed after the file was
+		// created, the number of dataPieces determines the chunk size and
+		// is therefore static.
 		//
-		StaticErasureCodeType   [4]byte              `json:"erasurecodetype"`
-		StaticErasureCodeParams [8]byte              `json:"erasurecodeparams"`
-		staticErasureCode       modules.ErasureCoder // not persisted, exists for convenience
+		StaticErasureCodeType [4]byte              `json:"erasurecodetype"`
+		ErasureCodeParams     [8]byte              `json:"erasurecodeparams"`
+		erasureCode           modules.ErasureCoder // not persisted, exists for convenience
 	}
 )
 
@@ -89,6 +143,14 @@ func (sf *SiaFile) ChangeTime() time.Time {
 	return sf.staticMetadata.ChangeTime
 }
 
+// ContentHash returns the hash of the plaintext of the file. It is empty if the
+// hash isn't known.
+func (sf *SiaFile) ContentHash() crypto.Hash {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.ContentHash
+}
+
 // CreateTime returns the CreateTime timestamp of the file.
 func (sf *SiaFile) CreateTime() time.Time {
 	sf.mu.RLock()
@@ -98,7 +160,17 @@ func (sf *SiaFile) CreateTime() time.Time {
 
 // ChunkSize returns the size of a single chunk of the file.
 func (sf *SiaFile) ChunkSize() uint64 {
-	return sf.staticChunkSize()
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.chunkSize()
+}
+
+// Degraded returns true if the file was uploaded with fewer contracts than its
+// erasure code needs and hasn't reached its full redundancy yet.
+func (sf *SiaFile) Degraded() bool {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.Degraded
 }
 
 // Delete removes the file from disk and marks it as deleted. Once the file is
@@ -158,7 +230,15 @@ func (sf *SiaFile) LocalPath() string {
 
 // MasterKey returns the masterkey used to encrypt the file.
 func (sf *SiaFile) MasterKey() crypto.CipherKey {
-	sk, err := crypto.NewSiaKey(sf.staticMetadata.StaticMasterKeyType, sf.staticMetadata.StaticMasterKey)
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.masterKey()
+}
+
+// masterKey returns the masterkey used to encrypt the file without acquiring
+// the lock.
+func (sf *SiaFile) masterKey() crypto.CipherKey {
+	sk, err := crypto.NewSiaKey(sf.staticMetadata.MasterKeyType, sf.staticMetadata.MasterKey)
 	if err != nil {
 		// This should never happen since the constructor of the SiaFile takes
 		// a CipherKey as an argument which guarantees that it is already a
@@ -237,6 +317,133 @@ func (sf *SiaFile) SetMode(mode os.FileMode) error {
 	return sf.createAndApplyTransaction(updates...)
 }
 
+// SetErasureCode changes the number of parity pieces the file's chunks are
+// encoded into. The number of data pieces determines the size of a chunk and
+// can't be changed. The existing pieces stay valid since a Reed-Solomon parity
+// piece only depends on its index and the data pieces. When the number of
+// pieces grows, the chunks get empty slots for the new parity pieces which
+// are filled in by the repair loop.
+// When it shrinks, the surplus pieces are dropped from the file and the file
+// is truncated to the size of the remaining chunks. The hosts keep storing
+// the dropped pieces until their contracts expire.
+func (sf *SiaFile) SetErasureCode(ec modules.ErasureCoder) error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.New("can't change the erasure code of a deleted file")
+	}
+	// Rekeying replaces the pieces of a chunk with the same number of pieces
+	// encrypted under the new key.
+	if sf.rekeying() {
+		return ErrRekeyInProgress
+	}
+	// The files of a pack share their pieces and need to use the same
+	// erasure code.
+	if sf.staticMetadata.Pack != nil {
+		return errors.New("can't change the erasure code of a file which is stored in a pack")
+	}
+	if ec.MinPieces() != sf.staticMetadata.erasureCode.MinPieces() {
+		return ErrDataPiecesChanged
+	}
+	ecType, ecParams := marshalErasureCoder(ec)
+	if ecType != sf.staticMetadata.StaticErasureCodeType {
+		return errors.New("can't change the type of the erasure code")
+	}
+	sf.staticMetadata.erasureCode = ec
+	sf.staticMetadata.ErasureCodeParams = ecParams
+	sf.staticMetadata.ChangeTime = time.Now()
+	for i := range sf.staticChunks {
+		pieces := sf.staticChunks[i].Pieces
+		if len(pieces) > ec.NumPieces() {
+			pieces = pieces[:ec.NumPieces()]
+		}
+		for len(pieces) < ec.NumPieces() {
+			pieces = append(pieces, nil)
+		}
+		sf.staticChunks[i].Pieces = pieces
+	}
+
+	updates, err := sf.saveMetadata()
+	if err != nil {
+		return err
+	}
+	chunksUpdates, err := sf.saveChunksTruncated()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(append(updates, chunksUpdates...)...)
+}
+
+// SetContentHash sets the hash of the plaintext of the file.
+func (sf *SiaFile) SetContentHash(hash crypto.Hash) error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.New("can't set the content hash of a deleted file")
+	}
+	sf.staticMetadata.ContentHash = hash
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveHeader()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetDegraded sets whether the file is still missing pieces that couldn't be
+// placed during its upload.
+func (sf *SiaFile) SetDegraded(degraded bool) error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.New("can't change a deleted file")
+	}
+	sf.staticMetadata.Degraded = degraded
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveHeader()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetRedundancyGroup sets the name of the redundancy group of the file.
+func (sf *SiaFile) SetRedundancyGroup(group string) error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.New("can't set the redundancy group of a deleted file")
+	}
+	sf.staticMetadata.RedundancyGroup = group
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveHeader()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetSpareHosts sets the number of spare hosts that store additional copies of
+// the pieces of every chunk.
+func (sf *SiaFile) SetSpareHosts(spareHosts uint64) error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.New("can't set the spare hosts of a deleted file")
+	}
+	sf.staticMetadata.SpareHosts = spareHosts
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveHeader()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
 // SetLocalPath changes the local path of the file which is used to repair
 // the file from disk.
 func (sf *SiaFile) SetLocalPath(path string) error {
@@ -252,6 +459,13 @@ func (sf *SiaFile) SetLocalPath(path string) error {
 	return sf.createAndApplyTransaction(updates...)
 }
 
+// RedundancyGroup returns the name of the redundancy group of the file.
+func (sf *SiaFile) RedundancyGroup() string {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.RedundancyGroup
+}
+
 // SiaPath returns the file's sia path.
 func (sf *SiaFile) SiaPath() string {
 	sf.mu.RLock()
@@ -264,6 +478,14 @@ func (sf *SiaFile) Size() uint64 {
 	return uint64(sf.staticMetadata.StaticFileSize)
 }
 
+// SpareHosts returns the number of spare hosts that store additional copies of
+// the pieces of every chunk.
+func (sf *SiaFile) SpareHosts() uint64 {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.SpareHosts
+}
+
 // UpdateAccessTime updates the AccessTime timestamp to the current time.
 func (sf *SiaFile) UpdateAccessTime() error {
 	sf.mu.Lock()
@@ -308,7 +530,7 @@ func (sf *SiaFile) UploadProgress() float64 {
 	return math.Min(100*(float64(uploaded)/float64(desired)), 100)
 }
 
-// ChunkSize returns the size of a single chunk of the file.
-func (sf *SiaFile) staticChunkSize() uint64 {
-	return sf.staticMetadata.StaticPieceSize * uint64(sf.staticMetadata.staticErasureCode.MinPieces())
+// chunkSize returns the size of a single chunk of the file.
+func (sf *SiaFile) chunkSize() uint64 {
+	return sf.staticMetadata.StaticPieceSize * uint64(sf.staticMetadata.erasureCode.MinPieces())
 }
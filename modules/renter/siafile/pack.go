@@ -0,0 +1,77 @@
+package siafile
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/errors"
+)
+
+// PackedChunk describes where the data of a small file is stored within a
+// chunk that is shared by several files. All files of a pack have the same
+// pieces, the data of a file starts at Offset within the logical data of the
+// chunk. Length is the number of bytes of the chunk that are used by all the
+// files of the pack.
+type PackedChunk struct {
+	ID     crypto.Hash `json:"id"`
+	Offset uint64      `json:"offset"`
+	Length uint64      `json:"length"`
+}
+
+// Packed returns the location of the file within its pack and true if the
+// file is stored in a chunk that is shared with other files.
+func (sf *SiaFile) Packed() (PackedChunk, bool) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	if sf.staticMetadata.Pack == nil {
+		return PackedChunk{}, false
+	}
+	return *sf.staticMetadata.Pack, true
+}
+
+// SetPacked stores the file within a chunk that is shared with other files.
+// The pieces of the chunk are encrypted with key, which all files of the pack
+// share. The file needs to fit into a single chunk and may not have any
+// pieces yet.
+func (sf *SiaFile) SetPacked(key crypto.CipherKey, pack PackedChunk) error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.New("can't pack a deleted file")
+	}
+	if sf.rekeying() {
+		return ErrRekeyInProgress
+	}
+	if len(sf.staticChunks) != 1 {
+		return fmt.Errorf("only files with a single chunk can be packed, file has %v", len(sf.staticChunks))
+	}
+	if pack.Offset+uint64(sf.staticMetadata.StaticFileSize) > pack.Length || pack.Length > sf.chunkSize() {
+		return errors.New("file doesn't fit into the pack")
+	}
+	// The piece size depends on the overhead of the cipher.
+	if key.Type() != sf.staticMetadata.MasterKeyType {
+		return errors.New("the pack needs to be encrypted with a key of the same type as the masterkey")
+	}
+	for _, pieceSet := range sf.staticChunks[0].Pieces {
+		if len(pieceSet) > 0 {
+			return errChunkNotEmpty
+		}
+	}
+	sf.staticMetadata.SharedChunks = map[uint64]SharedChunk{
+		0: {
+			Key:      key.Key(),
+			KeyType:  key.Type(),
+			KeyIndex: 0,
+		},
+	}
+	sf.staticMetadata.Pack = &pack
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveHeader()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
@@ -0,0 +1,77 @@
+package siafile
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+// TestSetPacked tests storing small files in a shared pack and makes sure the
+// files decrypt the pack with the same key, also after reloading them from
+// disk.
+func TestSetPacked(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rc, err := NewRSCode(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(os.TempDir(), "siafiles")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	wal := newTestWAL()
+	newFile := func(size uint64) *SiaFile {
+		siaPath := hex.EncodeToString(fastrand.Bytes(8))
+		sf, err := New(filepath.Join(dir, siaPath), siaPath, "", wal, rc, crypto.GenerateSiaKey(crypto.TypeTwofish), size, 0777)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sf
+	}
+	sf1, sf2 := newFile(100), newFile(200)
+	if _, packed := sf1.Packed(); packed {
+		t.Fatal("new file shouldn't be packed")
+	}
+
+	key := crypto.GenerateSiaKey(crypto.TypeTwofish)
+	pack := PackedChunk{ID: crypto.Hash{1}, Length: 300}
+	if err := sf1.SetPacked(key, pack); err != nil {
+		t.Fatal(err)
+	}
+	pack.Offset = 100
+	if err := sf2.SetPacked(key, pack); err != nil {
+		t.Fatal(err)
+	}
+	pack.Offset = 101
+	if err := newFile(200).SetPacked(key, pack); err == nil {
+		t.Fatal("file beyond the end of the pack shouldn't be accepted")
+	}
+	if err := newFile(100).SetPacked(crypto.GenerateSiaKey(crypto.TypePlain), PackedChunk{Length: 100}); err == nil {
+		t.Fatal("key of a different type shouldn't be accepted")
+	}
+
+	// Both files derive the same piece keys after reloading them.
+	sf2, err = LoadSiaFile(sf2.siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, packed := sf2.Packed()
+	if !packed || p.ID != (crypto.Hash{1}) || p.Offset != 100 || p.Length != 300 {
+		t.Fatal("wrong pack after reloading the file", p, packed)
+	}
+	k1, k2 := sf1.ChunkKey(0).Derive(0, 1), sf2.ChunkKey(0).Derive(0, 1)
+	if string(k1.Key()) != string(k2.Key()) {
+		t.Fatal("files of a pack should derive the same piece keys")
+	}
+	if _, err := sf2.StartRekey(crypto.GenerateSiaKey(crypto.TypeTwofish)); err == nil {
+		t.Fatal("packed file shouldn't be rekeyed")
+	}
+}
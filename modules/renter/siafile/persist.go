@@ -9,6 +9,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/HyperspaceApp/Hyperspace/build"
 	"github.com/HyperspaceApp/Hyperspace/encoding"
@@ -34,6 +35,19 @@ func ApplyUpdates(updates ...writeaheadlog.Update) error {
 					return err
 				}
 			}
+			// Check if it is a truncate update.
+			if u.Name == updateTruncateName {
+				path, size, err := readTruncateUpdate(u)
+				if err != nil {
+					return err
+				}
+				if err := os.Truncate(path, size); os.IsNotExist(err) {
+					return nil
+				} else if err != nil {
+					return err
+				}
+				return nil
+			}
 
 			// Decode update.
 			path, index, data, err := readInsertUpdate(u)
@@ -84,7 +98,7 @@ func LoadSiaFile(path string, wal *writeaheadlog.WAL) (*SiaFile, error) {
 		return nil, errors.AddContext(err, "failed to decode metadata")
 	}
 	// Create the erasure coder.
-	sf.staticMetadata.staticErasureCode, err = unmarshalErasureCoder(sf.staticMetadata.StaticErasureCodeType, sf.staticMetadata.StaticErasureCodeParams)
+	sf.staticMetadata.erasureCode, err = unmarshalErasureCoder(sf.staticMetadata.StaticErasureCodeType, sf.staticMetadata.ErasureCodeParams)
 	if err != nil {
 		return nil, err
 	}
@@ -186,7 +200,7 @@ func unmarshalMetadata(raw []byte) (md metadata, err error) {
 	err = json.Unmarshal(raw, &md)
 
 	// We also need to create the erasure coder object.
-	md.staticErasureCode, err = unmarshalErasureCoder(md.StaticErasureCodeType, md.StaticErasureCodeParams)
+	md.erasureCode, err = unmarshalErasureCoder(md.StaticErasureCodeType, md.ErasureCodeParams)
 	if err != nil {
 		return
 	}
@@ -228,6 +242,16 @@ func readInsertUpdate(update writeaheadlog.Update) (path string, index int64, da
 	return
 }
 
+// readTruncateUpdate unmarshals the update's instructions and returns the path
+// and size encoded in the instructions.
+func readTruncateUpdate(update writeaheadlog.Update) (path string, size int64, err error) {
+	if !IsSiaFileUpdate(update) {
+		panic("readUpdate can't read non-SiaFile update")
+	}
+	err = encoding.UnmarshalAll(update.Instructions, &path, &size)
+	return
+}
+
 // allocateHeaderPage allocates a new page for the metadata and publicKeyTable.
 // It returns an update that moves the chunkData back by one pageSize if
 // applied and also updates the ChunkOffset of the metadata.
@@ -291,6 +315,17 @@ func (sf *SiaFile) applyUpdates(updates ...writeaheadlog.Update) (err error) {
 				}
 				return err
 			}
+			// Check if it is a truncate update.
+			if u.Name == updateTruncateName {
+				path, size, err := readTruncateUpdate(u)
+				if err != nil {
+					return err
+				}
+				if sf.siaFilePath != path {
+					panic(fmt.Sprintf("can't apply update for file %s to SiaFile %s", path, sf.siaFilePath))
+				}
+				return f.Truncate(size)
+			}
 			// Decode update.
 			path, index, data, err := readInsertUpdate(u)
 			if err != nil {
@@ -351,6 +386,18 @@ func (sf *SiaFile) createDeleteUpdate() writeaheadlog.Update {
 	}
 }
 
+// createTruncateUpdate is a helper method which creates a writeaheadlog update
+// for truncating the SiaFile to the provided size.
+func (sf *SiaFile) createTruncateUpdate(size int64) writeaheadlog.Update {
+	if size < 0 {
+		panic("size passed to createTruncateUpdate should never be negative")
+	}
+	return writeaheadlog.Update{
+		Name:         updateTruncateName,
+		Instructions: encoding.MarshalAll(sf.siaFilePath, size),
+	}
+}
+
 // createInsertUpdate is a helper method which creates a writeaheadlog update for
 // writing the specified data to the provided index. It is usually not called
 // directly but wrapped into another helper that creates an update for a
@@ -366,6 +413,37 @@ func (sf *SiaFile) createInsertUpdate(index int64, data []byte) writeaheadlog.Up
 	}
 }
 
+// SaveCopy writes a copy of the SiaFile's file on disk to dstDir, at the
+// location of its siapath. The file is locked while it is copied, so the copy
+// can't contain a partially applied update.
+func (sf *SiaFile) SaveCopy(dstDir string) (err error) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	if sf.deleted {
+		return errors.New("can't copy a deleted file")
+	}
+	dst := filepath.Join(dstDir, sf.staticMetadata.SiaPath+filepath.Ext(sf.siaFilePath))
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	src, err := os.Open(sf.siaFilePath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	if _, err := io.Copy(f, src); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
 // saveFile saves the whole SiaFile atomically.
 func (sf *SiaFile) saveFile() error {
 	headerUpdates, err := sf.saveHeader()
@@ -389,6 +467,21 @@ func (sf *SiaFile) saveChunks() (writeaheadlog.Update, error) {
 	return sf.createInsertUpdate(sf.staticMetadata.ChunkOffset, chunks), nil
 }
 
+// saveChunksTruncated creates writeaheadlog updates that save the marshaled
+// chunks of the SiaFile to disk and truncate the file after them when
+// applied. It is used instead of saveChunks when the chunks might have
+// shrunk, otherwise the end of the old chunks stays in the file.
+func (sf *SiaFile) saveChunksTruncated() ([]writeaheadlog.Update, error) {
+	chunks, err := marshalChunks(sf.staticChunks)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to marshal chunks")
+	}
+	return []writeaheadlog.Update{
+		sf.createInsertUpdate(sf.staticMetadata.ChunkOffset, chunks),
+		sf.createTruncateUpdate(sf.staticMetadata.ChunkOffset + int64(len(chunks))),
+	}, nil
+}
+
 // saveHeader creates writeaheadlog updates to saves the metadata and
 // pubKeyTable of the SiaFile to disk using the writeaheadlog. If the metadata
 // and overlap due to growing too large and would therefore corrupt if they
@@ -261,6 +261,34 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+// TestSaveCopy tests that a copy of a siafile can be loaded and that deleted
+// files can't be copied.
+func TestSaveCopy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf := newTestFile()
+	dstDir := filepath.Join(os.TempDir(), "siafilecopies", t.Name())
+	if err := sf.SaveCopy(dstDir); err != nil {
+		t.Fatal(err)
+	}
+	cpy, err := LoadSiaFile(filepath.Join(dstDir, sf.SiaPath()), newTestWAL())
+	if err != nil {
+		t.Fatal("unable to load the copy", err)
+	}
+	if cpy.SiaPath() != sf.SiaPath() || cpy.NumChunks() != sf.NumChunks() {
+		t.Fatal("copy doesn't match the original file")
+	}
+	if err := sf.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.SaveCopy(dstDir); err == nil {
+		t.Fatal("deleted file shouldn't be copied")
+	}
+}
+
 // TestRename tests if renaming a siafile moves the file correctly and also
 // updates the metadata.
 func TestRename(t *testing.T) {
@@ -0,0 +1,277 @@
+package siafile
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/errors"
+	"github.com/HyperspaceApp/writeaheadlog"
+)
+
+var (
+	// ErrRekeyInProgress is returned if pieces are added to a file while its
+	// masterkey is being rotated.
+	ErrRekeyInProgress = errors.New("the masterkey of the file is being rotated")
+
+	// errNoRekeyInProgress is returned when trying to update the rekey state
+	// of a file which isn't being rekeyed.
+	errNoRekeyInProgress = errors.New("the masterkey of the file is not being rotated")
+
+	// errRekeyIncomplete is returned when trying to finish a rekey while some
+	// chunks are still encrypted with the old masterkey.
+	errRekeyIncomplete = errors.New("not all chunks of the file have been rekeyed yet")
+)
+
+// rekeyed returns true if the pieces of the chunk are encrypted with the
+// RekeyMasterKey.
+func (c chunk) rekeyed() bool {
+	return c.ExtensionInfo[0]&chunkFlagRekeyed != 0
+}
+
+// rekeyMasterKey returns the key the file is being rekeyed to. It should only be
+// called while a rekey is in progress.
+func (sf *SiaFile) rekeyMasterKey() crypto.CipherKey {
+	sk, err := crypto.NewSiaKey(sf.staticMetadata.RekeyMasterKeyType, sf.staticMetadata.RekeyMasterKey)
+	if err != nil {
+		// The key was validated before it was stored in the metadata.
+		panic(errors.AddContext(err, "failed to create rekey masterkey of siafile"))
+	}
+	return sk
+}
+
+// rekeying returns true if the masterkey of the file is being rotated.
+func (sf *SiaFile) rekeying() bool {
+	return sf.staticMetadata.RekeyMasterKeyType != (crypto.CipherType{})
+}
+
+// chunkKey returns the key used to encrypt the pieces of a chunk.
+func (sf *SiaFile) chunkKey(chunkIndex uint64) crypto.CipherKey {
+	if sf.rekeying() && sf.staticChunks[chunkIndex].rekeyed() {
+		return sf.rekeyMasterKey()
+	}
+	if sc, shared := sf.staticMetadata.SharedChunks[chunkIndex]; shared {
+		return sf.sharedChunkKey(sc)
+	}
+	return sf.masterKey()
+}
+
+// ChunkKey returns the key used to encrypt the pieces of a chunk. This is the
+// masterkey of the file unless the chunk was already rekeyed or its pieces
+// were taken from another file.
+func (sf *SiaFile) ChunkKey(chunkIndex uint64) crypto.CipherKey {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	if chunkIndex >= uint64(len(sf.staticChunks)) {
+		panic(fmt.Sprintf("index %v out of bounds (%v)", chunkIndex, len(sf.staticChunks)))
+	}
+	return sf.chunkKey(chunkIndex)
+}
+
+// ChunkPiecesAndKey returns the pieces of a chunk together with the key they
+// are encrypted with. Both are read at the same time, so the key is guaranteed
+// to match the pieces even if the chunk is rekeyed concurrently.
+func (sf *SiaFile) ChunkPiecesAndKey(chunkIndex uint64) ([][]Piece, crypto.CipherKey, error) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	if chunkIndex >= uint64(len(sf.staticChunks)) {
+		panic(fmt.Sprintf("index %v out of bounds (%v)", chunkIndex, len(sf.staticChunks)))
+	}
+	pieces := make([][]Piece, len(sf.staticChunks[chunkIndex].Pieces))
+	for pieceIndex := range pieces {
+		pieces[pieceIndex] = make([]Piece, len(sf.staticChunks[chunkIndex].Pieces[pieceIndex]))
+		copy(pieces[pieceIndex], sf.staticChunks[chunkIndex].Pieces[pieceIndex])
+	}
+	return pieces, sf.chunkKey(chunkIndex), nil
+}
+
+// ChunkRekeyed returns true if the chunk was already rekeyed as part of the
+// ongoing rotation of the masterkey.
+func (sf *SiaFile) ChunkRekeyed(chunkIndex uint64) bool {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.rekeying() && sf.staticChunks[chunkIndex].rekeyed()
+}
+
+// Rekeying returns true if the masterkey of the file is being rotated.
+func (sf *SiaFile) Rekeying() bool {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.rekeying()
+}
+
+// StartRekey begins rotating the masterkey of the file to newKey. If a rekey
+// is already in progress, it is resumed instead and the key which was
+// previously chosen is returned.
+func (sf *SiaFile) StartRekey(newKey crypto.CipherKey) (crypto.CipherKey, error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return nil, errors.New("can't rekey deleted file")
+	}
+	if sf.rekeying() {
+		return sf.rekeyMasterKey(), nil
+	}
+	if sf.staticMetadata.MasterKeyType == crypto.TypePlain {
+		return nil, errors.New("can't rekey a file which isn't encrypted")
+	}
+	// The pieces of a packed file are shared with the other files of the
+	// pack, which would no longer be able to decrypt them.
+	if sf.staticMetadata.Pack != nil {
+		return nil, errors.New("can't rekey a file which is stored in a pack")
+	}
+	// The piece size depends on the overhead of the cipher, so the new key
+	// needs to be of the same type.
+	if newKey.Type() != sf.staticMetadata.MasterKeyType {
+		return nil, errors.New("new masterkey needs to be of the same type as the current one")
+	}
+	sf.staticMetadata.RekeyMasterKey = newKey.Key()
+	sf.staticMetadata.RekeyMasterKeyType = newKey.Type()
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	updates, err := sf.saveMetadata()
+	if err != nil {
+		return nil, err
+	}
+	return newKey, sf.createAndApplyTransaction(updates...)
+}
+
+// ReplaceChunkPieces replaces the pieces of a chunk with pieces that are
+// encrypted with the new masterkey and flags the chunk as rekeyed. Both
+// changes are persisted atomically. A shared chunk stops being shared since
+// its new pieces belong to this file only.
+func (sf *SiaFile) ReplaceChunkPieces(chunkIndex uint64, pieces [][]Piece) error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.New("can't replace pieces of deleted file")
+	}
+	if !sf.rekeying() {
+		return errNoRekeyInProgress
+	}
+	if chunkIndex >= uint64(len(sf.staticChunks)) {
+		return fmt.Errorf("chunkIndex %v out of bounds (%v)", chunkIndex, len(sf.staticChunks))
+	}
+	if len(pieces) != len(sf.staticChunks[chunkIndex].Pieces) {
+		return fmt.Errorf("expected %v pieces but got %v", len(sf.staticChunks[chunkIndex].Pieces), len(pieces))
+	}
+
+	// Add hosts we don't know yet to the public key table.
+	tableChanged := false
+	for _, pieceSet := range pieces {
+		for _, piece := range pieceSet {
+			known := false
+			for _, hpk := range sf.pubKeyTable {
+				if hpk.Algorithm == piece.HostPubKey.Algorithm && bytes.Equal(hpk.Key, piece.HostPubKey.Key) {
+					known = true
+					break
+				}
+			}
+			if !known {
+				sf.pubKeyTable = append(sf.pubKeyTable, piece.HostPubKey)
+				tableChanged = true
+			}
+		}
+	}
+	sf.staticChunks[chunkIndex].Pieces = pieces
+	sf.staticChunks[chunkIndex].ExtensionInfo[0] |= chunkFlagRekeyed
+	delete(sf.staticMetadata.SharedChunks, chunkIndex)
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	var updates []writeaheadlog.Update
+	var err error
+	if tableChanged {
+		updates, err = sf.saveHeader()
+	} else {
+		updates, err = sf.saveMetadata()
+	}
+	if err != nil {
+		return err
+	}
+	// The new pieces might be fewer than the old ones.
+	chunksUpdates, err := sf.saveChunksTruncated()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(append(updates, chunksUpdates...)...)
+}
+
+// CancelRekey stops the rotation of the masterkey. The chunks that weren't
+// rekeyed yet keep using the old masterkey. The chunks that were rekeyed
+// already keep their new pieces, the new key is stored as the key of each of
+// these chunks the same way as the key of a shared chunk.
+func (sf *SiaFile) CancelRekey() error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.New("can't cancel rekey of deleted file")
+	}
+	if !sf.rekeying() {
+		return errNoRekeyInProgress
+	}
+	for i := range sf.staticChunks {
+		if !sf.staticChunks[i].rekeyed() {
+			continue
+		}
+		if sf.staticMetadata.SharedChunks == nil {
+			sf.staticMetadata.SharedChunks = make(map[uint64]SharedChunk)
+		}
+		sf.staticMetadata.SharedChunks[uint64(i)] = SharedChunk{
+			Key:      sf.staticMetadata.RekeyMasterKey,
+			KeyType:  sf.staticMetadata.RekeyMasterKeyType,
+			KeyIndex: uint64(i),
+		}
+		sf.staticChunks[i].ExtensionInfo[0] &^= chunkFlagRekeyed
+	}
+	sf.staticMetadata.RekeyMasterKey = nil
+	sf.staticMetadata.RekeyMasterKeyType = crypto.CipherType{}
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	updates, err := sf.saveHeader()
+	if err != nil {
+		return err
+	}
+	chunksUpdate, err := sf.saveChunks()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(append(updates, chunksUpdate)...)
+}
+
+// FinishRekey completes the rotation of the masterkey once all chunks have
+// been rekeyed. The new key replaces the masterkey and the rekey flags of the
+// chunks are cleared in the same transaction.
+func (sf *SiaFile) FinishRekey() error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.New("can't finish rekey of deleted file")
+	}
+	if !sf.rekeying() {
+		return errNoRekeyInProgress
+	}
+	for _, c := range sf.staticChunks {
+		if !c.rekeyed() {
+			return errRekeyIncomplete
+		}
+	}
+	sf.staticMetadata.MasterKey = sf.staticMetadata.RekeyMasterKey
+	sf.staticMetadata.MasterKeyType = sf.staticMetadata.RekeyMasterKeyType
+	sf.staticMetadata.RekeyMasterKey = nil
+	sf.staticMetadata.RekeyMasterKeyType = crypto.CipherType{}
+	sf.staticMetadata.ChangeTime = time.Now()
+	for i := range sf.staticChunks {
+		sf.staticChunks[i].ExtensionInfo[0] &^= chunkFlagRekeyed
+	}
+
+	updates, err := sf.saveMetadata()
+	if err != nil {
+		return err
+	}
+	chunksUpdate, err := sf.saveChunks()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(append(updates, chunksUpdate)...)
+}
@@ -0,0 +1,197 @@
+package siafile
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+// TestRekey tests rotating the masterkey of a file chunk by chunk and makes
+// sure that the progress survives reloading the file from disk.
+func TestRekey(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create an encrypted file with 2 chunks.
+	oldKey := crypto.GenerateSiaKey(crypto.TypeTwofish)
+	rc, err := NewRSCode(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath := hex.EncodeToString(fastrand.Bytes(8))
+	siaFilePath := filepath.Join(os.TempDir(), "siafiles", siaPath)
+	if err := os.MkdirAll(filepath.Dir(siaFilePath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	wal := newTestWAL()
+	fileSize := 2 * (modules.SectorSize - oldKey.Type().Overhead())
+	sf, err := New(siaFilePath, siaPath, "", wal, rc, oldKey, fileSize, 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.NumChunks() != 2 {
+		t.Fatal("expected 2 chunks but got", sf.NumChunks())
+	}
+
+	// The new key needs to be of the same type.
+	if _, err := sf.StartRekey(crypto.GenerateSiaKey(crypto.TypePlain)); err == nil {
+		t.Fatal("rekey with a different cipher type should fail")
+	}
+	if err := sf.ReplaceChunkPieces(0, make([][]Piece, 2)); err != errNoRekeyInProgress {
+		t.Fatal("expected errNoRekeyInProgress but got", err)
+	}
+
+	// Start the rekey. Starting it again should resume it with the same key.
+	newKey, err := sf.StartRekey(crypto.GenerateSiaKey(crypto.TypeTwofish))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumedKey, err := sf.StartRekey(crypto.GenerateSiaKey(crypto.TypeTwofish))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(newKey.Key(), resumedKey.Key()) {
+		t.Fatal("resuming the rekey returned a different key")
+	}
+	if !sf.Rekeying() {
+		t.Fatal("file should be rekeying")
+	}
+	// Adding pieces is not allowed while rekeying.
+	if err := sf.AddPiece(types.SiaPublicKey{}, 0, 0, crypto.Hash{}); err != ErrRekeyInProgress {
+		t.Fatal("expected ErrRekeyInProgress but got", err)
+	}
+
+	// Rekey the first chunk.
+	hostKey := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+	root := crypto.Hash{1}
+	if err := sf.ReplaceChunkPieces(0, [][]Piece{{{HostPubKey: hostKey, MerkleRoot: root}}, nil}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.FinishRekey(); err != errRekeyIncomplete {
+		t.Fatal("expected errRekeyIncomplete but got", err)
+	}
+	// Pieces can still be added with the key of their chunk.
+	if err := sf.AddPieceWithKey(hostKey, 0, 1, crypto.Hash{2}, oldKey); err != ErrRekeyInProgress {
+		t.Fatal("expected ErrRekeyInProgress but got", err)
+	}
+	if err := sf.AddPieceWithKey(hostKey, 1, 0, crypto.Hash{3}, oldKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reload the file. The first chunk should use the new key together with
+	// the new pieces while the second chunk still uses the old key.
+	sf, err = LoadSiaFile(siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sf.Rekeying() || !sf.ChunkRekeyed(0) || sf.ChunkRekeyed(1) {
+		t.Fatal("rekey progress wasn't persisted")
+	}
+	pieces, key, err := sf.ChunkPiecesAndKey(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.Key(), newKey.Key()) {
+		t.Fatal("rekeyed chunk should use the new key")
+	}
+	if len(pieces[0]) != 1 || pieces[0][0].MerkleRoot != root || !bytes.Equal(pieces[0][0].HostPubKey.Key, hostKey.Key) {
+		t.Fatal("pieces of rekeyed chunk weren't persisted")
+	}
+	if !bytes.Equal(sf.ChunkKey(1).Key(), oldKey.Key()) {
+		t.Fatal("chunk which wasn't rekeyed should use the old key")
+	}
+
+	// Rekey the second chunk and finish.
+	if err := sf.ReplaceChunkPieces(1, make([][]Piece, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.FinishRekey(); err != nil {
+		t.Fatal(err)
+	}
+	sf, err = LoadSiaFile(siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.Rekeying() || sf.ChunkRekeyed(0) || sf.ChunkRekeyed(1) {
+		t.Fatal("file shouldn't be rekeying anymore")
+	}
+	if !bytes.Equal(sf.MasterKey().Key(), newKey.Key()) {
+		t.Fatal("masterkey wasn't replaced")
+	}
+	if !bytes.Equal(sf.ChunkKey(1).Key(), newKey.Key()) {
+		t.Fatal("chunks should use the new masterkey")
+	}
+}
+
+// TestCancelRekey tests that cancelling a rekey keeps the new key for the
+// chunks that were rekeyed already and the old key for all others.
+func TestCancelRekey(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	oldKey := crypto.GenerateSiaKey(crypto.TypeTwofish)
+	rc, err := NewRSCode(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath := hex.EncodeToString(fastrand.Bytes(8))
+	siaFilePath := filepath.Join(os.TempDir(), "siafiles", siaPath)
+	if err := os.MkdirAll(filepath.Dir(siaFilePath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	wal := newTestWAL()
+	fileSize := 2 * (modules.SectorSize - oldKey.Type().Overhead())
+	sf, err := New(siaFilePath, siaPath, "", wal, rc, oldKey, fileSize, 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.CancelRekey(); err != errNoRekeyInProgress {
+		t.Fatal("expected errNoRekeyInProgress but got", err)
+	}
+
+	// Rekey the first chunk and cancel.
+	newKey, err := sf.StartRekey(crypto.GenerateSiaKey(crypto.TypeTwofish))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.ReplaceChunkPieces(0, make([][]Piece, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.CancelRekey(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The keys should survive reloading the file.
+	sf, err = LoadSiaFile(siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.Rekeying() || sf.ChunkRekeyed(0) {
+		t.Fatal("file shouldn't be rekeying anymore")
+	}
+	if !bytes.Equal(sf.MasterKey().Key(), oldKey.Key()) {
+		t.Fatal("masterkey shouldn't change")
+	}
+	if !bytes.Equal(sf.ChunkKey(0).Key(), newKey.Key()) {
+		t.Fatal("rekeyed chunk should keep the new key")
+	}
+	if !bytes.Equal(sf.ChunkKey(1).Key(), oldKey.Key()) {
+		t.Fatal("chunk which wasn't rekeyed should use the old key")
+	}
+	// Pieces can be added again.
+	hostKey := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+	if err := sf.AddPiece(hostKey, 0, 0, crypto.Hash{1}); err != nil {
+		t.Fatal(err)
+	}
+}
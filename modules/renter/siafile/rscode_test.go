@@ -57,6 +57,37 @@ func TestRSEncode(t *testing.T) {
 	}
 }
 
+// TestRSParityStable checks that changing the number of parity pieces doesn't
+// change the existing pieces, which is required to change the redundancy of a
+// file without uploading it again.
+func TestRSParityStable(t *testing.T) {
+	rsc, err := NewRSCode(10, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rscMore, err := NewRSCode(10, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(777)
+	pieces, err := rsc.Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	morePieces, err := rscMore.Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(morePieces) != rscMore.NumPieces() {
+		t.Fatal("expected", rscMore.NumPieces(), "pieces but got", len(morePieces))
+	}
+	for i := range pieces {
+		if !bytes.Equal(pieces[i], morePieces[i]) {
+			t.Fatalf("piece %v changed when adding parity pieces", i)
+		}
+	}
+}
+
 func BenchmarkRSEncode(b *testing.B) {
 	rsc, err := NewRSCode(80, 20)
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -18,6 +19,12 @@ import (
 	"github.com/HyperspaceApp/writeaheadlog"
 )
 
+var (
+	// ErrDataPiecesChanged is returned if the erasure code of a file is
+	// replaced with one that uses a different number of data pieces.
+	ErrDataPiecesChanged = errors.New("the number of data pieces of a file can't be changed")
+)
+
 type (
 	// SiaFile is the disk format for files uploaded to the Sia network.  It
 	// contains all the necessary information to recover a file from its hosts and
@@ -71,29 +78,29 @@ func New(siaFilePath, siaPath, source string, wal *writeaheadlog.WAL, erasureCod
 	ecType, ecParams := marshalErasureCoder(erasureCode)
 	file := &SiaFile{
 		staticMetadata: metadata{
-			AccessTime:              currentTime,
-			ChunkOffset:             defaultReservedMDPages * pageSize,
-			ChangeTime:              currentTime,
-			CreateTime:              currentTime,
-			StaticFileSize:          int64(fileSize),
-			LocalPath:               source,
-			StaticMasterKey:         masterKey.Key(),
-			StaticMasterKeyType:     masterKey.Type(),
-			Mode:                    fileMode,
-			ModTime:                 currentTime,
-			staticErasureCode:       erasureCode,
-			StaticErasureCodeType:   ecType,
-			StaticErasureCodeParams: ecParams,
-			StaticPieceSize:         modules.SectorSize - masterKey.Type().Overhead(),
-			SiaPath:                 siaPath,
+			AccessTime:            currentTime,
+			ChunkOffset:           defaultReservedMDPages * pageSize,
+			ChangeTime:            currentTime,
+			CreateTime:            currentTime,
+			StaticFileSize:        int64(fileSize),
+			LocalPath:             source,
+			MasterKey:             masterKey.Key(),
+			MasterKeyType:         masterKey.Type(),
+			Mode:                  fileMode,
+			ModTime:               currentTime,
+			erasureCode:           erasureCode,
+			StaticErasureCodeType: ecType,
+			ErasureCodeParams:     ecParams,
+			StaticPieceSize:       modules.SectorSize - masterKey.Type().Overhead(),
+			SiaPath:               siaPath,
 		},
 		siaFilePath: siaFilePath,
 		staticUID:   hex.EncodeToString(fastrand.Bytes(20)),
 		wal:         wal,
 	}
 	// Init chunks.
-	numChunks := fileSize / file.staticChunkSize()
-	if fileSize%file.staticChunkSize() != 0 || numChunks == 0 {
+	numChunks := fileSize / file.chunkSize()
+	if fileSize%file.chunkSize() != 0 || numChunks == 0 {
 		numChunks++
 	}
 	file.staticChunks = make([]chunk, numChunks)
@@ -114,7 +121,36 @@ func (sf *SiaFile) AddPiece(pk types.SiaPublicKey, chunkIndex, pieceIndex uint64
 	if sf.deleted {
 		return errors.New("can't add piece to deleted file")
 	}
+	// Pieces which are uploaded while the masterkey is rotated might be
+	// encrypted with the wrong key.
+	if sf.rekeying() {
+		return ErrRekeyInProgress
+	}
+	return sf.addPiece(pk, chunkIndex, pieceIndex, merkleRoot)
+}
+
+// AddPieceWithKey adds an uploaded piece to the file like AddPiece, but can
+// also be used while the masterkey is rotated. key is the key the piece was
+// encrypted with. If the chunk was rekeyed after the piece was encrypted, the
+// piece is rejected with ErrRekeyInProgress.
+func (sf *SiaFile) AddPieceWithKey(pk types.SiaPublicKey, chunkIndex, pieceIndex uint64, merkleRoot crypto.Hash, key crypto.CipherKey) error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.New("can't add piece to deleted file")
+	}
+	if chunkIndex >= uint64(len(sf.staticChunks)) {
+		return fmt.Errorf("chunkIndex %v out of bounds (%v)", chunkIndex, len(sf.staticChunks))
+	}
+	chunkKey := sf.chunkKey(chunkIndex)
+	if chunkKey.Type() != key.Type() || !bytes.Equal(chunkKey.Key(), key.Key()) {
+		return ErrRekeyInProgress
+	}
+	return sf.addPiece(pk, chunkIndex, pieceIndex, merkleRoot)
+}
 
+// addPiece adds a piece to the file and persists the change.
+func (sf *SiaFile) addPiece(pk types.SiaPublicKey, chunkIndex, pieceIndex uint64, merkleRoot crypto.Hash) error {
 	// Get the index of the host in the public key table.
 	tableIndex := -1
 	for i, hpk := range sf.pubKeyTable {
@@ -172,44 +208,84 @@ func (sf *SiaFile) AddPiece(pk types.SiaPublicKey, chunkIndex, pieceIndex uint64
 	return sf.createAndApplyTransaction(append(updates, chunksUpdate)...)
 }
 
-// Available indicates whether the file is ready to be downloaded.
-func (sf *SiaFile) Available(offline map[string]bool) bool {
+// Available indicates whether the file is ready to be downloaded. Every chunk
+// needs to be spread across at least minHosts distinct online hosts.
+func (sf *SiaFile) Available(offline map[string]bool, minHosts int) bool {
 	sf.mu.RLock()
 	defer sf.mu.RUnlock()
 	// We need to find at least erasureCode.MinPieces different pieces for each
 	// chunk for the file to be available.
 	for _, chunk := range sf.staticChunks {
 		piecesForChunk := 0
+		hosts := make(map[string]struct{})
 		for _, pieceSet := range chunk.Pieces {
+			counted := false
 			for _, piece := range pieceSet {
 				if !offline[string(piece.HostPubKey.Key)] {
-					piecesForChunk++
-					break // break out since we only count unique pieces
+					hosts[string(piece.HostPubKey.Key)] = struct{}{}
+					if !counted {
+						piecesForChunk++
+						counted = true // we only count unique pieces
+					}
 				}
 			}
-			if piecesForChunk >= sf.staticMetadata.staticErasureCode.MinPieces() {
+			if piecesForChunk >= sf.staticMetadata.erasureCode.MinPieces() && len(hosts) >= minHosts {
 				break // we already have enough pieces for this chunk.
 			}
 		}
-		if piecesForChunk < sf.staticMetadata.staticErasureCode.MinPieces() {
+		if piecesForChunk < sf.staticMetadata.erasureCode.MinPieces() || len(hosts) < minHosts {
 			return false // this chunk isn't available.
 		}
 	}
 	return true
 }
 
+// UnrecoverableChunks returns the indices of the chunks that have fewer than
+// erasureCode.MinPieces unique pieces on online hosts. Unlike Available, hosts
+// that are missing from the offline map are considered to be gone, since the
+// renter has no contract with them anymore.
+func (sf *SiaFile) UnrecoverableChunks(offline map[string]bool) []uint64 {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	var chunks []uint64
+	for chunkIndex, chunk := range sf.staticChunks {
+		piecesForChunk := 0
+		for _, pieceSet := range chunk.Pieces {
+			for _, piece := range pieceSet {
+				if off, exists := offline[string(piece.HostPubKey.Key)]; exists && !off {
+					piecesForChunk++
+					break // break out since we only count unique pieces
+				}
+			}
+			if piecesForChunk >= sf.staticMetadata.erasureCode.MinPieces() {
+				break // we already have enough pieces for this chunk.
+			}
+		}
+		if piecesForChunk < sf.staticMetadata.erasureCode.MinPieces() {
+			chunks = append(chunks, uint64(chunkIndex))
+		}
+	}
+	return chunks
+}
+
 // ChunkIndexByOffset will return the chunkIndex that contains the provided
 // offset of a file and also the relative offset within the chunk. If the
 // offset is out of bounds, chunkIndex will be equal to NumChunk().
 func (sf *SiaFile) ChunkIndexByOffset(offset uint64) (chunkIndex uint64, off uint64) {
-	chunkIndex = offset / sf.staticChunkSize()
-	off = offset % sf.staticChunkSize()
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	chunkIndex = offset / sf.chunkSize()
+	off = offset % sf.chunkSize()
 	return
 }
 
-// ErasureCode returns the erasure coder used by the file.
+// ErasureCode returns the erasure coder used by the file. The number of
+// parity pieces might change with SetErasureCode, so callers that need a
+// consistent view should only call it once.
 func (sf *SiaFile) ErasureCode() modules.ErasureCoder {
-	return sf.staticMetadata.staticErasureCode
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.erasureCode
 }
 
 // NumChunks returns the number of chunks the file consists of. This will
@@ -238,6 +314,154 @@ func (sf *SiaFile) Pieces(chunkIndex uint64) ([][]Piece, error) {
 	return pieces, nil
 }
 
+// HostSpread returns the smallest number of distinct hosts that store pieces
+// of a single chunk of the file. Only hosts that are online and goodForRenew
+// are counted, since pieces on other hosts will be moved eventually.
+func (sf *SiaFile) HostSpread(offlineMap map[string]bool, goodForRenewMap map[string]bool) uint64 {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	if len(sf.staticChunks) == 0 {
+		return 0
+	}
+	minSpread := uint64(math.MaxUint64)
+	for _, chunk := range sf.staticChunks {
+		hosts := make(map[string]struct{})
+		for _, pieceSet := range chunk.Pieces {
+			for _, piece := range pieceSet {
+				key := string(piece.HostPubKey.Key)
+				if offlineMap[key] || !goodForRenewMap[key] {
+					continue
+				}
+				hosts[key] = struct{}{}
+			}
+		}
+		if uint64(len(hosts)) < minSpread {
+			minSpread = uint64(len(hosts))
+		}
+	}
+	return minSpread
+}
+
+// TolerableHostLoss returns the number of hosts that can be lost in the worst
+// case while every chunk of the file remains recoverable. Like HostSpread,
+// only online and goodForRenew hosts are counted. A chunk becomes
+// unrecoverable once all copies of enough of its pieces are lost, so the
+// cheapest pieces to lose are the ones stored on the fewest hosts. This
+// assumes that a host stores at most one piece of a chunk, which the uploader
+// ensures.
+func (sf *SiaFile) TolerableHostLoss(offlineMap map[string]bool, goodForRenewMap map[string]bool) uint64 {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	if len(sf.staticChunks) == 0 {
+		return 0
+	}
+	minPieces := sf.staticMetadata.erasureCode.MinPieces()
+	minLoss := uint64(math.MaxUint64)
+	for _, chunk := range sf.staticChunks {
+		// Count the distinct hosts storing each piece.
+		var copies []int
+		for _, pieceSet := range chunk.Pieces {
+			hosts := make(map[string]struct{})
+			for _, piece := range pieceSet {
+				key := string(piece.HostPubKey.Key)
+				if offlineMap[key] || !goodForRenewMap[key] {
+					continue
+				}
+				hosts[key] = struct{}{}
+			}
+			if len(hosts) > 0 {
+				copies = append(copies, len(hosts))
+			}
+		}
+		if len(copies) < minPieces {
+			return 0
+		}
+		// Losing the pieces with the fewest copies first, the chunk can
+		// afford to lose len(copies)-minPieces pieces entirely.
+		sort.Ints(copies)
+		loss := uint64(0)
+		for _, c := range copies[:len(copies)-minPieces+1] {
+			loss += uint64(c)
+		}
+		loss-- // losing one host less keeps the chunk recoverable
+		if loss < minLoss {
+			minLoss = loss
+		}
+	}
+	return minLoss
+}
+
+// EffectiveRedundancy returns the redundancy of the least redundant chunk when
+// all the hosts in the same address range are treated as a single host.
+// addressRanges maps the hosts of the file to their address range, hosts
+// that are missing from it are treated as a range of their own. Every range
+// contributes at most one piece to a chunk, since a single operator can lose
+// all of them at once. Like HostSpread, only online and goodForRenew hosts are
+// counted.
+func (sf *SiaFile) EffectiveRedundancy(offlineMap map[string]bool, goodForRenewMap map[string]bool, addressRanges map[string]string) float64 {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	ec := sf.staticMetadata.erasureCode
+	if sf.staticMetadata.StaticFileSize == 0 {
+		return float64(ec.NumPieces()) / float64(ec.MinPieces())
+	}
+	if len(sf.staticChunks) == 0 {
+		return 0
+	}
+
+	minRedundancy := math.MaxFloat64
+	for _, chunk := range sf.staticChunks {
+		// Collect the address ranges that store each piece.
+		var pieceRanges [][]string
+		for _, pieceSet := range chunk.Pieces {
+			var ranges []string
+			for _, piece := range pieceSet {
+				key := string(piece.HostPubKey.Key)
+				if offlineMap[key] || !goodForRenewMap[key] {
+					continue
+				}
+				r, exists := addressRanges[key]
+				if !exists {
+					r = piece.HostPubKey.String()
+				}
+				ranges = append(ranges, r)
+			}
+			if len(ranges) > 0 {
+				pieceRanges = append(pieceRanges, ranges)
+			}
+		}
+		// Assign as many pieces as possible to distinct ranges. A piece
+		// stored in several ranges may take over the range of a piece that
+		// was assigned before if that piece can move to another range.
+		assigned := make(map[string]int)
+		var assign func(piece int, visited map[string]bool) bool
+		assign = func(piece int, visited map[string]bool) bool {
+			for _, r := range pieceRanges[piece] {
+				if visited[r] {
+					continue
+				}
+				visited[r] = true
+				if other, exists := assigned[r]; !exists || assign(other, visited) {
+					assigned[r] = piece
+					return true
+				}
+			}
+			return false
+		}
+		numPieces := 0
+		for piece := range pieceRanges {
+			if assign(piece, make(map[string]bool)) {
+				numPieces++
+			}
+		}
+		redundancy := float64(numPieces) / float64(ec.MinPieces())
+		if redundancy < minRedundancy {
+			minRedundancy = redundancy
+		}
+	}
+	return minRedundancy
+}
+
 // Redundancy returns the redundancy of the least redundant chunk. A file
 // becomes available when this redundancy is >= 1. Assumes that every piece is
 // unique within a file contract. -1 is returned if the file has size 0. It
@@ -246,13 +470,19 @@ func (sf *SiaFile) Pieces(chunkIndex uint64) ([][]Piece, error) {
 func (sf *SiaFile) Redundancy(offlineMap map[string]bool, goodForRenewMap map[string]bool) float64 {
 	sf.mu.RLock()
 	defer sf.mu.RUnlock()
+	return sf.redundancy(offlineMap, goodForRenewMap)
+}
+
+// redundancy returns the redundancy of the least redundant chunk without
+// acquiring the lock.
+func (sf *SiaFile) redundancy(offlineMap map[string]bool, goodForRenewMap map[string]bool) float64 {
 	if sf.staticMetadata.StaticFileSize == 0 {
 		// TODO change this once tiny files are supported.
 		if len(sf.staticChunks) != 1 {
 			// should never happen
 			return -1
 		}
-		ec := sf.staticMetadata.staticErasureCode
+		ec := sf.staticMetadata.erasureCode
 		return float64(ec.NumPieces()) / float64(ec.MinPieces())
 	}
 
@@ -294,11 +524,11 @@ func (sf *SiaFile) Redundancy(offlineMap map[string]bool, goodForRenewMap map[st
 				numPiecesNoRenew++
 			}
 		}
-		redundancy := float64(numPiecesRenew) / float64(sf.staticMetadata.staticErasureCode.MinPieces())
+		redundancy := float64(numPiecesRenew) / float64(sf.staticMetadata.erasureCode.MinPieces())
 		if redundancy < minRedundancy {
 			minRedundancy = redundancy
 		}
-		redundancyNoRenew := float64(numPiecesNoRenew) / float64(sf.staticMetadata.staticErasureCode.MinPieces())
+		redundancyNoRenew := float64(numPiecesNoRenew) / float64(sf.staticMetadata.erasureCode.MinPieces())
 		if redundancyNoRenew < minRedundancyNoRenew {
 			minRedundancyNoRenew = redundancyNoRenew
 		}
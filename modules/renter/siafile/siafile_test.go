@@ -0,0 +1,244 @@
+package siafile
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestHostSpread checks that the host spread of a file only counts distinct,
+// online and goodForRenew hosts.
+func TestHostSpread(t *testing.T) {
+	sf := newTestFile()
+	if len(sf.staticChunks) != 1 {
+		t.Fatal("expected a single chunk but got", len(sf.staticChunks))
+	}
+	hostKey := func(b byte) types.SiaPublicKey {
+		return types.SiaPublicKey{Key: []byte{b}}
+	}
+	// Host 1 stores two pieces, host 3 is offline and host 4 is not
+	// goodForRenew.
+	sf.staticChunks[0].Pieces[0] = []Piece{{HostPubKey: hostKey(1)}, {HostPubKey: hostKey(2)}}
+	sf.staticChunks[0].Pieces[1] = []Piece{{HostPubKey: hostKey(1)}}
+	sf.staticChunks[0].Pieces[2] = []Piece{{HostPubKey: hostKey(3)}}
+	sf.staticChunks[0].Pieces[3] = []Piece{{HostPubKey: hostKey(4)}}
+	offline := map[string]bool{
+		string(hostKey(1).Key): false,
+		string(hostKey(2).Key): false,
+		string(hostKey(3).Key): true,
+		string(hostKey(4).Key): false,
+	}
+	goodForRenew := map[string]bool{
+		string(hostKey(1).Key): true,
+		string(hostKey(2).Key): true,
+		string(hostKey(3).Key): true,
+		string(hostKey(4).Key): false,
+	}
+	if spread := sf.HostSpread(offline, goodForRenew); spread != 2 {
+		t.Fatal("expected a host spread of 2 but got", spread)
+	}
+	goodForRenew[string(hostKey(4).Key)] = true
+	if spread := sf.HostSpread(offline, goodForRenew); spread != 3 {
+		t.Fatal("expected a host spread of 3 but got", spread)
+	}
+}
+
+// TestUnrecoverableChunks checks that a chunk is unrecoverable if fewer than
+// MinPieces of its pieces are stored on online hosts with a contract.
+func TestUnrecoverableChunks(t *testing.T) {
+	sf := newTestFile()
+	minPieces := sf.ErasureCode().MinPieces()
+	offline := make(map[string]bool)
+	for i := 0; i < minPieces; i++ {
+		pk := types.SiaPublicKey{Key: []byte{byte(i)}}
+		sf.staticChunks[0].Pieces[i] = []Piece{{HostPubKey: pk}}
+		offline[string(pk.Key)] = false
+	}
+	if chunks := sf.UnrecoverableChunks(offline); len(chunks) != 0 {
+		t.Fatal("chunk with MinPieces online pieces should be recoverable", chunks)
+	}
+
+	// A host going offline makes the chunk unrecoverable, and so does a host
+	// that the renter has no contract with anymore.
+	offline[string([]byte{0})] = true
+	if chunks := sf.UnrecoverableChunks(offline); len(chunks) != 1 || chunks[0] != 0 {
+		t.Fatal("chunk with an offline piece should be unrecoverable", chunks)
+	}
+	offline[string([]byte{0})] = false
+	delete(offline, string([]byte{1}))
+	if chunks := sf.UnrecoverableChunks(offline); len(chunks) != 1 {
+		t.Fatal("chunk with a piece on a host without a contract should be unrecoverable", chunks)
+	}
+
+	// Another copy of the missing piece makes the chunk recoverable again.
+	pk := types.SiaPublicKey{Key: []byte{byte(minPieces)}}
+	sf.staticChunks[0].Pieces[1] = append(sf.staticChunks[0].Pieces[1], Piece{HostPubKey: pk})
+	offline[string(pk.Key)] = false
+	if chunks := sf.UnrecoverableChunks(offline); len(chunks) != 0 {
+		t.Fatal("chunk with a second copy of the piece should be recoverable", chunks)
+	}
+}
+
+// TestTolerableHostLoss checks that the tolerable host loss of a file only
+// grows once every piece that could be lost has a copy on a spare host.
+func TestTolerableHostLoss(t *testing.T) {
+	sf := newTestFile()
+	ec := sf.ErasureCode()
+	offline := make(map[string]bool)
+	goodForRenew := make(map[string]bool)
+	addPiece := func(pieceIndex int, host byte) {
+		pk := types.SiaPublicKey{Key: []byte{host}}
+		sf.staticChunks[0].Pieces[pieceIndex] = append(sf.staticChunks[0].Pieces[pieceIndex], Piece{HostPubKey: pk})
+		offline[string(pk.Key)] = false
+		goodForRenew[string(pk.Key)] = true
+	}
+	for i := 0; i < ec.NumPieces(); i++ {
+		addPiece(i, byte(i))
+	}
+	expected := uint64(ec.NumPieces() - ec.MinPieces())
+	if loss := sf.TolerableHostLoss(offline, goodForRenew); loss != expected {
+		t.Fatalf("expected a tolerable host loss of %v but got %v", expected, loss)
+	}
+
+	// A single copy doesn't help, the other pieces can still be lost.
+	addPiece(0, byte(ec.NumPieces()))
+	if loss := sf.TolerableHostLoss(offline, goodForRenew); loss != expected {
+		t.Fatalf("expected a tolerable host loss of %v but got %v", expected, loss)
+	}
+	// With a copy of every piece, twice as many hosts need to be lost to
+	// lose the same number of pieces.
+	for i := 1; i < ec.NumPieces(); i++ {
+		addPiece(i, byte(ec.NumPieces()+i))
+	}
+	expected = uint64(2*(ec.NumPieces()-ec.MinPieces()+1) - 1)
+	if loss := sf.TolerableHostLoss(offline, goodForRenew); loss != expected {
+		t.Fatalf("expected a tolerable host loss of %v but got %v", expected, loss)
+	}
+
+	// A chunk that is already unrecoverable can't lose any host.
+	for key := range offline {
+		offline[key] = true
+	}
+	if loss := sf.TolerableHostLoss(offline, goodForRenew); loss != 0 {
+		t.Fatal("expected a tolerable host loss of 0 but got", loss)
+	}
+}
+
+// TestEffectiveRedundancy checks that pieces on hosts in the same address
+// range only count once, unless a piece can be assigned to another range.
+func TestEffectiveRedundancy(t *testing.T) {
+	sf := newTestFile()
+	ec := sf.ErasureCode()
+	offline := make(map[string]bool)
+	goodForRenew := make(map[string]bool)
+	ranges := make(map[string]string)
+	addPiece := func(pieceIndex int, host byte, addressRange string) {
+		pk := types.SiaPublicKey{Key: []byte{host}}
+		sf.staticChunks[0].Pieces[pieceIndex] = append(sf.staticChunks[0].Pieces[pieceIndex], Piece{HostPubKey: pk})
+		offline[string(pk.Key)] = false
+		goodForRenew[string(pk.Key)] = true
+		ranges[string(pk.Key)] = addressRange
+	}
+	for i := 0; i < ec.MinPieces(); i++ {
+		addPiece(i, byte(i), fmt.Sprint(i))
+	}
+	if r := sf.EffectiveRedundancy(offline, goodForRenew, ranges); r != 1 {
+		t.Fatal("expected an effective redundancy of 1 but got", r)
+	}
+
+	// Hosts 0 and 1 are in the same range.
+	ranges[string([]byte{1})] = "0"
+	expected := float64(ec.MinPieces()-1) / float64(ec.MinPieces())
+	if r := sf.EffectiveRedundancy(offline, goodForRenew, ranges); r != expected {
+		t.Fatalf("expected an effective redundancy of %v but got %v", expected, r)
+	}
+	if r := sf.Redundancy(offline, goodForRenew); r != 1 {
+		t.Fatal("the redundancy shouldn't change but got", r)
+	}
+
+	// A copy of piece 0 in a new range lets both pieces count again.
+	addPiece(0, byte(ec.NumPieces()), "new")
+	if r := sf.EffectiveRedundancy(offline, goodForRenew, ranges); r != 1 {
+		t.Fatal("expected an effective redundancy of 1 but got", r)
+	}
+}
+
+// TestSetErasureCode checks that the pieces of a file are kept when its
+// number of parity pieces changes and that the change is persisted.
+func TestSetErasureCode(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf := newTestFile()
+	numChunks := sf.NumChunks()
+	piece := Piece{HostPubKey: types.SiaPublicKey{Key: []byte{1}}}
+	sf.staticChunks[0].Pieces[0] = []Piece{piece}
+	sf.staticChunks[0].Pieces[29] = []Piece{piece}
+
+	// The number of data pieces can't change.
+	ec, err := NewRSCode(11, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.SetErasureCode(ec); err != ErrDataPiecesChanged {
+		t.Fatal("expected ErrDataPiecesChanged but got", err)
+	}
+
+	// Add parity pieces.
+	ec, err = NewRSCode(10, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.SetErasureCode(ec); err != nil {
+		t.Fatal(err)
+	}
+	sf, err = LoadSiaFile(sf.siaFilePath, sf.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.ErasureCode().NumPieces() != 35 || sf.NumChunks() != numChunks {
+		t.Fatal("erasure code wasn't persisted")
+	}
+	pieces, err := sf.Pieces(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pieces) != 35 || len(pieces[0]) != 1 || len(pieces[29]) != 1 {
+		t.Fatal("existing pieces should be kept when adding parity pieces")
+	}
+
+	// Remove parity pieces. The surplus pieces are dropped and the file
+	// shrinks.
+	fi, err := os.Stat(sf.siaFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizeBefore := fi.Size()
+	ec, err = NewRSCode(10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.SetErasureCode(ec); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err = os.Stat(sf.siaFilePath); err != nil {
+		t.Fatal(err)
+	} else if fi.Size() >= sizeBefore {
+		t.Fatal("file wasn't truncated", fi.Size(), sizeBefore)
+	}
+	sf, err = LoadSiaFile(sf.siaFilePath, sf.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pieces, err = sf.Pieces(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.ErasureCode().NumPieces() != 20 || len(pieces) != 20 || len(pieces[0]) != 1 {
+		t.Fatal("surplus pieces weren't dropped")
+	}
+}
@@ -0,0 +1,158 @@
+package siafile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/errors"
+	"github.com/HyperspaceApp/writeaheadlog"
+)
+
+// verifyOnDisk checks the raw contents of the SiaFile's file on disk against
+// the on-disk format and the loaded SiaFile, and returns the problems found.
+func (sf *SiaFile) verifyOnDisk(raw []byte) (problems []string) {
+	var md metadata
+	if err := json.NewDecoder(bytes.NewReader(raw)).Decode(&md); err != nil {
+		return []string{"unable to decode metadata: " + err.Error()}
+	}
+	if _, err := unmarshalErasureCoder(md.StaticErasureCodeType, md.ErasureCodeParams); err != nil {
+		problems = append(problems, "unable to decode erasure code: "+err.Error())
+	}
+	if md.ChunkOffset%pageSize != 0 {
+		problems = append(problems, fmt.Sprintf("chunk offset %v isn't page aligned", md.ChunkOffset))
+	}
+	if md.PubKeyTableOffset < 0 || md.PubKeyTableOffset > md.ChunkOffset || md.ChunkOffset > int64(len(raw)) {
+		return append(problems, fmt.Sprintf("invalid offsets, public key table at %v and chunks at %v in %v bytes", md.PubKeyTableOffset, md.ChunkOffset, len(raw)))
+	}
+	if rawMetadata, err := marshalMetadata(md); err == nil && int64(len(rawMetadata)) > md.PubKeyTableOffset {
+		problems = append(problems, "metadata overlaps the public key table")
+	}
+	if _, err := unmarshalPubKeyTable(raw[md.PubKeyTableOffset:md.ChunkOffset]); err != nil {
+		problems = append(problems, "unable to decode public key table: "+err.Error())
+	}
+	chunks, err := unmarshalChunks(raw[md.ChunkOffset:])
+	if err != nil {
+		return append(problems, "unable to decode chunks: "+err.Error())
+	}
+	rawChunks, err1 := marshalChunks(chunks)
+	loadedChunks, err2 := marshalChunks(sf.staticChunks)
+	if err := errors.Compose(err1, err2); err != nil {
+		return append(problems, "unable to compare chunks: "+err.Error())
+	}
+	if !bytes.Equal(rawChunks, loadedChunks) {
+		problems = append(problems, "chunks on disk don't match the loaded file")
+	}
+	return problems
+}
+
+// verifyLoaded checks the chunks of the loaded SiaFile. Problems of the loaded
+// SiaFile can't be repaired by writing it to disk again.
+func (sf *SiaFile) verifyLoaded() (problems []string) {
+	numPieces := sf.staticMetadata.erasureCode.NumPieces()
+	hosts := make(map[string]struct{}, len(sf.pubKeyTable))
+	for _, pk := range sf.pubKeyTable {
+		hosts[pk.String()] = struct{}{}
+	}
+	for i, chunk := range sf.staticChunks {
+		if len(chunk.Pieces) != numPieces {
+			problems = append(problems, fmt.Sprintf("chunk %v has %v pieces instead of %v", i, len(chunk.Pieces), numPieces))
+		}
+		for _, pieceSet := range chunk.Pieces {
+			for _, piece := range pieceSet {
+				if _, ok := hosts[piece.HostPubKey.String()]; !ok {
+					problems = append(problems, fmt.Sprintf("chunk %v references a host that isn't in the public key table", i))
+					break
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// compactedFile returns the contents of the SiaFile's file with the header
+// using the fewest pages possible, and the metadata that belongs to it.
+func (sf *SiaFile) compactedFile() ([]byte, metadata, error) {
+	pubKeyTable, err := marshalPubKeyTable(sf.pubKeyTable)
+	if err != nil {
+		return nil, metadata{}, err
+	}
+	chunks, err := marshalChunks(sf.staticChunks)
+	if err != nil {
+		return nil, metadata{}, err
+	}
+	md := sf.staticMetadata
+	md.ChunkOffset = defaultReservedMDPages * pageSize
+	var rawMetadata []byte
+	for {
+		md.PubKeyTableOffset = md.ChunkOffset - int64(len(pubKeyTable))
+		rawMetadata, err = marshalMetadata(md)
+		if err != nil {
+			return nil, metadata{}, err
+		}
+		if int64(len(rawMetadata))+int64(len(pubKeyTable)) <= md.ChunkOffset {
+			break
+		}
+		md.ChunkOffset += pageSize
+	}
+	data := make([]byte, md.ChunkOffset+int64(len(chunks)))
+	copy(data, rawMetadata)
+	copy(data[md.PubKeyTableOffset:], pubKeyTable)
+	copy(data[md.ChunkOffset:], chunks)
+	return data, md, nil
+}
+
+// Verify checks the SiaFile's file on disk against the on-disk format and the
+// loaded SiaFile. If compact is true, the file is written again if it is
+// corrupted or if its header uses more pages than necessary or it contains
+// stale data, which reclaims the unused space. The loaded SiaFile is the
+// source of the rewritten file, so problems of the loaded SiaFile itself are
+// only reported.
+func (sf *SiaFile) Verify(compact bool) (modules.SiaFileVerification, error) {
+	if compact {
+		sf.mu.Lock()
+		defer sf.mu.Unlock()
+	} else {
+		sf.mu.RLock()
+		defer sf.mu.RUnlock()
+	}
+	if sf.deleted {
+		return modules.SiaFileVerification{}, errors.New("can't verify a deleted file")
+	}
+	v := modules.SiaFileVerification{
+		SiaPath: sf.staticMetadata.SiaPath,
+	}
+	raw, err := ioutil.ReadFile(sf.siaFilePath)
+	if err != nil {
+		return modules.SiaFileVerification{}, errors.AddContext(err, "unable to read the file")
+	}
+	v.Problems = sf.verifyOnDisk(raw)
+	if problems := sf.verifyLoaded(); len(problems) > 0 {
+		v.Problems = append(v.Problems, problems...)
+		return v, nil
+	}
+	if !compact {
+		return v, nil
+	}
+
+	data, md, err := sf.compactedFile()
+	if err != nil {
+		return v, errors.AddContext(err, "unable to compact the file")
+	}
+	if len(v.Problems) == 0 && len(data) >= len(raw) {
+		return v, nil
+	}
+	updates := []writeaheadlog.Update{sf.createInsertUpdate(0, data), sf.createTruncateUpdate(int64(len(data)))}
+	if err := sf.createAndApplyTransaction(updates...); err != nil {
+		return v, errors.AddContext(err, "unable to write the compacted file")
+	}
+	sf.staticMetadata.ChunkOffset = md.ChunkOffset
+	sf.staticMetadata.PubKeyTableOffset = md.PubKeyTableOffset
+	v.Repaired = len(v.Problems) > 0
+	if len(data) < len(raw) {
+		v.BytesReclaimed = uint64(len(raw) - len(data))
+	}
+	return v, nil
+}
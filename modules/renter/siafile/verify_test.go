@@ -0,0 +1,91 @@
+package siafile
+
+import (
+	"os"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/types"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+// TestVerify tests that stale data after the chunks of a SiaFile is reported
+// as corruption and that compacting the file removes it.
+func TestVerify(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf := newTestFile()
+	pk := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+	if err := sf.AddPiece(pk, 0, 0, crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new file has no problems.
+	v, err := sf.Verify(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Problems) != 0 || v.Repaired || v.BytesReclaimed != 0 {
+		t.Fatal("new file shouldn't have problems", v)
+	}
+
+	// Append stale data to the file.
+	f, err := os.OpenFile(sf.siaFilePath, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	garbage := append([]byte("x"), fastrand.Bytes(100)...)
+	if _, err := f.Write(garbage); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSiaFile(sf.siaFilePath, sf.wal); err == nil {
+		t.Fatal("file with stale data shouldn't load")
+	}
+
+	// Verifying without compacting reports the problem but doesn't fix it.
+	v, err = sf.Verify(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Problems) == 0 || v.Repaired {
+		t.Fatal("stale data wasn't reported", v)
+	}
+
+	// Compacting the file removes the stale data.
+	v, err = sf.Verify(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Problems) == 0 || !v.Repaired {
+		t.Fatal("file wasn't repaired", v)
+	}
+	if v.BytesReclaimed != uint64(len(garbage)) {
+		t.Fatalf("expected %v bytes to be reclaimed but got %v", len(garbage), v.BytesReclaimed)
+	}
+	v, err = sf.Verify(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Problems) != 0 || v.Repaired || v.BytesReclaimed != 0 {
+		t.Fatal("repaired file shouldn't have problems", v)
+	}
+
+	// The repaired file loads with the same pieces.
+	sf2, err := LoadSiaFile(sf.siaFilePath, sf.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pieces, err := sf2.Pieces(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pieces[0]) != 1 || pieces[0][0].HostPubKey.String() != pk.String() {
+		t.Fatal("repaired file lost its pieces", pieces)
+	}
+}
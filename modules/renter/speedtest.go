@@ -0,0 +1,156 @@
+package renter
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+var (
+	// errSpeedTestNoContracts is returned if the renter has no active
+	// contracts to run a speed test against.
+	errSpeedTestNoContracts = errors.New("no active contracts to run a speed test against")
+
+	// errSpeedTestNoData is reported for hosts that don't store any sectors
+	// of the renter yet.
+	errSpeedTestNoData = errors.New("host doesn't store any data of the renter")
+
+	// errSpeedTestWrongData is reported for hosts that returned a different
+	// sector than the one that was uploaded.
+	errSpeedTestWrongData = errors.New("host returned different data than was uploaded")
+)
+
+// managedSpeedTestHost downloads up to 'sectors' random sectors from the host
+// of the contract. The sectors are fetched through the same downloader the
+// workers use, so the result reflects the throughput of regular downloads.
+// The cost is the increase of the download spending of the contract, which
+// includes downloads of other threads that happen at the same time.
+func (r *Renter) managedSpeedTestHost(contract modules.RenterContract, sectors int) modules.HostSpeedTest {
+	result := modules.HostSpeedTest{
+		HostPublicKey: contract.HostPublicKey,
+	}
+	if host, ok := r.hostDB.Host(contract.HostPublicKey); ok {
+		result.NetAddress = host.NetAddress
+	}
+	if r.hostContractor.IsOffline(contract.HostPublicKey) {
+		result.Error = "host is offline"
+		return result
+	}
+	roots, err := r.hostContractor.RandomSectorRoots(contract.ID, sectors)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	} else if len(roots) == 0 {
+		result.Error = errSpeedTestNoData.Error()
+		return result
+	}
+	return r.managedDownloadSpeedTest(result, contract, roots, nil)
+}
+
+// managedDownloadSpeedTest downloads the sectors with the provided roots from
+// the host of the contract and adds the measurements to result. If expected
+// is set, every sector has to match it.
+func (r *Renter) managedDownloadSpeedTest(result modules.HostSpeedTest, contract modules.RenterContract, roots []crypto.Hash, expected []byte) modules.HostSpeedTest {
+	start := time.Now()
+	d, err := r.hostContractor.DownloaderForContract(contract.ID, r.tg.StopChan())
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Latency = time.Since(start)
+
+	start = time.Now()
+	for _, root := range roots {
+		sector, err := d.Sector(root)
+		if err != nil {
+			result.Error = err.Error()
+			break
+		} else if expected != nil && !bytes.Equal(sector, expected) {
+			result.Error = errSpeedTestWrongData.Error()
+			break
+		}
+		result.Bytes += modules.SectorSize
+	}
+	result.Duration = time.Since(start)
+	if err := d.Close(); err != nil {
+		r.log.Debugln("Unable to close downloader after speed test:", err)
+	}
+	if result.Duration > 0 {
+		result.Throughput = float64(result.Bytes) / result.Duration.Seconds()
+	}
+	if updated, ok := r.hostContractor.ContractByPublicKey(contract.HostPublicKey); ok && updated.DownloadSpending.Cmp(contract.DownloadSpending) > 0 {
+		result.Cost = updated.DownloadSpending.Sub(contract.DownloadSpending)
+	}
+	return result
+}
+
+// SpeedTest downloads up to sectorsPerHost random sectors from every host the
+// renter has an active contract with. All hosts are tested at the same time,
+// so the combined throughput is what a download spread across the whole
+// contract set can achieve.
+func (r *Renter) SpeedTest(sectorsPerHost uint64) (modules.RenterSpeedTest, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterSpeedTest{}, err
+	}
+	defer r.tg.Done()
+	if sectorsPerHost == 0 || sectorsPerHost > maxSpeedTestSectors {
+		return modules.RenterSpeedTest{}, fmt.Errorf("number of sectors per host must be between 1 and %v", maxSpeedTestSectors)
+	}
+
+	var contracts []modules.RenterContract
+	for _, contract := range r.hostContractor.Contracts() {
+		if contract.Utility.GoodForUpload || contract.Utility.GoodForRenew {
+			contracts = append(contracts, contract)
+		}
+	}
+	if len(contracts) == 0 {
+		return modules.RenterSpeedTest{}, errSpeedTestNoContracts
+	}
+
+	results := make([]modules.HostSpeedTest, len(contracts))
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := range contracts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = r.managedSpeedTestHost(contracts[i], int(sectorsPerHost))
+		}(i)
+	}
+	wg.Wait()
+	return combineSpeedTests(results, time.Since(start)), nil
+}
+
+// combineSpeedTests combines the results of hosts that were tested at the
+// same time, which took duration in total.
+func combineSpeedTests(results []modules.HostSpeedTest, duration time.Duration) modules.RenterSpeedTest {
+	st := modules.RenterSpeedTest{
+		Hosts:    results,
+		Duration: duration,
+		Cost:     types.ZeroCurrency,
+	}
+	var reached int64
+	var totalLatency time.Duration
+	for _, result := range results {
+		st.Bytes += result.Bytes
+		st.Cost = st.Cost.Add(result.Cost)
+		if result.Latency > 0 {
+			reached++
+			totalLatency += result.Latency
+		}
+	}
+	if reached > 0 {
+		st.AverageLatency = totalLatency / time.Duration(reached)
+	}
+	if st.Duration > 0 {
+		st.Throughput = float64(st.Bytes) / st.Duration.Seconds()
+	}
+	return st
+}
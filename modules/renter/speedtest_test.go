@@ -0,0 +1,255 @@
+package renter
+
+import (
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/contractor"
+	"github.com/HyperspaceApp/Hyperspace/persist"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+// speedTestPrice is the download spending the speedTestContractor adds for
+// every downloaded sector.
+const speedTestPrice = 10
+
+// speedTestContract describes the behavior of a contract of the
+// speedTestContractor.
+type speedTestContract struct {
+	contract      modules.RenterContract
+	offline       bool
+	sectors       int
+	downloaderErr error
+	sectorErr     error // returned after the first sector
+	editorErr     error
+	uploadErr     error
+	uploaded      []byte // returned by every download once set
+	corrupt       bool   // downloads return empty sectors even after an upload
+}
+
+// speedTestContractor is a hostContractor that serves the calls of a speed
+// test. The embedded interface is nil, all other methods panic.
+type speedTestContractor struct {
+	hostContractor
+
+	mu        sync.Mutex
+	contracts map[types.FileContractID]*speedTestContract
+}
+
+func (c *speedTestContractor) Contracts() []modules.RenterContract {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var contracts []modules.RenterContract
+	for _, stc := range c.contracts {
+		contracts = append(contracts, stc.contract)
+	}
+	return contracts
+}
+
+func (c *speedTestContractor) contractByKey(pk types.SiaPublicKey) (*speedTestContract, bool) {
+	for _, stc := range c.contracts {
+		if stc.contract.HostPublicKey.String() == pk.String() {
+			return stc, true
+		}
+	}
+	return nil, false
+}
+
+func (c *speedTestContractor) ContractByPublicKey(pk types.SiaPublicKey) (modules.RenterContract, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stc, ok := c.contractByKey(pk)
+	if !ok {
+		return modules.RenterContract{}, false
+	}
+	return stc.contract, true
+}
+
+func (c *speedTestContractor) IsOffline(pk types.SiaPublicKey) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stc, ok := c.contractByKey(pk)
+	return !ok || stc.offline
+}
+
+func (c *speedTestContractor) RandomSectorRoots(id types.FileContractID, n int) ([]crypto.Hash, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stc := c.contracts[id]
+	if n > stc.sectors {
+		n = stc.sectors
+	}
+	return make([]crypto.Hash, n), nil
+}
+
+func (c *speedTestContractor) DownloaderForContract(id types.FileContractID, cancel <-chan struct{}) (contractor.Downloader, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stc := c.contracts[id]
+	if stc.downloaderErr != nil {
+		return nil, stc.downloaderErr
+	}
+	return &speedTestDownloader{contractor: c, id: id}, nil
+}
+
+// speedTestDownloader downloads empty sectors and charges speedTestPrice for
+// every sector.
+type speedTestDownloader struct {
+	contractor *speedTestContractor
+	id         types.FileContractID
+	downloaded int
+}
+
+func (d *speedTestDownloader) Sector(crypto.Hash) ([]byte, error) {
+	d.contractor.mu.Lock()
+	defer d.contractor.mu.Unlock()
+	stc := d.contractor.contracts[d.id]
+	if d.downloaded > 0 && stc.sectorErr != nil {
+		return nil, stc.sectorErr
+	}
+	d.downloaded++
+	stc.contract.DownloadSpending = stc.contract.DownloadSpending.Add(types.NewCurrency64(speedTestPrice))
+	if stc.uploaded != nil && !stc.corrupt {
+		return stc.uploaded, nil
+	}
+	return make([]byte, modules.SectorSize), nil
+}
+
+func (d *speedTestDownloader) SectorWithDeadline(root crypto.Hash, _ time.Time) ([]byte, time.Duration, error) {
+	sector, err := d.Sector(root)
+	return sector, 0, err
+}
+
+func (d *speedTestDownloader) Close() error { return nil }
+
+func (c *speedTestContractor) EditorForContract(id types.FileContractID, cancel <-chan struct{}) (contractor.Editor, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stc := c.contracts[id]
+	if stc.editorErr != nil {
+		return nil, stc.editorErr
+	}
+	return &speedTestEditor{contractor: c, id: id}, nil
+}
+
+// speedTestEditor stores the uploaded sector with the contract and charges
+// speedTestPrice for it. The embedded interface is nil, all other methods
+// panic.
+type speedTestEditor struct {
+	contractor.Editor
+	contractor *speedTestContractor
+	id         types.FileContractID
+}
+
+func (e *speedTestEditor) Upload(data []byte) (crypto.Hash, error) {
+	e.contractor.mu.Lock()
+	defer e.contractor.mu.Unlock()
+	stc := e.contractor.contracts[e.id]
+	if stc.uploadErr != nil {
+		return crypto.Hash{}, stc.uploadErr
+	}
+	stc.uploaded = append([]byte(nil), data...)
+	stc.contract.UploadSpending = stc.contract.UploadSpending.Add(types.NewCurrency64(speedTestPrice))
+	return crypto.MerkleRoot(data), nil
+}
+
+func (e *speedTestEditor) Close() error { return nil }
+
+// TestSpeedTest checks that the speed test reports the measurements of every
+// host, including the hosts that couldn't be tested, and combines them.
+func TestSpeedTest(t *testing.T) {
+	errDownloader := errors.New("unable to connect")
+	errSector := errors.New("sector not found")
+	contracts := []*speedTestContract{
+		{sectors: 4},
+		{sectors: 1},
+		{sectors: 4, offline: true},
+		{sectors: 0},
+		{sectors: 4, downloaderErr: errDownloader},
+		{sectors: 4, sectorErr: errSector},
+	}
+	c := &speedTestContractor{contracts: make(map[types.FileContractID]*speedTestContract)}
+	hostKeys := make([]types.SiaPublicKey, len(contracts))
+	for i, stc := range contracts {
+		hostKeys[i] = types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{byte(i)}}
+		stc.contract = modules.RenterContract{
+			ID:               types.FileContractID{byte(i)},
+			HostPublicKey:    hostKeys[i],
+			DownloadSpending: types.NewCurrency64(100),
+			Utility:          modules.ContractUtility{GoodForUpload: true},
+		}
+		c.contracts[stc.contract.ID] = stc
+	}
+	r := &Renter{
+		hostContractor: c,
+		hostDB:         stubHostDB{},
+		log:            persist.NewLogger(ioutil.Discard),
+	}
+
+	// The number of sectors needs to be within bounds.
+	if _, err := r.SpeedTest(0); err == nil {
+		t.Fatal("speed test without sectors should fail")
+	}
+	if _, err := r.SpeedTest(maxSpeedTestSectors + 1); err == nil {
+		t.Fatal("speed test with too many sectors should fail")
+	}
+
+	st, err := r.SpeedTest(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Hosts) != len(contracts) {
+		t.Fatalf("expected %v hosts, got %v", len(contracts), len(st.Hosts))
+	}
+	// Only the hosts that were connected to have a latency.
+	expected := map[string]struct {
+		sectors   uint64
+		err       string
+		connected bool
+	}{
+		hostKeys[0].String(): {2, "", true},
+		hostKeys[1].String(): {1, "", true},
+		hostKeys[2].String(): {0, "host is offline", false},
+		hostKeys[3].String(): {0, errSpeedTestNoData.Error(), false},
+		hostKeys[4].String(): {0, errDownloader.Error(), false},
+		hostKeys[5].String(): {1, errSector.Error(), true},
+	}
+	for _, host := range st.Hosts {
+		exp, ok := expected[host.HostPublicKey.String()]
+		if !ok {
+			t.Fatal("unexpected host", host.HostPublicKey)
+		}
+		if host.Bytes != exp.sectors*modules.SectorSize || host.Error != exp.err {
+			t.Fatalf("unexpected result for host %v: %v bytes, error %q", host.HostPublicKey, host.Bytes, host.Error)
+		}
+		if cost := types.NewCurrency64(exp.sectors * speedTestPrice); !host.Cost.Equals(cost) {
+			t.Fatalf("expected a cost of %v for host %v, got %v", cost, host.HostPublicKey, host.Cost)
+		}
+		if host.Bytes > 0 && host.Throughput <= 0 {
+			t.Fatal("no throughput reported for host", host.HostPublicKey)
+		}
+		if !exp.connected && host.Latency != 0 {
+			t.Fatal("latency reported for a host that wasn't connected to", host.HostPublicKey)
+		}
+	}
+	if st.Bytes != 4*modules.SectorSize || !st.Cost.Equals64(4*speedTestPrice) {
+		t.Fatalf("unexpected totals: %v bytes for %v", st.Bytes, st.Cost)
+	}
+	if st.Throughput <= 0 {
+		t.Fatal("no combined throughput reported")
+	}
+
+	// Without usable contracts there is nothing to test.
+	for _, stc := range contracts {
+		stc.contract.Utility = modules.ContractUtility{}
+	}
+	if _, err := r.SpeedTest(1); err != errSpeedTestNoContracts {
+		t.Fatal("expected errSpeedTestNoContracts, got", err)
+	}
+}
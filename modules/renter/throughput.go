@@ -0,0 +1,167 @@
+package renter
+
+// throughput.go keeps a history of the aggregate upload and download
+// throughput of the workers. The workers add the bytes of every piece they
+// transfer to a pair of counters, and the sampling loop records the increase
+// of the counters every throughputSampleInterval. The most recent
+// throughputHistoryLen samples are kept in a ring buffer, older samples are
+// overwritten. The history is not persisted.
+//
+// The repair estimate uses the same history. Its upload throughput is the
+// average of the samples of the last repairThroughputWindow, plus the bytes
+// that were uploaded since the last sample.
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// throughputHistory counts the bytes transferred by the workers and keeps the
+// recent samples of the counters.
+type throughputHistory struct {
+	// The counters are accessed atomically and need to be at the start of
+	// the struct to be 64-bit aligned.
+	atomicBytesUploaded   uint64
+	atomicBytesDownloaded uint64
+
+	// samples is a ring buffer, next is the index that the next sample is
+	// written to. start is the time at which the history started sampling.
+	start          time.Time
+	lastSample     time.Time
+	lastUploaded   uint64
+	lastDownloaded uint64
+	next           int
+	samples        []modules.RenterThroughputSample
+	mu             sync.Mutex
+}
+
+// newThroughputHistory returns a history that starts sampling at now.
+func newThroughputHistory(now time.Time) *throughputHistory {
+	return &throughputHistory{
+		start:      now,
+		lastSample: now,
+		samples:    make([]modules.RenterThroughputSample, 0, throughputHistoryLen),
+	}
+}
+
+// addUploaded records bytes uploaded by a worker.
+func (th *throughputHistory) addUploaded(bytes uint64) {
+	atomic.AddUint64(&th.atomicBytesUploaded, bytes)
+}
+
+// addDownloaded records bytes downloaded by a worker.
+func (th *throughputHistory) addDownloaded(bytes uint64) {
+	atomic.AddUint64(&th.atomicBytesDownloaded, bytes)
+}
+
+// managedSample records the bytes that were transferred since the previous
+// sample.
+func (th *throughputHistory) managedSample(now time.Time) {
+	uploaded := atomic.LoadUint64(&th.atomicBytesUploaded)
+	downloaded := atomic.LoadUint64(&th.atomicBytesDownloaded)
+
+	th.mu.Lock()
+	defer th.mu.Unlock()
+	sample := modules.RenterThroughputSample{
+		Time:            now,
+		BytesUploaded:   uploaded - th.lastUploaded,
+		BytesDownloaded: downloaded - th.lastDownloaded,
+	}
+	if elapsed := now.Sub(th.lastSample).Seconds(); elapsed > 0 {
+		sample.UploadThroughput = float64(sample.BytesUploaded) / elapsed
+		sample.DownloadThroughput = float64(sample.BytesDownloaded) / elapsed
+	}
+	th.lastSample, th.lastUploaded, th.lastDownloaded = now, uploaded, downloaded
+
+	if len(th.samples) < throughputHistoryLen {
+		th.samples = append(th.samples, sample)
+	} else {
+		th.samples[th.next] = sample
+	}
+	th.next = (th.next + 1) % throughputHistoryLen
+}
+
+// managedUploadThroughput returns the average upload throughput of the last
+// repairThroughputWindow in bytes per second. The average starts at the oldest
+// sample within the window that has uploads, so a renter that only started
+// uploading recently isn't averaged over the idle time before.
+func (th *throughputHistory) managedUploadThroughput(now time.Time) float64 {
+	uploaded := atomic.LoadUint64(&th.atomicBytesUploaded)
+
+	th.mu.Lock()
+	defer th.mu.Unlock()
+	total := uploaded - th.lastUploaded
+	var start time.Time
+	if total > 0 {
+		start = th.lastSample
+	}
+	// Walk the samples newest first. Every sample covers the time since the
+	// previous sample, which is unknown for the oldest sample of a full
+	// history.
+	for i := 1; i <= len(th.samples); i++ {
+		s := th.samples[(th.next-i+throughputHistoryLen)%throughputHistoryLen]
+		if now.Sub(s.Time) >= repairThroughputWindow {
+			break
+		}
+		if s.BytesUploaded == 0 {
+			continue
+		}
+		if i < len(th.samples) {
+			start = th.samples[(th.next-i-1+throughputHistoryLen)%throughputHistoryLen].Time
+		} else if len(th.samples) < throughputHistoryLen {
+			start = th.start
+		} else {
+			break
+		}
+		total += s.BytesUploaded
+	}
+	if total == 0 {
+		return 0
+	}
+	elapsed := now.Sub(start)
+	if elapsed < time.Second {
+		elapsed = time.Second
+	}
+	return float64(total) / elapsed.Seconds()
+}
+
+// managedSamples returns the samples in the history, oldest first.
+func (th *throughputHistory) managedSamples() []modules.RenterThroughputSample {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+	samples := make([]modules.RenterThroughputSample, 0, len(th.samples))
+	if len(th.samples) == throughputHistoryLen {
+		samples = append(samples, th.samples[th.next:]...)
+		return append(samples, th.samples[:th.next]...)
+	}
+	return append(samples, th.samples...)
+}
+
+// threadedSampleThroughput samples the throughput counters every
+// throughputSampleInterval until the renter is stopped.
+func (r *Renter) threadedSampleThroughput() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	ticker := time.NewTicker(throughputSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case now := <-ticker.C:
+			r.throughputHistory.managedSample(now)
+		}
+	}
+}
+
+// ThroughputHistory returns the recent throughput samples of the renter,
+// oldest first.
+func (r *Renter) ThroughputHistory() []modules.RenterThroughputSample {
+	return r.throughputHistory.managedSamples()
+}
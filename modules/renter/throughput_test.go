@@ -0,0 +1,66 @@
+package renter
+
+import (
+	"testing"
+	"time"
+)
+
+// TestThroughputHistory checks that the samples record the bytes transferred
+// since the previous sample and that the oldest samples are overwritten.
+func TestThroughputHistory(t *testing.T) {
+	now := time.Now()
+	th := newThroughputHistory(now)
+	th.addUploaded(1000)
+	th.addDownloaded(500)
+	th.managedSample(now.Add(10 * time.Second))
+	samples := th.managedSamples()
+	if len(samples) != 1 {
+		t.Fatal("expected 1 sample, got", len(samples))
+	}
+	if s := samples[0]; s.BytesUploaded != 1000 || s.BytesDownloaded != 500 || s.UploadThroughput != 100 || s.DownloadThroughput != 50 {
+		t.Fatal("wrong sample", s)
+	}
+
+	// Fill the history until the first sample is overwritten.
+	for i := 1; i <= throughputHistoryLen; i++ {
+		th.addUploaded(uint64(i))
+		th.managedSample(now.Add(time.Duration(i+1) * 10 * time.Second))
+	}
+	samples = th.managedSamples()
+	if len(samples) != throughputHistoryLen {
+		t.Fatal("expected a full history, got", len(samples))
+	}
+	if samples[0].BytesUploaded != 1 || samples[len(samples)-1].BytesUploaded != throughputHistoryLen {
+		t.Fatal("samples aren't ordered oldest first", samples[0], samples[len(samples)-1])
+	}
+	for i := 1; i < len(samples); i++ {
+		if !samples[i].Time.After(samples[i-1].Time) {
+			t.Fatal("samples aren't ordered oldest first")
+		}
+	}
+}
+
+// TestUploadThroughput checks that the upload throughput is averaged over the
+// samples of the last repairThroughputWindow, starting at the oldest sample
+// with uploads, and includes the bytes uploaded since the last sample.
+func TestUploadThroughput(t *testing.T) {
+	now := time.Now()
+	th := newThroughputHistory(now)
+	if tp := th.managedUploadThroughput(now); tp != 0 {
+		t.Fatal("expected no throughput without uploads, got", tp)
+	}
+
+	// The first sample is outside the window when the throughput is
+	// computed, the idle second sample only starts the average.
+	th.addUploaded(100)
+	th.managedSample(now.Add(repairThroughputWindow))
+	th.managedSample(now.Add(2 * repairThroughputWindow))
+	th.addUploaded(1000)
+	th.managedSample(now.Add(2*repairThroughputWindow + repairThroughputWindow/2))
+	th.addUploaded(1000)
+
+	tp := th.managedUploadThroughput(now.Add(2*repairThroughputWindow + 3*repairThroughputWindow/4))
+	if expected := 2000 / (3 * repairThroughputWindow / 4).Seconds(); tp != expected {
+		t.Fatalf("expected a throughput of %v bytes per second, got %v", expected, tp)
+	}
+}
@@ -0,0 +1,158 @@
+package renter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+var (
+	// errTrialNotActive is returned if the trial hosts are tested while no
+	// trial is running.
+	errTrialNotActive = errors.New("no trial is running")
+
+	// errTrialNotUploaded is reported for hosts that the test file couldn't
+	// be uploaded to, so it can't be downloaded either.
+	errTrialNotUploaded = errors.New("test file wasn't uploaded to the host")
+)
+
+// StartTrial sets a trial allowance with the provided funds. The contractor
+// forms contracts with a few hosts for a short period, which is enough to
+// upload and download a test file before committing to a full allowance.
+func (r *Renter) StartTrial(funds types.Currency) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.hostContractor.StartTrial(funds)
+}
+
+// Trial reports the state of the trial and the spending of its contracts.
+func (r *Renter) Trial() modules.RenterTrialReport {
+	return modules.RenterTrialReport{
+		Trial:    r.hostContractor.Trial(),
+		Spending: r.hostContractor.PeriodSpending(),
+	}
+}
+
+// TestTrial uploads a test file of one sector of random data to every host of
+// the running trial and downloads it again. First all hosts receive the file
+// at the same time, then it is downloaded from all of them, so the combined
+// throughput of both steps is what a file spread across the trial hosts can
+// achieve. The test file isn't tracked by the renter, its sectors stay with
+// the hosts until the contracts of the trial end.
+func (r *Renter) TestTrial() (modules.RenterTrialReport, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterTrialReport{}, err
+	}
+	defer r.tg.Done()
+	if !r.hostContractor.Trial().Active {
+		return modules.RenterTrialReport{}, errTrialNotActive
+	}
+	var contracts []modules.RenterContract
+	for _, contract := range r.hostContractor.Contracts() {
+		if contract.Utility.GoodForUpload {
+			contracts = append(contracts, contract)
+		}
+	}
+	if len(contracts) == 0 {
+		return modules.RenterTrialReport{}, errSpeedTestNoContracts
+	}
+
+	data := fastrand.Bytes(int(modules.SectorSize))
+	uploads := make([]modules.HostSpeedTest, len(contracts))
+	roots := make([]crypto.Hash, len(contracts))
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := range contracts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			uploads[i], roots[i] = r.managedUploadSpeedTest(contracts[i], data)
+		}(i)
+	}
+	wg.Wait()
+	upload := combineSpeedTests(uploads, time.Since(start))
+
+	downloads := make([]modules.HostSpeedTest, len(contracts))
+	start = time.Now()
+	for i := range contracts {
+		if uploads[i].Bytes == 0 {
+			downloads[i] = modules.HostSpeedTest{
+				HostPublicKey: uploads[i].HostPublicKey,
+				NetAddress:    uploads[i].NetAddress,
+				Error:         errTrialNotUploaded.Error(),
+			}
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result := modules.HostSpeedTest{
+				HostPublicKey: uploads[i].HostPublicKey,
+				NetAddress:    uploads[i].NetAddress,
+			}
+			downloads[i] = r.managedDownloadSpeedTest(result, contracts[i], []crypto.Hash{roots[i]}, data)
+		}(i)
+	}
+	wg.Wait()
+	download := combineSpeedTests(downloads, time.Since(start))
+
+	report := r.Trial()
+	report.Upload = &upload
+	report.Download = &download
+	return report, nil
+}
+
+// managedUploadSpeedTest uploads data as a single sector to the host of the
+// contract and returns the measurements together with the Merkle root of the
+// sector. The cost is the increase of the upload and storage spending of the
+// contract.
+func (r *Renter) managedUploadSpeedTest(contract modules.RenterContract, data []byte) (modules.HostSpeedTest, crypto.Hash) {
+	result := modules.HostSpeedTest{
+		HostPublicKey: contract.HostPublicKey,
+	}
+	if host, ok := r.hostDB.Host(contract.HostPublicKey); ok {
+		result.NetAddress = host.NetAddress
+	}
+	if r.hostContractor.IsOffline(contract.HostPublicKey) {
+		result.Error = "host is offline"
+		return result, crypto.Hash{}
+	}
+
+	start := time.Now()
+	e, err := r.hostContractor.EditorForContract(contract.ID, r.tg.StopChan())
+	if err != nil {
+		result.Error = err.Error()
+		return result, crypto.Hash{}
+	}
+	result.Latency = time.Since(start)
+
+	start = time.Now()
+	root, err := e.Upload(data)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Bytes = uint64(len(data))
+	}
+	if err := e.Close(); err != nil {
+		r.log.Debugln("Unable to close editor after speed test:", err)
+	}
+	if result.Duration > 0 {
+		result.Throughput = float64(result.Bytes) / result.Duration.Seconds()
+	}
+	spent := contract.UploadSpending.Add(contract.StorageSpending)
+	if updated, ok := r.hostContractor.ContractByPublicKey(contract.HostPublicKey); ok {
+		if updatedSpent := updated.UploadSpending.Add(updated.StorageSpending); updatedSpent.Cmp(spent) > 0 {
+			result.Cost = updatedSpent.Sub(spent)
+		}
+	}
+	return result, root
+}
@@ -0,0 +1,111 @@
+package renter
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/persist"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+)
+
+// trialContractor is a speedTestContractor that runs a trial.
+type trialContractor struct {
+	*speedTestContractor
+	trial modules.RenterTrial
+}
+
+func (c *trialContractor) Trial() modules.RenterTrial { return c.trial }
+
+func (c *trialContractor) PeriodSpending() modules.ContractorSpending {
+	return modules.ContractorSpending{}
+}
+
+// TestTestTrial checks that testing a trial uploads the test file to every
+// host, downloads and checks it again from the hosts it was uploaded to and
+// reports the measurements of both.
+func TestTestTrial(t *testing.T) {
+	errEditor := errors.New("unable to connect")
+	errUpload := errors.New("not enough storage")
+	contracts := []*speedTestContract{
+		{},
+		{},
+		{offline: true},
+		{editorErr: errEditor},
+		{uploadErr: errUpload},
+		{corrupt: true},
+	}
+	c := &trialContractor{
+		speedTestContractor: &speedTestContractor{contracts: make(map[types.FileContractID]*speedTestContract)},
+	}
+	hostKeys := make([]types.SiaPublicKey, len(contracts))
+	for i, stc := range contracts {
+		hostKeys[i] = types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{byte(i)}}
+		stc.contract = modules.RenterContract{
+			ID:            types.FileContractID{byte(i)},
+			HostPublicKey: hostKeys[i],
+			Utility:       modules.ContractUtility{GoodForUpload: true},
+		}
+		c.contracts[stc.contract.ID] = stc
+	}
+	r := &Renter{
+		hostContractor: c,
+		hostDB:         stubHostDB{},
+		log:            persist.NewLogger(ioutil.Discard),
+	}
+
+	// The hosts can only be tested while a trial is running.
+	if _, err := r.TestTrial(); err != errTrialNotActive {
+		t.Fatal("expected errTrialNotActive, got", err)
+	}
+	c.trial.Active = true
+
+	report, err := r.TestTrial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Upload == nil || report.Download == nil {
+		t.Fatal("trial test didn't report the upload and download")
+	}
+	expected := map[string]struct {
+		uploadErr, downloadErr string
+	}{
+		hostKeys[0].String(): {"", ""},
+		hostKeys[1].String(): {"", ""},
+		hostKeys[2].String(): {"host is offline", errTrialNotUploaded.Error()},
+		hostKeys[3].String(): {errEditor.Error(), errTrialNotUploaded.Error()},
+		hostKeys[4].String(): {errUpload.Error(), errTrialNotUploaded.Error()},
+		hostKeys[5].String(): {"", errSpeedTestWrongData.Error()},
+	}
+	check := func(results []modules.HostSpeedTest, upload bool) {
+		if len(results) != len(contracts) {
+			t.Fatalf("expected %v hosts, got %v", len(contracts), len(results))
+		}
+		for _, host := range results {
+			exp, ok := expected[host.HostPublicKey.String()]
+			if !ok {
+				t.Fatal("unexpected host", host.HostPublicKey)
+			}
+			wantErr := exp.downloadErr
+			if upload {
+				wantErr = exp.uploadErr
+			}
+			if host.Error != wantErr {
+				t.Fatalf("expected error %q for host %v, got %q", wantErr, host.HostPublicKey, host.Error)
+			}
+			if wantErr == "" && (host.Bytes != modules.SectorSize || !host.Cost.Equals64(speedTestPrice)) {
+				t.Fatalf("unexpected result for host %v: %v bytes for %v", host.HostPublicKey, host.Bytes, host.Cost)
+			} else if wantErr != "" && host.Bytes != 0 {
+				t.Fatal("failed host reported transferred data", host.HostPublicKey)
+			}
+		}
+	}
+	check(report.Upload.Hosts, true)
+	check(report.Download.Hosts, false)
+	if report.Upload.Bytes != 3*modules.SectorSize || report.Download.Bytes != 2*modules.SectorSize {
+		t.Fatal("unexpected totals", report.Upload.Bytes, report.Download.Bytes)
+	}
+
+}
@@ -87,17 +87,32 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 		return err
 	}
 	if up.ErasureCode == nil {
-		up.ErasureCode, _ = siafile.NewRSCode(defaultDataPieces, defaultParityPieces)
+		up.ErasureCode, err = r.managedUploadErasureCode(up.SiaPath)
+		if err != nil {
+			return errors.AddContext(err, "invalid erasure code of the directory")
+		}
 	}
 
 	// Check that we have contracts to upload to. We need at least data +
 	// parity/2 contracts. NumPieces is equal to data+parity, and min pieces is
 	// equal to parity. Therefore (NumPieces+MinPieces)/2 = (data+data+parity)/2
 	// = data+parity/2.
+	//
+	// If the allowance allows degraded uploads, fewer contracts are accepted
+	// and the file is flagged as degraded until the repair loop uploaded the
+	// remaining pieces.
 	numContracts := len(r.hostContractor.Contracts())
 	requiredContracts := (up.ErasureCode.NumPieces() + up.ErasureCode.MinPieces()) / 2
+	var degraded bool
 	if numContracts < requiredContracts && build.Release != "testing" {
-		return fmt.Errorf("not enough contracts to upload file: got %v, needed %v", numContracts, (up.ErasureCode.NumPieces()+up.ErasureCode.MinPieces())/2)
+		allowance := r.hostContractor.Allowance()
+		if !allowance.AllowDegradedUpload {
+			return fmt.Errorf("not enough contracts to upload file: got %v, needed %v", numContracts, (up.ErasureCode.NumPieces()+up.ErasureCode.MinPieces())/2)
+		}
+		if minContracts := degradedUploadMinContracts(allowance, up.ErasureCode); numContracts < minContracts {
+			return fmt.Errorf("not enough contracts to upload file, even degraded: got %v, needed %v", numContracts, minContracts)
+		}
+		degraded = true
 	}
 
 	// Create the directory path on disk. Renter directory is already present so
@@ -119,16 +134,34 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	if err != nil {
 		return err
 	}
+	if up.Dedup {
+		if err := f.SetDedup(true); err != nil {
+			return err
+		}
+	}
+	if up.RedundancyGroup != "" {
+		if err := f.SetRedundancyGroup(up.RedundancyGroup); err != nil {
+			return err
+		}
+	}
+	if degraded {
+		if err := f.SetDegraded(true); err != nil {
+			return err
+		}
+		r.log.Printf("Uploading %v degraded with %v contracts, %v are needed for its full redundancy", up.SiaPath, numContracts, up.ErasureCode.NumPieces())
+	}
 
-	// Add file to renter.
+	// Add file to renter. The content hash is computed while its chunks
+	// are read for the upload.
 	lockID = r.mu.Lock()
 	r.files[up.SiaPath] = f
 	r.mu.Unlock(lockID)
+	r.managedStartContentHash(f)
 
 	// Send the upload to the repair loop.
 	hosts := r.managedRefreshHostsAndWorkers()
 	id := r.mu.Lock()
-	unfinishedChunks := r.buildUnfinishedChunks(f, hosts)
+	unfinishedChunks := r.buildUnfinishedChunks(f, hosts, r.redundancyGroups())
 	r.mu.Unlock(id)
 	for i := 0; i < len(unfinishedChunks); i++ {
 		r.uploadHeap.managedPush(unfinishedChunks[i])
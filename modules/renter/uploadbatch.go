@@ -0,0 +1,564 @@
+package renter
+
+// A batch upload packs small files into shared chunks. Every file is padded to
+// a full chunk when it is uploaded on its own, so uploading many small files
+// one at a time uploads a full sector to every host for each of them and
+// revises every contract once per file. The files of a batch are instead
+// packed into as few chunks as possible, and every file of a pack gets the
+// pieces of the pack's chunk. The metadata of a packed file records the offset
+// of its data within the pack, which downloads use to extract it.
+//
+// The packs of a batch are uploaded concurrently. Their chunks compete for
+// repair slots and memory like any other chunk, so the number of packs that
+// are uploaded at the same time is limited by the repair limiter and the
+// memory manager. A pack counts as uploaded once enough pieces are stored to
+// recover it, the repair loop takes care of the remaining pieces. If a pack
+// can't be uploaded, or the renter is stopped before it is, its files are not
+// tracked and are reported as failed. The other packs of the batch are not
+// affected.
+//
+// Every file is uploaded with the redundancy of its directory. Only files with
+// the same erasure code can share a pack, so the files are packed separately
+// for every erasure code of the batch.
+//
+// Files that don't fit into a single chunk can't be packed and are rejected.
+// Packed files can't be rekeyed, since the other files of the pack would no
+// longer be able to decrypt the shared pieces.
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/errors"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+var (
+	// errBatchDuplicatePath is returned if a siapath appears more than once
+	// in a batch.
+	errBatchDuplicatePath = errors.New("siapath appears more than once in the batch")
+
+	// errBatchEmpty is returned if a batch upload doesn't contain any files.
+	errBatchEmpty = errors.New("no files in the batch")
+
+	// errBatchFileTooLarge is returned if a file of a batch doesn't fit into
+	// a single chunk.
+	errBatchFileTooLarge = errors.New("file is too large to be packed, it needs to fit into a single chunk")
+
+	// errBatchInsufficientWorkers is returned if there aren't enough workers
+	// to upload a pack.
+	errBatchInsufficientWorkers = errors.New("not enough workers to upload the batch")
+
+	// errBatchInterrupted is returned if the renter is stopped before a pack
+	// is uploaded.
+	errBatchInterrupted = errors.New("batch upload interrupted by stop call")
+
+	// errBatchPackFailed is returned if not enough pieces of a pack could be
+	// uploaded to recover it.
+	errBatchPackFailed = errors.New("not enough pieces of the pack could be uploaded")
+
+	// errBatchTooLarge is returned if a batch contains too many files.
+	errBatchTooLarge = fmt.Errorf("a batch can't contain more than %v files", maxBatchFiles)
+)
+
+// packMember is a file stored in a pack together with the offset of its data
+// within the pack.
+type packMember struct {
+	file   *siafile.SiaFile
+	offset uint64
+}
+
+// uploadBatch tracks the progress of a batch upload. Every pack of the batch
+// is uploaded by its own thread, which reports back once the workers are done
+// with the chunk of the pack.
+type uploadBatch struct {
+	id string
+
+	failed        []string
+	files         uint64
+	filesUploaded uint64
+	finished      bool
+	err           error
+	packs         uint64
+	packsUploaded uint64
+
+	mu sync.Mutex
+}
+
+// newUploadBatch creates the tracker for a batch upload of the given number of
+// files and packs.
+func newUploadBatch(files, packs uint64) *uploadBatch {
+	return &uploadBatch{
+		id:    hex.EncodeToString(fastrand.Bytes(8)),
+		files: files,
+		packs: packs,
+	}
+}
+
+// managedPackFailed records that the files of a pack couldn't be uploaded. The
+// error of the batch combines the errors of all failed packs.
+func (b *uploadBatch) managedPackFailed(failed []string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failed = append(b.failed, failed...)
+	b.err = errors.Compose(b.err, err)
+}
+
+// managedFinish marks the batch as finished.
+func (b *uploadBatch) managedFinish() {
+	b.mu.Lock()
+	b.finished = true
+	b.mu.Unlock()
+}
+
+// managedPackUploaded records that a pack with the given number of files was
+// uploaded.
+func (b *uploadBatch) managedPackUploaded(files int) {
+	b.mu.Lock()
+	b.packsUploaded++
+	b.filesUploaded += uint64(files)
+	b.mu.Unlock()
+}
+
+// managedStatus returns the progress of the batch.
+func (b *uploadBatch) managedStatus() modules.RenterUploadBatch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := modules.RenterUploadBatch{
+		ID:            b.id,
+		Files:         b.files,
+		Packs:         b.packs,
+		PacksUploaded: b.packsUploaded,
+		FilesUploaded: b.filesUploaded,
+		Failed:        append([]string(nil), b.failed...),
+		Finished:      b.finished,
+	}
+	if b.err != nil {
+		status.Error = b.err.Error()
+	}
+	return status
+}
+
+// chunkUploadID returns the id of a chunk of a file in the upload heap. The
+// files of a pack share their chunk, so it has the same id for all of them.
+func chunkUploadID(f *siafile.SiaFile, index uint64) uploadChunkID {
+	if pack, packed := f.Packed(); packed {
+		return uploadChunkID{
+			fileUID: "pack:" + pack.ID.String(),
+			index:   index,
+		}
+	}
+	return uploadChunkID{
+		fileUID: f.UID(),
+		index:   index,
+	}
+}
+
+// packBatchFiles assigns files of the given sizes to packs of at most
+// chunkSize bytes. The largest files are placed first, every file goes into
+// the first pack it fits into. The returned packs contain the indices of
+// their files.
+func packBatchFiles(sizes []uint64, chunkSize uint64) [][]int {
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return sizes[order[a]] > sizes[order[b]]
+	})
+	var packs [][]int
+	var packSizes []uint64
+	for _, i := range order {
+		placed := false
+		for j := range packs {
+			if packSizes[j]+sizes[i] <= chunkSize {
+				packs[j] = append(packs[j], i)
+				packSizes[j] += sizes[i]
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			packs = append(packs, []int{i})
+			packSizes = append(packSizes, sizes[i])
+		}
+	}
+	return packs
+}
+
+// batchPack is a pack of a batch upload. All files of a pack share the
+// erasure code of the pack.
+type batchPack struct {
+	files []int
+	ec    modules.ErasureCoder
+}
+
+// packBatch packs the files of a batch separately for every erasure code, so
+// that the files of a pack share the erasure code of their directories. Every
+// file is expected to fit into a chunk of its erasure code.
+func packBatch(sizes []uint64, ecs []modules.ErasureCoder) []batchPack {
+	var codes []string
+	groups := make(map[string][]int)
+	for i, ec := range ecs {
+		code := fmt.Sprintf("%v+%v", ec.MinPieces(), ec.NumPieces()-ec.MinPieces())
+		if _, exists := groups[code]; !exists {
+			codes = append(codes, code)
+		}
+		groups[code] = append(groups[code], i)
+	}
+	var packs []batchPack
+	for _, code := range codes {
+		group := groups[code]
+		ec := ecs[group[0]]
+		groupSizes := make([]uint64, len(group))
+		for j, i := range group {
+			groupSizes[j] = sizes[i]
+		}
+		for _, pack := range packBatchFiles(groupSizes, batchChunkSize(ec)) {
+			files := make([]int, len(pack))
+			for j, k := range pack {
+				files[j] = group[k]
+			}
+			packs = append(packs, batchPack{files: files, ec: ec})
+		}
+	}
+	return packs
+}
+
+// batchChunkSize returns the number of bytes of files that fit into a pack
+// with the provided erasure code.
+func batchChunkSize(ec modules.ErasureCoder) uint64 {
+	return (modules.SectorSize - crypto.TypeDefaultRenter.Overhead()) * uint64(ec.MinPieces())
+}
+
+// indexPack adds a packed file to the index of packs.
+func (r *Renter) indexPack(file *siafile.SiaFile) {
+	pack, packed := file.Packed()
+	if !packed {
+		return
+	}
+	for _, m := range r.packs[pack.ID] {
+		if m.file == file {
+			return
+		}
+	}
+	r.packs[pack.ID] = append(r.packs[pack.ID], packMember{file: file, offset: pack.Offset})
+}
+
+// removePackMember removes a packed file from the index of packs. Packs
+// without files are dropped from the index.
+func (r *Renter) removePackMember(file *siafile.SiaFile) {
+	pack, packed := file.Packed()
+	if !packed {
+		return
+	}
+	// Chunks which are being uploaded hold on to the current list of
+	// members, so the list is copied rather than modified in place.
+	var members []packMember
+	for _, m := range r.packs[pack.ID] {
+		if m.file != file {
+			members = append(members, m)
+		}
+	}
+	if len(members) == 0 {
+		delete(r.packs, pack.ID)
+	} else {
+		r.packs[pack.ID] = members
+	}
+}
+
+// managedAddPiece adds an uploaded piece to the file of the chunk. If the
+// chunk is shared by a pack, the piece is added to every file of the pack
+// that wasn't deleted in the meantime. The pieces of a file that is being
+// rekeyed are accepted as long as they were encrypted with the current key of
+// their chunk.
+func (uc *unfinishedUploadChunk) managedAddPiece(hostKey types.SiaPublicKey, pieceIndex uint64, root crypto.Hash) error {
+	if uc.pack == nil && uc.key != nil {
+		return uc.renterFile.AddPieceWithKey(hostKey, uc.index, pieceIndex, root, uc.key)
+	} else if uc.pack == nil {
+		return uc.renterFile.AddPiece(hostKey, uc.index, pieceIndex, root)
+	}
+	var err error
+	added := false
+	for _, m := range uc.pack {
+		if m.file.Deleted() {
+			continue
+		}
+		if addErr := m.file.AddPiece(hostKey, uc.index, pieceIndex, root); addErr != nil {
+			err = errors.Compose(err, addErr)
+			continue
+		}
+		added = true
+	}
+	if !added {
+		return errors.Compose(errors.New("no file of the pack accepted the piece"), err)
+	}
+	return nil
+}
+
+// readPackMember reads the local copy of a packed file into the logical data
+// of its pack and returns the content hash of the file.
+func readPackMember(buf downloadDestinationBuffer, m packMember) (crypto.Hash, error) {
+	if m.file.LocalPath() == "" {
+		return crypto.Hash{}, errors.New("file not available locally")
+	}
+	f, err := os.Open(m.file.LocalPath())
+	if err != nil {
+		return crypto.Hash{}, err
+	}
+	defer f.Close()
+	data := make([]byte, m.file.Size())
+	if _, err := io.ReadFull(f, data); err != nil {
+		return crypto.Hash{}, err
+	}
+	_, err = buf.WriteAt(data, int64(m.offset))
+	return crypto.HashBytes(data), err
+}
+
+// managedFetchPackData assembles the logical data of the chunk of a pack from
+// the local copies of its files. If any of them can't be read, the pack is
+// downloaded instead if download is set.
+func (r *Renter) managedFetchPackData(chunk *unfinishedUploadChunk, download bool) error {
+	buf := NewDownloadDestinationBuffer(chunk.length, chunk.renterFile.PieceSize())
+	for _, m := range chunk.pack {
+		if m.file.Deleted() {
+			continue
+		}
+		contentHash, err := readPackMember(buf, m)
+		if err != nil && download {
+			r.log.Debugln("failed to read packed file, downloading the pack instead:", err)
+			return r.managedDownloadLogicalChunkData(chunk)
+		} else if err != nil {
+			return errors.AddContext(err, "failed to read packed file locally")
+		}
+		if m.file.ContentHash() == (crypto.Hash{}) {
+			r.managedSetContentHash(m.file, contentHash)
+		}
+	}
+	chunk.logicalChunkData = buf.buf
+	return nil
+}
+
+// managedNewBatchFile creates the siafile of a file of a batch. The file isn't
+// added to the renter.
+func (r *Renter) managedNewBatchFile(bf modules.RenterBatchFile, ec modules.ErasureCoder, cipherType crypto.CipherType) (*siafile.SiaFile, error) {
+	fileInfo, err := os.Stat(bf.Source)
+	if err != nil {
+		return nil, err
+	}
+	dir, _ := filepath.Split(bf.SiaPath)
+	dirSiaPath := strings.TrimSuffix(dir, "/")
+	if dirSiaPath != "" {
+		if err := r.createDir(dirSiaPath); err != nil {
+			return nil, err
+		}
+	}
+	siaFilePath := filepath.Join(r.persistDir, bf.SiaPath+ShareExtension)
+	return siafile.New(siaFilePath, bf.SiaPath, bf.Source, r.wal, ec, crypto.GenerateSiaKey(cipherType), uint64(fileInfo.Size()), fileInfo.Mode())
+}
+
+// managedUploadPack creates the files of a pack, adds them to the renter and
+// waits until enough pieces of the pack are uploaded to recover it. If the
+// upload fails, the files of the pack are removed again.
+func (r *Renter) managedUploadPack(files []modules.RenterBatchFile, sizes []uint64, pack []int, ec modules.ErasureCoder) error {
+	cipherType := crypto.TypeDefaultRenter
+	key := crypto.GenerateSiaKey(cipherType)
+	pc := siafile.PackedChunk{}
+	fastrand.Read(pc.ID[:])
+	for _, i := range pack {
+		pc.Length += sizes[i]
+	}
+
+	// Create the files of the pack.
+	members := make([]packMember, 0, len(pack))
+	deleteMembers := func() {
+		for _, m := range members {
+			m.file.Delete()
+		}
+	}
+	for _, i := range pack {
+		f, err := r.managedNewBatchFile(files[i], ec, cipherType)
+		if err != nil {
+			deleteMembers()
+			return errors.AddContext(err, "unable to create "+files[i].SiaPath)
+		}
+		members = append(members, packMember{file: f, offset: pc.Offset})
+		if err := f.SetPacked(key, pc); err != nil {
+			deleteMembers()
+			return errors.AddContext(err, "unable to pack "+files[i].SiaPath)
+		}
+		pc.Offset += sizes[i]
+	}
+
+	// Add the files to the renter and hand the chunk of the pack to the
+	// workers.
+	hosts := r.managedRefreshHostsAndWorkers()
+	id := r.mu.Lock()
+	for _, m := range members {
+		if _, exists := r.files[m.file.SiaPath()]; exists {
+			r.mu.Unlock(id)
+			deleteMembers()
+			return errors.AddContext(ErrPathOverload, m.file.SiaPath())
+		}
+	}
+	for _, m := range members {
+		r.files[m.file.SiaPath()] = m.file
+		r.indexPack(m.file)
+	}
+	chunks := r.buildUnfinishedChunks(members[0].file, hosts, r.redundancyGroups())
+	r.mu.Unlock(id)
+	removeMembers := func() {
+		for _, m := range members {
+			r.DeleteFile(m.file.SiaPath())
+		}
+	}
+	if len(chunks) == 0 {
+		removeMembers()
+		return errBatchInsufficientWorkers
+	}
+	chunk := chunks[0]
+	chunk.packDone = make(chan bool, 1)
+	if !r.uploadHeap.managedTryActivate(chunk.id) {
+		// The id of the pack is random, the chunk can't be active yet.
+		removeMembers()
+		return errors.New("chunk of the pack is already being uploaded")
+	}
+	// Blocks until a repair slot and enough memory are available, which
+	// limits the number of packs that are uploaded at the same time.
+	r.managedPrepareNextChunk(chunk, hosts)
+
+	select {
+	case recoverable := <-chunk.packDone:
+		if !recoverable {
+			removeMembers()
+			return errBatchPackFailed
+		}
+	case <-r.tg.StopChan():
+		// The batch is reported as failed, so the files of the pack must not
+		// be tracked either, even if some of their pieces were uploaded.
+		removeMembers()
+		return errBatchInterrupted
+	}
+	return nil
+}
+
+// threadedUploadBatch uploads the packs of a batch concurrently and finishes
+// the batch once all of them are done. The files of the packs that fail are
+// reported as failed.
+func (r *Renter) threadedUploadBatch(b *uploadBatch, files []modules.RenterBatchFile, sizes []uint64, packs []batchPack) {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	var wg sync.WaitGroup
+	for _, pack := range packs {
+		wg.Add(1)
+		go func(pack batchPack) {
+			defer wg.Done()
+			if err := r.managedUploadPack(files, sizes, pack.files, pack.ec); err != nil {
+				failed := make([]string, 0, len(pack.files))
+				for _, i := range pack.files {
+					failed = append(failed, files[i].SiaPath)
+				}
+				b.managedPackFailed(failed, err)
+				r.log.Printf("Pack of batch upload %v with %v files failed: %v", b.id, len(pack.files), err)
+				return
+			}
+			b.managedPackUploaded(len(pack.files))
+		}(pack)
+	}
+	wg.Wait()
+	b.managedFinish()
+}
+
+// UploadBatch uploads a batch of small files by packing them into shared
+// chunks. All files are checked before the upload starts, the upload itself
+// happens in the background. The returned ID identifies the batch in the list
+// of UploadBatches.
+func (r *Renter) UploadBatch(files []modules.RenterBatchFile) (string, error) {
+	if err := r.tg.Add(); err != nil {
+		return "", err
+	}
+	defer r.tg.Done()
+	if len(files) == 0 {
+		return "", errBatchEmpty
+	} else if len(files) > maxBatchFiles {
+		return "", errBatchTooLarge
+	}
+
+	// Every file is uploaded with the erasure code of its directory and needs
+	// to fit into a single chunk of it.
+	ecs := make([]modules.ErasureCoder, len(files))
+	for i, bf := range files {
+		if err := validateSiapath(bf.SiaPath); err != nil {
+			return "", errors.AddContext(err, bf.SiaPath)
+		}
+		ec, err := r.managedUploadErasureCode(bf.SiaPath)
+		if err != nil {
+			return "", errors.AddContext(err, "unable to get the erasure code of "+bf.SiaPath)
+		}
+		ecs[i] = ec
+	}
+	sizes := make([]uint64, len(files))
+	siaPaths := make(map[string]struct{})
+	id := r.mu.RLock()
+	for i, bf := range files {
+		if _, exists := siaPaths[bf.SiaPath]; exists {
+			r.mu.RUnlock(id)
+			return "", errors.AddContext(errBatchDuplicatePath, bf.SiaPath)
+		}
+		siaPaths[bf.SiaPath] = struct{}{}
+		if _, exists := r.files[bf.SiaPath]; exists {
+			r.mu.RUnlock(id)
+			return "", errors.AddContext(ErrPathOverload, bf.SiaPath)
+		}
+		if err := validateSource(bf.Source); err != nil {
+			r.mu.RUnlock(id)
+			return "", errors.AddContext(err, bf.Source)
+		}
+		fileInfo, err := os.Stat(bf.Source)
+		if err != nil {
+			r.mu.RUnlock(id)
+			return "", err
+		}
+		if uint64(fileInfo.Size()) > batchChunkSize(ecs[i]) {
+			r.mu.RUnlock(id)
+			return "", errors.AddContext(errBatchFileTooLarge, bf.Source)
+		}
+		sizes[i] = uint64(fileInfo.Size())
+	}
+	r.mu.RUnlock(id)
+
+	packs := packBatch(sizes, ecs)
+	b := newUploadBatch(uint64(len(files)), uint64(len(packs)))
+	id = r.mu.Lock()
+	r.uploadBatches = append(r.uploadBatches, b)
+	r.mu.Unlock(id)
+	go r.threadedUploadBatch(b, files, sizes, packs)
+	return b.id, nil
+}
+
+// UploadBatches returns the progress of the batch uploads since the renter was
+// started.
+func (r *Renter) UploadBatches() []modules.RenterUploadBatch {
+	id := r.mu.RLock()
+	batches := append([]*uploadBatch(nil), r.uploadBatches...)
+	r.mu.RUnlock(id)
+	statuses := make([]modules.RenterUploadBatch, 0, len(batches))
+	for _, b := range batches {
+		statuses = append(statuses, b.managedStatus())
+	}
+	return statuses
+}
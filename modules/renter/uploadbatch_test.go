@@ -0,0 +1,91 @@
+package renter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+)
+
+// TestPackBatchFiles checks that the files of a batch are packed into as few
+// chunks as the sizes allow without exceeding the chunk size.
+func TestPackBatchFiles(t *testing.T) {
+	sizes := []uint64{30, 70, 50, 50, 100, 0}
+	packs := packBatchFiles(sizes, 100)
+	if len(packs) != 3 {
+		t.Fatal("expected 3 packs but got", packs)
+	}
+	seen := make(map[int]bool)
+	for _, pack := range packs {
+		var size uint64
+		for _, i := range pack {
+			if seen[i] {
+				t.Fatal("file was packed twice", i)
+			}
+			seen[i] = true
+			size += sizes[i]
+		}
+		if size > 100 {
+			t.Fatal("pack exceeds the chunk size", pack, size)
+		}
+	}
+	if len(seen) != len(sizes) {
+		t.Fatal("not every file was packed", packs)
+	}
+
+	// Files are placed into the first pack they fit into, largest first.
+	if packs[0][0] != 4 || len(packs[0]) != 2 || packs[0][1] != 5 {
+		t.Fatal("the largest file and the empty file should share the first pack", packs)
+	}
+	if len(packBatchFiles(nil, 100)) != 0 {
+		t.Fatal("no files shouldn't result in any packs")
+	}
+}
+
+// TestPackBatch checks that only files with the same erasure code share a
+// pack and that every pack gets the erasure code of its files.
+func TestPackBatch(t *testing.T) {
+	rsc1, _ := siafile.NewRSCode(1, 1)
+	rsc2, _ := siafile.NewRSCode(2, 1)
+	sizes := []uint64{10, 10, 10, 10}
+	ecs := []modules.ErasureCoder{rsc1, rsc2, rsc1, rsc2}
+	packs := packBatch(sizes, ecs)
+	if len(packs) != 2 {
+		t.Fatal("expected 2 packs but got", packs)
+	}
+	for _, pack := range packs {
+		if len(pack.files) != 2 {
+			t.Fatal("files with the same erasure code should share a pack", pack.files)
+		}
+		for _, i := range pack.files {
+			if ecs[i] != pack.ec {
+				t.Fatal("file was packed with a different erasure code", i)
+			}
+		}
+	}
+	if len(packBatch(nil, nil)) != 0 {
+		t.Fatal("no files shouldn't result in any packs")
+	}
+}
+
+// TestUploadBatchStatus checks that the packs of a batch report their outcome
+// independently of each other.
+func TestUploadBatchStatus(t *testing.T) {
+	b := newUploadBatch(5, 3)
+	b.managedPackUploaded(2)
+	b.managedPackFailed([]string{"foo", "bar"}, errBatchPackFailed)
+	b.managedPackFailed([]string{"baz"}, errBatchInsufficientWorkers)
+	b.managedFinish()
+
+	status := b.managedStatus()
+	if !status.Finished || status.PacksUploaded != 1 || status.FilesUploaded != 2 {
+		t.Fatal("unexpected status", status)
+	}
+	if len(status.Failed) != 3 {
+		t.Fatal("the files of both failed packs should be listed", status.Failed)
+	}
+	if !strings.Contains(status.Error, errBatchPackFailed.Error()) || !strings.Contains(status.Error, errBatchInsufficientWorkers.Error()) {
+		t.Fatal("the error should contain the errors of both packs", status.Error)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"sync"
 
+	"github.com/HyperspaceApp/Hyperspace/crypto"
 	"github.com/HyperspaceApp/Hyperspace/modules"
 	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
 
@@ -25,6 +26,12 @@ type unfinishedUploadChunk struct {
 	id         uploadChunkID
 	renterFile *siafile.SiaFile
 
+	// erasureCode is the erasure code of the file at the time the chunk was
+	// built. The redundancy of the file might change while the chunk is being
+	// repaired, but the chunk needs to stick to the number of pieces it was
+	// built with.
+	erasureCode modules.ErasureCoder
+
 	// Information about the chunk, namely where it exists within the file.
 	//
 	// TODO / NOTE: As we change the file mapper, we're probably going to have
@@ -35,9 +42,36 @@ type unfinishedUploadChunk struct {
 	length         uint64
 	memoryNeeded   uint64 // memory needed in bytes
 	memoryReleased uint64 // memory that has been returned of memoryNeeded
+	minimumHosts   int    // number of distinct hosts that should store pieces of the chunk.
 	minimumPieces  int    // number of pieces required to recover the file.
 	offset         int64  // Offset of the chunk within the file.
 	piecesNeeded   int    // number of pieces to achieve a 100% complete upload
+	spareCopies    int    // number of pieces that are copied to spare hosts.
+
+	// degraded is set if the file was uploaded with fewer contracts than its
+	// erasure code needs. Its chunks are repaired first.
+	degraded bool
+
+	// redundancyGroup is the redundancy group of the file at the time the
+	// chunk was built, the hosts of the chunk were picked for that group.
+	redundancyGroup string
+
+	// rebuild is set if the chunk is repaired as part of a user-requested
+	// rebuild of the file. Such chunks are repaired regardless of the repair
+	// threshold.
+	rebuild *fileRebuild
+
+	// key is the key the pieces of the chunk were encrypted with. The pieces
+	// are only added to the file if the key of the chunk didn't change in the
+	// meantime.
+	key crypto.CipherKey
+
+	// pack is set if the chunk is shared by the files of a pack. The pieces
+	// uploaded for the chunk are added to all of them. packDone is set if the
+	// chunk is uploaded as part of a batch upload, it receives whether the
+	// pack is recoverable once the workers are done with the chunk.
+	pack     []packMember
+	packDone chan bool
 
 	// The logical data is the data that is presented to the user when the user
 	// requests the chunk. The physical data is all of the pieces that get
@@ -76,6 +110,7 @@ type unfinishedUploadChunk struct {
 	pieceUsage       []bool              // 'true' if a piece is either uploaded, or a worker is attempting to upload that piece.
 	piecesCompleted  int                 // number of pieces that have been fully uploaded.
 	piecesRegistered int                 // number of pieces that are being uploaded, but aren't finished yet (may fail).
+	hostsUsed        int                 // number of distinct hosts that store pieces of the chunk.
 	released         bool                // whether this chunk has been released from the active chunks set.
 	unusedHosts      map[string]struct{} // hosts that aren't yet storing any pieces or performing any work.
 	workersRemaining int                 // number of inactive workers still able to upload a piece.
@@ -107,13 +142,21 @@ func (r *Renter) managedDistributeChunkToWorkers(uc *unfinishedUploadChunk) {
 	// renter is holding a lock, so we need to build a list of workers while
 	// under lock and then launch work jobs after that.
 	id := r.mu.RLock()
-	uc.workersRemaining += len(r.workerPool)
 	workers := make([]*worker, 0, len(r.workerPool))
 	for _, worker := range r.workerPool {
 		workers = append(workers, worker)
 	}
 	r.mu.RUnlock(id)
-	for _, worker := range workers {
+
+	// The piece placement strategy may hold back some of the workers. Those
+	// are put on standby right away and only join if a preferred worker
+	// fails.
+	preferred, standby := r.managedPlaceChunk(uc, workers)
+	uc.mu.Lock()
+	uc.workersRemaining += len(preferred) + len(standby)
+	uc.workersStandby = append(uc.workersStandby, standby...)
+	uc.mu.Unlock()
+	for _, worker := range preferred {
 		worker.managedQueueUploadChunk(uc)
 	}
 }
@@ -122,33 +165,61 @@ func (r *Renter) managedDistributeChunkToWorkers(uc *unfinishedUploadChunk) {
 // download to the renter's downloader, and then using the data that gets
 // returned.
 func (r *Renter) managedDownloadLogicalChunkData(chunk *unfinishedUploadChunk) error {
+	data, err := r.managedDownloadChunkData(chunk.renterFile, chunk.index, chunk.length, chunk.offset)
+	if err != nil {
+		return err
+	}
+	chunk.logicalChunkData = data
+
+	// Charge the repair budget of the file with the estimated cost of the
+	// download.
+	if pieces, err := chunk.renterFile.Pieces(chunk.index); err == nil {
+		sectorPrices := r.managedSectorDownloadPrices(chunk.renterFile)
+		cost, err := cheapestChunkDownloadCost(pieces, sectorPrices, chunk.renterFile.ErasureCode().MinPieces())
+		if err == nil {
+			r.managedChargeRepairBudget(chunk.renterFile.SiaPath(), cost)
+		}
+	}
+	return nil
+}
+
+// managedDownloadChunkData will download the logical data of the chunk at
+// index from the hosts and return it as a set of pieces that can be passed to
+// the erasure coder.
+func (r *Renter) managedDownloadChunkData(file *siafile.SiaFile, index, length uint64, offset int64) ([][]byte, error) {
 	//  Determine what the download length should be. Normally it is just the
 	//  chunk size, but if this is the last chunk we need to download less
 	//  because the file is not that large.
 	//
 	// TODO: There is a disparity in the way that the upload and download code
 	// handle the last chunk, which may not be full sized.
-	downloadLength := chunk.length
-	if chunk.index == chunk.renterFile.NumChunks()-1 && chunk.renterFile.Size()%chunk.length != 0 {
-		downloadLength = chunk.renterFile.Size() % chunk.length
+	//
+	// The chunk of a packed file contains the data of the whole pack.
+	downloadLength := length
+	pack, packed := file.Packed()
+	if packed {
+		downloadLength = pack.Length
+	} else if index == file.NumChunks()-1 && file.Size()%length != 0 {
+		downloadLength = file.Size() % length
 	}
 
 	// Create the download.
-	buf := NewDownloadDestinationBuffer(chunk.length, chunk.renterFile.PieceSize())
+	buf := NewDownloadDestinationBuffer(length, file.PieceSize())
 	d, err := r.managedNewDownload(downloadParams{
 		destination:     buf,
 		destinationType: "buffer",
-		file:            chunk.renterFile,
+		file:            file,
 
 		latencyTarget: 200e3, // No need to rush latency on repair downloads.
 		length:        downloadLength,
 		needsMemory:   false, // We already requested memory, the download memory fits inside of that.
-		offset:        uint64(chunk.offset),
+		offset:        uint64(offset),
 		overdrive:     0, // No need to rush the latency on repair downloads.
 		priority:      0, // Repair downloads are completely de-prioritized.
+		wholePack:     packed,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Set the in-memory buffer to nil just to be safe in case of a memory
@@ -161,14 +232,13 @@ func (r *Renter) managedDownloadLogicalChunkData(chunk *unfinishedUploadChunk) e
 	select {
 	case <-d.completeChan:
 	case <-r.tg.StopChan():
-		return errors.New("repair download interrupted by stop call")
+		return nil, errors.New("repair download interrupted by stop call")
 	}
 	if d.Err() != nil {
 		buf.buf = nil
-		return d.Err()
+		return nil, d.Err()
 	}
-	chunk.logicalChunkData = [][]byte(buf.buf)
-	return nil
+	return [][]byte(buf.buf), nil
 }
 
 // managedFetchAndRepairChunk will fetch the logical data for a chunk, create
@@ -176,7 +246,7 @@ func (r *Renter) managedDownloadLogicalChunkData(chunk *unfinishedUploadChunk) e
 func (r *Renter) managedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 	// Calculate the amount of memory needed for erasure coding. This will need
 	// to be released if there's an error before erasure coding is complete.
-	erasureCodingMemory := chunk.renterFile.PieceSize() * uint64(chunk.renterFile.ErasureCode().MinPieces())
+	erasureCodingMemory := chunk.renterFile.PieceSize() * uint64(chunk.erasureCode.MinPieces())
 
 	// Calculate the amount of memory to release due to already completed
 	// pieces. This memory gets released during encryption, but needs to be
@@ -212,6 +282,20 @@ func (r *Renter) managedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 		r.log.Debugln("Fetching logical data of a chunk failed:", err)
 		return
 	}
+	if chunk.pack == nil {
+		r.managedHashChunk(chunk)
+	}
+
+	// If the file was uploaded with deduplication and an identical chunk is
+	// already stored, the chunk references its pieces instead. Nothing needs
+	// to be uploaded, so the chunk is not distributed to workers.
+	if r.managedShareChunk(chunk) {
+		chunk.logicalChunkData = nil
+		chunk.workersRemaining = 0
+		r.memoryManager.Return(erasureCodingMemory + pieceCompletedMemory)
+		chunk.memoryReleased += erasureCodingMemory + pieceCompletedMemory
+		return
+	}
 
 	// Create the physical pieces for the data. Immediately release the logical
 	// data.
@@ -221,7 +305,7 @@ func (r *Renter) managedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 	// fact to reduce the total memory required to create the physical data.
 	// That will also change the amount of memory we need to allocate, and the
 	// number of times we need to return memory.
-	chunk.physicalChunkData, err = chunk.renterFile.ErasureCode().EncodeShards(chunk.logicalChunkData, chunk.renterFile.PieceSize())
+	chunk.physicalChunkData, err = chunk.erasureCode.EncodeShards(chunk.logicalChunkData, chunk.renterFile.PieceSize())
 	chunk.logicalChunkData = nil
 	r.memoryManager.Return(erasureCodingMemory)
 	chunk.memoryReleased += erasureCodingMemory
@@ -245,13 +329,15 @@ func (r *Renter) managedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 		return
 	}
 	// Loop through the pieces and encrypt any that are needed, while dropping
-	// any pieces that are not needed.
+	// any pieces that are not needed. The key is fetched once, since the chunk
+	// might be rekeyed concurrently.
+	chunk.key = chunk.renterFile.ChunkKey(chunk.index)
 	for i := 0; i < len(chunk.pieceUsage); i++ {
 		if chunk.pieceUsage[i] {
 			chunk.physicalChunkData[i] = nil
 		} else {
 			// Encrypt the piece.
-			key := chunk.renterFile.MasterKey().Derive(chunk.index, uint64(i))
+			key := chunk.key.Derive(chunk.index, uint64(i))
 			chunk.physicalChunkData[i] = key.EncryptBytes(chunk.physicalChunkData[i])
 		}
 	}
@@ -276,6 +362,18 @@ func (r *Renter) managedFetchLogicalChunkData(chunk *unfinishedUploadChunk) erro
 	minMissingPiecesToDownload := int(numParityPieces * RemoteRepairDownloadThreshold)
 	download := chunk.piecesCompleted+minMissingPiecesToDownload < chunk.piecesNeeded
 
+	// A chunk whose pieces are concentrated on too few hosts needs to be
+	// redistributed even if enough of its pieces are available. The same
+	// goes for chunks whose pieces are copied to spare hosts.
+	if chunk.hostsUsed < chunk.minimumHosts || chunk.spareCopies > 0 || chunk.rebuild != nil {
+		download = true
+	}
+
+	// The data of a pack is spread across the local copies of its files.
+	if chunk.pack != nil {
+		return r.managedFetchPackData(chunk, download)
+	}
+
 	// Download the chunk if it's not on disk.
 	if chunk.renterFile.LocalPath() == "" && download {
 		return r.managedDownloadLogicalChunkData(chunk)
@@ -356,16 +454,22 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 	if chunkComplete && !released {
 		uc.released = true
 	}
+	fullyRepaired := uc.piecesCompleted >= uc.piecesNeeded && uc.hostsUsed >= uc.minimumHosts
+	recoverable := uc.piecesCompleted >= uc.minimumPieces && uc.hostsUsed >= uc.minimumHosts
+	// Once all pieces are uploaded, the standby workers have to drop the
+	// chunk so that it can be released.
+	releaseStandby := uc.piecesCompleted >= uc.piecesNeeded && len(uc.workersStandby) > 0
 	uc.memoryReleased += uint64(memoryReleased)
 	totalMemoryReleased := uc.memoryReleased
 	uc.mu.Unlock()
 
 	// If there are pieces available, add the standby workers to collect them.
 	// Standby workers are only added to the chunk when piecesAvailable is equal
-	// to zero, meaning this code will only trigger if the number of pieces
-	// available increases from zero. That can only happen if a worker
-	// experiences an error during upload.
-	if piecesAvailable > 0 {
+	// to zero, or when the piece placement strategy holds them back, meaning
+	// this code will mostly trigger if a worker experiences an error during
+	// upload. Once the chunk is complete, the standby workers are notified so
+	// that they drop the chunk.
+	if piecesAvailable > 0 || releaseStandby {
 		uc.managedNotifyStandbyWorkers()
 	}
 	// If required, return the memory to the renter.
@@ -377,6 +481,13 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 		r.uploadHeap.mu.Lock()
 		delete(r.uploadHeap.activeChunks, uc.id)
 		r.uploadHeap.mu.Unlock()
+		r.repairLimiter.Return()
+		if uc.rebuild != nil {
+			uc.rebuild.managedChunkFinished(fullyRepaired)
+		}
+		if uc.packDone != nil {
+			uc.packDone <- recoverable
+		}
 	}
 	// Sanity check - all memory should be released if the chunk is complete.
 	if chunkComplete && totalMemoryReleased != uc.memoryNeeded {
@@ -19,6 +19,7 @@ package renter
 import (
 	"container/heap"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -52,7 +53,26 @@ type uploadChunkHeap []*unfinishedUploadChunk
 // Implementation of heap.Interface for uploadChunkHeap.
 func (uch uploadChunkHeap) Len() int { return len(uch) }
 func (uch uploadChunkHeap) Less(i, j int) bool {
-	return float64(uch[i].piecesCompleted)/float64(uch[i].piecesNeeded) < float64(uch[j].piecesCompleted)/float64(uch[j].piecesNeeded)
+	// Chunks that aren't spread across enough hosts are the most vulnerable
+	// to a single host going offline.
+	spreadI := uch[i].hostsUsed >= uch[i].minimumHosts
+	spreadJ := uch[j].hostsUsed >= uch[j].minimumHosts
+	if spreadI != spreadJ {
+		return !spreadI
+	}
+	// Chunks of degraded uploads are topped up as soon as more contracts are
+	// available.
+	if uch[i].degraded != uch[j].degraded {
+		return uch[i].degraded
+	}
+	completedI := float64(uch[i].piecesCompleted) / float64(uch[i].piecesNeeded)
+	completedJ := float64(uch[j].piecesCompleted) / float64(uch[j].piecesNeeded)
+	if completedI != completedJ {
+		return completedI < completedJ
+	}
+	// Chunks of the same file are uploaded in order, which allows the content
+	// hash of the file to be computed while it is uploaded.
+	return uch[i].id.fileUID == uch[j].id.fileUID && uch[i].index < uch[j].index
 }
 func (uch uploadChunkHeap) Swap(i, j int)       { uch[i], uch[j] = uch[j], uch[i] }
 func (uch *uploadChunkHeap) Push(x interface{}) { *uch = append(*uch, x.(*unfinishedUploadChunk)) }
@@ -66,13 +86,10 @@ func (uch *uploadChunkHeap) Pop() interface{} {
 
 // managedPush will add a chunk to the upload heap.
 func (uh *uploadHeap) managedPush(uuc *unfinishedUploadChunk) {
-	// Create the unique chunk id.
-	ucid := uploadChunkID{
-		fileUID: uuc.renterFile.UID(),
-		index:   uuc.index,
-	}
+	// The unique chunk id. Chunks of files in the same pack share an id.
+	ucid := uuc.id
 	// Sanity check: fileUID should not be the empty value.
-	if uuc.renterFile.UID() == "" {
+	if ucid.fileUID == "" {
 		panic("empty string for file UID")
 	}
 
@@ -87,6 +104,18 @@ func (uh *uploadHeap) managedPush(uuc *unfinishedUploadChunk) {
 	uh.mu.Unlock()
 }
 
+// managedTryActivate adds a chunk to the set of active chunks without pushing
+// it onto the heap. False is returned if the chunk is already active.
+func (uh *uploadHeap) managedTryActivate(ucid uploadChunkID) bool {
+	uh.mu.Lock()
+	defer uh.mu.Unlock()
+	if _, exists := uh.activeChunks[ucid]; exists {
+		return false
+	}
+	uh.activeChunks[ucid] = struct{}{}
+	return true
+}
+
 // managedPop will pull a chunk off of the upload heap and return it.
 func (uh *uploadHeap) managedPop() (uc *unfinishedUploadChunk) {
 	uh.mu.Lock()
@@ -97,20 +126,33 @@ func (uh *uploadHeap) managedPop() (uc *unfinishedUploadChunk) {
 	return uc
 }
 
+// minUploadWorkers returns the number of workers required to repair the file
+// together with the number of distinct hosts each chunk of the file should be
+// spread across.
+func (r *Renter) minUploadWorkers(f *siafile.SiaFile) (minWorkers, minHosts int) {
+	minWorkers = f.ErasureCode().MinPieces()
+	// Chunks also need to be spread across a minimum number of hosts. A chunk
+	// can't be spread across more hosts than it has pieces though.
+	minHosts = int(r.hostContractor.Allowance().MinHostsPerChunk)
+	if minHosts > f.ErasureCode().NumPieces() {
+		minHosts = f.ErasureCode().NumPieces()
+	}
+	if minHosts > minWorkers {
+		minWorkers = minHosts
+	}
+	return minWorkers, minHosts
+}
+
 // buildUnfinishedChunks will pull all of the unfinished chunks out of a file.
+// The new pieces of files in a redundancy group are placed according to the
+// snapshot of the groups.
 //
 // TODO / NOTE: This code can be substantially simplified once the files store
 // the HostPubKey instead of the FileContractID, and can be simplified even
 // further once the layout is per-chunk instead of per-filecontract.
-func (r *Renter) buildUnfinishedChunks(f *siafile.SiaFile, hosts map[string]struct{}) []*unfinishedUploadChunk {
+func (r *Renter) buildUnfinishedChunks(f *siafile.SiaFile, hosts map[string]struct{}, groups *redundancyGroups) []*unfinishedUploadChunk {
 	// If we don't have enough workers for the file, don't repair it right now.
-	minWorkers := 0
-	for i := uint64(0); i < f.NumChunks(); i++ {
-		minPieces := f.ErasureCode().MinPieces()
-		if minPieces > minWorkers {
-			minWorkers = minPieces
-		}
-	}
+	minWorkers, minHosts := r.minUploadWorkers(f)
 	if len(r.workerPool) < minWorkers {
 		return nil
 	}
@@ -121,15 +163,18 @@ func (r *Renter) buildUnfinishedChunks(f *siafile.SiaFile, hosts map[string]stru
 	// number of chunks. Changes will be made due to things like sparse files,
 	// and the fact that chunks are going to be different sizes.
 	chunkCount := f.NumChunks()
+	ec := f.ErasureCode()
+	degraded := f.Degraded()
+	// Keep the new pieces of files in a redundancy group off the hosts of
+	// other groups.
+	hosts = groups.uploadHosts(f, hosts)
 	newUnfinishedChunks := make([]*unfinishedUploadChunk, chunkCount)
 	for i := uint64(0); i < chunkCount; i++ {
 		newUnfinishedChunks[i] = &unfinishedUploadChunk{
-			renterFile: f,
+			renterFile:  f,
+			erasureCode: ec,
 
-			id: uploadChunkID{
-				fileUID: f.UID(),
-				index:   i,
-			},
+			id: chunkUploadID(f, i),
 
 			index:  i,
 			length: f.ChunkSize(),
@@ -144,19 +189,27 @@ func (r *Renter) buildUnfinishedChunks(f *siafile.SiaFile, hosts map[string]stru
 			// TODO: Currently we request memory for all of the pieces as well
 			// as the minimum pieces, but we perhaps don't need to request all
 			// of that.
-			memoryNeeded:  f.PieceSize()*uint64(f.ErasureCode().NumPieces()+f.ErasureCode().MinPieces()) + uint64(f.ErasureCode().NumPieces())*f.MasterKey().Type().Overhead(),
-			minimumPieces: f.ErasureCode().MinPieces(),
-			piecesNeeded:  f.ErasureCode().NumPieces(),
+			memoryNeeded:  f.PieceSize()*uint64(ec.NumPieces()+ec.MinPieces()) + uint64(ec.NumPieces())*f.MasterKey().Type().Overhead(),
+			minimumHosts:  minHosts,
+			minimumPieces: ec.MinPieces(),
+			piecesNeeded:  ec.NumPieces(),
+			degraded:      degraded,
 
-			physicalChunkData: make([][]byte, f.ErasureCode().NumPieces()),
+			redundancyGroup: f.RedundancyGroup(),
 
-			pieceUsage:  make([]bool, f.ErasureCode().NumPieces()),
+			physicalChunkData: make([][]byte, ec.NumPieces()),
+
+			pieceUsage:  make([]bool, ec.NumPieces()),
 			unusedHosts: make(map[string]struct{}),
 		}
 		// Every chunk can have a different set of unused hosts.
 		for host := range hosts {
 			newUnfinishedChunks[i].unusedHosts[host] = struct{}{}
 		}
+		// The chunk of a packed file is shared by all files of the pack.
+		if pack, packed := f.Packed(); packed {
+			newUnfinishedChunks[i].pack = r.packs[pack.ID]
+		}
 	}
 
 	// Build a map of host public keys.
@@ -165,6 +218,12 @@ func (r *Renter) buildUnfinishedChunks(f *siafile.SiaFile, hosts map[string]stru
 		pks[string(pk.Key)] = pk
 	}
 
+	// copies counts the distinct hosts storing each piece of every chunk.
+	copies := make([][]int, chunkCount)
+	for i := range copies {
+		copies[i] = make([]int, ec.NumPieces())
+	}
+
 	// Iterate through the pieces of all chunks of the file and mark which
 	// hosts are already in use for a particular chunk. As you delete hosts
 	// from the 'unusedHosts' map, also increment the 'piecesCompleted' value.
@@ -175,6 +234,11 @@ func (r *Renter) buildUnfinishedChunks(f *siafile.SiaFile, hosts map[string]stru
 			return nil
 		}
 		for pieceIndex, pieceSet := range pieces {
+			// The redundancy of the file might have been reduced since the
+			// erasure code was fetched.
+			if pieceIndex >= ec.NumPieces() {
+				break
+			}
 			for _, piece := range pieceSet {
 				// Get the contract for the piece.
 				pk, exists := pks[string(piece.HostPubKey.Key)]
@@ -195,6 +259,10 @@ func (r *Renter) buildUnfinishedChunks(f *siafile.SiaFile, hosts map[string]stru
 				// Mark the chunk set based on the pieces in this contract.
 				_, exists = newUnfinishedChunks[chunkIndex].unusedHosts[pk.String()]
 				redundantPiece := newUnfinishedChunks[chunkIndex].pieceUsage[pieceIndex]
+				if exists {
+					newUnfinishedChunks[chunkIndex].hostsUsed++
+					copies[chunkIndex][pieceIndex]++
+				}
 				if exists && !redundantPiece {
 					newUnfinishedChunks[chunkIndex].pieceUsage[pieceIndex] = true
 					newUnfinishedChunks[chunkIndex].piecesCompleted++
@@ -213,11 +281,44 @@ func (r *Renter) buildUnfinishedChunks(f *siafile.SiaFile, hosts map[string]stru
 		}
 	}
 
+	// Chunks that have all of their pieces but are spread across fewer hosts
+	// than the number of pieces plus the spare hosts of the file upload
+	// additional copies of the pieces with the fewest copies. Marking these
+	// pieces as unused makes the workers upload them to hosts that don't store
+	// any piece of the chunk yet.
+	if spareHosts := int(f.SpareHosts()); spareHosts > 0 {
+		targetHosts := ec.NumPieces() + spareHosts
+		for i, uc := range newUnfinishedChunks {
+			if uc.piecesCompleted < uc.piecesNeeded || uc.hostsUsed >= targetHosts {
+				continue
+			}
+			missing := targetHosts - uc.hostsUsed
+			if missing > len(uc.unusedHosts) {
+				missing = len(uc.unusedHosts)
+			}
+			if missing > ec.NumPieces() {
+				missing = ec.NumPieces()
+			}
+			pieceIndices := make([]int, ec.NumPieces())
+			for j := range pieceIndices {
+				pieceIndices[j] = j
+			}
+			sort.SliceStable(pieceIndices, func(a, b int) bool {
+				return copies[i][pieceIndices[a]] < copies[i][pieceIndices[b]]
+			})
+			for _, pieceIndex := range pieceIndices[:missing] {
+				uc.pieceUsage[pieceIndex] = false
+				uc.piecesCompleted--
+			}
+			uc.spareCopies = missing
+		}
+	}
+
 	// Iterate through the set of newUnfinishedChunks and remove any that are
 	// completed.
 	incompleteChunks := newUnfinishedChunks[:0]
 	for i := 0; i < len(newUnfinishedChunks); i++ {
-		if newUnfinishedChunks[i].piecesCompleted < newUnfinishedChunks[i].piecesNeeded {
+		if newUnfinishedChunks[i].piecesCompleted < newUnfinishedChunks[i].piecesNeeded || newUnfinishedChunks[i].hostsUsed < newUnfinishedChunks[i].minimumHosts {
 			incompleteChunks = append(incompleteChunks, newUnfinishedChunks[i])
 		}
 	}
@@ -235,6 +336,7 @@ func (r *Renter) managedBuildChunkHeap(hosts map[string]struct{}) {
 	for _, file := range r.files {
 		files = append(files, file)
 	}
+	groups := r.redundancyGroups()
 	r.mu.RUnlock(lockID)
 
 	// Save host keys in map. We can't do that under the same lock since we
@@ -256,25 +358,44 @@ func (r *Renter) managedBuildChunkHeap(hosts map[string]struct{}) {
 			continue
 		}
 		goodForRenew[string(pk.Key)] = ok && cu.GoodForRenew
-		offline[string(pk.Key)] = r.hostContractor.IsOffline(pk)
+		offline[string(pk.Key)] = r.managedIsOffline(pk)
 	}
 
+	// Every run of the repair loop is a chance to notice pieces that were
+	// lost or added since the previous run.
+	r.managedRecordFileHistories(files, offline, goodForRenew)
+
 	// Loop through the whole set of files and get a list of chunks to add to
 	// the heap.
 	for _, file := range files {
+		// Resume the rekey of files that are being rekeyed in case it was
+		// interrupted. The files are still repaired in the meantime, the
+		// pieces of every chunk are encrypted with the key of the chunk at
+		// the time it is repaired.
+		if file.Rekeying() {
+			r.managedResumeRekey(file)
+		}
+		// Files that used up their repair budget aren't repaired until the
+		// budget is reset or raised.
+		if r.managedRepairBudgetExhausted(file.SiaPath()) {
+			continue
+		}
 		id := r.mu.Lock()
-		unfinishedUploadChunks := r.buildUnfinishedChunks(file, hosts)
+		unfinishedUploadChunks := r.buildUnfinishedChunks(file, hosts, groups)
 		r.mu.Unlock(id)
 		for i := 0; i < len(unfinishedUploadChunks); i++ {
 			r.uploadHeap.managedPush(unfinishedUploadChunks[i])
 		}
 	}
+	r.managedUpdateRedundancyGroupAlerts(groups)
 	for _, file := range files {
 		// Check if local file is missing and redundancy is less than 1
 		// log warning to renter log
 		if _, err := os.Stat(file.LocalPath()); os.IsNotExist(err) && file.Redundancy(offline, goodForRenew) < 1 {
 			r.log.Println("File not found on disk and possibly unrecoverable:", file.LocalPath())
 		}
+		r.managedUpdateDegraded(file, offline, goodForRenew)
+		r.managedRemoveStagedStream(file, offline, goodForRenew)
 	}
 }
 
@@ -282,12 +403,20 @@ func (r *Renter) managedBuildChunkHeap(hosts map[string]struct{}) {
 // it for upload. Preparation includes blocking until enough memory is
 // available, fetching the logical data for the chunk (either from the disk or
 // from the network), erasure coding the logical data into the physical data,
-// and then finally passing the work onto the workers.
+// and then finally passing the work onto the workers. The number of chunks that
+// are prepared at the same time is limited by the repairLimiter.
 func (r *Renter) managedPrepareNextChunk(uuc *unfinishedUploadChunk, hosts map[string]struct{}) {
+	// Wait for a repair slot before requesting memory, so that a chunk which
+	// is waiting for a slot doesn't hold on to memory that other chunks could
+	// use. The slot is returned when the chunk is released.
+	if !r.repairLimiter.Request() {
+		return
+	}
 	// Grab the next chunk, loop until we have enough memory, update the amount
 	// of memory available, and then spin up a thread to asynchronously handle
 	// the rest of the chunk tasks.
 	if !r.memoryManager.Request(uuc.memoryNeeded, memoryPriorityLow) {
+		r.repairLimiter.Return()
 		return
 	}
 	// Fetch the chunk in a separate goroutine, as it can take a long time and
@@ -309,6 +438,10 @@ func (r *Renter) managedRefreshHostsAndWorkers() map[string]struct{} {
 	for _, contract := range currentContracts {
 		hosts[contract.HostPublicKey.String()] = struct{}{}
 	}
+	// The data of hosts that are being migrated is moved to other hosts.
+	for host := range r.managedMigratingHosts() {
+		delete(hosts, host)
+	}
 	// Refresh the worker pool as well.
 	r.managedUpdateWorkerPool()
 	return hosts
@@ -330,6 +463,18 @@ func (r *Renter) threadedUploadLoop() {
 			return
 		}
 
+		// Idle while maintenance is paused. The heap is left as it is, so the
+		// repair continues with the same chunks once ResumeMaintenance sends a
+		// signal on newUploads.
+		if r.hostContractor.MaintenancePaused() {
+			select {
+			case <-r.uploadHeap.newUploads:
+			case <-r.tg.StopChan():
+				return
+			}
+			continue
+		}
+
 		// Refresh the worker pool and get the set of hosts that are currently
 		// useful for uploading.
 		hosts := r.managedRefreshHostsAndWorkers()
@@ -359,8 +504,9 @@ func (r *Renter) threadedUploadLoop() {
 			default:
 			}
 
-			// Break to the outer loop if not online.
-			if !r.g.Online() {
+			// Break to the outer loop if not online or if maintenance was
+			// paused.
+			if !r.g.Online() || r.hostContractor.MaintenancePaused() {
 				break
 			}
 
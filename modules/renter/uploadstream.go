@@ -0,0 +1,184 @@
+package renter
+
+// uploadstream.go receives the data of a file through the API and uploads the
+// file once all of its data arrived. The repair loop reads the chunks of a file
+// from its source on disk, so the data is staged in the renter directory and
+// the staged file becomes the source of the upload. The data is appended to a
+// .part file until the stream is complete, which lets an interrupted stream
+// resume at the number of bytes that were received. Once the file reached its
+// full redundancy, the staged data is deleted and the file is repaired from
+// the hosts like any file whose local copy is gone.
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+	"github.com/HyperspaceApp/errors"
+)
+
+const (
+	// uploadStreamPartExtension is the extension of the staged data of a
+	// stream that isn't complete yet.
+	uploadStreamPartExtension = ".part"
+)
+
+var (
+	// errUploadStreamOffset is returned if data is streamed from an offset
+	// other than the number of bytes received so far.
+	errUploadStreamOffset = errors.New("offset doesn't match the data received so far")
+
+	// errUploadStreamActive is returned if data is streamed to a path while
+	// another stream to the same path is still receiving data.
+	errUploadStreamActive = errors.New("another stream to the same path is in progress")
+)
+
+// uploadStreamPath returns the path of the file that the data streamed to
+// siaPath is staged in once the stream is complete.
+func (r *Renter) uploadStreamPath(siaPath string) string {
+	return filepath.Join(r.persistDir, uploadStreamDir, crypto.HashObject(siaPath).String())
+}
+
+// UploadStreamOffset returns the number of bytes that were received for the
+// incomplete stream to siaPath.
+func (r *Renter) UploadStreamOffset(siaPath string) (uint64, error) {
+	if err := validateSiapath(siaPath); err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(r.uploadStreamPath(siaPath) + uploadStreamPartExtension)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return uint64(info.Size()), nil
+}
+
+// UploadStream appends data to the file that is streamed to up.SiaPath. offset
+// must equal the number of bytes received so far. Once complete is true, the
+// received data is uploaded with the parameters of up, up.Source is replaced
+// by the staged data. The number of bytes received is returned, also if the
+// data was only received partially.
+func (r *Renter) UploadStream(up modules.FileUploadParams, offset uint64, data io.Reader, complete bool) (uint64, error) {
+	if err := validateSiapath(up.SiaPath); err != nil {
+		return 0, err
+	}
+	if err := r.tg.Add(); err != nil {
+		return 0, err
+	}
+	defer r.tg.Done()
+
+	// Only one stream can write to the staged data of a path. The data of
+	// streams to other paths is received at the same time.
+	path := r.uploadStreamPath(up.SiaPath)
+	if !r.managedStartUploadStream(path) {
+		return 0, errUploadStreamActive
+	}
+	defer r.managedFinishUploadStream(path)
+
+	// Check for a nickname conflict before any data is received, so that a
+	// stream to an existing file is rejected right away and the staged data
+	// never replaces the source of an existing file.
+	lockID := r.mu.RLock()
+	_, exists := r.files[up.SiaPath]
+	r.mu.RUnlock(lockID)
+	if exists && !up.Overwrite {
+		return 0, ErrPathOverload
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return 0, errors.AddContext(err, "unable to create the staging directory")
+	}
+	f, err := os.OpenFile(path+uploadStreamPartExtension, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return 0, errors.AddContext(err, "unable to open the staged data")
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return 0, errors.Compose(err, f.Close())
+	}
+	received := uint64(info.Size())
+	if offset != received {
+		return received, errors.Compose(errors.AddContext(errUploadStreamOffset, fmt.Sprintf("%v bytes were received", received)), f.Close())
+	}
+
+	// Keep the data that arrived if the stream is interrupted, so that it
+	// can be resumed.
+	n, err := io.Copy(f, data)
+	received += uint64(n)
+	if err := errors.Compose(err, f.Sync(), f.Close()); err != nil {
+		return received, errors.AddContext(err, "stream was interrupted")
+	}
+	if !complete {
+		return received, nil
+	}
+
+	// Move the staged data out of the way of new streams to the same path.
+	// If the upload fails, the data is moved back so the upload can be
+	// retried without sending the data again.
+	if err := os.Rename(path+uploadStreamPartExtension, path); err != nil {
+		return received, errors.AddContext(err, "unable to move the staged data")
+	}
+	up.Source = path
+	if err := r.Upload(up); err != nil {
+		return received, errors.Compose(err, os.Rename(path, path+uploadStreamPartExtension))
+	}
+	return received, nil
+}
+
+// managedStartUploadStream marks the staged data at path as being written and
+// returns false if another stream is writing it already.
+func (r *Renter) managedStartUploadStream(path string) bool {
+	r.uploadStreamMu.Lock()
+	defer r.uploadStreamMu.Unlock()
+	if _, active := r.activeUploadStreams[path]; active {
+		return false
+	}
+	r.activeUploadStreams[path] = struct{}{}
+	return true
+}
+
+// managedFinishUploadStream marks the staged data at path as no longer being
+// written.
+func (r *Renter) managedFinishUploadStream(path string) {
+	r.uploadStreamMu.Lock()
+	delete(r.activeUploadStreams, path)
+	r.uploadStreamMu.Unlock()
+}
+
+// managedRemoveStagedStream deletes the staged data of a streamed upload once
+// the file reached its full redundancy and its content hash is no longer
+// computed from the staged data. Without it, every streamed file would be kept on the disk
+// of the renter for good.
+func (r *Renter) managedRemoveStagedStream(f *siafile.SiaFile, offline, goodForRenew map[string]bool) {
+	path := f.LocalPath()
+	if path == "" || filepath.Dir(path) != filepath.Join(r.persistDir, uploadStreamDir) {
+		return
+	}
+	if r.managedHashingContent(f) {
+		return
+	}
+	ec := f.ErasureCode()
+	if f.Redundancy(offline, goodForRenew) < float64(ec.NumPieces())/float64(ec.MinPieces()) {
+		return
+	}
+	// A stream to the same path replaces the staged data once it completes,
+	// leave it alone until the stream is done.
+	if !r.managedStartUploadStream(path) {
+		return
+	}
+	defer r.managedFinishUploadStream(path)
+	if err := f.SetLocalPath(""); err != nil {
+		r.log.Println("WARN: unable to clear the staged source of", f.SiaPath(), err)
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		r.log.Println("WARN: unable to delete the staged data of", f.SiaPath(), err)
+		return
+	}
+	r.log.Debugln("Deleted the staged data of", f.SiaPath(), "at full redundancy")
+}
@@ -0,0 +1,156 @@
+package renter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+	"github.com/HyperspaceApp/errors"
+	"github.com/HyperspaceApp/fastrand"
+)
+
+// TestUploadStream checks that a streamed upload can be resumed at the data
+// that was received and that the staged data becomes the source of the file.
+func TestUploadStream(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	data := fastrand.Bytes(1000)
+	up := modules.FileUploadParams{SiaPath: "stream/test"}
+	if offset, err := rt.renter.UploadStreamOffset(up.SiaPath); err != nil || offset != 0 {
+		t.Fatal("expected an empty stream", offset, err)
+	}
+	received, err := rt.renter.UploadStream(up, 0, bytes.NewReader(data[:400]), false)
+	if err != nil || received != 400 {
+		t.Fatal("unexpected result of the first part", received, err)
+	}
+	if offset, err := rt.renter.UploadStreamOffset(up.SiaPath); err != nil || offset != 400 {
+		t.Fatal("expected an offset of 400", offset, err)
+	}
+
+	// Data that doesn't continue the stream is rejected.
+	received, err = rt.renter.UploadStream(up, 0, bytes.NewReader(data), true)
+	if !errors.Contains(err, errUploadStreamOffset) || received != 400 {
+		t.Fatal("expected errUploadStreamOffset", received, err)
+	}
+
+	// Complete the stream.
+	received, err = rt.renter.UploadStream(up, 400, bytes.NewReader(data[400:]), true)
+	if err != nil || received != uint64(len(data)) {
+		t.Fatal("unexpected result of the last part", received, err)
+	}
+	if offset, err := rt.renter.UploadStreamOffset(up.SiaPath); err != nil || offset != 0 {
+		t.Fatal("expected the stream to be complete", offset, err)
+	}
+	lockID := rt.renter.mu.RLock()
+	f, exists := rt.renter.files[up.SiaPath]
+	rt.renter.mu.RUnlock(lockID)
+	if !exists {
+		t.Fatal("streamed file wasn't uploaded")
+	}
+	staged, err := ioutil.ReadFile(f.LocalPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(staged, data) {
+		t.Fatal("staged data doesn't match the streamed data")
+	}
+
+	// Another stream to the same file is rejected.
+	if _, err := rt.renter.UploadStream(up, 0, bytes.NewReader(data), true); err != ErrPathOverload {
+		t.Fatal("expected ErrPathOverload, got", err)
+	}
+}
+
+// TestUploadStreamActive checks that only one stream at a time can send data
+// to a path, while streams to other paths aren't blocked.
+func TestUploadStreamActive(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	up := modules.FileUploadParams{SiaPath: "stream/test"}
+	path := rt.renter.uploadStreamPath(up.SiaPath)
+	if !rt.renter.managedStartUploadStream(path) {
+		t.Fatal("unable to start the stream")
+	}
+	if _, err := rt.renter.UploadStream(up, 0, bytes.NewReader(fastrand.Bytes(100)), false); err != errUploadStreamActive {
+		t.Fatal("expected errUploadStreamActive, got", err)
+	}
+	other := modules.FileUploadParams{SiaPath: "stream/other"}
+	if _, err := rt.renter.UploadStream(other, 0, bytes.NewReader(fastrand.Bytes(100)), false); err != nil {
+		t.Fatal("stream to another path was blocked", err)
+	}
+	rt.renter.managedFinishUploadStream(path)
+	if _, err := rt.renter.UploadStream(up, 0, bytes.NewReader(fastrand.Bytes(100)), false); err != nil {
+		t.Fatal("stream wasn't released", err)
+	}
+}
+
+// TestRemoveStagedStream checks that the staged data of a streamed file is
+// only deleted once the file reached its full redundancy.
+func TestRemoveStagedStream(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	up := modules.FileUploadParams{SiaPath: "stream/test"}
+	data := fastrand.Bytes(1000)
+	if _, err := rt.renter.UploadStream(up, 0, bytes.NewReader(data), true); err != nil {
+		t.Fatal(err)
+	}
+	lockID := rt.renter.mu.RLock()
+	f := rt.renter.files[up.SiaPath]
+	rt.renter.mu.RUnlock(lockID)
+	staged := f.LocalPath()
+	for _, chunk := range testContentChunks(f, data) {
+		rt.renter.managedHashChunk(chunk)
+	}
+
+	// Without any pieces the staged data is kept.
+	offline := make(map[string]bool)
+	goodForRenew := make(map[string]bool)
+	rt.renter.managedRemoveStagedStream(f, offline, goodForRenew)
+	if _, err := os.Stat(staged); err != nil {
+		t.Fatal("staged data was deleted before the file reached full redundancy", err)
+	}
+
+	// Upload every piece to a different host.
+	for i := 0; i < f.ErasureCode().NumPieces(); i++ {
+		pk := types.SiaPublicKey{Key: []byte{byte(i)}}
+		offline[string(pk.Key)] = false
+		goodForRenew[string(pk.Key)] = true
+		for chunk := uint64(0); chunk < f.NumChunks(); chunk++ {
+			if err := f.AddPiece(pk, chunk, uint64(i), crypto.Hash{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	rt.renter.managedRemoveStagedStream(f, offline, goodForRenew)
+	if _, err := os.Stat(staged); !os.IsNotExist(err) {
+		t.Fatal("staged data wasn't deleted at full redundancy", err)
+	}
+	if f.LocalPath() != "" {
+		t.Fatal("file still uses the deleted staged data as its source")
+	}
+}
@@ -0,0 +1,51 @@
+package renter
+
+import (
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
+)
+
+// VerifyFiles checks the files of all siafiles on disk for corruption. If
+// compact is true, corrupted files are written again from the loaded siafiles
+// and files with unused space are compacted.
+func (r *Renter) VerifyFiles(compact bool) (modules.RenterFilesVerification, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterFilesVerification{}, err
+	}
+	defer r.tg.Done()
+
+	// Get all the files holding the readlock. The files are verified without
+	// it since verifying reads them from disk.
+	id := r.mu.RLock()
+	files := make([]*siafile.SiaFile, 0, len(r.files))
+	for _, file := range r.files {
+		files = append(files, file)
+	}
+	r.mu.RUnlock(id)
+
+	var report modules.RenterFilesVerification
+	for _, f := range files {
+		v, err := f.Verify(compact)
+		if err != nil {
+			v = modules.SiaFileVerification{
+				SiaPath:  f.SiaPath(),
+				Problems: []string{"unable to verify the file: " + err.Error()},
+			}
+		}
+		report.FilesChecked++
+		if len(v.Problems) > 0 {
+			report.FilesCorrupted++
+		}
+		if v.Repaired {
+			report.FilesRepaired++
+		}
+		report.BytesReclaimed += v.BytesReclaimed
+		if len(v.Problems) > 0 || v.BytesReclaimed > 0 {
+			report.Files = append(report.Files, v)
+		}
+	}
+	if report.FilesCorrupted > 0 {
+		r.log.Printf("WARN: %v of %v siafiles are corrupted, %v were repaired", report.FilesCorrupted, report.FilesChecked, report.FilesRepaired)
+	}
+	return report, nil
+}
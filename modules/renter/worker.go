@@ -33,18 +33,32 @@ type worker struct {
 	ownedDownloadConsecutiveFailures int       // How many failures in a row?
 	ownedDownloadRecentFailure       time.Time // How recent was the last failure?
 
+	// Transfers that haven't been reported to the hostdb yet. Like the
+	// download failures, they are only accessed by the master thread.
+	ownedDownloadBandwidth bandwidthBatch
+	ownedUploadBandwidth   bandwidthBatch
+
+	// Latency estimates of the host, which set the timeouts of the RPCs. They
+	// have their own mutex so that they can be reported while the worker is
+	// busy.
+	downloadLatency rpcLatency
+	uploadLatency   rpcLatency
+
 	// Download variables related to queuing work. They have a separate mutex to
 	// minimize lock contention.
 	downloadChan       chan struct{}              // Notifications of new work. Takes priority over uploads.
 	downloadChunks     []*unfinishedDownloadChunk // Yet unprocessed work items.
+	downloadQueued     []time.Time                // When the unprocessed work items were queued.
 	downloadMu         sync.Mutex
 	downloadTerminated bool // Has downloading been terminated for this worker?
 
 	// Upload variables.
 	unprocessedChunks         []*unfinishedUploadChunk // Yet unprocessed work items.
+	unprocessedQueued         []time.Time              // When the unprocessed work items were queued.
 	uploadChan                chan struct{}            // Notifications of new work.
 	uploadConsecutiveFailures int                      // How many times in a row uploading has failed.
 	uploadRecentFailure       time.Time                // How recent was the last failure?
+	uploadRecentFailureErr    error                    // Why did the last upload fail?
 	uploadTerminated          bool                     // Have we stopped uploading?
 
 	// Utilities.
@@ -62,6 +76,11 @@ func (r *Renter) managedUpdateWorkerPool() {
 	contractSlice := r.hostContractor.Contracts()
 	contractMap := make(map[types.FileContractID]modules.RenterContract)
 	for i := 0; i < len(contractSlice); i++ {
+		// Hosts with a simulated failure don't get a worker, just like hosts
+		// without a contract.
+		if r.managedHostFailureSimulated(contractSlice[i].HostPublicKey) {
+			continue
+		}
 		contractMap[contractSlice[i].ID] = contractSlice[i]
 	}
 
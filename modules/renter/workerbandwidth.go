@@ -0,0 +1,52 @@
+package renter
+
+// workerbandwidth.go reports the throughput of the workers' transfers to the
+// hostdb. Every transfer only times the data sent to or received from the
+// host, not the negotiation of the revision. The workers combine
+// bandwidthBatchSize transfers into a single measurement, which evens out
+// single slow pieces and keeps the workers from locking the hostdb for every
+// piece. Transfers that haven't filled a batch when the worker is removed are
+// dropped.
+
+import (
+	"time"
+)
+
+// bandwidthBatch collects the transfers of a worker in one direction.
+type bandwidthBatch struct {
+	bytes        uint64
+	transferTime time.Duration
+	transfers    int
+}
+
+// add records a transfer. Once the batch is full, it is reset and the
+// throughput of its transfers is returned in bytes per second.
+func (bb *bandwidthBatch) add(bytes uint64, transferTime time.Duration) (throughput float64, full bool) {
+	bb.bytes += bytes
+	bb.transferTime += transferTime
+	bb.transfers++
+	if bb.transfers < bandwidthBatchSize {
+		return 0, false
+	}
+	if bb.transferTime > 0 {
+		throughput = float64(bb.bytes) / bb.transferTime.Seconds()
+	}
+	*bb = bandwidthBatch{}
+	return throughput, true
+}
+
+// ownedRecordDownloadBandwidth records a download of the worker and reports
+// the throughput of the batch to the hostdb once it is full.
+func (w *worker) ownedRecordDownloadBandwidth(bytes uint64, transferTime time.Duration) {
+	if throughput, full := w.ownedDownloadBandwidth.add(bytes, transferTime); full {
+		w.renter.hostDB.UpdateBandwidth(w.contract.HostPublicKey, 0, throughput)
+	}
+}
+
+// ownedRecordUploadBandwidth records an upload of the worker and reports the
+// throughput of the batch to the hostdb once it is full.
+func (w *worker) ownedRecordUploadBandwidth(bytes uint64, transferTime time.Duration) {
+	if throughput, full := w.ownedUploadBandwidth.add(bytes, transferTime); full {
+		w.renter.hostDB.UpdateBandwidth(w.contract.HostPublicKey, throughput, 0)
+	}
+}
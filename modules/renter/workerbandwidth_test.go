@@ -0,0 +1,37 @@
+package renter
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBandwidthBatch checks that the transfers of a batch are combined into a
+// single measurement once the batch is full.
+func TestBandwidthBatch(t *testing.T) {
+	var bb bandwidthBatch
+	for i := 1; i < bandwidthBatchSize; i++ {
+		if _, full := bb.add(1000, time.Second); full {
+			t.Fatal("batch is full after", i, "transfers")
+		}
+	}
+	// The last transfer is slower, the measurement is the throughput of all
+	// transfers together.
+	throughput, full := bb.add(1000, 3*time.Second)
+	if !full {
+		t.Fatal("batch should be full")
+	}
+	if expected := float64(1000*bandwidthBatchSize) / float64(bandwidthBatchSize+2); throughput != expected {
+		t.Fatalf("expected a throughput of %v, got %v", expected, throughput)
+	}
+	if bb != (bandwidthBatch{}) {
+		t.Fatal("batch wasn't reset", bb)
+	}
+
+	// A batch without a transfer time isn't a measurement.
+	for i := 0; i < bandwidthBatchSize-1; i++ {
+		bb.add(1000, 0)
+	}
+	if throughput, full := bb.add(1000, 0); !full || throughput != 0 {
+		t.Fatal("expected a full batch without a throughput", throughput, full)
+	}
+}
@@ -26,26 +26,32 @@ func (w *worker) managedDownload(udc *unfinishedDownloadChunk) {
 	defer udc.managedRemoveWorker()
 
 	// Fetch the sector. If fetching the sector fails, the worker needs to be
-	// unregistered with the chunk.
-	d, err := w.renter.hostContractor.Downloader(w.contract.HostPublicKey, w.renter.tg.StopChan())
+	// unregistered with the chunk. The download is abandoned if it takes much
+	// longer than the recent downloads from the host.
+	deadline, finishRPC := w.managedStartRPC(&w.downloadLatency)
+	d, err := w.renter.hostContractor.DownloaderForContract(w.contract.ID, w.renter.tg.StopChan())
 	if err != nil {
+		finishRPC(false)
 		w.renter.log.Debugln("worker failed to create downloader:", err)
 		udc.managedUnregisterWorker(w)
 		return
 	}
 	defer d.Close()
-	pieceData, err := d.Sector(udc.staticChunkMap[string(w.contract.HostPublicKey.Key)].root)
+	pieceData, transferTime, err := d.SectorWithDeadline(udc.staticChunkMap[string(w.contract.HostPublicKey.Key)].root, deadline)
+	finishRPC(err == nil)
 	if err != nil {
 		w.renter.log.Debugln("worker failed to download sector:", err)
 		udc.managedUnregisterWorker(w)
 		return
 	}
+	w.ownedRecordDownloadBandwidth(uint64(len(pieceData)), transferTime)
 	// TODO: Instead of adding the whole sector after the download completes,
 	// have the 'd.Sector' call add to this value ongoing as the sector comes
 	// in. Perhaps even include the data from creating the downloader and other
 	// data sent to and received from the host (like signatures) that aren't
 	// actually payload data.
 	atomic.AddUint64(&udc.download.atomicTotalDataTransferred, udc.staticPieceSize)
+	w.renter.throughputHistory.addDownloaded(udc.staticPieceSize)
 
 	// Decrypt the piece. This might introduce some overhead for downloads with
 	// a large overdrive. It shouldn't be a bottleneck though since bandwidth
@@ -88,6 +94,7 @@ func (w *worker) managedKillDownloading() {
 		removedChunks = append(removedChunks, w.downloadChunks[i])
 	}
 	w.downloadChunks = w.downloadChunks[:0]
+	w.downloadQueued = w.downloadQueued[:0]
 	w.downloadTerminated = true
 	w.downloadMu.Unlock()
 	for i := 0; i < len(removedChunks); i++ {
@@ -106,6 +113,7 @@ func (w *worker) managedNextDownloadChunk() *unfinishedDownloadChunk {
 	}
 	nextChunk := w.downloadChunks[0]
 	w.downloadChunks = w.downloadChunks[1:]
+	w.downloadQueued = w.downloadQueued[1:]
 	return nextChunk
 }
 
@@ -120,6 +128,7 @@ func (w *worker) managedQueueDownloadChunk(udc *unfinishedDownloadChunk) {
 		// Accept the chunk and issue a notification to the master thread that
 		// there is a new download.
 		w.downloadChunks = append(w.downloadChunks, udc)
+		w.downloadQueued = append(w.downloadQueued, time.Now())
 		select {
 		case w.downloadChan <- struct{}{}:
 		default:
@@ -141,6 +150,9 @@ func (udc *unfinishedDownloadChunk) managedUnregisterWorker(w *worker) {
 	udc.mu.Lock()
 	udc.piecesRegistered--
 	udc.pieceUsage[udc.staticChunkMap[string(w.contract.HostPublicKey.Key)].index] = false
+	if udc.staticPreferredHosts != nil && udc.preferredWorker(w) {
+		udc.preferenceRelaxed = true
+	}
 	udc.mu.Unlock()
 }
 
@@ -170,6 +182,11 @@ func (w *worker) ownedProcessDownloadChunk(udc *unfinishedDownloadChunk) *unfini
 	pieceData, workerHasPiece := udc.staticChunkMap[string(w.contract.HostPublicKey.Key)]
 	pieceTaken := udc.pieceUsage[pieceData.index]
 	if chunkComplete || chunkFailed || w.ownedOnDownloadCooldown() || !workerHasPiece || pieceTaken {
+		// A preferred worker that can't help means that the piece has to be
+		// fetched from a more expensive host.
+		if !chunkComplete && udc.staticPreferredHosts != nil && udc.preferredWorker(w) {
+			udc.preferenceRelaxed = true
+		}
 		udc.mu.Unlock()
 		udc.managedRemoveWorker()
 		return nil
@@ -203,6 +220,17 @@ func (w *worker) ownedProcessDownloadChunk(udc *unfinishedDownloadChunk) *unfini
 	// variables that are only accessed by the master worker thread.
 	meetsExtraCriteria := true
 
+	// Cost optimized downloads only use the workers of the cheapest hosts
+	// that can recover the chunk. If all the other workers have processed the
+	// chunk already, there is no cheaper worker left to wait for.
+	if udc.staticPreferredHosts != nil && !udc.preferenceRelaxed && !udc.preferredWorker(w) {
+		if udc.unprocessedWorkers() <= 1 {
+			udc.preferenceRelaxed = true
+		} else {
+			meetsExtraCriteria = false
+		}
+	}
+
 	// TODO: There's going to need to be some method for relaxing criteria after
 	// the first wave of workers are sent off. If the first waves of workers
 	// fail, the next wave need to realize that they shouldn't immediately go on
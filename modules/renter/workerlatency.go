@@ -0,0 +1,114 @@
+package renter
+
+// workerlatency.go adapts the timeouts of the workers to the hosts they work
+// with. The fixed timeouts of the host protocol are high enough for a full
+// sector to be transferred over Tor, which lets a stalled host hold on to a
+// piece for many minutes. Instead, every worker keeps the durations of its
+// recent RPCs and abandons an RPC once it takes a multiple of the median
+// duration. A distant host that is consistently slow gets a high timeout,
+// while a nearby host that usually responds quickly is dropped soon after it
+// stalls.
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// rpcLatency tracks the durations of the most recent RPCs of a worker with its
+// host.
+type rpcLatency struct {
+	samples []time.Duration
+	next    int
+	mu      sync.Mutex
+}
+
+// managedAddSample records the duration of an RPC, replacing the oldest sample
+// once workerLatencySamples durations are known.
+func (l *rpcLatency) managedAddSample(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.samples) < workerLatencySamples {
+		l.samples = append(l.samples, d)
+		return
+	}
+	l.samples[l.next] = d
+	l.next = (l.next + 1) % workerLatencySamples
+}
+
+// managedEstimate returns the median duration of the recent RPCs and the
+// timeout of the next RPC. Until enough RPCs have completed to trust the
+// estimate, the maximum timeout is used.
+func (l *rpcLatency) managedEstimate() (rtt, timeout time.Duration) {
+	l.mu.Lock()
+	samples := append([]time.Duration(nil), l.samples...)
+	l.mu.Unlock()
+	if len(samples) == 0 {
+		return 0, maxWorkerRPCTimeout
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	rtt = samples[len(samples)/2]
+	if len(samples) < minWorkerLatencySamples {
+		return rtt, maxWorkerRPCTimeout
+	}
+	timeout = rtt * workerTimeoutMultiplier
+	if timeout < minWorkerRPCTimeout {
+		timeout = minWorkerRPCTimeout
+	} else if timeout > maxWorkerRPCTimeout {
+		timeout = maxWorkerRPCTimeout
+	}
+	return rtt, timeout
+}
+
+// managedStartRPC returns the deadline of an RPC, which is the timeout of the
+// latency estimate from now, and a function that has to be called after the
+// RPC has finished. The deadline is set on the connection of the RPC rather
+// than cancelling the session, since the sessions with a host are shared by
+// all RPCs of the worker. Successful RPCs and RPCs that timed out are recorded
+// as samples. Counting the timeouts lets the estimate grow if the host became
+// slower for good, instead of every following RPC timing out as well.
+func (w *worker) managedStartRPC(l *rpcLatency) (time.Time, func(success bool)) {
+	_, timeout := l.managedEstimate()
+	start := time.Now()
+	deadline := start.Add(timeout)
+	return deadline, func(success bool) {
+		if !success && !time.Now().Before(deadline) {
+			w.renter.log.Debugf("RPC with host %v timed out after %v", w.hostPubKey, timeout)
+			success = true
+		}
+		if success {
+			l.managedAddSample(time.Since(start))
+		}
+	}
+}
+
+// WorkerPoolStatus returns the latency estimates and the resulting timeouts
+// of the workers in the worker pool.
+func (r *Renter) WorkerPoolStatus() modules.WorkerPoolStatus {
+	id := r.mu.RLock()
+	workers := make([]*worker, 0, len(r.workerPool))
+	for _, w := range r.workerPool {
+		workers = append(workers, w)
+	}
+	r.mu.RUnlock(id)
+
+	status := modules.WorkerPoolStatus{
+		NumWorkers: len(workers),
+		Workers:    make([]modules.WorkerStatus, 0, len(workers)),
+	}
+	for _, w := range workers {
+		ws := modules.WorkerStatus{
+			ContractID:    w.contract.ID,
+			HostPublicKey: w.hostPubKey,
+		}
+		ws.DownloadRTT, ws.DownloadTimeout = w.downloadLatency.managedEstimate()
+		ws.UploadRTT, ws.UploadTimeout = w.uploadLatency.managedEstimate()
+		status.Workers = append(status.Workers, ws)
+	}
+	sort.Slice(status.Workers, func(i, j int) bool {
+		return status.Workers[i].HostPublicKey.String() < status.Workers[j].HostPublicKey.String()
+	})
+	return status
+}
@@ -0,0 +1,94 @@
+package renter
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/persist"
+)
+
+// TestRPCLatencyEstimate checks that the timeout of a worker only adapts once
+// enough RPCs have completed, and that it stays within its bounds.
+func TestRPCLatencyEstimate(t *testing.T) {
+	var l rpcLatency
+	if rtt, timeout := l.managedEstimate(); rtt != 0 || timeout != maxWorkerRPCTimeout {
+		t.Fatal("expected the maximum timeout without samples, got", rtt, timeout)
+	}
+
+	// A distant host that is consistently slow gets a timeout that is a
+	// multiple of its median latency.
+	rtt := maxWorkerRPCTimeout / (2 * workerTimeoutMultiplier)
+	for i := 0; i < minWorkerLatencySamples-1; i++ {
+		l.managedAddSample(rtt)
+	}
+	if _, timeout := l.managedEstimate(); timeout != maxWorkerRPCTimeout {
+		t.Fatal("timeout shouldn't adapt before enough samples were recorded, got", timeout)
+	}
+	l.managedAddSample(rtt)
+	if median, timeout := l.managedEstimate(); median != rtt || timeout != rtt*workerTimeoutMultiplier {
+		t.Fatal("unexpected estimate", median, timeout)
+	}
+
+	// A fast host is still given the minimum timeout, and a slow one can't
+	// exceed the maximum timeout.
+	for i := 0; i < workerLatencySamples; i++ {
+		l.managedAddSample(time.Millisecond)
+	}
+	if len(l.samples) != workerLatencySamples {
+		t.Fatal("old samples weren't replaced", len(l.samples))
+	}
+	if median, timeout := l.managedEstimate(); median != time.Millisecond || timeout != minWorkerRPCTimeout {
+		t.Fatal("expected the minimum timeout, got", median, timeout)
+	}
+	for i := 0; i < workerLatencySamples; i++ {
+		l.managedAddSample(time.Hour)
+	}
+	if _, timeout := l.managedEstimate(); timeout != maxWorkerRPCTimeout {
+		t.Fatal("expected the maximum timeout, got", timeout)
+	}
+}
+
+// TestWorkerStartRPC checks that the deadline of an RPC follows the timeout of
+// the estimate, and that timeouts are recorded as samples.
+func TestWorkerStartRPC(t *testing.T) {
+	w := &worker{renter: &Renter{log: persist.NewLogger(ioutil.Discard)}}
+
+	// Without samples the maximum timeout is used.
+	deadline, finish := w.managedStartRPC(&w.downloadLatency)
+	if time.Until(deadline) > maxWorkerRPCTimeout || time.Until(deadline) < maxWorkerRPCTimeout-time.Second {
+		t.Fatal("expected the maximum timeout, got", time.Until(deadline))
+	}
+	finish(true)
+	if len(w.downloadLatency.samples) != 1 {
+		t.Fatal("successful RPC wasn't recorded")
+	}
+
+	// Failed RPCs aren't recorded.
+	_, finish = w.managedStartRPC(&w.downloadLatency)
+	finish(false)
+	if len(w.downloadLatency.samples) != 1 {
+		t.Fatal("failed RPC was recorded")
+	}
+
+	// A fast host gets the minimum timeout, which long tests wait for.
+	w.downloadLatency.samples = nil
+	for i := 0; i < minWorkerLatencySamples; i++ {
+		w.downloadLatency.managedAddSample(time.Millisecond)
+	}
+	if _, timeout := w.downloadLatency.managedEstimate(); timeout != minWorkerRPCTimeout {
+		t.Fatal("expected the minimum timeout, got", timeout)
+	}
+	if testing.Short() {
+		t.SkipNow()
+	}
+	deadline, finish = w.managedStartRPC(&w.downloadLatency)
+	if time.Until(deadline) > minWorkerRPCTimeout {
+		t.Fatal("expected the minimum timeout, got", time.Until(deadline))
+	}
+	time.Sleep(time.Until(deadline))
+	finish(false)
+	if len(w.downloadLatency.samples) != minWorkerLatencySamples+1 {
+		t.Fatal("timed out RPC wasn't recorded")
+	}
+}
@@ -0,0 +1,86 @@
+package renter
+
+// workerqueues.go reports the job queues of the workers. Every queue keeps the
+// times at which its jobs were queued next to the jobs, under the same lock, so
+// a snapshot of a queue never mixes states from before and after a concurrent
+// change. The queues of a worker are locked one after the other and never at
+// the same time, so the download and upload queues of a worker may be taken a
+// moment apart.
+
+import (
+	"sort"
+	"time"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+)
+
+// queueStatus summarizes a queue from the times at which its jobs were
+// queued.
+func queueStatus(queued []time.Time, now time.Time) modules.WorkerQueueStatus {
+	status := modules.WorkerQueueStatus{
+		Jobs: len(queued),
+	}
+	if len(queued) == 0 {
+		return status
+	}
+	var total time.Duration
+	for _, t := range queued {
+		total += now.Sub(t)
+	}
+	// Jobs are queued at the back and taken from the front.
+	status.OldestJobAge = now.Sub(queued[0])
+	status.AverageJobAge = total / time.Duration(len(queued))
+	return status
+}
+
+// managedDetailedStatus returns a snapshot of the worker's queues and upload
+// cooldown.
+func (w *worker) managedDetailedStatus(now time.Time) modules.WorkerDetailedStatus {
+	status := modules.WorkerDetailedStatus{
+		ContractID:    w.contract.ID,
+		HostPublicKey: w.hostPubKey,
+	}
+
+	w.downloadMu.Lock()
+	status.DownloadQueue = queueStatus(w.downloadQueued, now)
+	status.DownloadQueue.Terminated = w.downloadTerminated
+	w.downloadMu.Unlock()
+
+	w.mu.Lock()
+	status.UploadQueue = queueStatus(w.unprocessedQueued, now)
+	status.UploadQueue.Terminated = w.uploadTerminated
+	status.UploadOnCooldown = w.onUploadCooldown()
+	status.UploadConsecutiveFailures = w.uploadConsecutiveFailures
+	if status.UploadOnCooldown {
+		status.UploadCooldownUntil = w.uploadCooldownUntil()
+	}
+	if w.uploadRecentFailureErr != nil {
+		status.UploadRecentError = w.uploadRecentFailureErr.Error()
+	}
+	w.mu.Unlock()
+	return status
+}
+
+// WorkerPoolDetailedStatus returns the job queues and upload cooldowns of the
+// workers in the worker pool.
+func (r *Renter) WorkerPoolDetailedStatus() modules.WorkerPoolDetailedStatus {
+	id := r.mu.RLock()
+	workers := make([]*worker, 0, len(r.workerPool))
+	for _, w := range r.workerPool {
+		workers = append(workers, w)
+	}
+	r.mu.RUnlock(id)
+
+	now := time.Now()
+	status := modules.WorkerPoolDetailedStatus{
+		NumWorkers: len(workers),
+		Workers:    make([]modules.WorkerDetailedStatus, 0, len(workers)),
+	}
+	for _, w := range workers {
+		status.Workers = append(status.Workers, w.managedDetailedStatus(now))
+	}
+	sort.Slice(status.Workers, func(i, j int) bool {
+		return status.Workers[i].HostPublicKey.String() < status.Workers[j].HostPublicKey.String()
+	})
+	return status
+}
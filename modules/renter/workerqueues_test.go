@@ -0,0 +1,30 @@
+package renter
+
+import (
+	"testing"
+	"time"
+)
+
+// TestQueueStatus checks the ages reported for the jobs of a worker queue.
+func TestQueueStatus(t *testing.T) {
+	now := time.Now()
+	if status := queueStatus(nil, now); status.Jobs != 0 || status.OldestJobAge != 0 || status.AverageJobAge != 0 {
+		t.Fatal("empty queue should report no jobs", status)
+	}
+
+	queued := []time.Time{
+		now.Add(-30 * time.Second),
+		now.Add(-20 * time.Second),
+		now.Add(-10 * time.Second),
+	}
+	status := queueStatus(queued, now)
+	if status.Jobs != 3 {
+		t.Fatal("expected 3 jobs, got", status.Jobs)
+	}
+	if status.OldestJobAge != 30*time.Second {
+		t.Fatal("expected the oldest job to be 30s old, got", status.OldestJobAge)
+	}
+	if status.AverageJobAge != 20*time.Second {
+		t.Fatal("expected an average age of 20s, got", status.AverageJobAge)
+	}
+}
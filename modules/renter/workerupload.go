@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/crypto"
 )
 
 // managedDropChunk will remove a worker from the responsibility of tracking a chunk.
@@ -29,6 +30,7 @@ func (w *worker) managedDropUploadChunks() {
 		chunksToDrop = append(chunksToDrop, w.unprocessedChunks[i])
 	}
 	w.unprocessedChunks = w.unprocessedChunks[:0]
+	w.unprocessedQueued = w.unprocessedQueued[:0]
 	w.mu.Unlock()
 
 	for i := 0; i < len(chunksToDrop); i++ {
@@ -60,6 +62,7 @@ func (w *worker) managedNextUploadChunk() (nextChunk *unfinishedUploadChunk, pie
 		}
 		chunk := w.unprocessedChunks[0]
 		w.unprocessedChunks = w.unprocessedChunks[1:]
+		w.unprocessedQueued = w.unprocessedQueued[1:]
 		w.mu.Unlock()
 
 		// Process the chunk and return it if valid.
@@ -86,6 +89,7 @@ func (w *worker) managedQueueUploadChunk(uc *unfinishedUploadChunk) {
 		return
 	}
 	w.unprocessedChunks = append(w.unprocessedChunks, uc)
+	w.unprocessedQueued = append(w.unprocessedQueued, time.Now())
 	w.mu.Unlock()
 
 	// Send a signal informing the work thread that there is work.
@@ -97,34 +101,57 @@ func (w *worker) managedQueueUploadChunk(uc *unfinishedUploadChunk) {
 
 // managedUpload will perform some upload work.
 func (w *worker) managedUpload(uc *unfinishedUploadChunk, pieceIndex uint64) {
-	// Open an editing connection to the host.
-	e, err := w.renter.hostContractor.Editor(w.contract.HostPublicKey, w.renter.tg.StopChan())
+	// Open an editing connection to the host. Like downloads, the upload is
+	// abandoned if it takes much longer than the recent uploads to the host.
+	// The editor revises the worker's own contract, so the workers of the
+	// parallel contracts with a host can upload at the same time.
+	deadline, finishRPC := w.managedStartRPC(&w.uploadLatency)
+	e, err := w.renter.hostContractor.EditorForContract(w.contract.ID, w.renter.tg.StopChan())
 	if err != nil {
+		finishRPC(false)
 		w.renter.log.Debugln("Worker failed to acquire an editor:", err)
-		w.managedUploadFailed(uc, pieceIndex)
+		w.managedUploadFailed(uc, pieceIndex, err)
 		return
 	}
 	defer e.Close()
 
+	// The sector is tracked until it was added to the file, so it isn't
+	// reported as orphaned while the upload is in flight.
+	untrack := w.renter.managedTrackUploadingSector(crypto.MerkleRoot(uc.physicalChunkData[pieceIndex]), w.contract.HostPublicKey)
+	defer untrack()
+
 	// Perform the upload, and update the failure stats based on the success of
-	// the upload attempt.
-	root, err := e.Upload(uc.physicalChunkData[pieceIndex])
+	// the upload attempt. The spending of the contract is compared before and
+	// after the upload to charge the repair budget of the file.
+	before, _ := w.renter.hostContractor.ContractByID(w.contract.ID)
+	root, transferTime, err := e.UploadWithDeadline(uc.physicalChunkData[pieceIndex], deadline)
+	finishRPC(err == nil)
 	if err != nil {
 		w.renter.log.Debugln("Worker failed to upload via the editor:", err)
-		w.managedUploadFailed(uc, pieceIndex)
+		w.managedUploadFailed(uc, pieceIndex, err)
 		return
 	}
+	w.ownedRecordUploadBandwidth(uint64(len(uc.physicalChunkData[pieceIndex])), transferTime)
 	w.mu.Lock()
 	w.uploadConsecutiveFailures = 0
 	w.mu.Unlock()
+	if after, ok := w.renter.hostContractor.ContractByID(w.contract.ID); ok {
+		spentBefore := before.UploadSpending.Add(before.StorageSpending)
+		spentAfter := after.UploadSpending.Add(after.StorageSpending)
+		if spentAfter.Cmp(spentBefore) > 0 {
+			w.renter.managedChargeRepairBudget(uc.renterFile.SiaPath(), spentAfter.Sub(spentBefore))
+		}
+	}
 
-	// Add piece to renterFile
-	err = uc.renterFile.AddPiece(w.contract.HostPublicKey, uc.index, pieceIndex, root)
+	// Add piece to renterFile. The file lists the host afterwards, so the
+	// placement isn't needed anymore.
+	err = uc.managedAddPiece(w.contract.HostPublicKey, pieceIndex, root)
 	if err != nil {
 		w.renter.log.Debugln("Worker failed to add new piece to SiaFile:", err)
-		w.managedUploadFailed(uc, pieceIndex)
+		w.managedUploadFailed(uc, pieceIndex, err)
 		return
 	}
+	w.renter.managedReleaseGroupPiece(uc, w.contract.HostPublicKey)
 
 	id := w.renter.mu.Lock()
 	w.renter.mu.Unlock(id)
@@ -135,21 +162,29 @@ func (w *worker) managedUpload(uc *unfinishedUploadChunk, pieceIndex uint64) {
 	releaseSize := len(uc.physicalChunkData[pieceIndex])
 	uc.piecesRegistered--
 	uc.piecesCompleted++
+	uc.hostsUsed++
 	uc.physicalChunkData[pieceIndex] = nil
 	uc.memoryReleased += uint64(releaseSize)
 	uc.mu.Unlock()
 	w.renter.memoryManager.Return(uint64(releaseSize))
+	w.renter.throughputHistory.addUploaded(uint64(releaseSize))
 	w.renter.managedCleanUpUploadChunk(uc)
 }
 
 // onUploadCooldown returns true if the worker is on cooldown from failed
 // uploads.
 func (w *worker) onUploadCooldown() bool {
+	return time.Now().Before(w.uploadCooldownUntil())
+}
+
+// uploadCooldownUntil returns the time at which the cooldown of the worker
+// from its recent upload failures ends.
+func (w *worker) uploadCooldownUntil() time.Time {
 	requiredCooldown := uploadFailureCooldown
 	for i := 0; i < w.uploadConsecutiveFailures && i < maxConsecutivePenalty; i++ {
 		requiredCooldown *= 2
 	}
-	return time.Now().Before(w.uploadRecentFailure.Add(requiredCooldown))
+	return w.uploadRecentFailure.Add(requiredCooldown)
 }
 
 // managedProcessUploadChunk will process a chunk from the worker chunk queue.
@@ -160,6 +195,9 @@ func (w *worker) managedProcessUploadChunk(uc *unfinishedUploadChunk) (nextChunk
 	w.mu.Lock()
 	onCooldown := w.onUploadCooldown()
 	w.mu.Unlock()
+	// While pieces of other redundancy groups are being uploaded to the host,
+	// it can't receive pieces of this chunk.
+	placed := w.renter.managedPlaceGroupPiece(uc, w.contract.HostPublicKey)
 
 	// Determine what sort of help this chunk needs.
 	uc.mu.Lock()
@@ -167,9 +205,12 @@ func (w *worker) managedProcessUploadChunk(uc *unfinishedUploadChunk) (nextChunk
 	chunkComplete := uc.piecesNeeded <= uc.piecesCompleted
 	needsHelp := uc.piecesNeeded > uc.piecesCompleted+uc.piecesRegistered
 	// If the chunk does not need help from this worker, release the chunk.
-	if chunkComplete || !candidateHost || !goodForUpload || onCooldown {
+	if chunkComplete || !candidateHost || !goodForUpload || onCooldown || !placed {
 		// This worker no longer needs to track this chunk.
 		uc.mu.Unlock()
+		if placed {
+			w.renter.managedReleaseGroupPiece(uc, w.contract.HostPublicKey)
+		}
 		w.managedDropChunk(uc)
 		return nil, 0
 	}
@@ -179,6 +220,7 @@ func (w *worker) managedProcessUploadChunk(uc *unfinishedUploadChunk) (nextChunk
 	if !needsHelp {
 		uc.workersStandby = append(uc.workersStandby, w)
 		uc.mu.Unlock()
+		w.renter.managedReleaseGroupPiece(uc, w.contract.HostPublicKey)
 		w.renter.managedCleanUpUploadChunk(uc)
 		return nil, 0
 	}
@@ -198,6 +240,7 @@ func (w *worker) managedProcessUploadChunk(uc *unfinishedUploadChunk) (nextChunk
 	if index == -1 {
 		build.Critical("worker was supposed to upload but couldn't find unused piece")
 		uc.mu.Unlock()
+		w.renter.managedReleaseGroupPiece(uc, w.contract.HostPublicKey)
 		w.managedDropChunk(uc)
 		return nil, 0
 	}
@@ -210,12 +253,13 @@ func (w *worker) managedProcessUploadChunk(uc *unfinishedUploadChunk) (nextChunk
 
 // managedUploadFailed is called if a worker failed to upload part of an unfinished
 // chunk.
-func (w *worker) managedUploadFailed(uc *unfinishedUploadChunk, pieceIndex uint64) {
+func (w *worker) managedUploadFailed(uc *unfinishedUploadChunk, pieceIndex uint64, err error) {
 	// Mark the failure in the worker if the gateway says we are online. It's
 	// not the worker's fault if we are offline.
 	if w.renter.g.Online() {
 		w.mu.Lock()
 		w.uploadRecentFailure = time.Now()
+		w.uploadRecentFailureErr = err
 		w.uploadConsecutiveFailures++
 		w.mu.Unlock()
 	}
@@ -225,6 +269,7 @@ func (w *worker) managedUploadFailed(uc *unfinishedUploadChunk, pieceIndex uint6
 	uc.piecesRegistered--
 	uc.pieceUsage[pieceIndex] = false
 	uc.mu.Unlock()
+	w.renter.managedReleaseGroupPiece(uc, w.contract.HostPublicKey)
 
 	// Notify the standby workers of the chunk
 	uc.managedNotifyStandbyWorkers()
@@ -42,6 +42,25 @@ type (
 		ProgressDenominator uint64
 	}
 
+	// StorageFolderMigration reports the progress of moving the sectors of a
+	// storage folder to other storage folders. A migration is paused if the
+	// targets run out of space or the storage manager shuts down, and
+	// resumed by migrating the same storage folder again.
+	StorageFolderMigration struct {
+		Active  bool     `json:"active"`
+		Paused  bool     `json:"paused"`
+		Source  uint16   `json:"source"`
+		Targets []uint16 `json:"targets"`
+
+		// SectorsTotal is the number of sectors in the source when the
+		// migration was started or resumed. SectorsMoved and SectorsFailed
+		// count the sectors since then.
+		SectorsTotal  uint64 `json:"sectorstotal"`
+		SectorsMoved  uint64 `json:"sectorsmoved"`
+		SectorsFailed uint64 `json:"sectorsfailed"`
+		Error         string `json:"error"`
+	}
+
 	// A StorageManager is responsible for managing storage folders and
 	// sectors. Sectors are the base unit of storage that gets moved between
 	// renters and hosts, and primarily is stored on the hosts.
@@ -94,6 +113,18 @@ type (
 		// necessary when clearing out an entire contract from the host.
 		RemoveSectorBatch(sectorRoots []crypto.Hash) error
 
+		// MigrateStorageFolder starts moving the sectors of the source
+		// storage folder to the target storage folders in the background, or
+		// to all other storage folders if no targets are provided. No new
+		// sectors are added to the source while the migration runs, but the
+		// sectors being moved can still be read. If a migration of the source
+		// is paused, it is resumed.
+		MigrateStorageFolder(source uint16, targets []uint16) error
+
+		// StorageFolderMigration returns the progress of the most recent
+		// storage folder migration.
+		StorageFolderMigration() StorageFolderMigration
+
 		// RemoveStorageFolder will remove a storage folder from the manager.
 		// All storage on the folder will be moved to other storage folders,
 		// meaning that no data will be lost. If the manager is unable to save
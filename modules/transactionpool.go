@@ -151,6 +151,10 @@ type (
 		// This is necessary for clean shutdown of the miner.
 		Unsubscribe(TransactionPoolSubscriber)
 
+		// ValidateTransactionSet checks whether a set of transactions would be
+		// accepted by the pool without adding or broadcasting it.
+		ValidateTransactionSet([]types.Transaction) error
+
 		// SetGetWalletKeysFuc setup the function for consensus to fetch keys from wallet
 		SetGetWalletKeysFunc(func() (map[types.UnlockHash]bool, error))
 
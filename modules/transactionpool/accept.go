@@ -95,8 +95,9 @@ func (tp *TransactionPool) checkTransactionSetComposition(ts []types.Transaction
 }
 
 // handleConflicts detects whether the conflicts in the transaction pool are
-// legal children of the new transaction pool set or not.
-func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []TransactionSetID, txnFn func([]types.Transaction) (modules.ConsensusChange, error)) error {
+// legal children of the new transaction pool set or not. If dryRun is set, the
+// pool is left unchanged.
+func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []TransactionSetID, txnFn func([]types.Transaction) (modules.ConsensusChange, error), dryRun bool) error {
 	// Create a list of all the transaction ids that compose the set of
 	// conflicts.
 	conflictMap := make(map[types.TransactionID]TransactionSetID)
@@ -136,7 +137,7 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 				conflicts = append(conflicts, conflict)
 			}
 		}
-		return tp.handleConflicts(dedupSet, conflicts, txnFn)
+		return tp.handleConflicts(dedupSet, conflicts, txnFn, dryRun)
 	}
 
 	// Merge all of the conflict sets with the input set (input set goes last
@@ -185,6 +186,9 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 	if err != nil {
 		return modules.NewConsensusConflict("provided transaction set has prereqs, but is still invalid: " + err.Error())
 	}
+	if dryRun {
+		return nil
+	}
 
 	// Remove the conflicts from the transaction pool.
 	for conflict := range supersetMap {
@@ -222,6 +226,19 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 // acceptTransactionSet verifies that a transaction set is allowed to be in the
 // transaction pool, and then adds it to the transaction pool.
 func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction, txnFn func([]types.Transaction) (modules.ConsensusChange, error)) error {
+	return tp.checkTransactionSet(ts, txnFn, false)
+}
+
+// validateTransactionSet verifies that a transaction set is allowed to be in
+// the transaction pool without adding it.
+func (tp *TransactionPool) validateTransactionSet(ts []types.Transaction, txnFn func([]types.Transaction) (modules.ConsensusChange, error)) error {
+	return tp.checkTransactionSet(ts, txnFn, true)
+}
+
+// checkTransactionSet runs all of the checks of the transaction pool on a
+// transaction set. If dryRun is not set, the set is added to the pool once it
+// passed the checks.
+func (tp *TransactionPool) checkTransactionSet(ts []types.Transaction, txnFn func([]types.Transaction) (modules.ConsensusChange, error), dryRun bool) error {
 	if len(ts) == 0 {
 		return errEmptySet
 	}
@@ -275,12 +292,15 @@ func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction, txnFn fu
 		}
 	}
 	if len(conflicts) > 0 {
-		return tp.handleConflicts(ts, conflicts, txnFn)
+		return tp.handleConflicts(ts, conflicts, txnFn, dryRun)
 	}
 	cc, err := txnFn(ts)
 	if err != nil {
 		return modules.NewConsensusConflict("provided transaction set is standalone and invalid: " + err.Error())
 	}
+	if dryRun {
+		return nil
+	}
 
 	// Add the transaction set to the pool.
 	setID := TransactionSetID(crypto.HashObject(ts))
@@ -339,6 +359,28 @@ func (tp *TransactionPool) AcceptTransactionSet(ts []types.Transaction) error {
 	})
 }
 
+// ValidateTransactionSet checks whether a transaction set would be accepted by
+// the transaction pool. The checks are the same as in AcceptTransactionSet,
+// but the set is neither added to the pool nor broadcast.
+func (tp *TransactionPool) ValidateTransactionSet(ts []types.Transaction) error {
+	if err := tp.tg.Add(); err != nil {
+		return err
+	}
+	defer tp.tg.Done()
+
+	cs, ok := tp.consensusSet.(interface {
+		LockedTryTransactionSet(fn func(func(txns []types.Transaction) (modules.ConsensusChange, error)) error) error
+	})
+	if !ok {
+		return errors.New("consensus set does not support LockedTryTransactionSet method")
+	}
+	return cs.LockedTryTransactionSet(func(txnFn func(txns []types.Transaction) (modules.ConsensusChange, error)) error {
+		tp.mu.Lock()
+		defer tp.mu.Unlock()
+		return tp.validateTransactionSet(ts, txnFn)
+	})
+}
+
 // relayTransactionSet is an RPC that accepts a transaction set from a peer. If
 // the accept is successful, the transaction will be relayed to the gateway's
 // other peers.
@@ -120,6 +120,65 @@ func TestConflictingTransactionSets(t *testing.T) {
 	}
 }
 
+// TestValidateTransactionSet checks that validating a transaction set reports
+// the same errors as accepting it, without adding the set to the pool.
+func TestValidateTransactionSet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt.Close()
+
+	// Create two transaction sets that spend the same output.
+	fund := types.NewCurrency64(30e6)
+	txnBuilder, err := tpt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = txnBuilder.FundSiacoins(fund)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnSet, err := txnBuilder.Sign(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnSetDoubleSpend := make([]types.Transaction, len(txnSet))
+	copy(txnSetDoubleSpend, txnSet)
+	txnIndex := len(txnSet) - 1
+	txnSet[txnIndex].MinerFees = append(txnSet[txnIndex].MinerFees, fund)
+	txnSetDoubleSpend[txnIndex].SiacoinOutputs = append(txnSetDoubleSpend[txnIndex].SiacoinOutputs, types.SiacoinOutput{Value: fund})
+
+	// Both sets are valid on their own, and validating them doesn't touch the
+	// pool.
+	if err := tpt.tpool.ValidateTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	if err := tpt.tpool.ValidateTransactionSet(txnSetDoubleSpend); err != nil {
+		t.Fatal(err)
+	}
+	if len(tpt.tpool.transactionSets) != 0 {
+		t.Fatal("validating a transaction set shouldn't add it to the pool")
+	}
+
+	// Once the first set is in the pool, the double spend is invalid.
+	if err := tpt.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	if err := tpt.tpool.ValidateTransactionSet(txnSetDoubleSpend); err == nil {
+		t.Fatal("double spend should not have passed validation")
+	}
+	if len(tpt.tpool.transactionSets) != 1 {
+		t.Fatal("validating a transaction set shouldn't change the pool")
+	}
+	if err := tpt.tpool.ValidateTransactionSet(nil); err != errEmptySet {
+		t.Fatal("expected errEmptySet but got", err)
+	}
+}
+
 // TestCheckMinerFees probes the checkMinerFees method of the
 // transaction pool.
 func TestCheckMinerFees(t *testing.T) {
@@ -80,4 +80,13 @@ var (
 		Dev:      20 * time.Second,
 		Testing:  3 * time.Second,
 	}).(time.Duration)
+
+	// restoredSetsBroadcastInterval is how often the transaction pool checks
+	// whether the gateway is connected to peers after startup, so that the
+	// transaction sets restored from disk can be rebroadcast.
+	restoredSetsBroadcastInterval = build.Select(build.Var{
+		Standard: 5 * time.Second,
+		Dev:      time.Second,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
 )
@@ -33,6 +33,10 @@ var (
 	// bucketRecentConsensusChange holds the most recent consensus change seen
 	// by the transaction pool.
 	bucketRecentConsensusChange = []byte("RecentConsensusChange")
+
+	// bucketUnconfirmedSets holds the transaction sets that were in the pool
+	// when it was last synced to disk, so they can be restored on startup.
+	bucketUnconfirmedSets = []byte("UnconfirmedSets")
 )
 
 // Explicitly named fields in the database.
@@ -77,6 +81,15 @@ type (
 		RecentMedians   []types.Currency
 		RecentMedianFee types.Currency
 	}
+
+	// unconfirmedSetPersist is a transaction set of the pool as it gets
+	// stored in the database. Height is the lowest height at which one of the
+	// transactions of the set was first seen, it is used to keep pruning old
+	// transactions after a restart.
+	unconfirmedSetPersist struct {
+		Height       types.BlockHeight
+		Transactions []types.Transaction
+	}
 )
 
 // deleteTransaction deletes a transaction from the list of confirmed
@@ -131,6 +144,21 @@ func (tp *TransactionPool) getRecentConsensusChange(tx *bolt.Tx) (cc modules.Con
 	return cc, nil
 }
 
+// getUnconfirmedSets returns the transaction sets that were stored by
+// putUnconfirmedSets.
+func (tp *TransactionPool) getUnconfirmedSets(tx *bolt.Tx) ([]unconfirmedSetPersist, error) {
+	var sets []unconfirmedSetPersist
+	err := tx.Bucket(bucketUnconfirmedSets).ForEach(func(_, v []byte) error {
+		var set unconfirmedSetPersist
+		if err := encoding.Unmarshal(v, &set); err != nil {
+			return err
+		}
+		sets = append(sets, set)
+		return nil
+	})
+	return sets, err
+}
+
 // putBlockHeight updates the transaction pool's block height.
 func (tp *TransactionPool) putBlockHeight(tx *bolt.Tx, height types.BlockHeight) error {
 	tp.blockHeight = height
@@ -158,6 +186,33 @@ func (tp *TransactionPool) putRecentConsensusChange(tx *bolt.Tx, cc modules.Cons
 	return tx.Bucket(bucketRecentConsensusChange).Put(fieldRecentConsensusChange, cc[:])
 }
 
+// putUnconfirmedSets replaces the stored transaction sets with the sets that
+// are currently in the pool.
+func (tp *TransactionPool) putUnconfirmedSets(tx *bolt.Tx) error {
+	if err := tx.DeleteBucket(bucketUnconfirmedSets); err != nil {
+		return err
+	}
+	bucket, err := tx.CreateBucket(bucketUnconfirmedSets)
+	if err != nil {
+		return err
+	}
+	for id, ts := range tp.transactionSets {
+		set := unconfirmedSetPersist{
+			Height:       tp.blockHeight,
+			Transactions: ts,
+		}
+		for _, txn := range ts {
+			if height, exists := tp.transactionHeights[txn.ID()]; exists && height < set.Height {
+				set.Height = height
+			}
+		}
+		if err := bucket.Put(id[:], encoding.Marshal(set)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // putTransaction adds a transaction to the list of confirmed transactions.
 func (tp *TransactionPool) putTransaction(tx *bolt.Tx, id types.TransactionID) error {
 	return tx.Bucket(bucketConfirmedTransactions).Put(id[:], []byte{})
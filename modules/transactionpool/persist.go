@@ -40,8 +40,13 @@ func (tp *TransactionPool) threadedRegularSync() {
 // syncDB commits the current global transaction and immediately begins a new
 // one.
 func (tp *TransactionPool) syncDB() {
+	// Store the unconfirmed transaction sets so they survive a restart.
+	err := tp.putUnconfirmedSets(tp.dbTx)
+	if err != nil {
+		tp.log.Println("ERROR: failed to store the unconfirmed transaction sets:", err)
+	}
 	// Commit the existing tx.
-	err := tp.dbTx.Commit()
+	err = tp.dbTx.Commit()
 	if err != nil {
 		tp.log.Severe("ERROR: failed to apply database update:", err)
 		tp.dbTx.Rollback()
@@ -113,7 +118,11 @@ func (tp *TransactionPool) initPersist() error {
 	}
 	tp.tg.AfterStop(func() {
 		tp.mu.Lock()
-		err := tp.dbTx.Commit()
+		err := tp.putUnconfirmedSets(tp.dbTx)
+		if err != nil {
+			tp.log.Println("Unable to store the unconfirmed transaction sets during shutdown:", err)
+		}
+		err = tp.dbTx.Commit()
 		tp.mu.Unlock()
 		if err != nil {
 			tp.log.Println("Unable to close transaction properly during shutdown:", err)
@@ -130,6 +139,7 @@ func (tp *TransactionPool) initPersist() error {
 		bucketRecentConsensusChange,
 		bucketConfirmedTransactions,
 		bucketFeeMedian,
+		bucketUnconfirmedSets,
 	}
 	for _, bucket := range buckets {
 		_, err := tp.dbTx.CreateBucketIfNotExists(bucket)
@@ -209,6 +219,82 @@ func (tp *TransactionPool) initPersist() error {
 	return nil
 }
 
+// managedRestoreUnconfirmedSets re-adds the transaction sets that were in the
+// pool when the node shut down. It needs to be called after the pool caught up
+// with the consensus set, so that transactions which were confirmed while the
+// node was offline are recognized and dropped. Sets which became invalid or
+// are older than maxTxnAge are dropped as well. The restored sets are
+// rebroadcast once the gateway is connected to peers.
+func (tp *TransactionPool) managedRestoreUnconfirmedSets() error {
+	cs, ok := tp.consensusSet.(interface {
+		LockedTryTransactionSet(fn func(func(txns []types.Transaction) (modules.ConsensusChange, error)) error) error
+	})
+	if !ok {
+		return errors.New("consensus set does not support LockedTryTransactionSet method")
+	}
+
+	tp.mu.Lock()
+	sets, err := tp.getUnconfirmedSets(tp.dbTx)
+	tp.mu.Unlock()
+	if err != nil {
+		return errors.AddContext(err, "unable to load the unconfirmed transaction sets")
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+
+	var restored [][]types.Transaction
+	err = cs.LockedTryTransactionSet(func(txnFn func(txns []types.Transaction) (modules.ConsensusChange, error)) error {
+		tp.mu.Lock()
+		defer tp.mu.Unlock()
+		for _, set := range sets {
+			if tp.blockHeight > set.Height && tp.blockHeight-set.Height > maxTxnAge {
+				continue
+			}
+			if err := tp.acceptTransactionSet(set.Transactions, txnFn); err != nil {
+				tp.log.Debugln("Dropping a restored transaction set:", err)
+				continue
+			}
+			for _, txn := range set.Transactions {
+				if height, exists := tp.transactionHeights[txn.ID()]; exists && set.Height < height {
+					tp.transactionHeights[txn.ID()] = set.Height
+				}
+			}
+			restored = append(restored, set.Transactions)
+		}
+		tp.updateSubscribersTransactions()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	tp.log.Printf("Restored %v of %v unconfirmed transaction sets", len(restored), len(sets))
+	if len(restored) > 0 {
+		go tp.threadedBroadcastRestoredSets(restored)
+	}
+	return nil
+}
+
+// threadedBroadcastRestoredSets waits until the gateway is connected to peers
+// and then broadcasts the transaction sets that were restored on startup.
+func (tp *TransactionPool) threadedBroadcastRestoredSets(sets [][]types.Transaction) {
+	if err := tp.tg.Add(); err != nil {
+		return
+	}
+	defer tp.tg.Done()
+
+	for len(tp.gateway.Peers()) == 0 {
+		select {
+		case <-tp.tg.StopChan():
+			return
+		case <-time.After(restoredSetsBroadcastInterval):
+		}
+	}
+	for _, set := range sets {
+		tp.gateway.Rebroadcast("RelayTransactionSet", set, tp.gateway.Peers())
+	}
+}
+
 // TransactionConfirmed returns true if the transaction has been seen on the
 // blockchain. Note, however, that the block containing the transaction may
 // later be invalidated by a reorg.
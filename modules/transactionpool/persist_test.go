@@ -93,3 +93,54 @@ func TestRescan(t *testing.T) {
 		t.Fatal("expecting modules.ErrDuplicateTransactionSet, got:", err)
 	}
 }
+
+// TestRestoreUnconfirmedSets checks that unconfirmed transaction sets survive
+// a restart of the transaction pool, and that sets which got confirmed while
+// the pool was offline are dropped.
+func TestRestoreUnconfirmedSets(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt.Close()
+
+	txns, err := tpt.wallet.SendSiacoins(types.NewCurrency64(100), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Restart the tpool, the set should be restored.
+	persistDir := tpt.tpool.persistDir
+	if err := tpt.tpool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tpt.tpool, err = New(tpt.cs, tpt.gateway, persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tpt.tpool.transactionSets) != 1 {
+		t.Fatal("expected 1 restored transaction set but got", len(tpt.tpool.transactionSets))
+	}
+
+	// Mine the set while the tpool is offline. The miner still knows the set
+	// from before the first restart.
+	if err := tpt.tpool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tpt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	tpt.tpool, err = New(tpt.cs, tpt.gateway, persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tpt.tpool.transactionSets) != 0 {
+		t.Fatal("confirmed transaction set was restored")
+	}
+	if err := tpt.tpool.AcceptTransactionSet(txns); err != modules.ErrDuplicateTransactionSet {
+		t.Fatal("expecting modules.ErrDuplicateTransactionSet, got:", err)
+	}
+}
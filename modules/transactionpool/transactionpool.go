@@ -113,6 +113,12 @@ func New(cs modules.ConsensusSet, g modules.Gateway, persistDir string) (*Transa
 		tp.tg.OnStop(func() {
 			tp.gateway.UnregisterRPC(modules.RelayTransactionSetCmd)
 		})
+
+		// Re-add the transaction sets that were in the pool at shutdown.
+		err = tp.managedRestoreUnconfirmedSets()
+		if err != nil {
+			tp.log.Println("WARN: unable to restore the unconfirmed transaction sets:", err)
+		}
 	}
 	return tp, nil
 }
@@ -275,7 +281,7 @@ func (tp *TransactionPool) TransactionSet(oid crypto.Hash) []types.Transaction {
 // Broadcast broadcasts a transaction set to all of the transaction pool's
 // peers.
 func (tp *TransactionPool) Broadcast(ts []types.Transaction) {
-	go tp.gateway.Broadcast("RelayTransactionSet", ts, tp.gateway.Peers())
+	go tp.gateway.Rebroadcast("RelayTransactionSet", ts, tp.gateway.Peers())
 }
 
 // SetGetWalletKeysFunc set the getWalletKeysFunc callback
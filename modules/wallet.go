@@ -2,11 +2,13 @@ package modules
 
 import (
 	"bytes"
+	"encoding/hex"
 	"errors"
 
 	"github.com/HyperspaceApp/entropy-mnemonics"
 
 	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/encoding"
 	"github.com/HyperspaceApp/Hyperspace/types"
 )
 
@@ -28,6 +30,12 @@ const (
 	// Bitcoin defaults to 20, but we can create a lot of addresses quickly
 	// when we form contracts, so we set to 50.
 	DefaultAddressGapLimit = 50
+
+	// SignedMessagePrefix is hashed together with every message signed by the
+	// wallet. Transaction signatures are made over a hash that never starts
+	// with this prefix, so a signed message can't be passed off as a signed
+	// transaction.
+	SignedMessagePrefix = "Hyperspace Signed Message:\n"
 )
 
 var (
@@ -56,6 +64,10 @@ var (
 	// ErrAddressGapLimit is return when a user tries to create a new address
 	// that does not respect the address gap limit as specified in BIP 44
 	ErrAddressGapLimit = errors.New("cannot create new address beyond address gap limit")
+
+	// ErrInvalidMessageSignature is returned if a message signature doesn't
+	// match the message or the address it was supposedly signed with.
+	ErrInvalidMessageSignature = errors.New("message signature is invalid")
 )
 
 type (
@@ -125,6 +137,19 @@ type (
 		ConfirmationHeight types.BlockHeight `json:"confirmationheight"`
 	}
 
+	// WalletFeeEstimate is the result of building a transaction without
+	// signing or broadcasting it. Fee is the miner fee the wallet would
+	// attach when sending the coins, Size is the size in bytes of the signed
+	// transaction. MinimumFee and RecommendedFee are the fees the transaction
+	// pool currently recommends for a transaction of that size.
+	WalletFeeEstimate struct {
+		Fee            types.Currency `json:"fee"`
+		Size           uint64         `json:"size"`
+		Inputs         uint64         `json:"inputs"`
+		MinimumFee     types.Currency `json:"minimumfee"`
+		RecommendedFee types.Currency `json:"recommendedfee"`
+	}
+
 	// TransactionBuilder is used to construct custom transactions. A transaction
 	// builder is initialized via 'RegisterTransaction' and then can be modified by
 	// adding funds or other fields. The transaction is completed by calling
@@ -337,11 +362,16 @@ type (
 		// Signature fields of each TransactionSignature referenced by toSign.
 		SignTransaction(txn *types.Transaction, toSign []crypto.Hash) error
 
+		// SignMessage signs an arbitrary message with the key of addr. The
+		// signature can be checked with VerifyMessage.
+		SignMessage(addr types.UnlockHash, message []byte) (MessageSignature, error)
+
 		// SweepSeed scans the blockchain for outputs generated from seed and
-		// creates a transaction that transfers them to the wallet. Note that
+		// creates transactions that transfer them to the wallet. Note that
 		// this incurs a transaction fee. It returns the total value of the
-		// outputs, minus the fee.
-		SweepSeed(seed Seed) (coins, funds types.Currency, err error)
+		// outputs, minus the fee, and the IDs of the transactions. The
+		// transactions submitted before an error are returned with it.
+		SweepSeed(seed Seed) (coins, funds types.Currency, txids []types.TransactionID, err error)
 	}
 
 	// Wallet stores and manages space cash. The wallet file is
@@ -366,9 +396,14 @@ type (
 
 		// ConfirmedBalance returns the confirmed balance of the wallet, minus
 		// any outgoing transactions. ConfirmedBalance will include unconfirmed
-		// refund transactions.
+		// refund transactions. Outputs that are still timelocked are
+		// excluded.
 		ConfirmedBalance() (siacoinBalance types.Currency, err error)
 
+		// TimelockedBalance returns the value of the confirmed outputs that
+		// can't be spent until their timelock expires.
+		TimelockedBalance() (siacoinBalance types.Currency, err error)
+
 		// UnconfirmedBalance returns the unconfirmed balance of the wallet.
 		// Outgoing funds and incoming funds are reported separately. Refund
 		// outputs are included, meaning that sending a single coin to
@@ -460,9 +495,28 @@ type (
 		// are also returned to the caller.
 		SendSiacoins(amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error)
 
+		// SendSiacoinsTimelocked sends space cash to the address with the
+		// unlock conditions dest, locked until the blockchain reaches the
+		// unlock height. If dest belongs to the wallet, the wallet recognizes
+		// the output and reports it as timelocked balance until it becomes
+		// spendable.
+		SendSiacoinsTimelocked(amount types.Currency, dest types.UnlockConditions, unlockHeight types.BlockHeight) ([]types.Transaction, error)
+
+		// TimelockedAddress returns the unlock conditions of a new address of
+		// the wallet that is locked until the blockchain reaches the unlock
+		// height. Coins that other wallets send to the address are reported
+		// as timelocked balance until they become spendable.
+		TimelockedAddress(unlockHeight types.BlockHeight) (types.UnlockConditions, error)
+
 		// SendSiacoinsMulti sends coins to multiple addresses.
 		SendSiacoinsMulti(outputs []types.SiacoinOutput) ([]types.Transaction, error)
 
+		// EstimateFee builds the transaction that SendSiacoins, or
+		// SendSiacoinsMulti for several outputs, would send without signing
+		// or broadcasting it, and returns the fee it would attach and the
+		// size of the transaction.
+		EstimateFee(outputs []types.SiacoinOutput) (WalletFeeEstimate, error)
+
 		// DustThreshold returns the quantity per byte below which a Currency is
 		// considered to be Dust.
 		DustThreshold() (types.Currency, error)
@@ -479,6 +533,14 @@ type (
 		WatchAddresses() ([]types.UnlockHash, error)
 	}
 
+	// A MessageSignature proves that a message was signed with the key of an
+	// address. The public key is included because an address is only the
+	// hash of its unlock conditions.
+	MessageSignature struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		Signature crypto.Signature   `json:"signature"`
+	}
+
 	// WalletSettings control the behavior of the Wallet.
 	WalletSettings struct {
 		NoDefrag bool `json:"noDefrag"`
@@ -519,3 +581,47 @@ func StringToSeed(str string, did mnemonics.DictionaryID) (Seed, error) {
 	}
 	return seed, nil
 }
+
+// SignedMessageHash returns the hash that is signed when signing a message
+// with a wallet address.
+func SignedMessageHash(message []byte) crypto.Hash {
+	return crypto.HashAll(SignedMessagePrefix, message)
+}
+
+// String encodes the signature as a hex string, which is the format used to
+// exchange message signatures.
+func (ms MessageSignature) String() string {
+	return hex.EncodeToString(encoding.Marshal(ms))
+}
+
+// LoadString decodes a message signature which was encoded with String.
+func (ms *MessageSignature) LoadString(s string) error {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return encoding.Unmarshal(b, ms)
+}
+
+// VerifyMessage checks that message was signed by the key of addr. Only
+// addresses which are controlled by a single ed25519 key without a timelock
+// can sign messages, which allows the address to be recomputed from the public
+// key in the signature. No wallet is required to verify a signature.
+func VerifyMessage(addr types.UnlockHash, message []byte, ms MessageSignature) error {
+	if ms.PublicKey.Algorithm != types.SignatureEd25519 || len(ms.PublicKey.Key) != crypto.PublicKeySize {
+		return ErrInvalidMessageSignature
+	}
+	uc := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{ms.PublicKey},
+		SignaturesRequired: 1,
+	}
+	if uc.UnlockHash() != addr {
+		return ErrInvalidMessageSignature
+	}
+	var pk crypto.PublicKey
+	copy(pk[:], ms.PublicKey.Key)
+	if crypto.VerifyHash(SignedMessageHash(message), pk, ms.Signature) != nil {
+		return ErrInvalidMessageSignature
+	}
+	return nil
+}
@@ -15,6 +15,12 @@ const (
 	// defragThreshold is the number of outputs a wallet is allowed before it is
 	// defragmented.
 	defragThreshold = 50
+
+	// sweepInitialKeysFactor is the multiple of the address gap limit that
+	// is scanned when sweeping a seed. Seeds that are swept often come from
+	// other wallets that didn't stick to the gap limit, so starting with a
+	// wider range avoids rescanning the blockchain in the common case.
+	sweepInitialKeysFactor = 50
 )
 
 func init() {
@@ -64,6 +64,7 @@ var (
 	keyPrimarySeedFile           = []byte("keyPrimarySeedFile")
 	keyPrimarySeedProgress       = []byte("keyPrimarySeedProgress")
 	keySpendableKeyFiles         = []byte("keySpendableKeyFiles")
+	keyTimelockedConditions      = []byte("keyTimelockedConditions")
 	keyUID                       = []byte("keyUID")
 	keyWatchedAddrs              = []byte("keyWatchedAddrs")
 	keySeedsMaximumInternalIndex = []byte("keySeedsMaximumInternalIndex")
@@ -146,6 +147,7 @@ func dbReset(tx *bolt.Tx) error {
 	wb.Put(keyAuxiliarySeedFiles, encoding.Marshal([]seedFile{}))
 	wb.Put(keySpendableKeyFiles, encoding.Marshal([]spendableKeyFile{}))
 	wb.Put(keyWatchedAddrs, encoding.Marshal([]types.UnlockHash{}))
+	wb.Put(keyTimelockedConditions, encoding.Marshal([]types.UnlockConditions{}))
 	wb.Put(keySeedsMaximumInternalIndex, encoding.Marshal([]uint64{0}))
 	wb.Put(keySeedsMaximumExternalIndex, encoding.Marshal([]uint64{0}))
 	dbPutConsensusHeight(tx, 0)
@@ -490,6 +492,19 @@ func dbPutWatchedAddresses(tx *bolt.Tx, addrs []types.UnlockHash) error {
 	return tx.Bucket(bucketWallet).Put(keyWatchedAddrs, encoding.Marshal(addrs))
 }
 
+// dbGetTimelockedConditions returns the timelocked unlock conditions of the
+// wallet's addresses.
+func dbGetTimelockedConditions(tx *bolt.Tx) (ucs []types.UnlockConditions, err error) {
+	err = encoding.Unmarshal(tx.Bucket(bucketWallet).Get(keyTimelockedConditions), &ucs)
+	return
+}
+
+// dbPutTimelockedConditions stores the timelocked unlock conditions of the
+// wallet's addresses.
+func dbPutTimelockedConditions(tx *bolt.Tx, ucs []types.UnlockConditions) error {
+	return tx.Bucket(bucketWallet).Put(keyTimelockedConditions, encoding.Marshal(ucs))
+}
+
 // dbPutSeedsMaximumInternalIndexForSeed sets the maximum internal address index for a given seed
 // number.
 func dbPutSeedsMaximumInternalIndexForSeed(tx *bolt.Tx, seedIndex, index uint64) (err error) {
@@ -124,6 +124,7 @@ func (w *Wallet) managedUnlock(masterKey crypto.CipherKey) error {
 	var auxiliarySeedFiles []seedFile
 	var unseededKeyFiles []spendableKeyFile
 	var watchedAddrs []types.UnlockHash
+	var timelockedConditions []types.UnlockConditions
 	err := func() error {
 		w.mu.Lock()
 		defer w.mu.Unlock()
@@ -173,6 +174,12 @@ func (w *Wallet) managedUnlock(masterKey crypto.CipherKey) error {
 			return err
 		}
 
+		// timelockedConditions
+		timelockedConditions, err = dbGetTimelockedConditions(w.dbTx)
+		if err != nil {
+			return err
+		}
+
 		return nil
 	}()
 	if err != nil {
@@ -222,6 +229,9 @@ func (w *Wallet) managedUnlock(masterKey crypto.CipherKey) error {
 			w.watchedAddrs[addr] = struct{}{}
 		}
 
+		// timelockedConditions
+		w.integrateTimelockedConditions(timelockedConditions)
+
 		return nil
 	}()
 	if err != nil {
@@ -0,0 +1,89 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// errNoOutputs is returned if a fee is estimated for a transaction without
+// outputs.
+var errNoOutputs = errors.New("no outputs were provided")
+
+// EstimateFee builds the transaction that SendSiacoins, or SendSiacoinsMulti
+// for several outputs, would send to the provided outputs and returns the fee
+// it would attach and the size of the signed transaction. The inputs are
+// selected the same way, but the transaction is neither signed nor broadcast,
+// no outputs are marked as spent and no refund address is generated.
+func (w *Wallet) EstimateFee(outputs []types.SiacoinOutput) (modules.WalletFeeEstimate, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.WalletFeeEstimate{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if len(outputs) == 0 {
+		return modules.WalletFeeEstimate{}, errNoOutputs
+	}
+
+	// dustThreshold has to be obtained separate from the lock
+	dustThreshold, err := w.DustThreshold()
+	if err != nil {
+		return modules.WalletFeeEstimate{}, err
+	}
+	minFee, maxFee := w.tpool.FeeEstimation()
+	var fee types.Currency
+	if len(outputs) == 1 {
+		fee = sendSiacoinsFee(maxFee)
+	} else {
+		fee = sendSiacoinsMultiFee(maxFee, len(outputs))
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.WalletFeeEstimate{}, modules.ErrLockedWallet
+	}
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return modules.WalletFeeEstimate{}, err
+	}
+	amount := calculateAmountFromOutputs(outputs, fee)
+	inputs, _, fund, err := w.selectSiacoinInputs(amount, dustThreshold, consensusHeight)
+	if err != nil {
+		return modules.WalletFeeEstimate{}, err
+	}
+
+	// Assemble the transaction like FundSiacoinsForOutputs and Sign would.
+	// The refund output and the signatures only need the right size.
+	txn := types.Transaction{
+		SiacoinInputs:  inputs,
+		SiacoinOutputs: append([]types.SiacoinOutput(nil), outputs...),
+	}
+	if !fee.IsZero() {
+		txn.MinerFees = append(txn.MinerFees, fee)
+	}
+	if !amount.Equals(fund) {
+		txn.SiacoinOutputs = append(txn.SiacoinOutputs, types.SiacoinOutput{
+			Value: fund.Sub(amount),
+		})
+	}
+	for _, sci := range inputs {
+		for i := uint64(0); i < sci.UnlockConditions.SignaturesRequired; i++ {
+			txn.TransactionSignatures = append(txn.TransactionSignatures, types.TransactionSignature{
+				ParentID:       crypto.Hash(sci.ParentID),
+				CoveredFields:  types.FullCoveredFields,
+				PublicKeyIndex: i,
+				Signature:      make([]byte, crypto.SignatureSize),
+			})
+		}
+	}
+	size := uint64(txn.MarshalSiaSize())
+	return modules.WalletFeeEstimate{
+		Fee:            fee,
+		Size:           size,
+		Inputs:         uint64(len(inputs)),
+		MinimumFee:     minFee.Mul64(size),
+		RecommendedFee: maxFee.Mul64(size),
+	}, nil
+}
@@ -0,0 +1,54 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestEstimateFee checks that the fee estimate matches the transaction that
+// SendSiacoins sends afterwards and that the estimate doesn't spend outputs.
+func TestEstimateFee(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	if _, err := wt.wallet.EstimateFee(nil); err != errNoOutputs {
+		t.Fatal("expected errNoOutputs, got", err)
+	}
+	sendValue := types.SiacoinPrecision.Mul64(3)
+	outputs := []types.SiacoinOutput{{Value: sendValue}}
+	estimate, err := wt.wallet.EstimateFee(outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Estimating again selects the same inputs.
+	estimate2, err := wt.wallet.EstimateFee(outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Size != estimate2.Size || estimate.Inputs != estimate2.Inputs || !estimate.Fee.Equals(estimate2.Fee) {
+		t.Fatal("estimate spent outputs of the wallet", estimate, estimate2)
+	}
+
+	txns, err := wt.wallet.SendSiacoins(sendValue, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn := txns[len(txns)-1]
+	if len(txn.MinerFees) != 1 || !txn.MinerFees[0].Equals(estimate.Fee) {
+		t.Fatal("estimated fee doesn't match the sent fee", estimate.Fee, txn.MinerFees)
+	}
+	if uint64(txn.MarshalSiaSize()) != estimate.Size {
+		t.Fatal("estimated size doesn't match the sent transaction", estimate.Size, txn.MarshalSiaSize())
+	}
+	if uint64(len(txn.SiacoinInputs)) != estimate.Inputs {
+		t.Fatal("estimated inputs don't match the sent transaction", estimate.Inputs, len(txn.SiacoinInputs))
+	}
+}
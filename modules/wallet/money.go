@@ -31,7 +31,7 @@ func (w *Wallet) DustThreshold() (types.Currency, error) {
 }
 
 // ConfirmedBalance returns the balance of the wallet according to all of the
-// confirmed transactions.
+// confirmed transactions, excluding outputs that are still timelocked.
 func (w *Wallet) ConfirmedBalance() (siacoinBalance types.Currency, err error) {
 	if err := w.tg.Add(); err != nil {
 		return types.ZeroCurrency, modules.ErrWalletShutdown
@@ -52,8 +52,14 @@ func (w *Wallet) ConfirmedBalance() (siacoinBalance types.Currency, err error) {
 		return
 	}
 
+	// outputs whose timelock hasn't expired yet are reported by
+	// TimelockedBalance instead
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return
+	}
 	dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
-		if sco.Value.Cmp(dustThreshold) > 0 {
+		if sco.Value.Cmp(dustThreshold) > 0 && w.keys[sco.UnlockHash].UnlockConditions.Timelock <= height {
 			siacoinBalance = siacoinBalance.Add(sco.Value)
 		}
 	})
@@ -143,6 +149,20 @@ func (w *Wallet) checkOutput(tx *bolt.Tx, currentHeight types.BlockHeight, id ty
 	return nil
 }
 
+// sendSiacoinsFee returns the miner fee that SendSiacoins attaches given the
+// maximum recommended fee per byte of the transaction pool.
+func sendSiacoinsFee(feePerByte types.Currency) types.Currency {
+	return feePerByte.Mul64(750) // Estimated transaction size in bytes
+}
+
+// sendSiacoinsMultiFee returns the miner fee that SendSiacoinsMulti attaches
+// to a transaction with numOutputs outputs given the maximum recommended fee
+// per byte of the transaction pool.
+func sendSiacoinsMultiFee(feePerByte types.Currency, numOutputs int) types.Currency {
+	fee := feePerByte.Mul64(2)                     // We don't want send-to-many transactions to fail.
+	return fee.Mul64(1000 + 60*uint64(numOutputs)) // Estimated transaction size in bytes
+}
+
 // SendSiacoins creates a transaction sending 'amount' to 'dest'. The transaction
 // is submitted to the transaction pool and is also returned.
 func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) (txns []types.Transaction, err error) {
@@ -161,7 +181,7 @@ func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) (txn
 	}
 
 	_, tpoolFee := w.tpool.FeeEstimation()
-	tpoolFee = tpoolFee.Mul64(750) // Estimated transaction size in bytes
+	tpoolFee = sendSiacoinsFee(tpoolFee)
 	output := types.SiacoinOutput{
 		Value:      amount,
 		UnlockHash: dest,
@@ -239,8 +259,7 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 
 	// Add estimated transaction fee.
 	_, tpoolFee := w.tpool.FeeEstimation()
-	tpoolFee = tpoolFee.Mul64(2)                              // We don't want send-to-many transactions to fail.
-	tpoolFee = tpoolFee.Mul64(1000 + 60*uint64(len(outputs))) // Estimated transaction size in bytes
+	tpoolFee = sendSiacoinsMultiFee(tpoolFee, len(outputs))
 
 	err = txnBuilder.FundSiacoinsForOutputs(outputs, tpoolFee)
 	if err != nil {
@@ -157,6 +157,38 @@ func (w *Wallet) SignTransaction(txn *types.Transaction, toSign []crypto.Hash) e
 	return signTransaction(txn, w.keys, toSign)
 }
 
+// SignMessage signs message with the secret key of addr. The message is hashed
+// together with modules.SignedMessagePrefix before signing, so the signature
+// can't be used to sign a transaction.
+func (w *Wallet) SignMessage(addr types.UnlockHash, message []byte) (modules.MessageSignature, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.MessageSignature{}, err
+	}
+	defer w.tg.Done()
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if !w.unlocked {
+		return modules.MessageSignature{}, modules.ErrLockedWallet
+	}
+	sk, ok := w.keys[addr]
+	if !ok {
+		return modules.MessageSignature{}, errors.New("could not locate signing key for " + addr.String())
+	}
+	// The verifier recomputes the address from the public key, which is only
+	// possible for the standard single key addresses generated by the wallet.
+	uc := sk.UnlockConditions
+	if len(sk.SecretKeys) != 1 || len(uc.PublicKeys) != 1 || uc.SignaturesRequired != 1 || uc.Timelock != 0 || uc.PublicKeys[0].Algorithm != types.SignatureEd25519 {
+		return modules.MessageSignature{}, errors.New("only addresses controlled by a single key can sign messages")
+	}
+	return modules.MessageSignature{
+		PublicKey: types.SiaPublicKey{
+			Algorithm: uc.PublicKeys[0].Algorithm,
+			Key:       append([]byte(nil), uc.PublicKeys[0].Key...),
+		},
+		Signature: crypto.SignHash(modules.SignedMessageHash(message), sk.SecretKeys[0]),
+	}, nil
+}
+
 // SignTransaction signs txn using secret keys derived from seed. The
 // transaction should be complete with the exception of the Signature fields
 // of each TransactionSignature referenced by toSign, which must not be empty.
@@ -188,3 +188,65 @@ func TestWatchOnly(t *testing.T) {
 		}
 	}
 }
+
+// TestSignMessage checks that messages signed by the wallet can be verified
+// without the wallet and that the signature is bound to the address and the
+// message.
+func TestSignMessage(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := uc.UnlockHash()
+	message := []byte("I control this address")
+	ms, err := wt.wallet.SignMessage(addr, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := modules.VerifyMessage(addr, message, ms); err != nil {
+		t.Fatal(err)
+	}
+
+	// The signature should survive being encoded as a string.
+	var decoded modules.MessageSignature
+	if err := decoded.LoadString(ms.String()); err != nil {
+		t.Fatal(err)
+	}
+	if err := modules.VerifyMessage(addr, message, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	// A different message or address should be rejected.
+	if err := modules.VerifyMessage(addr, []byte("I control another address"), ms); err != modules.ErrInvalidMessageSignature {
+		t.Fatal("expected ErrInvalidMessageSignature but got", err)
+	}
+	otherUC, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := modules.VerifyMessage(otherUC.UnlockHash(), message, ms); err != modules.ErrInvalidMessageSignature {
+		t.Fatal("expected ErrInvalidMessageSignature but got", err)
+	}
+
+	// The message signature must not be valid for the plain hash of the
+	// message.
+	var pk crypto.PublicKey
+	copy(pk[:], ms.PublicKey.Key)
+	if crypto.VerifyHash(crypto.HashBytes(message), pk, ms.Signature) == nil {
+		t.Fatal("message signature shouldn't be valid without the prefix")
+	}
+
+	// Addresses unknown to the wallet can't be signed with.
+	if _, err := wt.wallet.SignMessage(types.UnlockHash{}, message); err == nil {
+		t.Fatal("expected signing with an unknown address to fail")
+	}
+}
@@ -74,6 +74,9 @@ func (w *Wallet) openDB(filename string) (err error) {
 		if wb.Get(keyWatchedAddrs) == nil {
 			wb.Put(keyWatchedAddrs, encoding.Marshal([]types.UnlockHash{}))
 		}
+		if wb.Get(keyTimelockedConditions) == nil {
+			wb.Put(keyTimelockedConditions, encoding.Marshal([]types.UnlockConditions{}))
+		}
 		if wb.Get(keySeedsMaximumInternalIndex) == nil {
 			wb.Put(keySeedsMaximumInternalIndex, encoding.Marshal([]uint64{0}))
 		}
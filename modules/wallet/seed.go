@@ -4,6 +4,7 @@ import (
 
 	//"fmt"
 	"runtime"
+	"sort"
 	"sync"
 
 	"github.com/HyperspaceApp/Hyperspace/crypto"
@@ -348,17 +349,51 @@ func (w *Wallet) LoadSeed(masterKey crypto.CipherKey, seed modules.Seed) error {
 	return nil
 }
 
+// scanSweepSeed scans the blockchain for the outputs of seed that are worth
+// more than dustThreshold. The scanners only find outputs of keys that were
+// generated when the output's block was scanned, so an output far beyond the
+// used indices of earlier blocks could be missed. The scan is therefore
+// repeated with a wider range of keys until every used index was covered from
+// the start.
+func (w *Wallet) scanSweepSeed(seed modules.Seed, dustThreshold types.Currency) (map[types.SiacoinOutputID]scannedOutput, error) {
+	numKeys := w.addressGapLimit * sweepInitialKeysFactor
+	for {
+		s := newSeedScanner(seed, numKeys, w.cs, w.log, w.scanAirdrop)
+		s.setDustThreshold(dustThreshold)
+		if err := s.scan(w.tg.StopChan()); err != nil {
+			return nil, err
+		}
+		covered := s.getMaximumExternalIndex() + w.addressGapLimit
+		if covered <= numKeys {
+			return s.getSiacoinOutputs(), nil
+		}
+		// Widen the range at least twofold to bound the number of scans.
+		numKeys *= 2
+		if covered > numKeys {
+			numKeys = covered
+		}
+		if numKeys > maxScanKeys {
+			return nil, errMaxKeys
+		}
+		w.log.Debugln("Sweeping seed found keys beyond the scanned range, rescanning with", numKeys, "keys")
+	}
+}
+
 // SweepSeed scans the blockchain for outputs generated from seed and creates
-// a transaction that transfers them to the wallet. Note that this incurs a
-// transaction fee. It returns the total value of the outputs, minus the fee.
-func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err error) {
+// transactions that transfer them to the wallet. Note that this incurs a
+// transaction fee. It returns the total value of the outputs, minus the fee,
+// and the IDs of the submitted transactions. The keys of seed are only used to
+// sign the transactions and are not added to the wallet. If a transaction
+// fails, the transactions that were submitted before it are still returned
+// along with the error, since their coins are swept regardless.
+func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, txids []types.TransactionID, err error) {
 	if err = w.tg.Add(); err != nil {
 		return
 	}
 	defer w.tg.Done()
 
 	if !w.scanLock.TryLock() {
-		return types.Currency{}, types.Currency{}, errScanInProgress
+		return types.Currency{}, types.Currency{}, nil, errScanInProgress
 	}
 	defer w.scanLock.Unlock()
 
@@ -366,11 +401,11 @@ func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err
 	match := seed == w.primarySeed
 	w.mu.RUnlock()
 	if match {
-		return types.Currency{}, types.Currency{}, errors.New("cannot sweep primary seed")
+		return types.Currency{}, types.Currency{}, nil, errors.New("cannot sweep primary seed")
 	}
 
 	if !w.cs.Synced() {
-		return types.Currency{}, types.Currency{}, errors.New("cannot sweep until blockchain is synced")
+		return types.Currency{}, types.Currency{}, nil, errors.New("cannot sweep until blockchain is synced")
 	}
 
 	// get an address to spend into
@@ -383,26 +418,29 @@ func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err
 
 	// scan blockchain for outputs, filtering out 'dust' (outputs that cost
 	// more in fees than they are worth)
-	s := newSeedScanner(seed, w.addressGapLimit, w.cs, w.log, w.scanAirdrop)
 	_, maxFee := w.tpool.FeeEstimation()
 	const outputSize = 350 // approx. size in bytes of an output and accompanying signature
 	const maxOutputs = 50  // approx. number of outputs that a transaction can handle
-	s.setDustThreshold(maxFee.Mul64(outputSize))
-	if err = s.scan(w.tg.StopChan()); err != nil {
+	scanned, err := w.scanSweepSeed(seed, maxFee.Mul64(outputSize))
+	if err != nil {
 		return
 	}
 
-	if len(s.getSiacoinOutputs()) == 0 {
+	if len(scanned) == 0 {
 		// if we aren't sweeping any coins, then just return an
 		// error; no reason to proceed
-		return types.Currency{}, types.Currency{}, errors.New("nothing to sweep")
+		return types.Currency{}, types.Currency{}, nil, errors.New("nothing to sweep")
 	}
 
-	// Flatten map to slice
+	// Flatten map to slice, ordered by seed index so that outputs of nearby
+	// addresses end up in the same transaction.
 	var siacoinOutputs []scannedOutput
-	for _, sco := range s.getSiacoinOutputs() {
+	for _, sco := range scanned {
 		siacoinOutputs = append(siacoinOutputs, sco)
 	}
+	sort.Slice(siacoinOutputs, func(i, j int) bool {
+		return siacoinOutputs[i].seedIndex < siacoinOutputs[j].seedIndex
+	})
 
 	for len(siacoinOutputs) > 0 {
 		// process up to maxOutputs siacoinOutputs
@@ -416,13 +454,8 @@ func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err
 		// construct a transaction that spends the outputs
 		tb, err := w.StartTransaction()
 		if err != nil {
-			return types.ZeroCurrency, types.ZeroCurrency, err
+			return coins, funds, txids, err
 		}
-		defer func() {
-			if err != nil {
-				tb.Drop()
-			}
-		}()
 		var sweptCoins types.Currency // total values of swept outputs
 		for _, output := range txnSiacoinOutputs {
 			// construct a siacoin input that spends the output
@@ -447,19 +480,16 @@ func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err
 			txnCoins = sweptCoins.Sub(estFee)
 		}
 
-		switch {
-		case txnCoins.IsZero():
-			// if we aren't sweeping any coins, then just return an
-			// error; no reason to proceed
-			return types.Currency{}, types.Currency{}, errors.New("transaction fee exceeds value of swept outputs")
-
-		case !txnCoins.IsZero():
-			// if we're sweeping coins, add a siacoin output for them
-			tb.AddSiacoinOutput(types.SiacoinOutput{
-				Value:      txnCoins,
-				UnlockHash: uc.UnlockHash(),
-			})
+		// if the outputs of this transaction aren't worth the fee, skip
+		// them; the other transactions may still sweep coins
+		if txnCoins.IsZero() {
+			tb.Drop()
+			continue
 		}
+		tb.AddSiacoinOutput(types.SiacoinOutput{
+			Value:      txnCoins,
+			UnlockHash: uc.UnlockHash(),
+		})
 
 		// add signatures for all coins (manually, since tb doesn't have
 		// access to the signing keys)
@@ -486,7 +516,8 @@ func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err
 		// submit the transactions
 		err = w.tpool.AcceptTransactionSet(txnSet)
 		if err != nil {
-			return types.ZeroCurrency, types.ZeroCurrency, err
+			tb.Drop()
+			return coins, funds, txids, err
 		}
 
 		w.log.Println("Creating a transaction set to sweep a seed, IDs:")
@@ -495,6 +526,10 @@ func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err
 		}
 
 		coins = coins.Add(txnCoins)
+		txids = append(txids, txn.ID())
+	}
+	if len(txids) == 0 {
+		return types.Currency{}, types.Currency{}, nil, errors.New("transaction fee exceeds value of swept outputs")
 	}
 	return
 }
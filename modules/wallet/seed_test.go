@@ -9,6 +9,8 @@ import (
 	"github.com/HyperspaceApp/Hyperspace/crypto"
 	"github.com/HyperspaceApp/Hyperspace/modules"
 	"github.com/HyperspaceApp/Hyperspace/types"
+
+	"github.com/HyperspaceApp/fastrand"
 )
 
 // TestPrimarySeed checks that the correct seed is returned when calling
@@ -235,10 +237,13 @@ func TestSweepSeedCoins(t *testing.T) {
 	}
 
 	// sweep the seed of the first wallet into the second
-	sweptCoins, _, err := w.SweepSeed(seed)
+	sweptCoins, _, txids, err := w.SweepSeed(seed)
 	if err != nil {
 		t.Fatal(err)
 	}
+	if len(txids) == 0 {
+		t.Fatal("sweep should report the transactions it submitted")
+	}
 
 	// new wallet should have exactly 'sweptCoins' coins
 	_, incoming, err := w.UnconfirmedBalance()
@@ -250,6 +255,66 @@ func TestSweepSeedCoins(t *testing.T) {
 	}
 }
 
+// TestSweepSeedBatches checks that a seed with more outputs than fit into one
+// transaction is swept with multiple transactions.
+func TestSweepSeedBatches(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Send an output to each of the first 60 addresses of a new seed, more
+	// than the 50 outputs of a sweep transaction.
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+	const numOutputs = 60
+	outputs := make([]types.SiacoinOutput, numOutputs)
+	for i := range outputs {
+		outputs[i] = types.SiacoinOutput{
+			Value:      types.SiacoinPrecision,
+			UnlockHash: generateSpendableKey(seed, uint64(i)).UnlockConditions.UnlockHash(),
+		}
+	}
+	if _, err := wt.wallet.SendSiacoinsMulti(outputs); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, incomingBefore, err := wt.wallet.UnconfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	coins, _, txids, err := wt.wallet.SweepSeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txids) != 2 {
+		t.Fatalf("expected 2 sweep transactions, got %v", len(txids))
+	}
+	if coins.Cmp(types.SiacoinPrecision.Mul64(numOutputs)) >= 0 || coins.Cmp(types.SiacoinPrecision.Mul64(numOutputs-1)) < 0 {
+		t.Fatal("swept an unexpected amount", coins.HumanString())
+	}
+	for _, txid := range txids {
+		if _, _, ok := wt.tpool.Transaction(txid); !ok {
+			t.Fatal("sweep transaction isn't in the transaction pool", txid)
+		}
+	}
+	_, incoming, err := wt.wallet.UnconfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if incoming.Sub(incomingBefore).Cmp(coins) != 0 {
+		t.Fatalf("wallet should receive the swept coins: wanted %v, got %v", coins, incoming.Sub(incomingBefore))
+	}
+}
+
 // TestGenerateKeys tests that the generateKeys function correctly generates a
 // key for every index specified.
 func TestGenerateKeys(t *testing.T) {
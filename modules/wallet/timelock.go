@@ -0,0 +1,154 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// A timelocked output is sent to a variant of an address whose unlock
+// conditions carry a timelock. The unlock conditions hash to a different
+// address, so the receiving wallet has to remember them to recognize the
+// output once it appears in the blockchain. A wallet remembers the timelocked
+// variants of its own addresses that it sends coins to, as well as the
+// timelocked addresses it hands out to receive coins from other wallets. Only
+// the unlock conditions are stored; the secret keys are those of the address
+// without a timelock, which the wallet derives from its seeds when it is
+// unlocked.
+
+var (
+	// errTimelockDestination is returned when the unlock conditions of the
+	// destination of a timelocked output already carry a timelock.
+	errTimelockDestination = errors.New("the destination of a timelocked output must not have a timelock")
+
+	// errTimelockHeight is returned when the unlock height of a timelocked
+	// output has already been reached.
+	errTimelockHeight = errors.New("unlock height has to be above the current height")
+)
+
+// integrateTimelockedConditions adds the timelocked variants of the wallet's
+// addresses to the set of spendable keys.
+func (w *Wallet) integrateTimelockedConditions(ucs []types.UnlockConditions) {
+	for _, uc := range ucs {
+		base := uc
+		base.Timelock = 0
+		sk, exists := w.keys[base.UnlockHash()]
+		if !exists {
+			w.log.Println("WARN: no key for the timelocked address", uc.UnlockHash())
+			continue
+		}
+		w.keys[uc.UnlockHash()] = spendableKey{
+			UnlockConditions: uc,
+			SecretKeys:       sk.SecretKeys,
+		}
+	}
+}
+
+// managedTimelockedConditions returns the unlock conditions 'dest' with a
+// timelock at 'unlockHeight'. If 'dest' are the unlock conditions of an
+// address of the wallet, the timelocked unlock conditions are persisted before
+// they are returned, so that an output sent to them is recognized even if the
+// wallet shuts down right after sending it.
+func (w *Wallet) managedTimelockedConditions(dest types.UnlockConditions, unlockHeight types.BlockHeight) (types.UnlockConditions, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return types.UnlockConditions{}, modules.ErrLockedWallet
+	}
+	if dest.Timelock != 0 {
+		return types.UnlockConditions{}, errTimelockDestination
+	}
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	if unlockHeight <= height {
+		return types.UnlockConditions{}, errTimelockHeight
+	}
+
+	uc := dest
+	uc.Timelock = unlockHeight
+	if _, exists := w.keys[dest.UnlockHash()]; !exists {
+		// The coins are sent to another wallet, which has to track the
+		// timelocked address itself.
+		return uc, nil
+	}
+	if _, exists := w.keys[uc.UnlockHash()]; exists {
+		return uc, nil
+	}
+	ucs, err := dbGetTimelockedConditions(w.dbTx)
+	if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	if err := dbPutTimelockedConditions(w.dbTx, append(ucs, uc)); err != nil {
+		return types.UnlockConditions{}, err
+	}
+	if err := w.syncDB(); err != nil {
+		return types.UnlockConditions{}, err
+	}
+	w.integrateTimelockedConditions([]types.UnlockConditions{uc})
+	return uc, nil
+}
+
+// TimelockedAddress returns the unlock conditions of a new address of the
+// wallet with a timelock at 'unlockHeight'. Other wallets can send coins to the
+// address with a regular transaction, the wallet recognizes them and reports
+// them as timelocked balance until they become spendable.
+func (w *Wallet) TimelockedAddress(unlockHeight types.BlockHeight) (types.UnlockConditions, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.UnlockConditions{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	dest, err := w.NextAddress()
+	if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	return w.managedTimelockedConditions(dest, unlockHeight)
+}
+
+// SendSiacoinsTimelocked creates a transaction sending 'amount' to the address
+// with the unlock conditions 'dest', locked until the blockchain reaches
+// 'unlockHeight'. The transaction is submitted to the transaction pool and is
+// also returned.
+func (w *Wallet) SendSiacoinsTimelocked(amount types.Currency, dest types.UnlockConditions, unlockHeight types.BlockHeight) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	uc, err := w.managedTimelockedConditions(dest, unlockHeight)
+	if err != nil {
+		w.log.Println("Attempt to send timelocked coins has failed:", err)
+		return nil, err
+	}
+	txnSet, err := w.SendSiacoins(amount, uc.UnlockHash())
+	if err != nil {
+		return nil, err
+	}
+	w.log.Println("Sent", amount.HumanString(), "to", uc.UnlockHash(), "locked until height", unlockHeight)
+	return txnSet, nil
+}
+
+// TimelockedBalance returns the value of the confirmed outputs of the wallet
+// that can't be spent yet because of their timelock.
+func (w *Wallet) TimelockedBalance() (siacoinBalance types.Currency, err error) {
+	if err := w.tg.Add(); err != nil {
+		return types.ZeroCurrency, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return types.ZeroCurrency, err
+	}
+	err = dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if w.keys[sco.UnlockHash].UnlockConditions.Timelock > height {
+			siacoinBalance = siacoinBalance.Add(sco.Value)
+		}
+	})
+	return
+}
@@ -0,0 +1,250 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/modules"
+	"github.com/HyperspaceApp/Hyperspace/types"
+)
+
+// TestSendSiacoinsTimelocked checks that the wallet recognizes timelocked
+// outputs it sends to itself, and that they are excluded from the confirmed
+// balance until they mature.
+func TestSendSiacoinsTimelocked(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Mine blocks without payouts so that the balance stabilizes.
+	for i := types.BlockHeight(0); i < types.MaturityDelay; i++ {
+		if err := wt.addBlockNoPayout(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	height, err := wt.wallet.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The destination can't have a timelock already, and the coins can only
+	// be locked until a future height.
+	sendValue := types.SiacoinPrecision.Mul64(3)
+	timelocked := uc
+	timelocked.Timelock = height + 5
+	if _, err := wt.wallet.SendSiacoinsTimelocked(sendValue, timelocked, height+5); err != errTimelockDestination {
+		t.Fatal("expected errTimelockDestination, got", err)
+	}
+	if _, err := wt.wallet.SendSiacoinsTimelocked(sendValue, uc, height); err != errTimelockHeight {
+		t.Fatal("expected errTimelockHeight, got", err)
+	}
+
+	// Send the coins and confirm the transaction. The sent coins and the fee
+	// leave the confirmed balance, and the coins are reported as timelocked.
+	confirmedBal, err := wt.wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, tpoolFee := wt.wallet.tpool.FeeEstimation()
+	tpoolFee = tpoolFee.Mul64(750)
+	unlockHeight := height + 3
+	if _, err := wt.wallet.SendSiacoinsTimelocked(sendValue, uc, unlockHeight); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	confirmedBal2, err := wt.wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !confirmedBal2.Equals(confirmedBal.Sub(sendValue).Sub(tpoolFee)) {
+		t.Fatal("timelocked coins are part of the confirmed balance", confirmedBal, confirmedBal2)
+	}
+	timelockedBal, err := wt.wallet.TimelockedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !timelockedBal.Equals(sendValue) {
+		t.Fatal("unexpected timelocked balance", timelockedBal)
+	}
+
+	// The timelocked address is still known after the wallet was unlocked
+	// again.
+	if err := wt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	timelockedBal, err = wt.wallet.TimelockedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !timelockedBal.Equals(sendValue) {
+		t.Fatal("timelocked address wasn't restored after unlocking", timelockedBal)
+	}
+
+	// Once the unlock height is reached, the coins become spendable.
+	for height, _ = wt.wallet.Height(); height < unlockHeight; height, _ = wt.wallet.Height() {
+		if err := wt.addBlockNoPayout(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	timelockedBal, err = wt.wallet.TimelockedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !timelockedBal.Equals(types.ZeroCurrency) {
+		t.Fatal("matured coins are still timelocked", timelockedBal)
+	}
+	confirmedBal3, err := wt.wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !confirmedBal3.Equals(confirmedBal2.Add(sendValue)) {
+		t.Fatal("matured coins aren't part of the confirmed balance", confirmedBal2, confirmedBal3)
+	}
+}
+
+// TestSendSiacoinsTimelockedForeign checks that timelocked coins can be sent to
+// an address of another wallet, and that the sending wallet doesn't track
+// them.
+func TestSendSiacoinsTimelockedForeign(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Mine blocks without payouts so that the balance stabilizes.
+	for i := types.BlockHeight(0); i < types.MaturityDelay; i++ {
+		if err := wt.addBlockNoPayout(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, pk := crypto.GenerateKeyPair()
+	dest := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{types.Ed25519PublicKey(pk)},
+		SignaturesRequired: 1,
+	}
+	height, err := wt.wallet.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirmedBal, err := wt.wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, tpoolFee := wt.wallet.tpool.FeeEstimation()
+	tpoolFee = tpoolFee.Mul64(750)
+	sendValue := types.SiacoinPrecision.Mul64(3)
+	txns, err := wt.wallet.SendSiacoinsTimelocked(sendValue, dest, height+3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The output is sent to the timelocked variant of the address.
+	timelocked := dest
+	timelocked.Timelock = height + 3
+	var found bool
+	for _, sco := range txns[len(txns)-1].SiacoinOutputs {
+		if sco.UnlockHash == timelocked.UnlockHash() && sco.Value.Equals(sendValue) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("no output was sent to the timelocked address")
+	}
+	confirmedBal2, err := wt.wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !confirmedBal2.Equals(confirmedBal.Sub(sendValue).Sub(tpoolFee)) {
+		t.Fatal("unexpected confirmed balance", confirmedBal, confirmedBal2)
+	}
+	timelockedBal, err := wt.wallet.TimelockedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !timelockedBal.IsZero() {
+		t.Fatal("coins sent to another wallet are reported as timelocked", timelockedBal)
+	}
+}
+
+// TestTimelockedAddress checks that the wallet recognizes coins that are sent
+// to a timelocked address it handed out with a regular transaction.
+func TestTimelockedAddress(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	height, err := wt.wallet.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.TimelockedAddress(height); err != errTimelockHeight {
+		t.Fatal("expected errTimelockHeight, got", err)
+	}
+	unlockHeight := height + 3
+	uc, err := wt.wallet.TimelockedAddress(unlockHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uc.Timelock != unlockHeight {
+		t.Fatal("address has the wrong timelock", uc.Timelock)
+	}
+
+	// Send coins to the address like any other wallet would.
+	sendValue := types.SiacoinPrecision.Mul64(3)
+	if _, err := wt.wallet.SendSiacoins(sendValue, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	timelockedBal, err := wt.wallet.TimelockedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !timelockedBal.Equals(sendValue) {
+		t.Fatal("coins sent to the timelocked address weren't recognized", timelockedBal)
+	}
+
+	// The address is still known after the wallet was unlocked again.
+	if err := wt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	timelockedBal, err = wt.wallet.TimelockedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !timelockedBal.Equals(sendValue) {
+		t.Fatal("timelocked address wasn't restored after unlocking", timelockedBal)
+	}
+}
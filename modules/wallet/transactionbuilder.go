@@ -90,6 +90,60 @@ func addSignatures(txn *types.Transaction, cf types.CoveredFields, uc types.Unlo
 	return newSigIndices
 }
 
+// selectSiacoinInputs selects spendable outputs of the wallet until their
+// value covers amount and returns the inputs spending them, the ids of the
+// selected outputs and their total value. The outputs aren't marked as spent,
+// the caller has to do that once the inputs are used.
+func (w *Wallet) selectSiacoinInputs(amount, dustThreshold types.Currency, consensusHeight types.BlockHeight) (inputs []types.SiacoinInput, spentScoids []types.SiacoinOutputID, fund types.Currency, err error) {
+	so, err := w.getSortedOutputs()
+	if err != nil {
+		return nil, nil, types.Currency{}, err
+	}
+
+	// potentialFund tracks the balance of the wallet including outputs that
+	// have been spent in other unconfirmed transactions recently. This is to
+	// provide the user with a more useful error message in the event that they
+	// are overspending.
+	var potentialFund types.Currency
+	for i := range so.ids {
+		scoid := so.ids[i]
+		sco := so.outputs[i]
+		// Check that the output can be spent.
+		if err := w.checkOutput(w.dbTx, consensusHeight, scoid, sco, dustThreshold); err != nil {
+			if err == errSpendHeightTooHigh {
+				potentialFund = potentialFund.Add(sco.Value)
+			}
+			continue
+		}
+
+		key, ok := w.keys[sco.UnlockHash]
+		if !ok {
+			return nil, nil, types.Currency{}, errMissingOutputKey
+		}
+
+		// Add a siacoin input for this output.
+		inputs = append(inputs, types.SiacoinInput{
+			ParentID:         scoid,
+			UnlockConditions: key.UnlockConditions,
+		})
+		spentScoids = append(spentScoids, scoid)
+
+		// Add the output to the total fund
+		fund = fund.Add(sco.Value)
+		potentialFund = potentialFund.Add(sco.Value)
+		if fund.Cmp(amount) >= 0 {
+			break
+		}
+	}
+	if potentialFund.Cmp(amount) >= 0 && fund.Cmp(amount) < 0 {
+		return nil, nil, types.Currency{}, modules.ErrIncompleteTransactions
+	}
+	if fund.Cmp(amount) < 0 {
+		return nil, nil, types.Currency{}, modules.ErrLowBalance
+	}
+	return inputs, spentScoids, fund, nil
+}
+
 func calculateAmountFromOutputs(outputs []types.SiacoinOutput, fee types.Currency) types.Currency {
 	// Calculate the total amount we need to send
 	var amount types.Currency
@@ -135,55 +189,13 @@ func (tb *transactionBuilder) FundSiacoinsForOutputs(outputs []types.SiacoinOutp
 		tb.transaction.MinerFees = append(tb.transaction.MinerFees, fee)
 	}
 
-	so, err := tb.wallet.getSortedOutputs()
+	inputs, spentScoids, fund, err := tb.wallet.selectSiacoinInputs(amount, dustThreshold, consensusHeight)
 	if err != nil {
 		return err
 	}
-
-	var fund types.Currency
-	// potentialFund tracks the balance of the wallet including outputs that
-	// have been spent in other unconfirmed transactions recently. This is to
-	// provide the user with a more useful error message in the event that they
-	// are overspending.
-	var potentialFund types.Currency
-	var spentScoids []types.SiacoinOutputID
-	for i := range so.ids {
-		scoid := so.ids[i]
-		sco := so.outputs[i]
-		// Check that the output can be spent.
-		if err := tb.wallet.checkOutput(tb.wallet.dbTx, consensusHeight, scoid, sco, dustThreshold); err != nil {
-			if err == errSpendHeightTooHigh {
-				potentialFund = potentialFund.Add(sco.Value)
-			}
-			continue
-		}
-
-		key, ok := tb.wallet.keys[sco.UnlockHash]
-		if !ok {
-			return errMissingOutputKey
-		}
-
-		// Add a siacoin input for this output.
-		sci := types.SiacoinInput{
-			ParentID:         scoid,
-			UnlockConditions: key.UnlockConditions,
-		}
+	for _, sci := range inputs {
 		tb.siacoinInputs = append(tb.siacoinInputs, len(tb.transaction.SiacoinInputs))
 		tb.transaction.SiacoinInputs = append(tb.transaction.SiacoinInputs, sci)
-		spentScoids = append(spentScoids, scoid)
-
-		// Add the output to the total fund
-		fund = fund.Add(sco.Value)
-		potentialFund = potentialFund.Add(sco.Value)
-		if fund.Cmp(amount) >= 0 {
-			break
-		}
-	}
-	if potentialFund.Cmp(amount) >= 0 && fund.Cmp(amount) < 0 {
-		return modules.ErrIncompleteTransactions
-	}
-	if fund.Cmp(amount) < 0 {
-		return modules.ErrLowBalance
 	}
 
 	// Add the outputs to the transaction
@@ -234,57 +246,14 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
 		return err
 	}
 
-	so, err := tb.wallet.getSortedOutputs()
-	if err != nil {
-		return err
-	}
-
 	// Create and fund a parent transaction that will add the correct amount of
 	// siacoins to the transaction.
-	var fund types.Currency
-	// potentialFund tracks the balance of the wallet including outputs that
-	// have been spent in other unconfirmed transactions recently. This is to
-	// provide the user with a more useful error message in the event that they
-	// are overspending.
-	var potentialFund types.Currency
-	parentTxn := types.Transaction{}
-	var spentScoids []types.SiacoinOutputID
-	for i := range so.ids {
-		scoid := so.ids[i]
-		sco := so.outputs[i]
-		// Check that the output can be spent.
-		if err := tb.wallet.checkOutput(tb.wallet.dbTx, consensusHeight, scoid, sco, dustThreshold); err != nil {
-			if err == errSpendHeightTooHigh {
-				potentialFund = potentialFund.Add(sco.Value)
-			}
-			continue
-		}
-
-		key, ok := tb.wallet.keys[sco.UnlockHash]
-		if !ok {
-			return errMissingOutputKey
-		}
-
-		// Add a siacoin input for this output.
-		sci := types.SiacoinInput{
-			ParentID:         scoid,
-			UnlockConditions: key.UnlockConditions,
-		}
-		parentTxn.SiacoinInputs = append(parentTxn.SiacoinInputs, sci)
-		spentScoids = append(spentScoids, scoid)
-
-		// Add the output to the total fund
-		fund = fund.Add(sco.Value)
-		potentialFund = potentialFund.Add(sco.Value)
-		if fund.Cmp(amount) >= 0 {
-			break
-		}
-	}
-	if potentialFund.Cmp(amount) >= 0 && fund.Cmp(amount) < 0 {
-		return modules.ErrIncompleteTransactions
+	inputs, spentScoids, fund, err := tb.wallet.selectSiacoinInputs(amount, dustThreshold, consensusHeight)
+	if err != nil {
+		return err
 	}
-	if fund.Cmp(amount) < 0 {
-		return modules.ErrLowBalance
+	parentTxn := types.Transaction{
+		SiacoinInputs: inputs,
 	}
 
 	// Create and add the output that will be used to fund the standard
@@ -16,9 +16,12 @@ func (wt *walletTester) addBlockNoPayout() error {
 		return err
 	}
 	// Clear the miner payout so that the wallet is not getting additional
-	// outputs from these blocks.
+	// outputs from these blocks. The dev fund payout has to stay in place for
+	// the block to be valid.
 	for i := range block.MinerPayouts {
-		block.MinerPayouts[i].UnlockHash = types.UnlockHash{}
+		if block.MinerPayouts[i].UnlockHash != types.DevFundUnlockHash {
+			block.MinerPayouts[i].UnlockHash = types.UnlockHash{}
+		}
 	}
 
 	// Solve and submit the block.
@@ -2,7 +2,10 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 
@@ -100,6 +103,50 @@ func HttpPOSTAuthenticated(url string, data string, password string) (resp *http
 	return http.DefaultClient.Do(req)
 }
 
+// httpPOSTStreamAuthenticated makes an authenticated http post request to
+// another Hyperspace node with body as the raw request body. A non-2xx response
+// does not return an error.
+func httpPOSTStreamAuthenticated(url string, body io.Reader, password string) (resp *http.Response, err error) {
+	req, err := http.NewRequest("POST", url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Hyperspace-Agent")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.SetBasicAuth("", password)
+	return http.DefaultClient.Do(req)
+}
+
+// readNodeResponse decodes the response to a request to another Hyperspace
+// node into obj and closes the body. A non-2xx response is returned as an
+// error.
+func readNodeResponse(resp *http.Response, obj interface{}) error {
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return errors.New("the node rejected the API password")
+	case resp.StatusCode == http.StatusNotFound:
+		return errors.New("the node doesn't support the API call")
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		var apiErr Error
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil {
+			return errors.New("the node responded with " + resp.Status)
+		}
+		return apiErr
+	}
+	return json.NewDecoder(resp.Body).Decode(obj)
+}
+
+// escapePath escapes every segment of a siapath to make it safe to use within
+// a URL.
+func escapePath(siaPath string) string {
+	segments := strings.Split(siaPath, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	return strings.Join(segments, "/")
+}
+
 // API encapsulates a collection of modules and implements a http.Handler
 // to access their methods.
 type API struct {
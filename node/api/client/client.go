@@ -13,6 +13,12 @@ import (
 	"github.com/HyperspaceApp/errors"
 )
 
+var (
+	// ErrRangeNotSatisfiable is returned if none of the requested bytes of a
+	// partial download are part of the resource.
+	ErrRangeNotSatisfiable = errors.New("requested range not satisfiable")
+)
+
 // A Client makes requests to the hsd HTTP API.
 type Client struct {
 	// Address is the API address of the hsd server.
@@ -117,6 +123,9 @@ func (c *Client) getRawPartialResponse(resource string, from, to uint64) ([]byte
 	if res.StatusCode == http.StatusNotFound {
 		return nil, errors.New("API call not recognized: " + resource)
 	}
+	if res.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return nil, ErrRangeNotSatisfiable
+	}
 
 	// If the status code is not 2xx, decode and return the accompanying
 	// api.Error.
@@ -128,6 +137,13 @@ func (c *Client) getRawPartialResponse(resource string, from, to uint64) ([]byte
 		// no reason to read the response
 		return []byte{}, nil
 	}
+	// The server has to respond with exactly the requested range.
+	if res.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("expected status %v, got %v", http.StatusPartialContent, res.StatusCode)
+	}
+	if cr := res.Header.Get("Content-Range"); !strings.HasPrefix(cr, fmt.Sprintf("bytes %d-%d/", from, to)) {
+		return nil, errors.New("unexpected content range: " + cr)
+	}
 	return ioutil.ReadAll(res.Body)
 }
 
@@ -206,3 +222,33 @@ func (c *Client) post(resource string, data string, obj interface{}) error {
 	}
 	return nil
 }
+
+// postReader makes a POST request to the resource at `resource`, using the
+// raw contents of `body` as the request body. The response, if provided, will
+// be decoded into `obj`.
+func (c *Client) postReader(resource string, body io.Reader, obj interface{}) error {
+	req, err := c.NewRequest("POST", resource, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.AddContext(err, "request failed")
+	}
+	defer drainAndClose(res.Body)
+
+	if res.StatusCode == http.StatusNotFound {
+		return errors.New("API call not recognized: " + resource)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return readAPIError(res.Body)
+	}
+	if obj == nil || res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(obj); err != nil {
+		return errors.AddContext(err, "could not read response")
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"fmt"
+	"net/url"
 
 	"github.com/HyperspaceApp/Hyperspace/node/api"
 	"github.com/HyperspaceApp/Hyperspace/types"
@@ -24,3 +25,17 @@ func (c *Client) ConsensusBlocksHeightGet(height types.BlockHeight) (cbg api.Con
 	err = c.get("/consensus/blocks?height="+fmt.Sprint(height), &cbg)
 	return
 }
+
+// ConsensusUTXOsGet requests the /consensus/utxos api resource, returning up
+// to limit unspent siacoin outputs whose address starts with addressPrefix and
+// whose id comes after the provided id.
+func (c *Client) ConsensusUTXOsGet(addressPrefix string, after types.SiacoinOutputID, limit int) (cug api.ConsensusUTXOsGET, err error) {
+	values := url.Values{}
+	values.Set("addressprefix", addressPrefix)
+	if after != (types.SiacoinOutputID{}) {
+		values.Set("after", after.String())
+	}
+	values.Set("limit", fmt.Sprint(limit))
+	err = c.get("/consensus/utxos?"+values.Encode(), &cug)
+	return
+}
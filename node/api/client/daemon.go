@@ -8,6 +8,13 @@ func (c *Client) DaemonVersionGet() (dvg api.DaemonVersionGet, err error) {
 	return
 }
 
+// DaemonReloadPost reloads the config file of the daemon using the
+// /daemon/reload endpoint.
+func (c *Client) DaemonReloadPost() (drp api.DaemonReloadPOST, err error) {
+	err = c.post("/daemon/reload", "", &drp)
+	return
+}
+
 // DaemonStopGet stops the daemon using the /daemon/stop endpoint.
 func (c *Client) DaemonStopGet() (err error) {
 	err = c.get("/daemon/stop", nil)
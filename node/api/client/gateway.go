@@ -1,6 +1,9 @@
 package client
 
 import (
+	"net/url"
+	"strconv"
+
 	"github.com/HyperspaceApp/Hyperspace/modules"
 	"github.com/HyperspaceApp/Hyperspace/node/api"
 	"github.com/HyperspaceApp/errors"
@@ -23,6 +26,18 @@ func (c *Client) GatewayConnectPost(address modules.NetAddress) (err error) {
 	return
 }
 
+// GatewayConnectRelayedPost uses the /gateway/connect/:address endpoint to
+// connect to the gateway at address through a relay.
+func (c *Client) GatewayConnectRelayedPost(address, relay modules.NetAddress) (err error) {
+	values := url.Values{}
+	values.Set("relay", string(relay))
+	err = c.post("/gateway/connect/"+string(address), values.Encode(), nil)
+	if err != nil && err.Error() == ErrPeerExists.Error() {
+		err = ErrPeerExists
+	}
+	return
+}
+
 // GatewayDisconnectPost uses the /gateway/disconnect/:address endpoint to
 // disconnect the gateway from a peer.
 func (c *Client) GatewayDisconnectPost(address modules.NetAddress) (err error) {
@@ -35,3 +50,49 @@ func (c *Client) GatewayGet() (gwg api.GatewayGET, err error) {
 	err = c.get("/gateway", &gwg)
 	return
 }
+
+// GatewayMinPeerVersionPost uses the /gateway endpoint to set the oldest
+// version that peers of the gateway may run.
+func (c *Client) GatewayMinPeerVersionPost(version string) (err error) {
+	values := url.Values{}
+	values.Set("minpeerversion", version)
+	err = c.post("/gateway", values.Encode(), nil)
+	return
+}
+
+// GatewayRelayFanoutPost uses the /gateway endpoint to set the maximum number
+// of peers that objects are relayed to.
+func (c *Client) GatewayRelayFanoutPost(fanout int) (err error) {
+	values := url.Values{}
+	values.Set("relayfanout", strconv.Itoa(fanout))
+	err = c.post("/gateway", values.Encode(), nil)
+	return
+}
+
+// GatewayPeerLimitsPost uses the /gateway endpoint to set the number of peers
+// the gateway connects to.
+func (c *Client) GatewayPeerLimitsPost(limits modules.GatewayPeerLimits) (err error) {
+	values := url.Values{}
+	values.Set("maxpeers", strconv.Itoa(limits.MaxPeers))
+	values.Set("maxoutboundpeers", strconv.Itoa(limits.MaxOutboundPeers))
+	err = c.post("/gateway", values.Encode(), nil)
+	return
+}
+
+// GatewayRelayConnectionsPost uses the /gateway endpoint to enable or disable
+// forwarding connections between the peers of the gateway.
+func (c *Client) GatewayRelayConnectionsPost(relay bool) (err error) {
+	values := url.Values{}
+	values.Set("relayconnections", strconv.FormatBool(relay))
+	err = c.post("/gateway", values.Encode(), nil)
+	return
+}
+
+// GatewayAcceptRelayedPost uses the /gateway endpoint to enable or disable
+// accepting connections that relays forward to the gateway.
+func (c *Client) GatewayAcceptRelayedPost(accept bool) (err error) {
+	values := url.Values{}
+	values.Set("acceptrelayed", strconv.FormatBool(accept))
+	err = c.post("/gateway", values.Encode(), nil)
+	return
+}
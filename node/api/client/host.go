@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/HyperspaceApp/Hyperspace/crypto"
 	"github.com/HyperspaceApp/Hyperspace/modules"
@@ -47,6 +49,9 @@ const (
 	HostParamMaxReviseBatchSize = HostParam("maxrevisebatchsize")
 	// HostParamNetAddress is the announced netaddress of the host.
 	HostParamNetAddress = HostParam("netaddress")
+	// HostParamReservedStorage is a comma separated list of pubkey=bytes
+	// pairs describing the storage reserved for specific renters.
+	HostParamReservedStorage = HostParam("reservedstorage")
 )
 
 // HostAnnouncePost uses the /host/announce endpoint to announce the host to
@@ -56,6 +61,34 @@ func (c *Client) HostAnnouncePost() (err error) {
 	return
 }
 
+// HostDrainPost uses the /host/drain endpoint to stop the host from accepting
+// contracts and uploads.
+func (c *Client) HostDrainPost() (err error) {
+	err = c.post("/host/drain", "", nil)
+	return
+}
+
+// HostUndrainPost uses the /host/undrain endpoint to end the drain mode of the
+// host.
+func (c *Client) HostUndrainPost() (err error) {
+	err = c.post("/host/undrain", "", nil)
+	return
+}
+
+// HostBenchmarkPost uses the /host/benchmark endpoint to measure the disk and
+// network throughput of the host.
+func (c *Client) HostBenchmarkPost() (hbp api.HostBenchmarkPOST, err error) {
+	err = c.post("/host/benchmark", "", &hbp)
+	return
+}
+
+// HostPrunePost uses the /host/prune endpoint to remove the sectors of
+// resolved storage obligations.
+func (c *Client) HostPrunePost() (hpp api.HostPrunePOST, err error) {
+	err = c.post("/host/prune", "", &hpp)
+	return
+}
+
 // HostAnnounceAddrPost uses the /host/anounce endpoint to announce the host to
 // the network using the provided address.
 func (c *Client) HostAnnounceAddrPost(address modules.NetAddress) (err error) {
@@ -70,6 +103,34 @@ func (c *Client) HostContractInfoGet() (cg api.ContractInfoGET, err error) {
 	return
 }
 
+// HostEarningsGet requests the /host/earnings endpoint. A zero start or end
+// time doesn't filter the earnings.
+func (c *Client) HostEarningsGet(start, end time.Time) (heg api.HostEarningsGET, err error) {
+	err = c.get("/host/earnings?"+earningsRangeValues(start, end).Encode(), &heg)
+	return
+}
+
+// HostEarningsExportPost uses the /host/earnings/export endpoint to write the
+// earnings between start and end as CSV to dst.
+func (c *Client) HostEarningsExportPost(dst string, start, end time.Time) (err error) {
+	values := earningsRangeValues(start, end)
+	values.Set("destination", dst)
+	err = c.post("/host/earnings/export", values.Encode(), nil)
+	return
+}
+
+// earningsRangeValues encodes the time range of a request to /host/earnings.
+func earningsRangeValues(start, end time.Time) url.Values {
+	values := url.Values{}
+	if !start.IsZero() {
+		values.Set("start", fmt.Sprint(start.Unix()))
+	}
+	if !end.IsZero() {
+		values.Set("end", fmt.Sprint(end.Unix()))
+	}
+	return values
+}
+
 // HostEstimateScoreGet requests the /host/estimatescore endpoint.
 func (c *Client) HostEstimateScoreGet(param, value string) (eg api.HostEstimateScoreGET, err error) {
 	err = c.get(fmt.Sprintf("/host/estimatescore?%v=%v", param, value), &eg)
@@ -118,6 +179,22 @@ func (c *Client) HostStorageFoldersResizePost(path string, size uint64) (err err
 	return
 }
 
+// HostStorageMigrateGet requests the /host/storage/migrate endpoint.
+func (c *Client) HostStorageMigrateGet() (smg api.StorageMigrateGET, err error) {
+	err = c.get("/host/storage/migrate", &smg)
+	return
+}
+
+// HostStorageMigratePost uses the /host/storage/migrate api endpoint to move
+// the sectors of a storage folder to the target storage folders.
+func (c *Client) HostStorageMigratePost(path string, targets []string) (err error) {
+	values := url.Values{}
+	values.Set("path", path)
+	values.Set("targets", strings.Join(targets, ","))
+	err = c.post("/host/storage/migrate", values.Encode(), nil)
+	return
+}
+
 // HostStorageGet requests the /host/storage endpoint.
 func (c *Client) HostStorageGet() (sg api.StorageGET, err error) {
 	err = c.get("/host/storage", &sg)
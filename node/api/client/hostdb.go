@@ -1,6 +1,12 @@
 package client
 
 import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/HyperspaceApp/Hyperspace/modules"
 	"github.com/HyperspaceApp/Hyperspace/node/api"
 	"github.com/HyperspaceApp/Hyperspace/types"
 )
@@ -23,8 +29,79 @@ func (c *Client) HostDbAllGet() (hdag api.HostdbAllGET, err error) {
 	return
 }
 
+// HostDbExportGet requests the /hostdb/export endpoint's resources.
+func (c *Client) HostDbExportGet() (hdeg api.HostdbExportGET, err error) {
+	err = c.get("/hostdb/export", &hdeg)
+	return
+}
+
+// HostDbImportPost uses the /hostdb/import endpoint to seed the hostdb with
+// hosts exported by another node.
+func (c *Client) HostDbImportPost(hosts []modules.HostDBEntry) (hdip api.HostdbImportPOST, err error) {
+	json, err := json.Marshal(api.HostdbImportPOSTParams{
+		Hosts: hosts,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/hostdb/import", string(json), &hdip)
+	return
+}
+
 // HostDbHostsGet request the /hostdb/hosts/:pubkey endpoint's resources.
 func (c *Client) HostDbHostsGet(pk types.SiaPublicKey) (hhg api.HostdbHostsGET, err error) {
 	err = c.get("/hostdb/hosts/"+pk.String(), &hhg)
 	return
 }
+
+// HostDbRescanPost uses the /hostdb/rescan endpoint to queue a scan of every
+// host known to the hostdb.
+func (c *Client) HostDbRescanPost() (err error) {
+	err = c.post("/hostdb/rescan", "", nil)
+	return
+}
+
+// HostDbReputationFeedGet requests the /hostdb/reputationfeed endpoint's
+// resources.
+func (c *Client) HostDbReputationFeedGet() (hdrfg api.HostdbReputationFeedGET, err error) {
+	err = c.get("/hostdb/reputationfeed", &hdrfg)
+	return
+}
+
+// HostDbReputationFeedPost uses the /hostdb/reputationfeed endpoint to set the
+// external reputation feed of the hostdb. An empty feedURL disables the feed.
+func (c *Client) HostDbReputationFeedPost(feedURL string, weight float64) (err error) {
+	values := url.Values{}
+	values.Set("url", feedURL)
+	values.Set("weight", strconv.FormatFloat(weight, 'f', -1, 64))
+	err = c.post("/hostdb/reputationfeed", values.Encode(), nil)
+	return
+}
+
+// HostDbRescanStatusGet requests the /hostdb/rescan/status endpoint's
+// resources.
+func (c *Client) HostDbRescanStatusGet() (hdrsg api.HostdbRescanStatusGET, err error) {
+	err = c.get("/hostdb/rescan/status", &hdrsg)
+	return
+}
+
+// RenterFilterModeGet requests the /renter/hostdb/filtermode endpoint's
+// resources.
+func (c *Client) RenterFilterModeGet() (hdfmg api.HostdbFilterModeGET, err error) {
+	err = c.get("/renter/hostdb/filtermode", &hdfmg)
+	return
+}
+
+// RenterSetFilterModePost uses the /renter/hostdb/filtermode endpoint to set
+// the filter of hosts of the hostdb.
+func (c *Client) RenterSetFilterModePost(mode string, hosts []types.SiaPublicKey) (err error) {
+	keys := make([]string, 0, len(hosts))
+	for _, pk := range hosts {
+		keys = append(keys, pk.String())
+	}
+	values := url.Values{}
+	values.Set("filtermode", mode)
+	values.Set("hosts", strings.Join(keys, ","))
+	err = c.post("/renter/hostdb/filtermode", values.Encode(), nil)
+	return
+}
@@ -1,12 +1,15 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/HyperspaceApp/Hyperspace/crypto"
 	"github.com/HyperspaceApp/Hyperspace/modules"
 	"github.com/HyperspaceApp/Hyperspace/node/api"
 	"github.com/HyperspaceApp/Hyperspace/types"
@@ -39,6 +42,88 @@ func (c *Client) RenterContractCancelPost(id types.FileContractID) error {
 	return err
 }
 
+// RenterContractMigratePost uses the /renter/contract/cancel endpoint to
+// cancel a contract once its data was migrated to other hosts.
+func (c *Client) RenterContractMigratePost(id types.FileContractID) error {
+	values := url.Values{}
+	values.Set("id", id.String())
+	values.Set("migrate", "true")
+	err := c.post("/renter/contract/cancel", values.Encode(), nil)
+	return err
+}
+
+// RenterHostContractsCancelPost uses the /renter/host/contracts/cancel
+// endpoint to cancel all contracts with a host.
+func (c *Client) RenterHostContractsCancelPost(pk types.SiaPublicKey) (rhcc api.RenterHostContractsCancelPOST, err error) {
+	values := url.Values{}
+	values.Set("pubkey", pk.String())
+	err = c.post("/renter/host/contracts/cancel", values.Encode(), &rhcc)
+	return
+}
+
+// RenterOrphanedSectorsGet uses the /renter/orphanedsectors endpoint to find
+// the sectors stored under the active contracts that no file references.
+func (c *Client) RenterOrphanedSectorsGet() (rosg api.RenterOrphanedSectorsGET, err error) {
+	err = c.get("/renter/orphanedsectors", &rosg)
+	return
+}
+
+// RenterOrphanedSectorsReclaimPost uses the /renter/orphanedsectors/reclaim
+// endpoint to re-associate the active contracts with the files that reference
+// their sectors.
+func (c *Client) RenterOrphanedSectorsReclaimPost() (rosrp api.RenterOrphanedSectorsReclaimPOST, err error) {
+	err = c.post("/renter/orphanedsectors/reclaim", "", &rosrp)
+	return
+}
+
+// RenterContractReassociatePost uses the /renter/contract/reassociate
+// endpoint to re-associate a contract with the files that reference the
+// sectors stored under it.
+func (c *Client) RenterContractReassociatePost(id types.FileContractID) (rcrp api.RenterContractReassociatePOST, err error) {
+	values := url.Values{}
+	values.Set("id", id.String())
+	err = c.post("/renter/contract/reassociate", values.Encode(), &rcrp)
+	return
+}
+
+// RenterContractMetadataPost uses the /renter/contract/metadata endpoint to
+// replace the metadata attached to a contract.
+func (c *Client) RenterContractMetadataPost(id types.FileContractID, kv map[string]string) error {
+	md, err := json.Marshal(kv)
+	if err != nil {
+		return err
+	}
+	values := url.Values{}
+	values.Set("id", id.String())
+	values.Set("metadata", string(md))
+	return c.post("/renter/contract/metadata", values.Encode(), nil)
+}
+
+// RenterContractRevisionGet uses the /renter/contract/revision endpoint to get
+// the latest signed revision of a contract.
+func (c *Client) RenterContractRevisionGet(id types.FileContractID) (rcr api.RenterContractRevisionGET, err error) {
+	values := url.Values{}
+	values.Set("id", id.String())
+	err = c.get("/renter/contract/revision?"+values.Encode(), &rcr)
+	return
+}
+
+// RenterSimulateHostFailurePost uses the /renter/debug/simulatehostfailure
+// endpoint to make the renter treat a host as failed.
+func (c *Client) RenterSimulateHostFailurePost(pk types.SiaPublicKey) error {
+	values := url.Values{}
+	values.Set("pubkey", pk.String())
+	return c.post("/renter/debug/simulatehostfailure", values.Encode(), nil)
+}
+
+// RenterRestoreHostPost uses the /renter/debug/restorehost endpoint to undo a
+// simulated host failure.
+func (c *Client) RenterRestoreHostPost(pk types.SiaPublicKey) error {
+	values := url.Values{}
+	values.Set("pubkey", pk.String())
+	return c.post("/renter/debug/restorehost", values.Encode(), nil)
+}
+
 // RenterContractsGet requests the /renter/contracts resource and returns
 // Contracts and ActiveContracts
 func (c *Client) RenterContractsGet() (rc api.RenterContracts, err error) {
@@ -84,6 +169,48 @@ func (c *Client) RenterDownloadGet(siaPath, destination string, offset, length u
 	return
 }
 
+// RenterDownloadByHashGet uses the /renter/downloadbyhash endpoint to download
+// the file with the given content hash to a destination on disk.
+func (c *Client) RenterDownloadByHashGet(contentHash crypto.Hash, destination string, async bool) (rdh api.RenterDownloadByHashGET, err error) {
+	values := url.Values{}
+	values.Set("destination", url.QueryEscape(destination))
+	values.Set("async", fmt.Sprint(async))
+	err = c.get(fmt.Sprintf("/renter/downloadbyhash/%s?%s", contentHash, values.Encode()), &rdh)
+	return
+}
+
+// RenterDownloadRepairPost uses the /renter/downloadrepair endpoint to verify
+// a local copy of a file and to download the chunks that don't match again.
+func (c *Client) RenterDownloadRepairPost(siaPath, localPath string) (rdr api.RenterDownloadRepairPOST, err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	values := url.Values{}
+	values.Set("destination", url.QueryEscape(localPath))
+	err = c.post(fmt.Sprintf("/renter/downloadrepair/%s", siaPath), values.Encode(), &rdr)
+	return
+}
+
+// RenterDownloadToNodePost uses the /renter/downloadtonode endpoint to stream
+// a file to the renter of the node with the API address targetNodeAPI, which
+// uploads it to targetSiaPath. A transfer that failed is resumed by calling
+// the method again.
+func (c *Client) RenterDownloadToNodePost(siaPath, targetNodeAPI, targetPassword, targetSiaPath string) (rdn api.RenterDownloadToNodePOST, err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	values := url.Values{}
+	values.Set("address", targetNodeAPI)
+	values.Set("password", targetPassword)
+	values.Set("targetpath", trimSiaPath(targetSiaPath))
+	err = c.post(fmt.Sprintf("/renter/downloadtonode/%s", siaPath), values.Encode(), &rdn)
+	return
+}
+
+// RenterDownloadCostGet uses the /renter/downloadcost endpoint to estimate
+// the cost of downloading a file.
+func (c *Client) RenterDownloadCostGet(siaPath string) (rdc api.RenterDownloadCostGET, err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	err = c.get(fmt.Sprintf("/renter/downloadcost/%s", siaPath), &rdc)
+	return
+}
+
 // RenterDownloadFullGet uses the /renter/download endpoint to download a full
 // file.
 func (c *Client) RenterDownloadFullGet(siaPath, destination string, async bool) (err error) {
@@ -131,6 +258,35 @@ func (c *Client) RenterClearDownloadsRangePost(after, before time.Time) (err err
 	return
 }
 
+// RenterDownloadPausePost uses the /renter/downloads/pause endpoint to pause
+// the download with the provided id.
+func (c *Client) RenterDownloadPausePost(id string) (err error) {
+	values := url.Values{}
+	values.Set("id", id)
+	err = c.post("/renter/downloads/pause", values.Encode(), nil)
+	return
+}
+
+// RenterDownloadResumePost uses the /renter/downloads/resume endpoint to
+// remove the pause and the throttle of the download with the provided id.
+func (c *Client) RenterDownloadResumePost(id string) (err error) {
+	values := url.Values{}
+	values.Set("id", id)
+	err = c.post("/renter/downloads/resume", values.Encode(), nil)
+	return
+}
+
+// RenterDownloadThrottlePost uses the /renter/downloads/throttle endpoint to
+// limit the download with the provided id to bytesPerSecond. A limit of zero
+// pauses the download.
+func (c *Client) RenterDownloadThrottlePost(id string, bytesPerSecond uint64) (err error) {
+	values := url.Values{}
+	values.Set("id", id)
+	values.Set("bytespersecond", strconv.FormatUint(bytesPerSecond, 10))
+	err = c.post("/renter/downloads/throttle", values.Encode(), nil)
+	return
+}
+
 // RenterDownloadsGet requests the /renter/downloads resource
 func (c *Client) RenterDownloadsGet() (rdq api.RenterDownloadQueue, err error) {
 	err = c.get("/renter/downloads", &rdq)
@@ -149,6 +305,18 @@ func (c *Client) RenterDownloadHTTPResponseGet(siaPath string, offset, length ui
 	return
 }
 
+// RenterDownloadTailGet uses the /renter/download endpoint to download the
+// last bytes of a file and return them. The whole file is returned if it is
+// smaller than bytes.
+func (c *Client) RenterDownloadTailGet(siaPath string, bytes uint64) (resp []byte, err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	values := url.Values{}
+	values.Set("tail", fmt.Sprint(bytes))
+	values.Set("httpresp", fmt.Sprint(true))
+	resp, err = c.getRawResponse(fmt.Sprintf("/renter/download/%s?%s", siaPath, values.Encode()))
+	return
+}
+
 // RenterFileGet uses the /renter/file/:hyperspacepath endpoint to query a file.
 func (c *Client) RenterFileGet(siaPath string) (rf api.RenterFile, err error) {
 	siaPath = escapeSiaPath(trimSiaPath(siaPath))
@@ -162,6 +330,15 @@ func (c *Client) RenterFilesGet() (rf api.RenterFiles, err error) {
 	return
 }
 
+// RenterFilesVerifyPost uses the /renter/files/verify endpoint to check the
+// siafiles on disk for corruption and optionally compact them.
+func (c *Client) RenterFilesVerifyPost(compact bool) (rfv api.RenterFilesVerifyPOST, err error) {
+	values := url.Values{}
+	values.Set("compact", strconv.FormatBool(compact))
+	err = c.post("/renter/files/verify", values.Encode(), &rfv)
+	return
+}
+
 // RenterFilesFilteredGet requests the /renter/files resource with a regex filter string.
 func (c *Client) RenterFilesFilteredGet(filter string) (rf api.RenterFiles, err error) {
 	query := fmt.Sprintf("?filter=%s", url.PathEscape(filter))
@@ -169,12 +346,32 @@ func (c *Client) RenterFilesFilteredGet(filter string) (rf api.RenterFiles, err
 	return
 }
 
+// RenterLostFilesGet requests the /renter/lostfiles resource.
+func (c *Client) RenterLostFilesGet() (rlf api.RenterLostFiles, err error) {
+	err = c.get("/renter/lostfiles", &rlf)
+	return
+}
+
 // RenterGet requests the /renter resource.
 func (c *Client) RenterGet() (rg api.RenterGET, err error) {
 	err = c.get("/renter", &rg)
 	return
 }
 
+// RenterMaintenancePausePost uses the /renter/maintenance/pause endpoint to
+// stop contract maintenance and repairs.
+func (c *Client) RenterMaintenancePausePost() (err error) {
+	err = c.post("/renter/maintenance/pause", "", nil)
+	return
+}
+
+// RenterMaintenanceResumePost uses the /renter/maintenance/resume endpoint to
+// resume contract maintenance and repairs.
+func (c *Client) RenterMaintenanceResumePost() (err error) {
+	err = c.post("/renter/maintenance/resume", "", nil)
+	return
+}
+
 // RenterPostAllowance uses the /renter endpoint to change the renter's allowance
 func (c *Client) RenterPostAllowance(allowance modules.Allowance) (err error) {
 	values := url.Values{}
@@ -182,6 +379,33 @@ func (c *Client) RenterPostAllowance(allowance modules.Allowance) (err error) {
 	values.Set("hosts", fmt.Sprint(allowance.Hosts))
 	values.Set("period", fmt.Sprint(uint64(allowance.Period)))
 	values.Set("renewwindow", fmt.Sprint(uint64(allowance.RenewWindow)))
+	values.Set("minhostsperchunk", fmt.Sprint(allowance.MinHostsPerChunk))
+	values.Set("preferrenewal", fmt.Sprint(allowance.PreferRenewal))
+	values.Set("renewalbias", fmt.Sprint(allowance.RenewalBias))
+	values.Set("refreshthreshold", fmt.Sprint(allowance.RefreshThreshold))
+	values.Set("diversifyversions", fmt.Sprint(allowance.DiversifyVersions))
+	values.Set("maxfundspercontract", allowance.MaxFundsPerContract.String())
+	values.Set("allowdegradedupload", fmt.Sprint(allowance.AllowDegradedUpload))
+	values.Set("degradeduploadminhosts", fmt.Sprint(allowance.DegradedUploadMinHosts))
+	pps := allowance.PiecePlacementStrategy
+	if pps == "" {
+		pps = "default"
+	}
+	values.Set("pieceplacementstrategy", pps)
+	values.Set("contractsperhost", fmt.Sprint(allowance.ContractsPerHost))
+	values.Set("integrityscaninterval", fmt.Sprint(uint64(allowance.IntegrityScanInterval)))
+	values.Set("autotopup", fmt.Sprint(allowance.AutoTopUp))
+	values.Set("topupamount", allowance.TopUpAmount.String())
+	values.Set("topupmaxfunds", allowance.TopUpMaxFunds.String())
+	values.Set("topupthreshold", allowance.TopUpThreshold.String())
+	values.Set("partialfunding", fmt.Sprint(allowance.PartialFunding))
+	values.Set("endheightalignment", fmt.Sprint(uint64(allowance.EndHeightAlignment)))
+	values.Set("renewstagger", fmt.Sprint(uint64(allowance.RenewStagger)))
+	if allowance.Arbiter != nil {
+		values.Set("arbiter", allowance.Arbiter.String())
+	} else {
+		values.Set("arbiter", "")
+	}
 	err = c.post("/renter", values.Encode(), nil)
 	return
 }
@@ -198,6 +422,81 @@ func (c *Client) RenterPricesGet() (rpg api.RenterPricesGET, err error) {
 	return
 }
 
+// RenterRepairEstimateGet requests the /renter/repairestimate resource.
+func (c *Client) RenterRepairEstimateGet() (rre api.RenterRepairEstimateGET, err error) {
+	err = c.get("/renter/repairestimate", &rre)
+	return
+}
+
+// RenterSpeedTestPost uses the /renter/speedtest endpoint to download up to
+// 'sectors' sectors from every host with an active contract.
+func (c *Client) RenterSpeedTestPost(sectors uint64) (rstp api.RenterSpeedTestPOST, err error) {
+	values := url.Values{}
+	values.Set("sectors", fmt.Sprint(sectors))
+	err = c.post("/renter/speedtest", values.Encode(), &rstp)
+	return
+}
+
+// RenterThroughputHistoryGet requests the /renter/throughputhistory resource.
+func (c *Client) RenterThroughputHistoryGet() (rthg api.RenterThroughputHistoryGET, err error) {
+	err = c.get("/renter/throughputhistory", &rthg)
+	return
+}
+
+// RenterRenewalScheduleGet requests the /renter/renewalschedule resource,
+// counting the contracts that are due for renewal within the next within
+// blocks.
+func (c *Client) RenterRenewalScheduleGet(within types.BlockHeight) (rrsg api.RenterRenewalScheduleGET, err error) {
+	err = c.get(fmt.Sprintf("/renter/renewalschedule?within=%v", within), &rrsg)
+	return
+}
+
+// RenterTrialGet requests the /renter/trial resource.
+func (c *Client) RenterTrialGet() (rtg api.RenterTrialGET, err error) {
+	err = c.get("/renter/trial", &rtg)
+	return
+}
+
+// RenterTrialPost uses the /renter/trial endpoint to start a trial with the
+// provided funds.
+func (c *Client) RenterTrialPost(funds types.Currency) (err error) {
+	values := url.Values{}
+	values.Set("funds", funds.String())
+	err = c.post("/renter/trial", values.Encode(), nil)
+	return
+}
+
+// RenterTrialTestPost uses the /renter/trial/test endpoint to upload a test
+// file to the hosts of the trial and download it again.
+func (c *Client) RenterTrialTestPost() (rttp api.RenterTrialTestPOST, err error) {
+	err = c.post("/renter/trial/test", "", &rttp)
+	return
+}
+
+// RenterWorkersGet requests the /renter/workers resource.
+func (c *Client) RenterWorkersGet() (rwg api.RenterWorkersGET, err error) {
+	err = c.get("/renter/workers", &rwg)
+	return
+}
+
+// RenterWorkersDetailedGet requests the /renter/workers/detailed resource.
+func (c *Client) RenterWorkersDetailedGet() (rwdg api.RenterWorkersDetailedGET, err error) {
+	err = c.get("/renter/workers/detailed", &rwdg)
+	return
+}
+
+// RenterAllowanceRecommendGet requests the /renter/allowance/recommend
+// resource to estimate the cheapest allowance that stores dataSize bytes at
+// the given redundancy for period blocks.
+func (c *Client) RenterAllowanceRecommendGet(dataSize uint64, redundancy float64, period types.BlockHeight) (rarg api.RenterAllowanceRecommendGET, err error) {
+	values := url.Values{}
+	values.Set("datasize", fmt.Sprint(dataSize))
+	values.Set("redundancy", fmt.Sprint(redundancy))
+	values.Set("period", fmt.Sprint(period))
+	err = c.get("/renter/allowance/recommend?"+values.Encode(), &rarg)
+	return
+}
+
 // RenterPostRateLimit uses the /renter endpoint to change the renter's bandwidth rate
 // limit.
 func (c *Client) RenterPostRateLimit(readBPS, writeBPS int64) (err error) {
@@ -208,6 +507,177 @@ func (c *Client) RenterPostRateLimit(readBPS, writeBPS int64) (err error) {
 	return
 }
 
+// RenterFileRebuildPost uses the /renter/rebuild/:hyperspacepath endpoint to
+// repair every chunk of a file to full redundancy.
+func (c *Client) RenterFileRebuildPost(siaPath string) (err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	err = c.post(fmt.Sprintf("/renter/rebuild/%s", siaPath), "", nil)
+	return
+}
+
+// RenterFileRebuildCancelPost uses the /renter/rebuild/:hyperspacepath
+// endpoint to cancel an active rebuild of a file.
+func (c *Client) RenterFileRebuildCancelPost(siaPath string) (err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	values := url.Values{}
+	values.Set("cancel", "true")
+	err = c.post(fmt.Sprintf("/renter/rebuild/%s", siaPath), values.Encode(), nil)
+	return
+}
+
+// RenterFileEffectiveRedundancyGet uses the
+// /renter/effectiveredundancy/:hyperspacepath endpoint to get the redundancy of
+// a file after collapsing the hosts that share an address range.
+func (c *Client) RenterFileEffectiveRedundancyGet(siaPath string) (rer api.RenterEffectiveRedundancyGET, err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	err = c.get(fmt.Sprintf("/renter/effectiveredundancy/%s", siaPath), &rer)
+	return
+}
+
+// RenterFileHistoryGet uses the /renter/history/:hyperspacepath endpoint to get
+// the complete repair history of a file.
+func (c *Client) RenterFileHistoryGet(siaPath string) (rfh api.RenterFileHistoryGET, err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	err = c.get(fmt.Sprintf("/renter/history/%s", siaPath), &rfh)
+	return
+}
+
+// RenterFileHistoryRangeGet uses the /renter/history/:hyperspacepath endpoint
+// to get the repair history of a file between two points in time.
+func (c *Client) RenterFileHistoryRangeGet(siaPath string, after, before time.Time) (rfh api.RenterFileHistoryGET, err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	values := url.Values{}
+	values.Set("after", strconv.FormatInt(after.UnixNano(), 10))
+	values.Set("before", strconv.FormatInt(before.UnixNano(), 10))
+	err = c.get(fmt.Sprintf("/renter/history/%s?%s", siaPath, values.Encode()), &rfh)
+	return
+}
+
+// RenterFileRebuildGet uses the /renter/rebuild/:hyperspacepath endpoint to
+// get the progress of the most recent rebuild of a file.
+func (c *Client) RenterFileRebuildGet(siaPath string) (rrg api.RenterRebuildGET, err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	err = c.get(fmt.Sprintf("/renter/rebuild/%s", siaPath), &rrg)
+	return
+}
+
+// RenterFileRekeyPost uses the /renter/rekey/:hyperspacepath endpoint to
+// re-encrypt a file under a new key.
+func (c *Client) RenterFileRekeyPost(siaPath string) (err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	err = c.post(fmt.Sprintf("/renter/rekey/%s", siaPath), "", nil)
+	return
+}
+
+// RenterFileRekeyCancelPost uses the /renter/rekey/:hyperspacepath endpoint
+// to stop re-encrypting a file.
+func (c *Client) RenterFileRekeyCancelPost(siaPath string) (err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	values := url.Values{}
+	values.Set("cancel", "true")
+	err = c.post(fmt.Sprintf("/renter/rekey/%s", siaPath), values.Encode(), nil)
+	return
+}
+
+// RenterErasureSchemeInfoGet requests the /renter/erasurescheme resource to
+// preview the overhead and fault tolerance of an erasure code.
+func (c *Client) RenterErasureSchemeInfoGet(dataPieces, parityPieces int) (resg api.RenterErasureSchemeGET, err error) {
+	values := url.Values{}
+	values.Set("datapieces", strconv.Itoa(dataPieces))
+	values.Set("paritypieces", strconv.Itoa(parityPieces))
+	err = c.get("/renter/erasurescheme?"+values.Encode(), &resg)
+	return
+}
+
+// RenterFileSetRedundancyPost uses the /renter/redundancy/:hyperspacepath
+// endpoint to change the erasure code parameters of an existing file.
+func (c *Client) RenterFileSetRedundancyPost(siaPath string, dataPieces, parityPieces uint64) (err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	err = c.post(fmt.Sprintf("/renter/redundancy/%s", siaPath), values.Encode(), nil)
+	return
+}
+
+// RenterRepairBudgetPost uses the /renter/budget/:hyperspacepath endpoint to
+// set the monthly repair budget of a file or directory. A zero budget removes
+// the budget.
+func (c *Client) RenterRepairBudgetPost(siaPath string, monthly types.Currency) (err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	values := url.Values{}
+	values.Set("monthly", monthly.String())
+	err = c.post(fmt.Sprintf("/renter/budget/%s", siaPath), values.Encode(), nil)
+	return
+}
+
+// RenterRepairBudgetsGet requests the /renter/budgets resource.
+func (c *Client) RenterRepairBudgetsGet() (rrbg api.RenterRepairBudgetsGET, err error) {
+	err = c.get("/renter/budgets", &rrbg)
+	return
+}
+
+// RenterAlertsGet requests the /renter/alerts resource.
+func (c *Client) RenterAlertsGet() (rag api.RenterAlertsGET, err error) {
+	err = c.get("/renter/alerts", &rag)
+	return
+}
+
+// RenterRecoveryHintSyncPost uses the /renter/recoveryhint/sync endpoint to
+// store a recovery hint on several hosts.
+func (c *Client) RenterRecoveryHintSyncPost() (rrhsp api.RenterRecoveryHintSyncPOST, err error) {
+	err = c.post("/renter/recoveryhint/sync", "", &rrhsp)
+	return
+}
+
+// RenterRecoveryHintRestorePost uses the /renter/recoveryhint/restore
+// endpoint to restore the recovery hint of a seed.
+func (c *Client) RenterRecoveryHintRestorePost(seed string) (rrhrp api.RenterRecoveryHintRestorePOST, err error) {
+	values := url.Values{}
+	values.Set("seed", seed)
+	err = c.post("/renter/recoveryhint/restore", values.Encode(), &rrhrp)
+	return
+}
+
+// RenterRecoverableContractsGet uses the /renter/recoverablecontracts endpoint
+// to list the contracts that can be recovered from the recovery hint.
+func (c *Client) RenterRecoverableContractsGet() (rrcg api.RenterRecoverableContractsGET, err error) {
+	err = c.get("/renter/recoverablecontracts", &rrcg)
+	return
+}
+
+// RenterRecoverContractsPost uses the /renter/recoverablecontracts/recover
+// endpoint to add the recoverable contracts to the contract set.
+func (c *Client) RenterRecoverContractsPost() (rrcp api.RenterRecoverContractsPOST, err error) {
+	err = c.post("/renter/recoverablecontracts/recover", "", &rrcp)
+	return
+}
+
+// RenterManifestGet uses the /renter/manifest/:hyperspacepath endpoint to
+// export the signed manifest of a file.
+func (c *Client) RenterManifestGet(siaPath string) (rmg api.RenterManifestGET, err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	err = c.get(fmt.Sprintf("/renter/manifest/%s", siaPath), &rmg)
+	return
+}
+
+// RenterVerifyManifestPost uses the /renter/verifymanifest endpoint to spot
+// check a file against a manifest. If samples is 0 the renter's default is
+// used.
+func (c *Client) RenterVerifyManifestPost(manifest modules.RenterFileManifest, samples uint64) (rvmp api.RenterVerifyManifestPOST, err error) {
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		return
+	}
+	values := url.Values{}
+	values.Set("manifest", string(b))
+	if samples != 0 {
+		values.Set("samples", strconv.FormatUint(samples, 10))
+	}
+	err = c.post("/renter/verifymanifest", values.Encode(), &rvmp)
+	return
+}
+
 // RenterRenamePost uses the /renter/rename/:hyperspacepath endpoint to rename a file.
 func (c *Client) RenterRenamePost(siaPathOld, siaPathNew string) (err error) {
 	siaPathOld = escapeSiaPath(trimSiaPath(siaPathOld))
@@ -217,6 +687,77 @@ func (c *Client) RenterRenamePost(siaPathOld, siaPathNew string) (err error) {
 	return
 }
 
+// RenterSetCostOptimizedDownloadsPost uses the /renter endpoint to choose
+// whether downloads should prefer cheap hosts over fast ones.
+func (c *Client) RenterSetCostOptimizedDownloadsPost(costOptimized bool) (err error) {
+	values := url.Values{}
+	values.Set("costoptimizeddownloads", fmt.Sprint(costOptimized))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterAuditLogGet requests the /renter/auditlog resource. A zero start or
+// end time and an empty list of categories don't filter the entries.
+func (c *Client) RenterAuditLogGet(start, end time.Time, categories ...string) (ralg api.RenterAuditLogGET, err error) {
+	values := url.Values{}
+	if !start.IsZero() {
+		values.Set("start", fmt.Sprint(start.Unix()))
+	}
+	if !end.IsZero() {
+		values.Set("end", fmt.Sprint(end.Unix()))
+	}
+	if len(categories) > 0 {
+		values.Set("category", strings.Join(categories, ","))
+	}
+	err = c.get("/renter/auditlog?"+values.Encode(), &ralg)
+	return
+}
+
+// RenterAuditLogExportPost uses the /renter/auditlog/export endpoint to write
+// the audit log as CSV to dst.
+func (c *Client) RenterAuditLogExportPost(dst string) (err error) {
+	values := url.Values{}
+	values.Set("destination", dst)
+	err = c.post("/renter/auditlog/export", values.Encode(), nil)
+	return
+}
+
+// RenterWebhooksGet requests the /renter/webhooks resource.
+func (c *Client) RenterWebhooksGet() (rwg api.RenterWebhooksGET, err error) {
+	err = c.get("/renter/webhooks", &rwg)
+	return
+}
+
+// RenterWebhooksPost uses the /renter/webhooks endpoint to register a webhook
+// for the given contract events. If no events are given, the webhook receives
+// all of them.
+func (c *Client) RenterWebhooksPost(hookURL, secret string, events ...string) (err error) {
+	values := url.Values{}
+	values.Set("url", hookURL)
+	values.Set("secret", secret)
+	values.Set("events", strings.Join(events, ","))
+	err = c.post("/renter/webhooks", values.Encode(), nil)
+	return
+}
+
+// RenterWebhooksRemovePost uses the /renter/webhooks/remove endpoint to remove
+// a webhook.
+func (c *Client) RenterWebhooksRemovePost(hookURL string) (err error) {
+	values := url.Values{}
+	values.Set("url", hookURL)
+	err = c.post("/renter/webhooks/remove", values.Encode(), nil)
+	return
+}
+
+// RenterSetMaxConcurrentRepairsPost uses the /renter endpoint to change the
+// maximum number of chunks the renter repairs at the same time.
+func (c *Client) RenterSetMaxConcurrentRepairsPost(maxRepairs uint64) (err error) {
+	values := url.Values{}
+	values.Set("maxconcurrentrepairs", fmt.Sprint(maxRepairs))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
 // RenterSetStreamCacheSizePost uses the /renter endpoint to change the renter's
 // streamCacheSize for streaming
 func (c *Client) RenterSetStreamCacheSizePost(cacheSize uint64) (err error) {
@@ -251,6 +792,32 @@ func (c *Client) RenterSetRepairPathPost(siaPath, newPath string) (err error) {
 	return
 }
 
+// RenterSetSpareHostsPost uses the /renter/file endpoint to set the number of
+// spare hosts that store additional copies of the pieces of a file.
+func (c *Client) RenterSetSpareHostsPost(siaPath string, spareHosts uint64) (err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	values := url.Values{}
+	values.Set("sparehosts", strconv.FormatUint(spareHosts, 10))
+	err = c.post("/renter/file/"+siaPath, values.Encode(), nil)
+	return
+}
+
+// RenterSetRedundancyGroupPost uses the /renter/file endpoint to add a file to
+// a redundancy group. An empty group removes the file from its group.
+func (c *Client) RenterSetRedundancyGroupPost(siaPath, group string) (err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	values := url.Values{}
+	values.Set("redundancygroup", group)
+	err = c.post("/renter/file/"+siaPath, values.Encode(), nil)
+	return
+}
+
+// RenterRedundancyGroupsGet requests the /renter/redundancygroups resource.
+func (c *Client) RenterRedundancyGroupsGet() (rrgg api.RenterRedundancyGroupsGET, err error) {
+	err = c.get("/renter/redundancygroups", &rrgg)
+	return
+}
+
 // RenterUploadPost uses the /renter/upload endpoint to upload a file
 func (c *Client) RenterUploadPost(path, siaPath string, dataPieces, parityPieces uint64) (err error) {
 	siaPath = escapeSiaPath(trimSiaPath(siaPath))
@@ -272,6 +839,112 @@ func (c *Client) RenterUploadDefaultPost(path, siaPath string) (err error) {
 	return
 }
 
+// RenterUploadDedupPost uses the /renter/upload endpoint to upload a file
+// whose chunks are deduplicated with the chunks of other files.
+func (c *Client) RenterUploadDedupPost(path, siaPath string, dataPieces, parityPieces uint64) (err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	values := url.Values{}
+	values.Set("source", path)
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("dedup", "true")
+	err = c.post(fmt.Sprintf("/renter/upload/%s", siaPath), values.Encode(), nil)
+	return
+}
+
+// RenterLocalBackupGet requests the /renter/localbackup resource.
+func (c *Client) RenterLocalBackupGet() (rlbg api.RenterLocalBackupGET, err error) {
+	err = c.get("/renter/localbackup", &rlbg)
+	return
+}
+
+// RenterLocalBackupConfigPost uses the /renter/localbackup endpoint to store
+// the count most recent snapshots of the file metadata in the directory at
+// path, taking a snapshot every interval. A zero interval disables the
+// snapshots.
+func (c *Client) RenterLocalBackupConfigPost(path string, count uint64, interval time.Duration) (err error) {
+	values := url.Values{}
+	values.Set("path", path)
+	values.Set("count", strconv.FormatUint(count, 10))
+	values.Set("interval", strconv.FormatUint(uint64(interval/time.Second), 10))
+	err = c.post("/renter/localbackup", values.Encode(), nil)
+	return
+}
+
+// RenterLocalBackupRestorePost uses the /renter/localbackup/restore endpoint
+// to restore the file metadata of a local snapshot.
+func (c *Client) RenterLocalBackupRestorePost(snapshotName string) (rlbrp api.RenterLocalBackupRestorePOST, err error) {
+	values := url.Values{}
+	values.Set("name", snapshotName)
+	err = c.post("/renter/localbackup/restore", values.Encode(), &rlbrp)
+	return
+}
+
+// RenterUploadSchedulePost uses the /renter/uploadschedule endpoint to upload
+// a file with default redundancy settings once startTime is reached.
+func (c *Client) RenterUploadSchedulePost(siaPath, path string, startTime time.Time) (rsup api.RenterScheduledUploadPOST, err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	values := url.Values{}
+	values.Set("source", path)
+	values.Set("starttime", fmt.Sprint(startTime.Unix()))
+	err = c.post(fmt.Sprintf("/renter/uploadschedule/%s", siaPath), values.Encode(), &rsup)
+	return
+}
+
+// RenterUploadStreamGet uses the /renter/uploadstream endpoint to get the
+// number of bytes received for an incomplete streamed upload.
+func (c *Client) RenterUploadStreamGet(siaPath string) (rus api.RenterUploadStreamGET, err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	err = c.get(fmt.Sprintf("/renter/uploadstream/%s", siaPath), &rus)
+	return
+}
+
+// RenterUploadStreamPost uses the /renter/uploadstream endpoint to append data
+// to a streamed upload, starting at offset. If complete is true, the upload
+// starts once all of the data was received.
+func (c *Client) RenterUploadStreamPost(siaPath string, offset uint64, data io.Reader, complete bool) (rus api.RenterUploadStreamGET, err error) {
+	siaPath = escapeSiaPath(trimSiaPath(siaPath))
+	values := url.Values{}
+	values.Set("offset", fmt.Sprint(offset))
+	values.Set("complete", fmt.Sprint(complete))
+	err = c.postReader(fmt.Sprintf("/renter/uploadstream/%s?%s", siaPath, values.Encode()), data, &rus)
+	return
+}
+
+// RenterUploadBatchPost uses the /renter/uploadbatch endpoint to upload a
+// batch of small files packed into shared chunks.
+func (c *Client) RenterUploadBatchPost(files []modules.RenterBatchFile) (rubp api.RenterUploadBatchPOST, err error) {
+	b, err := json.Marshal(files)
+	if err != nil {
+		return
+	}
+	values := url.Values{}
+	values.Set("files", string(b))
+	err = c.post("/renter/uploadbatch", values.Encode(), &rubp)
+	return
+}
+
+// RenterUploadBatchesGet requests the /renter/uploadbatches resource.
+func (c *Client) RenterUploadBatchesGet() (rubg api.RenterUploadBatchesGET, err error) {
+	err = c.get("/renter/uploadbatches", &rubg)
+	return
+}
+
+// RenterScheduledUploadsGet requests the /renter/scheduleduploads resource.
+func (c *Client) RenterScheduledUploadsGet() (rsug api.RenterScheduledUploadsGET, err error) {
+	err = c.get("/renter/scheduleduploads", &rsug)
+	return
+}
+
+// RenterScheduledUploadCancelPost uses the /renter/scheduleduploads/cancel
+// endpoint to cancel a scheduled upload that hasn't started yet.
+func (c *Client) RenterScheduledUploadCancelPost(id string) (err error) {
+	values := url.Values{}
+	values.Set("id", id)
+	err = c.post("/renter/scheduleduploads/cancel", values.Encode(), nil)
+	return
+}
+
 // RenterDirCreatePost uses the /renter/dir/ endpoint to create a directory for the
 // renter
 func (c *Client) RenterDirCreatePost(siaPath string) (err error) {
@@ -280,6 +953,26 @@ func (c *Client) RenterDirCreatePost(siaPath string) (err error) {
 	return
 }
 
+// RenterDirGet uses the /renter/dir/ endpoint to describe a directory of the
+// renter
+func (c *Client) RenterDirGet(siaPath string) (rd api.RenterDirectoryGET, err error) {
+	siaPath = strings.TrimPrefix(siaPath, "/")
+	err = c.get(fmt.Sprintf("/renter/dir/%s", siaPath), &rd)
+	return
+}
+
+// RenterDirRedundancyPost uses the /renter/dir/ endpoint to set the erasure
+// code of new uploads into a directory of the renter
+func (c *Client) RenterDirRedundancyPost(siaPath string, dataPieces, parityPieces uint64) (err error) {
+	siaPath = strings.TrimPrefix(siaPath, "/")
+	values := url.Values{}
+	values.Set("action", "redundancy")
+	values.Set("datapieces", fmt.Sprint(dataPieces))
+	values.Set("paritypieces", fmt.Sprint(parityPieces))
+	err = c.post(fmt.Sprintf("/renter/dir/%s", siaPath), values.Encode(), nil)
+	return
+}
+
 // RenterDirDeletePost uses the /renter/dir/ endpoint to delete a directory for the
 // renter
 func (c *Client) RenterDirDeletePost(siaPath string) (err error) {
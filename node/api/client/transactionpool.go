@@ -23,3 +23,13 @@ func (c *Client) TransactionPoolRawPost(txn types.Transaction, parents []types.T
 	err = c.post("/tpool/raw", values.Encode(), nil)
 	return
 }
+
+// TransactionPoolValidatePost uses the /tpool/validate endpoint to check a
+// transaction against the transaction pool without broadcasting it.
+func (c *Client) TransactionPoolValidatePost(txn types.Transaction, parents []types.Transaction) (tvp api.TpoolValidatePOST, err error) {
+	values := url.Values{}
+	values.Set("transaction", string(encoding.Marshal(txn)))
+	values.Set("parents", string(encoding.Marshal(parents)))
+	err = c.post("/tpool/validate", values.Encode(), &tvp)
+	return
+}
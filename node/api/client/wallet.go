@@ -114,6 +114,44 @@ func (c *Client) WalletSiacoinsPost(amount types.Currency, destination types.Unl
 	return
 }
 
+// WalletSiacoinsTimelockedPost uses the /wallet/spacecash/timelocked api
+// endpoint to send money to an address of the wallet that can't be spent until
+// the blockchain reaches unlockHeight.
+func (c *Client) WalletSiacoinsTimelockedPost(destination types.UnlockHash, amount types.Currency, unlockHeight types.BlockHeight) (wsp api.WalletSiacoinsPOST, err error) {
+	values := url.Values{}
+	values.Set("amount", amount.String())
+	values.Set("destination", destination.String())
+	values.Set("unlockheight", fmt.Sprint(unlockHeight))
+	err = c.post("/wallet/spacecash/timelocked", values.Encode(), &wsp)
+	return
+}
+
+// WalletSiacoinsTimelockedUnlockConditionsPost uses the
+// /wallet/spacecash/timelocked api endpoint to send money to the address with
+// the unlock conditions uc, which can belong to any wallet.
+func (c *Client) WalletSiacoinsTimelockedUnlockConditionsPost(uc types.UnlockConditions, amount types.Currency, unlockHeight types.BlockHeight) (wsp api.WalletSiacoinsPOST, err error) {
+	ucJSON, err := json.Marshal(uc)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	values := url.Values{}
+	values.Set("amount", amount.String())
+	values.Set("unlockconditions", string(ucJSON))
+	values.Set("unlockheight", fmt.Sprint(unlockHeight))
+	err = c.post("/wallet/spacecash/timelocked", values.Encode(), &wsp)
+	return
+}
+
+// WalletTimelockedAddressPost uses the /wallet/address/timelocked api endpoint
+// to create an address of the wallet that is locked until the blockchain
+// reaches unlockHeight.
+func (c *Client) WalletTimelockedAddressPost(unlockHeight types.BlockHeight) (wtap api.WalletTimelockedAddressPOST, err error) {
+	values := url.Values{}
+	values.Set("unlockheight", fmt.Sprint(unlockHeight))
+	err = c.post("/wallet/address/timelocked", values.Encode(), &wtap)
+	return
+}
+
 // WalletSignPost uses the /wallet/sign api endpoint to sign a transaction.
 func (c *Client) WalletSignPost(txn types.Transaction, toSign []crypto.Hash) (wspr api.WalletSignPOSTResp, err error) {
 	json, err := json.Marshal(api.WalletSignPOSTParams{
@@ -127,6 +165,16 @@ func (c *Client) WalletSignPost(txn types.Transaction, toSign []crypto.Hash) (ws
 	return
 }
 
+// WalletSignMessagePost uses the /wallet/sign/message endpoint to sign a
+// message with the key of a wallet address.
+func (c *Client) WalletSignMessagePost(addr types.UnlockHash, message string) (wsmp api.WalletSignMessagePOST, err error) {
+	values := url.Values{}
+	values.Set("address", addr.String())
+	values.Set("message", message)
+	err = c.post("/wallet/sign/message", values.Encode(), &wsmp)
+	return
+}
+
 // WalletSiagKeyPost uses the /wallet/siagkey endpoint to load a siag key into
 // the wallet.
 func (c *Client) WalletSiagKeyPost(keyfiles, password string) (err error) {
@@ -162,6 +210,23 @@ func (c *Client) WalletBuildTransactionGet(destination types.UnlockHash, amount
 	return
 }
 
+// WalletFeeEstimateGet requests the /wallet/feeestimate api resource to
+// estimate the fee of sending amount to each of the recipients.
+func (c *Client) WalletFeeEstimateGet(amount types.Currency, recipients []types.UnlockHash) (wfeg api.WalletFeeEstimateGET, err error) {
+	outputs := make([]types.SiacoinOutput, 0, len(recipients))
+	for _, recipient := range recipients {
+		outputs = append(outputs, types.SiacoinOutput{Value: amount, UnlockHash: recipient})
+	}
+	marshaledOutputs, err := json.Marshal(outputs)
+	if err != nil {
+		return api.WalletFeeEstimateGET{}, err
+	}
+	values := url.Values{}
+	values.Set("outputs", string(marshaledOutputs))
+	err = c.get("/wallet/feeestimate?"+values.Encode(), &wfeg)
+	return
+}
+
 // WalletTransactionGet requests the /wallet/transaction/:id api resource for a
 // certain TransactionID.
 func (c *Client) WalletTransactionGet(id types.TransactionID) (wtg api.WalletTransactionGETid, err error) {
@@ -185,6 +250,17 @@ func (c *Client) WalletUnlockConditionsGet(addr types.UnlockHash) (wucg api.Wall
 	return
 }
 
+// WalletVerifyMessageGet uses the /wallet/verify/message endpoint to check
+// whether a message was signed by the key of an address.
+func (c *Client) WalletVerifyMessageGet(addr types.UnlockHash, message, signature string) (wvmg api.WalletVerifyMessageGET, err error) {
+	values := url.Values{}
+	values.Set("address", addr.String())
+	values.Set("message", message)
+	values.Set("signature", signature)
+	err = c.get("/wallet/verify/message?"+values.Encode(), &wvmg)
+	return
+}
+
 // WalletUnspentGet requests the /wallet/unspent endpoint and returns all of
 // the unspent outputs related to the wallet.
 func (c *Client) WalletUnspentGet() (wug api.WalletUnspentGET, err error) {
@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/HyperspaceApp/Hyperspace/crypto"
@@ -29,6 +30,24 @@ type ConsensusHeadersGET struct {
 	BlockID types.BlockID `json:"blockid"`
 }
 
+const (
+	// defaultUTXOPageSize is the number of unspent outputs returned by
+	// /consensus/utxos if no limit is provided.
+	defaultUTXOPageSize = 1000
+
+	// maxUTXOPageSize is the maximum number of unspent outputs returned by a
+	// single call to /consensus/utxos.
+	maxUTXOPageSize = 10000
+)
+
+// ConsensusUTXOsGET contains a page of the unspent siacoin outputs of the
+// consensus set. If More is true, the next page starts after the last output
+// of this page.
+type ConsensusUTXOsGET struct {
+	SiacoinOutputs []modules.UnspentSiacoinOutput `json:"siacoinoutputs"`
+	More           bool                           `json:"more"`
+}
+
 // ConsensusFileContract contains information about a file contract
 type ConsensusFileContract struct {
 	FileSize           uint64                         `json:"filesize"`
@@ -259,6 +278,55 @@ func (api *API) consensusBlocksHandler(w http.ResponseWriter, req *http.Request,
 	WriteJSON(w, consensusBlocksGetFromBlock(b, h))
 }
 
+// consensusUTXOsHandler handles the API calls to /consensus/utxos. The outputs
+// are written to the response one by one, so that a full page doesn't need to
+// be encoded in memory at once.
+func (api *API) consensusUTXOsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var after types.SiacoinOutputID
+	if a := req.FormValue("after"); a != "" {
+		if err := (*crypto.Hash)(&after).LoadString(a); err != nil {
+			WriteError(w, Error{"unable to parse after: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	limit := defaultUTXOPageSize
+	if l := req.FormValue("limit"); l != "" {
+		if _, err := fmt.Sscan(l, &limit); err != nil {
+			WriteError(w, Error{"unable to parse limit: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if limit <= 0 || limit > maxUTXOPageSize {
+			WriteError(w, Error{fmt.Sprintf("limit must be between 1 and %v", maxUTXOPageSize)}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Request one more output than needed to find out whether there is
+	// another page.
+	outputs, err := api.cs.SiacoinOutputs(req.FormValue("addressprefix"), after, limit+1)
+	if err != nil {
+		WriteError(w, Error{"unable to get unspent outputs: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	more := len(outputs) > limit
+	if more {
+		outputs = outputs[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(w)
+	io.WriteString(w, `{"siacoinoutputs":[`)
+	for i, sco := range outputs {
+		if i > 0 {
+			io.WriteString(w, ",")
+		}
+		if err := enc.Encode(sco); err != nil {
+			return
+		}
+	}
+	fmt.Fprintf(w, `],"more":%v}`, more)
+}
+
 // consensusValidateTransactionsetHandler handles the API calls to
 // /consensus/validate/transactionset.
 func (api *API) consensusValidateTransactionsetHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -7,6 +7,13 @@ type DaemonVersionGet struct {
 	BuildTime   string
 }
 
+// DaemonReloadPOST contains the settings that were applied by reloading the
+// config file and the settings that only take effect after a restart.
+type DaemonReloadPOST struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restartrequired"`
+}
+
 // DaemonUpdateGet contains information about a potential available update for
 // the daemon.
 type DaemonUpdateGet struct {
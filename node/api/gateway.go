@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/HyperspaceApp/Hyperspace/modules"
 
@@ -10,8 +11,14 @@ import (
 
 // GatewayGET contains the fields returned by a GET call to "/gateway".
 type GatewayGET struct {
-	NetAddress modules.NetAddress `json:"netaddress"`
-	Peers      []modules.Peer     `json:"peers"`
+	AcceptRelayed    bool                          `json:"acceptrelayed"`
+	MinPeerVersion   string                        `json:"minpeerversion"`
+	NetAddress       modules.NetAddress            `json:"netaddress"`
+	PeerLimits       modules.GatewayPeerLimits     `json:"peerlimits"`
+	Peers            []modules.Peer                `json:"peers"`
+	RejectedPeers    modules.GatewayRejectionStats `json:"rejectedpeers"`
+	Relay            modules.GatewayRelayStats     `json:"relay"`
+	RelayConnections bool                          `json:"relayconnections"`
 }
 
 // gatewayHandler handles the API call asking for the gatway status.
@@ -23,13 +30,99 @@ func (api *API) gatewayHandler(w http.ResponseWriter, req *http.Request, _ httpr
 	if peers == nil {
 		peers = make([]modules.Peer, 0)
 	}
-	WriteJSON(w, GatewayGET{api.gateway.Address(), peers})
+	WriteJSON(w, GatewayGET{
+		AcceptRelayed:    api.gateway.AcceptRelayed(),
+		MinPeerVersion:   api.gateway.MinPeerVersion(),
+		NetAddress:       api.gateway.Address(),
+		PeerLimits:       api.gateway.PeerLimits(),
+		Peers:            peers,
+		RejectedPeers:    api.gateway.RejectionStats(),
+		Relay:            api.gateway.RelayStats(),
+		RelayConnections: api.gateway.RelayConnections(),
+	})
+}
+
+// gatewayHandlerPOST handles the API call to change the settings of the
+// gateway.
+func (api *API) gatewayHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// An empty minpeerversion resets the minimum to the oldest version that
+	// is compatible with the protocol.
+	version := req.FormValue("minpeerversion")
+	if _, ok := req.Form["minpeerversion"]; ok {
+		if err := api.gateway.SetMinPeerVersion(version); err != nil {
+			WriteError(w, Error{"unable to set the minimum peer version: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// A relayfanout of 0 relays objects to every peer.
+	if f := req.FormValue("relayfanout"); f != "" {
+		fanout, err := strconv.Atoi(f)
+		if err != nil {
+			WriteError(w, Error{"unable to parse relayfanout: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err := api.gateway.SetRelayFanout(fanout); err != nil {
+			WriteError(w, Error{"unable to set the relay fanout: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// The peer limits are set together because the outbound limit can't
+	// exceed the maximum. A limit of 0 resets it to the default.
+	maxPeers, maxOutbound := req.FormValue("maxpeers"), req.FormValue("maxoutboundpeers")
+	if maxPeers != "" || maxOutbound != "" {
+		limits := api.gateway.PeerLimits()
+		var err error
+		if maxPeers != "" {
+			if limits.MaxPeers, err = strconv.Atoi(maxPeers); err != nil {
+				WriteError(w, Error{"unable to parse maxpeers: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		if maxOutbound != "" {
+			if limits.MaxOutboundPeers, err = strconv.Atoi(maxOutbound); err != nil {
+				WriteError(w, Error{"unable to parse maxoutboundpeers: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		if err := api.gateway.SetPeerLimits(limits); err != nil {
+			WriteError(w, Error{"unable to set the peer limits: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if r := req.FormValue("relayconnections"); r != "" {
+		relay, err := strconv.ParseBool(r)
+		if err != nil {
+			WriteError(w, Error{"unable to parse relayconnections: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err := api.gateway.SetRelayConnections(relay); err != nil {
+			WriteError(w, Error{"unable to set relayconnections: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if a := req.FormValue("acceptrelayed"); a != "" {
+		accept, err := strconv.ParseBool(a)
+		if err != nil {
+			WriteError(w, Error{"unable to parse acceptrelayed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err := api.gateway.SetAcceptRelayed(accept); err != nil {
+			WriteError(w, Error{"unable to set acceptrelayed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	WriteSuccess(w)
 }
 
 // gatewayConnectHandler handles the API call to add a peer to the gateway.
 func (api *API) gatewayConnectHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	addr := modules.NetAddress(ps.ByName("netaddress"))
-	err := api.gateway.Connect(addr)
+	var err error
+	if relay := req.FormValue("relay"); relay != "" {
+		err = api.gateway.ConnectRelayed(addr, modules.NetAddress(relay))
+	} else {
+		err = api.gateway.Connect(addr)
+	}
 	if err != nil {
 		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
 		return
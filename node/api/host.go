@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/HyperspaceApp/Hyperspace/build"
 	"github.com/HyperspaceApp/Hyperspace/modules"
@@ -33,12 +35,21 @@ type (
 	// HostGET contains the information that is returned after a GET request to
 	// /host - a bunch of information about the status of the host.
 	HostGET struct {
+		CapacityMetrics      modules.HostCapacityMetrics      `json:"capacitymetrics"`
 		ExternalSettings     modules.HostExternalSettings     `json:"externalsettings"`
 		FinancialMetrics     modules.HostFinancialMetrics     `json:"financialmetrics"`
 		InternalSettings     modules.HostInternalSettings     `json:"internalsettings"`
 		NetworkMetrics       modules.HostNetworkMetrics       `json:"networkmetrics"`
 		ConnectabilityStatus modules.HostConnectabilityStatus `json:"connectabilitystatus"`
 		WorkingStatus        modules.HostWorkingStatus        `json:"workingstatus"`
+		Draining             bool                             `json:"draining"`
+		RPCQueue             []modules.HostRPCQueueStatus     `json:"rpcqueue"`
+	}
+
+	// HostEarningsGET contains the revenue of the storage obligations and the
+	// storage proof attempts returned by a GET request to /host/earnings.
+	HostEarningsGET struct {
+		modules.HostEarnings
 	}
 
 	// HostEstimateScoreGET contains the information that is returned from a
@@ -48,12 +59,30 @@ type (
 		ConversionRate float64        `json:"conversionrate"`
 	}
 
+	// HostBenchmarkPOST contains the information that is returned after a
+	// POST request to /host/benchmark.
+	HostBenchmarkPOST struct {
+		modules.HostBenchmark
+	}
+
+	// HostPrunePOST contains the information that is returned after a POST
+	// request to /host/prune.
+	HostPrunePOST struct {
+		modules.HostPruneResult
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
 	StorageGET struct {
 		Folders []modules.StorageFolderMetadata `json:"folders"`
 	}
+
+	// StorageMigrateGET contains the progress of the most recent storage
+	// folder migration.
+	StorageMigrateGET struct {
+		modules.StorageFolderMigration
+	}
 )
 
 // folderIndex determines the index of the storage folder with the provided
@@ -85,13 +114,17 @@ func (api *API) hostHandlerGET(w http.ResponseWriter, req *http.Request, _ httpr
 	nm := api.host.NetworkMetrics()
 	cs := api.host.ConnectabilityStatus()
 	ws := api.host.WorkingStatus()
+	cm := api.host.CapacityMetrics()
 	hg := HostGET{
+		CapacityMetrics:      cm,
 		ExternalSettings:     es,
 		FinancialMetrics:     fm,
 		InternalSettings:     is,
 		NetworkMetrics:       nm,
 		ConnectabilityStatus: cs,
 		WorkingStatus:        ws,
+		Draining:             api.host.Draining(),
+		RPCQueue:             api.host.RPCQueue(),
 	}
 	WriteJSON(w, hg)
 }
@@ -150,6 +183,14 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 		}
 		settings.WindowSize = x
 	}
+	if req.FormValue("prunegraceperiod") != "" {
+		var x types.BlockHeight
+		_, err := fmt.Sscan(req.FormValue("prunegraceperiod"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PruneGracePeriod = x
+	}
 
 	if req.FormValue("collateral") != "" {
 		var x types.Currency
@@ -208,10 +249,104 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 		}
 		settings.MinUploadBandwidthPrice = x
 	}
+	if req.FormValue("maxconcurrentrpcs") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxconcurrentrpcs"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxConcurrentRPCs = x
+	}
+	if req.FormValue("maxconcurrentrpcsperrenter") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxconcurrentrpcsperrenter"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxConcurrentRPCsPerRenter = x
+	}
+	if req.FormValue("maxstoragepercontract") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxstoragepercontract"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxStoragePerContract = x
+	}
+	if _, ok := req.Form["rpcqueuepolicy"]; ok {
+		settings.RPCQueuePolicy = req.FormValue("rpcqueuepolicy")
+	}
+	if req.FormValue("renewaldiscount") != "" {
+		var x float64
+		_, err := fmt.Sscan(req.FormValue("renewaldiscount"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.RenewalDiscount = x
+	}
+
+	// The reserved storage is passed as a comma separated list of
+	// pubkey=bytes pairs. Passing an empty value clears all reservations.
+	if _, ok := req.Form["reservedstorage"]; ok {
+		x, err := parseReservedStorage(req.FormValue("reservedstorage"))
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.ReservedStorage = x
+	}
+
+	// The trusted arbiters are passed as a comma separated list of public
+	// keys. Passing an empty value removes all arbiters.
+	if _, ok := req.Form["trustedarbiters"]; ok {
+		x, err := parseTrustedArbiters(req.FormValue("trustedarbiters"))
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.TrustedArbiters = x
+	}
 
 	return settings, nil
 }
 
+// parseReservedStorage parses a comma separated list of pubkey=bytes pairs
+// into a map of reserved storage.
+func parseReservedStorage(s string) (map[string]uint64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	reservations := make(map[string]uint64)
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.Split(pair, "=")
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid reserved storage entry %q", pair)
+		}
+		var x uint64
+		_, err := fmt.Sscan(kv[1], &x)
+		if err != nil {
+			return nil, err
+		}
+		reservations[kv[0]] = x
+	}
+	return reservations, nil
+}
+
+// parseTrustedArbiters parses a comma separated list of public keys.
+func parseTrustedArbiters(s string) ([]types.SiaPublicKey, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var arbiters []types.SiaPublicKey
+	for _, key := range strings.Split(s, ",") {
+		var pk types.SiaPublicKey
+		pk.LoadString(key)
+		if len(pk.Key) == 0 {
+			return nil, fmt.Errorf("invalid arbiter key %q", key)
+		}
+		arbiters = append(arbiters, pk)
+	}
+	return arbiters, nil
+}
+
 // hostEstimateScoreGET handles the POST request to /host/estimatescore and
 // computes an estimated HostDB score for the provided settings.
 func (api *API) hostEstimateScoreGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -295,6 +430,102 @@ func (api *API) hostAnnounceHandler(w http.ResponseWriter, req *http.Request, _
 	WriteSuccess(w)
 }
 
+// hostBenchmarkHandler handles the API call to benchmark the disk and the
+// network of the host.
+func (api *API) hostBenchmarkHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	benchmark, err := api.host.Benchmark()
+	if err != nil {
+		WriteError(w, Error{"unable to benchmark the host: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostBenchmarkPOST{benchmark})
+}
+
+// hostDrainHandler handles the API call to put the host into drain mode.
+func (api *API) hostDrainHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if err := api.host.Drain(); err != nil {
+		WriteError(w, Error{"unable to drain host: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostUndrainHandler handles the API call to end the drain mode of the host.
+func (api *API) hostUndrainHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if err := api.host.Undrain(); err != nil {
+		WriteError(w, Error{"unable to undrain host: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// scanEarningsRange parses the optional start and end unix timestamps of a
+// request to /host/earnings.
+func scanEarningsRange(req *http.Request) (start, end time.Time, err error) {
+	if s := req.FormValue("start"); s != "" {
+		var unix int64
+		if _, err := fmt.Sscan(s, &unix); err != nil {
+			return time.Time{}, time.Time{}, errors.New("unable to parse start: " + err.Error())
+		}
+		start = time.Unix(unix, 0)
+	}
+	if e := req.FormValue("end"); e != "" {
+		var unix int64
+		if _, err := fmt.Sscan(e, &unix); err != nil {
+			return time.Time{}, time.Time{}, errors.New("unable to parse end: " + err.Error())
+		}
+		end = time.Unix(unix, 0)
+	}
+	return start, end, nil
+}
+
+// hostEarningsHandlerGET handles the API call to get the earnings and storage
+// proof history of the host.
+func (api *API) hostEarningsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	start, end, err := scanEarningsRange(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	earnings, err := api.host.Earnings(start, end)
+	if err != nil {
+		WriteError(w, Error{"unable to get the earnings of the host: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostEarningsGET{earnings})
+}
+
+// hostEarningsExportHandler handles the API call to export the earnings of
+// the host as CSV.
+func (api *API) hostEarningsExportHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	dst := req.FormValue("destination")
+	if dst == "" {
+		WriteError(w, Error{"destination must be specified"}, http.StatusBadRequest)
+		return
+	}
+	start, end, err := scanEarningsRange(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.host.ExportEarnings(dst, start, end); err != nil {
+		WriteError(w, Error{"unable to export the earnings of the host: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostPruneHandler handles the API call to remove the sectors of resolved
+// storage obligations whose grace period has passed.
+func (api *API) hostPruneHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	result, err := api.host.PruneStorageObligations()
+	if err != nil {
+		WriteError(w, Error{"unable to prune storage obligations: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostPrunePOST{result})
+}
+
 // storageHandler returns a bunch of information about storage management on
 // the host.
 func (api *API) storageHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -374,6 +605,48 @@ func (api *API) storageFoldersRemoveHandler(w http.ResponseWriter, req *http.Req
 	WriteSuccess(w)
 }
 
+// storageMigrateHandlerGET returns the progress of the most recent storage
+// folder migration.
+func (api *API) storageMigrateHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, StorageMigrateGET{
+		StorageFolderMigration: api.host.StorageFolderMigration(),
+	})
+}
+
+// storageMigrateHandlerPOST starts moving the sectors of a storage folder to
+// other storage folders.
+func (api *API) storageMigrateHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		return
+	}
+
+	storageFolders := api.host.StorageFolders()
+	source, err := folderIndex(folderPath, storageFolders)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var targets []uint16
+	if t := req.FormValue("targets"); t != "" {
+		for _, targetPath := range strings.Split(t, ",") {
+			target, err := folderIndex(targetPath, storageFolders)
+			if err != nil {
+				WriteError(w, Error{"unable to find target " + targetPath + ": " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+			targets = append(targets, uint16(target))
+		}
+	}
+	err = api.host.MigrateStorageFolder(uint16(source), targets)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // storageSectorsDeleteHandler handles the call to delete a sector from the
 // storage manager.
 func (api *API) storageSectorsDeleteHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -1,8 +1,12 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/HyperspaceApp/Hyperspace/modules"
 	"github.com/HyperspaceApp/Hyperspace/types"
@@ -37,6 +41,41 @@ type (
 		ScoreBreakdown modules.HostScoreBreakdown `json:"scorebreakdown"`
 	}
 
+	// HostdbExportGET contains all hosts known to the hostdb together with
+	// their scan histories.
+	HostdbExportGET struct {
+		Hosts []modules.HostDBEntry `json:"hosts"`
+	}
+
+	// HostdbImportPOSTParams contains the hosts to import, usually the hosts
+	// returned by /hostdb/export of another node.
+	HostdbImportPOSTParams struct {
+		Hosts []modules.HostDBEntry `json:"hosts"`
+	}
+
+	// HostdbImportPOST contains the number of hosts that were imported.
+	HostdbImportPOST struct {
+		Imported int `json:"imported"`
+	}
+
+	// HostdbRescanStatusGET contains the progress of the most recent rescan
+	// of the hostdb.
+	HostdbRescanStatusGET struct {
+		modules.HostDBRescanStatus
+	}
+
+	// HostdbReputationFeedGET contains the external reputation feed of the
+	// hostdb and the state of its cached scores.
+	HostdbReputationFeedGET struct {
+		modules.HostDBReputationFeed
+	}
+
+	// HostdbFilterModeGET contains the filter of hosts the hostdb applies
+	// when selecting hosts for new contracts.
+	HostdbFilterModeGET struct {
+		modules.HostDBFilter
+	}
+
 	// HostdbGet holds information about the hostdb.
 	HostdbGet struct {
 		InitialScanComplete bool `json:"initialscancomplete"`
@@ -129,9 +168,115 @@ func (api *API) hostdbHostsHandler(w http.ResponseWriter, req *http.Request, ps
 	extendedEntry := ExtendedHostDBEntry{
 		HostDBEntry:     entry,
 		PublicKeyString: entry.PublicKey.String(),
+		ScoreBreakdown:  breakdown,
 	}
 	WriteJSON(w, HostdbHostsGET{
 		Entry:          extendedEntry,
 		ScoreBreakdown: breakdown,
 	})
 }
+
+// hostdbExportHandler handles the API call to export the hosts of the hostdb.
+func (api *API) hostdbExportHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostdbExportGET{
+		Hosts: api.renter.ExportHosts(),
+	})
+}
+
+// hostdbImportHandler handles the API call to import hosts exported by
+// another node.
+func (api *API) hostdbImportHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params HostdbImportPOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	imported, err := api.renter.ImportHosts(params.Hosts)
+	if err != nil {
+		WriteError(w, Error{"unable to import hosts: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostdbImportPOST{
+		Imported: imported,
+	})
+}
+
+// hostdbRescanHandler handles the API call to rescan all hosts of the hostdb.
+func (api *API) hostdbRescanHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if err := api.renter.RescanHosts(); err != nil {
+		WriteError(w, Error{"unable to rescan hosts: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostdbRescanStatusHandler handles the API call to get the progress of the
+// most recent rescan.
+func (api *API) hostdbRescanStatusHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostdbRescanStatusGET{
+		HostDBRescanStatus: api.renter.RescanStatus(),
+	})
+}
+
+// hostdbReputationFeedHandlerGET handles the API call to get the external
+// reputation feed of the hostdb.
+func (api *API) hostdbReputationFeedHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostdbReputationFeedGET{
+		HostDBReputationFeed: api.renter.ReputationFeed(),
+	})
+}
+
+// hostdbReputationFeedHandlerPOST handles the API call to set the external
+// reputation feed of the hostdb.
+func (api *API) hostdbReputationFeedHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	feedURL := req.FormValue("url")
+	var weight float64
+	if feedURL != "" {
+		if _, err := url.ParseRequestURI(feedURL); err != nil {
+			WriteError(w, Error{"unable to parse url: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		var err error
+		weight, err = strconv.ParseFloat(req.FormValue("weight"), 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse weight: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := api.renter.SetReputationFeed(feedURL, weight); err != nil {
+		WriteError(w, Error{"unable to set reputation feed: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostdbFilterModeHandlerGET handles the API call to get the filter of hosts
+// of the hostdb.
+func (api *API) hostdbFilterModeHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostdbFilterModeGET{
+		HostDBFilter: api.renter.Filter(),
+	})
+}
+
+// hostdbFilterModeHandlerPOST handles the API call to set the filter of hosts
+// of the hostdb.
+func (api *API) hostdbFilterModeHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var hosts []types.SiaPublicKey
+	if req.FormValue("hosts") != "" {
+		for _, key := range strings.Split(req.FormValue("hosts"), ",") {
+			var pk types.SiaPublicKey
+			pk.LoadString(key)
+			if len(pk.Key) == 0 {
+				WriteError(w, Error{fmt.Sprintf("unable to parse host key %q", key)}, http.StatusBadRequest)
+				return
+			}
+			hosts = append(hosts, pk)
+		}
+	}
+	if err := api.renter.SetFilterMode(req.FormValue("filtermode"), hosts); err != nil {
+		WriteError(w, Error{"unable to set filter mode: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
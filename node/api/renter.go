@@ -1,7 +1,10 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"path/filepath"
@@ -11,9 +14,12 @@ import (
 	"time"
 
 	"github.com/HyperspaceApp/Hyperspace/build"
+	"github.com/HyperspaceApp/Hyperspace/crypto"
+	"github.com/HyperspaceApp/Hyperspace/encoding"
 	"github.com/HyperspaceApp/Hyperspace/modules"
 	"github.com/HyperspaceApp/Hyperspace/modules/renter/siafile"
 	"github.com/HyperspaceApp/Hyperspace/types"
+	"github.com/HyperspaceApp/entropy-mnemonics"
 	"github.com/HyperspaceApp/errors"
 
 	"github.com/julienschmidt/httprouter"
@@ -69,9 +75,13 @@ var (
 type (
 	// RenterGET contains various renter metrics.
 	RenterGET struct {
-		Settings         modules.RenterSettings     `json:"settings"`
-		FinancialMetrics modules.ContractorSpending `json:"financialmetrics"`
-		CurrentPeriod    types.BlockHeight          `json:"currentperiod"`
+		Settings            modules.RenterSettings      `json:"settings"`
+		FinancialMetrics    modules.ContractorSpending  `json:"financialmetrics"`
+		CurrentPeriod       types.BlockHeight           `json:"currentperiod"`
+		AllowanceTransition modules.AllowanceTransition `json:"allowancetransition"`
+		Funding             modules.ContractorFunding   `json:"funding"`
+		MaintenancePaused   bool                        `json:"maintenancepaused"`
+		ActiveRepairs       uint64                      `json:"activerepairs"`
 	}
 
 	// RenterContract represents a contract formed by the renter.
@@ -84,10 +94,14 @@ type (
 		Fees types.Currency `json:"fees"`
 		// Public key of the host the contract was formed with.
 		HostPublicKey types.SiaPublicKey `json:"hostpublickey"`
+		// Version of the host software, as of the last scan of the host.
+		HostVersion string `json:"hostversion"`
 		// ID of the file contract.
 		ID types.FileContractID `json:"id"`
 		// A signed transaction containing the most recent contract revision.
 		LastTransaction types.Transaction `json:"lasttransaction"`
+		// Key/value pairs attached to the contract by the renter.
+		Metadata map[string]string `json:"metadata"`
 		// Address of the host the file contract was formed with.
 		NetAddress modules.NetAddress `json:"netaddress"`
 		// Remaining funds left for the renter to spend on uploads & downloads.
@@ -114,6 +128,9 @@ type (
 		GoodForUpload bool `json:"goodforupload"`
 		// Signals if contract is good for a renewal
 		GoodForRenew bool `json:"goodforrenew"`
+		// The most recent migration of the contract, if it was cancelled
+		// with migration.
+		Migration *modules.ContractMigration `json:"migration,omitempty"`
 	}
 
 	// RenterContracts contains the renter's contracts.
@@ -122,6 +139,27 @@ type (
 		ActiveContracts   []RenterContract `json:"activecontracts"`
 		InactiveContracts []RenterContract `json:"inactivecontracts"`
 		ExpiredContracts  []RenterContract `json:"expiredcontracts"`
+
+		// VersionDistribution counts the active contracts per host version.
+		VersionDistribution map[string]int `json:"versiondistribution"`
+	}
+
+	// RenterContractRevisionGET contains the latest signed revision of a
+	// contract.
+	RenterContractRevisionGET struct {
+		Revision    types.FileContractRevision   `json:"revision"`
+		Signatures  []types.TransactionSignature `json:"signatures"`
+		Transaction types.Transaction            `json:"transaction"`
+
+		// EncodedTransaction is the Sia encoding of Transaction. It can be
+		// submitted to the transaction pool as is.
+		EncodedTransaction []byte `json:"encodedtransaction"`
+	}
+
+	// RenterDownloadCostGET contains the estimated cost of downloading a
+	// file.
+	RenterDownloadCostGET struct {
+		Cost types.Currency `json:"cost"`
 	}
 
 	// RenterDownloadQueue contains the renter's download queue.
@@ -139,6 +177,11 @@ type (
 		Files []modules.FileInfo `json:"files"`
 	}
 
+	// RenterLostFiles lists the files that can't be recovered anymore.
+	RenterLostFiles struct {
+		LostFiles []modules.LostFileInfo `json:"lostfiles"`
+	}
+
 	// RenterLoad lists files that were loaded into the renter.
 	RenterLoad struct {
 		FilesAdded []string `json:"filesadded"`
@@ -150,6 +193,245 @@ type (
 		modules.RenterPriceEstimation
 	}
 
+	// RenterAllowanceRecommendGET contains the recommended allowance for
+	// storing an amount of data at a redundancy.
+	RenterAllowanceRecommendGET struct {
+		modules.AllowanceRecommendation
+	}
+
+	// RenterDownloadByHashGET contains the path of the file that was
+	// downloaded by its content hash and the paths of all the files with the
+	// same content hash.
+	RenterDownloadByHashGET struct {
+		SiaPath string   `json:"siapath"`
+		Matches []string `json:"matches"`
+	}
+
+	// RenterDownloadToNodePOST contains the outcome of streaming a file to
+	// another node.
+	RenterDownloadToNodePOST struct {
+		// BytesTransferred is the number of bytes sent by this request. It
+		// is less than Size if a previous request was resumed.
+		BytesTransferred uint64 `json:"bytestransferred"`
+		Size             uint64 `json:"size"`
+	}
+
+	// RenterDownloadRepairPOST contains the outcome of repairing a local copy
+	// of a file.
+	RenterDownloadRepairPOST struct {
+		modules.DownloadRepairResult
+	}
+
+	// RenterEffectiveRedundancyGET contains the redundancy of a file after
+	// collapsing the hosts that share an address range.
+	RenterEffectiveRedundancyGET struct {
+		modules.FileEffectiveRedundancy
+	}
+
+	// RenterFileHistoryGET contains the changes of the distribution of a
+	// file's pieces across hosts.
+	RenterFileHistoryGET struct {
+		modules.FileHistory
+	}
+
+	// RenterRebuildGET contains the progress of the most recent rebuild of a
+	// file.
+	RenterRebuildGET struct {
+		modules.FileRebuildStatus
+	}
+
+	// RenterRepairEstimateGET contains the approximate time until the files
+	// in the repair queue are repaired.
+	RenterRepairEstimateGET struct {
+		modules.RenterRepairEstimate
+	}
+
+	// RenterErasureSchemeGET contains the storage overhead and fault
+	// tolerance of an erasure code.
+	RenterErasureSchemeGET struct {
+		modules.ErasureSchemeInfo
+	}
+
+	// RenterUploadStreamGET contains the number of bytes received for an
+	// incomplete streamed upload.
+	RenterUploadStreamGET struct {
+		Offset uint64 `json:"offset"`
+	}
+
+	// RenterRenewalScheduleGET contains the renewal schedule of the active
+	// contracts.
+	RenterRenewalScheduleGET struct {
+		modules.ContractRenewalSchedule
+	}
+
+	// RenterTrialGET contains the state and cost of the renter's trial.
+	RenterTrialGET struct {
+		modules.RenterTrialReport
+	}
+
+	// RenterTrialTestPOST contains the state and cost of the renter's trial
+	// together with the results of testing its hosts.
+	RenterTrialTestPOST struct {
+		modules.RenterTrialReport
+	}
+
+	// RenterThroughputHistoryGET contains the recent throughput samples of
+	// the renter.
+	RenterThroughputHistoryGET struct {
+		Samples []modules.RenterThroughputSample `json:"samples"`
+	}
+
+	// RenterSpeedTestPOST contains the results of a speed test against the
+	// hosts of the active contracts.
+	RenterSpeedTestPOST struct {
+		modules.RenterSpeedTest
+	}
+
+	// RenterWorkersGET contains the latency estimates and timeouts of the
+	// renter's workers.
+	RenterWorkersGET struct {
+		modules.WorkerPoolStatus
+	}
+
+	// RenterWorkersDetailedGET contains the job queues and upload cooldowns
+	// of the renter's workers.
+	RenterWorkersDetailedGET struct {
+		modules.WorkerPoolDetailedStatus
+	}
+
+	// RenterAuditLogGET contains the paid operations of the renter that
+	// match the filters of the request, oldest first.
+	RenterAuditLogGET struct {
+		Entries []modules.RenterAuditEntry `json:"entries"`
+	}
+
+	// RenterAlertsGET contains the problems of the renter that need the
+	// attention of the user.
+	RenterAlertsGET struct {
+		Alerts []modules.RenterAlert `json:"alerts"`
+	}
+
+	// RenterFilesVerifyPOST contains the result of verifying the siafiles.
+	RenterFilesVerifyPOST struct {
+		modules.RenterFilesVerification
+	}
+
+	// RenterRedundancyGroupsGET contains the files and hosts of the
+	// redundancy groups.
+	RenterRedundancyGroupsGET struct {
+		Groups []modules.RedundancyGroup `json:"groups"`
+	}
+
+	// RenterRecoveryHintRestorePOST contains the contracts listed in a
+	// restored recovery hint.
+	RenterRecoveryHintRestorePOST struct {
+		modules.RenterRecoveryHint
+	}
+
+	// RenterHostContractsCancelPOST lists the contracts that were cancelled
+	// with a host.
+	RenterHostContractsCancelPOST struct {
+		modules.RenterHostContractsCancel
+	}
+
+	// RenterContractReassociatePOST reports the pieces that were
+	// re-associated with a contract.
+	RenterContractReassociatePOST struct {
+		modules.RenterContractReassociation
+	}
+
+	// RenterOrphanedSectorsGET reports the sectors stored under the active
+	// contracts that no file references.
+	RenterOrphanedSectorsGET struct {
+		modules.RenterOrphanedSectors
+	}
+
+	// RenterOrphanedSectorsReclaimPOST reports the pieces that were
+	// re-associated and the sectors that are still orphaned.
+	RenterOrphanedSectorsReclaimPOST struct {
+		modules.RenterOrphanedSectorsReclaim
+	}
+
+	// RenterDirectoryGET describes a directory of the renter, its
+	// subdirectories and the files it contains.
+	RenterDirectoryGET struct {
+		Directory   modules.DirectoryInfo   `json:"directory"`
+		Directories []modules.DirectoryInfo `json:"directories"`
+		Files       []string                `json:"files"`
+	}
+
+	// RenterRecoverableContractsGET lists the contracts that can be
+	// recovered from the recovery hint.
+	RenterRecoverableContractsGET struct {
+		Contracts []modules.RecoverableContract `json:"contracts"`
+	}
+
+	// RenterRecoverContractsPOST lists the contracts that were recovered.
+	RenterRecoverContractsPOST struct {
+		Contracts []modules.RecoverableContract `json:"contracts"`
+	}
+
+	// RenterRecoveryHintSyncPOST describes where a recovery hint was stored.
+	RenterRecoveryHintSyncPOST struct {
+		modules.RenterRecoveryHintSync
+	}
+
+	// RenterManifestGET contains the signed manifest of a file.
+	RenterManifestGET struct {
+		modules.RenterFileManifest
+	}
+
+	// RenterVerifyManifestPOST contains the result of spot checking a file
+	// against its manifest.
+	RenterVerifyManifestPOST struct {
+		modules.RenterManifestVerification
+	}
+
+	// RenterRepairBudgetsGET lists the monthly repair budgets of files and
+	// directories.
+	RenterRepairBudgetsGET struct {
+		Budgets []modules.RepairBudget `json:"budgets"`
+	}
+
+	// RenterLocalBackupGET contains the configuration of the local metadata
+	// backups and the snapshots in the backup directory.
+	RenterLocalBackupGET struct {
+		modules.RenterLocalBackups
+	}
+
+	// RenterLocalBackupRestorePOST reports the number of files restored
+	// from a local snapshot.
+	RenterLocalBackupRestorePOST struct {
+		FilesRestored uint64 `json:"filesrestored"`
+	}
+
+	// RenterScheduledUploadPOST is the scheduled upload returned by a POST
+	// request to /renter/uploadschedule/*hyperspacepath.
+	RenterScheduledUploadPOST struct {
+		modules.ScheduledUpload
+	}
+
+	// RenterScheduledUploadsGET lists the scheduled uploads.
+	RenterScheduledUploadsGET struct {
+		Uploads []modules.ScheduledUpload `json:"uploads"`
+	}
+
+	// RenterUploadBatchPOST contains the ID of a batch upload.
+	RenterUploadBatchPOST struct {
+		ID string `json:"id"`
+	}
+
+	// RenterUploadBatchesGET lists the progress of the batch uploads.
+	RenterUploadBatchesGET struct {
+		Batches []modules.RenterUploadBatch `json:"batches"`
+	}
+
+	// RenterWebhooksGET lists the webhooks that are notified of contract
+	// lifecycle events. The secrets of the webhooks are omitted.
+	RenterWebhooksGET struct {
+		Webhooks []modules.RenterWebhook `json:"webhooks"`
+	}
+
 	// RenterShareASCII contains an ASCII-encoded .sia file.
 	RenterShareASCII struct {
 		ASCIIsia string `json:"asciisia"`
@@ -163,6 +445,7 @@ type (
 		Length          uint64 `json:"length"`          // The length requested for the download.
 		Offset          uint64 `json:"offset"`          // The offset within the siafile requested for the download.
 		SiaPath         string `json:"hyperspacepath"`  // The hyperspacepath of the file used for the download.
+		ID              string `json:"id"`              // Identifies the download when throttling, pausing or resuming it.
 
 		Completed            bool      `json:"completed"`            // Whether or not the download has completed.
 		EndTime              time.Time `json:"endtime"`              // The time when the download fully completed.
@@ -171,6 +454,9 @@ type (
 		StartTime            time.Time `json:"starttime"`            // The time when the download was started.
 		StartTimeUnix        int64     `json:"starttimeunix"`        // The time when the download was started in unix format.
 		TotalDataTransferred uint64    `json:"totaldatatransferred"` // The total amount of data transferred, including negotiation, overdrive etc.
+
+		Paused   bool   `json:"paused"`   // Whether no new chunks of the download are fetched.
+		Throttle uint64 `json:"throttle"` // Bytes per second the download is limited to. 0 if it isn't throttled.
 	}
 )
 
@@ -179,9 +465,13 @@ func (api *API) renterHandlerGET(w http.ResponseWriter, req *http.Request, _ htt
 	settings := api.renter.Settings()
 	periodStart := api.renter.CurrentPeriod()
 	WriteJSON(w, RenterGET{
-		Settings:         settings,
-		FinancialMetrics: api.renter.PeriodSpending(),
-		CurrentPeriod:    periodStart,
+		Settings:            settings,
+		FinancialMetrics:    api.renter.PeriodSpending(),
+		CurrentPeriod:       periodStart,
+		AllowanceTransition: api.renter.AllowanceTransition(),
+		Funding:             api.renter.ContractorFunding(),
+		MaintenancePaused:   api.renter.MaintenancePaused(),
+		ActiveRepairs:       api.renter.ActiveRepairs(),
 	})
 }
 
@@ -242,6 +532,187 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		// Sane defaults if renew window hasn't been set before.
 		settings.Allowance.RenewWindow = settings.Allowance.Period / 2
 	}
+	// Scan the integrity scan interval. (optional parameter)
+	if isi := req.FormValue("integrityscaninterval"); isi != "" {
+		var interval types.BlockHeight
+		if _, err := fmt.Sscan(isi, &interval); err != nil {
+			WriteError(w, Error{"unable to parse integrityscaninterval: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.IntegrityScanInterval = interval
+	}
+	// Scan the minimum number of hosts per chunk. (optional parameter)
+	if m := req.FormValue("minhostsperchunk"); m != "" {
+		var minHosts uint64
+		if _, err := fmt.Sscan(m, &minHosts); err != nil {
+			WriteError(w, Error{"unable to parse minhostsperchunk: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.MinHostsPerChunk = minHosts
+	}
+	// Scan whether renewals are preferred. (optional parameter)
+	if pr := req.FormValue("preferrenewal"); pr != "" {
+		preferRenewal, err := strconv.ParseBool(pr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse preferrenewal: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.PreferRenewal = preferRenewal
+	}
+	// Scan whether host versions are diversified. (optional parameter)
+	if dv := req.FormValue("diversifyversions"); dv != "" {
+		diversify, err := strconv.ParseBool(dv)
+		if err != nil {
+			WriteError(w, Error{"unable to parse diversifyversions: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.DiversifyVersions = diversify
+	}
+	// Scan the maximum funds per contract. (optional parameter)
+	if m := req.FormValue("maxfundspercontract"); m != "" {
+		maxFunds, ok := scanAmount(m)
+		if !ok {
+			WriteError(w, Error{"unable to parse maxfundspercontract"}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.MaxFundsPerContract = maxFunds
+	}
+	// Scan whether degraded uploads are allowed. (optional parameter)
+	if adu := req.FormValue("allowdegradedupload"); adu != "" {
+		allow, err := strconv.ParseBool(adu)
+		if err != nil {
+			WriteError(w, Error{"unable to parse allowdegradedupload: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.AllowDegradedUpload = allow
+	}
+	// Scan the minimum number of hosts of degraded uploads. (optional
+	// parameter)
+	if m := req.FormValue("degradeduploadminhosts"); m != "" {
+		var minHosts uint64
+		if _, err := fmt.Sscan(m, &minHosts); err != nil {
+			WriteError(w, Error{"unable to parse degradeduploadminhosts: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.DegradedUploadMinHosts = minHosts
+	}
+	// Scan the piece placement strategy. "default" restores the default
+	// placement. (optional parameter)
+	if pps := req.FormValue("pieceplacementstrategy"); pps != "" {
+		if pps == "default" {
+			pps = ""
+		}
+		settings.Allowance.PiecePlacementStrategy = pps
+	}
+	// Scan the number of contracts per host. (optional parameter)
+	if cph := req.FormValue("contractsperhost"); cph != "" {
+		var contracts uint64
+		if _, err := fmt.Sscan(cph, &contracts); err != nil {
+			WriteError(w, Error{"unable to parse contractsperhost: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.ContractsPerHost = contracts
+	}
+	// Scan the renewal bias. (optional parameter)
+	if rb := req.FormValue("renewalbias"); rb != "" {
+		var bias float64
+		if _, err := fmt.Sscan(rb, &bias); err != nil {
+			WriteError(w, Error{"unable to parse renewalbias: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.RenewalBias = bias
+	}
+	// Scan the refresh threshold. (optional parameter)
+	if rt := req.FormValue("refreshthreshold"); rt != "" {
+		var threshold float64
+		if _, err := fmt.Sscan(rt, &threshold); err != nil {
+			WriteError(w, Error{"unable to parse refreshthreshold: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.RefreshThreshold = threshold
+	}
+	// Scan whether the allowance is topped up automatically. (optional
+	// parameter)
+	if atu := req.FormValue("autotopup"); atu != "" {
+		autoTopUp, err := strconv.ParseBool(atu)
+		if err != nil {
+			WriteError(w, Error{"unable to parse autotopup: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.AutoTopUp = autoTopUp
+	}
+	// Scan the amount of a top-up. (optional parameter)
+	if a := req.FormValue("topupamount"); a != "" {
+		amount, ok := scanAmount(a)
+		if !ok {
+			WriteError(w, Error{"unable to parse topupamount"}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.TopUpAmount = amount
+	}
+	// Scan the maximum funds of top-ups. (optional parameter)
+	if m := req.FormValue("topupmaxfunds"); m != "" {
+		maxFunds, ok := scanAmount(m)
+		if !ok {
+			WriteError(w, Error{"unable to parse topupmaxfunds"}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.TopUpMaxFunds = maxFunds
+	}
+	// Scan the remaining funds that trigger a top-up. (optional parameter)
+	if th := req.FormValue("topupthreshold"); th != "" {
+		threshold, ok := scanAmount(th)
+		if !ok {
+			WriteError(w, Error{"unable to parse topupthreshold"}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.TopUpThreshold = threshold
+	}
+	// Scan whether contracts may be funded partially. (optional parameter)
+	if pf := req.FormValue("partialfunding"); pf != "" {
+		partialFunding, err := strconv.ParseBool(pf)
+		if err != nil {
+			WriteError(w, Error{"unable to parse partialfunding: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.PartialFunding = partialFunding
+	}
+	// Scan the end height alignment. (optional parameter)
+	if eha := req.FormValue("endheightalignment"); eha != "" {
+		var alignment types.BlockHeight
+		if _, err := fmt.Sscan(eha, &alignment); err != nil {
+			WriteError(w, Error{"unable to parse endheightalignment: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.EndHeightAlignment = alignment
+	}
+	// Scan the renew stagger. (optional parameter)
+	if rs := req.FormValue("renewstagger"); rs != "" {
+		var stagger types.BlockHeight
+		if _, err := fmt.Sscan(rs, &stagger); err != nil {
+			WriteError(w, Error{"unable to parse renewstagger: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.RenewStagger = stagger
+	}
+	// Scan the arbiter of new contracts, an empty value removes it. (optional
+	// parameter)
+	if _, ok := req.Form["arbiter"]; ok {
+		settings.Allowance.Arbiter = nil
+		if a := req.FormValue("arbiter"); a != "" {
+			var arbiter types.SiaPublicKey
+			arbiter.LoadString(a)
+			if len(arbiter.Key) == 0 {
+				WriteError(w, Error{"unable to parse arbiter"}, http.StatusBadRequest)
+				return
+			}
+			settings.Allowance.Arbiter = &arbiter
+		}
+	}
+	if settings.Allowance.MinHostsPerChunk > settings.Allowance.Hosts {
+		WriteError(w, Error{fmt.Sprintf("minimum hosts per chunk can't exceed the number of hosts, have %v hosts but need %v", settings.Allowance.Hosts, settings.Allowance.MinHostsPerChunk)}, http.StatusBadRequest)
+		return
+	}
 	// Scan the download speed limit. (optional parameter)
 	if d := req.FormValue("maxdownloadspeed"); d != "" {
 		var downloadSpeed int64
@@ -269,6 +740,24 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		}
 		settings.StreamCacheSize = streamCacheSize
 	}
+	// Scan the download strategy. (optional parameter)
+	if cod := req.FormValue("costoptimizeddownloads"); cod != "" {
+		costOptimized, err := strconv.ParseBool(cod)
+		if err != nil {
+			WriteError(w, Error{"unable to parse costoptimizeddownloads: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.CostOptimizedDownloads = costOptimized
+	}
+	// Scan the maximum number of concurrent repairs. (optional parameter)
+	if mcr := req.FormValue("maxconcurrentrepairs"); mcr != "" {
+		var maxRepairs uint64
+		if _, err := fmt.Sscan(mcr, &maxRepairs); err != nil {
+			WriteError(w, Error{"unable to parse maxconcurrentrepairs: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.MaxConcurrentRepairs = maxRepairs
+	}
 	// Set the settings in the renter.
 	err := api.renter.SetSettings(settings)
 	if err != nil {
@@ -285,6 +774,23 @@ func (api *API) renterContractCancelHandler(w http.ResponseWriter, req *http.Req
 		WriteError(w, Error{"unable to parse id:" + err.Error()}, http.StatusBadRequest)
 		return
 	}
+	// Contracts cancelled with migration are cancelled once their data was
+	// moved to other hosts.
+	if migrate := req.FormValue("migrate"); migrate != "" {
+		m, err := scanBool(migrate)
+		if err != nil {
+			WriteError(w, Error{"unable to parse migrate: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if m {
+			if err := api.renter.MigrateContract(fcid); err != nil {
+				WriteError(w, Error{"unable to migrate contract: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+			WriteSuccess(w)
+			return
+		}
+	}
 	err := api.renter.CancelContract(fcid)
 	if err != nil {
 		WriteError(w, Error{"unable to cancel contract:" + err.Error()}, http.StatusBadRequest)
@@ -293,78 +799,314 @@ func (api *API) renterContractCancelHandler(w http.ResponseWriter, req *http.Req
 	WriteSuccess(w)
 }
 
-// renterContractsHandler handles the API call to request the Renter's
-// contracts.
-//
-// Active contracts are contracts that the renter is actively using to store
-// data and can upload, download, and renew
-//
-// Inactive contracts are contracts that are not currently being used by the
-// renter because they are !goodForRenew, but have endheights that are in the
-// future so could potentially become active again
-//
-// Expired contracts are contracts who's endheights are in the past
-func (api *API) renterContractsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	// Parse flags
-	inactive, err := scanBool(req.FormValue("inactive"))
+// renterHostContractsCancelHandler handles the API call to cancel all of the
+// contracts with a host.
+func (api *API) renterHostContractsCancelHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var pk types.SiaPublicKey
+	pk.LoadString(req.FormValue("pubkey"))
+	if len(pk.Key) == 0 {
+		WriteError(w, Error{"unable to parse pubkey"}, http.StatusBadRequest)
+		return
+	}
+	result, err := api.renter.CancelHostContracts(pk)
 	if err != nil {
-		WriteError(w, Error{"unable to parse inactive:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{"unable to cancel the contracts with the host: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
-	expired, err := scanBool(req.FormValue("expired"))
+	WriteJSON(w, RenterHostContractsCancelPOST{result})
+}
+
+// renterContractReassociateHandler handles the API call to re-associate a
+// contract with the files that reference its sectors.
+func (api *API) renterContractReassociateHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var fcid types.FileContractID
+	if err := fcid.LoadString(req.FormValue("id")); err != nil {
+		WriteError(w, Error{"unable to parse id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	result, err := api.renter.ReassociateContract(fcid)
 	if err != nil {
-		WriteError(w, Error{"unable to parse expired:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{"unable to re-associate contract: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
+	WriteJSON(w, RenterContractReassociatePOST{result})
+}
 
-	// Get current block height for reference
-	blockHeight := api.cs.Height()
+// renterOrphanedSectorsHandler handles the API call to report the orphaned
+// sectors of the active contracts.
+func (api *API) renterOrphanedSectorsHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	report, err := api.renter.OrphanedSectors()
+	if err != nil {
+		WriteError(w, Error{"unable to find orphaned sectors: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterOrphanedSectorsGET{report})
+}
 
-	// Get active contracts
-	contracts := []RenterContract{}
-	activeContracts := []RenterContract{}
-	inactiveContracts := []RenterContract{}
-	expiredContracts := []RenterContract{}
-	for _, c := range api.renter.Contracts() {
-		var size uint64
-		if len(c.Transaction.FileContractRevisions) != 0 {
-			size = c.Transaction.FileContractRevisions[0].NewFileSize
-		}
+// renterOrphanedSectorsReclaimHandler handles the API call to re-associate
+// the active contracts with the files that reference their sectors.
+func (api *API) renterOrphanedSectorsReclaimHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	result, err := api.renter.ReclaimOrphanedSectors()
+	if err != nil {
+		WriteError(w, Error{"unable to reclaim orphaned sectors: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterOrphanedSectorsReclaimPOST{result})
+}
 
-		// Fetch host address
-		var netAddress modules.NetAddress
-		hdbe, exists := api.renter.Host(c.HostPublicKey)
-		if exists {
-			netAddress = hdbe.NetAddress
+// renterContractMetadataHandler handles the API call to replace the metadata
+// attached to a specific Renter contract.
+func (api *API) renterContractMetadataHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var fcid types.FileContractID
+	if err := fcid.LoadString(req.FormValue("id")); err != nil {
+		WriteError(w, Error{"unable to parse id:" + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var metadata map[string]string
+	if md := req.FormValue("metadata"); md != "" {
+		if err := json.Unmarshal([]byte(md), &metadata); err != nil {
+			WriteError(w, Error{"unable to parse metadata:" + err.Error()}, http.StatusBadRequest)
+			return
 		}
+	}
+	err := api.renter.SetContractMetadata(fcid, metadata)
+	if err != nil {
+		WriteError(w, Error{"unable to set contract metadata:" + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
 
-		// Fetch utilities for contract
-		var goodForUpload bool
-		var goodForRenew bool
-		if utility, ok := api.renter.ContractUtility(c.HostPublicKey); ok {
-			goodForUpload = utility.GoodForUpload
-			goodForRenew = utility.GoodForRenew
+// renterAuditLogHandlerGET handles the API call to query the audit log of
+// the renter.
+func (api *API) renterAuditLogHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var start, end time.Time
+	if s := req.FormValue("start"); s != "" {
+		var unix int64
+		if _, err := fmt.Sscan(s, &unix); err != nil {
+			WriteError(w, Error{"unable to parse start: " + err.Error()}, http.StatusBadRequest)
+			return
 		}
-		contract := RenterContract{
-			DownloadSpending:          c.DownloadSpending,
-			EndHeight:                 c.EndHeight,
-			Fees:                      c.TxnFee.Add(c.ContractFee),
-			GoodForUpload:             goodForUpload,
-			GoodForRenew:              goodForRenew,
-			HostPublicKey:             c.HostPublicKey,
-			ID:                        c.ID,
-			LastTransaction:           c.Transaction,
-			NetAddress:                netAddress,
-			RenterFunds:               c.RenterFunds,
-			Size:                      size,
-			StartHeight:               c.StartHeight,
-			StorageSpending:           c.StorageSpending,
-			StorageSpendingDeprecated: c.StorageSpending,
-			TotalCost:                 c.TotalCost,
-			UploadSpending:            c.UploadSpending,
+		start = time.Unix(unix, 0)
+	}
+	if e := req.FormValue("end"); e != "" {
+		var unix int64
+		if _, err := fmt.Sscan(e, &unix); err != nil {
+			WriteError(w, Error{"unable to parse end: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		end = time.Unix(unix, 0)
+	}
+	var categories []string
+	if c := req.FormValue("category"); c != "" {
+		categories = strings.Split(c, ",")
+	}
+	entries, err := api.renter.AuditLog(start, end, categories)
+	if err != nil {
+		WriteError(w, Error{"unable to read the audit log: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterAuditLogGET{
+		Entries: entries,
+	})
+}
+
+// renterAuditLogExportHandler handles the API call to export the audit log of
+// the renter as CSV.
+func (api *API) renterAuditLogExportHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	dst := req.FormValue("destination")
+	if dst == "" {
+		WriteError(w, Error{"destination must be specified"}, http.StatusBadRequest)
+		return
+	}
+	err := api.renter.ExportAuditLog(dst)
+	if err != nil {
+		WriteError(w, Error{"unable to export the audit log: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterWebhooksHandlerGET handles the API call to list the webhooks of the
+// renter.
+func (api *API) renterWebhooksHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterWebhooksGET{
+		Webhooks: api.renter.Webhooks(),
+	})
+}
+
+// renterWebhooksHandlerPOST handles the API call to register a webhook for
+// contract lifecycle events.
+func (api *API) renterWebhooksHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	hook := modules.RenterWebhook{
+		URL:    req.FormValue("url"),
+		Secret: req.FormValue("secret"),
+	}
+	if hook.URL == "" {
+		WriteError(w, Error{"url must be specified"}, http.StatusBadRequest)
+		return
+	}
+	if events := req.FormValue("events"); events != "" {
+		hook.Events = strings.Split(events, ",")
+	}
+	err := api.renter.RegisterWebhook(hook)
+	if err != nil {
+		WriteError(w, Error{"unable to register webhook: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterWebhooksRemoveHandler handles the API call to remove a webhook.
+func (api *API) renterWebhooksRemoveHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	err := api.renter.UnregisterWebhook(req.FormValue("url"))
+	if err != nil {
+		WriteError(w, Error{"unable to remove webhook: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterContractRevisionHandler handles the API call to get the latest signed
+// revision of a contract.
+func (api *API) renterContractRevisionHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var fcid types.FileContractID
+	if err := fcid.LoadString(req.FormValue("id")); err != nil {
+		WriteError(w, Error{"unable to parse id:" + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	txn, ok := api.renter.ContractRevision(fcid)
+	if !ok {
+		WriteError(w, Error{"no contract with that id"}, http.StatusBadRequest)
+		return
+	}
+	if len(txn.FileContractRevisions) == 0 {
+		WriteError(w, Error{"contract transaction doesn't contain a revision"}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, RenterContractRevisionGET{
+		Revision:           txn.FileContractRevisions[0],
+		Signatures:         txn.TransactionSignatures,
+		Transaction:        txn,
+		EncodedTransaction: encoding.Marshal(txn),
+	})
+}
+
+// renterSimulateHostFailureHandler handles the API call to make the renter
+// treat a host as failed.
+func (api *API) renterSimulateHostFailureHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var pk types.SiaPublicKey
+	pk.LoadString(req.FormValue("pubkey"))
+	if len(pk.Key) == 0 {
+		WriteError(w, Error{"unable to parse pubkey"}, http.StatusBadRequest)
+		return
+	}
+	err := api.renter.SimulateHostFailure(pk)
+	if err != nil {
+		WriteError(w, Error{"unable to simulate host failure: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterRestoreHostHandler handles the API call to undo a simulated host
+// failure.
+func (api *API) renterRestoreHostHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var pk types.SiaPublicKey
+	pk.LoadString(req.FormValue("pubkey"))
+	if len(pk.Key) == 0 {
+		WriteError(w, Error{"unable to parse pubkey"}, http.StatusBadRequest)
+		return
+	}
+	err := api.renter.RestoreSimulatedHost(pk)
+	if err != nil {
+		WriteError(w, Error{"unable to restore host: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterContractsHandler handles the API call to request the Renter's
+// contracts.
+//
+// Active contracts are contracts that the renter is actively using to store
+// data and can upload, download, and renew
+//
+// Inactive contracts are contracts that are not currently being used by the
+// renter because they are !goodForRenew, but have endheights that are in the
+// future so could potentially become active again
+//
+// Expired contracts are contracts who's endheights are in the past
+func (api *API) renterContractsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse flags
+	inactive, err := scanBool(req.FormValue("inactive"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse inactive:" + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	expired, err := scanBool(req.FormValue("expired"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse expired:" + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Get current block height for reference
+	blockHeight := api.cs.Height()
+
+	// Get active contracts
+	contracts := []RenterContract{}
+	activeContracts := []RenterContract{}
+	inactiveContracts := []RenterContract{}
+	expiredContracts := []RenterContract{}
+	versions := make(map[string]int)
+	for _, c := range api.renter.Contracts() {
+		var size uint64
+		if len(c.Transaction.FileContractRevisions) != 0 {
+			size = c.Transaction.FileContractRevisions[0].NewFileSize
+		}
+
+		// Fetch host address and version
+		var netAddress modules.NetAddress
+		var hostVersion string
+		hdbe, exists := api.renter.Host(c.HostPublicKey)
+		if exists {
+			netAddress = hdbe.NetAddress
+			hostVersion = hdbe.Version
+		}
+
+		// Fetch utilities for contract
+		var goodForUpload bool
+		var goodForRenew bool
+		if utility, ok := api.renter.ContractUtility(c.HostPublicKey); ok {
+			goodForUpload = utility.GoodForUpload
+			goodForRenew = utility.GoodForRenew
+		}
+		contract := RenterContract{
+			DownloadSpending:          c.DownloadSpending,
+			EndHeight:                 c.EndHeight,
+			Fees:                      c.TxnFee.Add(c.ContractFee),
+			GoodForUpload:             goodForUpload,
+			GoodForRenew:              goodForRenew,
+			HostPublicKey:             c.HostPublicKey,
+			HostVersion:               hostVersion,
+			ID:                        c.ID,
+			LastTransaction:           c.Transaction,
+			Metadata:                  c.Metadata,
+			NetAddress:                netAddress,
+			RenterFunds:               c.RenterFunds,
+			Size:                      size,
+			StartHeight:               c.StartHeight,
+			StorageSpending:           c.StorageSpending,
+			StorageSpendingDeprecated: c.StorageSpending,
+			TotalCost:                 c.TotalCost,
+			UploadSpending:            c.UploadSpending,
+		}
+		if m, exists := api.renter.ContractMigration(c.ID); exists {
+			contract.Migration = &m
 		}
 		if goodForRenew {
 			activeContracts = append(activeContracts, contract)
+			versions[hostVersion]++
 		} else if inactive && !goodForRenew {
 			inactiveContracts = append(inactiveContracts, contract)
 		}
@@ -379,11 +1121,13 @@ func (api *API) renterContractsHandler(w http.ResponseWriter, req *http.Request,
 				size = c.Transaction.FileContractRevisions[0].NewFileSize
 			}
 
-			// Fetch host address
+			// Fetch host address and version
 			var netAddress modules.NetAddress
+			var hostVersion string
 			hdbe, exists := api.renter.Host(c.HostPublicKey)
 			if exists {
 				netAddress = hdbe.NetAddress
+				hostVersion = hdbe.Version
 			}
 
 			// Fetch utilities for contract
@@ -401,8 +1145,10 @@ func (api *API) renterContractsHandler(w http.ResponseWriter, req *http.Request,
 				GoodForUpload:             goodForUpload,
 				GoodForRenew:              goodForRenew,
 				HostPublicKey:             c.HostPublicKey,
+				HostVersion:               hostVersion,
 				ID:                        c.ID,
 				LastTransaction:           c.Transaction,
+				Metadata:                  c.Metadata,
 				NetAddress:                netAddress,
 				RenterFunds:               c.RenterFunds,
 				Size:                      size,
@@ -425,6 +1171,8 @@ func (api *API) renterContractsHandler(w http.ResponseWriter, req *http.Request,
 		ActiveContracts:   activeContracts,
 		InactiveContracts: inactiveContracts,
 		ExpiredContracts:  expiredContracts,
+
+		VersionDistribution: versions,
 	})
 }
 
@@ -469,6 +1217,7 @@ func (api *API) renterDownloadsHandler(w http.ResponseWriter, _ *http.Request, _
 			Length:          di.Length,
 			Offset:          di.Offset,
 			SiaPath:         di.SiaPath,
+			ID:              di.ID,
 
 			Completed:            di.Completed,
 			EndTime:              di.EndTime,
@@ -477,6 +1226,9 @@ func (api *API) renterDownloadsHandler(w http.ResponseWriter, _ *http.Request, _
 			StartTime:            di.StartTime,
 			StartTimeUnix:        di.StartTimeUnix,
 			TotalDataTransferred: di.TotalDataTransferred,
+
+			Paused:   di.Paused,
+			Throttle: di.Throttle,
 		})
 	}
 	WriteJSON(w, RenterDownloadQueue{
@@ -484,6 +1236,39 @@ func (api *API) renterDownloadsHandler(w http.ResponseWriter, _ *http.Request, _
 	})
 }
 
+// renterDownloadPauseHandler handles the API call to pause a download.
+func (api *API) renterDownloadPauseHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if err := api.renter.PauseDownload(req.FormValue("id")); err != nil {
+		WriteError(w, Error{"unable to pause download: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterDownloadResumeHandler handles the API call to resume a paused or
+// throttled download.
+func (api *API) renterDownloadResumeHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if err := api.renter.ResumeDownload(req.FormValue("id")); err != nil {
+		WriteError(w, Error{"unable to resume download: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterDownloadThrottleHandler handles the API call to throttle a download.
+func (api *API) renterDownloadThrottleHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	bytesPerSecond, err := strconv.ParseUint(req.FormValue("bytespersecond"), 10, 64)
+	if err != nil {
+		WriteError(w, Error{"unable to parse bytespersecond: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.ThrottleDownload(req.FormValue("id"), bytesPerSecond); err != nil {
+		WriteError(w, Error{"unable to throttle download: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterLoadHandler handles the API call to load a '.sia' file.
 func (api *API) renterLoadHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	source, err := url.QueryUnescape(req.FormValue("source"))
@@ -496,25 +1281,488 @@ func (api *API) renterLoadHandler(w http.ResponseWriter, req *http.Request, _ ht
 		return
 	}
 
-	files, err := api.renter.LoadSharedFiles(source)
+	files, err := api.renter.LoadSharedFiles(source)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	WriteJSON(w, RenterLoad{FilesAdded: files})
+}
+
+// renterLoadAsciiHandler handles the API call to load a '.sia' file
+// in ASCII form.
+func (api *API) renterLoadASCIIHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	files, err := api.renter.LoadSharedFilesASCII(req.FormValue("asciisia"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	WriteJSON(w, RenterLoad{FilesAdded: files})
+}
+
+// renterEffectiveRedundancyHandler handles the API call to retrieve the
+// redundancy of a file after collapsing the hosts that share an address range.
+func (api *API) renterEffectiveRedundancyHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	redundancy, err := api.renter.EffectiveRedundancy(strings.TrimPrefix(ps.ByName("hyperspacepath"), "/"))
+	if err != nil {
+		WriteError(w, Error{"unable to get effective redundancy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterEffectiveRedundancyGET{redundancy})
+}
+
+// renterHistoryHandler handles the API call to retrieve the changes of the
+// distribution of a file's pieces across hosts within a time range.
+func (api *API) renterHistoryHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var afterTime time.Time
+	beforeTime := types.EndOfTime
+	beforeStr, afterStr := req.FormValue("before"), req.FormValue("after")
+	if beforeStr != "" {
+		beforeInt, err := strconv.ParseInt(beforeStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `before` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		beforeTime = time.Unix(0, beforeInt)
+	}
+	if afterStr != "" {
+		afterInt, err := strconv.ParseInt(afterStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `after` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		afterTime = time.Unix(0, afterInt)
+	}
+	history, err := api.renter.FileHistory(strings.TrimPrefix(ps.ByName("hyperspacepath"), "/"), afterTime, beforeTime)
+	if err != nil {
+		WriteError(w, Error{"unable to get file history: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterFileHistoryGET{history})
+}
+
+// renterRebuildHandlerGET handles the API call to retrieve the progress of a
+// file rebuild.
+func (api *API) renterRebuildHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	status, err := api.renter.RebuildStatus(strings.TrimPrefix(ps.ByName("hyperspacepath"), "/"))
+	if err != nil {
+		WriteError(w, Error{"unable to get rebuild status: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterRebuildGET{status})
+}
+
+// renterDownloadRepairHandler handles the API call to verify a local copy of a
+// file and to download the chunks that are corrupt.
+func (api *API) renterDownloadRepairHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	destination, err := url.QueryUnescape(req.FormValue("destination"))
+	if err != nil {
+		WriteError(w, Error{"failed to unescape the destination: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if destination == "" {
+		WriteError(w, Error{"destination not supplied"}, http.StatusBadRequest)
+		return
+	}
+	result, err := api.renter.RepairDownload(strings.TrimPrefix(ps.ByName("hyperspacepath"), "/"), destination)
+	if err != nil {
+		WriteError(w, Error{"unable to repair download: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterDownloadRepairPOST{result})
+}
+
+// renterDownloadToNodeHandler handles the API call to stream a file to the
+// renter of another node. The transfer resumes at the data the other node
+// already received, so a failed transfer is resumed by repeating the call.
+func (api *API) renterDownloadToNodeHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath := strings.TrimPrefix(ps.ByName("hyperspacepath"), "/")
+	address := req.FormValue("address")
+	if address == "" {
+		WriteError(w, Error{"address of the target node not supplied"}, http.StatusBadRequest)
+		return
+	}
+	// The password of the target node is only accepted in the request body,
+	// query strings end up in logs and the history of proxies.
+	if req.URL.Query().Get("password") != "" {
+		WriteError(w, Error{"the password of the target node must be sent in the request body"}, http.StatusBadRequest)
+		return
+	}
+	password := req.PostFormValue("password")
+	targetPath := strings.TrimPrefix(req.FormValue("targetpath"), "/")
+	if targetPath == "" {
+		targetPath = siaPath
+	}
+
+	_, streamer, err := api.renter.Streamer(siaPath)
+	if err != nil {
+		WriteError(w, Error{"failed to create download streamer: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	size, err := streamer.Seek(0, io.SeekEnd)
+	if err != nil {
+		WriteError(w, Error{"failed to determine the size of the file: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	// Ask the target node how much of the file it received already.
+	resource := fmt.Sprintf("http://%s/renter/uploadstream/%s", address, escapePath(targetPath))
+	var rus RenterUploadStreamGET
+	resp, err := HttpGETAuthenticated(resource, password)
+	if err == nil {
+		err = readNodeResponse(resp, &rus)
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to query the target node: " + err.Error()}, http.StatusBadGateway)
+		return
+	}
+	offset := rus.Offset
+	if offset > uint64(size) {
+		WriteError(w, Error{fmt.Sprintf("target node received %v bytes, but the file only has %v bytes", offset, size)}, http.StatusConflict)
+		return
+	}
+	if _, err := streamer.Seek(int64(offset), io.SeekStart); err != nil {
+		WriteError(w, Error{"failed to seek to the offset of the target node: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	// Stream the rest of the file to the target node, which starts the
+	// upload once it received all of the data.
+	values := url.Values{}
+	values.Set("offset", fmt.Sprint(offset))
+	values.Set("complete", "true")
+	for _, key := range []string{"datapieces", "paritypieces", "overwrite"} {
+		if v := req.FormValue(key); v != "" {
+			values.Set(key, v)
+		}
+	}
+	resp, err = httpPOSTStreamAuthenticated(resource+"?"+values.Encode(), streamer, password)
+	if err == nil {
+		err = readNodeResponse(resp, &rus)
+	}
+	if err != nil {
+		WriteError(w, Error{"transfer to the target node failed, repeat the call to resume: " + err.Error()}, http.StatusBadGateway)
+		return
+	}
+	WriteJSON(w, RenterDownloadToNodePOST{
+		BytesTransferred: uint64(size) - offset,
+		Size:             uint64(size),
+	})
+}
+
+// renterUploadStreamHandlerGET handles the API call to get the number of
+// bytes received for an incomplete streamed upload.
+func (api *API) renterUploadStreamHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	offset, err := api.renter.UploadStreamOffset(strings.TrimPrefix(ps.ByName("hyperspacepath"), "/"))
+	if err != nil {
+		WriteError(w, Error{"unable to get the offset of the stream: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterUploadStreamGET{offset})
+}
+
+// renterUploadStreamHandlerPOST handles the API call to append the request
+// body to a streamed upload.
+func (api *API) renterUploadStreamHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var offset uint64
+	if _, err := fmt.Sscan(req.FormValue("offset"), &offset); err != nil {
+		WriteError(w, Error{"unable to parse offset: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	complete := false
+	if c := req.FormValue("complete"); c != "" {
+		var err error
+		complete, err = strconv.ParseBool(c)
+		if err != nil {
+			WriteError(w, Error{"unable to parse complete: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	overwrite := false
+	if o := req.FormValue("overwrite"); o != "" {
+		var err error
+		overwrite, err = strconv.ParseBool(o)
+		if err != nil {
+			WriteError(w, Error{"unable to parse overwrite: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	dataPieces, parityPieces, err := scanErasureCodeParams(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var ec modules.ErasureCoder
+	if dataPieces != 0 || parityPieces != 0 {
+		ec, err = siafile.NewRSCode(dataPieces, parityPieces)
+		if err != nil {
+			WriteError(w, Error{"unable to encode file using the provided parameters: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	received, err := api.renter.UploadStream(modules.FileUploadParams{
+		SiaPath:     strings.TrimPrefix(ps.ByName("hyperspacepath"), "/"),
+		ErasureCode: ec,
+		Overwrite:   overwrite,
+	}, offset, req.Body, complete)
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("streamed upload failed after receiving %v bytes: %v", received, err)}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterUploadStreamGET{received})
+}
+
+// renterRebuildHandlerPOST handles the API call to rebuild a file to full
+// redundancy or to cancel an active rebuild.
+func (api *API) renterRebuildHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath := strings.TrimPrefix(ps.ByName("hyperspacepath"), "/")
+	cancel := false
+	if c := req.FormValue("cancel"); c != "" {
+		var err error
+		cancel, err = strconv.ParseBool(c)
+		if err != nil {
+			WriteError(w, Error{"unable to parse cancel: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	var err error
+	if cancel {
+		err = api.renter.CancelRebuild(siaPath)
+	} else {
+		err = api.renter.RebuildFile(siaPath)
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to rebuild file: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterErasureSchemeHandler handles the API call to preview the storage
+// overhead and fault tolerance of an erasure code.
+func (api *API) renterErasureSchemeHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if req.FormValue("datapieces") == "" || req.FormValue("paritypieces") == "" {
+		WriteError(w, Error{"must provide both the datapieces parameter and the paritypieces parameter"}, http.StatusBadRequest)
+		return
+	}
+	var dataPieces, parityPieces int
+	_, err := fmt.Sscan(req.FormValue("datapieces"), &dataPieces)
+	if err != nil {
+		WriteError(w, Error{"unable to read parameter 'datapieces': " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	_, err = fmt.Sscan(req.FormValue("paritypieces"), &parityPieces)
+	if err != nil {
+		WriteError(w, Error{"unable to read parameter 'paritypieces': " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	info, err := api.renter.ErasureSchemeInfo(dataPieces, parityPieces)
+	if err != nil {
+		WriteError(w, Error{"invalid erasure code parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterErasureSchemeGET{
+		ErasureSchemeInfo: info,
+	})
+}
+
+// renterRedundancyHandler handles the API call to change the erasure code
+// parameters of an existing file.
+func (api *API) renterRedundancyHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	if req.FormValue("datapieces") == "" || req.FormValue("paritypieces") == "" {
+		WriteError(w, Error{"must provide both the datapieces parameter and the paritypieces parameter"}, http.StatusBadRequest)
+		return
+	}
+	var dataPieces, parityPieces int
+	_, err := fmt.Sscan(req.FormValue("datapieces"), &dataPieces)
+	if err != nil {
+		WriteError(w, Error{"unable to read parameter 'datapieces': " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	_, err = fmt.Sscan(req.FormValue("paritypieces"), &parityPieces)
+	if err != nil {
+		WriteError(w, Error{"unable to read parameter 'paritypieces': " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.renter.SetFileRedundancy(strings.TrimPrefix(ps.ByName("hyperspacepath"), "/"), dataPieces, parityPieces)
+	if err != nil {
+		WriteError(w, Error{"unable to change the redundancy of the file: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterRepairBudgetHandler handles the API call to set the monthly repair
+// budget of a file or directory.
+func (api *API) renterRepairBudgetHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	monthly, ok := scanAmount(req.FormValue("monthly"))
+	if !ok {
+		WriteError(w, Error{"unable to parse monthly"}, http.StatusBadRequest)
+		return
+	}
+	err := api.renter.SetRepairBudget(strings.TrimPrefix(ps.ByName("hyperspacepath"), "/"), monthly)
+	if err != nil {
+		WriteError(w, Error{"unable to set the repair budget: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterRepairBudgetsHandler handles the API call to list the repair budgets.
+func (api *API) renterRepairBudgetsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterRepairBudgetsGET{
+		Budgets: api.renter.RepairBudgets(),
+	})
+}
+
+// renterRecoveryHintSyncHandler handles the API call to store a recovery hint
+// on several hosts.
+func (api *API) renterRecoveryHintSyncHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	sync, err := api.renter.SyncRecoveryHint()
+	if err != nil {
+		WriteError(w, Error{"unable to sync the recovery hint: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterRecoveryHintSyncPOST{sync})
+}
+
+// renterManifestHandler handles the API call to export the signed manifest of
+// a file.
+func (api *API) renterManifestHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	manifest, err := api.renter.FileManifest(strings.TrimPrefix(ps.ByName("hyperspacepath"), "/"))
+	if err != nil {
+		WriteError(w, Error{"unable to export the manifest: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterManifestGET{manifest})
+}
+
+// renterVerifyManifestHandler handles the API call to spot check a file
+// against a manifest.
+func (api *API) renterVerifyManifestHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var manifest modules.RenterFileManifest
+	if err := json.Unmarshal([]byte(req.FormValue("manifest")), &manifest); err != nil {
+		WriteError(w, Error{"unable to parse manifest: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var samples uint64
+	if s := req.FormValue("samples"); s != "" {
+		if _, err := fmt.Sscan(s, &samples); err != nil {
+			WriteError(w, Error{"unable to parse samples: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	verification, err := api.renter.VerifyManifest(manifest, samples)
+	if err != nil {
+		WriteError(w, Error{"unable to verify the manifest: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterVerifyManifestPOST{verification})
+}
+
+// renterRecoveryHintRestoreHandler handles the API call to restore a recovery
+// hint from a seed.
+func (api *API) renterRecoveryHintRestoreHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	dictID := mnemonics.DictionaryID(req.FormValue("dictionary"))
+	if dictID == "" {
+		dictID = "english"
+	}
+	seed, err := modules.StringToSeed(req.FormValue("seed"), dictID)
+	if err != nil {
+		WriteError(w, Error{"unable to parse seed: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	hint, err := api.renter.RestoreRecoveryHint(seed)
+	if err != nil {
+		WriteError(w, Error{"unable to restore the recovery hint: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterRecoveryHintRestorePOST{hint})
+}
+
+// renterRecoverableContractsHandler handles the API call to list the contracts
+// that can be recovered from the recovery hint.
+func (api *API) renterRecoverableContractsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	contracts, err := api.renter.RecoverableContracts()
+	if err != nil {
+		WriteError(w, Error{"unable to list recoverable contracts: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterRecoverableContractsGET{contracts})
+}
+
+// renterRecoverContractsHandler handles the API call to add the recoverable
+// contracts to the contract set.
+func (api *API) renterRecoverContractsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	contracts, err := api.renter.RecoverContracts()
+	if err != nil {
+		WriteError(w, Error{"unable to recover contracts: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterRecoverContractsPOST{contracts})
+}
+
+// renterAlertsHandler handles the API call to list the renter's alerts.
+func (api *API) renterAlertsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterAlertsGET{
+		Alerts: api.renter.Alerts(),
+	})
+}
+
+// renterRedundancyGroupsHandler handles the API call to list the redundancy
+// groups of the renter.
+func (api *API) renterRedundancyGroupsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterRedundancyGroupsGET{
+		Groups: api.renter.RedundancyGroups(),
+	})
+}
+
+// renterMaintenancePauseHandler handles the API call to pause contract
+// maintenance and repairs.
+func (api *API) renterMaintenancePauseHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if err := api.renter.PauseMaintenance(); err != nil {
+		WriteError(w, Error{"unable to pause maintenance: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterMaintenanceResumeHandler handles the API call to resume contract
+// maintenance and repairs.
+func (api *API) renterMaintenanceResumeHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if err := api.renter.ResumeMaintenance(); err != nil {
+		WriteError(w, Error{"unable to resume maintenance: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterRekeyHandler handles the API call to rotate the encryption key of a
+// file.
+func (api *API) renterRekeyHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath := strings.TrimPrefix(ps.ByName("hyperspacepath"), "/")
+	cancel, err := scanBool(req.FormValue("cancel"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{"unable to parse cancel: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
-
-	WriteJSON(w, RenterLoad{FilesAdded: files})
-}
-
-// renterLoadAsciiHandler handles the API call to load a '.sia' file
-// in ASCII form.
-func (api *API) renterLoadASCIIHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	files, err := api.renter.LoadSharedFilesASCII(req.FormValue("asciisia"))
+	if cancel {
+		if err := api.renter.CancelRekey(siaPath); err != nil {
+			WriteError(w, Error{"unable to cancel rekey: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
+	err = api.renter.RekeyFile(siaPath)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{"unable to rekey file: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
-
-	WriteJSON(w, RenterLoad{FilesAdded: files})
+	WriteSuccess(w)
 }
 
 // renterRenameHandler handles the API call to rename a file entry in the
@@ -561,6 +1809,30 @@ func (api *API) renterFileHandlerPOST(w http.ResponseWriter, req *http.Request,
 			return
 		}
 	}
+
+	// Handle changing the number of spare hosts of a file.
+	if sh := req.FormValue("sparehosts"); sh != "" {
+		spareHosts, err := strconv.ParseUint(sh, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse sparehosts: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		siapath := strings.TrimPrefix(ps.ByName("hyperspacepath"), "/")
+		if err := api.renter.SetFileSpareHosts(siapath, spareHosts); err != nil {
+			WriteError(w, Error{"unable to set spare hosts: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Handle changing the redundancy group of a file. An empty group removes
+	// the file from its group.
+	if _, ok := req.Form["redundancygroup"]; ok {
+		siapath := strings.TrimPrefix(ps.ByName("hyperspacepath"), "/")
+		if err := api.renter.SetFileRedundancyGroup(siapath, req.FormValue("redundancygroup")); err != nil {
+			WriteError(w, Error{"unable to set redundancy group: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	WriteSuccess(w)
 }
 
@@ -583,6 +1855,34 @@ func (api *API) renterFilesHandler(w http.ResponseWriter, req *http.Request, _ h
 	}
 }
 
+// renterFilesVerifyHandler handles the API call to verify the files of the
+// siafiles on disk and optionally compact them.
+func (api *API) renterFilesVerifyHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var compact bool
+	if c := req.FormValue("compact"); c != "" {
+		var err error
+		compact, err = strconv.ParseBool(c)
+		if err != nil {
+			WriteError(w, Error{"unable to parse compact: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	report, err := api.renter.VerifyFiles(compact)
+	if err != nil {
+		WriteError(w, Error{"unable to verify files: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, RenterFilesVerifyPOST{report})
+}
+
+// renterLostFilesHandler handles the API call to list the files that can't be
+// recovered from the renter's hosts.
+func (api *API) renterLostFilesHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterLostFiles{
+		LostFiles: api.renter.LostFiles(),
+	})
+}
+
 // renterPricesHandler reports the expected costs of various actions given the
 // renter settings and the set of available hosts.
 func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -591,6 +1891,135 @@ func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _
 	})
 }
 
+// renterAllowanceRecommendHandler handles the API call to estimate the
+// cheapest allowance for storing data at a redundancy.
+func (api *API) renterAllowanceRecommendHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var dataSize uint64
+	if _, err := fmt.Sscan(req.FormValue("datasize"), &dataSize); err != nil {
+		WriteError(w, Error{"unable to parse datasize: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var redundancy float64
+	if _, err := fmt.Sscan(req.FormValue("redundancy"), &redundancy); err != nil {
+		WriteError(w, Error{"unable to parse redundancy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var period types.BlockHeight
+	if _, err := fmt.Sscan(req.FormValue("period"), &period); err != nil {
+		WriteError(w, Error{"unable to parse period: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	rec, err := api.renter.RecommendAllowance(dataSize, redundancy, period)
+	if err != nil {
+		WriteError(w, Error{"unable to recommend an allowance: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterAllowanceRecommendGET{
+		AllowanceRecommendation: rec,
+	})
+}
+
+// renterRepairEstimateHandler handles the API call to estimate the time until
+// the files in the repair queue are repaired.
+func (api *API) renterRepairEstimateHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterRepairEstimateGET{
+		RenterRepairEstimate: api.renter.RepairEstimate(),
+	})
+}
+
+// renterThroughputHistoryHandler handles the API call to retrieve the recent
+// throughput samples of the renter.
+func (api *API) renterThroughputHistoryHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterThroughputHistoryGET{
+		Samples: api.renter.ThroughputHistory(),
+	})
+}
+
+// renterRenewalScheduleHandler handles the API call to retrieve when the
+// active contracts are due for renewal.
+func (api *API) renterRenewalScheduleHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	within := api.renter.Settings().Allowance.RenewWindow
+	if wi := req.FormValue("within"); wi != "" {
+		if _, err := fmt.Sscan(wi, &within); err != nil {
+			WriteError(w, Error{"unable to parse within: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	schedule, err := api.renter.RenewalSchedule(within)
+	if err != nil {
+		WriteError(w, Error{"unable to get the renewal schedule: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, RenterRenewalScheduleGET{schedule})
+}
+
+// renterTrialHandlerGET handles the API call to report the state and cost of
+// the trial.
+func (api *API) renterTrialHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterTrialGET{api.renter.Trial()})
+}
+
+// renterTrialHandlerPOST handles the API call to start a trial.
+func (api *API) renterTrialHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	funds, ok := scanAmount(req.FormValue("funds"))
+	if !ok {
+		WriteError(w, Error{"unable to parse funds"}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.StartTrial(funds); err != nil {
+		WriteError(w, Error{"unable to start the trial: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterTrialTestHandlerPOST handles the API call to upload a test file to the
+// hosts of the trial and download it again.
+func (api *API) renterTrialTestHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	report, err := api.renter.TestTrial()
+	if err != nil {
+		WriteError(w, Error{"unable to test the trial: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterTrialTestPOST{report})
+}
+
+// renterSpeedTestHandlerPOST handles the API call to measure the download
+// throughput of the active contracts.
+func (api *API) renterSpeedTestHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	sectors := uint64(1)
+	if s := req.FormValue("sectors"); s != "" {
+		if _, err := fmt.Sscan(s, &sectors); err != nil {
+			WriteError(w, Error{"unable to parse sectors: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	st, err := api.renter.SpeedTest(sectors)
+	if err != nil {
+		WriteError(w, Error{"unable to run speed test: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterSpeedTestPOST{
+		RenterSpeedTest: st,
+	})
+}
+
+// renterWorkersHandler handles the API call to report the status of the
+// workers.
+func (api *API) renterWorkersHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterWorkersGET{
+		WorkerPoolStatus: api.renter.WorkerPoolStatus(),
+	})
+}
+
+// renterWorkersDetailedHandler handles the API call to report the job queues
+// of the workers.
+func (api *API) renterWorkersDetailedHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterWorkersDetailedGET{
+		WorkerPoolDetailedStatus: api.renter.WorkerPoolDetailedStatus(),
+	})
+}
+
 // renterDeleteHandler handles the API call to delete a file entry from the
 // renter.
 func (api *API) renterDeleteHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -628,6 +2057,48 @@ func (api *API) renterDownloadHandler(w http.ResponseWriter, req *http.Request,
 	}
 }
 
+// renterDownloadByHashHandler handles the API call to download a file by its
+// content hash.
+func (api *API) renterDownloadByHashHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var contentHash crypto.Hash
+	if err := contentHash.LoadString(ps.ByName("hash")); err != nil {
+		WriteError(w, Error{"unable to parse content hash: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	params, err := parseDownloadParameters(w, req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	matches, err := api.renter.DownloadByContentHash(contentHash, params)
+	if len(matches) == 0 {
+		WriteError(w, Error{"download failed: " + err.Error()}, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		WriteError(w, Error{"download failed: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	if params.Httpwriter == nil {
+		WriteJSON(w, RenterDownloadByHashGET{
+			SiaPath: matches[0],
+			Matches: matches,
+		})
+	}
+}
+
+// renterDownloadCostHandlerGET handles the API call to estimate the cost of
+// downloading a file.
+func (api *API) renterDownloadCostHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	cost, err := api.renter.DownloadCost(strings.TrimPrefix(ps.ByName("hyperspacepath"), "/"))
+	if err != nil {
+		WriteError(w, Error{"unable to estimate download cost: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterDownloadCostGET{
+		Cost: cost,
+	})
+}
+
 // renterDownloadAsyncHandler handles the API call to download a file asynchronously.
 func (api *API) renterDownloadAsyncHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	req.ParseForm()
@@ -648,6 +2119,9 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 	offsetparam := req.FormValue("offset")
 	lengthparam := req.FormValue("length")
 
+	// The number of bytes to download from the end of the file.
+	tailparam := req.FormValue("tail")
+
 	// Determines whether the response is written to response body.
 	httprespparam := req.FormValue("httpresp")
 
@@ -669,6 +2143,13 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 			return modules.RenterDownloadParameters{}, errors.AddContext(err, "could not decode the offset as uint64")
 		}
 	}
+	var tail uint64
+	if len(tailparam) > 0 {
+		_, err := fmt.Sscan(tailparam, &tail)
+		if err != nil {
+			return modules.RenterDownloadParameters{}, errors.AddContext(err, "could not decode the tail as uint64")
+		}
+	}
 
 	// Parse the httpresp parameter.
 	httpresp, err := scanBool(httprespparam)
@@ -690,6 +2171,7 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 		Length:      length,
 		Offset:      offset,
 		SiaPath:     hyperspacepath,
+		Tail:        tail,
 	}
 	if httpresp {
 		dp.Httpwriter = w
@@ -743,9 +2225,52 @@ func (api *API) renterStreamHandler(w http.ResponseWriter, req *http.Request, ps
 			http.StatusInternalServerError)
 		return
 	}
+	// http.ServeContent honors the Range header by seeking the streamer to
+	// the requested offsets, so only the chunks overlapping the ranges are
+	// downloaded. An unknown content type would be sniffed from the first
+	// bytes of the file though, which requires downloading the first chunk
+	// for every range request.
+	if req.Header.Get("Range") != "" && w.Header().Get("Content-Type") == "" {
+		contentType := mime.TypeByExtension(filepath.Ext(fileName))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+	}
 	http.ServeContent(w, req, fileName, time.Time{}, streamer)
 }
 
+// scanErasureCodeParams parses the optional erasure coding parameters of an
+// upload. Zero values are returned if the parameters weren't supplied.
+func scanErasureCodeParams(req *http.Request) (dataPieces, parityPieces int, err error) {
+	if req.FormValue("datapieces") == "" && req.FormValue("paritypieces") == "" {
+		return 0, 0, nil
+	}
+	// Check that both values have been supplied.
+	if req.FormValue("datapieces") == "" || req.FormValue("paritypieces") == "" {
+		return 0, 0, errors.New("must provide both the datapieces parameter and the paritypieces parameter if specifying erasure coding parameters")
+	}
+
+	// Parse the erasure coding parameters.
+	if _, err := fmt.Sscan(req.FormValue("datapieces"), &dataPieces); err != nil {
+		return 0, 0, errors.New("unable to read parameter 'datapieces': " + err.Error())
+	}
+	if _, err := fmt.Sscan(req.FormValue("paritypieces"), &parityPieces); err != nil {
+		return 0, 0, errors.New("unable to read parameter 'paritypieces': " + err.Error())
+	}
+
+	// Verify that sane values for parityPieces and redundancy are being
+	// supplied.
+	if parityPieces < requiredParityPieces {
+		return 0, 0, fmt.Errorf("a minimum of %v parity pieces is required, but %v parity pieces requested", parityPieces, requiredParityPieces)
+	}
+	redundancy := float64(dataPieces+parityPieces) / float64(dataPieces)
+	if float64(dataPieces+parityPieces)/float64(dataPieces) < requiredRedundancy {
+		return 0, 0, fmt.Errorf("a redundancy of %.2f is required, but redundancy of %.2f supplied", redundancy, requiredRedundancy)
+	}
+	return dataPieces, parityPieces, nil
+}
+
 // renterUploadHandler handles the API call to upload a file.
 func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	source, err := url.QueryUnescape(req.FormValue("source"))
@@ -769,40 +2294,25 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		overwrite = b
 	}
 
-	// Check whether the erasure coding parameters have been supplied.
-	var ec modules.ErasureCoder
-	if req.FormValue("datapieces") != "" || req.FormValue("paritypieces") != "" {
-		// Check that both values have been supplied.
-		if req.FormValue("datapieces") == "" || req.FormValue("paritypieces") == "" {
-			WriteError(w, Error{"must provide both the datapieces parameter and the paritypieces parameter if specifying erasure coding parameters"}, http.StatusBadRequest)
-			return
-		}
-
-		// Parse the erasure coding parameters.
-		var dataPieces, parityPieces int
-		_, err := fmt.Sscan(req.FormValue("datapieces"), &dataPieces)
-		if err != nil {
-			WriteError(w, Error{"unable to read parameter 'datapieces': " + err.Error()}, http.StatusBadRequest)
-			return
-		}
-		_, err = fmt.Sscan(req.FormValue("paritypieces"), &parityPieces)
+	// Check whether the chunks of the file should be deduplicated.
+	dedup := false
+	if req.FormValue("dedup") != "" {
+		b, err := strconv.ParseBool(req.FormValue("dedup"))
 		if err != nil {
-			WriteError(w, Error{"unable to read parameter 'paritypieces': " + err.Error()}, http.StatusBadRequest)
-			return
-		}
-
-		// Verify that sane values for parityPieces and redundancy are being
-		// supplied.
-		if parityPieces < requiredParityPieces {
-			WriteError(w, Error{fmt.Sprintf("a minimum of %v parity pieces is required, but %v parity pieces requested", parityPieces, requiredParityPieces)}, http.StatusBadRequest)
-			return
-		}
-		redundancy := float64(dataPieces+parityPieces) / float64(dataPieces)
-		if float64(dataPieces+parityPieces)/float64(dataPieces) < requiredRedundancy {
-			WriteError(w, Error{fmt.Sprintf("a redundancy of %.2f is required, but redundancy of %.2f supplied", redundancy, requiredRedundancy)}, http.StatusBadRequest)
+			WriteError(w, Error{"unable to parse 'dedup' parameter: " + err.Error()}, http.StatusBadRequest)
 			return
 		}
+		dedup = b
+	}
 
+	// Check whether the erasure coding parameters have been supplied.
+	dataPieces, parityPieces, err := scanErasureCodeParams(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var ec modules.ErasureCoder
+	if dataPieces != 0 || parityPieces != 0 {
 		// Create the erasure coder.
 		ec, err = siafile.NewRSCode(dataPieces, parityPieces)
 		if err != nil {
@@ -817,6 +2327,9 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		SiaPath:     strings.TrimPrefix(ps.ByName("hyperspacepath"), "/"),
 		ErasureCode: ec,
 		Overwrite:   overwrite,
+		Dedup:       dedup,
+
+		RedundancyGroup: req.FormValue("redundancygroup"),
 	})
 	if err != nil {
 		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInternalServerError)
@@ -825,6 +2338,166 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 	WriteSuccess(w)
 }
 
+// renterUploadBatchHandler handles the API call to upload a batch of small
+// files.
+func (api *API) renterUploadBatchHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var files []modules.RenterBatchFile
+	if err := json.Unmarshal([]byte(req.FormValue("files")), &files); err != nil {
+		WriteError(w, Error{"unable to parse files: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	for _, f := range files {
+		if !filepath.IsAbs(f.Source) {
+			WriteError(w, Error{"source must be an absolute path: " + f.Source}, http.StatusBadRequest)
+			return
+		}
+	}
+	id, err := api.renter.UploadBatch(files)
+	if err != nil {
+		WriteError(w, Error{"unable to upload batch: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterUploadBatchPOST{ID: id})
+}
+
+// renterUploadBatchesHandler handles the API call to list the progress of the
+// batch uploads.
+func (api *API) renterUploadBatchesHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterUploadBatchesGET{
+		Batches: api.renter.UploadBatches(),
+	})
+}
+
+// renterUploadScheduleHandler handles the API call to schedule an upload.
+func (api *API) renterUploadScheduleHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	source, err := url.QueryUnescape(req.FormValue("source"))
+	if err != nil {
+		WriteError(w, Error{"failed to unescape the source path"}, http.StatusBadRequest)
+		return
+	}
+	if !filepath.IsAbs(source) {
+		WriteError(w, Error{"source must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	var startTime int64
+	if _, err := fmt.Sscan(req.FormValue("starttime"), &startTime); err != nil {
+		WriteError(w, Error{"unable to parse starttime: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	overwrite := false
+	if req.FormValue("overwrite") != "" {
+		overwrite, err = strconv.ParseBool(req.FormValue("overwrite"))
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'overwrite' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	dataPieces, parityPieces, err := scanErasureCodeParams(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	su, err := api.renter.ScheduleUpload(modules.ScheduledUpload{
+		SiaPath:      strings.TrimPrefix(ps.ByName("hyperspacepath"), "/"),
+		Source:       source,
+		StartTime:    time.Unix(startTime, 0),
+		DataPieces:   dataPieces,
+		ParityPieces: parityPieces,
+		Overwrite:    overwrite,
+	})
+	if err != nil {
+		WriteError(w, Error{"unable to schedule upload: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterScheduledUploadPOST{su})
+}
+
+// renterLocalBackupHandlerGET handles the API call to list the local
+// snapshots of the file metadata.
+func (api *API) renterLocalBackupHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	backups, err := api.renter.LocalBackups()
+	if err != nil {
+		WriteError(w, Error{"unable to list local snapshots: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterLocalBackupGET{backups})
+}
+
+// renterLocalBackupHandlerPOST handles the API call to configure the local
+// snapshots of the file metadata.
+func (api *API) renterLocalBackupHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var config modules.RenterLocalBackupConfig
+	config.Path = req.FormValue("path")
+	if c := req.FormValue("count"); c != "" {
+		if _, err := fmt.Sscan(c, &config.Count); err != nil {
+			WriteError(w, Error{"unable to parse count: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if i := req.FormValue("interval"); i != "" {
+		var seconds uint64
+		if _, err := fmt.Sscan(i, &seconds); err != nil {
+			WriteError(w, Error{"unable to parse interval: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		config.Interval = time.Duration(seconds) * time.Second
+	}
+	if err := api.renter.SetLocalBackupConfig(config); err != nil {
+		WriteError(w, Error{"unable to configure local backups: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterLocalBackupRestoreHandler handles the API call to restore the file
+// metadata from a local snapshot.
+func (api *API) renterLocalBackupRestoreHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	restored, err := api.renter.RestoreLocalBackup(req.FormValue("name"))
+	if err != nil {
+		WriteError(w, Error{"unable to restore local snapshot: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterLocalBackupRestorePOST{FilesRestored: restored})
+}
+
+// renterScheduledUploadsHandler handles the API call to list the scheduled
+// uploads.
+func (api *API) renterScheduledUploadsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterScheduledUploadsGET{
+		Uploads: api.renter.ScheduledUploads(),
+	})
+}
+
+// renterScheduledUploadCancelHandler handles the API call to cancel a
+// scheduled upload that hasn't started yet.
+func (api *API) renterScheduledUploadCancelHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	id := req.FormValue("id")
+	if id == "" {
+		WriteError(w, Error{"id must be specified"}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.CancelScheduledUpload(id); err != nil {
+		WriteError(w, Error{"unable to cancel scheduled upload: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterDirHandlerGET handles the API call to describe a directory
+func (api *API) renterDirHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	dir, dirs, files, err := api.renter.DirInfo(strings.TrimPrefix(ps.ByName("hyperspacepath"), "/"))
+	if err != nil {
+		WriteError(w, Error{"failed to get directory: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterDirectoryGET{
+		Directory:   dir,
+		Directories: dirs,
+		Files:       files,
+	})
+}
+
 // renterDirHandlerPOST handles the API call to create a directory
 func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	// Parse action
@@ -843,6 +2516,25 @@ func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, p
 		WriteSuccess(w)
 		return
 	}
+	if action == "redundancy" {
+		var dataPieces, parityPieces int
+		if _, err := fmt.Sscan(req.FormValue("datapieces"), &dataPieces); err != nil {
+			WriteError(w, Error{"unable to read parameter 'datapieces': " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if _, err := fmt.Sscan(req.FormValue("paritypieces"), &parityPieces); err != nil {
+			WriteError(w, Error{"unable to read parameter 'paritypieces': " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		// Call the renter to set the redundancy of the directory
+		err := api.renter.SetDirRedundancy(strings.TrimPrefix(ps.ByName("hyperspacepath"), "/"), dataPieces, parityPieces)
+		if err != nil {
+			WriteError(w, Error{"failed to set directory redundancy: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
 	if action == "delete" {
 		fmt.Println("delete")
 		// TODO - implement
@@ -29,6 +29,7 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 		router.POST("/consensus/validate/transactionset", api.consensusValidateTransactionsetHandler)
 		router.GET("/consensus/blocks/:height", api.consensusBlocksHandlerSanasol)
 		router.GET("/consensus/future/:height", api.consensusFutureBlocksHandler)
+		router.GET("/consensus/utxos", api.consensusUTXOsHandler)
 	}
 
 	// Explorer API Calls
@@ -66,6 +67,7 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 	// Gateway API Calls
 	if api.gateway != nil {
 		router.GET("/gateway", api.gatewayHandler)
+		router.POST("/gateway", RequirePassword(api.gatewayHandlerPOST, requiredPassword))
 		router.POST("/gateway/connect/:netaddress", RequirePassword(api.gatewayConnectHandler, requiredPassword))
 		router.POST("/gateway/disconnect/:netaddress", RequirePassword(api.gatewayDisconnectHandler, requiredPassword))
 	}
@@ -77,13 +79,21 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 		router.POST("/host", RequirePassword(api.hostHandlerPOST, requiredPassword))              // Change the settings of the host.
 		router.POST("/host/announce", RequirePassword(api.hostAnnounceHandler, requiredPassword)) // Announce the host to the network.
 		router.GET("/host/contracts", api.hostContractInfoHandler)                                // Get info about contracts.
+		router.POST("/host/benchmark", RequirePassword(api.hostBenchmarkHandler, requiredPassword))
 		router.GET("/host/estimatescore", api.hostEstimateScoreGET)
+		router.POST("/host/drain", RequirePassword(api.hostDrainHandler, requiredPassword))
+		router.GET("/host/earnings", api.hostEarningsHandlerGET)
+		router.POST("/host/earnings/export", RequirePassword(api.hostEarningsExportHandler, requiredPassword))
+		router.POST("/host/prune", RequirePassword(api.hostPruneHandler, requiredPassword))
+		router.POST("/host/undrain", RequirePassword(api.hostUndrainHandler, requiredPassword))
 
 		// Calls pertaining to the storage manager that the host uses.
 		router.GET("/host/storage", api.storageHandler)
 		router.POST("/host/storage/folders/add", RequirePassword(api.storageFoldersAddHandler, requiredPassword))
 		router.POST("/host/storage/folders/remove", RequirePassword(api.storageFoldersRemoveHandler, requiredPassword))
 		router.POST("/host/storage/folders/resize", RequirePassword(api.storageFoldersResizeHandler, requiredPassword))
+		router.GET("/host/storage/migrate", api.storageMigrateHandlerGET)
+		router.POST("/host/storage/migrate", RequirePassword(api.storageMigrateHandlerPOST, requiredPassword))
 		router.POST("/host/storage/sectors/delete/:merkleroot", RequirePassword(api.storageSectorsDeleteHandler, requiredPassword))
 	}
 
@@ -111,13 +121,59 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 	if api.renter != nil {
 		router.GET("/renter", api.renterHandlerGET)
 		router.POST("/renter", RequirePassword(api.renterHandlerPOST, requiredPassword))
+		router.GET("/renter/allowance/recommend", api.renterAllowanceRecommendHandler)
+		router.GET("/renter/alerts", api.renterAlertsHandler)
+		router.GET("/renter/redundancygroups", api.renterRedundancyGroupsHandler)
+		router.GET("/renter/erasurescheme", api.renterErasureSchemeHandler)
+		router.GET("/renter/repairestimate", api.renterRepairEstimateHandler)
+		router.GET("/renter/throughputhistory", api.renterThroughputHistoryHandler)
+		router.GET("/renter/renewalschedule", api.renterRenewalScheduleHandler)
+		router.POST("/renter/speedtest", RequirePassword(api.renterSpeedTestHandlerPOST, requiredPassword))
+		router.GET("/renter/trial", api.renterTrialHandlerGET)
+		router.POST("/renter/trial", RequirePassword(api.renterTrialHandlerPOST, requiredPassword))
+		router.POST("/renter/trial/test", RequirePassword(api.renterTrialTestHandlerPOST, requiredPassword))
+		router.GET("/renter/workers", api.renterWorkersHandler)
+		router.GET("/renter/workers/detailed", api.renterWorkersDetailedHandler)
 		router.POST("/renter/contract/cancel", RequirePassword(api.renterContractCancelHandler, requiredPassword))
+		router.POST("/renter/contract/metadata", RequirePassword(api.renterContractMetadataHandler, requiredPassword))
+		router.POST("/renter/contract/reassociate", RequirePassword(api.renterContractReassociateHandler, requiredPassword))
+		router.GET("/renter/contract/revision", RequirePassword(api.renterContractRevisionHandler, requiredPassword))
 		router.GET("/renter/contracts", api.renterContractsHandler)
+		router.GET("/renter/auditlog", api.renterAuditLogHandlerGET)
+		router.POST("/renter/auditlog/export", RequirePassword(api.renterAuditLogExportHandler, requiredPassword))
+		router.POST("/renter/debug/simulatehostfailure", RequirePassword(api.renterSimulateHostFailureHandler, requiredPassword))
+		router.POST("/renter/debug/restorehost", RequirePassword(api.renterRestoreHostHandler, requiredPassword))
 		router.GET("/renter/downloads", api.renterDownloadsHandler)
 		router.POST("/renter/downloads/clear", RequirePassword(api.renterClearDownloadsHandler, requiredPassword))
+		router.POST("/renter/downloads/pause", RequirePassword(api.renterDownloadPauseHandler, requiredPassword))
+		router.POST("/renter/downloads/resume", RequirePassword(api.renterDownloadResumeHandler, requiredPassword))
+		router.POST("/renter/downloads/throttle", RequirePassword(api.renterDownloadThrottleHandler, requiredPassword))
 		router.GET("/renter/files", api.renterFilesHandler)
+		router.POST("/renter/files/verify", RequirePassword(api.renterFilesVerifyHandler, requiredPassword))
+		router.POST("/renter/maintenance/pause", RequirePassword(api.renterMaintenancePauseHandler, requiredPassword))
+		router.POST("/renter/maintenance/resume", RequirePassword(api.renterMaintenanceResumeHandler, requiredPassword))
 		router.GET("/renter/file/*hyperspacepath", api.renterFileHandlerGET)
+		router.POST("/renter/host/contracts/cancel", RequirePassword(api.renterHostContractsCancelHandler, requiredPassword))
+		router.GET("/renter/localbackup", api.renterLocalBackupHandlerGET)
+		router.POST("/renter/localbackup", RequirePassword(api.renterLocalBackupHandlerPOST, requiredPassword))
+		router.POST("/renter/localbackup/restore", RequirePassword(api.renterLocalBackupRestoreHandler, requiredPassword))
+		router.GET("/renter/lostfiles", api.renterLostFilesHandler)
+		router.GET("/renter/manifest/*hyperspacepath", api.renterManifestHandler)
+		router.GET("/renter/orphanedsectors", api.renterOrphanedSectorsHandler)
+		router.POST("/renter/orphanedsectors/reclaim", RequirePassword(api.renterOrphanedSectorsReclaimHandler, requiredPassword))
 		router.GET("/renter/prices", api.renterPricesHandler)
+		router.GET("/renter/scheduleduploads", api.renterScheduledUploadsHandler)
+		router.POST("/renter/scheduleduploads/cancel", RequirePassword(api.renterScheduledUploadCancelHandler, requiredPassword))
+		router.GET("/renter/recoverablecontracts", RequirePassword(api.renterRecoverableContractsHandler, requiredPassword))
+		router.POST("/renter/recoverablecontracts/recover", RequirePassword(api.renterRecoverContractsHandler, requiredPassword))
+		router.POST("/renter/recoveryhint/restore", RequirePassword(api.renterRecoveryHintRestoreHandler, requiredPassword))
+		router.POST("/renter/recoveryhint/sync", RequirePassword(api.renterRecoveryHintSyncHandler, requiredPassword))
+		router.POST("/renter/uploadbatch", RequirePassword(api.renterUploadBatchHandler, requiredPassword))
+		router.GET("/renter/uploadbatches", api.renterUploadBatchesHandler)
+		router.POST("/renter/verifymanifest", RequirePassword(api.renterVerifyManifestHandler, requiredPassword))
+		router.GET("/renter/webhooks", api.renterWebhooksHandlerGET)
+		router.POST("/renter/webhooks", RequirePassword(api.renterWebhooksHandlerPOST, requiredPassword))
+		router.POST("/renter/webhooks/remove", RequirePassword(api.renterWebhooksRemoveHandler, requiredPassword))
 
 		// TODO: re-enable these routes once the new .sia format has been
 		// standardized and implemented.
@@ -129,19 +185,43 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 		router.POST("/renter/delete/*hyperspacepath", RequirePassword(api.renterDeleteHandler, requiredPassword))
 		router.GET("/renter/download/*hyperspacepath", RequirePassword(api.renterDownloadHandler, requiredPassword))
 		router.GET("/renter/downloadasync/*hyperspacepath", RequirePassword(api.renterDownloadAsyncHandler, requiredPassword))
+		router.GET("/renter/downloadbyhash/:hash", RequirePassword(api.renterDownloadByHashHandler, requiredPassword))
+		router.GET("/renter/downloadcost/*hyperspacepath", api.renterDownloadCostHandlerGET)
+		router.POST("/renter/downloadrepair/*hyperspacepath", RequirePassword(api.renterDownloadRepairHandler, requiredPassword))
+		router.POST("/renter/downloadtonode/*hyperspacepath", RequirePassword(api.renterDownloadToNodeHandler, requiredPassword))
+		router.GET("/renter/effectiveredundancy/*hyperspacepath", api.renterEffectiveRedundancyHandler)
+		router.GET("/renter/history/*hyperspacepath", api.renterHistoryHandler)
+		router.GET("/renter/rebuild/*hyperspacepath", RequirePassword(api.renterRebuildHandlerGET, requiredPassword))
+		router.POST("/renter/rebuild/*hyperspacepath", RequirePassword(api.renterRebuildHandlerPOST, requiredPassword))
+		router.GET("/renter/budgets", api.renterRepairBudgetsHandler)
+		router.POST("/renter/budget/*hyperspacepath", RequirePassword(api.renterRepairBudgetHandler, requiredPassword))
+		router.POST("/renter/redundancy/*hyperspacepath", RequirePassword(api.renterRedundancyHandler, requiredPassword))
+		router.POST("/renter/rekey/*hyperspacepath", RequirePassword(api.renterRekeyHandler, requiredPassword))
 		router.POST("/renter/rename/*hyperspacepath", RequirePassword(api.renterRenameHandler, requiredPassword))
 		router.GET("/renter/stream/*hyperspacepath", api.renterStreamHandler)
 		router.POST("/renter/upload/*hyperspacepath", RequirePassword(api.renterUploadHandler, requiredPassword))
+		router.POST("/renter/uploadschedule/*hyperspacepath", RequirePassword(api.renterUploadScheduleHandler, requiredPassword))
+		router.GET("/renter/uploadstream/*hyperspacepath", RequirePassword(api.renterUploadStreamHandlerGET, requiredPassword))
+		router.POST("/renter/uploadstream/*hyperspacepath", RequirePassword(api.renterUploadStreamHandlerPOST, requiredPassword))
 		router.POST("/renter/file/*hyperspacepath", RequirePassword(api.renterFileHandlerPOST, requiredPassword))
 
 		// Directory endpoints
-		router.POST("/renter/dir/*siapath", RequirePassword(api.renterDirHandlerPOST, requiredPassword))
+		router.GET("/renter/dir/*hyperspacepath", api.renterDirHandlerGET)
+		router.POST("/renter/dir/*hyperspacepath", RequirePassword(api.renterDirHandlerPOST, requiredPassword))
 
 		// HostDB endpoints.
 		router.GET("/hostdb", api.hostdbHandler)
 		router.GET("/hostdb/active", api.hostdbActiveHandler)
 		router.GET("/hostdb/all", api.hostdbAllHandler)
+		router.GET("/hostdb/export", api.hostdbExportHandler)
+		router.POST("/hostdb/import", RequirePassword(api.hostdbImportHandler, requiredPassword))
 		router.GET("/hostdb/hosts/:pubkey", api.hostdbHostsHandler)
+		router.POST("/hostdb/rescan", RequirePassword(api.hostdbRescanHandler, requiredPassword))
+		router.GET("/hostdb/rescan/status", api.hostdbRescanStatusHandler)
+		router.GET("/hostdb/reputationfeed", api.hostdbReputationFeedHandlerGET)
+		router.POST("/hostdb/reputationfeed", RequirePassword(api.hostdbReputationFeedHandlerPOST, requiredPassword))
+		router.GET("/renter/hostdb/filtermode", api.hostdbFilterModeHandlerGET)
+		router.POST("/renter/hostdb/filtermode", RequirePassword(api.hostdbFilterModeHandlerPOST, requiredPassword))
 	}
 
 	if api.stratumminer != nil {
@@ -156,6 +236,7 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 		router.GET("/tpool/raw/:id", api.tpoolRawHandlerGET)
 		router.POST("/tpool/raw", api.tpoolRawHandlerPOST)
 		router.GET("/tpool/confirmed/:id", api.tpoolConfirmedGET)
+		router.POST("/tpool/validate", api.tpoolValidateHandlerPOST)
 
 		// TODO: re-enable this route once the transaction pool API has been finalized
 		//router.GET("/transactionpool/transactions", api.transactionpoolTransactionsHandler)
@@ -166,27 +247,32 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 		router.GET("/wallet", api.walletHandler)
 		router.GET("/wallet/address", RequirePassword(api.walletGetAddressHandler, requiredPassword))
 		router.POST("/wallet/address", RequirePassword(api.walletCreateAddressHandler, requiredPassword))
+		router.POST("/wallet/address/timelocked", RequirePassword(api.walletTimelockedAddressHandler, requiredPassword))
 		router.GET("/wallet/addresses", api.walletAddressesHandler)
 		router.GET("/wallet/backup", RequirePassword(api.walletBackupHandler, requiredPassword))
 		router.GET("/wallet/build/transaction", api.walletBuildTransactionHandler)
+		router.GET("/wallet/feeestimate", api.walletFeeEstimateHandler)
 		router.POST("/wallet/init", RequirePassword(api.walletInitHandler, requiredPassword))
 		router.POST("/wallet/init/seed", RequirePassword(api.walletInitSeedHandler, requiredPassword))
 		router.POST("/wallet/lock", RequirePassword(api.walletLockHandler, requiredPassword))
 		router.POST("/wallet/seed", RequirePassword(api.walletSeedHandler, requiredPassword))
 		router.GET("/wallet/seeds", RequirePassword(api.walletSeedsHandler, requiredPassword))
 		router.POST("/wallet/spacecash", RequirePassword(api.walletSiacoinsHandler, requiredPassword))
+		router.POST("/wallet/spacecash/timelocked", RequirePassword(api.walletSiacoinsTimelockedHandler, requiredPassword))
 		router.POST("/wallet/siagkey", RequirePassword(api.walletSiagkeyHandler, requiredPassword))
 		router.POST("/wallet/sweep/seed", RequirePassword(api.walletSweepSeedHandler, requiredPassword))
 		router.GET("/wallet/transaction/:id", api.walletTransactionHandler)
 		router.GET("/wallet/transactions", api.walletTransactionsHandler)
 		router.GET("/wallet/transactions/:addr", api.walletTransactionsAddrHandler)
 		router.GET("/wallet/verify/address/:addr", api.walletVerifyAddressHandler)
+		router.GET("/wallet/verify/message", api.walletVerifyMessageHandler)
 		router.POST("/wallet/unlock", RequirePassword(api.walletUnlockHandler, requiredPassword))
 		router.POST("/wallet/changepassword", RequirePassword(api.walletChangePasswordHandler, requiredPassword))
 		router.GET("/wallet/unlockconditions/:addr", RequirePassword(api.walletUnlockConditionsHandlerGET, requiredPassword))
 		router.POST("/wallet/unlockconditions", RequirePassword(api.walletUnlockConditionsHandlerPOST, requiredPassword))
 		router.GET("/wallet/unspent", RequirePassword(api.walletUnspentHandler, requiredPassword))
 		router.POST("/wallet/sign", RequirePassword(api.walletSignHandler, requiredPassword))
+		router.POST("/wallet/sign/message", RequirePassword(api.walletSignMessageHandler, requiredPassword))
 		router.GET("/wallet/watch", RequirePassword(api.walletWatchHandlerGET, requiredPassword))
 		router.POST("/wallet/watch", RequirePassword(api.walletWatchHandlerPOST, requiredPassword))
 	}
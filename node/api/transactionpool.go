@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/base64"
+	"errors"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
@@ -32,6 +33,13 @@ type (
 	TpoolConfirmedGET struct {
 		Confirmed bool `json:"confirmed"`
 	}
+
+	// TpoolValidatePOST contains the result of validating a transaction set
+	// against the transaction pool.
+	TpoolValidatePOST struct {
+		Valid bool   `json:"valid"`
+		Error string `json:"error"`
+	}
 )
 
 // decodeTransactionID will decode a transaction id from a string.
@@ -75,10 +83,9 @@ func (api *API) tpoolRawHandlerGET(w http.ResponseWriter, req *http.Request, ps
 	})
 }
 
-// tpoolRawHandlerPOST takes a raw encoded transaction set and posts
-// it to the transaction pool, relaying it to the transaction pool's peers
-// regardless of if the set is accepted.
-func (api *API) tpoolRawHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+// decodeTransactionSet decodes the raw transaction and parents of a request
+// into a transaction set that can be given to the transaction pool.
+func decodeTransactionSet(req *http.Request) ([]types.Transaction, error) {
 	// Try accepting the transactions both as base64 and as clean values.
 	rawParents, err := base64.StdEncoding.DecodeString(req.FormValue("parents"))
 	if err != nil {
@@ -89,21 +96,28 @@ func (api *API) tpoolRawHandlerPOST(w http.ResponseWriter, req *http.Request, _
 		rawTransaction = []byte(req.FormValue("transaction"))
 	}
 
-	// Decode the transaction and parents into a transaction set that can be
-	// given to the transaction pool.
 	var parents []types.Transaction
 	var txn types.Transaction
 	err = encoding.Unmarshal(rawParents, &parents)
 	if err != nil {
-		WriteError(w, Error{"error decoding parents:" + err.Error()}, http.StatusBadRequest)
-		return
+		return nil, errors.New("error decoding parents:" + err.Error())
 	}
 	err = encoding.Unmarshal(rawTransaction, &txn)
 	if err != nil {
-		WriteError(w, Error{"error decoding transaction:" + err.Error()}, http.StatusBadRequest)
+		return nil, errors.New("error decoding transaction:" + err.Error())
+	}
+	return append(parents, txn), nil
+}
+
+// tpoolRawHandlerPOST takes a raw encoded transaction set and posts
+// it to the transaction pool, relaying it to the transaction pool's peers
+// regardless of if the set is accepted.
+func (api *API) tpoolRawHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	txnSet, err := decodeTransactionSet(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
 		return
 	}
-	txnSet := append(parents, txn)
 
 	// Re-broadcast the transactions, so that they are passed to any peers that
 	// may have rejected them earlier.
@@ -133,3 +147,26 @@ func (api *API) tpoolConfirmedGET(w http.ResponseWriter, req *http.Request, ps h
 		Confirmed: confirmed,
 	})
 }
+
+// tpoolValidateHandlerPOST checks a raw encoded transaction set against the
+// transaction pool and the current consensus set without accepting or
+// broadcasting it.
+func (api *API) tpoolValidateHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	txnSet, err := decodeTransactionSet(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// A set that is already in the pool is valid.
+	err = api.tpool.ValidateTransactionSet(txnSet)
+	if err != nil && err != modules.ErrDuplicateTransactionSet {
+		WriteJSON(w, TpoolValidatePOST{
+			Valid: false,
+			Error: err.Error(),
+		})
+		return
+	}
+	WriteJSON(w, TpoolValidatePOST{
+		Valid: true,
+	})
+}
@@ -28,6 +28,7 @@ type (
 		ConfirmedSiacoinBalance     types.Currency `json:"confirmedspacecashbalance"`
 		UnconfirmedOutgoingSiacoins types.Currency `json:"unconfirmedoutgoingspacecash"`
 		UnconfirmedIncomingSiacoins types.Currency `json:"unconfirmedincomingspacecash"`
+		TimelockedSiacoinBalance    types.Currency `json:"timelockedspacecashbalance"`
 
 		SiacoinClaimBalance types.Currency `json:"siacoinclaimbalance"`
 
@@ -40,6 +41,13 @@ type (
 		Address types.UnlockHash `json:"address"`
 	}
 
+	// WalletTimelockedAddressPOST contains a timelocked address of the wallet
+	// and its unlock conditions.
+	WalletTimelockedAddressPOST struct {
+		Address          types.UnlockHash       `json:"address"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+	}
+
 	// WalletAddressPOST contains an address returned by a POST call to
 	// /wallet/address.
 	WalletAddressPOST struct {
@@ -76,6 +84,12 @@ type (
 		Transaction types.Transaction `json:"transaction"`
 	}
 
+	// WalletSignMessagePOST contains the signature of a message signed with
+	// the key of a wallet address.
+	WalletSignMessagePOST struct {
+		Signature string `json:"signature"`
+	}
+
 	// WalletSeedsGET contains the seeds used by the wallet.
 	WalletSeedsGET struct {
 		PrimarySeed        string   `json:"primaryseed"`
@@ -86,8 +100,9 @@ type (
 	// WalletSweepPOST contains the coins and funds returned by a call to
 	// /wallet/sweep.
 	WalletSweepPOST struct {
-		Coins types.Currency `json:"coins"`
-		Funds types.Currency `json:"funds"`
+		Coins          types.Currency        `json:"coins"`
+		Funds          types.Currency        `json:"funds"`
+		TransactionIDs []types.TransactionID `json:"transactionids"`
 	}
 
 	// WalletTransactionGETid contains the transaction returned by a call to
@@ -96,6 +111,12 @@ type (
 		Transaction modules.ProcessedTransaction `json:"transaction"`
 	}
 
+	// WalletFeeEstimateGET contains the fee and size of the transaction that
+	// sending coins to the requested outputs would create.
+	WalletFeeEstimateGET struct {
+		modules.WalletFeeEstimate
+	}
+
 	// WalletBuildTransactionGET contains the transaction returned by a call to
 	// /wallet/build/transaction
 	WalletBuildTransactionGET struct {
@@ -140,6 +161,12 @@ type (
 		Valid bool `json:"valid"`
 	}
 
+	// WalletVerifyMessageGET contains a bool indicating if a message signature
+	// passed to /wallet/verify/message is valid.
+	WalletVerifyMessageGET struct {
+		Valid bool `json:"valid"`
+	}
+
 	// WalletWatchPOST contains the set of addresses to add or remove from the
 	// watch set.
 	WalletWatchPOST struct {
@@ -182,6 +209,11 @@ func (api *API) walletHandler(w http.ResponseWriter, req *http.Request, _ httpro
 		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
 		return
 	}
+	timelockedBal, err := api.wallet.TimelockedBalance()
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		return
+	}
 	dustThreshold, err := api.wallet.DustThreshold()
 	if err != nil {
 		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
@@ -216,6 +248,7 @@ func (api *API) walletHandler(w http.ResponseWriter, req *http.Request, _ httpro
 		ConfirmedSiacoinBalance:     siacoinBal,
 		UnconfirmedOutgoingSiacoins: siacoinsOut,
 		UnconfirmedIncomingSiacoins: siacoinsIn,
+		TimelockedSiacoinBalance:    timelockedBal,
 
 		DustThreshold: dustThreshold,
 	})
@@ -497,6 +530,73 @@ func (api *API) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request,
 	})
 }
 
+// walletSiacoinsTimelockedHandler handles API calls to
+// /wallet/spacecash/timelocked.
+func (api *API) walletSiacoinsTimelockedHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		WriteError(w, Error{"could not read amount from POST call to /wallet/spacecash/timelocked"}, http.StatusBadRequest)
+		return
+	}
+	// The coins can be sent to any address whose unlock conditions are
+	// known. For an address of the wallet, the wallet looks them up.
+	var dest types.UnlockConditions
+	if ucs := req.FormValue("unlockconditions"); ucs != "" {
+		if err := json.Unmarshal([]byte(ucs), &dest); err != nil {
+			WriteError(w, Error{"could not read unlockconditions from POST call to /wallet/spacecash/timelocked: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	} else {
+		addr, err := scanAddress(req.FormValue("destination"))
+		if err != nil {
+			WriteError(w, Error{"could not read address from POST call to /wallet/spacecash/timelocked"}, http.StatusBadRequest)
+			return
+		}
+		dest, err = api.wallet.UnlockConditions(addr)
+		if err != nil {
+			WriteError(w, Error{"destination isn't an address of the wallet, provide its unlockconditions instead: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	var unlockHeight types.BlockHeight
+	if _, err := fmt.Sscan(req.FormValue("unlockheight"), &unlockHeight); err != nil {
+		WriteError(w, Error{"could not read unlockheight from POST call to /wallet/spacecash/timelocked: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	txns, err := api.wallet.SendSiacoinsTimelocked(amount, dest, unlockHeight)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/spacecash/timelocked: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiacoinsPOST{
+		TransactionIDs: txids,
+	})
+}
+
+// walletTimelockedAddressHandler handles API calls to
+// /wallet/address/timelocked.
+func (api *API) walletTimelockedAddressHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var unlockHeight types.BlockHeight
+	if _, err := fmt.Sscan(req.FormValue("unlockheight"), &unlockHeight); err != nil {
+		WriteError(w, Error{"could not read unlockheight from call to /wallet/address/timelocked: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	uc, err := api.wallet.TimelockedAddress(unlockHeight)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/address/timelocked: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletTimelockedAddressPOST{
+		Address:          uc.UnlockHash(),
+		UnlockConditions: uc,
+	})
+}
+
 // walletSweepSeedHandler handles API calls to /wallet/sweep/seed.
 func (api *API) walletSweepSeedHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Get the seed using the ditionary + phrase
@@ -510,14 +610,25 @@ func (api *API) walletSweepSeedHandler(w http.ResponseWriter, req *http.Request,
 		return
 	}
 
-	coins, funds, err := api.wallet.SweepSeed(seed)
+	coins, funds, txids, err := api.wallet.SweepSeed(seed)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/sweep/seed: " + err.Error()}, http.StatusBadRequest)
+		// The transactions submitted before the error sweep their coins
+		// anyway, so they are reported with it.
+		msg := "error when calling /wallet/sweep/seed: " + err.Error()
+		if len(txids) > 0 {
+			ids := make([]string, len(txids))
+			for i, txid := range txids {
+				ids[i] = txid.String()
+			}
+			msg += "; submitted transactions: " + strings.Join(ids, ", ")
+		}
+		WriteError(w, Error{msg}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletSweepPOST{
-		Coins: coins,
-		Funds: funds,
+		Coins:          coins,
+		Funds:          funds,
+		TransactionIDs: txids,
 	})
 }
 
@@ -708,6 +819,42 @@ func (api *API) walletBuildTransactionHandler(w http.ResponseWriter, req *http.R
 	})
 }
 
+// walletFeeEstimateHandler handles API calls to /wallet/feeestimate.
+func (api *API) walletFeeEstimateHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var outputs []types.SiacoinOutput
+	if req.FormValue("outputs") != "" {
+		// multiple amounts + destinations
+		if req.FormValue("amount") != "" || req.FormValue("destination") != "" {
+			WriteError(w, Error{"cannot supply both 'outputs' and single amount+destination pair"}, http.StatusBadRequest)
+			return
+		}
+		if err := json.Unmarshal([]byte(req.FormValue("outputs")), &outputs); err != nil {
+			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	} else {
+		// single amount + destination
+		amount, ok := scanAmount(req.FormValue("amount"))
+		if !ok {
+			WriteError(w, Error{"could not read amount from GET call to /wallet/feeestimate"}, http.StatusBadRequest)
+			return
+		}
+		dest, err := scanAddress(req.FormValue("destination"))
+		if err != nil {
+			WriteError(w, Error{"could not read address from GET call to /wallet/feeestimate"}, http.StatusBadRequest)
+			return
+		}
+		outputs = []types.SiacoinOutput{{Value: amount, UnlockHash: dest}}
+	}
+
+	estimate, err := api.wallet.EstimateFee(outputs)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/feeestimate: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletFeeEstimateGET{estimate})
+}
+
 // walletUnlockHandler handles API calls to /wallet/unlock.
 func (api *API) walletUnlockHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	potentialKeys := encryptionKeys(req.FormValue("encryptionpassword"))
@@ -822,6 +969,41 @@ func (api *API) walletSignHandler(w http.ResponseWriter, req *http.Request, _ ht
 	})
 }
 
+// walletSignMessageHandler handles API calls to /wallet/sign/message.
+func (api *API) walletSignMessageHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var addr types.UnlockHash
+	if err := addr.LoadString(req.FormValue("address")); err != nil {
+		WriteError(w, Error{"error when calling /wallet/sign/message: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	ms, err := api.wallet.SignMessage(addr, []byte(req.FormValue("message")))
+	if err != nil {
+		WriteError(w, Error{"failed to sign message: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletSignMessagePOST{
+		Signature: ms.String(),
+	})
+}
+
+// walletVerifyMessageHandler handles API calls to /wallet/verify/message. The
+// signature is checked without consulting the wallet, so any node can verify
+// it.
+func (api *API) walletVerifyMessageHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var addr types.UnlockHash
+	if err := addr.LoadString(req.FormValue("address")); err != nil {
+		WriteError(w, Error{"error when calling /wallet/verify/message: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var ms modules.MessageSignature
+	if err := ms.LoadString(req.FormValue("signature")); err != nil {
+		WriteError(w, Error{"error when calling /wallet/verify/message: unable to parse signature: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err := modules.VerifyMessage(addr, []byte(req.FormValue("message")), ms)
+	WriteJSON(w, WalletVerifyMessageGET{Valid: err == nil})
+}
+
 // walletWatchHandlerGET handles GET calls to /wallet/watch.
 func (api *API) walletWatchHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	addrs, err := api.wallet.WatchAddresses()
@@ -6,10 +6,35 @@ import (
 	"log"
 	"os"
 	"sync"
+	"sync/atomic"
 
 	"github.com/HyperspaceApp/Hyperspace/build"
 )
 
+// debugLogging is 1 if the Debug methods of all loggers write their messages.
+// It defaults to build.DEBUG and can be changed at runtime with
+// SetDebugLogging.
+var debugLogging = func() int32 {
+	if build.DEBUG {
+		return 1
+	}
+	return 0
+}()
+
+// SetDebugLogging enables or disables the debug messages of all loggers.
+func SetDebugLogging(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&debugLogging, v)
+}
+
+// DebugLogging returns true if the debug messages of all loggers are written.
+func DebugLogging() bool {
+	return atomic.LoadInt32(&debugLogging) == 1
+}
+
 // Logger is a wrapper for the standard library logger that enforces logging
 // with the Sia-standard settings. It also supports a Close method, which
 // attempts to close the underlying io.Writer.
@@ -37,26 +62,26 @@ func (l *Logger) Critical(v ...interface{}) {
 	build.Critical(v...)
 }
 
-// Debug is equivalent to Logger.Print when build.DEBUG is true. Otherwise it
-// is a no-op.
+// Debug is equivalent to Logger.Print when debug logging is enabled. Otherwise
+// it is a no-op.
 func (l *Logger) Debug(v ...interface{}) {
-	if build.DEBUG {
+	if DebugLogging() {
 		l.Output(2, fmt.Sprint(v...))
 	}
 }
 
-// Debugf is equivalent to Logger.Printf when build.DEBUG is true. Otherwise it
-// is a no-op.
+// Debugf is equivalent to Logger.Printf when debug logging is enabled.
+// Otherwise it is a no-op.
 func (l *Logger) Debugf(format string, v ...interface{}) {
-	if build.DEBUG {
+	if DebugLogging() {
 		l.Output(2, fmt.Sprintf(format, v...))
 	}
 }
 
-// Debugln is equivalent to Logger.Println when build.DEBUG is true. Otherwise
-// it is a no-op.
+// Debugln is equivalent to Logger.Println when debug logging is enabled.
+// Otherwise it is a no-op.
 func (l *Logger) Debugln(v ...interface{}) {
-	if build.DEBUG {
+	if DebugLogging() {
 		l.Output(2, "[DEBUG] "+fmt.Sprintln(v...))
 	}
 }
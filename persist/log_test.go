@@ -1,6 +1,7 @@
 package persist
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -87,3 +88,23 @@ func TestLoggerCritical(t *testing.T) {
 	}()
 	fl.Critical("a critical message")
 }
+
+// TestSetDebugLogging checks that debug messages are only written while debug
+// logging is enabled.
+func TestSetDebugLogging(t *testing.T) {
+	enabled := DebugLogging()
+	defer SetDebugLogging(enabled)
+
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	SetDebugLogging(false)
+	l.Debugln("hidden")
+	SetDebugLogging(true)
+	l.Debugln("shown")
+	if strings.Contains(buf.String(), "hidden") {
+		t.Error("debug message was written while debug logging was disabled")
+	}
+	if !strings.Contains(buf.String(), "shown") {
+		t.Error("debug message wasn't written while debug logging was enabled")
+	}
+}
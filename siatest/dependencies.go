@@ -68,6 +68,14 @@ type (
 		modules.ProductionDependencies
 		lookupIP func(string) ([]net.IP, error)
 	}
+
+	// dependencyCustomHostWeight is a dependency which overrides the
+	// HostWeightFunc method to weigh the hosts of the hostdb with a custom
+	// function.
+	dependencyCustomHostWeight struct {
+		modules.ProductionDependencies
+		weightFn modules.HostWeightFunc
+	}
 )
 
 // LookupIP implements the modules.Resolver interface.
@@ -92,3 +100,14 @@ func (d *dependencyCustomResolver) Resolver() modules.Resolver {
 func NewDependencyCustomResolver(lookupIP func(string) ([]net.IP, error)) modules.Dependencies {
 	return &dependencyCustomResolver{lookupIP: lookupIP}
 }
+
+// HostWeightFunc returns the custom weight function.
+func (d *dependencyCustomHostWeight) HostWeightFunc() modules.HostWeightFunc {
+	return d.weightFn
+}
+
+// NewDependencyCustomHostWeight creates a dependency which makes the hostdb
+// select hosts at random using weightFn instead of its default weighting.
+func NewDependencyCustomHostWeight(weightFn modules.HostWeightFunc) modules.Dependencies {
+	return &dependencyCustomHostWeight{weightFn: weightFn}
+}
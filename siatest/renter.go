@@ -263,6 +263,50 @@ func (tn *TestNode) UploadNewFileBlocking(filesize int, dataPieces uint64, parit
 	return localFile, remoteFile, err
 }
 
+// UploadBatchBlocking uploads the files as a batch and waits until all packs of
+// the batch are uploaded. An error is returned if any pack failed.
+func (tn *TestNode) UploadBatchBlocking(lfs []*LocalFile) ([]*RemoteFile, error) {
+	files := make([]modules.RenterBatchFile, 0, len(lfs))
+	rfs := make([]*RemoteFile, 0, len(lfs))
+	for _, lf := range lfs {
+		siapath := tn.SiaPath(lf.path)
+		files = append(files, modules.RenterBatchFile{SiaPath: siapath, Source: lf.path})
+		rfs = append(rfs, &RemoteFile{
+			siaPath:  siapath,
+			checksum: lf.checksum,
+		})
+	}
+	rubp, err := tn.RenterUploadBatchPost(files)
+	if err != nil {
+		return nil, err
+	}
+	var batch modules.RenterUploadBatch
+	err = Retry(600, 100*time.Millisecond, func() error {
+		rubg, err := tn.RenterUploadBatchesGet()
+		if err != nil {
+			return err
+		}
+		for _, b := range rubg.Batches {
+			if b.ID != rubp.ID {
+				continue
+			}
+			if !b.Finished {
+				return fmt.Errorf("%v of %v packs uploaded", b.PacksUploaded, b.Packs)
+			}
+			batch = b
+			return nil
+		}
+		return errors.New("batch upload is not listed")
+	})
+	if err != nil {
+		return nil, err
+	}
+	if batch.Error != "" {
+		return nil, errors.New("batch upload failed: " + batch.Error)
+	}
+	return rfs, nil
+}
+
 // WaitForDownload waits for the download of a file to finish. If a file wasn't
 // scheduled for download it will return instantly without an error. If parent
 // is provided, it will compare the contents of the downloaded file to the
@@ -92,6 +92,72 @@ func TestInitialScanComplete(t *testing.T) {
 	}
 }
 
+// TestHostDBRescan tests that a rescan covers every host, reports its
+// progress and can be requested repeatedly.
+func TestHostDBRescan(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Get a directory for testing.
+	testDir := renterTestDir(t.Name())
+
+	// Create a group. The renter should block the scanning thread using a
+	// dependency.
+	deps := &dependencyBlockScan{}
+	renterTemplate := node.Renter(filepath.Join(testDir, "renter"))
+	renterTemplate.SkipSetAllowance = true
+	renterTemplate.SkipHostDiscovery = true
+	renterTemplate.HostDBDeps = deps
+
+	tg, err := siatest.NewGroup(testDir, renterTemplate, node.Host(filepath.Join(testDir, "host")),
+		siatest.Miner(filepath.Join(testDir, "miner")))
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		deps.Scan()
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Request the rescan twice while scanning is blocked. The second request
+	// shouldn't add the host again.
+	renter := tg.Renters()[0]
+	for i := 0; i < 2; i++ {
+		if err := renter.HostDbRescanPost(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	status, err := renter.HostDbRescanStatusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Active || status.Total != 1 || status.Pending != 1 || status.Done != 0 {
+		t.Fatalf("unexpected status of blocked rescan: %+v", status.HostDBRescanStatus)
+	}
+
+	// Unblock the scan and wait for the rescan to finish.
+	deps.Scan()
+	err = build.Retry(600, 100*time.Millisecond, func() error {
+		status, err := renter.HostDbRescanStatusGet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Active || status.Pending != 0 {
+			return fmt.Errorf("rescan should be complete: %+v", status.HostDBRescanStatus)
+		}
+		if status.Done != 1 || status.EndTime.IsZero() {
+			return fmt.Errorf("rescan should have scanned the host once: %+v", status.HostDBRescanStatus)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestPruneRedundantAddressRange checks if the contractor correctly cancels
 // contracts with redundant IP ranges.
 func TestPruneRedundantAddressRange(t *testing.T) {
@@ -100,6 +166,37 @@ func TestPruneRedundantAddressRange(t *testing.T) {
 	}
 	t.Parallel()
 
+	// host4 shares a /24 with host3.
+	testPruneRedundantAddressRange(t, map[string]net.IP{
+		"host1.com": {128, 0, 0, 1},
+		"host2.com": {129, 0, 0, 1},
+		"host3.com": {130, 0, 0, 1},
+		"host4.com": {130, 0, 0, 2},
+	})
+}
+
+// TestPruneRedundantAddressRangeIPv6 checks if the contractor correctly
+// cancels contracts with redundant IPv6 ranges.
+func TestPruneRedundantAddressRangeIPv6(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// host4 shares a /54 with host3.
+	testPruneRedundantAddressRange(t, map[string]net.IP{
+		"host1.com": net.ParseIP("2001:db8:1::1"),
+		"host2.com": net.ParseIP("2001:db8:2::1"),
+		"host3.com": net.ParseIP("2001:db8:3::1"),
+		"host4.com": net.ParseIP("2001:db8:3::2"),
+	})
+}
+
+// testPruneRedundantAddressRange announces 3 hosts as host1.com, host2.com and
+// host3.com and reannounces host1 as host4.com, which has to share an address
+// range with host3.com, and checks that one of the contracts with host3 and
+// host4 is cancelled.
+func testPruneRedundantAddressRange(t *testing.T, addresses map[string]net.IP) {
 	// Get the testDir for this test.
 	testDir := renterTestDir(t.Name())
 
@@ -147,18 +244,11 @@ func TestPruneRedundantAddressRange(t *testing.T) {
 	// Add a renter with a custom resolver to the group.
 	renterTemplate := node.Renter(testDir + "/renter")
 	renterTemplate.HostDBDeps = siatest.NewDependencyCustomResolver(func(host string) ([]net.IP, error) {
-		switch host {
-		case "host1.com":
-			return []net.IP{{128, 0, 0, 1}}, nil
-		case "host2.com":
-			return []net.IP{{129, 0, 0, 1}}, nil
-		case "host3.com":
-			return []net.IP{{130, 0, 0, 1}}, nil
-		case "host4.com":
-			return []net.IP{{130, 0, 0, 2}}, nil
-		default:
+		ip, exists := addresses[host]
+		if !exists {
 			panic("shouldn't happen")
 		}
+		return []net.IP{ip}, nil
 	})
 	renterTemplate.ContractorDeps = renterTemplate.HostDBDeps
 	_, err = tg.AddNodes(renterTemplate)
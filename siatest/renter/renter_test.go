@@ -1,6 +1,7 @@
 package renter
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -89,6 +90,7 @@ func TestRenter(t *testing.T) {
 		{"TestDownloadAfterRenew", testDownloadAfterRenew},
 		{"TestDownloadMultipleLargeSectors", testDownloadMultipleLargeSectors},
 		{"TestLocalRepair", testLocalRepair},
+		{"TestUploadBatch", testUploadBatch},
 	}
 
 	// Run tests
@@ -117,6 +119,7 @@ func TestRenterTwo(t *testing.T) {
 		{"TestRemoteRepair", testRemoteRepair},
 		{"TestSingleFileGet", testSingleFileGet},
 		{"TestStreamingCache", testStreamingCache},
+		{"TestStreamRange", testStreamRange},
 		{"TestUploadDownload", testUploadDownload},
 		{"TestSiaFileTimestamps", testSiafileTimestamps},
 	}
@@ -590,6 +593,75 @@ func testLocalRepair(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testUploadBatch tests that the files of a batch, which share the chunk of
+// their pack, are extracted from their own offset within the pack when they are
+// downloaded and when a local copy of them is verified.
+func testUploadBatch(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the first of the group's renters
+	r := tg.Renters()[0]
+
+	// Upload a batch of files with different sizes, so each of them is stored
+	// at a different offset of the pack.
+	var lfs []*siatest.LocalFile
+	for _, size := range []int{100, 1000, 3000} {
+		lf, err := r.UploadDir().NewFile(size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lfs = append(lfs, lf)
+	}
+	rfs, err := r.UploadBatchBlocking(lfs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, rf := range rfs {
+		// Downloading the file verifies its checksum.
+		if _, err := r.DownloadByStream(rf); err != nil {
+			t.Fatal("Failed to stream packed file", i, err)
+		}
+		downloaded, err := r.DownloadToDisk(rf, false)
+		if err != nil {
+			t.Fatal("Failed to download packed file", i, err)
+		}
+
+		// The downloaded copy matches the pack.
+		localPath := filepath.Join(r.DownloadDir().Path(), downloaded.FileName())
+		rdr, err := r.RenterDownloadRepairPost(rf.SiaPath(), localPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rdr.ChunksVerified != 1 || rdr.ChunksRepaired != 0 {
+			t.Fatalf("intact copy of packed file %v wasn't verified: %+v", i, rdr)
+		}
+
+		// A corrupt copy is repaired.
+		data, err := ioutil.ReadFile(localPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		corrupt := append([]byte(nil), data...)
+		corrupt[len(corrupt)/2]++
+		if err := ioutil.WriteFile(localPath, corrupt, 0600); err != nil {
+			t.Fatal(err)
+		}
+		rdr, err = r.RenterDownloadRepairPost(rf.SiaPath(), localPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rdr.ChunksRepaired != 1 {
+			t.Fatalf("corrupt copy of packed file %v wasn't repaired: %+v", i, rdr)
+		}
+		repaired, err := ioutil.ReadFile(localPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(repaired, data) {
+			t.Fatal("repaired copy of packed file doesn't match", i)
+		}
+	}
+}
+
 // testRemoteRepair tests if a renter correctly repairs a file by
 // downloading it after a host goes offline.
 func testRemoteRepair(t *testing.T, tg *siatest.TestGroup) {
@@ -847,6 +919,41 @@ func testUploadDownload(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testStreamRange tests that the streaming endpoint serves ranges that span
+// several chunks and rejects ranges beyond the end of the file.
+func testStreamRange(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the first of the group's renters
+	renter := tg.Renters()[0]
+	// Upload a file that spans several chunks.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	fileSize := int(3*modules.SectorSize) + siatest.Fuzz()
+	localFile, remoteFile, err := renter.UploadNewFileBlocking(fileSize, dataPieces, parityPieces)
+	if err != nil {
+		t.Fatal("Failed to upload a file for testing: ", err)
+	}
+	// Stream ranges within a single chunk, across chunk boundaries and up to
+	// the end of the file.
+	ranges := [][2]uint64{
+		{0, 0},
+		{10, 100},
+		{modules.SectorSize / 2, modules.SectorSize + modules.SectorSize/2},
+		{modules.SectorSize - 1, 2*modules.SectorSize + 1},
+		{1, uint64(fileSize) - 2},
+		{uint64(fileSize) - 1, uint64(fileSize) - 1},
+	}
+	for _, r := range ranges {
+		if _, err := renter.StreamPartial(remoteFile, localFile, r[0], r[1]); err != nil {
+			t.Fatalf("failed to stream range %v-%v: %v", r[0], r[1], err)
+		}
+	}
+	// A range that starts beyond the end of the file can't be satisfied.
+	_, err = renter.RenterStreamPartialGet(remoteFile.SiaPath(), uint64(fileSize), uint64(fileSize)+10)
+	if err != client.ErrRangeNotSatisfiable {
+		t.Fatal("expected ErrRangeNotSatisfiable, got", err)
+	}
+}
+
 // TestRenterInterrupt executes a number of subtests using the same TestGroup to
 // save time on initialization
 func TestRenterInterrupt(t *testing.T) {
@@ -1126,6 +1233,71 @@ func testUploadInterrupted(t *testing.T, tg *siatest.TestGroup, deps *siatest.De
 	}
 }
 
+// TestRenterHostContractsCancel tests that all contracts with a host can be
+// cancelled at once and that the files storing pieces on it are reported.
+func TestRenterHostContractsCancel(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group with 2 hosts.
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Renters: 1,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(renterTestDir(t.Name()), groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Upload a file with a piece on every host.
+	r := tg.Renters()[0]
+	if _, _, err := r.UploadNewFileBlocking(int(modules.SectorSize), 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	rc, err := r.RenterContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rc.ActiveContracts) != 2 {
+		t.Fatalf("Expected 2 active contracts but got %v", len(rc.ActiveContracts))
+	}
+	contract := rc.ActiveContracts[0]
+
+	// Cancel the contracts with the host of the first contract.
+	result, err := r.RenterHostContractsCancelPost(contract.HostPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Contracts) != 1 || result.Contracts[0] != contract.ID {
+		t.Fatal("Expected the contract with the host to be cancelled, got", result.Contracts)
+	}
+	if result.AffectedFiles != 1 {
+		t.Fatal("Expected 1 affected file but got", result.AffectedFiles)
+	}
+	rc, err = r.RenterInactiveContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range rc.ActiveContracts {
+		if c.ID == contract.ID {
+			t.Fatal("Contract not cancelled, contract found in Active Contracts")
+		}
+	}
+
+	// There are no active contracts with the host left to cancel.
+	if _, err := r.RenterHostContractsCancelPost(contract.HostPublicKey); err == nil {
+		t.Fatal("Expected cancelling the contracts with the host again to fail")
+	}
+}
+
 // TestRenterAddNodes runs a subset of tests that require adding their own renter
 func TestRenterAddNodes(t *testing.T) {
 	if testing.Short() {
@@ -2990,3 +3162,311 @@ func testSetFileTrackingPath(t *testing.T, tg *siatest.TestGroup) {
 		t.Fatal("Changing repair path to a nonexistent file shouldn't work")
 	}
 }
+
+// TestRenterContractRefresh tests that contracts which are running out of
+// funds are refreshed before the end of the period and that uploading
+// continues with the refreshed contracts.
+func TestRenterContractRefresh(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group with a renter whose contracts are refreshed as soon as
+	// a small share of their funds is spent.
+	groupParams := siatest.GroupParams{
+		Hosts:  2,
+		Miners: 1,
+	}
+	testDir := siatest.TestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group:", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	renterParams := node.Renter(filepath.Join(testDir, "renter"))
+	renterParams.Allowance = siatest.DefaultAllowance
+	renterParams.Allowance.Hosts = uint64(len(tg.Hosts()))
+	renterParams.Allowance.RefreshThreshold = 0.999
+	nodes, err := tg.AddNodes(renterParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := nodes[0]
+	rc, err := r.RenterContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	originalContracts := make(map[types.FileContractID]struct{})
+	for _, c := range rc.ActiveContracts {
+		originalContracts[c.ID] = struct{}{}
+	}
+
+	// Upload files and mine blocks until a contract was refreshed.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	miner := tg.Miners()[0]
+	err = build.Retry(10, 100*time.Millisecond, func() error {
+		if _, _, err := r.UploadNewFileBlocking(int(modules.SectorSize), dataPieces, parityPieces); err != nil {
+			return err
+		}
+		if err := miner.MineBlock(); err != nil {
+			return err
+		}
+		return build.Retry(50, 100*time.Millisecond, func() error {
+			rc, err := r.RenterInactiveContractsGet()
+			if err != nil {
+				return err
+			}
+			if len(rc.ActiveContracts) != len(tg.Hosts()) {
+				return fmt.Errorf("expected %v active contracts, got %v", len(tg.Hosts()), len(rc.ActiveContracts))
+			}
+			for _, c := range rc.InactiveContracts {
+				if _, exists := originalContracts[c.ID]; exists {
+					return nil
+				}
+			}
+			return errors.New("no contract was refreshed")
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The refreshed contracts are active and uploads and downloads keep
+	// working.
+	rc, err = r.RenterContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshed := 0
+	for _, c := range rc.ActiveContracts {
+		if _, exists := originalContracts[c.ID]; !exists {
+			refreshed++
+		}
+	}
+	if refreshed == 0 {
+		t.Fatal("no refreshed contract is active")
+	}
+	_, rf, err := r.UploadNewFileBlocking(int(modules.SectorSize), dataPieces, parityPieces)
+	if err != nil {
+		t.Fatal("upload failed after the refresh:", err)
+	}
+	if _, err := r.DownloadByStream(rf); err != nil {
+		t.Fatal("download failed after the refresh:", err)
+	}
+}
+
+// TestRenterRecoverContracts tests that a renter that restored the recovery
+// hint of another renter's seed can list and recover the contracts of that
+// renter.
+func TestRenterRecoverContracts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group for testing
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Renters: 1,
+		Miners:  1,
+	}
+	testDir := siatest.TestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group:", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Upload a file and sync the recovery hint of the renter.
+	r := tg.Renters()[0]
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	if _, _, err := r.UploadNewFileBlocking(int(modules.SectorSize), dataPieces, parityPieces); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RenterRecoveryHintSyncPost(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tg.Miners()[0].MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// No file references the sector of the hint, but it is pinned and
+	// shouldn't be reclaimed.
+	rosg, err := r.RenterOrphanedSectorsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rosg.OrphanedSectors != 0 {
+		t.Fatalf("expected no orphaned sectors, got %v", rosg.OrphanedSectors)
+	}
+	rc, err := r.RenterContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	contracts := make(map[types.FileContractID]uint64)
+	for _, c := range rc.ActiveContracts {
+		contracts[c.ID] = c.Size
+	}
+	wsg, err := r.WalletSeedsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Restore the hint on a new renter without contracts.
+	renterParams := node.Renter(filepath.Join(testDir, "recovering"))
+	renterParams.SkipSetAllowance = true
+	nodes, err := tg.AddNodes(renterParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovering := nodes[0]
+	if _, err := recovering.RenterRecoveryHintRestorePost(wsg.PrimarySeed); err != nil {
+		t.Fatal(err)
+	}
+
+	// All contracts of the hint should be recoverable once the hostdb knows
+	// the hosts.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rrcg, err := recovering.RenterRecoverableContractsGet()
+		if err != nil {
+			return err
+		}
+		if len(rrcg.Contracts) != len(contracts) {
+			return fmt.Errorf("expected %v recoverable contracts, got %v", len(contracts), len(rrcg.Contracts))
+		}
+		for _, c := range rrcg.Contracts {
+			if _, exists := contracts[c.ID]; !exists {
+				return fmt.Errorf("unexpected recoverable contract %v", c.ID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Recover the contracts. They should be part of the contract set
+	// afterwards and no longer be recoverable.
+	rrcp, err := recovering.RenterRecoverContractsPost()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rrcp.Contracts) != len(contracts) {
+		t.Fatalf("expected %v recovered contracts, got %v", len(contracts), len(rrcp.Contracts))
+	}
+	for _, c := range rrcp.Contracts {
+		if size, exists := contracts[c.ID]; !exists || size != c.Size {
+			t.Fatalf("unexpected recovered contract %v of size %v", c.ID, c.Size)
+		}
+	}
+	rc, err = recovering.RenterContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered := make(map[types.FileContractID]struct{})
+	for _, c := range rc.Contracts {
+		recovered[c.ID] = struct{}{}
+	}
+	for id := range contracts {
+		if _, exists := recovered[id]; !exists {
+			t.Fatal("contract wasn't added to the contract set:", id)
+		}
+	}
+	rrcg, err := recovering.RenterRecoverableContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rrcg.Contracts) != 0 {
+		t.Fatal("recovered contracts are still recoverable:", len(rrcg.Contracts))
+	}
+}
+
+// TestRenterContractMigration tests that a contract cancelled with migration
+// is only cancelled once its data was uploaded to another host.
+func TestRenterContractMigration(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group with a host more than the file needs.
+	groupParams := siatest.GroupParams{
+		Hosts:   4,
+		Renters: 1,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(renterTestDir(t.Name()), groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Upload a file with a piece on 3 of the hosts.
+	r := tg.Renters()[0]
+	dataPieces, parityPieces := uint64(1), uint64(2)
+	_, rf, err := r.UploadNewFileBlocking(int(modules.SectorSize), dataPieces, parityPieces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := r.RenterContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contract api.RenterContract
+	for _, c := range rc.ActiveContracts {
+		if c.Size > 0 {
+			contract = c
+			break
+		}
+	}
+	if contract.Size == 0 {
+		t.Fatal("No contract stores a piece of the file")
+	}
+
+	// Cancel the contract with migration and wait for it to complete.
+	if err := r.RenterContractMigratePost(contract.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterContractMigratePost(contract.ID); err == nil {
+		t.Fatal("Expected migrating the contract twice to fail")
+	}
+	err = build.Retry(600, 100*time.Millisecond, func() error {
+		rc, err := r.RenterInactiveContractsGet()
+		if err != nil {
+			return err
+		}
+		for _, c := range rc.InactiveContracts {
+			if c.ID != contract.ID {
+				continue
+			}
+			if c.Migration == nil || c.Migration.Status != modules.ContractMigrationComplete {
+				return errors.New("contract was cancelled before its migration completed")
+			}
+			return nil
+		}
+		return errors.New("contract wasn't cancelled yet")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file is still fully redundant without the contract.
+	if err := r.WaitForUploadRedundancy(rf, float64(dataPieces+parityPieces)/float64(dataPieces)); err != nil {
+		t.Fatal(err)
+	}
+}